@@ -0,0 +1,536 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.1
+// - protoc             (unknown)
+// source: api/proto/agent.proto
+
+// backend/api/proto/agent.proto 为同一份协议定义（仅 go_package 不同），修改时需同步两处并重新生成代码
+
+package agent
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	AgentService_StopProcess_FullMethodName          = "/agent.AgentService/StopProcess"
+	AgentService_ResetSSH_FullMethodName             = "/agent.AgentService/ResetSSH"
+	AgentService_CleanupMachine_FullMethodName       = "/agent.AgentService/CleanupMachine"
+	AgentService_SyncSSHKeys_FullMethodName          = "/agent.AgentService/SyncSSHKeys"
+	AgentService_MountDataset_FullMethodName         = "/agent.AgentService/MountDataset"
+	AgentService_UnmountDataset_FullMethodName       = "/agent.AgentService/UnmountDataset"
+	AgentService_GetSystemInfo_FullMethodName        = "/agent.AgentService/GetSystemInfo"
+	AgentService_ExecuteCommand_FullMethodName       = "/agent.AgentService/ExecuteCommand"
+	AgentService_ExecuteCommandStream_FullMethodName = "/agent.AgentService/ExecuteCommandStream"
+	AgentService_StreamTaskLogs_FullMethodName       = "/agent.AgentService/StreamTaskLogs"
+	AgentService_Ping_FullMethodName                 = "/agent.AgentService/Ping"
+)
+
+// AgentServiceClient is the client API for AgentService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Agent 服务定义
+type AgentServiceClient interface {
+	// 停止进程
+	StopProcess(ctx context.Context, in *StopProcessRequest, opts ...grpc.CallOption) (*Response, error)
+	// 重置SSH密钥
+	ResetSSH(ctx context.Context, in *ResetSSHRequest, opts ...grpc.CallOption) (*Response, error)
+	// 清理机器
+	CleanupMachine(ctx context.Context, in *CleanupRequest, opts ...grpc.CallOption) (*Response, error)
+	// 同步SSH密钥（全量覆盖平台管理的公钥）
+	SyncSSHKeys(ctx context.Context, in *SyncSSHKeysRequest, opts ...grpc.CallOption) (*Response, error)
+	// 挂载数据集
+	MountDataset(ctx context.Context, in *MountDatasetRequest, opts ...grpc.CallOption) (*Response, error)
+	// 卸载数据集
+	UnmountDataset(ctx context.Context, in *UnmountDatasetRequest, opts ...grpc.CallOption) (*Response, error)
+	// 获取系统信息
+	GetSystemInfo(ctx context.Context, in *SystemInfoRequest, opts ...grpc.CallOption) (*SystemInfo, error)
+	// 执行命令
+	ExecuteCommand(ctx context.Context, in *ExecuteCommandRequest, opts ...grpc.CallOption) (*ExecuteCommandResponse, error)
+	// 执行命令并流式返回输出
+	ExecuteCommandStream(ctx context.Context, in *ExecuteCommandRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[CommandOutput], error)
+	// 流式读取任务日志，follow 时持续推送直到任务结束
+	StreamTaskLogs(ctx context.Context, in *TaskLogsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[TaskLogChunk], error)
+	// 健康检查
+	Ping(ctx context.Context, in *PingRequest, opts ...grpc.CallOption) (*PingResponse, error)
+}
+
+type agentServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAgentServiceClient(cc grpc.ClientConnInterface) AgentServiceClient {
+	return &agentServiceClient{cc}
+}
+
+func (c *agentServiceClient) StopProcess(ctx context.Context, in *StopProcessRequest, opts ...grpc.CallOption) (*Response, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Response)
+	err := c.cc.Invoke(ctx, AgentService_StopProcess_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *agentServiceClient) ResetSSH(ctx context.Context, in *ResetSSHRequest, opts ...grpc.CallOption) (*Response, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Response)
+	err := c.cc.Invoke(ctx, AgentService_ResetSSH_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *agentServiceClient) CleanupMachine(ctx context.Context, in *CleanupRequest, opts ...grpc.CallOption) (*Response, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Response)
+	err := c.cc.Invoke(ctx, AgentService_CleanupMachine_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *agentServiceClient) SyncSSHKeys(ctx context.Context, in *SyncSSHKeysRequest, opts ...grpc.CallOption) (*Response, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Response)
+	err := c.cc.Invoke(ctx, AgentService_SyncSSHKeys_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *agentServiceClient) MountDataset(ctx context.Context, in *MountDatasetRequest, opts ...grpc.CallOption) (*Response, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Response)
+	err := c.cc.Invoke(ctx, AgentService_MountDataset_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *agentServiceClient) UnmountDataset(ctx context.Context, in *UnmountDatasetRequest, opts ...grpc.CallOption) (*Response, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Response)
+	err := c.cc.Invoke(ctx, AgentService_UnmountDataset_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *agentServiceClient) GetSystemInfo(ctx context.Context, in *SystemInfoRequest, opts ...grpc.CallOption) (*SystemInfo, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SystemInfo)
+	err := c.cc.Invoke(ctx, AgentService_GetSystemInfo_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *agentServiceClient) ExecuteCommand(ctx context.Context, in *ExecuteCommandRequest, opts ...grpc.CallOption) (*ExecuteCommandResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ExecuteCommandResponse)
+	err := c.cc.Invoke(ctx, AgentService_ExecuteCommand_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *agentServiceClient) ExecuteCommandStream(ctx context.Context, in *ExecuteCommandRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[CommandOutput], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &AgentService_ServiceDesc.Streams[0], AgentService_ExecuteCommandStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ExecuteCommandRequest, CommandOutput]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AgentService_ExecuteCommandStreamClient = grpc.ServerStreamingClient[CommandOutput]
+
+func (c *agentServiceClient) StreamTaskLogs(ctx context.Context, in *TaskLogsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[TaskLogChunk], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &AgentService_ServiceDesc.Streams[1], AgentService_StreamTaskLogs_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[TaskLogsRequest, TaskLogChunk]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AgentService_StreamTaskLogsClient = grpc.ServerStreamingClient[TaskLogChunk]
+
+func (c *agentServiceClient) Ping(ctx context.Context, in *PingRequest, opts ...grpc.CallOption) (*PingResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PingResponse)
+	err := c.cc.Invoke(ctx, AgentService_Ping_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AgentServiceServer is the server API for AgentService service.
+// All implementations must embed UnimplementedAgentServiceServer
+// for forward compatibility.
+//
+// Agent 服务定义
+type AgentServiceServer interface {
+	// 停止进程
+	StopProcess(context.Context, *StopProcessRequest) (*Response, error)
+	// 重置SSH密钥
+	ResetSSH(context.Context, *ResetSSHRequest) (*Response, error)
+	// 清理机器
+	CleanupMachine(context.Context, *CleanupRequest) (*Response, error)
+	// 同步SSH密钥（全量覆盖平台管理的公钥）
+	SyncSSHKeys(context.Context, *SyncSSHKeysRequest) (*Response, error)
+	// 挂载数据集
+	MountDataset(context.Context, *MountDatasetRequest) (*Response, error)
+	// 卸载数据集
+	UnmountDataset(context.Context, *UnmountDatasetRequest) (*Response, error)
+	// 获取系统信息
+	GetSystemInfo(context.Context, *SystemInfoRequest) (*SystemInfo, error)
+	// 执行命令
+	ExecuteCommand(context.Context, *ExecuteCommandRequest) (*ExecuteCommandResponse, error)
+	// 执行命令并流式返回输出
+	ExecuteCommandStream(*ExecuteCommandRequest, grpc.ServerStreamingServer[CommandOutput]) error
+	// 流式读取任务日志，follow 时持续推送直到任务结束
+	StreamTaskLogs(*TaskLogsRequest, grpc.ServerStreamingServer[TaskLogChunk]) error
+	// 健康检查
+	Ping(context.Context, *PingRequest) (*PingResponse, error)
+	mustEmbedUnimplementedAgentServiceServer()
+}
+
+// UnimplementedAgentServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedAgentServiceServer struct{}
+
+func (UnimplementedAgentServiceServer) StopProcess(context.Context, *StopProcessRequest) (*Response, error) {
+	return nil, status.Error(codes.Unimplemented, "method StopProcess not implemented")
+}
+func (UnimplementedAgentServiceServer) ResetSSH(context.Context, *ResetSSHRequest) (*Response, error) {
+	return nil, status.Error(codes.Unimplemented, "method ResetSSH not implemented")
+}
+func (UnimplementedAgentServiceServer) CleanupMachine(context.Context, *CleanupRequest) (*Response, error) {
+	return nil, status.Error(codes.Unimplemented, "method CleanupMachine not implemented")
+}
+func (UnimplementedAgentServiceServer) SyncSSHKeys(context.Context, *SyncSSHKeysRequest) (*Response, error) {
+	return nil, status.Error(codes.Unimplemented, "method SyncSSHKeys not implemented")
+}
+func (UnimplementedAgentServiceServer) MountDataset(context.Context, *MountDatasetRequest) (*Response, error) {
+	return nil, status.Error(codes.Unimplemented, "method MountDataset not implemented")
+}
+func (UnimplementedAgentServiceServer) UnmountDataset(context.Context, *UnmountDatasetRequest) (*Response, error) {
+	return nil, status.Error(codes.Unimplemented, "method UnmountDataset not implemented")
+}
+func (UnimplementedAgentServiceServer) GetSystemInfo(context.Context, *SystemInfoRequest) (*SystemInfo, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetSystemInfo not implemented")
+}
+func (UnimplementedAgentServiceServer) ExecuteCommand(context.Context, *ExecuteCommandRequest) (*ExecuteCommandResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ExecuteCommand not implemented")
+}
+func (UnimplementedAgentServiceServer) ExecuteCommandStream(*ExecuteCommandRequest, grpc.ServerStreamingServer[CommandOutput]) error {
+	return status.Error(codes.Unimplemented, "method ExecuteCommandStream not implemented")
+}
+func (UnimplementedAgentServiceServer) StreamTaskLogs(*TaskLogsRequest, grpc.ServerStreamingServer[TaskLogChunk]) error {
+	return status.Error(codes.Unimplemented, "method StreamTaskLogs not implemented")
+}
+func (UnimplementedAgentServiceServer) Ping(context.Context, *PingRequest) (*PingResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Ping not implemented")
+}
+func (UnimplementedAgentServiceServer) mustEmbedUnimplementedAgentServiceServer() {}
+func (UnimplementedAgentServiceServer) testEmbeddedByValue()                      {}
+
+// UnsafeAgentServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AgentServiceServer will
+// result in compilation errors.
+type UnsafeAgentServiceServer interface {
+	mustEmbedUnimplementedAgentServiceServer()
+}
+
+func RegisterAgentServiceServer(s grpc.ServiceRegistrar, srv AgentServiceServer) {
+	// If the following call panics, it indicates UnimplementedAgentServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&AgentService_ServiceDesc, srv)
+}
+
+func _AgentService_StopProcess_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StopProcessRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AgentServiceServer).StopProcess(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AgentService_StopProcess_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AgentServiceServer).StopProcess(ctx, req.(*StopProcessRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AgentService_ResetSSH_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResetSSHRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AgentServiceServer).ResetSSH(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AgentService_ResetSSH_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AgentServiceServer).ResetSSH(ctx, req.(*ResetSSHRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AgentService_CleanupMachine_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CleanupRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AgentServiceServer).CleanupMachine(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AgentService_CleanupMachine_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AgentServiceServer).CleanupMachine(ctx, req.(*CleanupRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AgentService_SyncSSHKeys_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SyncSSHKeysRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AgentServiceServer).SyncSSHKeys(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AgentService_SyncSSHKeys_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AgentServiceServer).SyncSSHKeys(ctx, req.(*SyncSSHKeysRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AgentService_MountDataset_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MountDatasetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AgentServiceServer).MountDataset(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AgentService_MountDataset_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AgentServiceServer).MountDataset(ctx, req.(*MountDatasetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AgentService_UnmountDataset_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnmountDatasetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AgentServiceServer).UnmountDataset(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AgentService_UnmountDataset_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AgentServiceServer).UnmountDataset(ctx, req.(*UnmountDatasetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AgentService_GetSystemInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SystemInfoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AgentServiceServer).GetSystemInfo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AgentService_GetSystemInfo_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AgentServiceServer).GetSystemInfo(ctx, req.(*SystemInfoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AgentService_ExecuteCommand_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExecuteCommandRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AgentServiceServer).ExecuteCommand(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AgentService_ExecuteCommand_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AgentServiceServer).ExecuteCommand(ctx, req.(*ExecuteCommandRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AgentService_ExecuteCommandStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExecuteCommandRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AgentServiceServer).ExecuteCommandStream(m, &grpc.GenericServerStream[ExecuteCommandRequest, CommandOutput]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AgentService_ExecuteCommandStreamServer = grpc.ServerStreamingServer[CommandOutput]
+
+func _AgentService_StreamTaskLogs_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(TaskLogsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AgentServiceServer).StreamTaskLogs(m, &grpc.GenericServerStream[TaskLogsRequest, TaskLogChunk]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AgentService_StreamTaskLogsServer = grpc.ServerStreamingServer[TaskLogChunk]
+
+func _AgentService_Ping_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AgentServiceServer).Ping(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AgentService_Ping_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AgentServiceServer).Ping(ctx, req.(*PingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// AgentService_ServiceDesc is the grpc.ServiceDesc for AgentService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var AgentService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "agent.AgentService",
+	HandlerType: (*AgentServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "StopProcess",
+			Handler:    _AgentService_StopProcess_Handler,
+		},
+		{
+			MethodName: "ResetSSH",
+			Handler:    _AgentService_ResetSSH_Handler,
+		},
+		{
+			MethodName: "CleanupMachine",
+			Handler:    _AgentService_CleanupMachine_Handler,
+		},
+		{
+			MethodName: "SyncSSHKeys",
+			Handler:    _AgentService_SyncSSHKeys_Handler,
+		},
+		{
+			MethodName: "MountDataset",
+			Handler:    _AgentService_MountDataset_Handler,
+		},
+		{
+			MethodName: "UnmountDataset",
+			Handler:    _AgentService_UnmountDataset_Handler,
+		},
+		{
+			MethodName: "GetSystemInfo",
+			Handler:    _AgentService_GetSystemInfo_Handler,
+		},
+		{
+			MethodName: "ExecuteCommand",
+			Handler:    _AgentService_ExecuteCommand_Handler,
+		},
+		{
+			MethodName: "Ping",
+			Handler:    _AgentService_Ping_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ExecuteCommandStream",
+			Handler:       _AgentService_ExecuteCommandStream_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "StreamTaskLogs",
+			Handler:       _AgentService_StreamTaskLogs_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "api/proto/agent.proto",
+}
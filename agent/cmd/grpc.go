@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+
+	pb "github.com/YoungBoyGod/remotegpu-agent/api/proto/agent"
+	agentcfg "github.com/YoungBoyGod/remotegpu-agent/internal/config"
+	"github.com/YoungBoyGod/remotegpu-agent/internal/grpcserver"
+	"github.com/YoungBoyGod/remotegpu-agent/internal/security"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// startGRPCServer 启动 gRPC 控制接口，与 HTTP 控制接口共用签名校验器
+func startGRPCServer(cfg *agentcfg.Config, verifier *security.RequestVerifier, svc *grpcserver.Server) (*grpc.Server, error) {
+	opts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(grpcserver.UnaryAuth(verifier)),
+		grpc.ChainStreamInterceptor(grpcserver.StreamAuth(verifier)),
+	}
+	if cfg.GRPC.TLSCert != "" && cfg.GRPC.TLSKey != "" {
+		creds, err := credentials.NewServerTLSFromFile(cfg.GRPC.TLSCert, cfg.GRPC.TLSKey)
+		if err != nil {
+			return nil, fmt.Errorf("load grpc tls: %w", err)
+		}
+		opts = append(opts, grpc.Creds(creds))
+	} else {
+		slog.Warn("grpc TLS not configured, serving plaintext")
+	}
+
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.GRPC.Port))
+	if err != nil {
+		return nil, fmt.Errorf("listen grpc: %w", err)
+	}
+
+	srv := grpc.NewServer(opts...)
+	pb.RegisterAgentServiceServer(srv, svc)
+	go func() {
+		if err := srv.Serve(lis); err != nil {
+			slog.Error("grpc server error", "error", err)
+		}
+	}()
+	slog.Info("grpc server started", "port", cfg.GRPC.Port, "tls", cfg.GRPC.TLSCert != "")
+	return srv, nil
+}
@@ -3,43 +3,28 @@ package main
 import (
 	"bytes"
 	"context"
+	"errors"
+	"log/slog"
 	"net/http"
-	"os"
-	"os/exec"
-	"runtime"
-	"strings"
-	"syscall"
 	"time"
 
+	"github.com/YoungBoyGod/remotegpu-agent/internal/control"
 	agentErrors "github.com/YoungBoyGod/remotegpu-agent/internal/errors"
+	"github.com/YoungBoyGod/remotegpu-agent/internal/mount"
+	"github.com/YoungBoyGod/remotegpu-agent/internal/sshkeys"
+	"github.com/YoungBoyGod/remotegpu-agent/internal/tenant"
 	"github.com/gin-gonic/gin"
-	"github.com/shirou/gopsutil/v3/cpu"
-	"github.com/shirou/gopsutil/v3/disk"
-	"github.com/shirou/gopsutil/v3/host"
-	"github.com/shirou/gopsutil/v3/mem"
 )
 
+// mountTimeout 单次挂载/卸载操作超时
+const mountTimeout = 2 * time.Minute
+
 func handlePing(c *gin.Context) {
 	respondSuccess(c, gin.H{"ok": true})
 }
 
 func handleSystemInfo(c *gin.Context) {
-	hostInfo, _ := host.Info()
-	memInfo, _ := mem.VirtualMemory()
-	diskInfo, _ := disk.Usage("/")
-	cpuCount, _ := cpu.Counts(true)
-
-	c.JSON(http.StatusOK, gin.H{
-		"hostname":     hostInfo.Hostname,
-		"os":           runtime.GOOS,
-		"kernel":       hostInfo.KernelVersion,
-		"cpu_cores":    cpuCount,
-		"memory_total": memInfo.Total,
-		"memory_free":  memInfo.Free,
-		"disk_total":   diskInfo.Total,
-		"disk_free":    diskInfo.Free,
-		"uptime":       hostInfo.Uptime,
-	})
+	c.JSON(http.StatusOK, control.CollectSystemInfo())
 }
 
 func handleStopProcess(c *gin.Context) {
@@ -52,18 +37,7 @@ func handleStopProcess(c *gin.Context) {
 		return
 	}
 
-	sig := syscall.SIGTERM
-	if req.Signal == "SIGKILL" {
-		sig = syscall.SIGKILL
-	}
-
-	process, err := os.FindProcess(req.ProcessID)
-	if err != nil {
-		respondError(c, http.StatusInternalServerError, agentErrors.ErrInternal, err.Error())
-		return
-	}
-
-	if err := process.Signal(sig); err != nil {
+	if err := control.StopProcess(req.ProcessID, req.Signal); err != nil {
 		respondError(c, http.StatusInternalServerError, agentErrors.ErrInternal, err.Error())
 		return
 	}
@@ -71,30 +45,133 @@ func handleStopProcess(c *gin.Context) {
 	respondSuccess(c, nil)
 }
 
+// handleResetSSH 清空平台管理的公钥，指定 public_key 时替换为该公钥；管理员自行添加的公钥保留
 func handleResetSSH(c *gin.Context) {
-	cmd := exec.Command("bash", "-c", "rm -f ~/.ssh/authorized_keys")
-	if err := cmd.Run(); err != nil {
+	var req struct {
+		PublicKey string `json:"public_key"`
+		Username  string `json:"username"`
+	}
+	_ = c.ShouldBindJSON(&req)
+
+	var keys []string
+	if req.PublicKey != "" {
+		keys = []string{req.PublicKey}
+	}
+	if syncAuthorizedKeys(c, req.Username, keys) {
+		respondSuccess(c, gin.H{"keys": len(keys)})
+	}
+}
+
+// handleSyncSSHKeys 全量同步指定用户的平台公钥和 GPU 粒度分配客户的独立账号
+func handleSyncSSHKeys(tenants tenant.Syncer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			PublicKeys []string         `json:"public_keys"`
+			Tenants    []tenant.Account `json:"tenants"`
+			Username   string           `json:"username"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondErrorCode(c, http.StatusBadRequest, agentErrors.ErrInvalidParams)
+			return
+		}
+		if err := tenant.Validate(req.Tenants); err != nil {
+			respondError(c, http.StatusBadRequest, agentErrors.ErrInvalidParams, err.Error())
+			return
+		}
+		if !syncAuthorizedKeys(c, req.Username, req.PublicKeys) {
+			return
+		}
+		if err := tenants.Sync(c.Request.Context(), req.Tenants); err != nil {
+			respondError(c, http.StatusInternalServerError, agentErrors.ErrInternal, err.Error())
+			return
+		}
+		respondSuccess(c, gin.H{"keys": len(req.PublicKeys), "tenants": len(req.Tenants)})
+	}
+}
+
+// syncAuthorizedKeys 全量同步共享账号的平台公钥，失败时输出错误响应并返回 false
+func syncAuthorizedKeys(c *gin.Context, username string, keys []string) bool {
+	target, err := sshkeys.Resolve(username)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, agentErrors.ErrInvalidParams, err.Error())
+		return false
+	}
+	if err := sshkeys.Sync(target, keys); err != nil {
+		if errors.Is(err, sshkeys.ErrInvalidKey) {
+			respondError(c, http.StatusBadRequest, agentErrors.ErrInvalidParams, err.Error())
+			return false
+		}
 		respondError(c, http.StatusInternalServerError, agentErrors.ErrInternal, err.Error())
-		return
+		return false
 	}
-	respondSuccess(c, nil)
+	return true
 }
 
-func handleCleanup(c *gin.Context) {
-	var req struct {
-		CleanupTypes []string `json:"cleanup_types"`
+// handleMountDataset 挂载数据集
+func handleMountDataset(m mount.Mounter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			DatasetID uint `json:"dataset_id"`
+			mount.Request
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondErrorCode(c, http.StatusBadRequest, agentErrors.ErrInvalidParams)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), mountTimeout)
+		defer cancel()
+		if err := m.Mount(ctx, &req.Request); err != nil {
+			slog.Error("mount dataset error", "dataset_id", req.DatasetID, "mount_point", req.MountPoint, "error", err)
+			respondMountError(c, err)
+			return
+		}
+		slog.Info("dataset mounted", "dataset_id", req.DatasetID, "type", req.SourceType, "mount_point", req.MountPoint)
+		respondSuccess(c, gin.H{"mount_point": req.MountPoint, "status": "mounted"})
 	}
-	c.ShouldBindJSON(&req)
+}
 
-	for _, t := range req.CleanupTypes {
-		switch strings.ToLower(t) {
-		case "docker":
-			exec.Command("docker", "system", "prune", "-af").Run()
-		case "ssh":
-			exec.Command("bash", "-c", "rm -f ~/.ssh/authorized_keys").Run()
+// handleUnmountDataset 卸载数据集
+func handleUnmountDataset(m mount.Mounter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			DatasetID  uint   `json:"dataset_id"`
+			MountPoint string `json:"mount_point" binding:"required"`
 		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondErrorCode(c, http.StatusBadRequest, agentErrors.ErrInvalidParams)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), mountTimeout)
+		defer cancel()
+		if err := m.Unmount(ctx, req.MountPoint); err != nil {
+			slog.Error("unmount dataset error", "dataset_id", req.DatasetID, "mount_point", req.MountPoint, "error", err)
+			respondMountError(c, err)
+			return
+		}
+		respondSuccess(c, gin.H{"mount_point": req.MountPoint, "status": "unmounted"})
+	}
+}
+
+func respondMountError(c *gin.Context, err error) {
+	if errors.Is(err, mount.ErrInvalidRequest) || errors.Is(err, mount.ErrMountPointDenied) || errors.Is(err, mount.ErrS3NotConfigured) {
+		respondError(c, http.StatusBadRequest, agentErrors.ErrInvalidParams, err.Error())
+		return
+	}
+	respondError(c, http.StatusInternalServerError, agentErrors.ErrMountFailed, err.Error())
+}
+
+func handleCleanup(tenants tenant.Syncer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			CleanupTypes []string `json:"cleanup_types"`
+		}
+		c.ShouldBindJSON(&req)
+
+		control.Cleanup(c.Request.Context(), req.CleanupTypes, tenants)
+		respondSuccess(c, nil)
 	}
-	respondSuccess(c, nil)
 }
 
 // handleExecCommand 执行 shell 命令
@@ -109,32 +186,16 @@ func handleExecCommand(c *gin.Context) {
 		return
 	}
 
-	timeout := req.Timeout
+	timeout := time.Duration(req.Timeout) * time.Second
 	if timeout <= 0 {
-		timeout = 60
+		timeout = control.DefaultCommandTimeout
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
-	cmd := exec.CommandContext(ctx, "bash", "-c", req.Command)
-	if req.WorkDir != "" {
-		cmd.Dir = req.WorkDir
-	}
-
 	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	err := cmd.Run()
-	exitCode := 0
-	if err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			exitCode = exitErr.ExitCode()
-		} else {
-			exitCode = -1
-		}
-	}
+	exitCode, err := control.RunCommand(ctx, req.Command, req.WorkDir, &stdout, &stderr)
 
 	data := gin.H{
 		"exit_code": exitCode,
@@ -15,13 +15,18 @@ import (
 	"github.com/YoungBoyGod/remotegpu-agent/internal/client"
 	"github.com/YoungBoyGod/remotegpu-agent/internal/collector"
 	agentcfg "github.com/YoungBoyGod/remotegpu-agent/internal/config"
+	"github.com/YoungBoyGod/remotegpu-agent/internal/container"
+	"github.com/YoungBoyGod/remotegpu-agent/internal/grpcserver"
 	"github.com/YoungBoyGod/remotegpu-agent/internal/handler"
 	"github.com/YoungBoyGod/remotegpu-agent/internal/models"
+	"github.com/YoungBoyGod/remotegpu-agent/internal/mount"
 	"github.com/YoungBoyGod/remotegpu-agent/internal/poller"
 	"github.com/YoungBoyGod/remotegpu-agent/internal/scheduler"
 	"github.com/YoungBoyGod/remotegpu-agent/internal/security"
 	"github.com/YoungBoyGod/remotegpu-agent/internal/syncer"
+	"github.com/YoungBoyGod/remotegpu-agent/internal/tenant"
 	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
 )
 
 var version = "0.1.0"
@@ -47,6 +52,9 @@ func main() {
 		slog.Info("command validator enabled")
 	}
 
+	// 任务输出缓存目录，供日志增量上报
+	sched.GetExecutor().SetLogDir(cfg.LogDir)
+
 	// 启动调度器
 	if err := sched.Start(); err != nil {
 		log.Fatalf("start scheduler error: %v", err)
@@ -64,6 +72,9 @@ func main() {
 			Token:     cfg.Server.Token,
 			Timeout:   cfg.Server.Timeout,
 		})
+		if err := setupCredential(cfg, serverClient); err != nil {
+			log.Fatalf("agent credential error: %v", err)
+		}
 		sched.SetClient(serverClient)
 
 		p = poller.NewPoller(&poller.Config{
@@ -117,7 +128,25 @@ func main() {
 
 	// 注册路由
 	taskHandler := handler.NewTaskHandler(sched)
-	registerRoutes(r, taskHandler)
+	containerHandler := handler.NewContainerHandler(container.NewDockerRuntime(cfg.Container.DockerBinary))
+	mounter := mount.NewManager(mount.Config{
+		S3Endpoint:   cfg.Dataset.S3Endpoint,
+		S3PasswdFile: cfg.Dataset.S3PasswdFile,
+		AllowedRoots: cfg.Dataset.AllowedMountRoots,
+	})
+	tenants := tenant.NewManager()
+	verifier := controlVerifier(cfg)
+	registerRoutes(r, verifier, taskHandler, containerHandler, mounter, tenants)
+
+	// 启动 gRPC 控制接口
+	var grpcSrv *grpc.Server
+	if cfg.GRPC.Enabled {
+		svc := grpcserver.NewServer(version, mounter, tenants, sched, sched.GetExecutor().LogDir())
+		grpcSrv, err = startGRPCServer(cfg, verifier, svc)
+		if err != nil {
+			log.Fatalf("start grpc server error: %v", err)
+		}
+	}
 
 	fmt.Printf("RemoteGPU Agent v%s starting on :%s\n", version, port)
 
@@ -127,6 +156,9 @@ func main() {
 		signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 		<-sigCh
 		slog.Info("shutting down...")
+		if grpcSrv != nil {
+			grpcSrv.Stop()
+		}
 		if heartbeatTicker != nil {
 			heartbeatTicker.Stop()
 		}
@@ -144,3 +176,87 @@ func main() {
 		log.Fatal(err)
 	}
 }
+
+// setupCredential 准备 Server 认证凭证：优先使用已保存的机器密钥，
+// 否则用一次性注册令牌注册并保存换得的密钥，都没有时回退到共享 Token。
+// 配置的注册令牌与换取已保存密钥的令牌不同时（密钥轮换）重新注册，Server 随即作废旧密钥
+func setupCredential(cfg *agentcfg.Config, serverClient *client.ServerClient) error {
+	hostname, _ := os.Hostname()
+	info := &client.RegisterInfo{
+		Version:    version,
+		Hostname:   hostname,
+		AgentPort:  cfg.Port,
+		MaxWorkers: cfg.MaxWorkers,
+	}
+
+	secret, err := client.LoadCredential(cfg.Server.CredentialPath)
+	if err != nil {
+		return fmt.Errorf("load credential: %w", err)
+	}
+	if secret != "" {
+		rotate := false
+		if cfg.Server.EnrollmentToken != "" {
+			enrolled, err := client.EnrolledWith(cfg.Server.CredentialPath, cfg.Server.EnrollmentToken)
+			if err != nil {
+				return fmt.Errorf("load credential: %w", err)
+			}
+			rotate = !enrolled
+		}
+		if rotate {
+			err := enrollAgent(cfg, serverClient, info)
+			if err == nil {
+				return nil
+			}
+			// 令牌已过期或已被兑换时继续使用已保存的密钥
+			slog.Warn("re-enroll with new enrollment token error, using saved credential", "error", err)
+		}
+		serverClient.SetToken(secret)
+		if _, err := serverClient.Register(info); err != nil {
+			slog.Warn("agent register error", "error", err)
+		}
+		return nil
+	}
+
+	if cfg.Server.EnrollmentToken == "" {
+		slog.Warn("no agent credential found, using shared token")
+		return nil
+	}
+	return enrollAgent(cfg, serverClient, info)
+}
+
+// enrollAgent 用注册令牌注册并保存换得的机器密钥
+func enrollAgent(cfg *agentcfg.Config, serverClient *client.ServerClient, info *client.RegisterInfo) error {
+	serverClient.SetToken(cfg.Server.EnrollmentToken)
+	secret, err := serverClient.Register(info)
+	if err != nil {
+		return fmt.Errorf("enroll agent: %w", err)
+	}
+	if secret == "" {
+		return fmt.Errorf("enroll agent: server returned empty secret")
+	}
+	if err := client.SaveCredential(cfg.Server.CredentialPath, secret, cfg.Server.EnrollmentToken); err != nil {
+		return fmt.Errorf("save credential: %w", err)
+	}
+	serverClient.SetToken(secret)
+	slog.Info("agent enrolled", "credential", cfg.Server.CredentialPath)
+	return nil
+}
+
+// controlVerifier 创建控制接口签名校验器：优先使用机器密钥派生的签名密钥，
+// 否则使用配置的共享控制密钥；都没有时返回 nil，控制接口仅接受本机请求
+func controlVerifier(cfg *agentcfg.Config) *security.RequestVerifier {
+	secret, err := client.LoadCredential(cfg.Server.CredentialPath)
+	if err != nil {
+		slog.Warn("load credential for control auth error", "error", err)
+	}
+	if secret != "" {
+		slog.Info("control API auth enabled", "key", "machine credential")
+		return security.NewRequestVerifier(security.SigningKeyFromSecret(secret), security.DefaultMaxSkew)
+	}
+	if cfg.Security.ControlSecret != "" {
+		slog.Info("control API auth enabled", "key", "control_secret")
+		return security.NewRequestVerifier(cfg.Security.ControlSecret, security.DefaultMaxSkew)
+	}
+	slog.Warn("no control key configured, control API only accepts loopback requests")
+	return nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+
+	agentErrors "github.com/YoungBoyGod/remotegpu-agent/internal/errors"
+	"github.com/YoungBoyGod/remotegpu-agent/internal/security"
+	"github.com/gin-gonic/gin"
+)
+
+// maxSignedBodySize 签名校验时读取的请求体上限
+const maxSignedBodySize = 10 << 20
+
+// controlAuth 控制接口认证：校验 Server 的 HMAC 签名；verifier 为 nil（未配置密钥）时仅允许本机访问
+func controlAuth(verifier *security.RequestVerifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if verifier == nil {
+			if !isLoopback(c.Request.RemoteAddr) {
+				respondErrorCode(c, http.StatusUnauthorized, agentErrors.ErrUnauthorized)
+				c.Abort()
+				return
+			}
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxSignedBodySize))
+		if err != nil {
+			respondErrorCode(c, http.StatusBadRequest, agentErrors.ErrInvalidParams)
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		err = verifier.Verify(
+			c.Request.Method,
+			c.Request.URL.RequestURI(),
+			c.GetHeader(security.HeaderTimestamp),
+			c.GetHeader(security.HeaderNonce),
+			c.GetHeader(security.HeaderSignature),
+			body,
+		)
+		if err != nil {
+			slog.Warn("reject control request", "path", c.Request.URL.Path, "remote", c.Request.RemoteAddr, "error", err)
+			respondError(c, http.StatusUnauthorized, agentErrors.ErrUnauthorized, err.Error())
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// isLoopback 按 TCP 连接地址判断是否为本机请求（不信任 X-Forwarded-For）
+func isLoopback(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -2,23 +2,40 @@ package main
 
 import (
 	"github.com/YoungBoyGod/remotegpu-agent/internal/handler"
+	"github.com/YoungBoyGod/remotegpu-agent/internal/mount"
+	"github.com/YoungBoyGod/remotegpu-agent/internal/security"
+	"github.com/YoungBoyGod/remotegpu-agent/internal/tenant"
 	"github.com/gin-gonic/gin"
 )
 
-func registerRoutes(r *gin.Engine, taskHandler *handler.TaskHandler) {
-	api := r.Group("/api/v1")
+func registerRoutes(r *gin.Engine, verifier *security.RequestVerifier, taskHandler *handler.TaskHandler, containerHandler *handler.ContainerHandler, mounter mount.Mounter, tenants tenant.Syncer) {
+	r.GET("/api/v1/ping", handlePing)
+
+	// 除 ping 外的接口均需通过控制认证
+	api := r.Group("/api/v1", controlAuth(verifier))
 	{
-		api.GET("/ping", handlePing)
 		api.GET("/system/info", handleSystemInfo)
 		api.POST("/process/stop", handleStopProcess)
 		api.POST("/ssh/reset", handleResetSSH)
-		api.POST("/machine/cleanup", handleCleanup)
+		api.POST("/ssh/sync-keys", handleSyncSSHKeys(tenants))
+		api.POST("/machine/cleanup", handleCleanup(tenants))
 		api.POST("/command/exec", handleExecCommand)
 
+		// 数据集挂载 API
+		api.POST("/dataset/mount", handleMountDataset(mounter))
+		api.POST("/dataset/unmount", handleUnmountDataset(mounter))
+
 		// 任务队列 API
 		api.POST("/tasks", taskHandler.CreateTask)
 		api.GET("/tasks/:id", taskHandler.GetTask)
 		api.POST("/tasks/:id/cancel", taskHandler.CancelTask)
 		api.GET("/queue/status", taskHandler.GetQueueStatus)
+
+		// 容器生命周期 API
+		api.POST("/containers", containerHandler.CreateContainer)
+		api.GET("/containers/:id", containerHandler.InspectContainer)
+		api.POST("/containers/:id/start", containerHandler.StartContainer)
+		api.POST("/containers/:id/stop", containerHandler.StopContainer)
+		api.DELETE("/containers/:id", containerHandler.RemoveContainer)
 	}
 }
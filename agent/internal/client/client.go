@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/YoungBoyGod/remotegpu-agent/internal/collector"
+	"github.com/YoungBoyGod/remotegpu-agent/internal/logship"
 	"github.com/YoungBoyGod/remotegpu-agent/internal/models"
 	"github.com/google/uuid"
 )
@@ -277,3 +278,48 @@ func (c *ServerClient) ReportComplete(task *models.Task) error {
 	}
 	return nil
 }
+
+// UploadLog 增量上报任务日志，返回 Server 已持久化的偏移
+// 偏移不连续时返回 logship.ErrOffsetMismatch 及 Server 的偏移
+func (c *ServerClient) UploadLog(taskID, attemptID, stream string, offset int64, data []byte) (int64, error) {
+	reqBody := map[string]interface{}{
+		"agent_id":   c.agentID,
+		"attempt_id": attemptID,
+		"stream":     stream,
+		"offset":     offset,
+		"data":       data,
+	}
+
+	body, err := json.Marshal(reqBody)
+	if err != nil {
+		return 0, fmt.Errorf("marshal request: %w", err)
+	}
+	url := fmt.Sprintf("%s/api/v1/agent/tasks/%s/logs", c.baseURL, taskID)
+	resp, err := c.doPost(url, body)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+		Data *struct {
+			NextOffset int64 `json:"next_offset"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return 0, fmt.Errorf("decode response: %w", err)
+	}
+
+	if result.Code == http.StatusConflict && result.Data != nil {
+		return result.Data.NextOffset, logship.ErrOffsetMismatch
+	}
+	if result.Code != 0 {
+		return 0, fmt.Errorf("upload log failed: %s", result.Msg)
+	}
+	if result.Data == nil {
+		return 0, fmt.Errorf("upload log failed: empty response")
+	}
+	return result.Data.NextOffset, nil
+}
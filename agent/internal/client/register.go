@@ -0,0 +1,119 @@
+package client
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// RegisterInfo Agent 注册信息
+type RegisterInfo struct {
+	Version    string
+	Hostname   string
+	IPAddress  string
+	AgentPort  int
+	MaxWorkers int
+}
+
+// Register 向 Server 注册 Agent
+// 使用一次性注册令牌认证时，Server 返回机器专属密钥，调用方需持久化保存
+func (c *ServerClient) Register(info *RegisterInfo) (string, error) {
+	reqBody := map[string]interface{}{
+		"agent_id":    c.agentID,
+		"machine_id":  c.machineID,
+		"version":     info.Version,
+		"hostname":    info.Hostname,
+		"ip_address":  info.IPAddress,
+		"agent_port":  info.AgentPort,
+		"max_workers": info.MaxWorkers,
+	}
+
+	body, err := json.Marshal(reqBody)
+	if err != nil {
+		return "", fmt.Errorf("marshal request: %w", err)
+	}
+	url := fmt.Sprintf("%s/api/v1/agent/register", c.baseURL)
+	resp, err := c.doPost(url, body)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+		Data struct {
+			AgentSecret string `json:"agent_secret"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("decode response: %w", err)
+	}
+
+	if result.Code != 0 {
+		return "", fmt.Errorf("register failed: %s", result.Msg)
+	}
+	return result.Data.AgentSecret, nil
+}
+
+// SetToken 更新认证令牌，需在启动轮询和心跳之前调用
+func (c *ServerClient) SetToken(token string) {
+	c.token = token
+}
+
+// LoadCredential 读取已保存的机器密钥，文件不存在时返回空字符串
+func LoadCredential(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// enrollmentSuffix 记录换取机器密钥所用注册令牌摘要的文件后缀
+const enrollmentSuffix = ".enrollment"
+
+// SaveCredential 保存机器密钥及换取它的注册令牌摘要，仅当前用户可读
+func SaveCredential(path, secret, enrollmentToken string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	// 先写密钥再写令牌摘要：中途失败时下次启动会重新兑换令牌，不会丢失新密钥
+	if err := writeFileAtomic(path, secret); err != nil {
+		return err
+	}
+	return writeFileAtomic(path+enrollmentSuffix, hashEnrollmentToken(enrollmentToken))
+}
+
+// EnrolledWith 判断已保存的机器密钥是否由指定注册令牌换取
+// 配置了新的注册令牌（密钥轮换）时返回 false，调用方应重新注册
+func EnrolledWith(path, enrollmentToken string) (bool, error) {
+	data, err := os.ReadFile(path + enrollmentSuffix)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return strings.TrimSpace(string(data)) == hashEnrollmentToken(enrollmentToken), nil
+}
+
+func hashEnrollmentToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func writeFileAtomic(path, content string) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(content+"\n"), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
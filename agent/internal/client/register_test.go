@@ -0,0 +1,55 @@
+package client
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCredentialEnrollmentToken(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agent", "credential")
+
+	secret, err := LoadCredential(path)
+	if err != nil || secret != "" {
+		t.Fatalf("LoadCredential on missing file = %q, %v", secret, err)
+	}
+	if enrolled, err := EnrolledWith(path, "ret_a"); err != nil || enrolled {
+		t.Fatalf("EnrolledWith on missing file = %v, %v", enrolled, err)
+	}
+
+	if err := SaveCredential(path, "ras_secret", "ret_a"); err != nil {
+		t.Fatal(err)
+	}
+	if secret, err = LoadCredential(path); err != nil || secret != "ras_secret" {
+		t.Fatalf("LoadCredential = %q, %v", secret, err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("credential mode = %v, want 0600", info.Mode().Perm())
+	}
+	data, _ := os.ReadFile(path + enrollmentSuffix)
+	if string(data) == "ret_a\n" {
+		t.Error("enrollment token stored in plaintext")
+	}
+
+	if enrolled, err := EnrolledWith(path, "ret_a"); err != nil || !enrolled {
+		t.Errorf("EnrolledWith same token = %v, %v", enrolled, err)
+	}
+	// 配置了新的注册令牌时需要重新注册
+	if enrolled, err := EnrolledWith(path, "ret_b"); err != nil || enrolled {
+		t.Errorf("EnrolledWith new token = %v, %v", enrolled, err)
+	}
+
+	if err := SaveCredential(path, "ras_rotated", "ret_b"); err != nil {
+		t.Fatal(err)
+	}
+	if secret, _ = LoadCredential(path); secret != "ras_rotated" {
+		t.Errorf("LoadCredential after rotation = %q", secret)
+	}
+	if enrolled, _ := EnrolledWith(path, "ret_b"); !enrolled {
+		t.Error("rotated token not recorded")
+	}
+}
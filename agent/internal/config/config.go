@@ -12,12 +12,16 @@ import (
 type Config struct {
 	Port       int    `yaml:"port"`
 	DBPath     string `yaml:"db_path"`
+	LogDir     string `yaml:"log_dir"`
 	MaxWorkers int    `yaml:"max_workers"`
 
-	Server   ServerConfig   `yaml:"server"`
-	Poll     PollConfig     `yaml:"poll"`
-	Limits   LimitsConfig   `yaml:"limits"`
-	Security SecurityConfig `yaml:"security"`
+	Server    ServerConfig    `yaml:"server"`
+	Poll      PollConfig      `yaml:"poll"`
+	Limits    LimitsConfig    `yaml:"limits"`
+	Security  SecurityConfig  `yaml:"security"`
+	Container ContainerConfig `yaml:"container"`
+	Dataset   DatasetConfig   `yaml:"dataset"`
+	GRPC      GRPCConfig      `yaml:"grpc"`
 }
 
 // ServerConfig Server 连接配置
@@ -27,6 +31,11 @@ type ServerConfig struct {
 	MachineID string        `yaml:"machine_id"`
 	Token     string        `yaml:"token"`
 	Timeout   time.Duration `yaml:"timeout"`
+
+	// EnrollmentToken 平台签发的一次性注册令牌，首次注册后换取机器密钥
+	EnrollmentToken string `yaml:"enrollment_token"`
+	// CredentialPath 机器密钥保存路径
+	CredentialPath string `yaml:"credential_path"`
 }
 
 // PollConfig 轮询配置
@@ -44,6 +53,33 @@ type LimitsConfig struct {
 type SecurityConfig struct {
 	AllowedCommands []string `yaml:"allowed_commands"`
 	BlockedPatterns []string `yaml:"blocked_patterns"`
+
+	// ControlSecret 控制接口签名共享密钥，未注册机器密钥时使用；均未配置时控制接口仅接受本机请求
+	ControlSecret string `yaml:"control_secret"`
+}
+
+// ContainerConfig 容器运行时配置
+type ContainerConfig struct {
+	DockerBinary string `yaml:"docker_binary"`
+}
+
+// DatasetConfig 数据集挂载配置
+type DatasetConfig struct {
+	// S3Endpoint s3fs 挂载对象存储数据集时访问的地址
+	S3Endpoint string `yaml:"s3_endpoint"`
+	// S3PasswdFile s3fs 凭证文件（ACCESS_KEY:SECRET_KEY），凭证只保存在本机
+	S3PasswdFile string `yaml:"s3_passwd_file"`
+	// AllowedMountRoots 允许挂载的根目录，为空时仅禁止系统目录
+	AllowedMountRoots []string `yaml:"allowed_mount_roots"`
+}
+
+// GRPCConfig gRPC 控制接口配置，认证规则与 HTTP 控制接口相同
+type GRPCConfig struct {
+	Enabled bool `yaml:"enabled"`
+	Port    int  `yaml:"port"`
+	// TLSCert/TLSKey 服务端证书，均配置时启用 TLS
+	TLSCert string `yaml:"tls_cert"`
+	TLSKey  string `yaml:"tls_key"`
 }
 
 // DefaultConfig 返回默认配置
@@ -51,9 +87,11 @@ func DefaultConfig() *Config {
 	return &Config{
 		Port:       8090,
 		DBPath:     "/var/lib/remotegpu-agent/tasks.db",
+		LogDir:     "/var/lib/remotegpu-agent/logs",
 		MaxWorkers: 4,
 		Server: ServerConfig{
-			Timeout: 30 * time.Second,
+			Timeout:        30 * time.Second,
+			CredentialPath: "/var/lib/remotegpu-agent/credential",
 		},
 		Poll: PollConfig{
 			Interval:  5 * time.Second,
@@ -62,6 +100,15 @@ func DefaultConfig() *Config {
 		Limits: LimitsConfig{
 			MaxOutputSize: 1 << 20, // 1MB
 		},
+		Container: ContainerConfig{
+			DockerBinary: "docker",
+		},
+		Dataset: DatasetConfig{
+			AllowedMountRoots: []string{"/mnt", "/data", "/workspace"},
+		},
+		GRPC: GRPCConfig{
+			Port: 50051,
+		},
 	}
 }
 
@@ -92,6 +139,9 @@ func applyEnv(cfg *Config) {
 	if v := os.Getenv("AGENT_DB_PATH"); v != "" {
 		cfg.DBPath = v
 	}
+	if v := os.Getenv("AGENT_LOG_DIR"); v != "" {
+		cfg.LogDir = v
+	}
 	if v := os.Getenv("AGENT_MAX_WORKERS"); v != "" {
 		if n, err := strconv.Atoi(v); err == nil {
 			cfg.MaxWorkers = n
@@ -109,6 +159,23 @@ func applyEnv(cfg *Config) {
 	if v := os.Getenv("AGENT_TOKEN"); v != "" {
 		cfg.Server.Token = v
 	}
+	if v := os.Getenv("AGENT_ENROLLMENT_TOKEN"); v != "" {
+		cfg.Server.EnrollmentToken = v
+	}
+	if v := os.Getenv("AGENT_CREDENTIAL_PATH"); v != "" {
+		cfg.Server.CredentialPath = v
+	}
+	if v := os.Getenv("AGENT_CONTROL_SECRET"); v != "" {
+		cfg.Security.ControlSecret = v
+	}
+	if v := os.Getenv("AGENT_GRPC_ENABLED"); v != "" {
+		cfg.GRPC.Enabled = v == "true" || v == "1"
+	}
+	if v := os.Getenv("AGENT_GRPC_PORT"); v != "" {
+		if port, err := strconv.Atoi(v); err == nil {
+			cfg.GRPC.Port = port
+		}
+	}
 }
 
 // ServerConfigured 检查 Server 配置是否完整
@@ -0,0 +1,228 @@
+package container
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DockerRuntime 基于 docker CLI 的容器运行时
+type DockerRuntime struct {
+	binary string
+}
+
+// NewDockerRuntime 创建 docker 运行时，binary 为空时使用 PATH 中的 docker
+func NewDockerRuntime(binary string) *DockerRuntime {
+	if binary == "" {
+		binary = "docker"
+	}
+	return &DockerRuntime{binary: binary}
+}
+
+// Create 创建并启动容器
+func (r *DockerRuntime) Create(ctx context.Context, spec *Spec) (string, error) {
+	if spec == nil || spec.Image == "" {
+		return "", fmt.Errorf("image required")
+	}
+	if !validImage(spec.Image) {
+		return "", fmt.Errorf("invalid image %q", spec.Image)
+	}
+	out, err := r.run(ctx, buildRunArgs(spec)...)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// Start 启动容器
+func (r *DockerRuntime) Start(ctx context.Context, id string) error {
+	_, err := r.run(ctx, "start", id)
+	return err
+}
+
+// Stop 停止容器
+func (r *DockerRuntime) Stop(ctx context.Context, id string, timeout int) error {
+	if timeout <= 0 {
+		timeout = 10
+	}
+	_, err := r.run(ctx, "stop", "-t", strconv.Itoa(timeout), id)
+	return err
+}
+
+// Remove 强制删除容器，容器不存在时视为成功
+func (r *DockerRuntime) Remove(ctx context.Context, id string) error {
+	_, err := r.run(ctx, "rm", "-f", id)
+	if err == ErrNotFound {
+		return nil
+	}
+	return err
+}
+
+// Inspect 查询容器状态
+func (r *DockerRuntime) Inspect(ctx context.Context, id string) (*State, error) {
+	out, err := r.run(ctx, "inspect", "--type", "container", id)
+	if err != nil {
+		return nil, err
+	}
+	return parseInspect([]byte(out))
+}
+
+// inspectResult docker inspect 输出中关注的字段
+type inspectResult struct {
+	ID     string `json:"Id"`
+	Name   string `json:"Name"`
+	Config struct {
+		Image string `json:"Image"`
+	} `json:"Config"`
+	State struct {
+		Status  string `json:"Status"`
+		Running bool   `json:"Running"`
+	} `json:"State"`
+	NetworkSettings struct {
+		Ports map[string][]struct {
+			HostIP   string `json:"HostIp"`
+			HostPort string `json:"HostPort"`
+		} `json:"Ports"`
+	} `json:"NetworkSettings"`
+}
+
+// parseInspect 解析 docker inspect 输出
+func parseInspect(data []byte) (*State, error) {
+	var results []inspectResult
+	if err := json.Unmarshal(data, &results); err != nil {
+		return nil, fmt.Errorf("decode inspect output: %w", err)
+	}
+	if len(results) == 0 {
+		return nil, ErrNotFound
+	}
+	res := results[0]
+	state := &State{
+		ID:      res.ID,
+		Name:    strings.TrimPrefix(res.Name, "/"),
+		Image:   res.Config.Image,
+		Status:  res.State.Status,
+		Running: res.State.Running,
+	}
+	for key, bindings := range res.NetworkSettings.Ports {
+		// key 形如 "22/tcp"
+		portStr, proto, _ := strings.Cut(key, "/")
+		containerPort, err := strconv.Atoi(portStr)
+		if err != nil {
+			continue
+		}
+		for _, b := range bindings {
+			hostPort, err := strconv.Atoi(b.HostPort)
+			if err != nil {
+				continue
+			}
+			state.Ports = append(state.Ports, PortBinding{ContainerPort: containerPort, HostPort: hostPort, Protocol: proto})
+			break
+		}
+	}
+	sort.Slice(state.Ports, func(i, j int) bool { return state.Ports[i].ContainerPort < state.Ports[j].ContainerPort })
+	return state, nil
+}
+
+// run 执行 docker 命令，返回标准输出
+func (r *DockerRuntime) run(ctx context.Context, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, r.binary, args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if strings.Contains(msg, "No such container") || strings.Contains(msg, "No such object") {
+			return "", ErrNotFound
+		}
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", fmt.Errorf("docker %s: %s", args[0], msg)
+	}
+	return stdout.String(), nil
+}
+
+// validImage 校验镜像名，拒绝以 - 开头（会被 docker 解析为选项）或包含空白、控制字符的值
+func validImage(image string) bool {
+	if strings.HasPrefix(image, "-") {
+		return false
+	}
+	for _, r := range image {
+		if r <= ' ' || r == 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
+// gpuDeviceOption 构造 --gpus 的设备参数，docker 按 CSV 解析该参数，多个序号需要用双引号包住
+func gpuDeviceOption(indexes []int) string {
+	ids := make([]string, len(indexes))
+	for i, idx := range indexes {
+		ids[i] = strconv.Itoa(idx)
+	}
+	return `"device=` + strings.Join(ids, ",") + `"`
+}
+
+// buildRunArgs 根据 Spec 构造 docker run 参数
+func buildRunArgs(spec *Spec) []string {
+	args := []string{"run", "-d", "--restart", "unless-stopped"}
+	if spec.Name != "" {
+		args = append(args, "--name", spec.Name)
+	}
+	if spec.CPU > 0 {
+		args = append(args, "--cpus", strconv.Itoa(spec.CPU))
+	}
+	if spec.MemoryMB > 0 {
+		args = append(args, "--memory", strconv.FormatInt(spec.MemoryMB, 10)+"m")
+	}
+	if len(spec.GPUDevices) > 0 {
+		args = append(args, "--gpus", gpuDeviceOption(spec.GPUDevices))
+	} else if spec.GPU < 0 {
+		args = append(args, "--gpus", "all")
+	} else if spec.GPU > 0 {
+		args = append(args, "--gpus", strconv.Itoa(spec.GPU))
+	}
+
+	// 按 key 排序，保证参数顺序稳定
+	envKeys := make([]string, 0, len(spec.Env))
+	for k := range spec.Env {
+		envKeys = append(envKeys, k)
+	}
+	sort.Strings(envKeys)
+	for _, k := range envKeys {
+		args = append(args, "-e", k+"="+spec.Env[k])
+	}
+
+	labelKeys := make([]string, 0, len(spec.Labels))
+	for k := range spec.Labels {
+		labelKeys = append(labelKeys, k)
+	}
+	sort.Strings(labelKeys)
+	for _, k := range labelKeys {
+		args = append(args, "--label", k+"="+spec.Labels[k])
+	}
+
+	for _, p := range spec.Ports {
+		proto := p.Protocol
+		if proto == "" {
+			proto = "tcp"
+		}
+		if p.HostPort > 0 {
+			args = append(args, "-p", fmt.Sprintf("%d:%d/%s", p.HostPort, p.ContainerPort, proto))
+		} else {
+			args = append(args, "-p", fmt.Sprintf("%d/%s", p.ContainerPort, proto))
+		}
+	}
+	for _, v := range spec.Volumes {
+		args = append(args, "-v", v)
+	}
+
+	args = append(args, spec.Image)
+	return append(args, spec.Command...)
+}
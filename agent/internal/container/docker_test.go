@@ -0,0 +1,105 @@
+package container
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBuildRunArgs(t *testing.T) {
+	spec := &Spec{
+		Name:     "env-1",
+		Image:    "pytorch:latest",
+		Command:  []string{"sleep", "infinity"},
+		Env:      map[string]string{"B": "2", "A": "1"},
+		CPU:      4,
+		MemoryMB: 8192,
+		GPU:      2,
+		Ports: []PortBinding{
+			{ContainerPort: 22, HostPort: 30022},
+			{ContainerPort: 8888},
+		},
+		Volumes: []string{"/data:/data:ro"},
+	}
+
+	got := buildRunArgs(spec)
+	want := []string{
+		"run", "-d", "--restart", "unless-stopped",
+		"--name", "env-1",
+		"--cpus", "4",
+		"--memory", "8192m",
+		"--gpus", "2",
+		"-e", "A=1", "-e", "B=2",
+		"-p", "30022:22/tcp",
+		"-p", "8888/tcp",
+		"-v", "/data:/data:ro",
+		"pytorch:latest", "sleep", "infinity",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("buildRunArgs() =\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestBuildRunArgsAllGPUs(t *testing.T) {
+	got := buildRunArgs(&Spec{Image: "ubuntu", GPU: -1})
+	want := []string{"run", "-d", "--restart", "unless-stopped", "--gpus", "all", "ubuntu"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("buildRunArgs() = %v, want %v", got, want)
+	}
+}
+
+func TestBuildRunArgsGPUDevices(t *testing.T) {
+	got := buildRunArgs(&Spec{Image: "ubuntu", GPU: 2, GPUDevices: []int{0, 2}})
+	want := []string{"run", "-d", "--restart", "unless-stopped", "--gpus", `"device=0,2"`, "ubuntu"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("buildRunArgs() = %v, want %v", got, want)
+	}
+}
+
+func TestCreateRejectsInvalidImage(t *testing.T) {
+	// binary 不存在，校验通过时会返回执行错误而不是 invalid image
+	r := NewDockerRuntime("/nonexistent/docker")
+	for _, image := range []string{"--privileged", "-v/:/host", "alpine --rm", "alpine\t", "alpine\n"} {
+		_, err := r.Create(context.Background(), &Spec{Image: image})
+		if err == nil || !strings.Contains(err.Error(), "invalid image") {
+			t.Errorf("Create(%q) error = %v, want invalid image", image, err)
+		}
+	}
+	if !validImage("registry.local:5000/team/pytorch:2.1@sha256:abcd") {
+		t.Error("valid image rejected")
+	}
+}
+
+func TestParseInspect(t *testing.T) {
+	out := `[{
+		"Id": "abc123",
+		"Name": "/env-1",
+		"Config": {"Image": "pytorch:latest"},
+		"State": {"Status": "running", "Running": true},
+		"NetworkSettings": {"Ports": {
+			"8888/tcp": [{"HostIp": "0.0.0.0", "HostPort": "32769"}],
+			"22/tcp": [{"HostIp": "0.0.0.0", "HostPort": "32768"}, {"HostIp": "::", "HostPort": "32768"}],
+			"6006/tcp": null
+		}}
+	}]`
+
+	state, err := parseInspect([]byte(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.ID != "abc123" || state.Name != "env-1" || !state.Running {
+		t.Errorf("unexpected state: %+v", state)
+	}
+	want := []PortBinding{
+		{ContainerPort: 22, HostPort: 32768, Protocol: "tcp"},
+		{ContainerPort: 8888, HostPort: 32769, Protocol: "tcp"},
+	}
+	if !reflect.DeepEqual(state.Ports, want) {
+		t.Errorf("ports = %+v, want %+v", state.Ports, want)
+	}
+
+	if _, err := parseInspect([]byte(`[]`)); err != ErrNotFound {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,56 @@
+package container
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNotFound 容器不存在
+var ErrNotFound = errors.New("container not found")
+
+// PortBinding 端口绑定
+type PortBinding struct {
+	ContainerPort int    `json:"container_port"`
+	HostPort      int    `json:"host_port"`
+	Protocol      string `json:"protocol,omitempty"` // tcp, udp
+}
+
+// Spec 容器创建参数
+type Spec struct {
+	Name     string            `json:"name"`
+	Image    string            `json:"image"`
+	Command  []string          `json:"command,omitempty"`
+	Env      map[string]string `json:"env,omitempty"`
+	CPU      int               `json:"cpu"`       // CPU 核数
+	MemoryMB int64             `json:"memory_mb"` // 内存上限（MB）
+	GPU      int               `json:"gpu"`       // GPU 数量，-1 表示全部
+	// GPUDevices 限定容器可见的 GPU 序号，非空时忽略 GPU
+	GPUDevices []int             `json:"gpu_devices,omitempty"`
+	Ports      []PortBinding     `json:"ports,omitempty"`
+	Volumes    []string          `json:"volumes,omitempty"` // host:container[:ro]
+	Labels     map[string]string `json:"labels,omitempty"`
+}
+
+// State 容器状态
+type State struct {
+	ID      string        `json:"id"`
+	Name    string        `json:"name"`
+	Image   string        `json:"image"`
+	Status  string        `json:"status"` // created, running, exited, ...
+	Running bool          `json:"running"`
+	Ports   []PortBinding `json:"ports,omitempty"`
+}
+
+// Runtime 容器运行时接口
+type Runtime interface {
+	// Create 创建并启动容器，返回容器 ID
+	Create(ctx context.Context, spec *Spec) (string, error)
+	// Start 启动已存在的容器
+	Start(ctx context.Context, id string) error
+	// Stop 停止容器，timeout 为等待退出的秒数
+	Stop(ctx context.Context, id string, timeout int) error
+	// Remove 强制删除容器
+	Remove(ctx context.Context, id string) error
+	// Inspect 查询容器状态
+	Inspect(ctx context.Context, id string) (*State, error)
+}
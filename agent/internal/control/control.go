@@ -0,0 +1,110 @@
+package control
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/YoungBoyGod/remotegpu-agent/internal/sshkeys"
+	"github.com/YoungBoyGod/remotegpu-agent/internal/tenant"
+	"github.com/shirou/gopsutil/v3/cpu"
+	"github.com/shirou/gopsutil/v3/disk"
+	"github.com/shirou/gopsutil/v3/host"
+	"github.com/shirou/gopsutil/v3/mem"
+)
+
+// DefaultCommandTimeout 未指定超时时命令的默认执行时间
+const DefaultCommandTimeout = 60 * time.Second
+
+// SystemInfo 机器基础信息
+type SystemInfo struct {
+	Hostname    string `json:"hostname"`
+	OS          string `json:"os"`
+	Kernel      string `json:"kernel"`
+	CPUCores    int    `json:"cpu_cores"`
+	MemoryTotal uint64 `json:"memory_total"`
+	MemoryFree  uint64 `json:"memory_free"`
+	DiskTotal   uint64 `json:"disk_total"`
+	DiskFree    uint64 `json:"disk_free"`
+	Uptime      uint64 `json:"uptime"`
+}
+
+// CollectSystemInfo 采集机器基础信息，单项采集失败时对应字段为零值
+func CollectSystemInfo() *SystemInfo {
+	info := &SystemInfo{OS: runtime.GOOS}
+	if h, err := host.Info(); err == nil {
+		info.Hostname = h.Hostname
+		info.Kernel = h.KernelVersion
+		info.Uptime = h.Uptime
+	}
+	if m, err := mem.VirtualMemory(); err == nil {
+		info.MemoryTotal = m.Total
+		info.MemoryFree = m.Free
+	}
+	if d, err := disk.Usage("/"); err == nil {
+		info.DiskTotal = d.Total
+		info.DiskFree = d.Free
+	}
+	info.CPUCores, _ = cpu.Counts(true)
+	return info
+}
+
+// StopProcess 向进程发送信号，signal 为 SIGKILL 时强制结束，否则发送 SIGTERM
+func StopProcess(pid int, signal string) error {
+	sig := syscall.SIGTERM
+	if signal == "SIGKILL" {
+		sig = syscall.SIGKILL
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	return process.Signal(sig)
+}
+
+// Cleanup 按类型清理机器：docker 清理镜像与容器，ssh 清空平台管理的公钥并删除所有客户账号（含进程和数据）
+func Cleanup(ctx context.Context, types []string, tenants tenant.Syncer) {
+	for _, t := range types {
+		switch strings.ToLower(t) {
+		case "docker":
+			exec.Command("docker", "system", "prune", "-af").Run()
+		case "ssh":
+			if target, err := sshkeys.Resolve(""); err == nil {
+				if err := sshkeys.Sync(target, nil); err != nil {
+					slog.Warn("cleanup ssh keys error", "error", err)
+				}
+			}
+			if tenants != nil {
+				if err := tenants.Sync(ctx, nil); err != nil {
+					slog.Warn("cleanup tenant accounts error", "error", err)
+				}
+			}
+		}
+	}
+}
+
+// RunCommand 通过 bash 执行命令，输出写入 stdout/stderr
+// 返回退出码；命令无法启动或被超时终止时同时返回错误，退出码为 -1
+func RunCommand(ctx context.Context, command, workDir string, stdout, stderr io.Writer) (int, error) {
+	cmd := exec.CommandContext(ctx, "bash", "-c", command)
+	if workDir != "" {
+		cmd.Dir = workDir
+	}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	err := cmd.Run()
+	if err == nil {
+		return 0, nil
+	}
+	if exitErr, ok := err.(*exec.ExitError); ok && ctx.Err() == nil {
+		return exitErr.ExitCode(), err
+	}
+	return -1, err
+}
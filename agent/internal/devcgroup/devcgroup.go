@@ -0,0 +1,323 @@
+// Package devcgroup 通过设备 cgroup 限制任务进程只能打开分配给它的 NVIDIA GPU 设备节点
+package devcgroup
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	cgroupRoot = "/sys/fs/cgroup"
+	// parentDir 任务 cgroup 的父目录，位于 Agent 自身所在 cgroup 之下
+	parentDir = "remotegpu-tasks"
+
+	// NVIDIA GPU 设备节点 /dev/nvidiaN 的主设备号，次设备号即 GPU 序号
+	nvidiaMajor = 195
+	// firstSharedMinor 起的次设备号为 nvidia-modeset(254)、nvidiactl(255) 等所有 GPU 共用的控制设备
+	firstSharedMinor = 254
+)
+
+var ErrUnsupported = errors.New("device cgroup not supported")
+
+// Group 任务的设备 cgroup
+type Group struct {
+	path    string
+	origin  string // Agent 自身所在的 cgroup，v1 下 fork 完成后将线程移回
+	version int
+	dir     *os.File // cgroup v2 目录句柄，用于把进程直接创建在该 cgroup 内
+}
+
+// New 为任务创建设备 cgroup，只允许访问 gpus 中的 GPU，其他设备不受限制
+func New(name string, gpus []int) (*Group, error) {
+	if name == "" || name == "." || name == ".." || strings.Contains(name, "/") {
+		return nil, fmt.Errorf("invalid cgroup name %q", name)
+	}
+	version, mount, err := detect()
+	if err != nil {
+		return nil, err
+	}
+	current, err := currentCgroup(version)
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(mount, current, parentDir, name)
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return nil, fmt.Errorf("create cgroup: %w", err)
+	}
+
+	g := &Group{path: path, origin: filepath.Join(mount, current), version: version}
+	if version == 1 {
+		err = g.denyV1(gpus)
+	} else {
+		err = g.attachV2(gpus)
+	}
+	if err != nil {
+		g.Remove()
+		return nil, err
+	}
+	return g, nil
+}
+
+// Start 在该 cgroup 内启动 cmd，进程从 exec 之前就受限，之后派生的子进程同样受限
+func (g *Group) Start(cmd *exec.Cmd) error {
+	if g.version == 2 {
+		if cmd.SysProcAttr == nil {
+			cmd.SysProcAttr = &syscall.SysProcAttr{}
+		}
+		cmd.SysProcAttr.UseCgroupFD = true
+		cmd.SysProcAttr.CgroupFD = int(g.dir.Fd())
+		return cmd.Start()
+	}
+
+	// cgroup v1 按线程划分：把一个专用线程移入该 cgroup 后在其上 fork，子进程继承该线程的 cgroup，
+	// 之后把线程移回原 cgroup 再解锁；移回失败时不解锁，goroutine 退出时该线程随之销毁
+	errCh := make(chan error, 1)
+	go func() {
+		runtime.LockOSThread()
+		tid := []byte(strconv.Itoa(unix.Gettid()))
+		if err := os.WriteFile(filepath.Join(g.path, "tasks"), tid, 0); err != nil {
+			runtime.UnlockOSThread()
+			errCh <- fmt.Errorf("enter cgroup: %w", err)
+			return
+		}
+		err := cmd.Start()
+		if werr := os.WriteFile(filepath.Join(g.origin, "tasks"), tid, 0); werr == nil {
+			runtime.UnlockOSThread()
+		} else {
+			slog.Warn("leave device cgroup error", "cgroup", g.path, "error", werr)
+		}
+		errCh <- err
+	}()
+	return <-errCh
+}
+
+// Remove 结束 cgroup 内残留的进程（如任务启动的后台进程）并删除 cgroup
+func (g *Group) Remove() error {
+	if g.dir != nil {
+		g.dir.Close()
+	}
+	var err error
+	for i := 0; i < 10; i++ {
+		killAll(g.path)
+		if err = os.Remove(g.path); err == nil || os.IsNotExist(err) {
+			return nil
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return fmt.Errorf("remove cgroup %s: %w", g.path, err)
+}
+
+// detect 判断 cgroup 版本，返回设备控制所在的挂载点
+func detect() (int, string, error) {
+	var st unix.Statfs_t
+	if err := unix.Statfs(cgroupRoot, &st); err != nil {
+		return 0, "", fmt.Errorf("%w: %v", ErrUnsupported, err)
+	}
+	if st.Type == unix.CGROUP2_SUPER_MAGIC {
+		return 2, cgroupRoot, nil
+	}
+	devices := filepath.Join(cgroupRoot, "devices")
+	if info, err := os.Stat(devices); err == nil && info.IsDir() {
+		return 1, devices, nil
+	}
+	return 0, "", ErrUnsupported
+}
+
+// currentCgroup 返回 Agent 所在的 cgroup 路径：v2 为统一层级，v1 为 devices 控制器层级
+func currentCgroup(version int) (string, error) {
+	f, err := os.Open("/proc/self/cgroup")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// 格式 hierarchy-ID:controller-list:cgroup-path
+		fields := strings.SplitN(scanner.Text(), ":", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		if version == 2 && fields[0] == "0" && fields[1] == "" {
+			return fields[2], nil
+		}
+		if version == 1 {
+			for _, c := range strings.Split(fields[1], ",") {
+				if c == "devices" {
+					return fields[2], nil
+				}
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("%w: cgroup of agent not found", ErrUnsupported)
+}
+
+// denyV1 在默认允许的基础上逐个拒绝未分配的 GPU 次设备号
+func (g *Group) denyV1(gpus []int) error {
+	allowed := allowedSet(gpus)
+	f, err := os.OpenFile(filepath.Join(g.path, "devices.deny"), os.O_WRONLY, 0)
+	if err != nil {
+		return fmt.Errorf("open devices.deny: %w", err)
+	}
+	defer f.Close()
+	// 每次 write 只能写入一条规则
+	for minor := 0; minor < firstSharedMinor; minor++ {
+		if allowed[minor] {
+			continue
+		}
+		if _, err := fmt.Fprintf(f, "c %d:%d rwm", nvidiaMajor, minor); err != nil {
+			return fmt.Errorf("deny gpu %d: %w", minor, err)
+		}
+	}
+	return nil
+}
+
+// attachV2 加载设备过滤 BPF 程序并挂到该 cgroup（cgroup v2 没有 devices 接口文件）
+func (g *Group) attachV2(gpus []int) error {
+	dir, err := os.Open(g.path)
+	if err != nil {
+		return fmt.Errorf("open cgroup: %w", err)
+	}
+	g.dir = dir
+
+	prog, err := loadProgram(gpus)
+	if err != nil {
+		return err
+	}
+	defer unix.Close(prog)
+
+	attr := struct {
+		targetFD    uint32
+		attachBPFFD uint32
+		attachType  uint32
+		attachFlags uint32
+	}{uint32(dir.Fd()), uint32(prog), unix.BPF_CGROUP_DEVICE, unix.BPF_F_ALLOW_MULTI}
+	if _, _, errno := unix.Syscall(unix.SYS_BPF, unix.BPF_PROG_ATTACH, uintptr(unsafe.Pointer(&attr)), unsafe.Sizeof(attr)); errno != 0 {
+		return fmt.Errorf("attach device program: %w", errno)
+	}
+	return nil
+}
+
+// bpfInsn 对应内核 struct bpf_insn
+type bpfInsn struct {
+	code uint8
+	regs uint8 // 低 4 位为目标寄存器，高 4 位为源寄存器
+	off  int16
+	imm  int32
+}
+
+// deviceProgram 生成设备过滤程序：主设备号为 195 且次设备号小于 254 的字符设备只允许 gpus 中的序号，其他设备一律放行
+// 程序入参为 struct bpf_cgroup_dev_ctx { u32 access_type; u32 major; u32 minor; }，返回 1 放行、0 拒绝
+func deviceProgram(gpus []int) []bpfInsn {
+	const (
+		ldxW = unix.BPF_LDX | unix.BPF_MEM | unix.BPF_W
+		andK = unix.BPF_ALU | unix.BPF_AND | unix.BPF_K
+		jeqK = unix.BPF_JMP | unix.BPF_JEQ | unix.BPF_K
+		jneK = unix.BPF_JMP | unix.BPF_JNE | unix.BPF_K
+		jgeK = unix.BPF_JMP | unix.BPF_JGE | unix.BPF_K
+		movK = unix.BPF_ALU64 | unix.BPF_MOV | unix.BPF_K
+		exit = unix.BPF_JMP | unix.BPF_EXIT
+		r0   = 0
+		r2   = 2
+		r2r1 = 1<<4 | r2 // dst=r2, src=r1
+	)
+	allowed := make([]int, 0, len(gpus))
+	for minor := range allowedSet(gpus) {
+		allowed = append(allowed, minor)
+	}
+	sort.Ints(allowed)
+
+	// 7 条固定指令 + 每个 GPU 一条比较，之后为 r0=0、exit（拒绝），再之后为放行分支
+	allowPos := 7 + len(allowed) + 2
+	prog := make([]bpfInsn, 0, allowPos+2)
+	jumpToAllow := func(code uint8, imm int32) {
+		prog = append(prog, bpfInsn{code: code, regs: r2, off: int16(allowPos - len(prog) - 1), imm: imm})
+	}
+
+	prog = append(prog,
+		bpfInsn{code: ldxW, regs: r2r1, off: 0},    // r2 = access_type
+		bpfInsn{code: andK, regs: r2, imm: 0xffff}, // 低 16 位为设备类型
+	)
+	jumpToAllow(jneK, unix.BPF_DEVCG_DEV_CHAR)                   // 非字符设备放行
+	prog = append(prog, bpfInsn{code: ldxW, regs: r2r1, off: 4}) // r2 = major
+	jumpToAllow(jneK, nvidiaMajor)                               // 非 GPU 设备放行
+	prog = append(prog, bpfInsn{code: ldxW, regs: r2r1, off: 8}) // r2 = minor
+	jumpToAllow(jgeK, firstSharedMinor)                          // 共用的控制设备放行
+	for _, minor := range allowed {
+		jumpToAllow(jeqK, int32(minor))
+	}
+	return append(prog,
+		bpfInsn{code: movK, regs: r0, imm: 0},
+		bpfInsn{code: exit},
+		bpfInsn{code: movK, regs: r0, imm: 1},
+		bpfInsn{code: exit},
+	)
+}
+
+func loadProgram(gpus []int) (int, error) {
+	insns := deviceProgram(gpus)
+	license := []byte("GPL\x00")
+	attr := struct {
+		progType    uint32
+		insnCnt     uint32
+		insns       uint64
+		license     uint64
+		logLevel    uint32
+		logSize     uint32
+		logBuf      uint64
+		kernVersion uint32
+		progFlags   uint32
+	}{
+		progType: unix.BPF_PROG_TYPE_CGROUP_DEVICE,
+		insnCnt:  uint32(len(insns)),
+		insns:    uint64(uintptr(unsafe.Pointer(&insns[0]))),
+		license:  uint64(uintptr(unsafe.Pointer(&license[0]))),
+	}
+	fd, _, errno := unix.Syscall(unix.SYS_BPF, unix.BPF_PROG_LOAD, uintptr(unsafe.Pointer(&attr)), unsafe.Sizeof(attr))
+	runtime.KeepAlive(insns)
+	runtime.KeepAlive(license)
+	if errno != 0 {
+		return -1, fmt.Errorf("load device program: %w", errno)
+	}
+	return int(fd), nil
+}
+
+func allowedSet(gpus []int) map[int]bool {
+	allowed := make(map[int]bool, len(gpus))
+	for _, idx := range gpus {
+		if idx >= 0 && idx < firstSharedMinor {
+			allowed[idx] = true
+		}
+	}
+	return allowed
+}
+
+// killAll 结束 cgroup 内的所有进程；v1 下用于 fork 的 Agent 线程移出前 Agent 自身也会出现在列表中，需跳过
+func killAll(path string) {
+	data, err := os.ReadFile(filepath.Join(path, "cgroup.procs"))
+	if err != nil {
+		return
+	}
+	for _, line := range strings.Fields(string(data)) {
+		if pid, err := strconv.Atoi(line); err == nil && pid != os.Getpid() {
+			syscall.Kill(pid, syscall.SIGKILL)
+		}
+	}
+}
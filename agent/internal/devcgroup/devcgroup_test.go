@@ -0,0 +1,90 @@
+package devcgroup
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+// openNode 在设备 cgroup 中打开设备节点，返回 shell 的错误输出
+func openNode(t *testing.T, gpus []int, node string) string {
+	t.Helper()
+	g, err := New("test-"+strings.ReplaceAll(t.Name(), "/", "-"), gpus)
+	if errors.Is(err, ErrUnsupported) {
+		t.Skipf("device cgroup unsupported: %v", err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := g.Remove(); err != nil {
+			t.Error(err)
+		}
+		if _, err := os.Stat(g.path); !os.IsNotExist(err) {
+			t.Errorf("cgroup %s should be removed", g.path)
+		}
+	}()
+
+	var stderr strings.Builder
+	cmd := exec.Command("sh", "-c", "exec 3<"+node)
+	cmd.Stderr = &stderr
+	if err := g.Start(cmd); err != nil {
+		t.Fatal(err)
+	}
+	cmd.Wait()
+	return stderr.String()
+}
+
+func TestGroupRestrictsGPUDevices(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("需要 root 权限创建 cgroup 和设备节点")
+	}
+	// 模拟 /dev/nvidia1；测试环境没有 NVIDIA 驱动，放行时打开会得到 ENXIO，被 cgroup 拒绝时得到 EPERM
+	node := filepath.Join(t.TempDir(), "nvidia1")
+	if err := unix.Mknod(node, unix.S_IFCHR|0666, int(unix.Mkdev(nvidiaMajor, 1))); err != nil {
+		t.Skipf("mknod: %v", err)
+	}
+
+	if out := openNode(t, []int{1}, node); !strings.Contains(out, "No such device") {
+		t.Skipf("设备节点无法在当前环境中使用: %q", out)
+	}
+	if out := openNode(t, []int{0}, node); !strings.Contains(out, "Operation not permitted") {
+		t.Errorf("未分配的 GPU 应被拒绝, got %q", out)
+	}
+	if out := openNode(t, nil, node); !strings.Contains(out, "Operation not permitted") {
+		t.Errorf("未分配任何 GPU 时应拒绝所有 GPU, got %q", out)
+	}
+}
+
+func TestNewRejectsInvalidName(t *testing.T) {
+	for _, name := range []string{"", ".", "..", "a/b", "../x"} {
+		if _, err := New(name, nil); err == nil {
+			t.Errorf("expected error for name %q", name)
+		}
+	}
+}
+
+func TestDeviceProgram(t *testing.T) {
+	prog := deviceProgram([]int{3, 1, 1, 300})
+	// 7 条固定指令 + 2 个去重后的 GPU 比较 + 拒绝、放行各 2 条
+	if len(prog) != 13 {
+		t.Fatalf("expected 13 instructions, got %d", len(prog))
+	}
+	// 所有跳转都应落在放行分支 r0=1
+	for i, insn := range prog {
+		if insn.code&0x07 == unix.BPF_JMP && insn.code != unix.BPF_JMP|unix.BPF_EXIT {
+			target := i + 1 + int(insn.off)
+			if target != 11 || prog[target].imm != 1 {
+				t.Errorf("instruction %d jumps to %d", i, target)
+			}
+		}
+	}
+	if prog[7].imm != 1 || prog[8].imm != 3 {
+		t.Errorf("expected sorted gpu comparisons, got %d %d", prog[7].imm, prog[8].imm)
+	}
+}
@@ -2,21 +2,29 @@ package errors
 
 // CodeX 2026-02-05: Agent task queue error codes.
 const (
-	ErrAttemptMismatch = 30001
-	ErrTaskImmutable   = 30002
-	ErrLeaseExpired    = 30003
-	ErrTaskNotFound    = 30004
-	ErrInvalidParams   = 30005
-	ErrInternal        = 30099
+	ErrAttemptMismatch   = 30001
+	ErrTaskImmutable     = 30002
+	ErrLeaseExpired      = 30003
+	ErrTaskNotFound      = 30004
+	ErrInvalidParams     = 30005
+	ErrContainerNotFound = 30006
+	ErrContainerRuntime  = 30007
+	ErrUnauthorized      = 30008
+	ErrMountFailed       = 30009
+	ErrInternal          = 30099
 )
 
 var errorMsg = map[int]string{
-	ErrAttemptMismatch: "attempt mismatch",
-	ErrTaskImmutable:   "task immutable",
-	ErrLeaseExpired:    "lease expired",
-	ErrTaskNotFound:    "task not found",
-	ErrInvalidParams:   "invalid params",
-	ErrInternal:        "internal error",
+	ErrAttemptMismatch:   "attempt mismatch",
+	ErrTaskImmutable:     "task immutable",
+	ErrLeaseExpired:      "lease expired",
+	ErrTaskNotFound:      "task not found",
+	ErrInvalidParams:     "invalid params",
+	ErrContainerNotFound: "container not found",
+	ErrContainerRuntime:  "container runtime error",
+	ErrUnauthorized:      "unauthorized",
+	ErrMountFailed:       "mount failed",
+	ErrInternal:          "internal error",
 }
 
 // CodeX 2026-02-05: Message returns the default error message for an agent error code.
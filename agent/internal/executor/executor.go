@@ -3,14 +3,21 @@ package executor
 import (
 	"bytes"
 	"context"
+	"io"
+	"log/slog"
 	"os"
 	"os/exec"
+	"os/user"
+	"strconv"
 	"sync"
 	"syscall"
 	"time"
 
+	"github.com/YoungBoyGod/remotegpu-agent/internal/devcgroup"
+	"github.com/YoungBoyGod/remotegpu-agent/internal/logship"
 	"github.com/YoungBoyGod/remotegpu-agent/internal/models"
 	"github.com/YoungBoyGod/remotegpu-agent/internal/security"
+	"github.com/YoungBoyGod/remotegpu-agent/internal/tenant"
 )
 
 const maxOutputSize = 1 << 20 // 1MB
@@ -30,11 +37,29 @@ func (w *limitedWriter) Write(p []byte) (int, error) {
 	remaining := w.limit - w.buf.Len()
 	if len(p) > remaining {
 		w.dropped = true
-		p = p[:remaining]
+		w.buf.Write(p[:remaining])
+		// 返回完整长度，否则 io.MultiWriter 会报 ErrShortWrite 导致管道关闭、进程被 SIGPIPE 杀死
+		return len(p), nil
 	}
 	return w.buf.Write(p)
 }
 
+// spoolWriter 写入本地输出缓存文件，写失败时不影响进程执行
+type spoolWriter struct {
+	f      *os.File
+	failed bool
+}
+
+func (w *spoolWriter) Write(p []byte) (int, error) {
+	if !w.failed {
+		if _, err := w.f.Write(p); err != nil {
+			w.failed = true
+			slog.Warn("write log spool error", "file", w.f.Name(), "error", err)
+		}
+	}
+	return len(p), nil
+}
+
 func (w *limitedWriter) String() string {
 	s := w.buf.String()
 	if w.dropped {
@@ -43,25 +68,40 @@ func (w *limitedWriter) String() string {
 	return s
 }
 
+// deviceGroup 限制任务可访问 GPU 设备的 cgroup
+type deviceGroup interface {
+	Start(cmd *exec.Cmd) error
+	Remove() error
+}
+
 // Executor 任务执行器
 type Executor struct {
 	mu         sync.Mutex
 	running    map[string]*runningTask
 	maxWorkers int
 	validator  *security.Validator
+	logDir     string
+
+	lookupTenant   func(username string) (*user.User, error)
+	newDeviceGroup func(name string, gpus []int) (deviceGroup, error)
 }
 
 type runningTask struct {
-	task   *models.Task
-	cmd    *exec.Cmd
-	cancel context.CancelFunc
+	task     *models.Task
+	cmd      *exec.Cmd
+	cancel   context.CancelFunc
+	progress *progressWriter
 }
 
 // NewExecutor 创建执行器
 func NewExecutor(maxWorkers int) *Executor {
 	return &Executor{
-		running:    make(map[string]*runningTask),
-		maxWorkers: maxWorkers,
+		running:      make(map[string]*runningTask),
+		maxWorkers:   maxWorkers,
+		lookupTenant: tenant.LookupManaged,
+		newDeviceGroup: func(name string, gpus []int) (deviceGroup, error) {
+			return devcgroup.New(name, gpus)
+		},
 	}
 }
 
@@ -70,6 +110,16 @@ func (e *Executor) SetValidator(v *security.Validator) {
 	e.validator = v
 }
 
+// SetLogDir 设置任务输出缓存目录，Server 下发的任务输出会同时写入该目录供增量上报
+func (e *Executor) SetLogDir(dir string) {
+	e.logDir = dir
+}
+
+// LogDir 返回任务输出缓存目录
+func (e *Executor) LogDir() string {
+	return e.logDir
+}
+
 // RunningCount 返回正在运行的任务数
 func (e *Executor) RunningCount() int {
 	e.mu.Lock()
@@ -124,14 +174,53 @@ func (e *Executor) Execute(task *models.Task) {
 	// 设置进程组，便于杀死子进程
 	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
 
+	// GPU 粒度分配的任务以客户账号运行，并放入只允许访问已分配 GPU 的设备 cgroup；任一步失败都不执行任务
+	var group deviceGroup
+	if task.RunAs != "" {
+		if err := e.runAsTenant(cmd, task); err != nil {
+			cancel()
+			rejectTask(task, "run as tenant: "+err.Error())
+			return
+		}
+		g, err := e.newDeviceGroup(cgroupName(task), task.AssignedGPUs)
+		if err != nil {
+			cancel()
+			rejectTask(task, "device cgroup: "+err.Error())
+			return
+		}
+		defer func() {
+			if err := g.Remove(); err != nil {
+				slog.Warn("remove device cgroup error", "task_id", task.ID, "error", err)
+			}
+		}()
+		group = g
+	}
+
 	stdout := &limitedWriter{limit: maxOutputSize}
 	stderr := &limitedWriter{limit: maxOutputSize}
-	cmd.Stdout = stdout
+	progress := &progressWriter{}
+	cmd.Stdout = io.MultiWriter(stdout, progress)
 	cmd.Stderr = stderr
 
+	// Server 下发的任务同时写入缓存文件，由 logship 增量上报
+	if e.logDir != "" && task.AttemptID != "" {
+		if f, err := logship.OpenSpool(e.logDir, task.ID, task.AttemptID, "stdout"); err == nil {
+			defer f.Close()
+			cmd.Stdout = io.MultiWriter(stdout, progress, &spoolWriter{f: f})
+		} else {
+			slog.Warn("open log spool error", "task_id", task.ID, "error", err)
+		}
+		if f, err := logship.OpenSpool(e.logDir, task.ID, task.AttemptID, "stderr"); err == nil {
+			defer f.Close()
+			cmd.Stderr = io.MultiWriter(stderr, &spoolWriter{f: f})
+		} else {
+			slog.Warn("open log spool error", "task_id", task.ID, "error", err)
+		}
+	}
+
 	// 记录运行中的任务
 	e.mu.Lock()
-	e.running[task.ID] = &runningTask{task: task, cmd: cmd, cancel: cancel}
+	e.running[task.ID] = &runningTask{task: task, cmd: cmd, cancel: cancel, progress: progress}
 	e.mu.Unlock()
 
 	// 更新任务状态
@@ -139,7 +228,15 @@ func (e *Executor) Execute(task *models.Task) {
 	task.StartedAt = time.Now()
 
 	// 执行命令
-	err := cmd.Run()
+	var err error
+	if group != nil {
+		err = group.Start(cmd)
+	} else {
+		err = cmd.Start()
+	}
+	if err == nil {
+		err = cmd.Wait()
+	}
 
 	// 清理
 	e.mu.Lock()
@@ -166,6 +263,66 @@ func (e *Executor) Execute(task *models.Task) {
 	}
 }
 
+// runAsTenant 以受管客户账号的身份运行命令，环境变量不继承 Agent 进程，工作目录默认为账号家目录
+func (e *Executor) runAsTenant(cmd *exec.Cmd, task *models.Task) error {
+	u, err := e.lookupTenant(task.RunAs)
+	if err != nil {
+		return err
+	}
+	uid, err := strconv.ParseUint(u.Uid, 10, 32)
+	if err != nil {
+		return err
+	}
+	gid, err := strconv.ParseUint(u.Gid, 10, 32)
+	if err != nil {
+		return err
+	}
+	cmd.SysProcAttr.Credential = &syscall.Credential{Uid: uint32(uid), Gid: uint32(gid), Groups: []uint32{}}
+
+	cmd.Env = []string{
+		"PATH=/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin",
+		"HOME=" + u.HomeDir,
+		"USER=" + u.Username,
+		"LOGNAME=" + u.Username,
+		"SHELL=/bin/bash",
+	}
+	for k, v := range task.Env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	if cmd.Dir == "" {
+		cmd.Dir = u.HomeDir
+	}
+	return nil
+}
+
+// cgroupName 任务设备 cgroup 的目录名，同一任务的每次执行使用不同的 cgroup
+func cgroupName(task *models.Task) string {
+	if task.AttemptID == "" {
+		return "task-" + task.ID
+	}
+	return "task-" + task.ID + "-" + task.AttemptID
+}
+
+// rejectTask 将未能启动的任务标记为失败
+func rejectTask(task *models.Task, msg string) {
+	task.Status = models.TaskStatusFailed
+	task.Error = msg
+	task.ExitCode = -1
+	task.EndedAt = time.Now()
+}
+
+// Progress 返回运行中任务最近一次输出的进度标记
+func (e *Executor) Progress(taskID string) (Progress, bool) {
+	e.mu.Lock()
+	rt, exists := e.running[taskID]
+	e.mu.Unlock()
+
+	if !exists || rt.progress == nil {
+		return Progress{}, false
+	}
+	return rt.progress.Latest()
+}
+
 // Cancel 取消任务
 func (e *Executor) Cancel(taskID string) bool {
 	e.mu.Lock()
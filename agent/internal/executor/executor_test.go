@@ -1,10 +1,16 @@
 package executor
 
 import (
+	"errors"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/YoungBoyGod/remotegpu-agent/internal/logship"
 	"github.com/YoungBoyGod/remotegpu-agent/internal/models"
 	"github.com/YoungBoyGod/remotegpu-agent/internal/security"
 )
@@ -93,9 +99,12 @@ func TestLimitedWriterExceedsLimit(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Write 不应返回错误: %v", err)
 	}
-	// 首次截断时返回实际写入的字节数（remaining），后续满后才返回 len(p)
-	if n != 10 {
-		t.Errorf("首次截断应返回 remaining 字节数 10，实际为 %d", n)
+	// 截断时也返回 len(p)，避免 io.MultiWriter 报 ErrShortWrite
+	if n != len(data) {
+		t.Errorf("截断时应返回 %d，实际为 %d", len(data), n)
+	}
+	if w.buf.Len() != 10 {
+		t.Errorf("缓冲区应为 10 字节，实际为 %d", w.buf.Len())
 	}
 	if !w.dropped {
 		t.Error("超限后 dropped 应为 true")
@@ -121,6 +130,34 @@ func TestLimitedWriterMultipleWrites(t *testing.T) {
 	}
 }
 
+func TestExecuteOutputExceedsLimit(t *testing.T) {
+	e := NewExecutor(1)
+	e.SetLogDir(t.TempDir())
+
+	// 输出超过内存上限后任务仍应正常结束，缓存文件保留完整输出
+	task := &models.Task{
+		ID:        "big1",
+		AttemptID: "a1",
+		Command:   "head -c 3145728 /dev/zero | tr '\\0' x; echo done",
+		Timeout:   30,
+	}
+	e.Execute(task)
+
+	if task.Status != models.TaskStatusCompleted {
+		t.Fatalf("任务应为 completed，实际为 %s, error: %s", task.Status, task.Error)
+	}
+	if !strings.Contains(task.Stdout, "truncated") {
+		t.Error("内存中的 stdout 应被截断")
+	}
+	info, err := os.Stat(logship.SpoolPath(e.LogDir(), task.ID, task.AttemptID, "stdout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := int64(3<<20 + len("done\n")); info.Size() != want {
+		t.Errorf("缓存文件应为 %d 字节，实际为 %d", want, info.Size())
+	}
+}
+
 func TestValidatorRejectsCommand(t *testing.T) {
 	e := NewExecutor(2)
 	v := security.NewValidator([]string{"echo", "ls"}, nil)
@@ -316,3 +353,136 @@ func TestExecuteStderr(t *testing.T) {
 		t.Errorf("stderr 应包含 error_msg，实际为 %q", task.Stderr)
 	}
 }
+
+// fakeDeviceGroup 记录任务是否通过设备 cgroup 启动
+type fakeDeviceGroup struct {
+	started bool
+	removed bool
+}
+
+func (g *fakeDeviceGroup) Start(cmd *exec.Cmd) error {
+	g.started = true
+	return cmd.Start()
+}
+
+func (g *fakeDeviceGroup) Remove() error {
+	g.removed = true
+	return nil
+}
+
+func TestExecuteRunAsTenantCannotReadOtherHome(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("需要 root 权限切换任务账号")
+	}
+	base := t.TempDir()
+	// t.TempDir 创建的目录只有 root 可进入，放开以便测试账号访问自己的家目录
+	for dir := base; dir != os.TempDir(); dir = filepath.Dir(dir) {
+		if err := os.Chmod(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ownHome := filepath.Join(base, "rg-c1")
+	otherHome := filepath.Join(base, "rg-c2")
+	for uid, home := range map[int]string{60001: ownHome, 60002: otherHome} {
+		if err := os.Mkdir(home, 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chown(home, uid, uid); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(otherHome, "secret"), []byte("s3cret"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	os.Chown(filepath.Join(otherHome, "secret"), 60002, 60002)
+
+	e := NewExecutor(2)
+	e.lookupTenant = func(username string) (*user.User, error) {
+		return &user.User{Uid: "60001", Gid: "60001", Username: username, HomeDir: ownHome}, nil
+	}
+	group := &fakeDeviceGroup{}
+	e.newDeviceGroup = func(name string, gpus []int) (deviceGroup, error) {
+		return group, nil
+	}
+
+	own := &models.Task{ID: "t-own", Command: "id -u && pwd && echo ok > own.txt", Timeout: 10, RunAs: "rg-c1"}
+	e.Execute(own)
+	if own.Status != models.TaskStatusCompleted {
+		t.Fatalf("任务应能在自己的家目录中运行: %s %s", own.Error, own.Stderr)
+	}
+	if !strings.Contains(own.Stdout, "60001") || !strings.Contains(own.Stdout, ownHome) {
+		t.Errorf("任务应以租户账号在其家目录中运行, got %q", own.Stdout)
+	}
+	if !group.started || !group.removed {
+		t.Error("任务应在设备 cgroup 中启动并在结束后删除 cgroup")
+	}
+
+	other := &models.Task{ID: "t-other", Command: "ls " + otherHome + " || cat " + filepath.Join(otherHome, "secret"), Timeout: 10, RunAs: "rg-c1"}
+	e.Execute(other)
+	if other.Status != models.TaskStatusFailed {
+		t.Fatalf("任务不应能访问其他租户的家目录, stdout=%q", other.Stdout)
+	}
+	if strings.Contains(other.Stdout, "secret") || strings.Contains(other.Stdout, "s3cret") {
+		t.Errorf("其他租户的文件被泄露: %q", other.Stdout)
+	}
+	if !strings.Contains(other.Stderr, "Permission denied") {
+		t.Errorf("expected permission denied, got %q", other.Stderr)
+	}
+}
+
+func TestExecuteRunAsDoesNotInheritAgentEnv(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("需要 root 权限切换任务账号")
+	}
+	t.Setenv("REMOTEGPU_AGENT_SECRET", "leak")
+	home := t.TempDir()
+
+	e := NewExecutor(2)
+	e.lookupTenant = func(username string) (*user.User, error) {
+		return &user.User{Uid: "0", Gid: "0", Username: username, HomeDir: home}, nil
+	}
+	e.newDeviceGroup = func(name string, gpus []int) (deviceGroup, error) {
+		return &fakeDeviceGroup{}, nil
+	}
+
+	task := &models.Task{ID: "t-env", Command: "env", Timeout: 10, RunAs: "rg-c1", Env: map[string]string{"CUDA_VISIBLE_DEVICES": "1"}}
+	e.Execute(task)
+	if task.Status != models.TaskStatusCompleted {
+		t.Fatalf("expected completed, got %s: %s", task.Status, task.Error)
+	}
+	if strings.Contains(task.Stdout, "REMOTEGPU_AGENT_SECRET") {
+		t.Error("任务不应继承 Agent 的环境变量")
+	}
+	if !strings.Contains(task.Stdout, "CUDA_VISIBLE_DEVICES=1") || !strings.Contains(task.Stdout, "HOME="+home) {
+		t.Errorf("unexpected env: %q", task.Stdout)
+	}
+}
+
+func TestExecuteRunAsFailsClosed(t *testing.T) {
+	marker := filepath.Join(t.TempDir(), "ran")
+
+	// 非受管账号
+	e := NewExecutor(2)
+	task := &models.Task{ID: "t-root", Command: "touch " + marker, Timeout: 10, RunAs: "root"}
+	e.Execute(task)
+	if task.Status != models.TaskStatusFailed || !strings.Contains(task.Error, "run as tenant") {
+		t.Errorf("非受管账号应拒绝执行, got %s %q", task.Status, task.Error)
+	}
+
+	// 设备 cgroup 创建失败
+	e.lookupTenant = func(username string) (*user.User, error) {
+		return &user.User{Uid: "60001", Gid: "60001", Username: username, HomeDir: "/"}, nil
+	}
+	e.newDeviceGroup = func(name string, gpus []int) (deviceGroup, error) {
+		return nil, errors.New("no cgroup")
+	}
+	task = &models.Task{ID: "t-nocg", Command: "touch " + marker, Timeout: 10, RunAs: "rg-c1"}
+	e.Execute(task)
+	if task.Status != models.TaskStatusFailed || !strings.Contains(task.Error, "device cgroup") {
+		t.Errorf("设备 cgroup 创建失败时应拒绝执行, got %s %q", task.Status, task.Error)
+	}
+
+	if _, err := os.Stat(marker); !os.IsNotExist(err) {
+		t.Error("被拒绝的任务不应执行命令")
+	}
+}
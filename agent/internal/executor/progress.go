@@ -0,0 +1,109 @@
+package executor
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// ProgressMarker 任务输出中的进度标记前缀
+// 任务向 stdout 打印 "##progress <percent> [message]" 即可上报进度，例如：
+//
+//	##progress 42 epoch 3/10
+const ProgressMarker = "##progress"
+
+// maxProgressLine 单行最大缓存长度，超长行不可能是进度标记，直接丢弃
+const maxProgressLine = 4096
+
+// maxProgressMessage 进度说明的最大字符数，与 Server 端 progress_message 字段长度一致
+const maxProgressMessage = 500
+
+// Progress 任务进度
+type Progress struct {
+	Percent int
+	Message string
+}
+
+// ParseProgressLine 解析进度标记行，percent 支持小数并截断到 0-100
+func ParseProgressLine(line string) (Progress, bool) {
+	line = strings.TrimSpace(line)
+	rest, ok := strings.CutPrefix(line, ProgressMarker)
+	if !ok || (rest != "" && rest[0] != ' ' && rest[0] != '\t') {
+		return Progress{}, false
+	}
+	fields := strings.Fields(rest)
+	if len(fields) == 0 {
+		return Progress{}, false
+	}
+	pct, err := strconv.ParseFloat(strings.TrimSuffix(fields[0], "%"), 64)
+	if err != nil {
+		return Progress{}, false
+	}
+	if pct < 0 {
+		pct = 0
+	} else if pct > 100 {
+		pct = 100
+	}
+
+	msg := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(rest), fields[0]))
+	// 按字符截断，避免截断多字节字符产生非法 UTF-8
+	if utf8.RuneCountInString(msg) > maxProgressMessage {
+		msg = string([]rune(msg)[:maxProgressMessage])
+	}
+	return Progress{Percent: int(pct), Message: msg}, true
+}
+
+// progressWriter 按行扫描任务输出，记录最近一次进度标记
+type progressWriter struct {
+	mu      sync.Mutex
+	line    []byte
+	skip    bool // 当前行超长，丢弃到下一个换行
+	latest  Progress
+	updated bool
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	data := p
+	for len(data) > 0 {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			w.appendPartial(data)
+			break
+		}
+		w.appendPartial(data[:i])
+		if !w.skip {
+			if prog, ok := ParseProgressLine(string(w.line)); ok {
+				w.latest = prog
+				w.updated = true
+			}
+		}
+		w.line = w.line[:0]
+		w.skip = false
+		data = data[i+1:]
+	}
+	return len(p), nil
+}
+
+func (w *progressWriter) appendPartial(b []byte) {
+	if w.skip {
+		return
+	}
+	if len(w.line)+len(b) > maxProgressLine {
+		w.line = w.line[:0]
+		w.skip = true
+		return
+	}
+	w.line = append(w.line, b...)
+}
+
+// Latest 返回最近一次进度，任务未输出过进度标记时 ok 为 false
+func (w *progressWriter) Latest() (Progress, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.latest, w.updated
+}
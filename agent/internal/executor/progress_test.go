@@ -0,0 +1,161 @@
+package executor
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/YoungBoyGod/remotegpu-agent/internal/models"
+)
+
+func TestParseProgressLine(t *testing.T) {
+	cases := []struct {
+		line    string
+		ok      bool
+		percent int
+		message string
+	}{
+		{"##progress 42 epoch 3/10", true, 42, "epoch 3/10"},
+		{"##progress 12.7%", true, 12, ""},
+		{"  ##progress 100 done  ", true, 100, "done"},
+		{"##progress 150 overflow", true, 100, "overflow"},
+		{"##progress -5", true, 0, ""},
+		{"##progress", false, 0, ""},
+		{"##progress abc", false, 0, ""},
+		{"##progressive 10", false, 0, ""},
+		{"loss=0.1 ##progress 10", false, 0, ""},
+	}
+	for _, c := range cases {
+		p, ok := ParseProgressLine(c.line)
+		if ok != c.ok {
+			t.Errorf("%q: expected ok=%v, got %v", c.line, c.ok, ok)
+			continue
+		}
+		if ok && (p.Percent != c.percent || p.Message != c.message) {
+			t.Errorf("%q: expected (%d, %q), got (%d, %q)", c.line, c.percent, c.message, p.Percent, p.Message)
+		}
+	}
+}
+
+func TestParseProgressLineTruncatesByRune(t *testing.T) {
+	p, ok := ParseProgressLine("##progress 50 " + strings.Repeat("训练中", 300))
+	if !ok {
+		t.Fatal("expected progress line")
+	}
+	if n := utf8.RuneCountInString(p.Message); n != maxProgressMessage {
+		t.Errorf("expected %d runes, got %d", maxProgressMessage, n)
+	}
+	if !utf8.ValidString(p.Message) {
+		t.Error("truncated message is not valid UTF-8")
+	}
+	if !strings.HasPrefix(p.Message, "训练中训练中") {
+		t.Errorf("unexpected message prefix %q", p.Message[:18])
+	}
+}
+
+func TestProgressWriterSplitWrites(t *testing.T) {
+	w := &progressWriter{}
+	if _, ok := w.Latest(); ok {
+		t.Fatal("expected no progress before any marker")
+	}
+
+	w.Write([]byte("training...\n##prog"))
+	w.Write([]byte("ress 30 epoch 1"))
+	if _, ok := w.Latest(); ok {
+		t.Fatal("incomplete line should not be parsed")
+	}
+	w.Write([]byte("/3\nloss=0.5\n##progress 60 epoch 2/3\n"))
+
+	p, ok := w.Latest()
+	if !ok || p.Percent != 60 || p.Message != "epoch 2/3" {
+		t.Errorf("unexpected progress: %+v ok=%v", p, ok)
+	}
+}
+
+func TestProgressWriterSkipsLongLine(t *testing.T) {
+	w := &progressWriter{}
+	long := make([]byte, maxProgressLine+10)
+	for i := range long {
+		long[i] = 'x'
+	}
+	w.Write([]byte("##progress 10 "))
+	w.Write(long)
+	w.Write([]byte("\n"))
+	if _, ok := w.Latest(); ok {
+		t.Fatal("overlong line should be ignored")
+	}
+
+	w.Write([]byte("##progress 20\n"))
+	if p, ok := w.Latest(); !ok || p.Percent != 20 {
+		t.Errorf("unexpected progress after long line: %+v ok=%v", p, ok)
+	}
+}
+
+func TestExecutorProgress(t *testing.T) {
+	e := NewExecutor(1)
+	task := &models.Task{
+		ID:      "t-progress",
+		Command: "echo '##progress 25 step 1'; sleep 1",
+		Timeout: 10,
+	}
+
+	done := make(chan struct{})
+	go func() {
+		e.Execute(task)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		if p, ok := e.Progress(task.ID); ok {
+			if p.Percent != 25 || p.Message != "step 1" {
+				t.Errorf("unexpected progress: %+v", p)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("progress not observed while task running")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	<-done
+
+	if _, ok := e.Progress(task.ID); ok {
+		t.Error("expected no progress after task finished")
+	}
+}
+
+func TestExecutorProgressAfterOutputLimit(t *testing.T) {
+	e := NewExecutor(1)
+	task := &models.Task{
+		ID:      "t-progress-big",
+		Command: "head -c 2097152 /dev/zero | tr '\\0' x; echo; echo '##progress 80 tail'; sleep 1",
+		Timeout: 30,
+	}
+
+	done := make(chan struct{})
+	go func() {
+		e.Execute(task)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		if p, ok := e.Progress(task.ID); ok {
+			if p.Percent != 80 || p.Message != "tail" {
+				t.Errorf("unexpected progress: %+v", p)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("progress after 1MB of output not observed")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	<-done
+
+	if task.Status != models.TaskStatusCompleted {
+		t.Errorf("expected completed, got %s: %s", task.Status, task.Error)
+	}
+}
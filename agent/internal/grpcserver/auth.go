@@ -0,0 +1,116 @@
+package grpcserver
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"strings"
+
+	pb "github.com/YoungBoyGod/remotegpu-agent/api/proto/agent"
+	"github.com/YoungBoyGod/remotegpu-agent/internal/security"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// SignMethod gRPC 请求签名使用的 method，uri 为完整方法名，body 为请求消息的确定性序列化结果
+const SignMethod = "GRPC"
+
+// UnaryAuth 一元调用认证拦截器，规则与 HTTP 控制接口相同
+func UnaryAuth(verifier *security.RequestVerifier) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := authorize(ctx, verifier, info.FullMethod, req); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamAuth 流式调用认证拦截器，在读取首条请求消息时校验签名
+func StreamAuth(verifier *security.RequestVerifier) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &authStream{ServerStream: ss, verifier: verifier, method: info.FullMethod})
+	}
+}
+
+type authStream struct {
+	grpc.ServerStream
+	verifier *security.RequestVerifier
+	method   string
+	verified bool
+}
+
+func (s *authStream) RecvMsg(m any) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	if s.verified {
+		return nil
+	}
+	if err := authorize(s.Context(), s.verifier, s.method, m); err != nil {
+		return err
+	}
+	s.verified = true
+	return nil
+}
+
+// authorize 校验请求签名；verifier 为 nil（未配置密钥）时仅允许本机连接
+func authorize(ctx context.Context, verifier *security.RequestVerifier, method string, req any) error {
+	// 与 HTTP /api/v1/ping 一致，健康检查无需认证
+	if method == pb.AgentService_Ping_FullMethodName {
+		return nil
+	}
+
+	var remote string
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		remote = p.Addr.String()
+	}
+	if verifier == nil {
+		if !isLoopback(remote) {
+			return status.Error(codes.Unauthenticated, "control API only accepts loopback requests")
+		}
+		return nil
+	}
+
+	msg, ok := req.(proto.Message)
+	if !ok {
+		return status.Error(codes.Internal, "unexpected request type")
+	}
+	body, err := proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	err = verifier.Verify(SignMethod, method,
+		firstValue(md, security.HeaderTimestamp),
+		firstValue(md, security.HeaderNonce),
+		firstValue(md, security.HeaderSignature),
+		body,
+	)
+	if err != nil {
+		slog.Warn("reject control request", "method", method, "remote", remote, "error", err)
+		return status.Error(codes.Unauthenticated, err.Error())
+	}
+	return nil
+}
+
+func firstValue(md metadata.MD, key string) string {
+	if v := md.Get(strings.ToLower(key)); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+// isLoopback 按连接地址判断是否为本机请求
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
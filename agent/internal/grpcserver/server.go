@@ -0,0 +1,340 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	pb "github.com/YoungBoyGod/remotegpu-agent/api/proto/agent"
+	"github.com/YoungBoyGod/remotegpu-agent/internal/control"
+	agentErrors "github.com/YoungBoyGod/remotegpu-agent/internal/errors"
+	"github.com/YoungBoyGod/remotegpu-agent/internal/logship"
+	"github.com/YoungBoyGod/remotegpu-agent/internal/models"
+	"github.com/YoungBoyGod/remotegpu-agent/internal/mount"
+	"github.com/YoungBoyGod/remotegpu-agent/internal/sshkeys"
+	"github.com/YoungBoyGod/remotegpu-agent/internal/tenant"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// logChunkSize 单条日志消息的最大字节数
+	logChunkSize = 64 * 1024
+	// mountTimeout 单次挂载/卸载操作超时
+	mountTimeout = 2 * time.Minute
+)
+
+// TaskSource 任务查询接口（由 Scheduler 实现）
+type TaskSource interface {
+	GetTask(id string) (*models.Task, error)
+}
+
+// Server 实现 AgentService gRPC 服务，与 HTTP 控制接口提供相同的能力
+type Server struct {
+	pb.UnimplementedAgentServiceServer
+
+	version      string
+	mounter      mount.Mounter
+	tenants      tenant.Syncer
+	tasks        TaskSource
+	logDir       string
+	pollInterval time.Duration
+}
+
+// NewServer 创建 gRPC 服务，logDir 为任务输出缓存目录
+func NewServer(version string, mounter mount.Mounter, tenants tenant.Syncer, tasks TaskSource, logDir string) *Server {
+	return &Server{
+		version:      version,
+		mounter:      mounter,
+		tenants:      tenants,
+		tasks:        tasks,
+		logDir:       logDir,
+		pollInterval: time.Second,
+	}
+}
+
+func ok() *pb.Response {
+	return &pb.Response{Success: true, Message: "ok"}
+}
+
+func fail(code int, err error) *pb.Response {
+	return &pb.Response{Success: false, Code: int32(code), Message: err.Error()}
+}
+
+// StopProcess 停止进程
+func (s *Server) StopProcess(ctx context.Context, req *pb.StopProcessRequest) (*pb.Response, error) {
+	if err := control.StopProcess(int(req.ProcessId), req.Signal); err != nil {
+		return fail(agentErrors.ErrInternal, err), nil
+	}
+	return ok(), nil
+}
+
+// ResetSSH 清空平台管理的公钥，指定 public_key 时替换为该公钥
+func (s *Server) ResetSSH(ctx context.Context, req *pb.ResetSSHRequest) (*pb.Response, error) {
+	var keys []string
+	if req.PublicKey != "" {
+		keys = []string{req.PublicKey}
+	}
+	return syncKeys(req.Username, keys), nil
+}
+
+// SyncSSHKeys 全量同步共享账号的平台公钥和 GPU 粒度分配客户的独立账号
+func (s *Server) SyncSSHKeys(ctx context.Context, req *pb.SyncSSHKeysRequest) (*pb.Response, error) {
+	accounts := make([]tenant.Account, 0, len(req.Tenants))
+	for _, t := range req.Tenants {
+		indexes := make([]int, len(t.GpuIndexes))
+		for i, idx := range t.GpuIndexes {
+			indexes[i] = int(idx)
+		}
+		accounts = append(accounts, tenant.Account{Username: t.Username, PublicKeys: t.PublicKeys, GPUIndexes: indexes})
+	}
+	if err := tenant.Validate(accounts); err != nil {
+		return fail(agentErrors.ErrInvalidParams, err), nil
+	}
+	if resp := syncKeys(req.Username, req.PublicKeys); !resp.Success {
+		return resp, nil
+	}
+	if s.tenants == nil {
+		if len(accounts) > 0 {
+			return fail(agentErrors.ErrInternal, errors.New("tenant accounts not supported")), nil
+		}
+		return ok(), nil
+	}
+	if err := s.tenants.Sync(ctx, accounts); err != nil {
+		return fail(agentErrors.ErrInternal, err), nil
+	}
+	return ok(), nil
+}
+
+func syncKeys(username string, keys []string) *pb.Response {
+	target, err := sshkeys.Resolve(username)
+	if err != nil {
+		return fail(agentErrors.ErrInvalidParams, err)
+	}
+	if err := sshkeys.Sync(target, keys); err != nil {
+		if errors.Is(err, sshkeys.ErrInvalidKey) {
+			return fail(agentErrors.ErrInvalidParams, err)
+		}
+		return fail(agentErrors.ErrInternal, err)
+	}
+	return ok()
+}
+
+// CleanupMachine 清理机器
+func (s *Server) CleanupMachine(ctx context.Context, req *pb.CleanupRequest) (*pb.Response, error) {
+	control.Cleanup(ctx, req.CleanupTypes, s.tenants)
+	return ok(), nil
+}
+
+// MountDataset 挂载数据集
+func (s *Server) MountDataset(ctx context.Context, req *pb.MountDatasetRequest) (*pb.Response, error) {
+	ctx, cancel := context.WithTimeout(ctx, mountTimeout)
+	defer cancel()
+	err := s.mounter.Mount(ctx, &mount.Request{
+		SourceType: req.SourceType,
+		Source:     req.SourcePath,
+		MountPoint: req.MountPoint,
+		ReadOnly:   req.ReadOnly,
+	})
+	return mountResponse(err), nil
+}
+
+// UnmountDataset 卸载数据集
+func (s *Server) UnmountDataset(ctx context.Context, req *pb.UnmountDatasetRequest) (*pb.Response, error) {
+	ctx, cancel := context.WithTimeout(ctx, mountTimeout)
+	defer cancel()
+	return mountResponse(s.mounter.Unmount(ctx, req.MountPoint)), nil
+}
+
+func mountResponse(err error) *pb.Response {
+	switch {
+	case err == nil:
+		return ok()
+	case errors.Is(err, mount.ErrInvalidRequest), errors.Is(err, mount.ErrMountPointDenied), errors.Is(err, mount.ErrS3NotConfigured):
+		return fail(agentErrors.ErrInvalidParams, err)
+	default:
+		return fail(agentErrors.ErrMountFailed, err)
+	}
+}
+
+// GetSystemInfo 获取系统信息
+func (s *Server) GetSystemInfo(ctx context.Context, req *pb.SystemInfoRequest) (*pb.SystemInfo, error) {
+	info := control.CollectSystemInfo()
+	return &pb.SystemInfo{
+		Hostname:    info.Hostname,
+		Os:          info.OS,
+		Kernel:      info.Kernel,
+		CpuCores:    int32(info.CPUCores),
+		MemoryTotal: info.MemoryTotal,
+		MemoryFree:  info.MemoryFree,
+		DiskTotal:   info.DiskTotal,
+		DiskFree:    info.DiskFree,
+		Uptime:      int64(info.Uptime),
+	}, nil
+}
+
+// ExecuteCommand 执行命令，非零退出码通过 exit_code 返回
+func (s *Server) ExecuteCommand(ctx context.Context, req *pb.ExecuteCommandRequest) (*pb.ExecuteCommandResponse, error) {
+	ctx, cancel := commandContext(ctx, req.Timeout)
+	defer cancel()
+
+	var stdout, stderr limitedBuffer
+	exitCode, err := control.RunCommand(ctx, req.Command, "", &stdout, &stderr)
+	if err != nil && exitCode == -1 {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &pb.ExecuteCommandResponse{
+		ExitCode: int32(exitCode),
+		Stdout:   stdout.String(),
+		Stderr:   stderr.String(),
+	}, nil
+}
+
+// ExecuteCommandStream 执行命令并实时推送输出，最后一条消息携带退出码
+func (s *Server) ExecuteCommandStream(req *pb.ExecuteCommandRequest, stream pb.AgentService_ExecuteCommandStreamServer) error {
+	ctx, cancel := commandContext(stream.Context(), req.Timeout)
+	defer cancel()
+
+	sender := &streamSender{stream: stream}
+	exitCode, err := control.RunCommand(ctx, req.Command, "",
+		sender.writer("stdout"), sender.writer("stderr"))
+	if sender.err != nil {
+		return sender.err
+	}
+	if err != nil && exitCode == -1 {
+		return status.Error(codes.Internal, err.Error())
+	}
+	return stream.Send(&pb.CommandOutput{Done: true, ExitCode: int32(exitCode)})
+}
+
+// StreamTaskLogs 从 offset 开始推送任务输出；follow 时持续推送直到任务结束
+func (s *Server) StreamTaskLogs(req *pb.TaskLogsRequest, stream pb.AgentService_StreamTaskLogsServer) error {
+	if req.Stream != "stdout" && req.Stream != "stderr" {
+		return status.Error(codes.InvalidArgument, "stream must be stdout or stderr")
+	}
+	if req.Offset < 0 {
+		return status.Error(codes.InvalidArgument, "offset must not be negative")
+	}
+
+	offset := req.Offset
+	for {
+		task, err := s.tasks.GetTask(req.TaskId)
+		if err != nil || task == nil {
+			return status.Error(codes.NotFound, "task not found")
+		}
+		done := !req.Follow || isFinished(task.Status)
+
+		data, err := s.readLog(task, req.Stream, offset)
+		if err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+		for len(data) > 0 {
+			n := min(len(data), logChunkSize)
+			if err := stream.Send(&pb.TaskLogChunk{Stream: req.Stream, Offset: offset, Data: data[:n]}); err != nil {
+				return err
+			}
+			offset += int64(n)
+			data = data[n:]
+		}
+
+		if done {
+			return stream.Send(&pb.TaskLogChunk{Stream: req.Stream, Offset: offset, Eof: true})
+		}
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case <-time.After(s.pollInterval):
+		}
+	}
+}
+
+// readLog 读取 offset 之后的输出：运行中的 Server 任务读取输出缓存文件，否则读取已保存的输出
+func (s *Server) readLog(task *models.Task, stream string, offset int64) ([]byte, error) {
+	if s.logDir != "" && task.AttemptID != "" {
+		f, err := os.Open(logship.SpoolPath(s.logDir, task.ID, task.AttemptID, stream))
+		if err == nil {
+			defer f.Close()
+			if _, err := f.Seek(offset, io.SeekStart); err != nil {
+				return nil, err
+			}
+			return io.ReadAll(f)
+		}
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+
+	output := task.Stdout
+	if stream == "stderr" {
+		output = task.Stderr
+	}
+	if offset >= int64(len(output)) {
+		return nil, nil
+	}
+	return []byte(output[offset:]), nil
+}
+
+// Ping 健康检查
+func (s *Server) Ping(ctx context.Context, req *pb.PingRequest) (*pb.PingResponse, error) {
+	return &pb.PingResponse{Ok: true, Version: s.version}, nil
+}
+
+func isFinished(st models.TaskStatus) bool {
+	return st == models.TaskStatusCompleted || st == models.TaskStatusFailed || st == models.TaskStatusCancelled
+}
+
+func commandContext(ctx context.Context, timeoutSec int32) (context.Context, context.CancelFunc) {
+	timeout := time.Duration(timeoutSec) * time.Second
+	if timeout <= 0 {
+		timeout = control.DefaultCommandTimeout
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// streamSender 将 stdout/stderr 的写入转为流消息，gRPC 流不支持并发 Send
+type streamSender struct {
+	mu     sync.Mutex
+	stream pb.AgentService_ExecuteCommandStreamServer
+	err    error
+}
+
+func (s *streamSender) writer(name string) io.Writer {
+	return writerFunc(func(p []byte) (int, error) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.err != nil {
+			return 0, s.err
+		}
+		// p 在返回后可能被复用，需要拷贝
+		data := append([]byte(nil), p...)
+		if err := s.stream.Send(&pb.CommandOutput{Stream: name, Data: data}); err != nil {
+			s.err = err
+			return 0, err
+		}
+		return len(p), nil
+	})
+}
+
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) { return f(p) }
+
+// maxCommandOutput 非流式执行时单个输出的最大缓存字节数
+const maxCommandOutput = 1 << 20
+
+// limitedBuffer 超过上限后丢弃后续输出
+type limitedBuffer struct {
+	buf []byte
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := maxCommandOutput - len(b.buf); room > 0 {
+		b.buf = append(b.buf, p[:min(len(p), room)]...)
+	}
+	return len(p), nil
+}
+
+func (b *limitedBuffer) String() string { return string(b.buf) }
@@ -0,0 +1,257 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	pb "github.com/YoungBoyGod/remotegpu-agent/api/proto/agent"
+	"github.com/YoungBoyGod/remotegpu-agent/internal/logship"
+	"github.com/YoungBoyGod/remotegpu-agent/internal/models"
+	"github.com/YoungBoyGod/remotegpu-agent/internal/security"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+)
+
+const testKey = "test-control-key"
+
+type fakeTasks struct {
+	mu   sync.Mutex
+	task *models.Task
+}
+
+func (f *fakeTasks) GetTask(id string) (*models.Task, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.task == nil || f.task.ID != id {
+		return nil, errors.New("not found")
+	}
+	t := *f.task
+	return &t, nil
+}
+
+// signContext 按 Server 侧规则为请求签名
+func signContext(ctx context.Context, key, method string, req proto.Message) context.Context {
+	body, _ := proto.MarshalOptions{Deterministic: true}.Marshal(req)
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	nonce := strconv.FormatInt(time.Now().UnixNano(), 36)
+	return metadata.AppendToOutgoingContext(ctx,
+		security.HeaderTimestamp, ts,
+		security.HeaderNonce, nonce,
+		security.HeaderSignature, security.Sign(key, SignMethod, method, ts, nonce, body),
+	)
+}
+
+func startServer(t *testing.T, verifier *security.RequestVerifier, svc *Server) *grpc.ClientConn {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(UnaryAuth(verifier)),
+		grpc.ChainStreamInterceptor(StreamAuth(verifier)),
+	)
+	pb.RegisterAgentServiceServer(srv, svc)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestUnaryAuth(t *testing.T) {
+	conn := startServer(t, security.NewRequestVerifier(testKey, 0), NewServer("test", nil, nil, &fakeTasks{}, ""))
+	client := pb.NewAgentServiceClient(conn)
+	ctx := context.Background()
+
+	// 健康检查无需签名
+	if resp, err := client.Ping(ctx, &pb.PingRequest{}); err != nil || resp.Version != "test" {
+		t.Fatalf("ping: %v %v", resp, err)
+	}
+
+	req := &pb.ExecuteCommandRequest{Command: "echo hi"}
+	if _, err := client.ExecuteCommand(ctx, req); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("unsigned request: expected Unauthenticated, got %v", err)
+	}
+	if _, err := client.ExecuteCommand(signContext(ctx, "wrong", pb.AgentService_ExecuteCommand_FullMethodName, req), req); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("wrong key: expected Unauthenticated, got %v", err)
+	}
+
+	// 签名覆盖请求内容，替换请求体后签名失效
+	signed := signContext(ctx, testKey, pb.AgentService_ExecuteCommand_FullMethodName, req)
+	if _, err := client.ExecuteCommand(signed, &pb.ExecuteCommandRequest{Command: "id"}); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("tampered body: expected Unauthenticated, got %v", err)
+	}
+
+	resp, err := client.ExecuteCommand(signContext(ctx, testKey, pb.AgentService_ExecuteCommand_FullMethodName, req), req)
+	if err != nil {
+		t.Fatalf("signed request: %v", err)
+	}
+	if resp.ExitCode != 0 || resp.Stdout != "hi\n" {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+}
+
+func TestNoKeyRejectsRemotePeers(t *testing.T) {
+	// bufconn 连接地址不是回环地址
+	conn := startServer(t, nil, NewServer("test", nil, nil, &fakeTasks{}, ""))
+	client := pb.NewAgentServiceClient(conn)
+	if _, err := client.GetSystemInfo(context.Background(), &pb.SystemInfoRequest{}); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("expected Unauthenticated, got %v", err)
+	}
+}
+
+func TestExecuteCommandStream(t *testing.T) {
+	conn := startServer(t, security.NewRequestVerifier(testKey, 0), NewServer("test", nil, nil, &fakeTasks{}, ""))
+	client := pb.NewAgentServiceClient(conn)
+	ctx := context.Background()
+	req := &pb.ExecuteCommandRequest{Command: "echo out; echo err >&2; exit 3"}
+
+	stream, err := client.ExecuteCommandStream(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Recv(); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("unsigned stream: expected Unauthenticated, got %v", err)
+	}
+
+	stream, err = client.ExecuteCommandStream(signContext(ctx, testKey, pb.AgentService_ExecuteCommandStream_FullMethodName, req), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := map[string]string{}
+	var last *pb.CommandOutput
+	for {
+		msg, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		output[msg.Stream] += string(msg.Data)
+		last = msg
+	}
+	if output["stdout"] != "out\n" || output["stderr"] != "err\n" {
+		t.Fatalf("unexpected output: %v", output)
+	}
+	if last == nil || !last.Done || last.ExitCode != 3 {
+		t.Fatalf("expected final message with exit code 3, got %+v", last)
+	}
+}
+
+func TestStreamTaskLogsFollow(t *testing.T) {
+	dir := t.TempDir()
+	tasks := &fakeTasks{task: &models.Task{ID: "t1", AttemptID: "a1", Status: models.TaskStatusRunning}}
+	svc := NewServer("test", nil, nil, tasks, dir)
+	svc.pollInterval = 10 * time.Millisecond
+	conn := startServer(t, security.NewRequestVerifier(testKey, 0), svc)
+	client := pb.NewAgentServiceClient(conn)
+
+	spool := logship.SpoolPath(dir, "t1", "a1", "stdout")
+	if err := os.WriteFile(spool, []byte("line1\nline2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	req := &pb.TaskLogsRequest{TaskId: "t1", Stream: "stdout", Offset: 6, Follow: true}
+	ctx := signContext(context.Background(), testKey, pb.AgentService_StreamTaskLogs_FullMethodName, req)
+	stream, err := client.StreamTaskLogs(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(first.Data) != "line2\n" || first.Offset != 6 {
+		t.Fatalf("unexpected first chunk: %+v", first)
+	}
+
+	// 追加输出后任务结束，输出缓存已上报删除，剩余部分从保存的输出读取
+	f, err := os.OpenFile(spool, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("line3\n")
+	f.Close()
+
+	var data string
+	var eof *pb.TaskLogChunk
+	for eof == nil {
+		msg, err := stream.Recv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data += string(msg.Data)
+		if msg.Eof {
+			eof = msg
+		}
+		if data == "line3\n" {
+			os.Remove(spool)
+			tasks.mu.Lock()
+			tasks.task.Stdout = "line1\nline2\nline3\nline4\n"
+			tasks.task.Status = models.TaskStatusCompleted
+			tasks.mu.Unlock()
+		}
+	}
+	if data != "line3\nline4\n" || eof.Offset != 24 {
+		t.Fatalf("unexpected tail %q, eof %+v", data, eof)
+	}
+	if _, err := stream.Recv(); err != io.EOF {
+		t.Fatalf("expected end of stream, got %v", err)
+	}
+}
+
+func TestStreamTaskLogsNoFollow(t *testing.T) {
+	tasks := &fakeTasks{task: &models.Task{ID: "t1", Status: models.TaskStatusRunning, Stderr: "boom\n"}}
+	conn := startServer(t, security.NewRequestVerifier(testKey, 0), NewServer("test", nil, nil, tasks, t.TempDir()))
+	client := pb.NewAgentServiceClient(conn)
+
+	req := &pb.TaskLogsRequest{TaskId: "t1", Stream: "stderr"}
+	ctx := signContext(context.Background(), testKey, pb.AgentService_StreamTaskLogs_FullMethodName, req)
+	stream, err := client.StreamTaskLogs(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var data string
+	for {
+		msg, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		data += string(msg.Data)
+	}
+	if data != "boom\n" {
+		t.Fatalf("unexpected output %q", data)
+	}
+
+	missing := &pb.TaskLogsRequest{TaskId: "nope", Stream: "stdout"}
+	ctx = signContext(context.Background(), testKey, pb.AgentService_StreamTaskLogs_FullMethodName, missing)
+	stream, err = client.StreamTaskLogs(ctx, missing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Recv(); status.Code(err) != codes.NotFound {
+		t.Fatalf("expected NotFound, got %v", err)
+	}
+}
@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+	"time"
+
+	"github.com/YoungBoyGod/remotegpu-agent/internal/container"
+	"github.com/YoungBoyGod/remotegpu-agent/internal/errors"
+	"github.com/gin-gonic/gin"
+)
+
+// containerOpTimeout 单次容器操作超时（含镜像拉取）
+const containerOpTimeout = 10 * time.Minute
+
+// ContainerHandler 容器生命周期处理器
+type ContainerHandler struct {
+	runtime container.Runtime
+}
+
+// NewContainerHandler 创建容器处理器
+func NewContainerHandler(rt container.Runtime) *ContainerHandler {
+	return &ContainerHandler{runtime: rt}
+}
+
+// CreateContainer 创建并启动容器，返回容器 ID 及实际发布的端口
+func (h *ContainerHandler) CreateContainer(c *gin.Context) {
+	var spec container.Spec
+	if err := c.ShouldBindJSON(&spec); err != nil || spec.Image == "" {
+		containerFail(c, http.StatusBadRequest, errors.ErrInvalidParams, "image required")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), containerOpTimeout)
+	defer cancel()
+
+	id, err := h.runtime.Create(ctx, &spec)
+	if err != nil {
+		containerRuntimeError(c, err)
+		return
+	}
+	state, err := h.runtime.Inspect(ctx, id)
+	if err != nil {
+		containerRuntimeError(c, err)
+		return
+	}
+	containerOK(c, gin.H{"container_id": id, "ports": state.Ports})
+}
+
+// StartContainer 启动容器
+func (h *ContainerHandler) StartContainer(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), containerOpTimeout)
+	defer cancel()
+
+	if err := h.runtime.Start(ctx, c.Param("id")); err != nil {
+		containerRuntimeError(c, err)
+		return
+	}
+	containerOK(c, nil)
+}
+
+// StopContainer 停止容器
+func (h *ContainerHandler) StopContainer(c *gin.Context) {
+	var req struct {
+		Timeout int `json:"timeout"`
+	}
+	_ = c.ShouldBindJSON(&req)
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), containerOpTimeout)
+	defer cancel()
+
+	if err := h.runtime.Stop(ctx, c.Param("id"), req.Timeout); err != nil {
+		containerRuntimeError(c, err)
+		return
+	}
+	containerOK(c, nil)
+}
+
+// RemoveContainer 删除容器
+func (h *ContainerHandler) RemoveContainer(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), containerOpTimeout)
+	defer cancel()
+
+	if err := h.runtime.Remove(ctx, c.Param("id")); err != nil {
+		containerRuntimeError(c, err)
+		return
+	}
+	containerOK(c, nil)
+}
+
+// InspectContainer 查询容器状态
+func (h *ContainerHandler) InspectContainer(c *gin.Context) {
+	state, err := h.runtime.Inspect(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		containerRuntimeError(c, err)
+		return
+	}
+	containerOK(c, state)
+}
+
+func containerRuntimeError(c *gin.Context, err error) {
+	if stderrors.Is(err, container.ErrNotFound) {
+		containerFail(c, http.StatusNotFound, errors.ErrContainerNotFound, err.Error())
+		return
+	}
+	containerFail(c, http.StatusInternalServerError, errors.ErrContainerRuntime, err.Error())
+}
+
+func containerOK(c *gin.Context, data interface{}) {
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"code":    0,
+		"message": "ok",
+		"data":    data,
+	})
+}
+
+func containerFail(c *gin.Context, httpStatus, code int, message string) {
+	c.JSON(httpStatus, gin.H{
+		"success": false,
+		"code":    code,
+		"message": message,
+	})
+}
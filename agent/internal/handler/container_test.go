@@ -0,0 +1,196 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/YoungBoyGod/remotegpu-agent/internal/container"
+	"github.com/YoungBoyGod/remotegpu-agent/internal/errors"
+	"github.com/gin-gonic/gin"
+)
+
+// fakeRuntime 内存中的容器运行时，用于测试
+type fakeRuntime struct {
+	mu         sync.Mutex
+	seq        int
+	containers map[string]*container.State
+	specs      map[string]*container.Spec
+}
+
+func newFakeRuntime() *fakeRuntime {
+	return &fakeRuntime{
+		containers: make(map[string]*container.State),
+		specs:      make(map[string]*container.Spec),
+	}
+}
+
+func (f *fakeRuntime) Create(ctx context.Context, spec *container.Spec) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.seq++
+	id := fmt.Sprintf("c%d", f.seq)
+	st := &container.State{ID: id, Name: spec.Name, Image: spec.Image, Status: "running", Running: true}
+	for i, p := range spec.Ports {
+		if p.HostPort == 0 {
+			p.HostPort = 30000 + f.seq*10 + i
+		}
+		st.Ports = append(st.Ports, p)
+	}
+	f.containers[id] = st
+	f.specs[id] = spec
+	return id, nil
+}
+
+func (f *fakeRuntime) Start(ctx context.Context, id string) error {
+	return f.setRunning(id, true)
+}
+
+func (f *fakeRuntime) Stop(ctx context.Context, id string, timeout int) error {
+	return f.setRunning(id, false)
+}
+
+func (f *fakeRuntime) Remove(ctx context.Context, id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.containers, id)
+	return nil
+}
+
+func (f *fakeRuntime) Inspect(ctx context.Context, id string) (*container.State, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	st, ok := f.containers[id]
+	if !ok {
+		return nil, container.ErrNotFound
+	}
+	cp := *st
+	return &cp, nil
+}
+
+func (f *fakeRuntime) setRunning(id string, running bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	st, ok := f.containers[id]
+	if !ok {
+		return container.ErrNotFound
+	}
+	st.Running = running
+	st.Status = "exited"
+	if running {
+		st.Status = "running"
+	}
+	return nil
+}
+
+type containerResp struct {
+	Success bool            `json:"success"`
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+func setupContainerRouter(rt container.Runtime) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	h := NewContainerHandler(rt)
+	r.POST("/api/v1/containers", h.CreateContainer)
+	r.GET("/api/v1/containers/:id", h.InspectContainer)
+	r.POST("/api/v1/containers/:id/start", h.StartContainer)
+	r.POST("/api/v1/containers/:id/stop", h.StopContainer)
+	r.DELETE("/api/v1/containers/:id", h.RemoveContainer)
+	return r
+}
+
+func doContainerRequest(t *testing.T, r *gin.Engine, method, path string, body interface{}) (int, containerResp) {
+	t.Helper()
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var resp containerResp
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v, body=%s", err, w.Body.String())
+	}
+	return w.Code, resp
+}
+
+func TestContainerLifecycle(t *testing.T) {
+	rt := newFakeRuntime()
+	r := setupContainerRouter(rt)
+
+	status, resp := doContainerRequest(t, r, http.MethodPost, "/api/v1/containers", container.Spec{
+		Name: "env-1", Image: "pytorch:latest", CPU: 4, MemoryMB: 8192, GPU: 1,
+		Ports: []container.PortBinding{{ContainerPort: 22}},
+	})
+	if status != http.StatusOK || !resp.Success {
+		t.Fatalf("create failed: %d %+v", status, resp)
+	}
+	var created struct {
+		ContainerID string                  `json:"container_id"`
+		Ports       []container.PortBinding `json:"ports"`
+	}
+	json.Unmarshal(resp.Data, &created)
+	if created.ContainerID == "" {
+		t.Fatal("expected container id")
+	}
+	if len(created.Ports) != 1 || created.Ports[0].ContainerPort != 22 || created.Ports[0].HostPort == 0 {
+		t.Errorf("unexpected ports: %+v", created.Ports)
+	}
+	if spec := rt.specs[created.ContainerID]; spec.MemoryMB != 8192 || spec.GPU != 1 {
+		t.Errorf("unexpected spec: %+v", spec)
+	}
+
+	base := "/api/v1/containers/" + created.ContainerID
+	if _, resp = doContainerRequest(t, r, http.MethodPost, base+"/stop", nil); !resp.Success {
+		t.Fatalf("stop failed: %+v", resp)
+	}
+	_, resp = doContainerRequest(t, r, http.MethodGet, base, nil)
+	var state container.State
+	json.Unmarshal(resp.Data, &state)
+	if state.Running {
+		t.Error("expected container stopped")
+	}
+
+	if _, resp = doContainerRequest(t, r, http.MethodPost, base+"/start", nil); !resp.Success {
+		t.Fatalf("start failed: %+v", resp)
+	}
+	if _, resp = doContainerRequest(t, r, http.MethodDelete, base, nil); !resp.Success {
+		t.Fatalf("remove failed: %+v", resp)
+	}
+
+	status, resp = doContainerRequest(t, r, http.MethodGet, base, nil)
+	if status != http.StatusNotFound || resp.Code != errors.ErrContainerNotFound {
+		t.Errorf("expected not found, got %d %+v", status, resp)
+	}
+}
+
+func TestCreateContainerRequiresImage(t *testing.T) {
+	r := setupContainerRouter(newFakeRuntime())
+
+	status, resp := doContainerRequest(t, r, http.MethodPost, "/api/v1/containers", container.Spec{Name: "env-1"})
+	if status != http.StatusBadRequest || resp.Code != errors.ErrInvalidParams {
+		t.Errorf("expected invalid params, got %d %+v", status, resp)
+	}
+}
+
+func TestStartUnknownContainer(t *testing.T) {
+	r := setupContainerRouter(newFakeRuntime())
+
+	status, resp := doContainerRequest(t, r, http.MethodPost, "/api/v1/containers/missing/start", nil)
+	if status != http.StatusNotFound || resp.Success {
+		t.Errorf("expected not found, got %d %+v", status, resp)
+	}
+}
@@ -0,0 +1,141 @@
+package logship
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// chunkSize 单次上报的最大字节数
+	chunkSize = 256 << 10
+	// finalAttempts 任务结束后最终上报的重试次数
+	finalAttempts = 3
+)
+
+// Streams 需要上报的输出流
+var Streams = []string{"stdout", "stderr"}
+
+// ErrOffsetMismatch Server 已持久化的偏移与本地不一致，需从 Server 返回的偏移继续
+var ErrOffsetMismatch = errors.New("log offset mismatch")
+
+// Uploader 日志上报接口
+// 返回 Server 已持久化的偏移；偏移不一致时返回 ErrOffsetMismatch 及 Server 的偏移
+type Uploader interface {
+	UploadLog(taskID, attemptID, stream string, offset int64, data []byte) (int64, error)
+}
+
+// SpoolPath 返回任务输出在本地缓存文件中的路径
+func SpoolPath(dir, taskID, attemptID, stream string) string {
+	return filepath.Join(dir, taskID+"_"+attemptID+"."+stream+".log")
+}
+
+// OpenSpool 打开（追加）任务输出缓存文件
+func OpenSpool(dir, taskID, attemptID, stream string) (*os.File, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return os.OpenFile(SpoolPath(dir, taskID, attemptID, stream), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+}
+
+// Shipper 将任务输出缓存文件按偏移增量上报到 Server
+type Shipper struct {
+	uploader  Uploader
+	dir       string
+	taskID    string
+	attemptID string
+	interval  time.Duration
+	offsets   map[string]int64
+}
+
+// NewShipper 创建日志上报器
+func NewShipper(uploader Uploader, dir, taskID, attemptID string, interval time.Duration) *Shipper {
+	if interval <= 0 {
+		interval = 2 * time.Second
+	}
+	return &Shipper{
+		uploader:  uploader,
+		dir:       dir,
+		taskID:    taskID,
+		attemptID: attemptID,
+		interval:  interval,
+		offsets:   make(map[string]int64),
+	}
+}
+
+// Run 周期性上报新增输出，stop 关闭后完成最终上报并清理缓存文件
+func (s *Shipper) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			s.finish()
+			return
+		case <-ticker.C:
+			for _, stream := range Streams {
+				if err := s.ship(stream); err != nil {
+					slog.Debug("ship log error", "task_id", s.taskID, "stream", stream, "error", err)
+				}
+			}
+		}
+	}
+}
+
+// finish 最终上报，全部上报成功后删除缓存文件；失败时保留文件便于排查
+func (s *Shipper) finish() {
+	for _, stream := range Streams {
+		var err error
+		for attempt := 0; attempt < finalAttempts; attempt++ {
+			if attempt > 0 {
+				time.Sleep(s.interval)
+			}
+			if err = s.ship(stream); err == nil {
+				break
+			}
+		}
+		if err != nil {
+			slog.Error("ship final log error", "task_id", s.taskID, "stream", stream, "error", err)
+			continue
+		}
+		os.Remove(SpoolPath(s.dir, s.taskID, s.attemptID, stream))
+	}
+}
+
+// ship 从当前偏移读取缓存文件直到末尾并上报
+func (s *Shipper) ship(stream string) error {
+	f, err := os.Open(SpoolPath(s.dir, s.taskID, s.attemptID, stream))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+
+	buf := make([]byte, chunkSize)
+	for {
+		offset := s.offsets[stream]
+		n, err := f.ReadAt(buf, offset)
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if n == 0 {
+			return nil
+		}
+
+		next, upErr := s.uploader.UploadLog(s.taskID, s.attemptID, stream, offset, buf[:n])
+		if upErr != nil && !errors.Is(upErr, ErrOffsetMismatch) {
+			return upErr
+		}
+		// 以 Server 返回的偏移为准：重传时跳过已持久化部分，缺失时回退补传
+		s.offsets[stream] = next
+		if upErr == nil && n < chunkSize {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package logship
+
+import (
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeUploader 模拟 Server：按偏移追加，偏移不连续时返回已持久化长度
+type fakeUploader struct {
+	mu    sync.Mutex
+	logs  map[string][]byte
+	failN int
+}
+
+func (u *fakeUploader) UploadLog(taskID, attemptID, stream string, offset int64, data []byte) (int64, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.failN > 0 {
+		u.failN--
+		return 0, os.ErrDeadlineExceeded
+	}
+	cur := u.logs[stream]
+	if offset > int64(len(cur)) {
+		return int64(len(cur)), ErrOffsetMismatch
+	}
+	if end := offset + int64(len(data)); end > int64(len(cur)) {
+		cur = append(cur, data[int64(len(cur))-offset:]...)
+	}
+	u.logs[stream] = cur
+	return int64(len(cur)), nil
+}
+
+func (u *fakeUploader) get(stream string) string {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return string(u.logs[stream])
+}
+
+func TestShipperStreamsAndCleansUp(t *testing.T) {
+	dir := t.TempDir()
+	up := &fakeUploader{logs: make(map[string][]byte)}
+
+	f, err := OpenSpool(dir, "t1", "a1", "stdout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("epoch 1\n")
+
+	sh := NewShipper(up, dir, "t1", "a1", 10*time.Millisecond)
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		sh.Run(stop)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for up.get("stdout") != "epoch 1\n" && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := up.get("stdout"); got != "epoch 1\n" {
+		t.Fatalf("incremental ship: got %q", got)
+	}
+
+	f.WriteString("epoch 2\n")
+	f.Close()
+	close(stop)
+	<-done
+
+	if got := up.get("stdout"); got != "epoch 1\nepoch 2\n" {
+		t.Errorf("final ship: got %q", got)
+	}
+	if _, err := os.Stat(SpoolPath(dir, "t1", "a1", "stdout")); !os.IsNotExist(err) {
+		t.Errorf("spool file should be removed, stat err = %v", err)
+	}
+}
+
+func TestShipperResumesFromServerOffset(t *testing.T) {
+	dir := t.TempDir()
+	// Server 已持久化前 6 字节（例如 Agent 重启前上报过）
+	up := &fakeUploader{logs: map[string][]byte{"stdout": []byte("hello ")}, failN: 1}
+
+	f, _ := OpenSpool(dir, "t2", "a1", "stdout")
+	f.WriteString("hello world\n")
+	f.Close()
+
+	sh := NewShipper(up, dir, "t2", "a1", time.Millisecond)
+	if err := sh.ship("stdout"); err == nil {
+		t.Fatal("expected transient upload error")
+	}
+	if err := sh.ship("stdout"); err != nil {
+		t.Fatal(err)
+	}
+	if got := up.get("stdout"); got != "hello world\n" {
+		t.Errorf("got %q", got)
+	}
+}
@@ -65,6 +65,8 @@ type Task struct {
 	AssignedAgentID string    `json:"assigned_agent_id"`
 	LeaseExpiresAt  time.Time `json:"lease_expires_at,omitempty"`
 	AttemptID       string    `json:"attempt_id"`
+	AssignedGPUs    []int     `json:"assigned_gpus,omitempty"` // Server 调度分配的 GPU 序号
+	RunAs           string    `json:"run_as,omitempty"`        // GPU 粒度分配时以该客户的系统账号运行
 
 	// 本地同步标记
 	Synced bool `json:"synced"`
@@ -0,0 +1,204 @@
+package mount
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// 数据源类型
+const (
+	SourceNFS  = "nfs"
+	SourceS3   = "s3"
+	SourceBind = "bind"
+)
+
+var (
+	ErrInvalidRequest   = errors.New("invalid mount request")
+	ErrS3NotConfigured  = errors.New("s3 mount not configured on this agent")
+	ErrMountPointDenied = errors.New("mount point not allowed")
+)
+
+// forbiddenMountPoints 禁止挂载的系统目录
+var forbiddenMountPoints = []string{"/", "/bin", "/sbin", "/usr", "/etc", "/proc", "/sys", "/dev", "/boot", "/root", "/var", "/lib", "/lib64"}
+
+// Request 挂载请求
+type Request struct {
+	SourceType string `json:"source_type"` // nfs, s3, bind
+	Source     string `json:"source_path"` // nfs: server:/export；s3: bucket/prefix；bind: 本地目录
+	MountPoint string `json:"mount_point"`
+	ReadOnly   bool   `json:"read_only"`
+}
+
+// Config 挂载配置
+type Config struct {
+	S3Endpoint   string   // s3fs 访问的对象存储地址
+	S3PasswdFile string   // s3fs 凭证文件，凭证保留在机器本地不经网络下发
+	AllowedRoots []string // 允许的挂载根目录，为空时不限制
+}
+
+// Mounter 数据集挂载接口
+type Mounter interface {
+	Mount(ctx context.Context, req *Request) error
+	Unmount(ctx context.Context, mountPoint string) error
+}
+
+// Manager 基于系统 mount/s3fs 命令的挂载实现
+type Manager struct {
+	cfg     Config
+	run     func(ctx context.Context, name string, args ...string) error
+	mounted func(mountPoint string) (bool, error)
+}
+
+// NewManager 创建挂载管理器
+func NewManager(cfg Config) *Manager {
+	return &Manager{cfg: cfg, run: runCommand, mounted: isMounted}
+}
+
+// Mount 挂载数据源，挂载点已挂载时视为成功
+func (m *Manager) Mount(ctx context.Context, req *Request) error {
+	if err := m.validateMountPoint(req.MountPoint); err != nil {
+		return err
+	}
+	cmds, err := m.commands(req)
+	if err != nil {
+		return err
+	}
+
+	if ok, err := m.mounted(req.MountPoint); err != nil {
+		return err
+	} else if ok {
+		return nil
+	}
+	if err := os.MkdirAll(req.MountPoint, 0755); err != nil {
+		return fmt.Errorf("create mount point: %w", err)
+	}
+
+	for i, args := range cmds {
+		if err := m.run(ctx, args[0], args[1:]...); err != nil {
+			// bind 挂载后设置只读失败时回滚，避免留下可写挂载
+			if i > 0 {
+				_ = m.run(ctx, "umount", req.MountPoint)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// Unmount 卸载挂载点，未挂载时视为成功
+func (m *Manager) Unmount(ctx context.Context, mountPoint string) error {
+	if err := m.validateMountPoint(mountPoint); err != nil {
+		return err
+	}
+	ok, err := m.mounted(mountPoint)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return nil
+	}
+	return m.run(ctx, "umount", mountPoint)
+}
+
+// commands 生成挂载命令序列
+func (m *Manager) commands(req *Request) ([][]string, error) {
+	if req.Source == "" {
+		return nil, fmt.Errorf("%w: source_path required", ErrInvalidRequest)
+	}
+
+	switch req.SourceType {
+	case SourceNFS:
+		args := []string{"mount", "-t", "nfs"}
+		if req.ReadOnly {
+			args = append(args, "-o", "ro")
+		}
+		return [][]string{append(args, req.Source, req.MountPoint)}, nil
+
+	case SourceS3:
+		if m.cfg.S3PasswdFile == "" || m.cfg.S3Endpoint == "" {
+			return nil, ErrS3NotConfigured
+		}
+		bucket, prefix, _ := strings.Cut(strings.Trim(req.Source, "/"), "/")
+		if bucket == "" {
+			return nil, fmt.Errorf("%w: bucket required", ErrInvalidRequest)
+		}
+		source := bucket
+		if prefix != "" {
+			source += ":/" + prefix
+		}
+		opts := "passwd_file=" + m.cfg.S3PasswdFile + ",url=" + m.cfg.S3Endpoint + ",use_path_request_style"
+		if req.ReadOnly {
+			opts += ",ro"
+		}
+		return [][]string{{"s3fs", source, req.MountPoint, "-o", opts}}, nil
+
+	case SourceBind:
+		if !filepath.IsAbs(req.Source) {
+			return nil, fmt.Errorf("%w: bind source must be absolute", ErrInvalidRequest)
+		}
+		cmds := [][]string{{"mount", "--bind", filepath.Clean(req.Source), req.MountPoint}}
+		if req.ReadOnly {
+			cmds = append(cmds, []string{"mount", "-o", "remount,bind,ro", req.MountPoint})
+		}
+		return cmds, nil
+	}
+	return nil, fmt.Errorf("%w: unsupported source type %q", ErrInvalidRequest, req.SourceType)
+}
+
+func (m *Manager) validateMountPoint(mp string) error {
+	if mp == "" || !filepath.IsAbs(mp) || filepath.Clean(mp) != mp {
+		return fmt.Errorf("%w: %q", ErrMountPointDenied, mp)
+	}
+	for _, f := range forbiddenMountPoints {
+		if mp == f {
+			return fmt.Errorf("%w: %q", ErrMountPointDenied, mp)
+		}
+	}
+	if len(m.cfg.AllowedRoots) == 0 {
+		return nil
+	}
+	for _, root := range m.cfg.AllowedRoots {
+		root = filepath.Clean(root)
+		if strings.HasPrefix(mp, root+"/") {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %q is outside allowed roots", ErrMountPointDenied, mp)
+}
+
+func runCommand(ctx context.Context, name string, args ...string) error {
+	out, err := exec.CommandContext(ctx, name, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s: %w: %s", name, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// isMounted 通过 /proc/self/mountinfo 判断挂载点是否已挂载
+func isMounted(mountPoint string) (bool, error) {
+	data, err := os.ReadFile("/proc/self/mountinfo")
+	if err != nil {
+		return false, fmt.Errorf("read mountinfo: %w", err)
+	}
+	return mountInfoContains(string(data), mountPoint), nil
+}
+
+func mountInfoContains(mountInfo, mountPoint string) bool {
+	for _, line := range strings.Split(mountInfo, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) > 4 && unescapeMountPath(fields[4]) == mountPoint {
+			return true
+		}
+	}
+	return false
+}
+
+// unescapeMountPath 还原 mountinfo 中八进制转义的空白字符
+func unescapeMountPath(s string) string {
+	return strings.NewReplacer(`\040`, " ", `\011`, "\t", `\012`, "\n", `\134`, `\`).Replace(s)
+}
@@ -0,0 +1,129 @@
+package mount
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeManager 记录执行的命令，挂载状态保存在内存中
+func fakeManager(t *testing.T, cfg Config, failOn string) (*Manager, *[]string) {
+	var calls []string
+	state := map[string]bool{}
+	m := NewManager(cfg)
+	m.run = func(ctx context.Context, name string, args ...string) error {
+		cmd := strings.Join(append([]string{name}, args...), " ")
+		calls = append(calls, cmd)
+		if failOn != "" && strings.Contains(cmd, failOn) {
+			return errors.New("exit status 32")
+		}
+		mp := args[len(args)-1]
+		if name == "s3fs" {
+			mp = args[1]
+		}
+		state[mp] = name != "umount"
+		return nil
+	}
+	m.mounted = func(mp string) (bool, error) { return state[mp], nil }
+	return m, &calls
+}
+
+func TestManagerMountCommands(t *testing.T) {
+	root := t.TempDir()
+	cfg := Config{S3Endpoint: "http://minio:9000", S3PasswdFile: "/etc/passwd-s3fs", AllowedRoots: []string{root}}
+
+	cases := []struct {
+		req  Request
+		want []string
+	}{
+		{Request{SourceType: SourceNFS, Source: "nas:/export/ds1", ReadOnly: true},
+			[]string{"mount -t nfs -o ro nas:/export/ds1 MP"}},
+		{Request{SourceType: SourceS3, Source: "datasets/customer-1/ds1"},
+			[]string{"s3fs datasets:/customer-1/ds1 MP -o passwd_file=/etc/passwd-s3fs,url=http://minio:9000,use_path_request_style"}},
+		{Request{SourceType: SourceBind, Source: "/data/shared", ReadOnly: true},
+			[]string{"mount --bind /data/shared MP", "mount -o remount,bind,ro MP"}},
+	}
+	for i, tc := range cases {
+		m, calls := fakeManager(t, cfg, "")
+		tc.req.MountPoint = filepath.Join(root, "ds", string(rune('a'+i)))
+		if err := m.Mount(context.Background(), &tc.req); err != nil {
+			t.Fatalf("%s: mount: %v", tc.req.SourceType, err)
+		}
+		var want []string
+		for _, w := range tc.want {
+			want = append(want, strings.ReplaceAll(w, "MP", tc.req.MountPoint))
+		}
+		if !reflect.DeepEqual(*calls, want) {
+			t.Errorf("%s: got %v, want %v", tc.req.SourceType, *calls, want)
+		}
+
+		// 重复挂载与卸载均为幂等操作
+		if err := m.Mount(context.Background(), &tc.req); err != nil {
+			t.Fatal(err)
+		}
+		if err := m.Unmount(context.Background(), tc.req.MountPoint); err != nil {
+			t.Fatal(err)
+		}
+		if err := m.Unmount(context.Background(), tc.req.MountPoint); err != nil {
+			t.Fatal(err)
+		}
+		if n := len(*calls); n != len(want)+1 {
+			t.Errorf("%s: expected one umount after mount, got %v", tc.req.SourceType, *calls)
+		}
+	}
+}
+
+func TestManagerBindReadOnlyRollback(t *testing.T) {
+	root := t.TempDir()
+	m, calls := fakeManager(t, Config{}, "remount")
+	mp := filepath.Join(root, "ds")
+
+	err := m.Mount(context.Background(), &Request{SourceType: SourceBind, Source: "/data", MountPoint: mp, ReadOnly: true})
+	if err == nil {
+		t.Fatal("expected remount failure")
+	}
+	if last := (*calls)[len(*calls)-1]; last != "umount "+mp {
+		t.Fatalf("expected rollback umount, got %v", *calls)
+	}
+}
+
+func TestManagerRejects(t *testing.T) {
+	root := t.TempDir()
+	m, calls := fakeManager(t, Config{AllowedRoots: []string{root}}, "")
+	ctx := context.Background()
+
+	cases := []struct {
+		req  Request
+		want error
+	}{
+		{Request{SourceType: SourceNFS, Source: "nas:/x", MountPoint: "/etc"}, ErrMountPointDenied},
+		{Request{SourceType: SourceNFS, Source: "nas:/x", MountPoint: "relative"}, ErrMountPointDenied},
+		{Request{SourceType: SourceNFS, Source: "nas:/x", MountPoint: root + "/../escape"}, ErrMountPointDenied},
+		{Request{SourceType: SourceNFS, Source: "nas:/x", MountPoint: "/mnt/outside"}, ErrMountPointDenied},
+		{Request{SourceType: SourceS3, Source: "bucket/x", MountPoint: root + "/s3"}, ErrS3NotConfigured},
+		{Request{SourceType: SourceBind, Source: "data", MountPoint: root + "/b"}, ErrInvalidRequest},
+		{Request{SourceType: "smb", Source: "//x", MountPoint: root + "/c"}, ErrInvalidRequest},
+	}
+	for _, tc := range cases {
+		if err := m.Mount(ctx, &tc.req); !errors.Is(err, tc.want) {
+			t.Errorf("%+v: got %v, want %v", tc.req, err, tc.want)
+		}
+	}
+	if len(*calls) != 0 {
+		t.Fatalf("rejected requests must not run commands: %v", *calls)
+	}
+}
+
+func TestMountInfoContains(t *testing.T) {
+	info := "36 35 98:0 /mnt1 /mnt/data\\040set rw,noatime master:1 - ext3 /dev/root rw\n" +
+		"37 35 0:31 / /sys rw - sysfs sysfs rw\n"
+	if !mountInfoContains(info, "/mnt/data set") {
+		t.Fatal("expected escaped mount point to match")
+	}
+	if mountInfoContains(info, "/mnt/data") {
+		t.Fatal("unexpected match")
+	}
+}
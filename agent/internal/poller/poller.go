@@ -2,6 +2,8 @@ package poller
 
 import (
 	"log/slog"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -84,8 +86,25 @@ func (p *Poller) poll() {
 	}
 
 	for _, task := range tasks {
+		bindAssignedGPUs(task)
 		if p.taskCallback != nil {
 			p.taskCallback(task)
 		}
 	}
 }
+
+// bindAssignedGPUs 将 Server 调度分配的 GPU 写入 CUDA_VISIBLE_DEVICES，使任务默认使用分配到的 GPU
+// 该变量不是访问控制，GPU 粒度分配的任务由执行器放入设备 cgroup 限制（见 models.Task.RunAs）
+func bindAssignedGPUs(task *models.Task) {
+	if len(task.AssignedGPUs) == 0 {
+		return
+	}
+	ids := make([]string, len(task.AssignedGPUs))
+	for i, idx := range task.AssignedGPUs {
+		ids[i] = strconv.Itoa(idx)
+	}
+	if task.Env == nil {
+		task.Env = make(map[string]string)
+	}
+	task.Env["CUDA_VISIBLE_DEVICES"] = strings.Join(ids, ",")
+}
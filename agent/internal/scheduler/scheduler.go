@@ -7,6 +7,7 @@ import (
 
 	"github.com/YoungBoyGod/remotegpu-agent/internal/client"
 	"github.com/YoungBoyGod/remotegpu-agent/internal/executor"
+	"github.com/YoungBoyGod/remotegpu-agent/internal/logship"
 	"github.com/YoungBoyGod/remotegpu-agent/internal/models"
 	"github.com/YoungBoyGod/remotegpu-agent/internal/queue"
 	"github.com/YoungBoyGod/remotegpu-agent/internal/store"
@@ -156,8 +157,9 @@ func (s *Scheduler) tryExecute() {
 }
 
 // dependenciesMet 检查任务的所有依赖是否已完成
+// Server 下发的任务（有 AttemptID）由 Server 保证上游已完成，上游可能运行在其他机器上，不在本地检查
 func (s *Scheduler) dependenciesMet(task *models.Task) bool {
-	if len(task.DependsOn) == 0 {
+	if len(task.DependsOn) == 0 || task.AttemptID != "" {
 		return true
 	}
 	for _, depID := range task.DependsOn {
@@ -188,12 +190,29 @@ func (s *Scheduler) runTask(task *models.Task) {
 		go s.renewLoop(task, stopRenew)
 	}
 
+	// 启动日志增量上报
+	stopShip := make(chan struct{})
+	shipDone := make(chan struct{})
+	if s.client != nil && task.AttemptID != "" && s.executor.LogDir() != "" {
+		shipper := logship.NewShipper(s.client, s.executor.LogDir(), task.ID, task.AttemptID, 2*time.Second)
+		go func() {
+			shipper.Run(stopShip)
+			close(shipDone)
+		}()
+	} else {
+		close(shipDone)
+	}
+
 	// 执行任务
 	s.executor.Execute(task)
 
 	// 停止续约
 	close(stopRenew)
 
+	// 等待剩余日志上报完成后再上报结果，保证任务结束时日志已完整
+	close(stopShip)
+	<-shipDone
+
 	// 检查是否需要重试
 	if task.Status == models.TaskStatusFailed && task.MaxRetries > 0 && task.RetryCount < task.MaxRetries {
 		task.RetryCount++
@@ -233,12 +252,16 @@ func (s *Scheduler) runTask(task *models.Task) {
 }
 
 // renewLoop 租约续约 + 进度上报循环
+// 进度来自任务 stdout 中的 ##progress 标记行，仅在变化时上报
 func (s *Scheduler) renewLoop(task *models.Task, stop <-chan struct{}) {
 	renewTicker := time.NewTicker(60 * time.Second)
-	progressTicker := time.NewTicker(30 * time.Second)
+	progressTicker := time.NewTicker(5 * time.Second)
 	defer renewTicker.Stop()
 	defer progressTicker.Stop()
 
+	var reported executor.Progress
+	hasReported := false
+
 	for {
 		select {
 		case <-stop:
@@ -248,9 +271,16 @@ func (s *Scheduler) renewLoop(task *models.Task, stop <-chan struct{}) {
 				slog.Error("renew lease error", "task_id", task.ID, "error", err)
 			}
 		case <-progressTicker.C:
-			if err := s.client.ReportProgress(task.ID, task.AttemptID, 0, "running"); err != nil {
+			prog, ok := s.executor.Progress(task.ID)
+			if !ok || (hasReported && prog == reported) {
+				continue
+			}
+			if err := s.client.ReportProgress(task.ID, task.AttemptID, prog.Percent, prog.Message); err != nil {
 				slog.Debug("report progress error", "task_id", task.ID, "error", err)
+				continue
 			}
+			reported = prog
+			hasReported = true
 		}
 	}
 }
@@ -287,6 +287,21 @@ func TestDependenciesMetMissing(t *testing.T) {
 	}
 }
 
+func TestDependenciesMetServerTask(t *testing.T) {
+	s := tempScheduler(t, 2)
+
+	// Server 下发的任务依赖由 Server 保证，上游不在本地也视为满足
+	task := &models.Task{
+		ID:        "t1",
+		Command:   "echo 1",
+		DependsOn: []string{"remote-upstream"},
+		AttemptID: "attempt-1",
+	}
+	if !s.dependenciesMet(task) {
+		t.Error("Server 任务 dependenciesMet 应返回 true")
+	}
+}
+
 func TestStartExecutesTask(t *testing.T) {
 	s := tempScheduler(t, 2)
 	s.Start()
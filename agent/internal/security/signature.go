@@ -0,0 +1,105 @@
+package security
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// 控制请求签名头，Server 调用 Agent 控制接口时携带
+const (
+	HeaderTimestamp = "X-RemoteGPU-Timestamp"
+	HeaderNonce     = "X-RemoteGPU-Nonce"
+	HeaderSignature = "X-RemoteGPU-Signature"
+)
+
+// DefaultMaxSkew 请求时间戳允许的最大偏差，同时也是 nonce 的记忆时长
+const DefaultMaxSkew = 5 * time.Minute
+
+var (
+	ErrSignatureMissing = errors.New("missing request signature")
+	ErrSignatureExpired = errors.New("request timestamp out of range")
+	ErrSignatureReplay  = errors.New("request nonce already used")
+	ErrSignatureInvalid = errors.New("invalid request signature")
+)
+
+// signingKeyLabel 派生控制接口签名密钥的用途标识，使签名密钥与 Server 保存的机器密钥摘要不同
+const signingKeyLabel = "remotegpu-agent-control"
+
+// SigningKeyFromSecret 由机器密钥派生控制接口签名密钥：HMAC-SHA256(secret, label)
+// Server 在注册时计算同一密钥并加密保存，仅凭数据库中的机器密钥摘要无法伪造签名
+func SigningKeyFromSecret(secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(signingKeyLabel))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Sign 计算请求签名：HMAC-SHA256(method \n uri \n timestamp \n nonce \n sha256(body))
+func Sign(key, method, uri, timestamp, nonce string, body []byte) string {
+	bodySum := sha256.Sum256(body)
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(method + "\n" + uri + "\n" + timestamp + "\n" + nonce + "\n" + hex.EncodeToString(bodySum[:])))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// RequestVerifier 控制请求签名校验器，拒绝过期和重放的请求
+type RequestVerifier struct {
+	key     string
+	maxSkew time.Duration
+	now     func() time.Time
+
+	mu     sync.Mutex
+	nonces map[string]time.Time
+}
+
+// NewRequestVerifier 创建签名校验器
+func NewRequestVerifier(key string, maxSkew time.Duration) *RequestVerifier {
+	if maxSkew <= 0 {
+		maxSkew = DefaultMaxSkew
+	}
+	return &RequestVerifier{
+		key:     key,
+		maxSkew: maxSkew,
+		now:     time.Now,
+		nonces:  make(map[string]time.Time),
+	}
+}
+
+// Verify 校验请求签名，timestamp 为 Unix 秒
+func (v *RequestVerifier) Verify(method, uri, timestamp, nonce, signature string, body []byte) error {
+	if timestamp == "" || nonce == "" || signature == "" {
+		return ErrSignatureMissing
+	}
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrSignatureExpired
+	}
+	now := v.now()
+	skew := now.Sub(time.Unix(ts, 0))
+	if skew > v.maxSkew || skew < -v.maxSkew {
+		return ErrSignatureExpired
+	}
+
+	expected := Sign(v.key, method, uri, timestamp, nonce, body)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return ErrSignatureInvalid
+	}
+
+	// 签名通过后再记录 nonce，避免伪造请求占满缓存
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for n, seen := range v.nonces {
+		if now.Sub(seen) > 2*v.maxSkew {
+			delete(v.nonces, n)
+		}
+	}
+	if _, ok := v.nonces[nonce]; ok {
+		return ErrSignatureReplay
+	}
+	v.nonces[nonce] = now
+	return nil
+}
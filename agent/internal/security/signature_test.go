@@ -0,0 +1,87 @@
+package security
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSigningKeyFromSecret(t *testing.T) {
+	// 与 Server 端 AgentCredentialService 的派生结果一致
+	const want = "744f42c353c4541242acd610c1ce10f1db64a8a2f921c9a3592410a266c8ac8d"
+	if got := SigningKeyFromSecret("ras_secret"); got != want {
+		t.Errorf("SigningKeyFromSecret = %s, want %s", got, want)
+	}
+	// 签名密钥不能是 Server 保存的机器密钥摘要
+	sum := sha256.Sum256([]byte("ras_secret"))
+	if SigningKeyFromSecret("ras_secret") == hex.EncodeToString(sum[:]) {
+		t.Error("signing key equals the stored secret hash")
+	}
+}
+
+func TestRequestVerifier(t *testing.T) {
+	key := SigningKeyFromSecret("ras_secret")
+	v := NewRequestVerifier(key, time.Minute)
+	now := time.Unix(1700000000, 0)
+	v.now = func() time.Time { return now }
+
+	ts := strconv.FormatInt(now.Unix(), 10)
+	body := []byte(`{"command":"nvidia-smi"}`)
+	sig := Sign(key, "POST", "/api/v1/command/exec", ts, "nonce-1", body)
+
+	if err := v.Verify("POST", "/api/v1/command/exec", ts, "nonce-1", sig, body); err != nil {
+		t.Fatalf("valid request rejected: %v", err)
+	}
+
+	cases := []struct {
+		name   string
+		method string
+		uri    string
+		ts     string
+		nonce  string
+		sig    string
+		body   []byte
+		want   error
+	}{
+		{"replay", "POST", "/api/v1/command/exec", ts, "nonce-1", sig, body, ErrSignatureReplay},
+		{"missing", "POST", "/api/v1/command/exec", ts, "nonce-2", "", body, ErrSignatureMissing},
+		{"tampered body", "POST", "/api/v1/command/exec", ts, "nonce-2",
+			Sign(key, "POST", "/api/v1/command/exec", ts, "nonce-2", body), []byte(`{"command":"rm -rf /"}`), ErrSignatureInvalid},
+		{"other path", "POST", "/api/v1/ssh/reset", ts, "nonce-3",
+			Sign(key, "POST", "/api/v1/command/exec", ts, "nonce-3", body), body, ErrSignatureInvalid},
+		{"wrong key", "POST", "/api/v1/command/exec", ts, "nonce-4",
+			Sign("other", "POST", "/api/v1/command/exec", ts, "nonce-4", body), body, ErrSignatureInvalid},
+		{"expired", "POST", "/api/v1/command/exec", "1699999000", "nonce-5",
+			Sign(key, "POST", "/api/v1/command/exec", "1699999000", "nonce-5", body), body, ErrSignatureExpired},
+	}
+	for _, tc := range cases {
+		err := v.Verify(tc.method, tc.uri, tc.ts, tc.nonce, tc.sig, tc.body)
+		if !errors.Is(err, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, err, tc.want)
+		}
+	}
+}
+
+func TestRequestVerifierForgetsOldNonces(t *testing.T) {
+	v := NewRequestVerifier("key", time.Minute)
+	now := time.Unix(1700000000, 0)
+	v.now = func() time.Time { return now }
+
+	sign := func(nonce string) error {
+		ts := strconv.FormatInt(now.Unix(), 10)
+		return v.Verify("GET", "/api/v1/system/info", ts, nonce, Sign("key", "GET", "/api/v1/system/info", ts, nonce, nil), nil)
+	}
+	if err := sign("n1"); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(3 * time.Minute)
+	if err := sign("n2"); err != nil {
+		t.Fatal(err)
+	}
+	if len(v.nonces) != 1 {
+		t.Fatalf("expected expired nonce to be evicted, have %d", len(v.nonces))
+	}
+}
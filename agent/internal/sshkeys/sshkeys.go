@@ -0,0 +1,375 @@
+package sshkeys
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// 平台管理的公钥写在标记区块内，区块外的内容（如管理员密钥）同步时保持不变
+const (
+	BeginMarker = "# BEGIN remotegpu managed keys"
+	EndMarker   = "# END remotegpu managed keys"
+)
+
+var (
+	ErrInvalidKey = errors.New("invalid public key")
+	// ErrUnsafePath .ssh 目录或 authorized_keys 是符号链接、硬链接或属主不是目标用户
+	ErrUnsafePath = errors.New("unsafe authorized_keys path")
+)
+
+// Target authorized_keys 文件及其属主，UID/GID 为 -1 时不修改属主
+type Target struct {
+	Path string
+	UID  int
+	GID  int
+}
+
+// Resolve 根据用户名定位 authorized_keys，用户名为空时使用 Agent 运行用户
+func Resolve(username string) (*Target, error) {
+	var (
+		u   *user.User
+		err error
+	)
+	if username == "" {
+		u, err = user.Current()
+	} else {
+		u, err = user.Lookup(username)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("lookup user: %w", err)
+	}
+	if u.HomeDir == "" {
+		return nil, fmt.Errorf("user %s has no home directory", u.Username)
+	}
+
+	t := &Target{Path: filepath.Join(u.HomeDir, ".ssh", "authorized_keys"), UID: -1, GID: -1}
+	// 仅在以 root 运行时为其他用户修正属主
+	if os.Geteuid() == 0 {
+		uid, err1 := strconv.Atoi(u.Uid)
+		gid, err2 := strconv.Atoi(u.Gid)
+		if err1 == nil && err2 == nil {
+			t.UID, t.GID = uid, gid
+		}
+	}
+	return t, nil
+}
+
+// DeviceKey 只能使用部分 GPU 的公钥（GPU 粒度分配）
+type DeviceKey struct {
+	PublicKey  string `json:"public_key"`
+	GPUIndexes []int  `json:"gpu_indexes"`
+}
+
+// Sync 用 keys 全量替换受管区块，keys 为空时移除区块
+func Sync(t *Target, keys []string) error {
+	return SyncWithDevices(t, keys, nil)
+}
+
+// SyncWithDevices 同 Sync，devices 中的公钥附加 environment="CUDA_VISIBLE_DEVICES=..." 选项，
+// 登录会话默认只使用分配的 GPU（需要 sshd 开启 PermitUserEnvironment CUDA_VISIBLE_DEVICES）。
+// 该变量可以被用户修改，只是便利设置而不是隔离手段。
+// 同一公钥同时出现在 keys 中时不加限制，在 devices 中多次出现时合并 GPU
+func SyncWithDevices(t *Target, keys []string, devices []DeviceKey) error {
+	normalized, err := normalizeKeys(keys, devices)
+	if err != nil {
+		return err
+	}
+
+	// 家目录由租户控制，之后的读写都相对于已校验的目录句柄进行，不再按路径解析
+	dir, err := openSSHDir(t, len(normalized) > 0)
+	if err != nil || dir == nil {
+		return err
+	}
+	defer dir.Close()
+
+	existing, err := readKeys(dir, t)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if os.IsNotExist(err) && len(normalized) == 0 {
+		return nil
+	}
+
+	content := render(string(existing), normalized)
+	if content == string(existing) {
+		return nil
+	}
+	return writeAtomic(dir, t, []byte(content))
+}
+
+// Validate 校验公钥格式，不写入文件
+func Validate(keys []string, devices []DeviceKey) error {
+	_, err := normalizeKeys(keys, devices)
+	return err
+}
+
+// normalizeKeys 校验公钥格式并去重，生成受管区块的行
+func normalizeKeys(keys []string, devices []DeviceKey) ([]string, error) {
+	seen := make(map[string]bool, len(keys))
+	result := make([]string, 0, len(keys)+len(devices))
+	for _, k := range keys {
+		line, blob, err := normalizeKey(k)
+		if err != nil {
+			return nil, err
+		}
+		if line == "" || seen[blob] {
+			continue
+		}
+		seen[blob] = true
+		result = append(result, line)
+	}
+
+	var order []string
+	lines := make(map[string]string)
+	gpus := make(map[string]map[int]bool)
+	for _, d := range devices {
+		line, blob, err := normalizeKey(d.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+		if line == "" || seen[blob] {
+			continue
+		}
+		if len(d.GPUIndexes) == 0 {
+			return nil, fmt.Errorf("%w: no gpu assigned to %.40s", ErrInvalidKey, line)
+		}
+		if _, ok := gpus[blob]; !ok {
+			order = append(order, blob)
+			lines[blob] = line
+			gpus[blob] = make(map[int]bool)
+		}
+		for _, idx := range d.GPUIndexes {
+			if idx < 0 {
+				return nil, fmt.Errorf("%w: invalid gpu index %d", ErrInvalidKey, idx)
+			}
+			gpus[blob][idx] = true
+		}
+	}
+	for _, blob := range order {
+		indexes := make([]int, 0, len(gpus[blob]))
+		for idx := range gpus[blob] {
+			indexes = append(indexes, idx)
+		}
+		sort.Ints(indexes)
+		ids := make([]string, len(indexes))
+		for i, idx := range indexes {
+			ids[i] = strconv.Itoa(idx)
+		}
+		result = append(result, fmt.Sprintf("environment=\"CUDA_VISIBLE_DEVICES=%s\" %s", strings.Join(ids, ","), lines[blob]))
+	}
+	return result, nil
+}
+
+// normalizeKey 校验单个公钥，必须是单行的 "<type> <base64> [comment]"，返回规范化后的行和 base64 部分，空行返回空串
+func normalizeKey(k string) (string, string, error) {
+	k = strings.TrimSpace(k)
+	if k == "" {
+		return "", "", nil
+	}
+	if strings.ContainsAny(k, "\r\n") {
+		return "", "", fmt.Errorf("%w: multi-line key", ErrInvalidKey)
+	}
+	fields := strings.Fields(k)
+	if len(fields) < 2 || !isKeyType(fields[0]) {
+		return "", "", fmt.Errorf("%w: %.40s", ErrInvalidKey, k)
+	}
+	if _, err := base64.StdEncoding.DecodeString(fields[1]); err != nil {
+		return "", "", fmt.Errorf("%w: %.40s", ErrInvalidKey, k)
+	}
+	return strings.Join(fields, " "), fields[1], nil
+}
+
+func isKeyType(t string) bool {
+	for _, prefix := range []string{"ssh-", "ecdsa-sha2-", "sk-ssh-", "sk-ecdsa-sha2-"} {
+		if strings.HasPrefix(t, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// render 移除原有受管区块，并在末尾追加新区块
+func render(existing string, keys []string) string {
+	var kept []string
+	inBlock := false
+	for _, line := range strings.Split(existing, "\n") {
+		switch strings.TrimSpace(line) {
+		case BeginMarker:
+			inBlock = true
+			continue
+		case EndMarker:
+			inBlock = false
+			continue
+		}
+		if !inBlock {
+			kept = append(kept, line)
+		}
+	}
+	for len(kept) > 0 && strings.TrimSpace(kept[len(kept)-1]) == "" {
+		kept = kept[:len(kept)-1]
+	}
+
+	var b strings.Builder
+	for _, line := range kept {
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	if len(keys) > 0 {
+		b.WriteString(BeginMarker + "\n")
+		for _, k := range keys {
+			b.WriteString(k + "\n")
+		}
+		b.WriteString(EndMarker + "\n")
+	}
+	return b.String()
+}
+
+// expectedUID authorized_keys 及 .ssh 目录应有的属主，未指定时为 Agent 运行用户
+func expectedUID(t *Target) int {
+	if t.UID >= 0 {
+		return t.UID
+	}
+	return os.Geteuid()
+}
+
+// openSSHDir 以 O_NOFOLLOW 打开 .ssh 目录并校验属主，create 为 true 时目录不存在则创建
+// 目录不存在且无需创建时返回 nil
+func openSSHDir(t *Target, create bool) (*os.File, error) {
+	sshDir := filepath.Dir(t.Path)
+	home, err := os.OpenFile(filepath.Dir(sshDir), os.O_RDONLY|syscall.O_DIRECTORY|syscall.O_NOFOLLOW, 0)
+	if err != nil {
+		if os.IsNotExist(err) && !create {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("open home dir: %w", err)
+	}
+	defer home.Close()
+
+	name := filepath.Base(sshDir)
+	flags := syscall.O_RDONLY | syscall.O_DIRECTORY | syscall.O_NOFOLLOW | syscall.O_CLOEXEC
+	fd, err := syscall.Openat(int(home.Fd()), name, flags, 0)
+	if err == syscall.ENOENT {
+		if !create {
+			return nil, nil
+		}
+		if err := syscall.Mkdirat(int(home.Fd()), name, 0700); err != nil && err != syscall.EEXIST {
+			return nil, fmt.Errorf("create ssh dir: %w", err)
+		}
+		fd, err = syscall.Openat(int(home.Fd()), name, flags, 0)
+		if err == nil && t.UID >= 0 {
+			if err := syscall.Fchown(fd, t.UID, t.GID); err != nil {
+				syscall.Close(fd)
+				return nil, fmt.Errorf("chown %s: %w", sshDir, err)
+			}
+		}
+	}
+	if err == syscall.ELOOP || err == syscall.ENOTDIR {
+		return nil, fmt.Errorf("%w: %s is not a directory", ErrUnsafePath, sshDir)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open ssh dir: %w", err)
+	}
+
+	dir := os.NewFile(uintptr(fd), sshDir)
+	if err := checkOwner(dir, t); err != nil {
+		dir.Close()
+		return nil, err
+	}
+	return dir, nil
+}
+
+// readKeys 读取 authorized_keys，拒绝符号链接、非普通文件、硬链接和属主不符的文件，
+// 防止租户借此让 Agent 把其他文件的内容写进自己可读的 authorized_keys
+func readKeys(dir *os.File, t *Target) ([]byte, error) {
+	fd, err := syscall.Openat(int(dir.Fd()), filepath.Base(t.Path),
+		syscall.O_RDONLY|syscall.O_NOFOLLOW|syscall.O_NONBLOCK|syscall.O_CLOEXEC, 0)
+	switch {
+	case err == syscall.ENOENT:
+		return nil, os.ErrNotExist
+	case err == syscall.ELOOP:
+		return nil, fmt.Errorf("%w: %s is a symlink", ErrUnsafePath, t.Path)
+	case err != nil:
+		return nil, fmt.Errorf("read authorized_keys: %w", err)
+	}
+	f := os.NewFile(uintptr(fd), t.Path)
+	defer f.Close()
+
+	if err := checkOwner(f, t); err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("read authorized_keys: %w", err)
+	}
+	return data, nil
+}
+
+// checkOwner 校验目录或普通文件的属主为目标用户，普通文件还要求只有一个硬链接
+func checkOwner(f *os.File, t *Target) error {
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("stat %s: %w", f.Name(), err)
+	}
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fmt.Errorf("%w: cannot stat %s", ErrUnsafePath, f.Name())
+	}
+	if !info.IsDir() && (!info.Mode().IsRegular() || st.Nlink != 1) {
+		return fmt.Errorf("%w: %s is not a regular file", ErrUnsafePath, f.Name())
+	}
+	if uid := expectedUID(t); int(st.Uid) != uid {
+		return fmt.Errorf("%w: %s is owned by uid %d, expected %d", ErrUnsafePath, f.Name(), st.Uid, uid)
+	}
+	return nil
+}
+
+// writeAtomic 在 .ssh 目录内写入临时文件后 rename，避免 sshd 读到半截文件
+// 临时文件以 O_EXCL|O_NOFOLLOW 创建，rename 替换目录项本身，不会跟随符号链接
+func writeAtomic(dir *os.File, t *Target, content []byte) error {
+	dirfd := int(dir.Fd())
+	base := filepath.Base(t.Path)
+	tmpName := fmt.Sprintf(".%s.%d", base, rand.Uint32())
+	fd, err := syscall.Openat(dirfd, tmpName,
+		syscall.O_WRONLY|syscall.O_CREAT|syscall.O_EXCL|syscall.O_NOFOLLOW|syscall.O_CLOEXEC, 0600)
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+	tmp := os.NewFile(uintptr(fd), filepath.Join(dir.Name(), tmpName))
+	defer syscall.Unlinkat(dirfd, tmpName)
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write authorized_keys: %w", err)
+	}
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return fmt.Errorf("chmod authorized_keys: %w", err)
+	}
+	if t.UID >= 0 {
+		if err := tmp.Chown(t.UID, t.GID); err != nil {
+			tmp.Close()
+			return fmt.Errorf("chown authorized_keys: %w", err)
+		}
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("sync authorized_keys: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close authorized_keys: %w", err)
+	}
+	if err := syscall.Renameat(dirfd, tmpName, dirfd, base); err != nil {
+		return fmt.Errorf("replace authorized_keys: %w", err)
+	}
+	return nil
+}
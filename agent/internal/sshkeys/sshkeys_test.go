@@ -0,0 +1,186 @@
+package sshkeys
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	keyA     = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIOMqqnkVzrm0SdG6UOoqKLsabgH5C9okWi0dh2l9GKJl alice@laptop"
+	keyB     = "ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAABAQC7 bob"
+	adminKey = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIAdminKeyAdminKeyAdminKeyAdminKey0000000 admin"
+)
+
+func newTarget(t *testing.T) *Target {
+	return &Target{Path: filepath.Join(t.TempDir(), ".ssh", "authorized_keys"), UID: -1, GID: -1}
+}
+
+func TestSyncPreservesUnmanagedKeys(t *testing.T) {
+	target := newTarget(t)
+	if err := os.MkdirAll(filepath.Dir(target.Path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(target.Path, []byte(adminKey+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Sync(target, []string{keyA, keyB, keyA}); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	want := adminKey + "\n" + BeginMarker + "\n" + keyA + "\n" + keyB + "\n" + EndMarker + "\n"
+	if got, _ := os.ReadFile(target.Path); string(got) != want {
+		t.Fatalf("unexpected content:\n%s", got)
+	}
+
+	// 全量替换：只保留新的公钥
+	if err := Sync(target, []string{keyB}); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	want = adminKey + "\n" + BeginMarker + "\n" + keyB + "\n" + EndMarker + "\n"
+	if got, _ := os.ReadFile(target.Path); string(got) != want {
+		t.Fatalf("unexpected content:\n%s", got)
+	}
+
+	// 空列表移除受管区块
+	if err := Sync(target, nil); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if got, _ := os.ReadFile(target.Path); string(got) != adminKey+"\n" {
+		t.Fatalf("unexpected content:\n%s", got)
+	}
+
+	info, err := os.Stat(target.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Fatalf("expected 0600, got %v", info.Mode().Perm())
+	}
+}
+
+func TestSyncCreatesSSHDir(t *testing.T) {
+	target := newTarget(t)
+	if err := Sync(target, []string{keyA}); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	info, err := os.Stat(filepath.Dir(target.Path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0700 {
+		t.Fatalf("expected .ssh to be 0700, got %v", info.Mode().Perm())
+	}
+	entries, _ := os.ReadDir(filepath.Dir(target.Path))
+	if len(entries) != 1 {
+		t.Fatalf("temp file left behind: %v", entries)
+	}
+}
+
+func TestSyncRejectsInvalidKeys(t *testing.T) {
+	target := newTarget(t)
+	for _, k := range []string{
+		"not-a-key AAAA",
+		"ssh-ed25519",
+		"ssh-ed25519 !!!notbase64",
+		keyA + "\n" + `command="rm -rf /" ` + keyB,
+	} {
+		if err := Sync(target, []string{k}); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("%q: expected ErrInvalidKey, got %v", k, err)
+		}
+	}
+	if _, err := os.Stat(target.Path); !os.IsNotExist(err) {
+		t.Fatal("invalid keys must not create the file")
+	}
+}
+
+func TestSyncWithDevicesRestrictsGPUs(t *testing.T) {
+	target := newTarget(t)
+	devices := []DeviceKey{
+		{PublicKey: keyB, GPUIndexes: []int{3, 1}},
+		{PublicKey: keyB, GPUIndexes: []int{2}},
+		{PublicKey: keyA, GPUIndexes: []int{0}}, // 同时拥有整机权限时不加限制
+	}
+	if err := SyncWithDevices(target, []string{keyA}, devices); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	want := BeginMarker + "\n" + keyA + "\n" + `environment="CUDA_VISIBLE_DEVICES=1,2,3" ` + keyB + "\n" + EndMarker + "\n"
+	if got, _ := os.ReadFile(target.Path); string(got) != want {
+		t.Fatalf("unexpected content:\n%s", got)
+	}
+
+	for _, d := range []DeviceKey{
+		{PublicKey: keyB},
+		{PublicKey: keyB, GPUIndexes: []int{-1}},
+		{PublicKey: `command="sh" ` + keyB, GPUIndexes: []int{0}},
+	} {
+		if err := SyncWithDevices(target, nil, []DeviceKey{d}); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("%+v: expected ErrInvalidKey, got %v", d, err)
+		}
+	}
+}
+
+func TestRenderReplacesExistingBlock(t *testing.T) {
+	existing := strings.Join([]string{adminKey, BeginMarker, keyA, EndMarker, "# trailing comment", ""}, "\n")
+	got := render(existing, []string{keyB})
+	want := strings.Join([]string{adminKey, "# trailing comment", BeginMarker, keyB, EndMarker, ""}, "\n")
+	if got != want {
+		t.Fatalf("unexpected render:\n%s", got)
+	}
+}
+
+func TestSyncRefusesSymlinkedSSHDir(t *testing.T) {
+	home := t.TempDir()
+	victim := t.TempDir()
+	if err := os.Symlink(victim, filepath.Join(home, ".ssh")); err != nil {
+		t.Fatal(err)
+	}
+	target := &Target{Path: filepath.Join(home, ".ssh", "authorized_keys"), UID: -1, GID: -1}
+
+	if err := Sync(target, []string{keyA}); !errors.Is(err, ErrUnsafePath) {
+		t.Fatalf("expected ErrUnsafePath, got %v", err)
+	}
+	if entries, _ := os.ReadDir(victim); len(entries) != 0 {
+		t.Fatalf("symlink target was written: %v", entries)
+	}
+}
+
+func TestSyncRefusesLinkedAuthorizedKeys(t *testing.T) {
+	secret := filepath.Join(t.TempDir(), "shadow")
+	if err := os.WriteFile(secret, []byte("root:secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, link := range map[string]func(string, string) error{"symlink": os.Symlink, "hardlink": os.Link} {
+		target := newTarget(t)
+		if err := os.MkdirAll(filepath.Dir(target.Path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := link(secret, target.Path); err != nil {
+			t.Fatal(err)
+		}
+		if err := Sync(target, []string{keyA}); !errors.Is(err, ErrUnsafePath) {
+			t.Fatalf("%s: expected ErrUnsafePath, got %v", name, err)
+		}
+		if got, _ := os.ReadFile(secret); string(got) != "root:secret\n" {
+			t.Fatalf("%s: linked file was modified: %q", name, got)
+		}
+	}
+}
+
+func TestSyncRefusesForeignOwnedSSHDir(t *testing.T) {
+	target := newTarget(t)
+	if err := os.MkdirAll(filepath.Dir(target.Path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	// 目录属于当前用户，而目标用户是另一个 UID
+	target.UID, target.GID = os.Geteuid()+1000, os.Getegid()
+	if err := Sync(target, []string{keyA}); !errors.Is(err, ErrUnsafePath) {
+		t.Fatalf("expected ErrUnsafePath, got %v", err)
+	}
+	if _, err := os.Stat(target.Path); !os.IsNotExist(err) {
+		t.Fatalf("authorized_keys should not be created, stat err: %v", err)
+	}
+}
@@ -62,13 +62,53 @@ func (s *SQLiteStore) init() error {
 		assigned_at     TEXT,
 		started_at      TEXT,
 		ended_at        TEXT,
-		synced          INTEGER DEFAULT 0
+		synced          INTEGER DEFAULT 0,
+		assigned_gpus   TEXT DEFAULT '',
+		run_as          TEXT DEFAULT ''
 	);
 	CREATE INDEX IF NOT EXISTS idx_local_tasks_status ON local_tasks(status);
 	CREATE INDEX IF NOT EXISTS idx_local_tasks_priority ON local_tasks(priority);
 	`
-	_, err := s.db.Exec(schema)
-	return err
+	if _, err := s.db.Exec(schema); err != nil {
+		return err
+	}
+	return s.migrate()
+}
+
+// migrate 为旧版本创建的表补充新增列，列追加在末尾以保持 SELECT * 的列顺序
+func (s *SQLiteStore) migrate() error {
+	rows, err := s.db.Query(`PRAGMA table_info(local_tasks)`)
+	if err != nil {
+		return err
+	}
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var dflt sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dflt, &pk); err != nil {
+			rows.Close()
+			return err
+		}
+		existing[name] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, col := range []struct{ name, def string }{
+		{"assigned_gpus", "TEXT DEFAULT ''"},
+		{"run_as", "TEXT DEFAULT ''"},
+	} {
+		if existing[col.name] {
+			continue
+		}
+		if _, err := s.db.Exec(`ALTER TABLE local_tasks ADD COLUMN ` + col.name + ` ` + col.def); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // Close 关闭数据库连接
@@ -80,6 +120,7 @@ func (s *SQLiteStore) Close() error {
 func (s *SQLiteStore) Save(task *models.Task) error {
 	argsJSON, _ := json.Marshal(task.Args)
 	envJSON, _ := json.Marshal(task.Env)
+	gpusJSON, _ := json.Marshal(task.AssignedGPUs)
 
 	_, err := s.db.Exec(`
 		INSERT OR REPLACE INTO local_tasks (
@@ -88,8 +129,9 @@ func (s *SQLiteStore) Save(task *models.Task) error {
 			status, exit_code, stdout, stderr, error,
 			machine_id, group_id, parent_id,
 			assigned_agent_id, lease_expires_at, attempt_id,
-			created_at, assigned_at, started_at, ended_at, synced
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			created_at, assigned_at, started_at, ended_at, synced,
+			assigned_gpus, run_as
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`,
 		task.ID, task.Name, task.Type, task.Command, string(argsJSON), task.WorkDir, string(envJSON), task.Timeout,
 		task.Priority, task.RetryCount, task.RetryDelay, task.MaxRetries,
@@ -98,6 +140,7 @@ func (s *SQLiteStore) Save(task *models.Task) error {
 		task.AssignedAgentID, formatTime(task.LeaseExpiresAt), task.AttemptID,
 		formatTime(task.CreatedAt), formatTime(task.AssignedAt), formatTime(task.StartedAt), formatTime(task.EndedAt),
 		boolToInt(task.Synced),
+		string(gpusJSON), task.RunAs,
 	)
 	return err
 }
@@ -129,7 +172,7 @@ type scanner interface {
 
 func (s *SQLiteStore) scanTask(row scanner) (*models.Task, error) {
 	var task models.Task
-	var argsJSON, envJSON string
+	var argsJSON, envJSON, gpusJSON string
 	var leaseExpires, createdAt, assignedAt, startedAt, endedAt string
 	var synced int
 
@@ -140,6 +183,7 @@ func (s *SQLiteStore) scanTask(row scanner) (*models.Task, error) {
 		&task.MachineID, &task.GroupID, &task.ParentID,
 		&task.AssignedAgentID, &leaseExpires, &task.AttemptID,
 		&createdAt, &assignedAt, &startedAt, &endedAt, &synced,
+		&gpusJSON, &task.RunAs,
 	)
 	if err != nil {
 		return nil, err
@@ -147,6 +191,7 @@ func (s *SQLiteStore) scanTask(row scanner) (*models.Task, error) {
 
 	json.Unmarshal([]byte(argsJSON), &task.Args)
 	json.Unmarshal([]byte(envJSON), &task.Env)
+	json.Unmarshal([]byte(gpusJSON), &task.AssignedGPUs)
 	task.LeaseExpiresAt = parseTime(leaseExpires)
 	task.CreatedAt = parseTime(createdAt)
 	task.AssignedAt = parseTime(assignedAt)
@@ -1,6 +1,7 @@
 package store
 
 import (
+	"database/sql"
 	"os"
 	"testing"
 	"time"
@@ -196,3 +197,79 @@ func TestSaveUpdatesExisting(t *testing.T) {
 		t.Errorf("更新后 Stdout 应为 output，实际为 %q", got.Stdout)
 	}
 }
+
+func TestSaveAndGetWithRunAs(t *testing.T) {
+	st := tempStore(t)
+
+	task := &models.Task{
+		ID:           "t1",
+		Command:      "nvidia-smi",
+		Status:       models.TaskStatusAssigned,
+		AssignedGPUs: []int{1, 3},
+		RunAs:        "rg-c7",
+	}
+	if err := st.Save(task); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := st.Get("t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.RunAs != "rg-c7" {
+		t.Errorf("expected run_as rg-c7, got %q", got.RunAs)
+	}
+	if len(got.AssignedGPUs) != 2 || got.AssignedGPUs[0] != 1 || got.AssignedGPUs[1] != 3 {
+		t.Errorf("unexpected assigned gpus: %v", got.AssignedGPUs)
+	}
+}
+
+func TestMigrateAddsColumnsToOldTable(t *testing.T) {
+	f, err := os.CreateTemp("", "agent-test-*.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	t.Cleanup(func() { os.Remove(f.Name()) })
+
+	// 旧版本的表结构，缺少 assigned_gpus 和 run_as
+	old, err := sql.Open("sqlite3", f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = old.Exec(`CREATE TABLE local_tasks (
+		id TEXT PRIMARY KEY, name TEXT, type TEXT DEFAULT 'shell', command TEXT NOT NULL,
+		args TEXT, workdir TEXT, env TEXT, timeout INTEGER DEFAULT 3600,
+		priority INTEGER DEFAULT 5, retry_count INTEGER DEFAULT 0, retry_delay INTEGER DEFAULT 60, max_retries INTEGER DEFAULT 3,
+		status TEXT DEFAULT 'pending', exit_code INTEGER, stdout TEXT, stderr TEXT, error TEXT,
+		machine_id TEXT, group_id TEXT, parent_id TEXT,
+		assigned_agent_id TEXT, lease_expires_at TEXT, attempt_id TEXT,
+		created_at TEXT, assigned_at TEXT, started_at TEXT, ended_at TEXT, synced INTEGER DEFAULT 0
+	);
+	INSERT INTO local_tasks VALUES ('old', '', 'shell', 'echo', '[]', '', '{}', 60, 5, 0, 60, 3,
+		'pending', 0, '', '', '', '', '', '', '', '', '', '', '', '', '', 0);`)
+	old.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	st, err := NewSQLiteStore(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+
+	got, err := st.Get("old")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.RunAs != "" || len(got.AssignedGPUs) != 0 {
+		t.Errorf("unexpected migrated task: %+v", got)
+	}
+	if err := st.Save(&models.Task{ID: "new", Command: "echo", RunAs: "rg-c1"}); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := st.Get("new"); err != nil || got.RunAs != "rg-c1" {
+		t.Errorf("expected run_as after migration, got %+v, err %v", got, err)
+	}
+}
@@ -0,0 +1,272 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/YoungBoyGod/remotegpu-agent/internal/sshkeys"
+)
+
+// Group 受管账号所属的系统组，同步时只会删除该组内的账号
+const Group = "remotegpu-tenants"
+
+// 账号名必须以 rg- 开头，避免误删机器上的其他账号
+var usernamePattern = regexp.MustCompile(`^rg-[a-z0-9][a-z0-9-]{0,28}$`)
+
+// 删除账号前清理其临时文件的目录
+var tempDirs = []string{"/tmp", "/var/tmp", "/dev/shm"}
+
+var ErrInvalidAccount = errors.New("invalid tenant account")
+
+// Account GPU 粒度分配客户的独立系统账号
+// 客户之间的文件和进程通过系统账号隔离；Server 下发的任务以该账号运行并放入只允许访问已分配 GPU 的设备 cgroup。
+// GPUIndexes 仅用于在 SSH 登录会话中设置 CUDA_VISIBLE_DEVICES，不是访问控制
+type Account struct {
+	Username   string   `json:"username"`
+	PublicKeys []string `json:"public_keys"`
+	GPUIndexes []int    `json:"gpu_indexes"`
+}
+
+// Syncer 受管账号同步接口
+type Syncer interface {
+	// Sync 全量同步：创建缺少的账号并写入公钥，删除不在列表中的受管账号（结束进程并删除其数据）
+	Sync(ctx context.Context, accounts []Account) error
+}
+
+// Manager 基于 useradd/userdel 等系统命令的账号管理实现
+type Manager struct {
+	run     func(ctx context.Context, name string, args ...string) ([]byte, error)
+	lookup  func(username string) (*user.User, error)
+	keysDir func(u *user.User) string
+	// retryDelay 结束进程后等待进程退出的间隔
+	retryDelay time.Duration
+}
+
+// NewManager 创建账号管理器
+func NewManager() *Manager {
+	return &Manager{
+		run:        runCommand,
+		lookup:     user.Lookup,
+		keysDir:    func(u *user.User) string { return filepath.Join(u.HomeDir, ".ssh") },
+		retryDelay: 200 * time.Millisecond,
+	}
+}
+
+// Validate 校验账号名、公钥和 GPU 序号
+func Validate(accounts []Account) error {
+	seen := make(map[string]bool, len(accounts))
+	for _, a := range accounts {
+		if !usernamePattern.MatchString(a.Username) {
+			return fmt.Errorf("%w: username %q", ErrInvalidAccount, a.Username)
+		}
+		if seen[a.Username] {
+			return fmt.Errorf("%w: duplicate username %q", ErrInvalidAccount, a.Username)
+		}
+		seen[a.Username] = true
+		if len(a.GPUIndexes) == 0 {
+			return fmt.Errorf("%w: no gpu assigned to %s", ErrInvalidAccount, a.Username)
+		}
+		if err := sshkeys.Validate(nil, devices(a)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LookupManaged 查找平台创建的受管账号，账号名不合法或账号不在受管组内时返回 ErrInvalidAccount
+func LookupManaged(username string) (*user.User, error) {
+	if !usernamePattern.MatchString(username) {
+		return nil, fmt.Errorf("%w: username %q", ErrInvalidAccount, username)
+	}
+	u, err := user.Lookup(username)
+	if err != nil {
+		return nil, err
+	}
+	g, err := user.LookupGroup(Group)
+	if err != nil {
+		return nil, err
+	}
+	gids, err := u.GroupIds()
+	if err != nil {
+		return nil, err
+	}
+	for _, gid := range gids {
+		if gid == g.Gid {
+			return u, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: user %s is not managed", ErrInvalidAccount, username)
+}
+
+// Sync 全量同步受管账号，单个账号失败时继续处理其他账号
+func (m *Manager) Sync(ctx context.Context, accounts []Account) error {
+	if err := Validate(accounts); err != nil {
+		return err
+	}
+	existing, err := m.list(ctx)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	wanted := make(map[string]bool, len(accounts))
+	for _, a := range accounts {
+		wanted[a.Username] = true
+		if err := m.ensure(ctx, a, existing[a.Username]); err != nil {
+			errs = append(errs, fmt.Errorf("sync account %s: %w", a.Username, err))
+		}
+	}
+	for name := range existing {
+		if wanted[name] {
+			continue
+		}
+		if err := m.remove(ctx, name); err != nil {
+			errs = append(errs, fmt.Errorf("remove account %s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// list 返回受管组内的账号
+func (m *Manager) list(ctx context.Context) (map[string]bool, error) {
+	out, err := m.run(ctx, "getent", "group", Group)
+	if err != nil {
+		// getent 在组不存在时退出码为 2
+		if exitCode(err) == 2 {
+			return map[string]bool{}, nil
+		}
+		return nil, err
+	}
+	// 格式 name:x:gid:member1,member2
+	fields := strings.Split(strings.TrimSpace(string(out)), ":")
+	members := make(map[string]bool)
+	if len(fields) < 4 {
+		return members, nil
+	}
+	for _, name := range strings.Split(fields[3], ",") {
+		if usernamePattern.MatchString(name) {
+			members[name] = true
+		}
+	}
+	return members, nil
+}
+
+// ensure 创建账号（家目录只有本人可访问）并全量写入公钥
+func (m *Manager) ensure(ctx context.Context, a Account, managed bool) error {
+	u, err := m.lookup(a.Username)
+	if err != nil {
+		var unknown user.UnknownUserError
+		if !errors.As(err, &unknown) {
+			return err
+		}
+		if _, err := m.run(ctx, "groupadd", "-f", Group); err != nil {
+			return err
+		}
+		if _, err := m.run(ctx, "useradd", "--create-home", "--shell", "/bin/bash", "--user-group",
+			"--groups", Group, "--comment", "remotegpu tenant", a.Username); err != nil {
+			return err
+		}
+		if u, err = m.lookup(a.Username); err != nil {
+			return err
+		}
+	} else if !managed {
+		// 同名账号不是平台创建的，不接管
+		return fmt.Errorf("%w: user %s exists and is not managed", ErrInvalidAccount, a.Username)
+	}
+	if u.HomeDir != "" {
+		if err := os.Chmod(u.HomeDir, 0700); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("chmod home: %w", err)
+		}
+	}
+
+	t := &sshkeys.Target{Path: filepath.Join(m.keysDir(u), "authorized_keys"), UID: -1, GID: -1}
+	if os.Geteuid() == 0 {
+		t.UID, _ = strconv.Atoi(u.Uid)
+		t.GID, _ = strconv.Atoi(u.Gid)
+	}
+	return sshkeys.SyncWithDevices(t, nil, devices(a))
+}
+
+// remove 结束账号的所有进程，删除其临时文件、家目录和账号
+func (m *Manager) remove(ctx context.Context, username string) error {
+	u, err := m.lookup(username)
+	if err != nil {
+		var unknown user.UnknownUserError
+		if errors.As(err, &unknown) {
+			return nil
+		}
+		return err
+	}
+
+	// pkill 没有匹配进程时退出码为 1；进程可能在结束期间派生新进程，重试直到没有进程
+	stopped := false
+	for i := 0; i < 5 && !stopped; i++ {
+		if _, err := m.run(ctx, "pkill", "-KILL", "-U", u.Uid); err != nil {
+			if exitCode(err) != 1 {
+				return err
+			}
+			stopped = true
+			continue
+		}
+		time.Sleep(m.retryDelay)
+	}
+	if !stopped {
+		return fmt.Errorf("processes of %s are still running", username)
+	}
+
+	for _, dir := range tempDirs {
+		if _, err := os.Stat(dir); err != nil {
+			continue
+		}
+		if _, err := m.run(ctx, "find", dir, "-xdev", "-user", u.Uid, "-delete"); err != nil {
+			slog.Warn("remove tenant temp files error", "user", username, "dir", dir, "error", err)
+		}
+	}
+	// --remove 同时删除家目录和邮件
+	if _, err := m.run(ctx, "userdel", "--remove", username); err != nil {
+		return err
+	}
+	slog.Info("tenant account removed", "user", username)
+	return nil
+}
+
+func devices(a Account) []sshkeys.DeviceKey {
+	result := make([]sshkeys.DeviceKey, 0, len(a.PublicKeys))
+	for _, k := range a.PublicKeys {
+		result = append(result, sshkeys.DeviceKey{PublicKey: k, GPUIndexes: a.GPUIndexes})
+	}
+	return result
+}
+
+// exitCode 返回命令的退出码，命令未能执行时返回 -1
+func exitCode(err error) int {
+	var exitErr interface{ ExitCode() int }
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
+func runCommand(ctx context.Context, name string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return out, fmt.Errorf("%s: %w: %s", name, err, msg)
+		}
+		return out, fmt.Errorf("%s: %w", name, err)
+	}
+	return out, nil
+}
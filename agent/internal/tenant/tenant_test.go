@@ -0,0 +1,215 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testKey = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIG1vY2s= alice"
+
+type exitError int
+
+func (e exitError) Error() string { return "exit status" }
+func (e exitError) ExitCode() int { return int(e) }
+
+// fakeSystem 在内存中模拟系统账号，记录执行的命令
+type fakeSystem struct {
+	root    string
+	users   map[string]*user.User
+	members []string
+	// running 账号仍有进程时 pkill 返回 0 的次数
+	running map[string]int
+	calls   []string
+}
+
+func newFakeManager(t *testing.T) (*Manager, *fakeSystem) {
+	fs := &fakeSystem{root: t.TempDir(), users: map[string]*user.User{}, running: map[string]int{}}
+	m := NewManager()
+	m.retryDelay = 0
+	m.lookup = func(name string) (*user.User, error) {
+		if u, ok := fs.users[name]; ok {
+			return u, nil
+		}
+		return nil, user.UnknownUserError(name)
+	}
+	m.run = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		fs.calls = append(fs.calls, strings.Join(append([]string{name}, args...), " "))
+		switch name {
+		case "getent":
+			if fs.members == nil {
+				return nil, exitError(2)
+			}
+			return []byte(Group + ":x:990:" + strings.Join(fs.members, ",") + "\n"), nil
+		case "useradd":
+			fs.addUser(args[len(args)-1], true)
+		case "pkill":
+			for n, u := range fs.users {
+				if u.Uid == args[len(args)-1] && fs.running[n] > 0 {
+					fs.running[n]--
+					return nil, nil
+				}
+			}
+			return nil, exitError(1)
+		case "userdel":
+			name := args[len(args)-1]
+			os.RemoveAll(fs.users[name].HomeDir)
+			delete(fs.users, name)
+			for i, m := range fs.members {
+				if m == name {
+					fs.members = append(fs.members[:i], fs.members[i+1:]...)
+					break
+				}
+			}
+		}
+		return nil, nil
+	}
+	return m, fs
+}
+
+func (fs *fakeSystem) addUser(name string, managed bool) *user.User {
+	home := filepath.Join(fs.root, name)
+	os.MkdirAll(home, 0755)
+	u := &user.User{Username: name, Uid: "100" + string(rune('0'+len(fs.users))), Gid: "100", HomeDir: home}
+	fs.users[name] = u
+	if managed {
+		fs.members = append(fs.members, name)
+	}
+	return u
+}
+
+func (fs *fakeSystem) ran(prefix string) bool {
+	for _, c := range fs.calls {
+		if strings.HasPrefix(c, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestSyncCreatesAccounts(t *testing.T) {
+	m, fs := newFakeManager(t)
+
+	err := m.Sync(context.Background(), []Account{{Username: "rg-c1", PublicKeys: []string{testKey}, GPUIndexes: []int{2, 0}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !fs.ran("groupadd -f "+Group) || !fs.ran("useradd --create-home") {
+		t.Fatalf("account not created: %v", fs.calls)
+	}
+
+	home := fs.users["rg-c1"].HomeDir
+	info, err := os.Stat(home)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0700 {
+		t.Errorf("home mode = %v, want 0700", info.Mode().Perm())
+	}
+	data, err := os.ReadFile(filepath.Join(home, ".ssh", "authorized_keys"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `environment="CUDA_VISIBLE_DEVICES=0,2" `+testKey) {
+		t.Errorf("unexpected authorized_keys:\n%s", data)
+	}
+
+	// 再次同步不重复创建
+	fs.calls = nil
+	if err := m.Sync(context.Background(), []Account{{Username: "rg-c1", GPUIndexes: []int{0}}}); err != nil {
+		t.Fatal(err)
+	}
+	if fs.ran("useradd") {
+		t.Errorf("account created twice: %v", fs.calls)
+	}
+	data, _ = os.ReadFile(filepath.Join(home, ".ssh", "authorized_keys"))
+	if strings.Contains(string(data), testKey) {
+		t.Errorf("key not removed:\n%s", data)
+	}
+}
+
+func TestSyncRemovesDepartedAccounts(t *testing.T) {
+	m, fs := newFakeManager(t)
+	fs.addUser("rg-c1", true)
+	gone := fs.addUser("rg-c2", true)
+	fs.running["rg-c2"] = 2
+	os.WriteFile(filepath.Join(gone.HomeDir, "model.pt"), []byte("weights"), 0600)
+
+	if err := m.Sync(context.Background(), []Account{{Username: "rg-c1", GPUIndexes: []int{0}}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := fs.users["rg-c2"]; ok {
+		t.Fatal("departed account not removed")
+	}
+	if _, err := os.Stat(gone.HomeDir); !os.IsNotExist(err) {
+		t.Errorf("home of departed account not removed: %v", err)
+	}
+	if fs.running["rg-c2"] != 0 || !fs.ran("pkill -KILL -U "+gone.Uid) {
+		t.Errorf("processes not killed: %v", fs.calls)
+	}
+	if !fs.ran("userdel --remove rg-c2") {
+		t.Errorf("userdel not called: %v", fs.calls)
+	}
+	if _, ok := fs.users["rg-c1"]; !ok {
+		t.Error("remaining account removed")
+	}
+
+	// 清理机器时删除全部账号
+	if err := m.Sync(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(fs.users) != 0 {
+		t.Errorf("accounts left: %v", fs.users)
+	}
+}
+
+func TestSyncKeepsUnmanagedAccounts(t *testing.T) {
+	m, fs := newFakeManager(t)
+	fs.addUser("rg-admin", false)
+
+	err := m.Sync(context.Background(), []Account{{Username: "rg-admin", GPUIndexes: []int{0}}})
+	if !errors.Is(err, ErrInvalidAccount) {
+		t.Fatalf("expected ErrInvalidAccount, got %v", err)
+	}
+	if err := m.Sync(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := fs.users["rg-admin"]; !ok || fs.ran("userdel") {
+		t.Error("unmanaged account removed")
+	}
+}
+
+func TestSyncStuckProcesses(t *testing.T) {
+	m, fs := newFakeManager(t)
+	fs.addUser("rg-c1", true)
+	fs.running["rg-c1"] = 100
+
+	if err := m.Sync(context.Background(), nil); err == nil {
+		t.Fatal("expected error while processes keep running")
+	}
+	if _, ok := fs.users["rg-c1"]; !ok || fs.ran("userdel") {
+		t.Error("account removed while processes are running")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	bad := [][]Account{
+		{{Username: "root", GPUIndexes: []int{0}}},
+		{{Username: "rg-../x", GPUIndexes: []int{0}}},
+		{{Username: "rg-c1"}},
+		{{Username: "rg-c1", GPUIndexes: []int{0}}, {Username: "rg-c1", GPUIndexes: []int{1}}},
+		{{Username: "rg-c1", GPUIndexes: []int{0}, PublicKeys: []string{"not a key"}}},
+	}
+	for i, accounts := range bad {
+		if err := Validate(accounts); err == nil {
+			t.Errorf("case %d: expected error", i)
+		}
+	}
+	if err := Validate([]Account{{Username: "rg-c12", GPUIndexes: []int{1}, PublicKeys: []string{testKey}}}); err != nil {
+		t.Errorf("valid account rejected: %v", err)
+	}
+}
@@ -1,9 +1,11 @@
 // Code generated by protoc-gen-go. DO NOT EDIT.
 // versions:
 // 	protoc-gen-go v1.36.11
-// 	protoc        v3.21.12
+// 	protoc        (unknown)
 // source: api/proto/agent.proto
 
+// agent/api/proto/agent.proto 为同一份协议定义（仅 go_package 不同），修改时需同步两处并重新生成代码
+
 package agent
 
 import (
@@ -204,6 +206,137 @@ func (x *ResetSSHRequest) GetUsername() string {
 	return ""
 }
 
+// 同步SSH密钥请求
+type SyncSSHKeysRequest struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	HostId     string                 `protobuf:"bytes,1,opt,name=host_id,json=hostId,proto3" json:"host_id,omitempty"`
+	PublicKeys []string               `protobuf:"bytes,2,rep,name=public_keys,json=publicKeys,proto3" json:"public_keys,omitempty"`
+	Username   string                 `protobuf:"bytes,3,opt,name=username,proto3" json:"username,omitempty"`
+	// GPU 粒度分配的客户，每个客户使用独立的系统账号；全量同步，不在列表中的受管账号会被删除（结束进程并删除数据）
+	Tenants       []*TenantAccount `protobuf:"bytes,5,rep,name=tenants,proto3" json:"tenants,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SyncSSHKeysRequest) Reset() {
+	*x = SyncSSHKeysRequest{}
+	mi := &file_api_proto_agent_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SyncSSHKeysRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SyncSSHKeysRequest) ProtoMessage() {}
+
+func (x *SyncSSHKeysRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SyncSSHKeysRequest.ProtoReflect.Descriptor instead.
+func (*SyncSSHKeysRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *SyncSSHKeysRequest) GetHostId() string {
+	if x != nil {
+		return x.HostId
+	}
+	return ""
+}
+
+func (x *SyncSSHKeysRequest) GetPublicKeys() []string {
+	if x != nil {
+		return x.PublicKeys
+	}
+	return nil
+}
+
+func (x *SyncSSHKeysRequest) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *SyncSSHKeysRequest) GetTenants() []*TenantAccount {
+	if x != nil {
+		return x.Tenants
+	}
+	return nil
+}
+
+// GPU 粒度分配客户的系统账号
+type TenantAccount struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Username      string                 `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+	PublicKeys    []string               `protobuf:"bytes,2,rep,name=public_keys,json=publicKeys,proto3" json:"public_keys,omitempty"`
+	GpuIndexes    []int32                `protobuf:"varint,3,rep,packed,name=gpu_indexes,json=gpuIndexes,proto3" json:"gpu_indexes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TenantAccount) Reset() {
+	*x = TenantAccount{}
+	mi := &file_api_proto_agent_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TenantAccount) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TenantAccount) ProtoMessage() {}
+
+func (x *TenantAccount) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TenantAccount.ProtoReflect.Descriptor instead.
+func (*TenantAccount) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *TenantAccount) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *TenantAccount) GetPublicKeys() []string {
+	if x != nil {
+		return x.PublicKeys
+	}
+	return nil
+}
+
+func (x *TenantAccount) GetGpuIndexes() []int32 {
+	if x != nil {
+		return x.GpuIndexes
+	}
+	return nil
+}
+
 // 清理请求
 type CleanupRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -215,7 +348,7 @@ type CleanupRequest struct {
 
 func (x *CleanupRequest) Reset() {
 	*x = CleanupRequest{}
-	mi := &file_api_proto_agent_proto_msgTypes[3]
+	mi := &file_api_proto_agent_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -227,7 +360,7 @@ func (x *CleanupRequest) String() string {
 func (*CleanupRequest) ProtoMessage() {}
 
 func (x *CleanupRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[3]
+	mi := &file_api_proto_agent_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -240,7 +373,7 @@ func (x *CleanupRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CleanupRequest.ProtoReflect.Descriptor instead.
 func (*CleanupRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{3}
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *CleanupRequest) GetHostId() string {
@@ -265,13 +398,14 @@ type MountDatasetRequest struct {
 	SourcePath    string                 `protobuf:"bytes,3,opt,name=source_path,json=sourcePath,proto3" json:"source_path,omitempty"`
 	MountPoint    string                 `protobuf:"bytes,4,opt,name=mount_point,json=mountPoint,proto3" json:"mount_point,omitempty"`
 	ReadOnly      bool                   `protobuf:"varint,5,opt,name=read_only,json=readOnly,proto3" json:"read_only,omitempty"`
+	SourceType    string                 `protobuf:"bytes,6,opt,name=source_type,json=sourceType,proto3" json:"source_type,omitempty"` // nfs, s3, bind
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *MountDatasetRequest) Reset() {
 	*x = MountDatasetRequest{}
-	mi := &file_api_proto_agent_proto_msgTypes[4]
+	mi := &file_api_proto_agent_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -283,7 +417,7 @@ func (x *MountDatasetRequest) String() string {
 func (*MountDatasetRequest) ProtoMessage() {}
 
 func (x *MountDatasetRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[4]
+	mi := &file_api_proto_agent_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -296,7 +430,7 @@ func (x *MountDatasetRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use MountDatasetRequest.ProtoReflect.Descriptor instead.
 func (*MountDatasetRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{4}
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *MountDatasetRequest) GetHostId() string {
@@ -334,6 +468,74 @@ func (x *MountDatasetRequest) GetReadOnly() bool {
 	return false
 }
 
+func (x *MountDatasetRequest) GetSourceType() string {
+	if x != nil {
+		return x.SourceType
+	}
+	return ""
+}
+
+// 卸载数据集请求
+type UnmountDatasetRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	HostId        string                 `protobuf:"bytes,1,opt,name=host_id,json=hostId,proto3" json:"host_id,omitempty"`
+	DatasetId     uint32                 `protobuf:"varint,2,opt,name=dataset_id,json=datasetId,proto3" json:"dataset_id,omitempty"`
+	MountPoint    string                 `protobuf:"bytes,3,opt,name=mount_point,json=mountPoint,proto3" json:"mount_point,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UnmountDatasetRequest) Reset() {
+	*x = UnmountDatasetRequest{}
+	mi := &file_api_proto_agent_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UnmountDatasetRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UnmountDatasetRequest) ProtoMessage() {}
+
+func (x *UnmountDatasetRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UnmountDatasetRequest.ProtoReflect.Descriptor instead.
+func (*UnmountDatasetRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *UnmountDatasetRequest) GetHostId() string {
+	if x != nil {
+		return x.HostId
+	}
+	return ""
+}
+
+func (x *UnmountDatasetRequest) GetDatasetId() uint32 {
+	if x != nil {
+		return x.DatasetId
+	}
+	return 0
+}
+
+func (x *UnmountDatasetRequest) GetMountPoint() string {
+	if x != nil {
+		return x.MountPoint
+	}
+	return ""
+}
+
 // 系统信息请求
 type SystemInfoRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -344,7 +546,7 @@ type SystemInfoRequest struct {
 
 func (x *SystemInfoRequest) Reset() {
 	*x = SystemInfoRequest{}
-	mi := &file_api_proto_agent_proto_msgTypes[5]
+	mi := &file_api_proto_agent_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -356,7 +558,7 @@ func (x *SystemInfoRequest) String() string {
 func (*SystemInfoRequest) ProtoMessage() {}
 
 func (x *SystemInfoRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[5]
+	mi := &file_api_proto_agent_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -369,7 +571,7 @@ func (x *SystemInfoRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SystemInfoRequest.ProtoReflect.Descriptor instead.
 func (*SystemInfoRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{5}
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *SystemInfoRequest) GetHostId() string {
@@ -394,7 +596,7 @@ type GPUInfo struct {
 
 func (x *GPUInfo) Reset() {
 	*x = GPUInfo{}
-	mi := &file_api_proto_agent_proto_msgTypes[6]
+	mi := &file_api_proto_agent_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -406,7 +608,7 @@ func (x *GPUInfo) String() string {
 func (*GPUInfo) ProtoMessage() {}
 
 func (x *GPUInfo) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[6]
+	mi := &file_api_proto_agent_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -419,7 +621,7 @@ func (x *GPUInfo) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GPUInfo.ProtoReflect.Descriptor instead.
 func (*GPUInfo) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{6}
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *GPUInfo) GetIndex() int32 {
@@ -484,7 +686,7 @@ type SystemInfo struct {
 
 func (x *SystemInfo) Reset() {
 	*x = SystemInfo{}
-	mi := &file_api_proto_agent_proto_msgTypes[7]
+	mi := &file_api_proto_agent_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -496,7 +698,7 @@ func (x *SystemInfo) String() string {
 func (*SystemInfo) ProtoMessage() {}
 
 func (x *SystemInfo) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[7]
+	mi := &file_api_proto_agent_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -509,7 +711,7 @@ func (x *SystemInfo) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SystemInfo.ProtoReflect.Descriptor instead.
 func (*SystemInfo) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{7}
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *SystemInfo) GetHostname() string {
@@ -601,7 +803,7 @@ type ExecuteCommandRequest struct {
 
 func (x *ExecuteCommandRequest) Reset() {
 	*x = ExecuteCommandRequest{}
-	mi := &file_api_proto_agent_proto_msgTypes[8]
+	mi := &file_api_proto_agent_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -613,7 +815,7 @@ func (x *ExecuteCommandRequest) String() string {
 func (*ExecuteCommandRequest) ProtoMessage() {}
 
 func (x *ExecuteCommandRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[8]
+	mi := &file_api_proto_agent_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -626,7 +828,7 @@ func (x *ExecuteCommandRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ExecuteCommandRequest.ProtoReflect.Descriptor instead.
 func (*ExecuteCommandRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{8}
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *ExecuteCommandRequest) GetHostId() string {
@@ -662,7 +864,7 @@ type ExecuteCommandResponse struct {
 
 func (x *ExecuteCommandResponse) Reset() {
 	*x = ExecuteCommandResponse{}
-	mi := &file_api_proto_agent_proto_msgTypes[9]
+	mi := &file_api_proto_agent_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -674,7 +876,7 @@ func (x *ExecuteCommandResponse) String() string {
 func (*ExecuteCommandResponse) ProtoMessage() {}
 
 func (x *ExecuteCommandResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[9]
+	mi := &file_api_proto_agent_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -687,7 +889,7 @@ func (x *ExecuteCommandResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ExecuteCommandResponse.ProtoReflect.Descriptor instead.
 func (*ExecuteCommandResponse) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{9}
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{12}
 }
 
 func (x *ExecuteCommandResponse) GetExitCode() int32 {
@@ -711,6 +913,221 @@ func (x *ExecuteCommandResponse) GetStderr() string {
 	return ""
 }
 
+// 命令输出片段，最后一条消息 done 为 true 并携带退出码
+type CommandOutput struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Stream        string                 `protobuf:"bytes,1,opt,name=stream,proto3" json:"stream,omitempty"` // stdout, stderr
+	Data          []byte                 `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	Done          bool                   `protobuf:"varint,3,opt,name=done,proto3" json:"done,omitempty"`
+	ExitCode      int32                  `protobuf:"varint,4,opt,name=exit_code,json=exitCode,proto3" json:"exit_code,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CommandOutput) Reset() {
+	*x = CommandOutput{}
+	mi := &file_api_proto_agent_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CommandOutput) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CommandOutput) ProtoMessage() {}
+
+func (x *CommandOutput) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CommandOutput.ProtoReflect.Descriptor instead.
+func (*CommandOutput) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *CommandOutput) GetStream() string {
+	if x != nil {
+		return x.Stream
+	}
+	return ""
+}
+
+func (x *CommandOutput) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *CommandOutput) GetDone() bool {
+	if x != nil {
+		return x.Done
+	}
+	return false
+}
+
+func (x *CommandOutput) GetExitCode() int32 {
+	if x != nil {
+		return x.ExitCode
+	}
+	return 0
+}
+
+// 任务日志请求
+type TaskLogsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	HostId        string                 `protobuf:"bytes,1,opt,name=host_id,json=hostId,proto3" json:"host_id,omitempty"`
+	TaskId        string                 `protobuf:"bytes,2,opt,name=task_id,json=taskId,proto3" json:"task_id,omitempty"`
+	Stream        string                 `protobuf:"bytes,3,opt,name=stream,proto3" json:"stream,omitempty"`  // stdout, stderr
+	Offset        int64                  `protobuf:"varint,4,opt,name=offset,proto3" json:"offset,omitempty"` // 起始字节偏移
+	Follow        bool                   `protobuf:"varint,5,opt,name=follow,proto3" json:"follow,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TaskLogsRequest) Reset() {
+	*x = TaskLogsRequest{}
+	mi := &file_api_proto_agent_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TaskLogsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TaskLogsRequest) ProtoMessage() {}
+
+func (x *TaskLogsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TaskLogsRequest.ProtoReflect.Descriptor instead.
+func (*TaskLogsRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *TaskLogsRequest) GetHostId() string {
+	if x != nil {
+		return x.HostId
+	}
+	return ""
+}
+
+func (x *TaskLogsRequest) GetTaskId() string {
+	if x != nil {
+		return x.TaskId
+	}
+	return ""
+}
+
+func (x *TaskLogsRequest) GetStream() string {
+	if x != nil {
+		return x.Stream
+	}
+	return ""
+}
+
+func (x *TaskLogsRequest) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *TaskLogsRequest) GetFollow() bool {
+	if x != nil {
+		return x.Follow
+	}
+	return false
+}
+
+// 任务日志片段，最后一条消息 eof 为 true
+type TaskLogChunk struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Stream        string                 `protobuf:"bytes,1,opt,name=stream,proto3" json:"stream,omitempty"`
+	Offset        int64                  `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
+	Data          []byte                 `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	Eof           bool                   `protobuf:"varint,4,opt,name=eof,proto3" json:"eof,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TaskLogChunk) Reset() {
+	*x = TaskLogChunk{}
+	mi := &file_api_proto_agent_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TaskLogChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TaskLogChunk) ProtoMessage() {}
+
+func (x *TaskLogChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TaskLogChunk.ProtoReflect.Descriptor instead.
+func (*TaskLogChunk) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *TaskLogChunk) GetStream() string {
+	if x != nil {
+		return x.Stream
+	}
+	return ""
+}
+
+func (x *TaskLogChunk) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *TaskLogChunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *TaskLogChunk) GetEof() bool {
+	if x != nil {
+		return x.Eof
+	}
+	return false
+}
+
 // Ping请求
 type PingRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -721,7 +1138,7 @@ type PingRequest struct {
 
 func (x *PingRequest) Reset() {
 	*x = PingRequest{}
-	mi := &file_api_proto_agent_proto_msgTypes[10]
+	mi := &file_api_proto_agent_proto_msgTypes[16]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -733,7 +1150,7 @@ func (x *PingRequest) String() string {
 func (*PingRequest) ProtoMessage() {}
 
 func (x *PingRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[10]
+	mi := &file_api_proto_agent_proto_msgTypes[16]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -746,7 +1163,7 @@ func (x *PingRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PingRequest.ProtoReflect.Descriptor instead.
 func (*PingRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{10}
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{16}
 }
 
 func (x *PingRequest) GetHostId() string {
@@ -767,7 +1184,7 @@ type PingResponse struct {
 
 func (x *PingResponse) Reset() {
 	*x = PingResponse{}
-	mi := &file_api_proto_agent_proto_msgTypes[11]
+	mi := &file_api_proto_agent_proto_msgTypes[17]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -779,7 +1196,7 @@ func (x *PingResponse) String() string {
 func (*PingResponse) ProtoMessage() {}
 
 func (x *PingResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[11]
+	mi := &file_api_proto_agent_proto_msgTypes[17]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -792,7 +1209,7 @@ func (x *PingResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PingResponse.ProtoReflect.Descriptor instead.
 func (*PingResponse) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{11}
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{17}
 }
 
 func (x *PingResponse) GetOk() bool {
@@ -827,10 +1244,22 @@ const file_api_proto_agent_proto_rawDesc = "" +
 	"\ahost_id\x18\x01 \x01(\tR\x06hostId\x12\x1d\n" +
 	"\n" +
 	"public_key\x18\x02 \x01(\tR\tpublicKey\x12\x1a\n" +
-	"\busername\x18\x03 \x01(\tR\busername\"N\n" +
+	"\busername\x18\x03 \x01(\tR\busername\"\xa0\x01\n" +
+	"\x12SyncSSHKeysRequest\x12\x17\n" +
+	"\ahost_id\x18\x01 \x01(\tR\x06hostId\x12\x1f\n" +
+	"\vpublic_keys\x18\x02 \x03(\tR\n" +
+	"publicKeys\x12\x1a\n" +
+	"\busername\x18\x03 \x01(\tR\busername\x12.\n" +
+	"\atenants\x18\x05 \x03(\v2\x14.agent.TenantAccountR\atenantsJ\x04\b\x04\x10\x05\"m\n" +
+	"\rTenantAccount\x12\x1a\n" +
+	"\busername\x18\x01 \x01(\tR\busername\x12\x1f\n" +
+	"\vpublic_keys\x18\x02 \x03(\tR\n" +
+	"publicKeys\x12\x1f\n" +
+	"\vgpu_indexes\x18\x03 \x03(\x05R\n" +
+	"gpuIndexes\"N\n" +
 	"\x0eCleanupRequest\x12\x17\n" +
 	"\ahost_id\x18\x01 \x01(\tR\x06hostId\x12#\n" +
-	"\rcleanup_types\x18\x02 \x03(\tR\fcleanupTypes\"\xac\x01\n" +
+	"\rcleanup_types\x18\x02 \x03(\tR\fcleanupTypes\"\xcd\x01\n" +
 	"\x13MountDatasetRequest\x12\x17\n" +
 	"\ahost_id\x18\x01 \x01(\tR\x06hostId\x12\x1d\n" +
 	"\n" +
@@ -839,7 +1268,15 @@ const file_api_proto_agent_proto_rawDesc = "" +
 	"sourcePath\x12\x1f\n" +
 	"\vmount_point\x18\x04 \x01(\tR\n" +
 	"mountPoint\x12\x1b\n" +
-	"\tread_only\x18\x05 \x01(\bR\breadOnly\",\n" +
+	"\tread_only\x18\x05 \x01(\bR\breadOnly\x12\x1f\n" +
+	"\vsource_type\x18\x06 \x01(\tR\n" +
+	"sourceType\"p\n" +
+	"\x15UnmountDatasetRequest\x12\x17\n" +
+	"\ahost_id\x18\x01 \x01(\tR\x06hostId\x12\x1d\n" +
+	"\n" +
+	"dataset_id\x18\x02 \x01(\rR\tdatasetId\x12\x1f\n" +
+	"\vmount_point\x18\x03 \x01(\tR\n" +
+	"mountPoint\",\n" +
 	"\x11SystemInfoRequest\x12\x17\n" +
 	"\ahost_id\x18\x01 \x01(\tR\x06hostId\"\xbb\x01\n" +
 	"\aGPUInfo\x12\x14\n" +
@@ -873,19 +1310,39 @@ const file_api_proto_agent_proto_rawDesc = "" +
 	"\x16ExecuteCommandResponse\x12\x1b\n" +
 	"\texit_code\x18\x01 \x01(\x05R\bexitCode\x12\x16\n" +
 	"\x06stdout\x18\x02 \x01(\tR\x06stdout\x12\x16\n" +
-	"\x06stderr\x18\x03 \x01(\tR\x06stderr\"&\n" +
+	"\x06stderr\x18\x03 \x01(\tR\x06stderr\"l\n" +
+	"\rCommandOutput\x12\x16\n" +
+	"\x06stream\x18\x01 \x01(\tR\x06stream\x12\x12\n" +
+	"\x04data\x18\x02 \x01(\fR\x04data\x12\x12\n" +
+	"\x04done\x18\x03 \x01(\bR\x04done\x12\x1b\n" +
+	"\texit_code\x18\x04 \x01(\x05R\bexitCode\"\x8b\x01\n" +
+	"\x0fTaskLogsRequest\x12\x17\n" +
+	"\ahost_id\x18\x01 \x01(\tR\x06hostId\x12\x17\n" +
+	"\atask_id\x18\x02 \x01(\tR\x06taskId\x12\x16\n" +
+	"\x06stream\x18\x03 \x01(\tR\x06stream\x12\x16\n" +
+	"\x06offset\x18\x04 \x01(\x03R\x06offset\x12\x16\n" +
+	"\x06follow\x18\x05 \x01(\bR\x06follow\"d\n" +
+	"\fTaskLogChunk\x12\x16\n" +
+	"\x06stream\x18\x01 \x01(\tR\x06stream\x12\x16\n" +
+	"\x06offset\x18\x02 \x01(\x03R\x06offset\x12\x12\n" +
+	"\x04data\x18\x03 \x01(\fR\x04data\x12\x10\n" +
+	"\x03eof\x18\x04 \x01(\bR\x03eof\"&\n" +
 	"\vPingRequest\x12\x17\n" +
 	"\ahost_id\x18\x01 \x01(\tR\x06hostId\"8\n" +
 	"\fPingResponse\x12\x0e\n" +
 	"\x02ok\x18\x01 \x01(\bR\x02ok\x12\x18\n" +
-	"\aversion\x18\x02 \x01(\tR\aversion2\xb3\x03\n" +
+	"\aversion\x18\x02 \x01(\tR\aversion2\xbe\x05\n" +
 	"\fAgentService\x129\n" +
 	"\vStopProcess\x12\x19.agent.StopProcessRequest\x1a\x0f.agent.Response\x123\n" +
 	"\bResetSSH\x12\x16.agent.ResetSSHRequest\x1a\x0f.agent.Response\x128\n" +
-	"\x0eCleanupMachine\x12\x15.agent.CleanupRequest\x1a\x0f.agent.Response\x12;\n" +
-	"\fMountDataset\x12\x1a.agent.MountDatasetRequest\x1a\x0f.agent.Response\x12<\n" +
+	"\x0eCleanupMachine\x12\x15.agent.CleanupRequest\x1a\x0f.agent.Response\x129\n" +
+	"\vSyncSSHKeys\x12\x19.agent.SyncSSHKeysRequest\x1a\x0f.agent.Response\x12;\n" +
+	"\fMountDataset\x12\x1a.agent.MountDatasetRequest\x1a\x0f.agent.Response\x12?\n" +
+	"\x0eUnmountDataset\x12\x1c.agent.UnmountDatasetRequest\x1a\x0f.agent.Response\x12<\n" +
 	"\rGetSystemInfo\x12\x18.agent.SystemInfoRequest\x1a\x11.agent.SystemInfo\x12M\n" +
-	"\x0eExecuteCommand\x12\x1c.agent.ExecuteCommandRequest\x1a\x1d.agent.ExecuteCommandResponse\x12/\n" +
+	"\x0eExecuteCommand\x12\x1c.agent.ExecuteCommandRequest\x1a\x1d.agent.ExecuteCommandResponse\x12L\n" +
+	"\x14ExecuteCommandStream\x12\x1c.agent.ExecuteCommandRequest\x1a\x14.agent.CommandOutput0\x01\x12?\n" +
+	"\x0eStreamTaskLogs\x12\x16.agent.TaskLogsRequest\x1a\x13.agent.TaskLogChunk0\x01\x12/\n" +
 	"\x04Ping\x12\x12.agent.PingRequest\x1a\x13.agent.PingResponseB2Z0github.com/YoungBoyGod/remotegpu/api/proto/agentb\x06proto3"
 
 var (
@@ -900,42 +1357,57 @@ func file_api_proto_agent_proto_rawDescGZIP() []byte {
 	return file_api_proto_agent_proto_rawDescData
 }
 
-var file_api_proto_agent_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
+var file_api_proto_agent_proto_msgTypes = make([]protoimpl.MessageInfo, 18)
 var file_api_proto_agent_proto_goTypes = []any{
 	(*Response)(nil),               // 0: agent.Response
 	(*StopProcessRequest)(nil),     // 1: agent.StopProcessRequest
 	(*ResetSSHRequest)(nil),        // 2: agent.ResetSSHRequest
-	(*CleanupRequest)(nil),         // 3: agent.CleanupRequest
-	(*MountDatasetRequest)(nil),    // 4: agent.MountDatasetRequest
-	(*SystemInfoRequest)(nil),      // 5: agent.SystemInfoRequest
-	(*GPUInfo)(nil),                // 6: agent.GPUInfo
-	(*SystemInfo)(nil),             // 7: agent.SystemInfo
-	(*ExecuteCommandRequest)(nil),  // 8: agent.ExecuteCommandRequest
-	(*ExecuteCommandResponse)(nil), // 9: agent.ExecuteCommandResponse
-	(*PingRequest)(nil),            // 10: agent.PingRequest
-	(*PingResponse)(nil),           // 11: agent.PingResponse
+	(*SyncSSHKeysRequest)(nil),     // 3: agent.SyncSSHKeysRequest
+	(*TenantAccount)(nil),          // 4: agent.TenantAccount
+	(*CleanupRequest)(nil),         // 5: agent.CleanupRequest
+	(*MountDatasetRequest)(nil),    // 6: agent.MountDatasetRequest
+	(*UnmountDatasetRequest)(nil),  // 7: agent.UnmountDatasetRequest
+	(*SystemInfoRequest)(nil),      // 8: agent.SystemInfoRequest
+	(*GPUInfo)(nil),                // 9: agent.GPUInfo
+	(*SystemInfo)(nil),             // 10: agent.SystemInfo
+	(*ExecuteCommandRequest)(nil),  // 11: agent.ExecuteCommandRequest
+	(*ExecuteCommandResponse)(nil), // 12: agent.ExecuteCommandResponse
+	(*CommandOutput)(nil),          // 13: agent.CommandOutput
+	(*TaskLogsRequest)(nil),        // 14: agent.TaskLogsRequest
+	(*TaskLogChunk)(nil),           // 15: agent.TaskLogChunk
+	(*PingRequest)(nil),            // 16: agent.PingRequest
+	(*PingResponse)(nil),           // 17: agent.PingResponse
 }
 var file_api_proto_agent_proto_depIdxs = []int32{
-	6,  // 0: agent.SystemInfo.gpu_info:type_name -> agent.GPUInfo
-	1,  // 1: agent.AgentService.StopProcess:input_type -> agent.StopProcessRequest
-	2,  // 2: agent.AgentService.ResetSSH:input_type -> agent.ResetSSHRequest
-	3,  // 3: agent.AgentService.CleanupMachine:input_type -> agent.CleanupRequest
-	4,  // 4: agent.AgentService.MountDataset:input_type -> agent.MountDatasetRequest
-	5,  // 5: agent.AgentService.GetSystemInfo:input_type -> agent.SystemInfoRequest
-	8,  // 6: agent.AgentService.ExecuteCommand:input_type -> agent.ExecuteCommandRequest
-	10, // 7: agent.AgentService.Ping:input_type -> agent.PingRequest
-	0,  // 8: agent.AgentService.StopProcess:output_type -> agent.Response
-	0,  // 9: agent.AgentService.ResetSSH:output_type -> agent.Response
-	0,  // 10: agent.AgentService.CleanupMachine:output_type -> agent.Response
-	0,  // 11: agent.AgentService.MountDataset:output_type -> agent.Response
-	7,  // 12: agent.AgentService.GetSystemInfo:output_type -> agent.SystemInfo
-	9,  // 13: agent.AgentService.ExecuteCommand:output_type -> agent.ExecuteCommandResponse
-	11, // 14: agent.AgentService.Ping:output_type -> agent.PingResponse
-	8,  // [8:15] is the sub-list for method output_type
-	1,  // [1:8] is the sub-list for method input_type
-	1,  // [1:1] is the sub-list for extension type_name
-	1,  // [1:1] is the sub-list for extension extendee
-	0,  // [0:1] is the sub-list for field type_name
+	4,  // 0: agent.SyncSSHKeysRequest.tenants:type_name -> agent.TenantAccount
+	9,  // 1: agent.SystemInfo.gpu_info:type_name -> agent.GPUInfo
+	1,  // 2: agent.AgentService.StopProcess:input_type -> agent.StopProcessRequest
+	2,  // 3: agent.AgentService.ResetSSH:input_type -> agent.ResetSSHRequest
+	5,  // 4: agent.AgentService.CleanupMachine:input_type -> agent.CleanupRequest
+	3,  // 5: agent.AgentService.SyncSSHKeys:input_type -> agent.SyncSSHKeysRequest
+	6,  // 6: agent.AgentService.MountDataset:input_type -> agent.MountDatasetRequest
+	7,  // 7: agent.AgentService.UnmountDataset:input_type -> agent.UnmountDatasetRequest
+	8,  // 8: agent.AgentService.GetSystemInfo:input_type -> agent.SystemInfoRequest
+	11, // 9: agent.AgentService.ExecuteCommand:input_type -> agent.ExecuteCommandRequest
+	11, // 10: agent.AgentService.ExecuteCommandStream:input_type -> agent.ExecuteCommandRequest
+	14, // 11: agent.AgentService.StreamTaskLogs:input_type -> agent.TaskLogsRequest
+	16, // 12: agent.AgentService.Ping:input_type -> agent.PingRequest
+	0,  // 13: agent.AgentService.StopProcess:output_type -> agent.Response
+	0,  // 14: agent.AgentService.ResetSSH:output_type -> agent.Response
+	0,  // 15: agent.AgentService.CleanupMachine:output_type -> agent.Response
+	0,  // 16: agent.AgentService.SyncSSHKeys:output_type -> agent.Response
+	0,  // 17: agent.AgentService.MountDataset:output_type -> agent.Response
+	0,  // 18: agent.AgentService.UnmountDataset:output_type -> agent.Response
+	10, // 19: agent.AgentService.GetSystemInfo:output_type -> agent.SystemInfo
+	12, // 20: agent.AgentService.ExecuteCommand:output_type -> agent.ExecuteCommandResponse
+	13, // 21: agent.AgentService.ExecuteCommandStream:output_type -> agent.CommandOutput
+	15, // 22: agent.AgentService.StreamTaskLogs:output_type -> agent.TaskLogChunk
+	17, // 23: agent.AgentService.Ping:output_type -> agent.PingResponse
+	13, // [13:24] is the sub-list for method output_type
+	2,  // [2:13] is the sub-list for method input_type
+	2,  // [2:2] is the sub-list for extension type_name
+	2,  // [2:2] is the sub-list for extension extendee
+	0,  // [0:2] is the sub-list for field type_name
 }
 
 func init() { file_api_proto_agent_proto_init() }
@@ -949,7 +1421,7 @@ func file_api_proto_agent_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_api_proto_agent_proto_rawDesc), len(file_api_proto_agent_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   12,
+			NumMessages:   18,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -1,9 +1,11 @@
 // Code generated by protoc-gen-go-grpc. DO NOT EDIT.
 // versions:
 // - protoc-gen-go-grpc v1.6.1
-// - protoc             v3.21.12
+// - protoc             (unknown)
 // source: api/proto/agent.proto
 
+// agent/api/proto/agent.proto 为同一份协议定义（仅 go_package 不同），修改时需同步两处并重新生成代码
+
 package agent
 
 import (
@@ -19,13 +21,17 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	AgentService_StopProcess_FullMethodName    = "/agent.AgentService/StopProcess"
-	AgentService_ResetSSH_FullMethodName       = "/agent.AgentService/ResetSSH"
-	AgentService_CleanupMachine_FullMethodName = "/agent.AgentService/CleanupMachine"
-	AgentService_MountDataset_FullMethodName   = "/agent.AgentService/MountDataset"
-	AgentService_GetSystemInfo_FullMethodName  = "/agent.AgentService/GetSystemInfo"
-	AgentService_ExecuteCommand_FullMethodName = "/agent.AgentService/ExecuteCommand"
-	AgentService_Ping_FullMethodName           = "/agent.AgentService/Ping"
+	AgentService_StopProcess_FullMethodName          = "/agent.AgentService/StopProcess"
+	AgentService_ResetSSH_FullMethodName             = "/agent.AgentService/ResetSSH"
+	AgentService_CleanupMachine_FullMethodName       = "/agent.AgentService/CleanupMachine"
+	AgentService_SyncSSHKeys_FullMethodName          = "/agent.AgentService/SyncSSHKeys"
+	AgentService_MountDataset_FullMethodName         = "/agent.AgentService/MountDataset"
+	AgentService_UnmountDataset_FullMethodName       = "/agent.AgentService/UnmountDataset"
+	AgentService_GetSystemInfo_FullMethodName        = "/agent.AgentService/GetSystemInfo"
+	AgentService_ExecuteCommand_FullMethodName       = "/agent.AgentService/ExecuteCommand"
+	AgentService_ExecuteCommandStream_FullMethodName = "/agent.AgentService/ExecuteCommandStream"
+	AgentService_StreamTaskLogs_FullMethodName       = "/agent.AgentService/StreamTaskLogs"
+	AgentService_Ping_FullMethodName                 = "/agent.AgentService/Ping"
 )
 
 // AgentServiceClient is the client API for AgentService service.
@@ -40,12 +46,20 @@ type AgentServiceClient interface {
 	ResetSSH(ctx context.Context, in *ResetSSHRequest, opts ...grpc.CallOption) (*Response, error)
 	// 清理机器
 	CleanupMachine(ctx context.Context, in *CleanupRequest, opts ...grpc.CallOption) (*Response, error)
+	// 同步SSH密钥（全量覆盖平台管理的公钥）
+	SyncSSHKeys(ctx context.Context, in *SyncSSHKeysRequest, opts ...grpc.CallOption) (*Response, error)
 	// 挂载数据集
 	MountDataset(ctx context.Context, in *MountDatasetRequest, opts ...grpc.CallOption) (*Response, error)
+	// 卸载数据集
+	UnmountDataset(ctx context.Context, in *UnmountDatasetRequest, opts ...grpc.CallOption) (*Response, error)
 	// 获取系统信息
 	GetSystemInfo(ctx context.Context, in *SystemInfoRequest, opts ...grpc.CallOption) (*SystemInfo, error)
 	// 执行命令
 	ExecuteCommand(ctx context.Context, in *ExecuteCommandRequest, opts ...grpc.CallOption) (*ExecuteCommandResponse, error)
+	// 执行命令并流式返回输出
+	ExecuteCommandStream(ctx context.Context, in *ExecuteCommandRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[CommandOutput], error)
+	// 流式读取任务日志，follow 时持续推送直到任务结束
+	StreamTaskLogs(ctx context.Context, in *TaskLogsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[TaskLogChunk], error)
 	// 健康检查
 	Ping(ctx context.Context, in *PingRequest, opts ...grpc.CallOption) (*PingResponse, error)
 }
@@ -88,6 +102,16 @@ func (c *agentServiceClient) CleanupMachine(ctx context.Context, in *CleanupRequ
 	return out, nil
 }
 
+func (c *agentServiceClient) SyncSSHKeys(ctx context.Context, in *SyncSSHKeysRequest, opts ...grpc.CallOption) (*Response, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Response)
+	err := c.cc.Invoke(ctx, AgentService_SyncSSHKeys_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *agentServiceClient) MountDataset(ctx context.Context, in *MountDatasetRequest, opts ...grpc.CallOption) (*Response, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(Response)
@@ -98,6 +122,16 @@ func (c *agentServiceClient) MountDataset(ctx context.Context, in *MountDatasetR
 	return out, nil
 }
 
+func (c *agentServiceClient) UnmountDataset(ctx context.Context, in *UnmountDatasetRequest, opts ...grpc.CallOption) (*Response, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Response)
+	err := c.cc.Invoke(ctx, AgentService_UnmountDataset_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *agentServiceClient) GetSystemInfo(ctx context.Context, in *SystemInfoRequest, opts ...grpc.CallOption) (*SystemInfo, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(SystemInfo)
@@ -118,6 +152,44 @@ func (c *agentServiceClient) ExecuteCommand(ctx context.Context, in *ExecuteComm
 	return out, nil
 }
 
+func (c *agentServiceClient) ExecuteCommandStream(ctx context.Context, in *ExecuteCommandRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[CommandOutput], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &AgentService_ServiceDesc.Streams[0], AgentService_ExecuteCommandStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ExecuteCommandRequest, CommandOutput]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AgentService_ExecuteCommandStreamClient = grpc.ServerStreamingClient[CommandOutput]
+
+func (c *agentServiceClient) StreamTaskLogs(ctx context.Context, in *TaskLogsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[TaskLogChunk], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &AgentService_ServiceDesc.Streams[1], AgentService_StreamTaskLogs_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[TaskLogsRequest, TaskLogChunk]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AgentService_StreamTaskLogsClient = grpc.ServerStreamingClient[TaskLogChunk]
+
 func (c *agentServiceClient) Ping(ctx context.Context, in *PingRequest, opts ...grpc.CallOption) (*PingResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(PingResponse)
@@ -140,12 +212,20 @@ type AgentServiceServer interface {
 	ResetSSH(context.Context, *ResetSSHRequest) (*Response, error)
 	// 清理机器
 	CleanupMachine(context.Context, *CleanupRequest) (*Response, error)
+	// 同步SSH密钥（全量覆盖平台管理的公钥）
+	SyncSSHKeys(context.Context, *SyncSSHKeysRequest) (*Response, error)
 	// 挂载数据集
 	MountDataset(context.Context, *MountDatasetRequest) (*Response, error)
+	// 卸载数据集
+	UnmountDataset(context.Context, *UnmountDatasetRequest) (*Response, error)
 	// 获取系统信息
 	GetSystemInfo(context.Context, *SystemInfoRequest) (*SystemInfo, error)
 	// 执行命令
 	ExecuteCommand(context.Context, *ExecuteCommandRequest) (*ExecuteCommandResponse, error)
+	// 执行命令并流式返回输出
+	ExecuteCommandStream(*ExecuteCommandRequest, grpc.ServerStreamingServer[CommandOutput]) error
+	// 流式读取任务日志，follow 时持续推送直到任务结束
+	StreamTaskLogs(*TaskLogsRequest, grpc.ServerStreamingServer[TaskLogChunk]) error
 	// 健康检查
 	Ping(context.Context, *PingRequest) (*PingResponse, error)
 	mustEmbedUnimplementedAgentServiceServer()
@@ -167,15 +247,27 @@ func (UnimplementedAgentServiceServer) ResetSSH(context.Context, *ResetSSHReques
 func (UnimplementedAgentServiceServer) CleanupMachine(context.Context, *CleanupRequest) (*Response, error) {
 	return nil, status.Error(codes.Unimplemented, "method CleanupMachine not implemented")
 }
+func (UnimplementedAgentServiceServer) SyncSSHKeys(context.Context, *SyncSSHKeysRequest) (*Response, error) {
+	return nil, status.Error(codes.Unimplemented, "method SyncSSHKeys not implemented")
+}
 func (UnimplementedAgentServiceServer) MountDataset(context.Context, *MountDatasetRequest) (*Response, error) {
 	return nil, status.Error(codes.Unimplemented, "method MountDataset not implemented")
 }
+func (UnimplementedAgentServiceServer) UnmountDataset(context.Context, *UnmountDatasetRequest) (*Response, error) {
+	return nil, status.Error(codes.Unimplemented, "method UnmountDataset not implemented")
+}
 func (UnimplementedAgentServiceServer) GetSystemInfo(context.Context, *SystemInfoRequest) (*SystemInfo, error) {
 	return nil, status.Error(codes.Unimplemented, "method GetSystemInfo not implemented")
 }
 func (UnimplementedAgentServiceServer) ExecuteCommand(context.Context, *ExecuteCommandRequest) (*ExecuteCommandResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method ExecuteCommand not implemented")
 }
+func (UnimplementedAgentServiceServer) ExecuteCommandStream(*ExecuteCommandRequest, grpc.ServerStreamingServer[CommandOutput]) error {
+	return status.Error(codes.Unimplemented, "method ExecuteCommandStream not implemented")
+}
+func (UnimplementedAgentServiceServer) StreamTaskLogs(*TaskLogsRequest, grpc.ServerStreamingServer[TaskLogChunk]) error {
+	return status.Error(codes.Unimplemented, "method StreamTaskLogs not implemented")
+}
 func (UnimplementedAgentServiceServer) Ping(context.Context, *PingRequest) (*PingResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method Ping not implemented")
 }
@@ -254,6 +346,24 @@ func _AgentService_CleanupMachine_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _AgentService_SyncSSHKeys_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SyncSSHKeysRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AgentServiceServer).SyncSSHKeys(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AgentService_SyncSSHKeys_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AgentServiceServer).SyncSSHKeys(ctx, req.(*SyncSSHKeysRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _AgentService_MountDataset_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(MountDatasetRequest)
 	if err := dec(in); err != nil {
@@ -272,6 +382,24 @@ func _AgentService_MountDataset_Handler(srv interface{}, ctx context.Context, de
 	return interceptor(ctx, in, info, handler)
 }
 
+func _AgentService_UnmountDataset_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnmountDatasetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AgentServiceServer).UnmountDataset(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AgentService_UnmountDataset_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AgentServiceServer).UnmountDataset(ctx, req.(*UnmountDatasetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _AgentService_GetSystemInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(SystemInfoRequest)
 	if err := dec(in); err != nil {
@@ -308,6 +436,28 @@ func _AgentService_ExecuteCommand_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _AgentService_ExecuteCommandStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExecuteCommandRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AgentServiceServer).ExecuteCommandStream(m, &grpc.GenericServerStream[ExecuteCommandRequest, CommandOutput]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AgentService_ExecuteCommandStreamServer = grpc.ServerStreamingServer[CommandOutput]
+
+func _AgentService_StreamTaskLogs_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(TaskLogsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AgentServiceServer).StreamTaskLogs(m, &grpc.GenericServerStream[TaskLogsRequest, TaskLogChunk]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AgentService_StreamTaskLogsServer = grpc.ServerStreamingServer[TaskLogChunk]
+
 func _AgentService_Ping_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(PingRequest)
 	if err := dec(in); err != nil {
@@ -345,10 +495,18 @@ var AgentService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "CleanupMachine",
 			Handler:    _AgentService_CleanupMachine_Handler,
 		},
+		{
+			MethodName: "SyncSSHKeys",
+			Handler:    _AgentService_SyncSSHKeys_Handler,
+		},
 		{
 			MethodName: "MountDataset",
 			Handler:    _AgentService_MountDataset_Handler,
 		},
+		{
+			MethodName: "UnmountDataset",
+			Handler:    _AgentService_UnmountDataset_Handler,
+		},
 		{
 			MethodName: "GetSystemInfo",
 			Handler:    _AgentService_GetSystemInfo_Handler,
@@ -362,6 +520,17 @@ var AgentService_ServiceDesc = grpc.ServiceDesc{
 			Handler:    _AgentService_Ping_Handler,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ExecuteCommandStream",
+			Handler:       _AgentService_ExecuteCommandStream_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "StreamTaskLogs",
+			Handler:       _AgentService_StreamTaskLogs_Handler,
+			ServerStreams: true,
+		},
+	},
 	Metadata: "api/proto/agent.proto",
 }
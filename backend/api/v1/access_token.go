@@ -0,0 +1,24 @@
+package v1
+
+import "time"
+
+// CreateAccessTokenRequest 创建个人访问令牌请求
+// scopes 如 tasks:write、datasets:read、machines:read；expire_days 为 0 时默认 30 天，最长 365 天
+type CreateAccessTokenRequest struct {
+	Name       string   `json:"name" binding:"required,min=1,max=64"`
+	Scopes     []string `json:"scopes" binding:"required,min=1"`
+	ExpireDays int      `json:"expire_days"`
+}
+
+// AccessTokenResponse 个人访问令牌信息，token 明文只在创建时返回
+type AccessTokenResponse struct {
+	ID          uint       `json:"id"`
+	Name        string     `json:"name"`
+	Token       string     `json:"token,omitempty"`
+	TokenPrefix string     `json:"token_prefix"`
+	Scopes      []string   `json:"scopes"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+	RevokedAt   *time.Time `json:"revoked_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
@@ -7,11 +7,16 @@ type LoginRequest struct {
 }
 
 // LoginResponse 登录响应
+// 需要两步验证时只返回 mfa_required 和 mfa_token；mfa_enrollment_required 表示需先开通两步验证
 type LoginResponse struct {
-	AccessToken        string `json:"access_token"`
-	RefreshToken       string `json:"refresh_token"`
-	ExpiresIn          int64  `json:"expires_in"`
-	MustChangePassword bool   `json:"must_change_password"`
+	AccessToken           string   `json:"access_token,omitempty"`
+	RefreshToken          string   `json:"refresh_token,omitempty"`
+	ExpiresIn             int64    `json:"expires_in,omitempty"`
+	MustChangePassword    bool     `json:"must_change_password"`
+	MFARequired           bool     `json:"mfa_required,omitempty"`
+	MFAEnrollmentRequired bool     `json:"mfa_enrollment_required,omitempty"`
+	MFAToken              string   `json:"mfa_token,omitempty"`
+	RecoveryCodes         []string `json:"recovery_codes,omitempty"`
 }
 
 // RefreshTokenRequest 刷新令牌请求
@@ -36,6 +41,69 @@ type ConfirmPasswordResetRequest struct {
 	NewPassword string `json:"new_password" binding:"required"`
 }
 
+// ConfirmEmailVerificationRequest 确认邮箱验证
+type ConfirmEmailVerificationRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// MFATokenRequest 使用登录时返回的 MFA 待验证令牌开通两步验证
+type MFATokenRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+}
+
+// MFAVerifyRequest 提交两步验证码完成登录，code 可以是验证码或恢复码
+type MFAVerifyRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// MFACodeRequest 提交当前两步验证码
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// MFAEnrollmentResponse 两步验证开通信息，前端根据 provisioning_uri 渲染二维码
+type MFAEnrollmentResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// MFARecoveryCodesResponse 恢复码，只展示一次
+type MFARecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// SSOProviderResponse 企业身份源信息，登录页据此选择 OIDC 跳转或 LDAP 账号密码登录
+type SSOProviderResponse struct {
+	CompanyCode string `json:"company_code"`
+	CompanyName string `json:"company_name"`
+	Type        string `json:"type"` // oidc, ldap
+}
+
+// SSOAuthorizeRequest 发起 OIDC 登录
+type SSOAuthorizeRequest struct {
+	CompanyCode string `json:"company_code" binding:"required"`
+}
+
+// SSOAuthorizeResponse OIDC 授权地址，前端跳转到 authorization_url
+type SSOAuthorizeResponse struct {
+	AuthorizationURL string `json:"authorization_url"`
+	State            string `json:"state"`
+}
+
+// SSOCallbackRequest 身份源回调参数，由控制台回调页面提交
+type SSOCallbackRequest struct {
+	State string `json:"state" binding:"required"`
+	Code  string `json:"code" binding:"required"`
+}
+
+// LDAPLoginRequest 企业 LDAP 账号密码登录
+type LDAPLoginRequest struct {
+	CompanyCode string `json:"company_code" binding:"required"`
+	Username    string `json:"username" binding:"required"`
+	Password    string `json:"password" binding:"required"`
+}
+
 // UpdateProfileRequest 更新个人资料请求
 type UpdateProfileRequest struct {
 	DisplayName string `json:"display_name"`
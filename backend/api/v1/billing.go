@@ -0,0 +1,6 @@
+package v1
+
+// GenerateInvoicesRequest 生成账单请求
+type GenerateInvoicesRequest struct {
+	Period string `json:"period" binding:"required"` // 账期月份，如 2026-09
+}
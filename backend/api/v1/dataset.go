@@ -2,22 +2,35 @@ package v1
 
 // InitMultipartRequest 初始化分片上传请求
 type InitMultipartRequest struct {
-	Filename string `json:"filename" binding:"required"`
-	Size     int64  `json:"size" binding:"required"`
-	MD5      string `json:"md5"`
+	Filename    string `json:"filename" binding:"required"`
+	Size        int64  `json:"size" binding:"required,gt=0"`
+	Name        string `json:"name"` // 数据集名称，为空时使用文件名
+	Description string `json:"description"`
+	WorkspaceID *uint  `json:"workspace_id"` // 归属的工作空间，同时计入工作空间存储配额
+}
+
+// PartURLsRequest 获取分片上传地址请求
+type PartURLsRequest struct {
+	PartNumbers []int `json:"part_numbers" binding:"required,min=1,max=1000"`
+}
+
+// CompletedPart 已上传的分片，ETag 为上传分片时响应头中的值
+type CompletedPart struct {
+	PartNumber int    `json:"part_number" binding:"required"`
+	ETag       string `json:"etag" binding:"required"`
 }
 
 // CompleteMultipartRequest 完成分片上传请求
 type CompleteMultipartRequest struct {
-	UploadID string `json:"upload_id" binding:"required"`
-	Name     string `json:"name" binding:"required"`
-	Size     int64  `json:"size"`
+	UploadID string          `json:"upload_id" binding:"required"`
+	Name     string          `json:"name"`
+	Parts    []CompletedPart `json:"parts" binding:"required,min=1,dive"`
 }
 
 // MountRequest 挂载数据集请求
 type MountRequest struct {
 	MachineID  string `json:"machine_id" binding:"required"`
-	MountPoint string `json:"mount_point" binding:"required"`
+	MountPoint string `json:"mount_point" binding:"required"` // 相对客户挂载根目录 /data/rg-c<客户ID> 的路径
 	ReadOnly   bool   `json:"read_only"`
 }
 
@@ -3,6 +3,7 @@ package v1
 // CreateEnvironmentRequest 创建环境请求
 type CreateEnvironmentRequest struct {
 	WorkspaceID *uint             `json:"workspace_id"`
+	HostID      string            `json:"host_id" binding:"required"`
 	Name        string            `json:"name" binding:"required,max=128"`
 	Description string            `json:"description"`
 	Image       string            `json:"image" binding:"required"`
@@ -1,11 +1,20 @@
 package v1
 
 // AllocateRequest 分配机器请求
+// 指定 gpu_indexes 或 gpu_uuids 时只分配这些 GPU（GPU 粒度分配），否则整机分配
 type AllocateRequest struct {
-	CustomerID     uint   `json:"customer_id" binding:"required"`
-	HostID         string `json:"host_id" binding:"required"`
-	DurationMonths int    `json:"duration_months" binding:"required,min=1"`
-	Remark         string `json:"remark"`
+	CustomerID     uint     `json:"customer_id" binding:"required"`
+	HostID         string   `json:"host_id" binding:"required"`
+	DurationMonths int      `json:"duration_months" binding:"required,min=1"`
+	Remark         string   `json:"remark"`
+	GPUIndexes     []int    `json:"gpu_indexes" binding:"omitempty,dive,min=0"`
+	GPUUUIDs       []string `json:"gpu_uuids"`
+}
+
+// RenewAllocationRequest 续期机器分配请求，上限由配置 allocation_expiry.max_renew_months 限制
+type RenewAllocationRequest struct {
+	Months int    `json:"months" binding:"required,min=1"`
+	Remark string `json:"remark"`
 }
 
 // ReclaimRequest 回收机器请求
@@ -0,0 +1,17 @@
+package v1
+
+import "time"
+
+// CreateReservationRequest 创建机器预约请求
+type CreateReservationRequest struct {
+	GPUModel  string    `json:"gpu_model" binding:"required,max=128"`
+	GPUCount  int       `json:"gpu_count" binding:"required,min=1"`
+	StartTime time.Time `json:"start_time"` // 为空或早于当前时间时从当前时间开始
+	EndTime   time.Time `json:"end_time" binding:"required"`
+	Remark    string    `json:"remark"`
+}
+
+// CancelReservationRequest 取消预约请求
+type CancelReservationRequest struct {
+	Reason string `json:"reason"`
+}
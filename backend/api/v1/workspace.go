@@ -17,3 +17,8 @@ type AddMemberRequest struct {
 	UserID uint   `json:"user_id" binding:"required"`
 	Role   string `json:"role" binding:"omitempty,oneof=admin member viewer"`
 }
+
+// UpdateWorkspaceQuotaRequest 更新工作空间配额请求（管理员）
+type UpdateWorkspaceQuotaRequest struct {
+	QuotaStorage int64 `json:"quota_storage" binding:"min=0"` // 存储容量配额（MB），0 表示不限制
+}
@@ -73,7 +73,10 @@ func runServer(restartChan chan struct{}) error {
 	}
 
 	// 创建 Gin 引擎
-	r := createGinEngine()
+	r, err := createGinEngine()
+	if err != nil {
+		return err
+	}
 
 	// 创建 HTTP 服务器
 	addr := fmt.Sprintf(":%d", config.GlobalConfig.Server.Port)
@@ -128,6 +131,8 @@ func initInfrastructure() error {
 		&entity.ActiveAlert{},
 		&entity.MachineEnrollment{},
 		&entity.HostMetric{},
+		&entity.AgentCredential{},
+		&entity.TaskLogChunk{},
 	)
 	if err != nil {
 		return fmt.Errorf("数据库迁移失败: %w", err)
@@ -149,13 +154,18 @@ func initInfrastructure() error {
 }
 
 // createGinEngine 创建 Gin 引擎
-func createGinEngine() *gin.Engine {
+func createGinEngine() (*gin.Engine, error) {
 	// 设置 Gin 模式
 	gin.SetMode(getGinMode(mode))
 
 	// 创建 Gin 引擎（不使用默认中间件）
 	r := gin.New()
 
+	// 只信任配置的反向代理传入的 X-Forwarded-For，未配置时按连接地址识别客户端 IP（限流、审计依赖该地址）
+	if err := r.SetTrustedProxies(config.GlobalConfig.Server.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("配置 trusted_proxies 无效: %w", err)
+	}
+
 	// 添加自定义中间件
 	r.Use(middleware.CORS())
 	r.Use(middleware.Logger(logger.GetLogger()))
@@ -164,7 +174,7 @@ func createGinEngine() *gin.Engine {
 	// 初始化路由
 	router.InitRouter(r)
 
-	return r
+	return r, nil
 }
 
 // initHotReload 初始化热更新管理器
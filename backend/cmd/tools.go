@@ -120,6 +120,8 @@ var migrateCmd = &cobra.Command{
 			&entity.AlertRule{},
 			&entity.ActiveAlert{},
 			&entity.MachineEnrollment{},
+			&entity.AgentCredential{},
+			&entity.TaskLogChunk{},
 		)
 		if err != nil {
 			log.Fatalf("迁移失败: %v", err)
@@ -34,6 +34,14 @@ type Config struct {
 	MachineAction MachineActionConfig `yaml:"machine_action"`
 	HeartbeatMonitor HeartbeatMonitorConfig `yaml:"heartbeat_monitor"`
 	MetricsCollector MetricsCollectorConfig `yaml:"metrics_collector"`
+	AlertEvaluator   AlertEvaluatorConfig   `yaml:"alert_evaluator"`
+	TaskLeaseReaper  TaskLeaseReaperConfig  `yaml:"task_lease_reaper"`
+	DatasetUpload    DatasetUploadConfig    `yaml:"dataset_upload"`
+	AllocationExpiry AllocationExpiryConfig `yaml:"allocation_expiry"`
+	Reservation      ReservationConfig      `yaml:"reservation"`
+	Billing          BillingConfig          `yaml:"billing"`
+	RateLimit        RateLimitConfig        `yaml:"rate_limit"`
+	LoginLockout     LoginLockoutConfig     `yaml:"login_lockout"`
 }
 
 // ServerConfig 服务器配置
@@ -41,6 +49,8 @@ type ServerConfig struct {
 	Host string `yaml:"host"`
 	Port int    `yaml:"port"`
 	Mode string `yaml:"mode"` // debug, release, test
+	// TrustedProxies 可信反向代理的 IP/CIDR，只有来自这些地址的请求才使用 X-Forwarded-For 识别客户端 IP；为空时不信任任何代理
+	TrustedProxies []string `yaml:"trusted_proxies"`
 }
 
 // DatabaseConfig 数据库配置
@@ -109,8 +119,9 @@ type MailConfig struct {
 	Port     int    `yaml:"port"`
 	User     string `yaml:"user"`
 	Password string `yaml:"password"`
-	From     string `yaml:"from"`    // 发件人名称
-	UseSSL   bool   `yaml:"use_ssl"` // 是否使用 SSL/TLS
+	From     string `yaml:"from"`     // 发件人名称
+	UseSSL   bool   `yaml:"use_ssl"`  // 是否使用 SSL/TLS
+	BaseURL  string `yaml:"base_url"` // 邮件中链接指向的控制台地址，如 https://gpu.example.com
 }
 
 // HarborConfig Harbor 镜像仓库配置
@@ -207,7 +218,7 @@ type SwaggerConfig struct {
 // AgentConfig Agent 通信配置
 type AgentConfig struct {
 	Enabled     bool   `yaml:"enabled"`     // 是否启用 Agent 通信
-	Token       string `yaml:"token"`       // Agent 认证 Token
+	Token       string `yaml:"token"`       // 共享 Token，Proxy 认证使用；Agent 仅在 allow_shared_token 时可用
 	Protocol    string `yaml:"protocol"`    // 通信协议: grpc, http
 	Port        int    `yaml:"port"`        // Agent 默认端口
 	GRPCPort    int    `yaml:"grpc_port"`   // gRPC 端口
@@ -218,6 +229,10 @@ type AgentConfig struct {
 	TLSEnabled  bool   `yaml:"tls_enabled"` // 是否启用 TLS
 	TLSCertFile string `yaml:"tls_cert"`    // TLS 证书文件
 	TLSKeyFile  string `yaml:"tls_key"`     // TLS 密钥文件
+
+	AllowSharedToken   bool   `yaml:"allow_shared_token"`   // 兼容旧 Agent，允许使用共享 Token（不绑定机器身份）
+	EnrollmentTokenTTL int    `yaml:"enrollment_token_ttl"` // 注册令牌有效期(秒)，默认 24 小时
+	ControlSecret      string `yaml:"control_secret"`       // 控制接口签名共享密钥，未签发机器密钥的 Agent 使用
 }
 
 // EnrollmentConfig 用户添加机器队列配置
@@ -242,9 +257,78 @@ type HeartbeatMonitorConfig struct {
 
 // MetricsCollectorConfig 监控数据采集配置
 type MetricsCollectorConfig struct {
-	Enabled       bool `yaml:"enabled"`        // 是否启用
-	Interval      int  `yaml:"interval"`       // 采集间隔(秒)
-	RetentionDays int  `yaml:"retention_days"` // 数据保留天数
+	Enabled             bool `yaml:"enabled"`               // 是否启用
+	Interval            int  `yaml:"interval"`              // GPU 指标降采样间隔(秒)
+	RetentionDays       int  `yaml:"retention_days"`        // 机器监控数据保留天数
+	RawRetentionHours   int  `yaml:"raw_retention_hours"`   // GPU 原始指标保留小时数
+	MinuteRetentionDays int  `yaml:"minute_retention_days"` // GPU 1m 降采样数据保留天数
+	HourRetentionDays   int  `yaml:"hour_retention_days"`   // GPU 1h 降采样数据保留天数
+	DayRetentionDays    int  `yaml:"day_retention_days"`    // GPU 1d 降采样数据保留天数
+}
+
+// AlertEvaluatorConfig 告警评估引擎配置
+type AlertEvaluatorConfig struct {
+	Enabled  bool `yaml:"enabled"`  // 是否启用
+	Interval int  `yaml:"interval"` // 评估间隔(秒)
+}
+
+// TaskLeaseReaperConfig 任务租约回收配置
+type TaskLeaseReaperConfig struct {
+	Enabled  bool `yaml:"enabled"`  // 是否启用
+	Interval int  `yaml:"interval"` // 扫描间隔(秒)
+}
+
+// DatasetUploadConfig 数据集分片上传配置
+type DatasetUploadConfig struct {
+	Backend         string `yaml:"backend"`          // 存储后端名称，为空时使用默认后端
+	PartSize        int64  `yaml:"part_size"`        // 分片大小(字节)，文件过大时自动增大以满足分片数上限
+	UploadTTL       int    `yaml:"upload_ttl"`       // 上传有效期(小时)，超时未完成的上传会被中止
+	URLExpire       int    `yaml:"url_expire"`       // 分片上传 URL 有效期(秒)
+	JanitorEnabled  bool   `yaml:"janitor_enabled"`  // 是否启用过期上传清理
+	JanitorInterval int    `yaml:"janitor_interval"` // 清理间隔(秒)
+}
+
+// AllocationExpiryConfig 机器分配到期处理配置
+type AllocationExpiryConfig struct {
+	Enabled        bool `yaml:"enabled"`          // 是否启用
+	Interval       int  `yaml:"interval"`         // 扫描间隔(秒)
+	WarnDays       int  `yaml:"warn_days"`        // 到期前多少天提醒客户
+	MaxRenewMonths int  `yaml:"max_renew_months"` // 单次续期最多月数
+}
+
+// ReservationConfig 机器预约配置
+type ReservationConfig struct {
+	Enabled         bool `yaml:"enabled"`           // 是否启用预约调度（排队分配、到点激活、过期处理）
+	Interval        int  `yaml:"interval"`          // 调度间隔(秒)
+	MaxAdvanceDays  int  `yaml:"max_advance_days"`  // 最多提前多少天预约
+	MaxDurationDays int  `yaml:"max_duration_days"` // 单个预约时间窗口最长天数
+}
+
+// BillingConfig 用量计量与账单配置（单价在系统配置 billing 分组中维护）
+type BillingConfig struct {
+	Enabled       bool `yaml:"enabled"`        // 是否启用用量计量和月度账单生成
+	Interval      int  `yaml:"interval"`       // 计量间隔(秒)
+	LookbackHours int  `yaml:"lookback_hours"` // 每轮回看最近多少小时内结束的分配/环境/任务，应大于服务可能停机的时长
+}
+
+// RateLimitConfig 接口限流配置，计数保存在缓存中（使用 Redis 时多实例共享），各项为 0 表示不限制
+type RateLimitConfig struct {
+	Enabled                 bool `yaml:"enabled"`                    // 是否启用
+	Window                  int  `yaml:"window"`                     // 计数窗口(秒)
+	LoginPerIP              int  `yaml:"login_per_ip"`               // 每个 IP 每窗口的登录请求数（含管理员登录）
+	LoginPerAccount         int  `yaml:"login_per_account"`          // 每个账号每窗口的登录请求数
+	PasswordResetPerIP      int  `yaml:"password_reset_per_ip"`      // 每个 IP 每窗口的找回密码请求数
+	PasswordResetPerAccount int  `yaml:"password_reset_per_account"` // 每个账号每窗口的找回密码请求数
+	AgentClaimPerMachine    int  `yaml:"agent_claim_per_machine"`    // 每台机器每窗口的任务领取请求数
+	MFAManagePerAccount     int  `yaml:"mfa_manage_per_account"`     // 每个账号每窗口关闭两步验证、重新生成恢复码的请求数
+}
+
+// LoginLockoutConfig 登录失败锁定配置
+type LoginLockoutConfig struct {
+	Enabled        bool `yaml:"enabled"`          // 是否启用
+	MaxAttempts    int  `yaml:"max_attempts"`     // 连续失败多少次后锁定
+	LockMinutes    int  `yaml:"lock_minutes"`     // 首次锁定时长(分钟)，之后每次锁定翻倍
+	MaxLockMinutes int  `yaml:"max_lock_minutes"` // 锁定时长上限(分钟)
 }
 
 var GlobalConfig *Config
@@ -19,12 +19,27 @@ type Client interface {
 	// MountDataset 挂载数据集
 	MountDataset(ctx context.Context, req *MountDatasetRequest) (*Response, error)
 
+	// UnmountDataset 卸载数据集
+	UnmountDataset(ctx context.Context, req *UnmountDatasetRequest) (*Response, error)
+
 	// GetSystemInfo 获取系统信息
 	GetSystemInfo(ctx context.Context, hostID string) (*SystemInfo, error)
 
 	// ExecuteCommand 执行命令
 	ExecuteCommand(ctx context.Context, req *ExecuteCommandRequest) (*ExecuteCommandResponse, error)
 
+	// CreateContainer 创建并启动容器
+	CreateContainer(ctx context.Context, req *CreateContainerRequest) (*ContainerInfo, error)
+
+	// StartContainer 启动容器
+	StartContainer(ctx context.Context, req *ContainerRequest) (*Response, error)
+
+	// StopContainer 停止容器
+	StopContainer(ctx context.Context, req *ContainerRequest) (*Response, error)
+
+	// RemoveContainer 删除容器
+	RemoveContainer(ctx context.Context, req *ContainerRequest) (*Response, error)
+
 	// Ping 检查连接
 	Ping(ctx context.Context, hostID string) error
 
@@ -0,0 +1,111 @@
+package agent
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// buildDockerRunCommand 根据创建请求构造 docker run 命令行（用于 ExecuteCommand 通道）
+func buildDockerRunCommand(req *CreateContainerRequest) string {
+	args := []string{"docker", "run", "-d", "--restart", "unless-stopped"}
+	if req.Name != "" {
+		args = append(args, "--name", shellQuote(req.Name))
+	}
+	if req.CPU > 0 {
+		args = append(args, "--cpus", strconv.Itoa(req.CPU))
+	}
+	if req.MemoryMB > 0 {
+		args = append(args, "--memory", strconv.FormatInt(req.MemoryMB, 10)+"m")
+	}
+	if len(req.GPUDevices) > 0 {
+		args = append(args, "--gpus", shellQuote(gpuDeviceOption(req.GPUDevices)))
+	} else if req.GPU < 0 {
+		args = append(args, "--gpus", "all")
+	} else if req.GPU > 0 {
+		args = append(args, "--gpus", strconv.Itoa(req.GPU))
+	}
+
+	envKeys := make([]string, 0, len(req.Env))
+	for k := range req.Env {
+		envKeys = append(envKeys, k)
+	}
+	sort.Strings(envKeys)
+	for _, k := range envKeys {
+		args = append(args, "-e", shellQuote(k+"="+req.Env[k]))
+	}
+
+	labelKeys := make([]string, 0, len(req.Labels))
+	for k := range req.Labels {
+		labelKeys = append(labelKeys, k)
+	}
+	sort.Strings(labelKeys)
+	for _, k := range labelKeys {
+		args = append(args, "--label", shellQuote(k+"="+req.Labels[k]))
+	}
+
+	for _, p := range req.Ports {
+		proto := p.Protocol
+		if proto == "" {
+			proto = "tcp"
+		}
+		if p.HostPort > 0 {
+			args = append(args, "-p", fmt.Sprintf("%d:%d/%s", p.HostPort, p.ContainerPort, proto))
+		} else {
+			args = append(args, "-p", fmt.Sprintf("%d/%s", p.ContainerPort, proto))
+		}
+	}
+
+	args = append(args, shellQuote(req.Image))
+	for _, c := range req.Command {
+		args = append(args, shellQuote(c))
+	}
+	return strings.Join(args, " ")
+}
+
+// gpuDeviceOption 构造 --gpus 的设备参数，多个序号时需要用双引号包住（docker 按 CSV 解析该参数）
+func gpuDeviceOption(indexes []int) string {
+	ids := make([]string, len(indexes))
+	for i, idx := range indexes {
+		ids[i] = strconv.Itoa(idx)
+	}
+	return `"device=` + strings.Join(ids, ",") + `"`
+}
+
+// parseDockerPortOutput 解析 docker port 输出，如 "22/tcp -> 0.0.0.0:32768"
+func parseDockerPortOutput(out string) []ContainerPort {
+	var ports []ContainerPort
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(out, "\n") {
+		left, right, ok := strings.Cut(strings.TrimSpace(line), " -> ")
+		if !ok {
+			continue
+		}
+		portStr, proto, _ := strings.Cut(left, "/")
+		containerPort, err := strconv.Atoi(portStr)
+		if err != nil {
+			continue
+		}
+		idx := strings.LastIndex(right, ":")
+		if idx < 0 {
+			continue
+		}
+		hostPort, err := strconv.Atoi(right[idx+1:])
+		if err != nil {
+			continue
+		}
+		// IPv4 与 IPv6 会各输出一行，只保留一条
+		if seen[left] {
+			continue
+		}
+		seen[left] = true
+		ports = append(ports, ContainerPort{ContainerPort: containerPort, HostPort: hostPort, Protocol: proto})
+	}
+	return ports
+}
+
+// shellQuote 使用单引号转义 shell 参数
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
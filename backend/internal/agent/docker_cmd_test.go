@@ -0,0 +1,38 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildDockerRunCommand(t *testing.T) {
+	cmd := buildDockerRunCommand(&CreateContainerRequest{
+		Name:     "env-1",
+		Image:    "pytorch:latest",
+		Env:      map[string]string{"TOKEN": "it's"},
+		CPU:      4,
+		MemoryMB: 8192,
+		GPU:      1,
+		Ports:    []ContainerPort{{ContainerPort: 22}},
+	})
+
+	assert.Equal(t,
+		`docker run -d --restart unless-stopped --name 'env-1' --cpus 4 --memory 8192m --gpus 1 -e 'TOKEN=it'\''s' -p 22/tcp 'pytorch:latest'`,
+		cmd)
+}
+
+func TestBuildDockerRunCommand_GPUDevices(t *testing.T) {
+	cmd := buildDockerRunCommand(&CreateContainerRequest{Image: "ubuntu", GPU: 2, GPUDevices: []int{1, 3}})
+	assert.Equal(t, `docker run -d --restart unless-stopped --gpus '"device=1,3"' 'ubuntu'`, cmd)
+}
+
+func TestParseDockerPortOutput(t *testing.T) {
+	out := "22/tcp -> 0.0.0.0:32768\n22/tcp -> [::]:32768\n8888/tcp -> 0.0.0.0:32769\n"
+
+	ports := parseDockerPortOutput(out)
+	assert.Equal(t, []ContainerPort{
+		{ContainerPort: 22, HostPort: 32768, Protocol: "tcp"},
+		{ContainerPort: 8888, HostPort: 32769, Protocol: "tcp"},
+	}, ports)
+}
@@ -3,21 +3,26 @@ package agent
 import (
 	"context"
 	"fmt"
+	"io"
+	"strings"
 	"sync"
+	"time"
 
 	pb "github.com/YoungBoyGod/remotegpu/api/proto/agent"
 	"github.com/YoungBoyGod/remotegpu/config"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/proto"
 )
 
 // GRPCClient gRPC 客户端实现
 type GRPCClient struct {
-	config  *config.AgentConfig
-	connMap map[string]*grpc.ClientConn
-	hostMap map[string]string // hostID -> address
-	mu      sync.RWMutex
+	config      *config.AgentConfig
+	connMap     map[string]*grpc.ClientConn
+	hostMap     map[string]string // hostID -> address
+	keyProvider ControlKeyProvider
+	mu          sync.RWMutex
 }
 
 // NewGRPCClient 创建 gRPC 客户端
@@ -36,6 +41,39 @@ func (c *GRPCClient) RegisterHost(hostID, address string) {
 	c.hostMap[hostID] = address
 }
 
+// SetControlKeyProvider 设置按主机选择签名密钥的提供者
+func (c *GRPCClient) SetControlKeyProvider(p ControlKeyProvider) {
+	c.keyProvider = p
+}
+
+// signedContext 为一次调用签名，签名覆盖完整方法名与请求消息
+func (c *GRPCClient) signedContext(ctx context.Context, hostID, fullMethod string, req proto.Message) (context.Context, error) {
+	key, err := resolveControlKey(ctx, c.keyProvider, c.config.ControlSecret, hostID)
+	if err != nil {
+		return nil, err
+	}
+	return signGRPC(ctx, key, fullMethod, req, time.Now())
+}
+
+// signingInterceptor 为发往指定主机的一元调用签名
+func (c *GRPCClient) signingInterceptor(hostID string) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		// 与 Agent 一致，健康检查无需签名
+		if method != pb.AgentService_Ping_FullMethodName {
+			msg, ok := req.(proto.Message)
+			if !ok {
+				return fmt.Errorf("unexpected request type %T", req)
+			}
+			signed, err := c.signedContext(ctx, hostID, method, msg)
+			if err != nil {
+				return err
+			}
+			ctx = signed
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
 // getClient 获取或创建 gRPC 客户端
 func (c *GRPCClient) getClient(hostID string) (pb.AgentServiceClient, error) {
 	c.mu.RLock()
@@ -73,6 +111,9 @@ func (c *GRPCClient) getClient(hostID string) (pb.AgentServiceClient, error) {
 		opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	}
 
+	// 流式调用的签名依赖请求消息，由各流式方法自行签名
+	opts = append(opts, grpc.WithUnaryInterceptor(c.signingInterceptor(hostID)))
+
 	conn, err := grpc.NewClient(target, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("create client %s: %w", target, err)
@@ -150,26 +191,39 @@ func (c *GRPCClient) ResetSSH(ctx context.Context, req *ResetSSHRequest) (*Respo
 	}, nil
 }
 
-// SyncSSHKeys 同步SSH密钥（通过 ExecuteCommand 写入 authorized_keys）
+// SyncSSHKeys 同步SSH密钥
 func (c *GRPCClient) SyncSSHKeys(ctx context.Context, req *SyncSSHKeysRequest) (*Response, error) {
-	// gRPC proto 暂未定义 SyncSSHKeys，通过 ExecuteCommand 实现
-	username := req.Username
-	if username == "" {
-		username = "root"
+	client, err := c.getClient(req.HostID)
+	if err != nil {
+		return nil, err
 	}
-	keysContent := buildAuthorizedKeysContent(req.PublicKeys)
-	cmd := fmt.Sprintf("mkdir -p /home/%s/.ssh && echo '%s' > /home/%s/.ssh/authorized_keys && chmod 600 /home/%s/.ssh/authorized_keys && chmod 700 /home/%s/.ssh",
-		username, keysContent, username, username, username)
 
-	_, err := c.ExecuteCommand(ctx, &ExecuteCommandRequest{
-		HostID:  req.HostID,
-		Command: cmd,
-		Timeout: 30,
-	})
+	pbReq := &pb.SyncSSHKeysRequest{
+		HostId:     req.HostID,
+		PublicKeys: req.PublicKeys,
+		Username:   req.Username,
+	}
+	for _, t := range req.Tenants {
+		indexes := make([]int32, len(t.GPUIndexes))
+		for i, idx := range t.GPUIndexes {
+			indexes[i] = int32(idx)
+		}
+		pbReq.Tenants = append(pbReq.Tenants, &pb.TenantAccount{Username: t.Username, PublicKeys: t.PublicKeys, GpuIndexes: indexes})
+	}
+
+	resp, err := client.SyncSSHKeys(ctx, pbReq)
 	if err != nil {
 		return nil, err
 	}
-	return &Response{Success: true, Message: "ssh keys synced"}, nil
+	if !resp.Success || resp.Code != 0 {
+		return nil, fmt.Errorf("agent error: code=%d message=%s", resp.Code, resp.Message)
+	}
+
+	return &Response{
+		Success: resp.Success,
+		Code:    int(resp.Code),
+		Message: resp.Message,
+	}, nil
 }
 
 // CleanupMachine 清理机器
@@ -209,6 +263,7 @@ func (c *GRPCClient) MountDataset(ctx context.Context, req *MountDatasetRequest)
 	pbReq := &pb.MountDatasetRequest{
 		HostId:     req.HostID,
 		DatasetId:  uint32(req.DatasetID),
+		SourceType: req.SourceType,
 		SourcePath: req.SourcePath,
 		MountPoint: req.MountPoint,
 		ReadOnly:   req.ReadOnly,
@@ -229,6 +284,34 @@ func (c *GRPCClient) MountDataset(ctx context.Context, req *MountDatasetRequest)
 	}, nil
 }
 
+// UnmountDataset 卸载数据集
+func (c *GRPCClient) UnmountDataset(ctx context.Context, req *UnmountDatasetRequest) (*Response, error) {
+	client, err := c.getClient(req.HostID)
+	if err != nil {
+		return nil, err
+	}
+
+	pbReq := &pb.UnmountDatasetRequest{
+		HostId:     req.HostID,
+		DatasetId:  uint32(req.DatasetID),
+		MountPoint: req.MountPoint,
+	}
+
+	resp, err := client.UnmountDataset(ctx, pbReq)
+	if err != nil {
+		return nil, err
+	}
+	if !resp.Success || resp.Code != 0 {
+		return nil, fmt.Errorf("agent error: code=%d message=%s", resp.Code, resp.Message)
+	}
+
+	return &Response{
+		Success: resp.Success,
+		Code:    int(resp.Code),
+		Message: resp.Message,
+	}, nil
+}
+
 // GetSystemInfo 获取系统信息
 func (c *GRPCClient) GetSystemInfo(ctx context.Context, hostID string) (*SystemInfo, error) {
 	client, err := c.getClient(hostID)
@@ -294,6 +377,80 @@ func (c *GRPCClient) ExecuteCommand(ctx context.Context, req *ExecuteCommandRequ
 	}, nil
 }
 
+// ExecuteCommandStream 执行命令并实时回调输出，返回退出码
+func (c *GRPCClient) ExecuteCommandStream(ctx context.Context, req *ExecuteCommandRequest, onOutput func(*CommandOutput)) (int, error) {
+	client, err := c.getClient(req.HostID)
+	if err != nil {
+		return 0, err
+	}
+
+	pbReq := &pb.ExecuteCommandRequest{
+		HostId:  req.HostID,
+		Command: req.Command,
+		Timeout: int32(req.Timeout),
+	}
+	ctx, err = c.signedContext(ctx, req.HostID, pb.AgentService_ExecuteCommandStream_FullMethodName, pbReq)
+	if err != nil {
+		return 0, err
+	}
+
+	stream, err := client.ExecuteCommandStream(ctx, pbReq)
+	if err != nil {
+		return 0, err
+	}
+	for {
+		msg, err := stream.Recv()
+		if err == io.EOF {
+			return 0, fmt.Errorf("agent closed stream without exit code")
+		}
+		if err != nil {
+			return 0, err
+		}
+		if msg.Done {
+			return int(msg.ExitCode), nil
+		}
+		onOutput(&CommandOutput{Stream: msg.Stream, Data: msg.Data})
+	}
+}
+
+// StreamTaskLogs 从 Offset 开始读取任务输出，Follow 时持续回调直到任务结束
+func (c *GRPCClient) StreamTaskLogs(ctx context.Context, req *TaskLogsRequest, onChunk func(*TaskLogChunk)) error {
+	client, err := c.getClient(req.HostID)
+	if err != nil {
+		return err
+	}
+
+	pbReq := &pb.TaskLogsRequest{
+		HostId: req.HostID,
+		TaskId: req.TaskID,
+		Stream: req.Stream,
+		Offset: req.Offset,
+		Follow: req.Follow,
+	}
+	ctx, err = c.signedContext(ctx, req.HostID, pb.AgentService_StreamTaskLogs_FullMethodName, pbReq)
+	if err != nil {
+		return err
+	}
+
+	stream, err := client.StreamTaskLogs(ctx, pbReq)
+	if err != nil {
+		return err
+	}
+	for {
+		msg, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		onChunk(&TaskLogChunk{Stream: msg.Stream, Offset: msg.Offset, Data: msg.Data, EOF: msg.Eof})
+		if msg.Eof {
+			return nil
+		}
+	}
+}
+
 // Ping 健康检查
 func (c *GRPCClient) Ping(ctx context.Context, hostID string) error {
 	client, err := c.getClient(hostID)
@@ -312,3 +469,71 @@ func (c *GRPCClient) Ping(ctx context.Context, hostID string) error {
 	}
 	return nil
 }
+
+// CreateContainer 创建并启动容器
+// gRPC proto 暂未定义容器接口，通过 ExecuteCommand 调用 docker CLI 实现
+func (c *GRPCClient) CreateContainer(ctx context.Context, req *CreateContainerRequest) (*ContainerInfo, error) {
+	res, err := c.execDocker(ctx, req.HostID, buildDockerRunCommand(req), 600)
+	if err != nil {
+		return nil, err
+	}
+	id := strings.TrimSpace(res.Stdout)
+	if id == "" {
+		return nil, fmt.Errorf("agent returned empty container id")
+	}
+
+	info := &ContainerInfo{ContainerID: id}
+	if len(req.Ports) > 0 {
+		portRes, err := c.execDocker(ctx, req.HostID, "docker port "+shellQuote(id), 30)
+		if err != nil {
+			return nil, err
+		}
+		info.Ports = parseDockerPortOutput(portRes.Stdout)
+	}
+	return info, nil
+}
+
+// StartContainer 启动容器
+func (c *GRPCClient) StartContainer(ctx context.Context, req *ContainerRequest) (*Response, error) {
+	if _, err := c.execDocker(ctx, req.HostID, "docker start "+shellQuote(req.ContainerID), 60); err != nil {
+		return nil, err
+	}
+	return &Response{Success: true, Message: "ok"}, nil
+}
+
+// StopContainer 停止容器
+func (c *GRPCClient) StopContainer(ctx context.Context, req *ContainerRequest) (*Response, error) {
+	timeout := req.Timeout
+	if timeout <= 0 {
+		timeout = 10
+	}
+	cmd := fmt.Sprintf("docker stop -t %d %s", timeout, shellQuote(req.ContainerID))
+	if _, err := c.execDocker(ctx, req.HostID, cmd, timeout+30); err != nil {
+		return nil, err
+	}
+	return &Response{Success: true, Message: "ok"}, nil
+}
+
+// RemoveContainer 删除容器
+func (c *GRPCClient) RemoveContainer(ctx context.Context, req *ContainerRequest) (*Response, error) {
+	if _, err := c.execDocker(ctx, req.HostID, "docker rm -f "+shellQuote(req.ContainerID), 60); err != nil {
+		return nil, err
+	}
+	return &Response{Success: true, Message: "ok"}, nil
+}
+
+// execDocker 执行 docker 命令，非零退出码视为失败
+func (c *GRPCClient) execDocker(ctx context.Context, hostID, cmd string, timeout int) (*ExecuteCommandResponse, error) {
+	res, err := c.ExecuteCommand(ctx, &ExecuteCommandRequest{
+		HostID:  hostID,
+		Command: cmd,
+		Timeout: timeout,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if res.ExitCode != 0 {
+		return nil, fmt.Errorf("agent error: exit=%d stderr=%s", res.ExitCode, strings.TrimSpace(res.Stderr))
+	}
+	return res, nil
+}
@@ -0,0 +1,164 @@
+package agent
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+
+	pb "github.com/YoungBoyGod/remotegpu/api/proto/agent"
+	"github.com/YoungBoyGod/remotegpu/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// fakeAgentServer 模拟 Agent gRPC 服务：按 key 校验签名
+type fakeAgentServer struct {
+	pb.UnimplementedAgentServiceServer
+	key     string
+	synced  *pb.SyncSSHKeysRequest
+	mounted *pb.MountDatasetRequest
+}
+
+func (s *fakeAgentServer) verify(ctx context.Context, method string, req proto.Message) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	get := func(k string) string {
+		if v := md.Get(strings.ToLower(k)); len(v) > 0 {
+			return v[0]
+		}
+		return ""
+	}
+	body, _ := proto.MarshalOptions{Deterministic: true}.Marshal(req)
+	bodySum := sha256.Sum256(body)
+	mac := hmac.New(sha256.New, []byte(s.key))
+	mac.Write([]byte("GRPC\n" + method + "\n" + get(headerTimestamp) + "\n" + get(headerNonce) + "\n" + hex.EncodeToString(bodySum[:])))
+	if !hmac.Equal([]byte(hex.EncodeToString(mac.Sum(nil))), []byte(get(headerSignature))) {
+		return status.Error(codes.Unauthenticated, "invalid request signature")
+	}
+	return nil
+}
+
+func (s *fakeAgentServer) SyncSSHKeys(ctx context.Context, req *pb.SyncSSHKeysRequest) (*pb.Response, error) {
+	if err := s.verify(ctx, pb.AgentService_SyncSSHKeys_FullMethodName, req); err != nil {
+		return nil, err
+	}
+	s.synced = req
+	return &pb.Response{Success: true, Message: "ok"}, nil
+}
+
+func (s *fakeAgentServer) MountDataset(ctx context.Context, req *pb.MountDatasetRequest) (*pb.Response, error) {
+	if err := s.verify(ctx, pb.AgentService_MountDataset_FullMethodName, req); err != nil {
+		return nil, err
+	}
+	s.mounted = req
+	return &pb.Response{Success: false, Code: 30009, Message: "mount failed"}, nil
+}
+
+func (s *fakeAgentServer) Ping(ctx context.Context, req *pb.PingRequest) (*pb.PingResponse, error) {
+	return &pb.PingResponse{Ok: true}, nil
+}
+
+func (s *fakeAgentServer) ExecuteCommandStream(req *pb.ExecuteCommandRequest, stream pb.AgentService_ExecuteCommandStreamServer) error {
+	if err := s.verify(stream.Context(), pb.AgentService_ExecuteCommandStream_FullMethodName, req); err != nil {
+		return err
+	}
+	_ = stream.Send(&pb.CommandOutput{Stream: "stdout", Data: []byte("hello\n")})
+	_ = stream.Send(&pb.CommandOutput{Stream: "stderr", Data: []byte("warn\n")})
+	return stream.Send(&pb.CommandOutput{Done: true, ExitCode: 2})
+}
+
+func (s *fakeAgentServer) StreamTaskLogs(req *pb.TaskLogsRequest, stream pb.AgentService_StreamTaskLogsServer) error {
+	if err := s.verify(stream.Context(), pb.AgentService_StreamTaskLogs_FullMethodName, req); err != nil {
+		return err
+	}
+	_ = stream.Send(&pb.TaskLogChunk{Stream: req.Stream, Offset: req.Offset, Data: []byte("line\n")})
+	return stream.Send(&pb.TaskLogChunk{Stream: req.Stream, Offset: req.Offset + 5, Eof: true})
+}
+
+func newTestGRPCClient(t *testing.T, srv *fakeAgentServer, cfg config.AgentConfig) *GRPCClient {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := grpc.NewServer()
+	pb.RegisterAgentServiceServer(s, srv)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	host, port, err := net.SplitHostPort(lis.Addr().String())
+	require.NoError(t, err)
+	cfg.GRPCPort, _ = strconv.Atoi(port)
+	c := NewGRPCClient(&cfg)
+	c.RegisterHost("host-1", host)
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestGRPCClient_SignsUnaryCalls(t *testing.T) {
+	srv := &fakeAgentServer{key: "machine-key"}
+	c := newTestGRPCClient(t, srv, config.AgentConfig{ControlSecret: "shared"})
+	c.SetControlKeyProvider(staticKeyProvider{"host-1": "machine-key"})
+	ctx := context.Background()
+
+	require.NoError(t, c.Ping(ctx, "host-1"))
+
+	_, err := c.SyncSSHKeys(ctx, &SyncSSHKeysRequest{
+		HostID:     "host-1",
+		PublicKeys: []string{"ssh-ed25519 AAAA a"},
+		Tenants:    []TenantAccount{{Username: "rg-c2", PublicKeys: []string{"ssh-ed25519 BBBB b"}, GPUIndexes: []int{2, 3}}},
+		Username:   "alice",
+	})
+	require.NoError(t, err)
+	require.NotNil(t, srv.synced)
+	assert.Equal(t, []string{"ssh-ed25519 AAAA a"}, srv.synced.PublicKeys)
+	require.Len(t, srv.synced.Tenants, 1)
+	assert.Equal(t, "rg-c2", srv.synced.Tenants[0].Username)
+	assert.Equal(t, []int32{2, 3}, srv.synced.Tenants[0].GpuIndexes)
+	assert.Equal(t, "alice", srv.synced.Username)
+
+	// 业务失败通过 Response.Code 返回
+	_, err = c.MountDataset(ctx, &MountDatasetRequest{HostID: "host-1", DatasetID: 7, SourceType: "nfs", SourcePath: "nas:/ds", MountPoint: "/mnt/ds"})
+	assert.EqualError(t, err, "agent error: code=30009 message=mount failed")
+	require.NotNil(t, srv.mounted)
+	assert.Equal(t, "nfs", srv.mounted.SourceType)
+}
+
+func TestGRPCClient_RejectedWithWrongKey(t *testing.T) {
+	srv := &fakeAgentServer{key: "machine-key"}
+	c := newTestGRPCClient(t, srv, config.AgentConfig{ControlSecret: "shared"})
+
+	_, err := c.SyncSSHKeys(context.Background(), &SyncSSHKeysRequest{HostID: "host-1"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestGRPCClient_Streams(t *testing.T) {
+	srv := &fakeAgentServer{key: "shared"}
+	c := newTestGRPCClient(t, srv, config.AgentConfig{ControlSecret: "shared"})
+	ctx := context.Background()
+
+	output := map[string]string{}
+	exitCode, err := c.ExecuteCommandStream(ctx, &ExecuteCommandRequest{HostID: "host-1", Command: "train.sh"}, func(o *CommandOutput) {
+		output[o.Stream] += string(o.Data)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, exitCode)
+	assert.Equal(t, map[string]string{"stdout": "hello\n", "stderr": "warn\n"}, output)
+
+	var chunks []*TaskLogChunk
+	err = c.StreamTaskLogs(ctx, &TaskLogsRequest{HostID: "host-1", TaskID: "t1", Stream: "stdout", Offset: 10, Follow: true}, func(chunk *TaskLogChunk) {
+		chunks = append(chunks, chunk)
+	})
+	require.NoError(t, err)
+	require.Len(t, chunks, 2)
+	assert.Equal(t, "line\n", string(chunks[0].Data))
+	assert.Equal(t, int64(10), chunks[0].Offset)
+	assert.True(t, chunks[1].EOF)
+	assert.Equal(t, int64(15), chunks[1].Offset)
+}
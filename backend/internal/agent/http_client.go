@@ -13,9 +13,10 @@ import (
 
 // HTTPClient HTTP 客户端实现
 type HTTPClient struct {
-	client  *http.Client
-	config  *config.AgentConfig
-	hostMap map[string]string // hostID -> host:port
+	client      *http.Client
+	config      *config.AgentConfig
+	hostMap     map[string]string // hostID -> host:port
+	keyProvider ControlKeyProvider
 }
 
 // NewHTTPClient 创建 HTTP 客户端
@@ -34,6 +35,32 @@ func (c *HTTPClient) RegisterHost(hostID, address string) {
 	c.hostMap[hostID] = address
 }
 
+// SetControlKeyProvider 设置按主机选择签名密钥的提供者
+func (c *HTTPClient) SetControlKeyProvider(p ControlKeyProvider) {
+	c.keyProvider = p
+}
+
+// signingKey 获取主机的签名密钥
+func (c *HTTPClient) signingKey(ctx context.Context, hostID string) (string, error) {
+	return resolveControlKey(ctx, c.keyProvider, c.config.ControlSecret, hostID)
+}
+
+// newRequest 创建已签名的请求
+func (c *HTTPClient) newRequest(ctx context.Context, hostID, method, url string, body []byte) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	key, err := c.signingKey(ctx, hostID)
+	if err != nil {
+		return nil, err
+	}
+	if err := signRequest(req, key, body, time.Now()); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
 // getHostURL 获取主机 URL
 func (c *HTTPClient) getHostURL(hostID, path string) (string, error) {
 	addr, ok := c.hostMap[hostID]
@@ -44,7 +71,7 @@ func (c *HTTPClient) getHostURL(hostID, path string) (string, error) {
 }
 
 // doRequest 执行 HTTP 请求
-func (c *HTTPClient) doRequest(ctx context.Context, method, url string, body any) (*Response, error) {
+func (c *HTTPClient) doRequest(ctx context.Context, hostID, method, url string, body any) (*Response, error) {
 	var reqBody []byte
 	var err error
 	if body != nil {
@@ -54,9 +81,9 @@ func (c *HTTPClient) doRequest(ctx context.Context, method, url string, body any
 		}
 	}
 
-	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(reqBody))
+	req, err := c.newRequest(ctx, hostID, method, url, reqBody)
 	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
+		return nil, err
 	}
 	req.Header.Set("Content-Type", "application/json")
 
@@ -82,7 +109,7 @@ func (c *HTTPClient) StopProcess(ctx context.Context, req *StopProcessRequest) (
 	if err != nil {
 		return nil, err
 	}
-	return c.doRequest(ctx, http.MethodPost, url, req)
+	return c.doRequest(ctx, req.HostID, http.MethodPost, url, req)
 }
 
 // ResetSSH 重置SSH密钥
@@ -91,7 +118,7 @@ func (c *HTTPClient) ResetSSH(ctx context.Context, req *ResetSSHRequest) (*Respo
 	if err != nil {
 		return nil, err
 	}
-	return c.doRequest(ctx, http.MethodPost, url, req)
+	return c.doRequest(ctx, req.HostID, http.MethodPost, url, req)
 }
 
 // SyncSSHKeys 同步SSH密钥（全量覆盖 authorized_keys）
@@ -100,7 +127,7 @@ func (c *HTTPClient) SyncSSHKeys(ctx context.Context, req *SyncSSHKeysRequest) (
 	if err != nil {
 		return nil, err
 	}
-	return c.doRequest(ctx, http.MethodPost, url, req)
+	return c.doRequest(ctx, req.HostID, http.MethodPost, url, req)
 }
 
 // CleanupMachine 清理机器
@@ -109,7 +136,7 @@ func (c *HTTPClient) CleanupMachine(ctx context.Context, req *CleanupRequest) (*
 	if err != nil {
 		return nil, err
 	}
-	return c.doRequest(ctx, http.MethodPost, url, req)
+	return c.doRequest(ctx, req.HostID, http.MethodPost, url, req)
 }
 
 // MountDataset 挂载数据集
@@ -118,7 +145,16 @@ func (c *HTTPClient) MountDataset(ctx context.Context, req *MountDatasetRequest)
 	if err != nil {
 		return nil, err
 	}
-	return c.doRequest(ctx, http.MethodPost, url, req)
+	return c.doRequest(ctx, req.HostID, http.MethodPost, url, req)
+}
+
+// UnmountDataset 卸载数据集
+func (c *HTTPClient) UnmountDataset(ctx context.Context, req *UnmountDatasetRequest) (*Response, error) {
+	url, err := c.getHostURL(req.HostID, "/api/v1/dataset/unmount")
+	if err != nil {
+		return nil, err
+	}
+	return c.doRequest(ctx, req.HostID, http.MethodPost, url, req)
 }
 
 // GetSystemInfo 获取系统信息
@@ -128,7 +164,7 @@ func (c *HTTPClient) GetSystemInfo(ctx context.Context, hostID string) (*SystemI
 		return nil, err
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	req, err := c.newRequest(ctx, hostID, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -153,7 +189,7 @@ func (c *HTTPClient) ExecuteCommand(ctx context.Context, req *ExecuteCommandRequ
 		return nil, err
 	}
 
-	resp, err := c.doRequest(ctx, http.MethodPost, url, req)
+	resp, err := c.doRequest(ctx, req.HostID, http.MethodPost, url, req)
 	if err != nil {
 		return nil, err
 	}
@@ -176,6 +212,60 @@ func (c *HTTPClient) ExecuteCommand(ctx context.Context, req *ExecuteCommandRequ
 	return result, nil
 }
 
+// CreateContainer 创建并启动容器
+func (c *HTTPClient) CreateContainer(ctx context.Context, req *CreateContainerRequest) (*ContainerInfo, error) {
+	url, err := c.getHostURL(req.HostID, "/api/v1/containers")
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.doRequest(ctx, req.HostID, http.MethodPost, url, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Data 为通用 map，重新编解码为结构体
+	raw, err := json.Marshal(resp.Data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid response data: %w", err)
+	}
+	var info ContainerInfo
+	if err := json.Unmarshal(raw, &info); err != nil {
+		return nil, fmt.Errorf("invalid response data: %w", err)
+	}
+	if info.ContainerID == "" {
+		return nil, fmt.Errorf("agent returned empty container id")
+	}
+	return &info, nil
+}
+
+// StartContainer 启动容器
+func (c *HTTPClient) StartContainer(ctx context.Context, req *ContainerRequest) (*Response, error) {
+	url, err := c.getHostURL(req.HostID, "/api/v1/containers/"+req.ContainerID+"/start")
+	if err != nil {
+		return nil, err
+	}
+	return c.doRequest(ctx, req.HostID, http.MethodPost, url, req)
+}
+
+// StopContainer 停止容器
+func (c *HTTPClient) StopContainer(ctx context.Context, req *ContainerRequest) (*Response, error) {
+	url, err := c.getHostURL(req.HostID, "/api/v1/containers/"+req.ContainerID+"/stop")
+	if err != nil {
+		return nil, err
+	}
+	return c.doRequest(ctx, req.HostID, http.MethodPost, url, req)
+}
+
+// RemoveContainer 删除容器
+func (c *HTTPClient) RemoveContainer(ctx context.Context, req *ContainerRequest) (*Response, error) {
+	url, err := c.getHostURL(req.HostID, "/api/v1/containers/"+req.ContainerID)
+	if err != nil {
+		return nil, err
+	}
+	return c.doRequest(ctx, req.HostID, http.MethodDelete, url, nil)
+}
+
 // Ping 检查连接
 func (c *HTTPClient) Ping(ctx context.Context, hostID string) error {
 	url, err := c.getHostURL(hostID, "/api/v1/ping")
@@ -0,0 +1,92 @@
+package agent
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/YoungBoyGod/remotegpu/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type staticKeyProvider map[string]string
+
+func (p staticKeyProvider) ControlKey(ctx context.Context, hostID string) (string, error) {
+	return p[hostID], nil
+}
+
+// newSignedTestServer 模拟 Agent：按 key 校验签名
+func newSignedTestServer(t *testing.T, key string) (*httptest.Server, *http.Header) {
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		body, _ := io.ReadAll(r.Body)
+		bodySum := sha256.Sum256(body)
+		mac := hmac.New(sha256.New, []byte(key))
+		mac.Write([]byte(r.Method + "\n" + r.URL.RequestURI() + "\n" + r.Header.Get(headerTimestamp) + "\n" +
+			r.Header.Get(headerNonce) + "\n" + hex.EncodeToString(bodySum[:])))
+		if !hmac.Equal([]byte(hex.EncodeToString(mac.Sum(nil))), []byte(r.Header.Get(headerSignature))) {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"success":false,"code":30008,"message":"unauthorized"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"success":true,"code":0,"message":"ok"}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &got
+}
+
+func newTestHTTPClient(t *testing.T, srv *httptest.Server, cfg config.AgentConfig) *HTTPClient {
+	host, port, err := net.SplitHostPort(srv.Listener.Addr().String())
+	require.NoError(t, err)
+	cfg.HTTPPort, _ = strconv.Atoi(port)
+	cfg.Timeout = 5
+	c := NewHTTPClient(&cfg)
+	c.RegisterHost("host-1", host)
+	return c
+}
+
+func TestHTTPClient_SignsWithMachineKey(t *testing.T) {
+	srv, headers := newSignedTestServer(t, "machine-key")
+	c := newTestHTTPClient(t, srv, config.AgentConfig{ControlSecret: "shared"})
+	c.SetControlKeyProvider(staticKeyProvider{"host-1": "machine-key"})
+
+	_, err := c.ExecuteCommand(context.Background(), &ExecuteCommandRequest{HostID: "host-1", Command: "nvidia-smi"})
+	// 响应无 data 字段，签名已通过
+	assert.EqualError(t, err, "invalid response data")
+	assert.NotEmpty(t, headers.Get(headerNonce))
+	assert.NotEmpty(t, headers.Get(headerTimestamp))
+
+	_, err = c.StopProcess(context.Background(), &StopProcessRequest{HostID: "host-1", ProcessID: 1})
+	assert.NoError(t, err)
+}
+
+func TestHTTPClient_FallsBackToControlSecret(t *testing.T) {
+	srv, _ := newSignedTestServer(t, "shared")
+	c := newTestHTTPClient(t, srv, config.AgentConfig{ControlSecret: "shared"})
+	c.SetControlKeyProvider(staticKeyProvider{})
+
+	_, err := c.StopProcess(context.Background(), &StopProcessRequest{HostID: "host-1", ProcessID: 1})
+	assert.NoError(t, err)
+
+	// 密钥不一致时 Agent 拒绝
+	c.SetControlKeyProvider(staticKeyProvider{"host-1": "stale-key"})
+	_, err = c.StopProcess(context.Background(), &StopProcessRequest{HostID: "host-1", ProcessID: 1})
+	assert.ErrorContains(t, err, "code=30008")
+}
+
+func TestHTTPClient_NoControlKey(t *testing.T) {
+	srv, _ := newSignedTestServer(t, "shared")
+	c := newTestHTTPClient(t, srv, config.AgentConfig{})
+
+	_, err := c.StopProcess(context.Background(), &StopProcessRequest{HostID: "host-1", ProcessID: 1})
+	assert.ErrorContains(t, err, "no control key for host host-1")
+}
@@ -0,0 +1,94 @@
+package agent
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+// Agent 控制接口签名头，与 Agent 端 security 包保持一致
+const (
+	headerTimestamp = "X-RemoteGPU-Timestamp"
+	headerNonce     = "X-RemoteGPU-Nonce"
+	headerSignature = "X-RemoteGPU-Signature"
+)
+
+// ControlKeyProvider 按主机提供控制接口签名密钥（由 AgentCredentialService 实现）
+// 主机没有机器密钥时返回空字符串
+type ControlKeyProvider interface {
+	ControlKey(ctx context.Context, hostID string) (string, error)
+}
+
+// resolveControlKey 获取主机的签名密钥：优先机器密钥，否则使用共享控制密钥
+func resolveControlKey(ctx context.Context, p ControlKeyProvider, sharedSecret, hostID string) (string, error) {
+	if p != nil {
+		key, err := p.ControlKey(ctx, hostID)
+		if err != nil {
+			return "", fmt.Errorf("load control key: %w", err)
+		}
+		if key != "" {
+			return key, nil
+		}
+	}
+	if sharedSecret != "" {
+		return sharedSecret, nil
+	}
+	return "", fmt.Errorf("no control key for host %s", hostID)
+}
+
+// sign 计算签名：HMAC-SHA256(method \n uri \n timestamp \n nonce \n sha256(body))
+func sign(key, method, uri string, body []byte, now time.Time) (timestamp, nonce, signature string, err error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", "", fmt.Errorf("generate nonce: %w", err)
+	}
+	nonce = hex.EncodeToString(b)
+	timestamp = strconv.FormatInt(now.Unix(), 10)
+
+	bodySum := sha256.Sum256(body)
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(method + "\n" + uri + "\n" + timestamp + "\n" + nonce + "\n" + hex.EncodeToString(bodySum[:])))
+	return timestamp, nonce, hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// signRequest 为 HTTP 请求添加签名头
+func signRequest(req *http.Request, key string, body []byte, now time.Time) error {
+	timestamp, nonce, signature, err := sign(key, req.Method, req.URL.RequestURI(), body, now)
+	if err != nil {
+		return err
+	}
+	req.Header.Set(headerTimestamp, timestamp)
+	req.Header.Set(headerNonce, nonce)
+	req.Header.Set(headerSignature, signature)
+	return nil
+}
+
+// grpcSignMethod gRPC 请求签名使用的 method，uri 为完整方法名，body 为请求消息的确定性序列化结果
+const grpcSignMethod = "GRPC"
+
+// signGRPC 将签名写入 gRPC metadata（键名小写）
+func signGRPC(ctx context.Context, key, fullMethod string, msg proto.Message, now time.Time) (context.Context, error) {
+	body, err := proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+	timestamp, nonce, signature, err := sign(key, grpcSignMethod, fullMethod, body, now)
+	if err != nil {
+		return nil, err
+	}
+	return metadata.AppendToOutgoingContext(ctx,
+		strings.ToLower(headerTimestamp), timestamp,
+		strings.ToLower(headerNonce), nonce,
+		strings.ToLower(headerSignature), signature,
+	), nil
+}
@@ -1,9 +1,6 @@
 package agent
 
-import (
-	"strings"
-	"time"
-)
+import "time"
 
 // Request 通用请求结构
 type Request struct {
@@ -39,8 +36,17 @@ type ResetSSHRequest struct {
 // SyncSSHKeysRequest 同步SSH密钥请求（全量覆盖）
 type SyncSSHKeysRequest struct {
 	HostID     string   `json:"host_id"`
-	PublicKeys []string `json:"public_keys"` // 客户的所有公钥列表
-	Username   string   `json:"username,omitempty"`
+	PublicKeys []string `json:"public_keys"` // 整机分配客户的公钥，写入共享账号
+	// Tenants GPU 粒度分配的客户，每个客户使用独立的系统账号；不在列表中的受管账号会被删除（结束进程并删除数据）
+	Tenants  []TenantAccount `json:"tenants"`
+	Username string          `json:"username,omitempty"`
+}
+
+// TenantAccount GPU 粒度分配客户的系统账号
+type TenantAccount struct {
+	Username   string   `json:"username"`
+	PublicKeys []string `json:"public_keys"`
+	GPUIndexes []int    `json:"gpu_indexes"` // 登录会话默认的 CUDA_VISIBLE_DEVICES，不是访问控制
 }
 
 // CleanupRequest 清理机器请求
@@ -53,11 +59,19 @@ type CleanupRequest struct {
 type MountDatasetRequest struct {
 	HostID     string `json:"host_id"`
 	DatasetID  uint   `json:"dataset_id"`
+	SourceType string `json:"source_type"` // nfs, s3, bind
 	SourcePath string `json:"source_path"`
 	MountPoint string `json:"mount_point"`
 	ReadOnly   bool   `json:"read_only"`
 }
 
+// UnmountDatasetRequest 卸载数据集请求
+type UnmountDatasetRequest struct {
+	HostID     string `json:"host_id"`
+	DatasetID  uint   `json:"dataset_id"`
+	MountPoint string `json:"mount_point"`
+}
+
 // SystemInfo 系统信息
 type SystemInfo struct {
 	Hostname    string    `json:"hostname"`
@@ -97,7 +111,61 @@ type ExecuteCommandResponse struct {
 	Stderr   string `json:"stderr"`
 }
 
-// buildAuthorizedKeysContent 将公钥列表拼接为 authorized_keys 文件内容
-func buildAuthorizedKeysContent(publicKeys []string) string {
-	return strings.Join(publicKeys, "\n")
+// ContainerPort 容器端口绑定
+type ContainerPort struct {
+	ContainerPort int    `json:"container_port"`
+	HostPort      int    `json:"host_port"` // 0 表示由宿主机随机分配
+	Protocol      string `json:"protocol,omitempty"`
+}
+
+// CreateContainerRequest 创建容器请求
+type CreateContainerRequest struct {
+	HostID   string            `json:"host_id"`
+	Name     string            `json:"name"`
+	Image    string            `json:"image"`
+	Command  []string          `json:"command,omitempty"`
+	Env      map[string]string `json:"env,omitempty"`
+	CPU      int               `json:"cpu"`
+	MemoryMB int64             `json:"memory_mb"`
+	GPU      int               `json:"gpu"`
+	// GPUDevices 限定容器可见的 GPU 序号（GPU 粒度分配），非空时忽略 GPU
+	GPUDevices []int             `json:"gpu_devices,omitempty"`
+	Ports      []ContainerPort   `json:"ports,omitempty"`
+	Labels     map[string]string `json:"labels,omitempty"`
+}
+
+// ContainerInfo 创建容器结果
+type ContainerInfo struct {
+	ContainerID string          `json:"container_id"`
+	Ports       []ContainerPort `json:"ports"`
+}
+
+// ContainerRequest 容器操作请求（启动、停止、删除）
+type ContainerRequest struct {
+	HostID      string `json:"host_id"`
+	ContainerID string `json:"container_id"`
+	Timeout     int    `json:"timeout,omitempty"`
+}
+
+// CommandOutput 流式执行命令的输出片段
+type CommandOutput struct {
+	Stream string // stdout 或 stderr
+	Data   []byte
+}
+
+// TaskLogsRequest 任务日志流请求
+type TaskLogsRequest struct {
+	HostID string `json:"host_id"`
+	TaskID string `json:"task_id"`
+	Stream string `json:"stream"` // stdout 或 stderr
+	Offset int64  `json:"offset"`
+	Follow bool   `json:"follow"` // 持续推送直到任务结束
+}
+
+// TaskLogChunk 任务日志片段，Offset 为 Data 在输出中的起始字节位置
+type TaskLogChunk struct {
+	Stream string
+	Offset int64
+	Data   []byte
+	EOF    bool
 }
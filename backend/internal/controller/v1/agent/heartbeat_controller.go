@@ -1,22 +1,32 @@
 package agent
 
 import (
+	"fmt"
+
 	v1 "github.com/YoungBoyGod/remotegpu/api/v1"
 	"github.com/YoungBoyGod/remotegpu/internal/controller/v1/common"
+	serviceCredential "github.com/YoungBoyGod/remotegpu/internal/service/credential"
 	serviceMachine "github.com/YoungBoyGod/remotegpu/internal/service/machine"
+	"github.com/YoungBoyGod/remotegpu/pkg/logger"
 	"github.com/gin-gonic/gin"
 )
 
 // HeartbeatController Agent 心跳与注册控制器
 type HeartbeatController struct {
 	common.BaseController
-	machineSvc *serviceMachine.MachineService
+	machineSvc    *serviceMachine.MachineService
+	credentialSvc *serviceCredential.AgentCredentialService
 }
 
 func NewHeartbeatController(machineSvc *serviceMachine.MachineService) *HeartbeatController {
 	return &HeartbeatController{machineSvc: machineSvc}
 }
 
+// SetCredentialService 设置 Agent 凭证服务，用于注册时兑换机器密钥
+func (c *HeartbeatController) SetCredentialService(svc *serviceCredential.AgentCredentialService) {
+	c.credentialSvc = svc
+}
+
 // Heartbeat 处理 Agent 心跳上报（支持携带监控指标）
 // @Summary Agent 心跳上报
 // @Description Agent 定期上报心跳，可携带 CPU/内存/GPU 等监控指标
@@ -69,7 +79,7 @@ func (c *HeartbeatController) Heartbeat(ctx *gin.Context) {
 
 // Register 处理 Agent 注册
 // @Summary Agent 注册
-// @Description Agent 首次启动时向平台注册，上报机器信息
+// @Description Agent 首次启动时向平台注册，上报机器信息；使用一次性注册令牌认证时返回机器专属密钥 agent_secret
 // @Tags Agent - Heartbeat
 // @Accept json
 // @Produce json
@@ -86,6 +96,21 @@ func (c *HeartbeatController) Register(ctx *gin.Context) {
 		return
 	}
 
+	// 先兑换注册令牌，令牌无效或已被使用时不修改机器信息
+	result := gin.H{"status": "registered", "machine_id": req.MachineID}
+	enrolled := false
+	if v, ok := ctx.Get("agentIdentity"); ok {
+		if identity, ok := v.(*serviceCredential.AgentIdentity); ok && identity.Enrollment && c.credentialSvc != nil {
+			secret, err := c.credentialSvc.Enroll(ctx, identity, req.AgentID)
+			if err != nil {
+				c.Error(ctx, 401, err.Error())
+				return
+			}
+			result["agent_secret"] = secret
+			enrolled = true
+		}
+	}
+
 	info := &serviceMachine.AgentRegistration{
 		AgentID:    req.AgentID,
 		MachineID:  req.MachineID,
@@ -97,8 +122,13 @@ func (c *HeartbeatController) Register(ctx *gin.Context) {
 	}
 
 	if err := c.machineSvc.RegisterAgent(ctx, info); err != nil {
-		c.Error(ctx, 500, err.Error())
-		return
+		if !enrolled {
+			c.Error(ctx, 500, err.Error())
+			return
+		}
+		// 注册令牌已兑换，仍需返回机器密钥；Agent 下次启动使用密钥注册时会重新上报机器信息
+		logger.GetLogger().Warn(fmt.Sprintf("Agent %s 注册令牌已兑换，但更新机器 %s 信息失败: %v", req.AgentID, req.MachineID, err))
 	}
-	c.Success(ctx, gin.H{"status": "registered", "machine_id": req.MachineID})
+
+	c.Success(ctx, result)
 }
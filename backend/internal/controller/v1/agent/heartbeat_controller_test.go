@@ -2,6 +2,10 @@ package agent
 
 import (
 	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -11,6 +15,7 @@ import (
 	"github.com/YoungBoyGod/remotegpu/config"
 	"github.com/YoungBoyGod/remotegpu/internal/middleware"
 	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+	serviceCredential "github.com/YoungBoyGod/remotegpu/internal/service/credential"
 	serviceMachine "github.com/YoungBoyGod/remotegpu/internal/service/machine"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -30,8 +35,9 @@ const testAgentToken = "test-agent-token-for-testing"
 
 // agentTestEnv Agent 通信测试环境
 type agentTestEnv struct {
-	db     *gorm.DB
-	router *gin.Engine
+	db            *gorm.DB
+	router        *gin.Engine
+	credentialSvc *serviceCredential.AgentCredentialService
 }
 
 // setupAgentTestEnv 初始化 Agent 通信测试环境
@@ -41,8 +47,9 @@ func setupAgentTestEnv(t *testing.T) *agentTestEnv {
 	// 设置全局配置，用于 AgentAuth 中间件验证 Token
 	config.GlobalConfig = &config.Config{
 		Agent: config.AgentConfig{
-			Enabled: true,
-			Token:   testAgentToken,
+			Enabled:          true,
+			Token:            testAgentToken,
+			AllowSharedToken: true,
 		},
 	}
 
@@ -103,23 +110,57 @@ func setupAgentTestEnv(t *testing.T) *agentTestEnv {
 	)`).Error
 	require.NoError(t, err)
 
+	err = db.Exec(`CREATE TABLE gpu_metrics (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		host_id VARCHAR(64) NOT NULL,
+		gpu_uuid VARCHAR(128) NOT NULL DEFAULT '',
+		gpu_index INTEGER NOT NULL DEFAULT 0,
+		utilization_percent REAL,
+		memory_used INTEGER,
+		memory_usage_percent REAL,
+		temperature REAL,
+		power_draw REAL,
+		collected_at DATETIME NOT NULL
+	)`).Error
+	require.NoError(t, err)
+
 	// 创建测试机器
 	err = db.Exec(`INSERT INTO hosts (id, name, ip_address, total_cpu, total_memory_gb, device_status)
 		VALUES ('host-001', '测试机器1', '192.168.1.100', 8, 32, 'offline')`).Error
 	require.NoError(t, err)
 
+	// 创建 agent_credentials 表
+	err = db.Exec(`CREATE TABLE agent_credentials (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		host_id VARCHAR(64) NOT NULL UNIQUE,
+		agent_id VARCHAR(64),
+		status VARCHAR(20) DEFAULT 'pending',
+		secret_hash VARCHAR(64),
+		enrollment_token_hash VARCHAR(64),
+		control_key TEXT,
+		enrollment_expires_at DATETIME,
+		secret_issued_at DATETIME,
+		revoked_at DATETIME,
+		last_used_at DATETIME,
+		created_at DATETIME,
+		updated_at DATETIME
+	)`).Error
+	require.NoError(t, err)
+
 	machineSvc := serviceMachine.NewMachineService(db)
+	credentialSvc := serviceCredential.NewAgentCredentialService(db)
 	controller := NewHeartbeatController(machineSvc)
+	controller.SetCredentialService(credentialSvc)
 
 	router := gin.New()
 	agentGroup := router.Group("/api/v1/agent")
-	agentGroup.Use(middleware.AgentAuth())
+	agentGroup.Use(middleware.AgentAuth(credentialSvc))
 	{
 		agentGroup.POST("/register", controller.Register)
 		agentGroup.POST("/heartbeat", controller.Heartbeat)
 	}
 
-	return &agentTestEnv{db: db, router: router}
+	return &agentTestEnv{db: db, router: router, credentialSvc: credentialSvc}
 }
 
 // ==================== Agent 认证测试 ====================
@@ -244,12 +285,17 @@ func TestHeartbeat_WithMetrics(t *testing.T) {
 
 	cpuUsage := 45.5
 	memUsage := 60.2
+	gpuUtil := 80.0
 	reqBody := apiV1.HeartbeatRequest{
 		AgentID:   "agent-001",
 		MachineID: "host-001",
 		Metrics: &apiV1.HeartbeatMetrics{
 			CPUUsagePercent:    &cpuUsage,
 			MemoryUsagePercent: &memUsage,
+			GPUMetrics: []apiV1.GPUMetric{
+				{Index: 0, UUID: "GPU-0001", UtilPercent: &gpuUtil},
+				{Index: 1, UUID: "GPU-0002"},
+			},
 		},
 	}
 	body, _ := json.Marshal(reqBody)
@@ -270,6 +316,10 @@ func TestHeartbeat_WithMetrics(t *testing.T) {
 	var count int64
 	env.db.Table("host_metrics").Where("host_id = ?", "host-001").Count(&count)
 	assert.Equal(t, int64(1), count)
+
+	// 验证 gpu_metrics 表中逐卡写入了 GPU 指标
+	env.db.Table("gpu_metrics").Where("host_id = ?", "host-001").Count(&count)
+	assert.Equal(t, int64(2), count)
 }
 
 func TestHeartbeat_MissingFields(t *testing.T) {
@@ -368,3 +418,194 @@ func TestRegister_MissingFields(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, 400, resp.Code)
 }
+
+// ==================== 机器级凭证测试 ====================
+
+// postAgent 以指定令牌发送 Agent 请求并返回业务响应码与数据
+func postAgent(t *testing.T, env *agentTestEnv, path, token string, payload interface{}) (int, json.RawMessage) {
+	body, _ := json.Marshal(payload)
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+
+	env.router.ServeHTTP(w, req)
+
+	var resp testResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return resp.Code, resp.Data
+}
+
+// enrollAgent 签发注册令牌并完成注册，返回机器密钥
+func enrollAgent(t *testing.T, env *agentTestEnv) string {
+	token, _, err := env.credentialSvc.IssueEnrollmentToken(context.Background(), "host-001")
+	require.NoError(t, err)
+
+	code, data := postAgent(t, env, "/api/v1/agent/register", token, apiV1.RegisterRequest{
+		AgentID: "agent-001", MachineID: "host-001",
+	})
+	require.Equal(t, 0, code)
+
+	var result struct {
+		AgentSecret string `json:"agent_secret"`
+	}
+	require.NoError(t, json.Unmarshal(data, &result))
+	require.NotEmpty(t, result.AgentSecret)
+
+	// 注册令牌只能兑换一次
+	code, _ = postAgent(t, env, "/api/v1/agent/register", token, apiV1.RegisterRequest{
+		AgentID: "agent-001", MachineID: "host-001",
+	})
+	assert.Equal(t, http.StatusUnauthorized, code)
+	return result.AgentSecret
+}
+
+func TestRegister_FailedEnrollmentKeepsHostInfo(t *testing.T) {
+	env := setupAgentTestEnv(t)
+	ctx := context.Background()
+
+	token, _, err := env.credentialSvc.IssueEnrollmentToken(ctx, "host-001")
+	require.NoError(t, err)
+	identity, err := env.credentialSvc.Authenticate(ctx, token)
+	require.NoError(t, err)
+
+	// 两个请求同时通过认证，另一个请求先兑换了令牌
+	_, err = env.credentialSvc.Enroll(ctx, identity, "agent-001")
+	require.NoError(t, err)
+
+	controller := NewHeartbeatController(serviceMachine.NewMachineService(env.db))
+	controller.SetCredentialService(env.credentialSvc)
+	body, _ := json.Marshal(apiV1.RegisterRequest{
+		AgentID: "agent-001", MachineID: "host-001", Hostname: "attacker", IPAddress: "10.0.0.66",
+	})
+	w := httptest.NewRecorder()
+	ginCtx, _ := gin.CreateTestContext(w)
+	ginCtx.Request = httptest.NewRequest(http.MethodPost, "/api/v1/agent/register", bytes.NewBuffer(body))
+	ginCtx.Request.Header.Set("Content-Type", "application/json")
+	ginCtx.Set("agentIdentity", identity)
+
+	controller.Register(ginCtx)
+
+	var resp testResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+
+	var host entity.Host
+	require.NoError(t, env.db.First(&host, "id = ?", "host-001").Error)
+	assert.Empty(t, host.Hostname)
+	assert.Equal(t, "192.168.1.100", host.IPAddress)
+	assert.Equal(t, "offline", host.DeviceStatus)
+}
+
+func TestAgentCredential_EnrollAndUseSecret(t *testing.T) {
+	env := setupAgentTestEnv(t)
+	config.GlobalConfig.Agent.AllowSharedToken = false
+
+	secret := enrollAgent(t, env)
+
+	code, _ := postAgent(t, env, "/api/v1/agent/heartbeat", secret, apiV1.HeartbeatRequest{
+		AgentID: "agent-001", MachineID: "host-001",
+	})
+	assert.Equal(t, 0, code)
+
+	// 关闭共享 Token 兼容后，共享 Token 不再可用
+	code, _ = postAgent(t, env, "/api/v1/agent/heartbeat", testAgentToken, apiV1.HeartbeatRequest{
+		AgentID: "agent-001", MachineID: "host-001",
+	})
+	assert.Equal(t, http.StatusUnauthorized, code)
+}
+
+func TestAgentCredential_EnrollmentTokenOnlyForRegister(t *testing.T) {
+	env := setupAgentTestEnv(t)
+
+	token, _, err := env.credentialSvc.IssueEnrollmentToken(context.Background(), "host-001")
+	require.NoError(t, err)
+
+	code, _ := postAgent(t, env, "/api/v1/agent/heartbeat", token, apiV1.HeartbeatRequest{
+		AgentID: "agent-001", MachineID: "host-001",
+	})
+	assert.Equal(t, http.StatusUnauthorized, code)
+}
+
+func TestAgentCredential_IdentityBinding(t *testing.T) {
+	env := setupAgentTestEnv(t)
+	require.NoError(t, env.db.Exec(`INSERT INTO hosts (id, name, ip_address, total_cpu, total_memory_gb)
+		VALUES ('host-002', '测试机器2', '192.168.1.101', 8, 32)`).Error)
+
+	secret := enrollAgent(t, env)
+
+	// 冒充其他机器
+	code, _ := postAgent(t, env, "/api/v1/agent/heartbeat", secret, apiV1.HeartbeatRequest{
+		AgentID: "agent-001", MachineID: "host-002",
+	})
+	assert.Equal(t, http.StatusForbidden, code)
+
+	// 冒充其他 Agent
+	code, _ = postAgent(t, env, "/api/v1/agent/heartbeat", secret, apiV1.HeartbeatRequest{
+		AgentID: "agent-002", MachineID: "host-001",
+	})
+	assert.Equal(t, http.StatusForbidden, code)
+}
+
+func TestAgentCredential_RotateAndRevoke(t *testing.T) {
+	env := setupAgentTestEnv(t)
+	ctx := context.Background()
+	heartbeat := apiV1.HeartbeatRequest{AgentID: "agent-001", MachineID: "host-001"}
+
+	oldSecret := enrollAgent(t, env)
+	oldKey, err := env.credentialSvc.ControlKey(ctx, "host-001")
+	require.NoError(t, err)
+	newSecret := enrollAgent(t, env)
+	assert.NotEqual(t, oldSecret, newSecret)
+
+	// 兑换新令牌后旧密钥及其控制签名密钥立即失效
+	newKey, err := env.credentialSvc.ControlKey(ctx, "host-001")
+	require.NoError(t, err)
+	assert.NotEqual(t, oldKey, newKey)
+
+	code, _ := postAgent(t, env, "/api/v1/agent/heartbeat", oldSecret, heartbeat)
+	assert.Equal(t, http.StatusUnauthorized, code)
+	code, _ = postAgent(t, env, "/api/v1/agent/heartbeat", newSecret, heartbeat)
+	assert.Equal(t, 0, code)
+
+	require.NoError(t, env.credentialSvc.Revoke(ctx, "host-001"))
+	code, _ = postAgent(t, env, "/api/v1/agent/heartbeat", newSecret, heartbeat)
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	var cred entity.AgentCredential
+	require.NoError(t, env.db.First(&cred, "host_id = ?", "host-001").Error)
+	assert.Equal(t, "revoked", cred.Status)
+}
+
+func TestAgentCredential_ControlKey(t *testing.T) {
+	env := setupAgentTestEnv(t)
+	ctx := context.Background()
+
+	secret := enrollAgent(t, env)
+	key, err := env.credentialSvc.ControlKey(ctx, "host-001")
+	require.NoError(t, err)
+
+	// 与 Agent 端 security.SigningKeyFromSecret 的派生方式一致
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("remotegpu-agent-control"))
+	assert.Equal(t, hex.EncodeToString(mac.Sum(nil)), key)
+
+	// 数据库中既没有签名密钥明文，签名密钥也不是机器密钥摘要
+	var cred entity.AgentCredential
+	require.NoError(t, env.db.First(&cred, "host_id = ?", "host-001").Error)
+	assert.NotEqual(t, cred.SecretHash, key)
+	assert.NotEmpty(t, cred.ControlKey)
+	assert.NotContains(t, cred.ControlKey, key)
+
+	// 旧版本注册的凭证没有签名密钥
+	require.NoError(t, env.db.Model(&cred).Update("control_key", "").Error)
+	key, err = env.credentialSvc.ControlKey(ctx, "host-001")
+	require.NoError(t, err)
+	assert.Empty(t, key)
+
+	enrollAgent(t, env)
+	require.NoError(t, env.credentialSvc.Revoke(ctx, "host-001"))
+	key, err = env.credentialSvc.ControlKey(ctx, "host-001")
+	require.NoError(t, err)
+	assert.Empty(t, key)
+}
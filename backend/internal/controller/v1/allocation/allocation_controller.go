@@ -3,8 +3,10 @@ package allocation
 import (
 	"strconv"
 
+	apiV1 "github.com/YoungBoyGod/remotegpu/api/v1"
 	"github.com/YoungBoyGod/remotegpu/internal/controller/v1/common"
 	serviceAllocation "github.com/YoungBoyGod/remotegpu/internal/service/allocation"
+	"github.com/YoungBoyGod/remotegpu/pkg/errors"
 	"github.com/gin-gonic/gin"
 )
 
@@ -64,3 +66,101 @@ func (c *AllocationController) List(ctx *gin.Context) {
 		"page_size": pageSize,
 	})
 }
+
+// Renew 续期分配
+// @Summary 续期分配
+// @Description 将活跃分配的到期时间顺延指定月数，并记录续期记录和审计日志
+// @Tags Admin - Allocations
+// @Accept json
+// @Produce json
+// @Param id path string true "分配ID"
+// @Param request body v1.RenewAllocationRequest true "续期请求"
+// @Security Bearer
+// @Success 200 {object} entity.Allocation
+// @Failure 400 {object} common.ErrorResponse
+// @Failure 404 {object} common.ErrorResponse
+// @Failure 409 {object} common.ErrorResponse
+// @Router /admin/allocations/{id}/renew [post]
+func (c *AllocationController) Renew(ctx *gin.Context) {
+	var req apiV1.RenewAllocationRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		c.Error(ctx, 400, "Invalid request parameters")
+		return
+	}
+
+	alloc, err := c.allocationService.RenewAllocation(ctx, ctx.Param("id"), req.Months, req.Remark, serviceAllocation.RenewOperator{
+		ID:       ctx.GetUint("userID"),
+		Username: ctx.GetString("username"),
+		Role:     serviceAllocation.RenewRoleAdmin,
+		IP:       ctx.ClientIP(),
+	})
+	if err != nil {
+		c.renewError(ctx, err)
+		return
+	}
+	c.Success(ctx, alloc)
+}
+
+// Renewals 获取分配的续期记录
+// @Summary 获取分配的续期记录
+// @Tags Admin - Allocations
+// @Produce json
+// @Param id path string true "分配ID"
+// @Security Bearer
+// @Success 200 {array} entity.AllocationRenewal
+// @Failure 404 {object} common.ErrorResponse
+// @Router /admin/allocations/{id}/renewals [get]
+func (c *AllocationController) Renewals(ctx *gin.Context) {
+	renewals, err := c.allocationService.ListRenewals(ctx, ctx.Param("id"))
+	if err != nil {
+		c.renewError(ctx, err)
+		return
+	}
+	c.Success(ctx, renewals)
+}
+
+// Reclaim 回收单个分配
+// @Summary 回收单个分配
+// @Description 结束指定分配，GPU 粒度分配只释放该分配绑定的 GPU，机器上的其他分配不受影响
+// @Tags Admin - Allocations
+// @Produce json
+// @Param id path string true "分配ID"
+// @Security Bearer
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} common.ErrorResponse
+// @Failure 409 {object} common.ErrorResponse
+// @Router /admin/allocations/{id}/reclaim [post]
+func (c *AllocationController) Reclaim(ctx *gin.Context) {
+	if err := c.allocationService.ReclaimAllocation(ctx, ctx.Param("id")); err != nil {
+		if appErr := errors.GetAppError(err); appErr != nil {
+			switch appErr.Code {
+			case errors.ErrorAllocationNotFound:
+				c.Error(ctx, 404, appErr.Message)
+				return
+			case errors.ErrorMachineHasRunningTasks:
+				c.Error(ctx, 409, appErr.Message)
+				return
+			}
+		}
+		c.Error(ctx, 500, "Failed to reclaim allocation")
+		return
+	}
+	c.Success(ctx, gin.H{"message": "Reclaim process started"})
+}
+
+func (c *AllocationController) renewError(ctx *gin.Context, err error) {
+	if appErr := errors.GetAppError(err); appErr != nil {
+		switch appErr.Code {
+		case errors.ErrorInvalidParams:
+			c.Error(ctx, 400, appErr.Message)
+			return
+		case errors.ErrorAllocationNotFound:
+			c.Error(ctx, 404, appErr.Message)
+			return
+		case errors.ErrorMachineNotAvailable:
+			c.Error(ctx, 409, appErr.Message)
+			return
+		}
+	}
+	c.Error(ctx, 500, "Failed to renew allocation")
+}
@@ -23,7 +23,7 @@ func NewAuthController(authService *serviceAuth.AuthService) *AuthController {
 
 // Login 用户登录
 // @Summary 用户登录
-// @Description 使用用户名和密码获取访问令牌
+// @Description 使用用户名和密码获取访问令牌；启用两步验证时返回 mfa_token，需调用 /auth/mfa/verify 完成登录
 // @Tags Auth
 // @Accept json
 // @Produce json
@@ -39,7 +39,7 @@ func (c *AuthController) Login(ctx *gin.Context) {
 		return
 	}
 
-	accessToken, refreshToken, expiresIn, mustChangePassword, err := c.authService.Login(ctx, req.Username, req.Password)
+	result, err := c.authService.Login(ctx, req.Username, req.Password, ctx.ClientIP())
 	if err != nil {
 		if appErr := errors.GetAppError(err); appErr != nil {
 			c.Error(ctx, appErr.Code, appErr.Message)
@@ -49,12 +49,7 @@ func (c *AuthController) Login(ctx *gin.Context) {
 		return
 	}
 
-	c.Success(ctx, apiV1.LoginResponse{
-		AccessToken:        accessToken,
-		RefreshToken:       refreshToken,
-		ExpiresIn:          expiresIn,
-		MustChangePassword: mustChangePassword,
-	})
+	c.Success(ctx, loginResponse(result))
 }
 
 // Refresh 刷新令牌
@@ -152,7 +147,7 @@ func (c *AuthController) AdminLogin(ctx *gin.Context) {
 		return
 	}
 
-	accessToken, refreshToken, expiresIn, mustChangePassword, err := c.authService.AdminLogin(ctx, req.Username, req.Password)
+	result, err := c.authService.AdminLogin(ctx, req.Username, req.Password, ctx.ClientIP())
 	if err != nil {
 		if appErr := errors.GetAppError(err); appErr != nil {
 			c.Error(ctx, appErr.Code, appErr.Message)
@@ -162,12 +157,7 @@ func (c *AuthController) AdminLogin(ctx *gin.Context) {
 		return
 	}
 
-	c.Success(ctx, apiV1.LoginResponse{
-		AccessToken:        accessToken,
-		RefreshToken:       refreshToken,
-		ExpiresIn:          expiresIn,
-		MustChangePassword: mustChangePassword,
-	})
+	c.Success(ctx, loginResponse(result))
 }
 
 // UpdateProfile 更新个人资料
@@ -265,7 +255,7 @@ func (c *AuthController) ChangePassword(ctx *gin.Context) {
 
 // RequestPasswordReset 请求密码重置
 // @Summary 请求密码重置
-// @Description 通过邮箱发送密码重置链接
+// @Description 通过邮箱发送密码重置链接，无论账号是否存在都返回相同的结果
 // @Tags Auth
 // @Accept json
 // @Produce json
@@ -280,8 +270,7 @@ func (c *AuthController) RequestPasswordReset(ctx *gin.Context) {
 		return
 	}
 
-	token, err := c.authService.RequestPasswordReset(ctx, req.Username)
-	if err != nil {
+	if err := c.authService.RequestPasswordReset(ctx, req.Username); err != nil {
 		if appErr := errors.GetAppError(err); appErr != nil {
 			c.Error(ctx, appErr.Code, appErr.Message)
 			return
@@ -290,7 +279,7 @@ func (c *AuthController) RequestPasswordReset(ctx *gin.Context) {
 		return
 	}
 
-	c.Success(ctx, gin.H{"reset_token": token})
+	c.Success(ctx, gin.H{"message": "If the account exists, a password reset link has been sent to its email"})
 }
 
 // ConfirmPasswordReset 确认密码重置
@@ -321,3 +310,54 @@ func (c *AuthController) ConfirmPasswordReset(ctx *gin.Context) {
 
 	c.Success(ctx, gin.H{"message": "Password reset successfully"})
 }
+
+// RequestEmailVerification 发送邮箱验证邮件
+// @Summary 发送邮箱验证邮件
+// @Description 向当前用户的邮箱发送验证链接
+// @Tags Auth
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} common.SuccessResponse
+// @Failure 400 {object} common.ErrorResponse
+// @Router /auth/email/verify [post]
+func (c *AuthController) RequestEmailVerification(ctx *gin.Context) {
+	if err := c.authService.RequestEmailVerification(ctx, ctx.GetUint("userID")); err != nil {
+		if appErr := errors.GetAppError(err); appErr != nil {
+			c.Error(ctx, appErr.Code, appErr.Message)
+			return
+		}
+		c.Error(ctx, 500, "Failed to send verification email")
+		return
+	}
+
+	c.Success(ctx, gin.H{"message": "Verification email sent"})
+}
+
+// ConfirmEmailVerification 确认邮箱验证
+// @Summary 确认邮箱验证
+// @Description 使用邮件中的验证令牌完成邮箱验证
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body v1.ConfirmEmailVerificationRequest true "邮箱验证请求"
+// @Success 200 {object} common.SuccessResponse
+// @Failure 400 {object} common.ErrorResponse
+// @Router /auth/email/verify/confirm [post]
+func (c *AuthController) ConfirmEmailVerification(ctx *gin.Context) {
+	var req apiV1.ConfirmEmailVerificationRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		c.Error(ctx, 400, "Invalid request parameters")
+		return
+	}
+
+	if err := c.authService.ConfirmEmailVerification(ctx, req.Token); err != nil {
+		if appErr := errors.GetAppError(err); appErr != nil {
+			c.Error(ctx, appErr.Code, appErr.Message)
+			return
+		}
+		c.Error(ctx, 500, "Failed to verify email")
+		return
+	}
+
+	c.Success(ctx, gin.H{"message": "Email verified successfully"})
+}
@@ -70,6 +70,19 @@ func setupHTTPTestEnv(t *testing.T) *httpTestEnv {
 	)`).Error
 	require.NoError(t, err)
 
+	err = db.Exec(`CREATE TABLE system_configs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		config_key TEXT NOT NULL UNIQUE,
+		config_value TEXT NOT NULL,
+		config_type TEXT NOT NULL DEFAULT 'string',
+		config_group TEXT NOT NULL DEFAULT 'general',
+		description TEXT,
+		is_public INTEGER DEFAULT 0,
+		created_at DATETIME,
+		updated_at DATETIME
+	)`).Error
+	require.NoError(t, err)
+
 	// 创建测试用户
 	password, _ := pkgAuth.HashPassword("Test123456")
 	testUser := &entity.Customer{
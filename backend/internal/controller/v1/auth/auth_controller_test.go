@@ -73,7 +73,27 @@ func setupTestEnv(t *testing.T) *testEnv {
 		currency TEXT DEFAULT 'CNY',
 		credit_limit REAL DEFAULT 0,
 		billing_plan_id INTEGER,
-		last_login_at DATETIME
+		last_login_at DATETIME,
+		failed_login_attempts INTEGER DEFAULT 0,
+		lockout_count INTEGER DEFAULT 0,
+		locked_until DATETIME,
+		mfa_enabled INTEGER DEFAULT 0,
+		mfa_secret TEXT DEFAULT '',
+		mfa_last_step INTEGER DEFAULT 0,
+		mfa_enabled_at DATETIME
+	)`).Error
+	require.NoError(t, err)
+
+	err = db.Exec(`CREATE TABLE system_configs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		config_key TEXT NOT NULL UNIQUE,
+		config_value TEXT NOT NULL,
+		config_type TEXT NOT NULL DEFAULT 'string',
+		config_group TEXT NOT NULL DEFAULT 'general',
+		description TEXT,
+		is_public INTEGER DEFAULT 0,
+		created_at DATETIME,
+		updated_at DATETIME
 	)`).Error
 	require.NoError(t, err)
 
@@ -0,0 +1,158 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/YoungBoyGod/remotegpu/config"
+	"github.com/YoungBoyGod/remotegpu/internal/middleware"
+	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+	serviceAudit "github.com/YoungBoyGod/remotegpu/internal/service/audit"
+	serviceAuth "github.com/YoungBoyGod/remotegpu/internal/service/auth"
+	serviceCustomer "github.com/YoungBoyGod/remotegpu/internal/service/customer"
+	"github.com/YoungBoyGod/remotegpu/pkg/cache"
+	"github.com/YoungBoyGod/remotegpu/pkg/errors"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupLockoutTestEnv(t *testing.T) *testEnv {
+	env := setupTestEnv(t)
+	require.NoError(t, env.db.Exec(`CREATE TABLE audit_logs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		customer_id INTEGER,
+		username TEXT,
+		ip_address TEXT,
+		method VARCHAR(10),
+		path VARCHAR(512),
+		action TEXT NOT NULL,
+		resource_type TEXT,
+		resource_id TEXT,
+		detail TEXT,
+		status_code INTEGER,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`).Error)
+
+	authService := serviceAuth.NewAuthService(env.db, nil)
+	authService.SetLoginLockout(config.LoginLockoutConfig{Enabled: true, MaxAttempts: 3, LockMinutes: 10, MaxLockMinutes: 30})
+	authService.SetAuditService(serviceAudit.NewAuditService(env.db))
+	controller := NewAuthController(authService)
+
+	router := gin.New()
+	router.POST("/api/v1/auth/login", controller.Login)
+	env.router = router
+	return env
+}
+
+func login(env *testEnv, password string) testResponse {
+	return postJSON(env, "/api/v1/auth/login", map[string]string{"username": "testuser", "password": password}, "")
+}
+
+func loadTestUser(t *testing.T, env *testEnv) entity.Customer {
+	var customer entity.Customer
+	require.NoError(t, env.db.Where("username = ?", "testuser").First(&customer).Error)
+	return customer
+}
+
+func TestLogin_LockoutAfterRepeatedFailures(t *testing.T) {
+	env := setupLockoutTestEnv(t)
+
+	for i := 0; i < 2; i++ {
+		assert.Equal(t, errors.ErrorPasswordIncorrect, login(env, "wrong").Code)
+	}
+	assert.Equal(t, 2, loadTestUser(t, env).FailedLoginAttempts)
+
+	// 第 3 次失败触发锁定
+	assert.Equal(t, errors.ErrorPasswordIncorrect, login(env, "wrong").Code)
+	customer := loadTestUser(t, env)
+	require.NotNil(t, customer.LockedUntil)
+	assert.WithinDuration(t, time.Now().Add(10*time.Minute), *customer.LockedUntil, time.Minute)
+	assert.Equal(t, 1, customer.LockoutCount)
+	assert.Equal(t, 0, customer.FailedLoginAttempts)
+
+	var auditCount int64
+	env.db.Table("audit_logs").Where("action = ? AND customer_id = ?", "account_locked", customer.ID).Count(&auditCount)
+	assert.Equal(t, int64(1), auditCount)
+
+	// 锁定期间正确密码也无法登录
+	assert.Equal(t, errors.ErrorAccountLocked, login(env, "Test123456").Code)
+
+	// 锁定到期后再次连续失败，锁定时长翻倍
+	require.NoError(t, env.db.Model(&customer).Update("locked_until", time.Now().Add(-time.Second)).Error)
+	for i := 0; i < 3; i++ {
+		login(env, "wrong")
+	}
+	customer = loadTestUser(t, env)
+	require.NotNil(t, customer.LockedUntil)
+	assert.WithinDuration(t, time.Now().Add(20*time.Minute), *customer.LockedUntil, time.Minute)
+	assert.Equal(t, 2, customer.LockoutCount)
+
+	// 翻倍后不超过上限
+	require.NoError(t, env.db.Model(&customer).Update("locked_until", time.Now().Add(-time.Second)).Error)
+	for i := 0; i < 3; i++ {
+		login(env, "wrong")
+	}
+	customer = loadTestUser(t, env)
+	assert.WithinDuration(t, time.Now().Add(30*time.Minute), *customer.LockedUntil, time.Minute)
+}
+
+func TestLogin_SuccessResetsFailures(t *testing.T) {
+	env := setupLockoutTestEnv(t)
+
+	login(env, "wrong")
+	login(env, "wrong")
+	assert.Equal(t, 0, login(env, "Test123456").Code)
+
+	customer := loadTestUser(t, env)
+	assert.Equal(t, 0, customer.FailedLoginAttempts)
+	assert.Nil(t, customer.LockedUntil)
+}
+
+func TestUnlockCustomer(t *testing.T) {
+	env := setupLockoutTestEnv(t)
+	for i := 0; i < 3; i++ {
+		login(env, "wrong")
+	}
+	require.Equal(t, errors.ErrorAccountLocked, login(env, "Test123456").Code)
+
+	customer := loadTestUser(t, env)
+	require.NoError(t, serviceCustomer.NewCustomerService(env.db).Unlock(t.Context(), customer.ID))
+
+	customer = loadTestUser(t, env)
+	assert.Nil(t, customer.LockedUntil)
+	assert.Equal(t, 0, customer.LockoutCount)
+	assert.Equal(t, 0, login(env, "Test123456").Code)
+}
+
+func TestRateLimit_LoginPerAccount(t *testing.T) {
+	env := setupTestEnv(t)
+	store := cache.NewMemoryCache()
+	controller := NewAuthController(serviceAuth.NewAuthService(env.db, nil))
+
+	router := gin.New()
+	router.POST("/api/v1/auth/login",
+		middleware.RateLimit(store, "login:account", middleware.RateLimitRule{Limit: 2, Window: time.Minute}, middleware.JSONFieldKey("username")),
+		controller.Login)
+	env.router = router
+
+	assert.Equal(t, 0, login(env, "Test123456").Code)
+	assert.Equal(t, errors.ErrorPasswordIncorrect, login(env, "wrong").Code)
+	assert.Equal(t, http.StatusTooManyRequests, login(env, "Test123456").Code)
+
+	// 其他账号不受影响，用户名不区分大小写
+	resp := postJSON(env, "/api/v1/auth/login", map[string]string{"username": "admin", "password": "luoyang@123"}, "")
+	assert.Equal(t, 0, resp.Code)
+	resp = postJSON(env, "/api/v1/auth/login", map[string]string{"username": "TestUser", "password": "Test123456"}, "")
+	assert.Equal(t, http.StatusTooManyRequests, resp.Code)
+
+	// 超限响应带 Retry-After
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", strings.NewReader(`{"username":"testuser","password":"x"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+}
@@ -0,0 +1,171 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+	serviceAuth "github.com/YoungBoyGod/remotegpu/internal/service/auth"
+	pkgAuth "github.com/YoungBoyGod/remotegpu/pkg/auth"
+	"github.com/YoungBoyGod/remotegpu/pkg/cache"
+	"github.com/YoungBoyGod/remotegpu/pkg/mail"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// chanMailer 将发送的邮件写入 channel，便于等待异步发送
+type chanMailer struct {
+	sent chan *mail.Message
+}
+
+func (m *chanMailer) Send(_ context.Context, msg *mail.Message) error {
+	m.sent <- msg
+	return nil
+}
+
+var tokenPattern = regexp.MustCompile(`token=(\S+)`)
+
+func setupMailTestEnv(t *testing.T) (*testEnv, *chanMailer) {
+	env := setupTestEnv(t)
+	mailer := &chanMailer{sent: make(chan *mail.Message, 4)}
+
+	authService := serviceAuth.NewAuthService(env.db, cache.NewMemoryCache())
+	authService.SetMailer(mailer, "https://gpu.example.com/")
+	controller := NewAuthController(authService)
+
+	router := gin.New()
+	authGroup := router.Group("/api/v1/auth")
+	{
+		authGroup.POST("/password/request", controller.RequestPasswordReset)
+		authGroup.POST("/password/confirm", controller.ConfirmPasswordReset)
+		authGroup.POST("/email/verify", testAuthMiddleware(), controller.RequestEmailVerification)
+		authGroup.POST("/email/verify/confirm", controller.ConfirmEmailVerification)
+	}
+	env.router = router
+	return env, mailer
+}
+
+func postJSON(env *testEnv, path string, body interface{}, token string) testResponse {
+	data, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewBuffer(data))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	env.router.ServeHTTP(w, req)
+
+	var resp testResponse
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	return resp
+}
+
+func waitMail(t *testing.T, mailer *chanMailer) *mail.Message {
+	select {
+	case msg := <-mailer.sent:
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatal("未收到邮件")
+		return nil
+	}
+}
+
+// extractToken 从邮件正文的链接中取出 token
+func extractToken(t *testing.T, msg *mail.Message) string {
+	m := tokenPattern.FindStringSubmatch(msg.Body)
+	require.Len(t, m, 2)
+	token, err := url.QueryUnescape(m[1])
+	require.NoError(t, err)
+	return token
+}
+
+func TestRequestPasswordReset_DeliversByEmail(t *testing.T) {
+	env, mailer := setupMailTestEnv(t)
+
+	resp := postJSON(env, "/api/v1/auth/password/request", map[string]string{"username": "testuser"}, "")
+	assert.Equal(t, 0, resp.Code)
+	assert.NotContains(t, string(resp.Data), "reset_token")
+
+	msg := waitMail(t, mailer)
+	assert.Equal(t, []string{"test@example.com"}, msg.To)
+	assert.Contains(t, msg.Body, "https://gpu.example.com/forgot-password?token=")
+	token := extractToken(t, msg)
+
+	resp = postJSON(env, "/api/v1/auth/password/confirm", map[string]string{"token": token, "new_password": "NewPass123"}, "")
+	assert.Equal(t, 0, resp.Code)
+
+	var customer entity.Customer
+	require.NoError(t, env.db.Where("username = ?", "testuser").First(&customer).Error)
+	assert.True(t, pkgAuth.CheckPasswordHash("NewPass123", customer.PasswordHash))
+
+	// token 只能使用一次
+	resp = postJSON(env, "/api/v1/auth/password/confirm", map[string]string{"token": token, "new_password": "Other123"}, "")
+	assert.NotEqual(t, 0, resp.Code)
+}
+
+func TestRequestPasswordReset_UnknownUserLooksTheSame(t *testing.T) {
+	env, mailer := setupMailTestEnv(t)
+
+	known := postJSON(env, "/api/v1/auth/password/request", map[string]string{"username": "testuser"}, "")
+	waitMail(t, mailer)
+	unknown := postJSON(env, "/api/v1/auth/password/request", map[string]string{"username": "nobody"}, "")
+
+	assert.Equal(t, known.Code, unknown.Code)
+	assert.JSONEq(t, string(known.Data), string(unknown.Data))
+	select {
+	case <-mailer.sent:
+		t.Fatal("不存在的账号不应发送邮件")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestEmailVerification(t *testing.T) {
+	env, mailer := setupMailTestEnv(t)
+
+	var customer entity.Customer
+	require.NoError(t, env.db.Where("username = ?", "testuser").First(&customer).Error)
+	accessToken, err := pkgAuth.GenerateToken(customer.ID, customer.Username, customer.Role)
+	require.NoError(t, err)
+
+	resp := postJSON(env, "/api/v1/auth/email/verify", nil, accessToken)
+	require.Equal(t, 0, resp.Code)
+	msg := waitMail(t, mailer)
+	assert.Contains(t, msg.Body, "https://gpu.example.com/verify-email?token=")
+	token := extractToken(t, msg)
+
+	resp = postJSON(env, "/api/v1/auth/email/verify/confirm", map[string]string{"token": token}, "")
+	assert.Equal(t, 0, resp.Code)
+	require.NoError(t, env.db.First(&customer, customer.ID).Error)
+	assert.True(t, customer.EmailVerified)
+
+	// 已验证后不再发送
+	resp = postJSON(env, "/api/v1/auth/email/verify", nil, accessToken)
+	assert.Equal(t, 400, resp.Code)
+}
+
+func TestEmailVerification_EmailChanged(t *testing.T) {
+	env, mailer := setupMailTestEnv(t)
+
+	var customer entity.Customer
+	require.NoError(t, env.db.Where("username = ?", "testuser").First(&customer).Error)
+	accessToken, err := pkgAuth.GenerateToken(customer.ID, customer.Username, customer.Role)
+	require.NoError(t, err)
+
+	require.Equal(t, 0, postJSON(env, "/api/v1/auth/email/verify", nil, accessToken).Code)
+	token := extractToken(t, waitMail(t, mailer))
+
+	// 验证前修改邮箱，旧 token 失效
+	require.NoError(t, env.db.Model(&customer).Update("email", "new@example.com").Error)
+	resp := postJSON(env, "/api/v1/auth/email/verify/confirm", map[string]string{"token": token}, "")
+	assert.NotEqual(t, 0, resp.Code)
+	require.NoError(t, env.db.First(&customer, customer.ID).Error)
+	assert.False(t, customer.EmailVerified)
+}
@@ -0,0 +1,272 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/url"
+	"testing"
+	"time"
+
+	apiV1 "github.com/YoungBoyGod/remotegpu/api/v1"
+	"github.com/YoungBoyGod/remotegpu/config"
+	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+	serviceAuth "github.com/YoungBoyGod/remotegpu/internal/service/auth"
+	serviceCustomer "github.com/YoungBoyGod/remotegpu/internal/service/customer"
+	pkgAuth "github.com/YoungBoyGod/remotegpu/pkg/auth"
+	"github.com/YoungBoyGod/remotegpu/pkg/cache"
+	"github.com/YoungBoyGod/remotegpu/pkg/errors"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupMFATestEnv(t *testing.T, opts ...func(*serviceAuth.AuthService)) *testEnv {
+	env := setupTestEnv(t)
+	require.NoError(t, env.db.Exec(`CREATE TABLE mfa_recovery_codes (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		customer_id INTEGER NOT NULL,
+		code_hash TEXT NOT NULL UNIQUE,
+		used_at DATETIME,
+		created_at DATETIME
+	)`).Error)
+
+	authService := serviceAuth.NewAuthService(env.db, cache.NewMemoryCache())
+	for _, opt := range opts {
+		opt(authService)
+	}
+	controller := NewAuthController(authService)
+	router := gin.New()
+	authGroup := router.Group("/api/v1/auth")
+	{
+		authGroup.POST("/login", controller.Login)
+		authGroup.POST("/admin/login", controller.AdminLogin)
+		authGroup.POST("/mfa/verify", controller.VerifyMFALogin)
+		authGroup.POST("/mfa/enroll", controller.EnrollMFAWithToken)
+		authGroup.POST("/mfa/setup", testAuthMiddleware(), controller.SetupMFA)
+		authGroup.POST("/mfa/enable", testAuthMiddleware(), controller.EnableMFA)
+		authGroup.POST("/mfa/disable", testAuthMiddleware(), controller.DisableMFA)
+		authGroup.POST("/mfa/recovery-codes", testAuthMiddleware(), controller.RegenerateRecoveryCodes)
+	}
+	env.router = router
+	return env
+}
+
+func decodeData[T any](t *testing.T, resp testResponse) T {
+	var v T
+	require.Equal(t, 0, resp.Code, resp.Msg)
+	require.NoError(t, json.Unmarshal(resp.Data, &v))
+	return v
+}
+
+func totpCode(t *testing.T, secret string, at time.Time) string {
+	code, err := pkgAuth.GenerateTOTPCode(secret, at)
+	require.NoError(t, err)
+	return code
+}
+
+func loginAs(env *testEnv, path, username, password string) testResponse {
+	return postJSON(env, path, map[string]string{"username": username, "password": password}, "")
+}
+
+// enableMFA 为 testuser 开通两步验证，返回密钥、开通时使用的验证码和恢复码
+func enableMFA(t *testing.T, env *testEnv) (string, string, []string) {
+	login := decodeData[apiV1.LoginResponse](t, loginAs(env, "/api/v1/auth/login", "testuser", "Test123456"))
+	require.NotEmpty(t, login.AccessToken)
+
+	enrollment := decodeData[apiV1.MFAEnrollmentResponse](t, postJSON(env, "/api/v1/auth/mfa/setup", nil, login.AccessToken))
+	u, err := url.Parse(enrollment.ProvisioningURI)
+	require.NoError(t, err)
+	assert.Equal(t, enrollment.Secret, u.Query().Get("secret"))
+
+	resp := postJSON(env, "/api/v1/auth/mfa/enable", map[string]string{"code": "000000"}, login.AccessToken)
+	require.Equal(t, errors.ErrorMFACodeInvalid, resp.Code)
+
+	code := totpCode(t, enrollment.Secret, time.Now())
+	codes := decodeData[apiV1.MFARecoveryCodesResponse](t, postJSON(env, "/api/v1/auth/mfa/enable",
+		map[string]string{"code": code}, login.AccessToken))
+	require.Len(t, codes.RecoveryCodes, 10)
+	return enrollment.Secret, code, codes.RecoveryCodes
+}
+
+func TestMFA_EnrollAndLogin(t *testing.T) {
+	env := setupMFATestEnv(t)
+	secret, usedCode, recoveryCodes := enableMFA(t, env)
+
+	var customer entity.Customer
+	require.NoError(t, env.db.Where("username = ?", "testuser").First(&customer).Error)
+	assert.True(t, customer.MFAEnabled)
+	assert.NotContains(t, customer.MFASecret, secret, "密钥应加密存储")
+
+	// 密码正确后只返回 mfa_token
+	login := decodeData[apiV1.LoginResponse](t, loginAs(env, "/api/v1/auth/login", "testuser", "Test123456"))
+	assert.True(t, login.MFARequired)
+	assert.Empty(t, login.AccessToken)
+	require.NotEmpty(t, login.MFAToken)
+
+	resp := postJSON(env, "/api/v1/auth/mfa/verify", map[string]string{"mfa_token": login.MFAToken, "code": "000000"}, "")
+	assert.Equal(t, errors.ErrorMFACodeInvalid, resp.Code)
+
+	// 开通时使用的验证码不能重放，下一个时间步的验证码可以登录
+	resp = postJSON(env, "/api/v1/auth/mfa/verify", map[string]string{"mfa_token": login.MFAToken, "code": usedCode}, "")
+	assert.Equal(t, errors.ErrorMFACodeInvalid, resp.Code)
+	next := totpCode(t, secret, time.Now().Add(30*time.Second))
+	tokens := decodeData[apiV1.LoginResponse](t, postJSON(env, "/api/v1/auth/mfa/verify", map[string]string{"mfa_token": login.MFAToken, "code": next}, ""))
+	assert.NotEmpty(t, tokens.AccessToken)
+	assert.NotEmpty(t, tokens.RefreshToken)
+
+	// mfa_token 只能使用一次
+	resp = postJSON(env, "/api/v1/auth/mfa/verify", map[string]string{"mfa_token": login.MFAToken, "code": next}, "")
+	assert.Equal(t, errors.ErrorTokenInvalid, resp.Code)
+
+	// 恢复码可以代替验证码登录，只能使用一次
+	login = decodeData[apiV1.LoginResponse](t, loginAs(env, "/api/v1/auth/login", "testuser", "Test123456"))
+	tokens = decodeData[apiV1.LoginResponse](t, postJSON(env, "/api/v1/auth/mfa/verify", map[string]string{"mfa_token": login.MFAToken, "code": recoveryCodes[0]}, ""))
+	assert.NotEmpty(t, tokens.AccessToken)
+
+	login = decodeData[apiV1.LoginResponse](t, loginAs(env, "/api/v1/auth/login", "testuser", "Test123456"))
+	resp = postJSON(env, "/api/v1/auth/mfa/verify", map[string]string{"mfa_token": login.MFAToken, "code": recoveryCodes[0]}, "")
+	assert.Equal(t, errors.ErrorMFACodeInvalid, resp.Code)
+}
+
+func TestMFA_TooManyAttempts(t *testing.T) {
+	env := setupMFATestEnv(t)
+	secret, _, _ := enableMFA(t, env)
+
+	login := decodeData[apiV1.LoginResponse](t, loginAs(env, "/api/v1/auth/login", "testuser", "Test123456"))
+	for i := 0; i < 5; i++ {
+		resp := postJSON(env, "/api/v1/auth/mfa/verify", map[string]string{"mfa_token": login.MFAToken, "code": "000000"}, "")
+		assert.Equal(t, errors.ErrorMFACodeInvalid, resp.Code)
+	}
+	resp := postJSON(env, "/api/v1/auth/mfa/verify", map[string]string{"mfa_token": login.MFAToken, "code": totpCode(t, secret, time.Now().Add(30*time.Second))}, "")
+	assert.Equal(t, errors.ErrorTokenInvalid, resp.Code)
+}
+
+func TestMFA_FailuresCountTowardLockout(t *testing.T) {
+	env := setupMFATestEnv(t, func(s *serviceAuth.AuthService) {
+		s.SetLoginLockout(config.LoginLockoutConfig{Enabled: true, MaxAttempts: 3, LockMinutes: 10, MaxLockMinutes: 30})
+	})
+	secret, _, _ := enableMFA(t, env)
+	verify := func(token, code string) testResponse {
+		return postJSON(env, "/api/v1/auth/mfa/verify", map[string]string{"mfa_token": token, "code": code}, "")
+	}
+
+	login := decodeData[apiV1.LoginResponse](t, loginAs(env, "/api/v1/auth/login", "testuser", "Test123456"))
+	assert.Equal(t, errors.ErrorMFACodeInvalid, verify(login.MFAToken, "000000").Code)
+	assert.Equal(t, errors.ErrorMFACodeInvalid, verify(login.MFAToken, "000000").Code)
+	assert.Equal(t, 2, loadTestUser(t, env).FailedLoginAttempts)
+
+	// 重新输入正确密码不会清除失败次数，第 3 次验证码错误锁定账号
+	login = decodeData[apiV1.LoginResponse](t, loginAs(env, "/api/v1/auth/login", "testuser", "Test123456"))
+	assert.Equal(t, 2, loadTestUser(t, env).FailedLoginAttempts)
+	assert.Equal(t, errors.ErrorMFACodeInvalid, verify(login.MFAToken, "000000").Code)
+	customer := loadTestUser(t, env)
+	require.NotNil(t, customer.LockedUntil)
+
+	// 锁定期间正确的验证码和密码都无法登录
+	assert.Equal(t, errors.ErrorAccountLocked, verify(login.MFAToken, totpCode(t, secret, time.Now().Add(30*time.Second))).Code)
+	assert.Equal(t, errors.ErrorAccountLocked, loginAs(env, "/api/v1/auth/login", "testuser", "Test123456").Code)
+
+	// 两步验证通过后清除失败记录
+	require.NoError(t, env.db.Model(&customer).Update("locked_until", time.Now().Add(-time.Second)).Error)
+	login = decodeData[apiV1.LoginResponse](t, loginAs(env, "/api/v1/auth/login", "testuser", "Test123456"))
+	assert.Equal(t, errors.ErrorMFACodeInvalid, verify(login.MFAToken, "000000").Code)
+	tokens := decodeData[apiV1.LoginResponse](t, verify(login.MFAToken, totpCode(t, secret, time.Now().Add(30*time.Second))))
+	assert.NotEmpty(t, tokens.AccessToken)
+	customer = loadTestUser(t, env)
+	assert.Equal(t, 0, customer.FailedLoginAttempts)
+	assert.Nil(t, customer.LockedUntil)
+}
+
+// mfaSession 开通两步验证并完成登录，返回密钥和访问令牌
+func mfaSession(t *testing.T, env *testEnv) (string, string) {
+	secret, _, _ := enableMFA(t, env)
+	login := decodeData[apiV1.LoginResponse](t, loginAs(env, "/api/v1/auth/login", "testuser", "Test123456"))
+	tokens := decodeData[apiV1.LoginResponse](t, postJSON(env, "/api/v1/auth/mfa/verify",
+		map[string]string{"mfa_token": login.MFAToken, "code": totpCode(t, secret, time.Now().Add(30*time.Second))}, ""))
+	require.NotEmpty(t, tokens.AccessToken)
+	return secret, tokens.AccessToken
+}
+
+func TestMFA_ManageFailuresCountTowardLockout(t *testing.T) {
+	env := setupMFATestEnv(t, func(s *serviceAuth.AuthService) {
+		s.SetLoginLockout(config.LoginLockoutConfig{Enabled: true, MaxAttempts: 3, LockMinutes: 10, MaxLockMinutes: 30})
+	})
+	_, accessToken := mfaSession(t, env)
+
+	resp := postJSON(env, "/api/v1/auth/mfa/disable", map[string]string{"code": "000000"}, accessToken)
+	assert.Equal(t, errors.ErrorMFACodeInvalid, resp.Code)
+	resp = postJSON(env, "/api/v1/auth/mfa/recovery-codes", map[string]string{"code": "000000"}, accessToken)
+	assert.Equal(t, errors.ErrorMFACodeInvalid, resp.Code)
+	assert.Equal(t, 2, loadTestUser(t, env).FailedLoginAttempts)
+
+	resp = postJSON(env, "/api/v1/auth/mfa/disable", map[string]string{"code": "000000"}, accessToken)
+	assert.Equal(t, errors.ErrorMFACodeInvalid, resp.Code)
+	require.NotNil(t, loadTestUser(t, env).LockedUntil)
+
+	// 锁定后会话仍有效，但不能继续尝试
+	resp = postJSON(env, "/api/v1/auth/mfa/disable", map[string]string{"code": "000000"}, accessToken)
+	assert.Equal(t, errors.ErrorAccountLocked, resp.Code)
+	assert.True(t, loadTestUser(t, env).MFAEnabled)
+}
+
+func TestMFA_ManageTooManyAttempts(t *testing.T) {
+	env := setupMFATestEnv(t)
+	secret, accessToken := mfaSession(t, env)
+
+	for i := 0; i < 5; i++ {
+		resp := postJSON(env, "/api/v1/auth/mfa/disable", map[string]string{"code": "000000"}, accessToken)
+		assert.Equal(t, errors.ErrorMFACodeInvalid, resp.Code)
+	}
+	resp := postJSON(env, "/api/v1/auth/mfa/disable",
+		map[string]string{"code": totpCode(t, secret, time.Now().Add(60*time.Second))}, accessToken)
+	assert.Equal(t, errors.ErrorAccountLocked, resp.Code)
+	assert.True(t, loadTestUser(t, env).MFAEnabled)
+}
+
+func TestMFA_EnforcedForAdmin(t *testing.T) {
+	env := setupMFATestEnv(t)
+	require.NoError(t, env.db.Create(&entity.SystemConfig{
+		ConfigKey: "security_mfa_enforce_admin", ConfigValue: "true", ConfigType: "boolean", ConfigGroup: "security",
+	}).Error)
+
+	// 未开通的管理员登录后必须先开通
+	login := decodeData[apiV1.LoginResponse](t, loginAs(env, "/api/v1/auth/admin/login", "admin", "luoyang@123"))
+	assert.True(t, login.MFARequired)
+	assert.True(t, login.MFAEnrollmentRequired)
+	assert.Empty(t, login.AccessToken)
+
+	enrollment := decodeData[apiV1.MFAEnrollmentResponse](t, postJSON(env, "/api/v1/auth/mfa/enroll", map[string]string{"mfa_token": login.MFAToken}, ""))
+	tokens := decodeData[apiV1.LoginResponse](t, postJSON(env, "/api/v1/auth/mfa/verify",
+		map[string]string{"mfa_token": login.MFAToken, "code": totpCode(t, enrollment.Secret, time.Now())}, ""))
+	assert.NotEmpty(t, tokens.AccessToken)
+	assert.Len(t, tokens.RecoveryCodes, 10)
+
+	// 策略要求启用时不能自行关闭
+	resp := postJSON(env, "/api/v1/auth/mfa/disable",
+		map[string]string{"code": totpCode(t, enrollment.Secret, time.Now().Add(30*time.Second))}, tokens.AccessToken)
+	assert.Equal(t, errors.ErrorForbidden, resp.Code)
+
+	// 普通用户不受策略影响
+	login = decodeData[apiV1.LoginResponse](t, loginAs(env, "/api/v1/auth/login", "testuser", "Test123456"))
+	assert.False(t, login.MFARequired)
+	assert.NotEmpty(t, login.AccessToken)
+}
+
+func TestMFA_AdminReset(t *testing.T) {
+	env := setupMFATestEnv(t)
+	enableMFA(t, env)
+
+	var customer entity.Customer
+	require.NoError(t, env.db.Where("username = ?", "testuser").First(&customer).Error)
+	require.NoError(t, serviceCustomer.NewCustomerService(env.db).ResetMFA(t.Context(), customer.ID))
+
+	require.NoError(t, env.db.First(&customer, customer.ID).Error)
+	assert.False(t, customer.MFAEnabled)
+	assert.Empty(t, customer.MFASecret)
+	var codes int64
+	env.db.Table("mfa_recovery_codes").Where("customer_id = ?", customer.ID).Count(&codes)
+	assert.Zero(t, codes)
+
+	login := decodeData[apiV1.LoginResponse](t, loginAs(env, "/api/v1/auth/login", "testuser", "Test123456"))
+	assert.False(t, login.MFARequired)
+	assert.NotEmpty(t, login.AccessToken)
+}
@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	serviceAuth "github.com/YoungBoyGod/remotegpu/internal/service/auth"
+	"github.com/YoungBoyGod/remotegpu/internal/service/sso"
+	"github.com/YoungBoyGod/remotegpu/pkg/cache"
+	"github.com/YoungBoyGod/remotegpu/pkg/errors"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// markExternal 把测试用户标记为单点登录账号
+func markExternal(t *testing.T, env *testEnv, username string) {
+	require.NoError(t, env.db.Exec(`ALTER TABLE customers ADD COLUMN auth_source TEXT DEFAULT 'local'`).Error)
+	require.NoError(t, env.db.Exec(`UPDATE customers SET auth_source = 'oidc' WHERE username = ?`, username).Error)
+}
+
+func TestLogin_ExternalAccountRequiresSSO(t *testing.T) {
+	env := setupTestEnv(t)
+	markExternal(t, env, "testuser")
+
+	resp := postJSON(env, "/api/v1/auth/login", map[string]string{"username": "testuser", "password": "Test123456"}, "")
+	assert.Equal(t, errors.ErrorSSOLoginRequired, resp.Code)
+
+	// 本地账号不受影响
+	resp = postJSON(env, "/api/v1/auth/login", map[string]string{"username": "user", "password": "user@123"}, "")
+	assert.Equal(t, 0, resp.Code)
+}
+
+func TestRequestPasswordReset_ExternalAccountSkipped(t *testing.T) {
+	env, mailer := setupMailTestEnv(t)
+	markExternal(t, env, "testuser")
+
+	resp := postJSON(env, "/api/v1/auth/password/request", map[string]string{"username": "testuser"}, "")
+	assert.Equal(t, 0, resp.Code)
+	select {
+	case <-mailer.sent:
+		t.Fatal("单点登录账号不应发送重置邮件")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestSSOGetProvider(t *testing.T) {
+	env := setupTestEnv(t)
+	ssoService := sso.NewSSOService(env.db, cache.NewMemoryCache(), serviceAuth.NewAuthService(env.db, nil))
+	router := gin.New()
+	router.GET("/api/v1/auth/sso/providers/:company_code", NewSSOController(ssoService).GetProvider)
+	env.router = router
+
+	getProvider := func(code string) testResponse {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/auth/sso/providers/"+code, nil))
+		var resp testResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp
+	}
+
+	assert.Equal(t, errors.ErrorNotFound, getProvider("acme").Code)
+
+	require.NoError(t, env.db.Exec(`INSERT INTO system_configs (config_key, config_value, config_type, config_group) VALUES (?, ?, 'json', 'sso')`,
+		"sso_idp_acme", `{"type":"ldap","enabled":true,"company_name":"Acme","ldap":{"url":"ldap://ldap.acme.com","base_dn":"dc=acme,dc=com"},"default_role":"customer_member"}`).Error)
+	resp := getProvider("ACME")
+	require.Equal(t, 0, resp.Code)
+	assert.JSONEq(t, `{"company_code":"acme","company_name":"Acme","type":"ldap"}`, string(resp.Data))
+}
@@ -0,0 +1,183 @@
+package auth
+
+import (
+	apiV1 "github.com/YoungBoyGod/remotegpu/api/v1"
+	serviceAuth "github.com/YoungBoyGod/remotegpu/internal/service/auth"
+	"github.com/YoungBoyGod/remotegpu/pkg/errors"
+	"github.com/gin-gonic/gin"
+)
+
+// loginResponse 将登录结果转换为响应
+func loginResponse(result *serviceAuth.LoginResult) apiV1.LoginResponse {
+	return apiV1.LoginResponse{
+		AccessToken:           result.AccessToken,
+		RefreshToken:          result.RefreshToken,
+		ExpiresIn:             result.ExpiresIn,
+		MustChangePassword:    result.MustChangePassword,
+		MFARequired:           result.MFARequired,
+		MFAEnrollmentRequired: result.MFAEnrollmentRequired,
+		MFAToken:              result.MFAToken,
+		RecoveryCodes:         result.RecoveryCodes,
+	}
+}
+
+// handleError 输出业务错误，非业务错误使用 fallback 提示
+func (c *AuthController) handleError(ctx *gin.Context, err error, fallback string) {
+	if appErr := errors.GetAppError(err); appErr != nil {
+		c.Error(ctx, appErr.Code, appErr.Message)
+		return
+	}
+	c.Error(ctx, 500, fallback)
+}
+
+// VerifyMFALogin 两步验证登录
+// @Summary 两步验证登录
+// @Description 提交登录返回的 mfa_token 和验证码（或恢复码）获取访问令牌；强制开通时首次提交即完成开通并返回恢复码
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body v1.MFAVerifyRequest true "两步验证请求"
+// @Success 200 {object} v1.LoginResponse
+// @Failure 400 {object} common.ErrorResponse
+// @Router /auth/mfa/verify [post]
+func (c *AuthController) VerifyMFALogin(ctx *gin.Context) {
+	var req apiV1.MFAVerifyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		c.Error(ctx, 400, "Invalid request parameters")
+		return
+	}
+
+	result, err := c.authService.VerifyMFALogin(ctx, req.MFAToken, req.Code, ctx.ClientIP())
+	if err != nil {
+		c.handleError(ctx, err, "Authentication failed")
+		return
+	}
+	c.Success(ctx, loginResponse(result))
+}
+
+// EnrollMFAWithToken 登录过程中开通两步验证
+// @Summary 登录过程中开通两步验证
+// @Description 系统要求启用两步验证但尚未开通时，使用登录返回的 mfa_token 生成密钥，再调用 /auth/mfa/verify 提交验证码
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body v1.MFATokenRequest true "MFA 待验证令牌"
+// @Success 200 {object} v1.MFAEnrollmentResponse
+// @Failure 400 {object} common.ErrorResponse
+// @Router /auth/mfa/enroll [post]
+func (c *AuthController) EnrollMFAWithToken(ctx *gin.Context) {
+	var req apiV1.MFATokenRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		c.Error(ctx, 400, "Invalid request parameters")
+		return
+	}
+
+	enrollment, err := c.authService.BeginMFAEnrollmentWithToken(ctx, req.MFAToken)
+	if err != nil {
+		c.handleError(ctx, err, "Failed to start mfa enrollment")
+		return
+	}
+	c.Success(ctx, apiV1.MFAEnrollmentResponse{
+		Secret:          enrollment.Secret,
+		ProvisioningURI: enrollment.ProvisioningURI,
+	})
+}
+
+// SetupMFA 开通两步验证
+// @Summary 开通两步验证
+// @Description 生成新的 TOTP 密钥和二维码地址，调用 /auth/mfa/enable 提交验证码后生效
+// @Tags Auth
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} v1.MFAEnrollmentResponse
+// @Failure 400 {object} common.ErrorResponse
+// @Router /auth/mfa/setup [post]
+func (c *AuthController) SetupMFA(ctx *gin.Context) {
+	enrollment, err := c.authService.BeginMFAEnrollment(ctx, ctx.GetUint("userID"))
+	if err != nil {
+		c.handleError(ctx, err, "Failed to start mfa enrollment")
+		return
+	}
+	c.Success(ctx, apiV1.MFAEnrollmentResponse{
+		Secret:          enrollment.Secret,
+		ProvisioningURI: enrollment.ProvisioningURI,
+	})
+}
+
+// EnableMFA 启用两步验证
+// @Summary 启用两步验证
+// @Description 提交验证器生成的验证码启用两步验证，返回恢复码（只展示一次）
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.MFACodeRequest true "验证码"
+// @Success 200 {object} v1.MFARecoveryCodesResponse
+// @Failure 400 {object} common.ErrorResponse
+// @Router /auth/mfa/enable [post]
+func (c *AuthController) EnableMFA(ctx *gin.Context) {
+	var req apiV1.MFACodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		c.Error(ctx, 400, "Invalid request parameters")
+		return
+	}
+
+	codes, err := c.authService.EnableMFA(ctx, ctx.GetUint("userID"), req.Code)
+	if err != nil {
+		c.handleError(ctx, err, "Failed to enable mfa")
+		return
+	}
+	c.Success(ctx, apiV1.MFARecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// DisableMFA 关闭两步验证
+// @Summary 关闭两步验证
+// @Description 提交当前验证码关闭两步验证；系统要求启用时不允许关闭
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.MFACodeRequest true "验证码"
+// @Success 200 {object} common.SuccessResponse
+// @Failure 400 {object} common.ErrorResponse
+// @Failure 403 {object} common.ErrorResponse
+// @Router /auth/mfa/disable [post]
+func (c *AuthController) DisableMFA(ctx *gin.Context) {
+	var req apiV1.MFACodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		c.Error(ctx, 400, "Invalid request parameters")
+		return
+	}
+
+	if err := c.authService.DisableMFA(ctx, ctx.GetUint("userID"), req.Code, ctx.ClientIP()); err != nil {
+		c.handleError(ctx, err, "Failed to disable mfa")
+		return
+	}
+	c.Success(ctx, gin.H{"message": "MFA disabled"})
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码
+// @Summary 重新生成恢复码
+// @Description 提交当前验证码重新生成恢复码，旧恢复码全部作废
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.MFACodeRequest true "验证码"
+// @Success 200 {object} v1.MFARecoveryCodesResponse
+// @Failure 400 {object} common.ErrorResponse
+// @Router /auth/mfa/recovery-codes [post]
+func (c *AuthController) RegenerateRecoveryCodes(ctx *gin.Context) {
+	var req apiV1.MFACodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		c.Error(ctx, 400, "Invalid request parameters")
+		return
+	}
+
+	codes, err := c.authService.RegenerateRecoveryCodes(ctx, ctx.GetUint("userID"), req.Code, ctx.ClientIP())
+	if err != nil {
+		c.handleError(ctx, err, "Failed to regenerate recovery codes")
+		return
+	}
+	c.Success(ctx, apiV1.MFARecoveryCodesResponse{RecoveryCodes: codes})
+}
@@ -0,0 +1,132 @@
+package auth
+
+import (
+	apiV1 "github.com/YoungBoyGod/remotegpu/api/v1"
+	"github.com/YoungBoyGod/remotegpu/internal/controller/v1/common"
+	"github.com/YoungBoyGod/remotegpu/internal/service/sso"
+	"github.com/YoungBoyGod/remotegpu/pkg/errors"
+	"github.com/gin-gonic/gin"
+)
+
+type SSOController struct {
+	common.BaseController
+	ssoService *sso.SSOService
+}
+
+func NewSSOController(ssoService *sso.SSOService) *SSOController {
+	return &SSOController{
+		ssoService: ssoService,
+	}
+}
+
+// handleError 输出业务错误，非业务错误使用 fallback 提示
+func (c *SSOController) handleError(ctx *gin.Context, err error, fallback string) {
+	if appErr := errors.GetAppError(err); appErr != nil {
+		c.Error(ctx, appErr.Code, appErr.Message)
+		return
+	}
+	c.Error(ctx, 500, fallback)
+}
+
+// GetProvider 查询企业身份源
+// @Summary 查询企业单点登录方式
+// @Description 根据企业编码返回身份源类型（oidc / ldap），未配置时返回 404
+// @Tags Auth
+// @Produce json
+// @Param company_code path string true "企业编码"
+// @Success 200 {object} v1.SSOProviderResponse
+// @Failure 404 {object} common.ErrorResponse
+// @Router /auth/sso/providers/{company_code} [get]
+func (c *SSOController) GetProvider(ctx *gin.Context) {
+	info, err := c.ssoService.GetProvider(ctx, ctx.Param("company_code"))
+	if err != nil {
+		c.handleError(ctx, err, "Failed to get sso provider")
+		return
+	}
+	c.Success(ctx, apiV1.SSOProviderResponse{
+		CompanyCode: info.CompanyCode,
+		CompanyName: info.CompanyName,
+		Type:        info.Type,
+	})
+}
+
+// AuthorizeOIDC 发起 OIDC 登录
+// @Summary 发起 OIDC 单点登录
+// @Description 返回身份源授权地址，前端跳转后身份源回调控制台页面，再由页面调用 /auth/sso/oidc/callback
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body v1.SSOAuthorizeRequest true "企业编码"
+// @Success 200 {object} v1.SSOAuthorizeResponse
+// @Failure 400 {object} common.ErrorResponse
+// @Failure 404 {object} common.ErrorResponse
+// @Router /auth/sso/oidc/authorize [post]
+func (c *SSOController) AuthorizeOIDC(ctx *gin.Context) {
+	var req apiV1.SSOAuthorizeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		c.Error(ctx, 400, "Invalid request parameters")
+		return
+	}
+
+	authz, err := c.ssoService.BeginOIDCLogin(ctx, req.CompanyCode)
+	if err != nil {
+		c.handleError(ctx, err, "Failed to start sso login")
+		return
+	}
+	c.Success(ctx, apiV1.SSOAuthorizeResponse{
+		AuthorizationURL: authz.URL,
+		State:            authz.State,
+	})
+}
+
+// OIDCCallback 完成 OIDC 登录
+// @Summary 完成 OIDC 单点登录
+// @Description 提交身份源回调的 state 和 code 换取访问令牌；首次登录时按配置自动创建账号
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body v1.SSOCallbackRequest true "回调参数"
+// @Success 200 {object} v1.LoginResponse
+// @Failure 400 {object} common.ErrorResponse
+// @Failure 403 {object} common.ErrorResponse
+// @Router /auth/sso/oidc/callback [post]
+func (c *SSOController) OIDCCallback(ctx *gin.Context) {
+	var req apiV1.SSOCallbackRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		c.Error(ctx, 400, "Invalid request parameters")
+		return
+	}
+
+	result, err := c.ssoService.CompleteOIDCLogin(ctx, req.State, req.Code)
+	if err != nil {
+		c.handleError(ctx, err, "Authentication failed")
+		return
+	}
+	c.Success(ctx, loginResponse(result))
+}
+
+// LDAPLogin 企业 LDAP 登录
+// @Summary 企业 LDAP 登录
+// @Description 使用企业目录账号密码登录；首次登录时按配置自动创建账号
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body v1.LDAPLoginRequest true "登录请求"
+// @Success 200 {object} v1.LoginResponse
+// @Failure 400 {object} common.ErrorResponse
+// @Failure 403 {object} common.ErrorResponse
+// @Router /auth/sso/ldap/login [post]
+func (c *SSOController) LDAPLogin(ctx *gin.Context) {
+	var req apiV1.LDAPLoginRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		c.Error(ctx, 400, "Invalid request parameters")
+		return
+	}
+
+	result, err := c.ssoService.LDAPLogin(ctx, req.CompanyCode, req.Username, req.Password)
+	if err != nil {
+		c.handleError(ctx, err, "Authentication failed")
+		return
+	}
+	c.Success(ctx, loginResponse(result))
+}
@@ -0,0 +1,220 @@
+package billing
+
+import (
+	"strconv"
+	"time"
+
+	apiV1 "github.com/YoungBoyGod/remotegpu/api/v1"
+	"github.com/YoungBoyGod/remotegpu/internal/controller/v1/common"
+	"github.com/YoungBoyGod/remotegpu/internal/dao"
+	serviceBilling "github.com/YoungBoyGod/remotegpu/internal/service/billing"
+	"github.com/gin-gonic/gin"
+)
+
+// AdminBillingController 管理员用量与账单控制器
+type AdminBillingController struct {
+	common.BaseController
+	billingService *serviceBilling.BillingService
+}
+
+func NewAdminBillingController(bs *serviceBilling.BillingService) *AdminBillingController {
+	return &AdminBillingController{billingService: bs}
+}
+
+// Usage 管理员查询用量记录
+// @Summary 管理员获取用量记录
+// @Tags Admin - Billing
+// @Produce json
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Param customer_id query int false "客户ID筛选"
+// @Param start_time query string false "开始时间 (RFC3339 或 2006-01-02)"
+// @Param end_time query string false "结束时间 (RFC3339 或 2006-01-02)"
+// @Param source_type query string false "来源筛选 (allocation, environment, task)"
+// @Param workspace_id query int false "工作空间ID筛选"
+// @Security Bearer
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} common.ErrorResponse
+// @Router /admin/billing/usage [get]
+func (c *AdminBillingController) Usage(ctx *gin.Context) {
+	filter, ok := c.parseFilter(ctx)
+	if !ok {
+		return
+	}
+	listUsage(&c.BaseController, ctx, c.billingService, filter)
+}
+
+// ExportUsage 管理员导出用量记录
+// @Summary 管理员导出用量记录
+// @Description 导出时间范围内的全部用量记录，未指定时间范围时导出当月
+// @Tags Admin - Billing
+// @Produce text/csv
+// @Produce json
+// @Param format query string false "导出格式 (csv, json)" default(csv)
+// @Param customer_id query int false "客户ID筛选"
+// @Param start_time query string false "开始时间 (RFC3339 或 2006-01-02)"
+// @Param end_time query string false "结束时间 (RFC3339 或 2006-01-02)"
+// @Param source_type query string false "来源筛选 (allocation, environment, task)"
+// @Param workspace_id query int false "工作空间ID筛选"
+// @Security Bearer
+// @Success 200 {file} file
+// @Failure 400 {object} common.ErrorResponse
+// @Router /admin/billing/usage/export [get]
+func (c *AdminBillingController) ExportUsage(ctx *gin.Context) {
+	filter, ok := c.parseFilter(ctx)
+	if !ok {
+		return
+	}
+	exportUsage(&c.BaseController, ctx, c.billingService, filter)
+}
+
+// Statement 管理员查看用量对账单
+// @Summary 管理员获取用量对账单
+// @Description 按来源、工作空间、GPU 型号汇总时间范围内的用量和费用，未指定客户时汇总全部客户
+// @Tags Admin - Billing
+// @Produce json
+// @Param customer_id query int false "客户ID筛选"
+// @Param start_time query string false "开始时间 (RFC3339 或 2006-01-02)"
+// @Param end_time query string false "结束时间 (RFC3339 或 2006-01-02)"
+// @Param workspace_id query int false "工作空间ID筛选"
+// @Security Bearer
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} common.ErrorResponse
+// @Router /admin/billing/statement [get]
+func (c *AdminBillingController) Statement(ctx *gin.Context) {
+	filter, ok := c.parseFilter(ctx)
+	if !ok {
+		return
+	}
+	statement, err := c.billingService.Statement(ctx, filter)
+	if err != nil {
+		billingError(&c.BaseController, ctx, err)
+		return
+	}
+	c.Success(ctx, statement)
+}
+
+// Invoices 管理员查询账单
+// @Summary 管理员获取账单列表
+// @Tags Admin - Billing
+// @Produce json
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(10)
+// @Param customer_id query int false "客户ID筛选"
+// @Param status query string false "状态筛选 (pending, paid, overdue, cancelled)"
+// @Security Bearer
+// @Success 200 {object} map[string]interface{}
+// @Failure 500 {object} common.ErrorResponse
+// @Router /admin/billing/invoices [get]
+func (c *AdminBillingController) Invoices(ctx *gin.Context) {
+	filters := make(map[string]interface{})
+	if customerID := ctx.Query("customer_id"); customerID != "" {
+		if id, err := strconv.ParseUint(customerID, 10, 64); err == nil {
+			filters["customer_id"] = uint(id)
+		}
+	}
+	listInvoices(&c.BaseController, ctx, c.billingService, filters)
+}
+
+// InvoiceDetail 管理员查看账单详情
+// @Summary 管理员获取账单详情
+// @Tags Admin - Billing
+// @Produce json
+// @Param id path int true "账单ID"
+// @Security Bearer
+// @Success 200 {object} entity.Invoice
+// @Failure 404 {object} common.ErrorResponse
+// @Router /admin/billing/invoices/{id} [get]
+func (c *AdminBillingController) InvoiceDetail(ctx *gin.Context) {
+	invoice, ok := getInvoice(&c.BaseController, ctx, c.billingService, nil)
+	if !ok {
+		return
+	}
+	c.Success(ctx, invoice)
+}
+
+// ExportInvoice 管理员导出账单
+// @Summary 管理员导出账单
+// @Description CSV 导出账单计入的全部用量记录，JSON 导出账单、明细和用量记录
+// @Tags Admin - Billing
+// @Produce text/csv
+// @Produce json
+// @Param id path int true "账单ID"
+// @Param format query string false "导出格式 (csv, json)" default(csv)
+// @Security Bearer
+// @Success 200 {file} file
+// @Failure 404 {object} common.ErrorResponse
+// @Router /admin/billing/invoices/{id}/export [get]
+func (c *AdminBillingController) ExportInvoice(ctx *gin.Context) {
+	invoice, ok := getInvoice(&c.BaseController, ctx, c.billingService, nil)
+	if !ok {
+		return
+	}
+	exportInvoice(&c.BaseController, ctx, c.billingService, invoice)
+}
+
+// GenerateInvoices 手动生成账单
+// @Summary 生成指定月份的账单
+// @Description 为已结束的账期生成账单，已有该账期账单的客户跳过（定时任务会在每月初自动生成上月账单）
+// @Tags Admin - Billing
+// @Accept json
+// @Produce json
+// @Param request body v1.GenerateInvoicesRequest true "账期"
+// @Security Bearer
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} common.ErrorResponse
+// @Router /admin/billing/invoices/generate [post]
+func (c *AdminBillingController) GenerateInvoices(ctx *gin.Context) {
+	var req apiV1.GenerateInvoicesRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		c.Error(ctx, 400, "Invalid request parameters")
+		return
+	}
+	period, err := time.ParseInLocation("2006-01", req.Period, time.Local)
+	if err != nil {
+		c.Error(ctx, 400, "period 格式错误，应为 YYYY-MM")
+		return
+	}
+
+	generated, err := c.billingService.GenerateInvoices(ctx, period)
+	if err != nil {
+		billingError(&c.BaseController, ctx, err)
+		return
+	}
+	c.Success(ctx, gin.H{"period": req.Period, "generated": generated})
+}
+
+// Prices 查看当前价格表
+// @Summary 获取当前价格表
+// @Description 价格表在系统配置 billing 分组中维护（billing_gpu_prices、billing_currency、billing_sources）
+// @Tags Admin - Billing
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} billing.PriceTable
+// @Failure 409 {object} common.ErrorResponse
+// @Router /admin/billing/prices [get]
+func (c *AdminBillingController) Prices(ctx *gin.Context) {
+	prices, err := c.billingService.Prices(ctx)
+	if err != nil {
+		billingError(&c.BaseController, ctx, err)
+		return
+	}
+	c.Success(ctx, prices)
+}
+
+func (c *AdminBillingController) parseFilter(ctx *gin.Context) (dao.UsageFilter, bool) {
+	filter, err := parseUsageFilter(ctx)
+	if err != nil {
+		c.Error(ctx, 400, err.Error())
+		return filter, false
+	}
+	if customerID := ctx.Query("customer_id"); customerID != "" {
+		id, err := strconv.ParseUint(customerID, 10, 64)
+		if err != nil {
+			c.Error(ctx, 400, "customer_id 格式错误")
+			return filter, false
+		}
+		filter.CustomerID = uint(id)
+	}
+	return filter, true
+}
@@ -0,0 +1,318 @@
+package billing
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/YoungBoyGod/remotegpu/internal/controller/v1/common"
+	"github.com/YoungBoyGod/remotegpu/internal/dao"
+	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+	serviceBilling "github.com/YoungBoyGod/remotegpu/internal/service/billing"
+	"github.com/gin-gonic/gin"
+)
+
+// BillingController 客户用量与账单控制器
+type BillingController struct {
+	common.BaseController
+	billingService *serviceBilling.BillingService
+}
+
+func NewBillingController(bs *serviceBilling.BillingService) *BillingController {
+	return &BillingController{billingService: bs}
+}
+
+// Usage 获取我的用量记录
+// @Summary 获取我的用量记录
+// @Description 用量记录按自然日切分，按记录开始时间筛选
+// @Tags Customer - Billing
+// @Produce json
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Param start_time query string false "开始时间 (RFC3339 或 2006-01-02)"
+// @Param end_time query string false "结束时间 (RFC3339 或 2006-01-02)"
+// @Param source_type query string false "来源筛选 (allocation, environment, task)"
+// @Param workspace_id query int false "工作空间ID筛选"
+// @Security Bearer
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} common.ErrorResponse
+// @Router /customer/billing/usage [get]
+func (c *BillingController) Usage(ctx *gin.Context) {
+	filter, err := parseUsageFilter(ctx)
+	if err != nil {
+		c.Error(ctx, 400, err.Error())
+		return
+	}
+	filter.CustomerID = ctx.GetUint("userID")
+	listUsage(&c.BaseController, ctx, c.billingService, filter)
+}
+
+// ExportUsage 导出我的用量记录
+// @Summary 导出我的用量记录
+// @Description 导出时间范围内的全部用量记录，未指定时间范围时导出当月
+// @Tags Customer - Billing
+// @Produce text/csv
+// @Produce json
+// @Param format query string false "导出格式 (csv, json)" default(csv)
+// @Param start_time query string false "开始时间 (RFC3339 或 2006-01-02)"
+// @Param end_time query string false "结束时间 (RFC3339 或 2006-01-02)"
+// @Param source_type query string false "来源筛选 (allocation, environment, task)"
+// @Param workspace_id query int false "工作空间ID筛选"
+// @Security Bearer
+// @Success 200 {file} file
+// @Failure 400 {object} common.ErrorResponse
+// @Router /customer/billing/usage/export [get]
+func (c *BillingController) ExportUsage(ctx *gin.Context) {
+	filter, err := parseUsageFilter(ctx)
+	if err != nil {
+		c.Error(ctx, 400, err.Error())
+		return
+	}
+	filter.CustomerID = ctx.GetUint("userID")
+	exportUsage(&c.BaseController, ctx, c.billingService, filter)
+}
+
+// Statement 获取我的用量对账单
+// @Summary 获取我的用量对账单
+// @Description 按来源、工作空间、GPU 型号汇总时间范围内的用量和费用（含尚未出账的用量）
+// @Tags Customer - Billing
+// @Produce json
+// @Param start_time query string false "开始时间 (RFC3339 或 2006-01-02)"
+// @Param end_time query string false "结束时间 (RFC3339 或 2006-01-02)"
+// @Param workspace_id query int false "工作空间ID筛选"
+// @Security Bearer
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} common.ErrorResponse
+// @Router /customer/billing/statement [get]
+func (c *BillingController) Statement(ctx *gin.Context) {
+	filter, err := parseUsageFilter(ctx)
+	if err != nil {
+		c.Error(ctx, 400, err.Error())
+		return
+	}
+	filter.CustomerID = ctx.GetUint("userID")
+	statement, err := c.billingService.Statement(ctx, filter)
+	if err != nil {
+		billingError(&c.BaseController, ctx, err)
+		return
+	}
+	c.Success(ctx, statement)
+}
+
+// Invoices 获取我的账单列表
+// @Summary 获取我的账单列表
+// @Tags Customer - Billing
+// @Produce json
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(10)
+// @Param status query string false "状态筛选 (pending, paid, overdue, cancelled)"
+// @Security Bearer
+// @Success 200 {object} map[string]interface{}
+// @Failure 500 {object} common.ErrorResponse
+// @Router /customer/billing/invoices [get]
+func (c *BillingController) Invoices(ctx *gin.Context) {
+	filters := map[string]interface{}{"customer_id": ctx.GetUint("userID")}
+	listInvoices(&c.BaseController, ctx, c.billingService, filters)
+}
+
+// InvoiceDetail 获取我的账单详情
+// @Summary 获取我的账单详情
+// @Tags Customer - Billing
+// @Produce json
+// @Param id path int true "账单ID"
+// @Security Bearer
+// @Success 200 {object} entity.Invoice
+// @Failure 404 {object} common.ErrorResponse
+// @Router /customer/billing/invoices/{id} [get]
+func (c *BillingController) InvoiceDetail(ctx *gin.Context) {
+	userID := ctx.GetUint("userID")
+	invoice, ok := getInvoice(&c.BaseController, ctx, c.billingService, &userID)
+	if !ok {
+		return
+	}
+	c.Success(ctx, invoice)
+}
+
+// ExportInvoice 导出我的账单
+// @Summary 导出我的账单
+// @Description CSV 导出账单计入的全部用量记录，JSON 导出账单、明细和用量记录
+// @Tags Customer - Billing
+// @Produce text/csv
+// @Produce json
+// @Param id path int true "账单ID"
+// @Param format query string false "导出格式 (csv, json)" default(csv)
+// @Security Bearer
+// @Success 200 {file} file
+// @Failure 404 {object} common.ErrorResponse
+// @Router /customer/billing/invoices/{id}/export [get]
+func (c *BillingController) ExportInvoice(ctx *gin.Context) {
+	userID := ctx.GetUint("userID")
+	invoice, ok := getInvoice(&c.BaseController, ctx, c.billingService, &userID)
+	if !ok {
+		return
+	}
+	exportInvoice(&c.BaseController, ctx, c.billingService, invoice)
+}
+
+// parseUsageFilter 解析用量查询条件
+func parseUsageFilter(ctx *gin.Context) (dao.UsageFilter, error) {
+	var filter dao.UsageFilter
+	var err error
+	if filter.Start, err = parseTime(ctx.Query("start_time")); err != nil {
+		return filter, errors.New("start_time 格式错误")
+	}
+	if filter.End, err = parseTime(ctx.Query("end_time")); err != nil {
+		return filter, errors.New("end_time 格式错误")
+	}
+	filter.SourceType = ctx.Query("source_type")
+	if ws := ctx.Query("workspace_id"); ws != "" {
+		id, err := strconv.ParseUint(ws, 10, 64)
+		if err != nil {
+			return filter, errors.New("workspace_id 格式错误")
+		}
+		wsID := uint(id)
+		filter.WorkspaceID = &wsID
+	}
+	return filter, nil
+}
+
+// parseTime 解析 RFC3339 时间或本地日期，空字符串返回零值
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation("2006-01-02", value, time.Local)
+}
+
+func listUsage(c *common.BaseController, ctx *gin.Context, bs *serviceBilling.BillingService, filter dao.UsageFilter) {
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(ctx.DefaultQuery("page_size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 200 {
+		pageSize = 20
+	}
+
+	records, total, err := bs.ListUsage(ctx, filter, page, pageSize)
+	if err != nil {
+		billingError(c, ctx, err)
+		return
+	}
+	c.Success(ctx, gin.H{
+		"list":      records,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// exportUsage 导出用量记录，未指定时间范围时导出当月
+func exportUsage(c *common.BaseController, ctx *gin.Context, bs *serviceBilling.BillingService, filter dao.UsageFilter) {
+	if filter.Start.IsZero() && filter.End.IsZero() {
+		now := time.Now()
+		filter.Start = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local)
+		filter.End = filter.Start.AddDate(0, 1, 0)
+	}
+	records, _, err := bs.ListUsage(ctx, filter, 0, 0)
+	if err != nil {
+		billingError(c, ctx, err)
+		return
+	}
+	name := "usage"
+	if !filter.Start.IsZero() {
+		name += "-" + filter.Start.Format("20060102")
+	}
+	writeExport(c, ctx, name, records, records)
+}
+
+func listInvoices(c *common.BaseController, ctx *gin.Context, bs *serviceBilling.BillingService, filters map[string]interface{}) {
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(ctx.DefaultQuery("page_size", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 10
+	}
+	if status := ctx.Query("status"); status != "" {
+		filters["status"] = status
+	}
+
+	invoices, total, err := bs.ListInvoices(ctx, page, pageSize, filters)
+	if err != nil {
+		c.Error(ctx, 500, "获取账单列表失败")
+		return
+	}
+	c.Success(ctx, gin.H{
+		"list":      invoices,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+func getInvoice(c *common.BaseController, ctx *gin.Context, bs *serviceBilling.BillingService, customerID *uint) (*entity.Invoice, bool) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(ctx, 400, "无效的账单ID")
+		return nil, false
+	}
+	invoice, err := bs.GetInvoice(ctx, uint(id), customerID)
+	if err != nil {
+		billingError(c, ctx, err)
+		return nil, false
+	}
+	return invoice, true
+}
+
+func exportInvoice(c *common.BaseController, ctx *gin.Context, bs *serviceBilling.BillingService, invoice *entity.Invoice) {
+	records, err := bs.InvoiceUsage(ctx, invoice.ID)
+	if err != nil {
+		billingError(c, ctx, err)
+		return
+	}
+	writeExport(c, ctx, invoice.InvoiceNo, gin.H{
+		"invoice": invoice,
+		"usage":   records,
+	}, records)
+}
+
+// writeExport 按 format 参数输出附件：csv 输出用量记录，json 输出 data
+func writeExport(c *common.BaseController, ctx *gin.Context, name string, data interface{}, records []entity.UsageRecord) {
+	switch ctx.DefaultQuery("format", "csv") {
+	case "csv":
+		var buf bytes.Buffer
+		if err := serviceBilling.WriteUsageCSV(&buf, records); err != nil {
+			c.Error(ctx, 500, "导出失败")
+			return
+		}
+		ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.csv"`, name))
+		ctx.Data(200, "text/csv; charset=utf-8", buf.Bytes())
+	case "json":
+		ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.json"`, name))
+		ctx.JSON(200, data)
+	default:
+		c.Error(ctx, 400, "不支持的导出格式")
+	}
+}
+
+// billingError 将计费服务错误映射为 HTTP 状态码
+func billingError(c *common.BaseController, ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, serviceBilling.ErrInvalidUsageFilter),
+		errors.Is(err, serviceBilling.ErrPeriodNotEnded):
+		c.Error(ctx, 400, err.Error())
+	case errors.Is(err, serviceBilling.ErrInvoiceNotFound):
+		c.Error(ctx, 404, err.Error())
+	case errors.Is(err, serviceBilling.ErrInvalidPriceTable):
+		c.Error(ctx, 409, err.Error())
+	default:
+		c.Error(ctx, 500, "计费操作失败")
+	}
+}
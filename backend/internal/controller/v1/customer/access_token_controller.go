@@ -0,0 +1,150 @@
+package customer
+
+import (
+	"errors"
+	"strconv"
+
+	apiV1 "github.com/YoungBoyGod/remotegpu/api/v1"
+	"github.com/YoungBoyGod/remotegpu/internal/controller/v1/common"
+	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+	"github.com/YoungBoyGod/remotegpu/internal/service/accesstoken"
+	"github.com/gin-gonic/gin"
+)
+
+type AccessTokenController struct {
+	common.BaseController
+	tokenService *accesstoken.AccessTokenService
+}
+
+func NewAccessTokenController(svc *accesstoken.AccessTokenService) *AccessTokenController {
+	return &AccessTokenController{
+		tokenService: svc,
+	}
+}
+
+func toAccessTokenResponse(t *entity.PersonalAccessToken) apiV1.AccessTokenResponse {
+	return apiV1.AccessTokenResponse{
+		ID:          t.ID,
+		Name:        t.Name,
+		TokenPrefix: t.TokenPrefix,
+		Scopes:      t.ScopeList(),
+		ExpiresAt:   t.ExpiresAt,
+		LastUsedAt:  t.LastUsedAt,
+		RevokedAt:   t.RevokedAt,
+		CreatedAt:   t.CreatedAt,
+	}
+}
+
+// List 列出当前用户的个人访问令牌
+// @Summary 获取个人访问令牌列表
+// @Description 列出当前登录用户的个人访问令牌（不含令牌明文）
+// @Tags Customer - Access Tokens
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} common.ErrorResponse
+// @Failure 500 {object} common.ErrorResponse
+// @Router /customer/tokens [get]
+func (c *AccessTokenController) List(ctx *gin.Context) {
+	userID := ctx.GetUint("userID")
+	if userID == 0 {
+		c.Error(ctx, 401, "未授权")
+		return
+	}
+
+	tokens, err := c.tokenService.List(ctx, userID)
+	if err != nil {
+		c.Error(ctx, 500, "获取访问令牌列表失败")
+		return
+	}
+
+	list := make([]apiV1.AccessTokenResponse, 0, len(tokens))
+	for i := range tokens {
+		list = append(list, toAccessTokenResponse(&tokens[i]))
+	}
+	c.Success(ctx, gin.H{"list": list})
+}
+
+// Create 创建个人访问令牌
+// @Summary 创建个人访问令牌
+// @Description 创建用于脚本和 CLI 的访问令牌，令牌明文只在本次响应中返回
+// @Tags Customer - Access Tokens
+// @Accept json
+// @Produce json
+// @Param request body v1.CreateAccessTokenRequest true "创建访问令牌请求"
+// @Security Bearer
+// @Success 200 {object} v1.AccessTokenResponse
+// @Failure 400 {object} common.ErrorResponse
+// @Failure 401 {object} common.ErrorResponse
+// @Failure 500 {object} common.ErrorResponse
+// @Router /customer/tokens [post]
+func (c *AccessTokenController) Create(ctx *gin.Context) {
+	userID := ctx.GetUint("userID")
+	if userID == 0 {
+		c.Error(ctx, 401, "未授权")
+		return
+	}
+
+	var req apiV1.CreateAccessTokenRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		c.Error(ctx, 400, "请求参数错误: "+err.Error())
+		return
+	}
+
+	token, plain, err := c.tokenService.Create(ctx, userID, req.Name, req.Scopes, req.ExpireDays)
+	if err != nil {
+		if errors.Is(err, accesstoken.ErrInvalidScope) || errors.Is(err, accesstoken.ErrInvalidExpiry) ||
+			errors.Is(err, accesstoken.ErrTooManyTokens) {
+			c.Error(ctx, 400, err.Error())
+			return
+		}
+		c.Error(ctx, 500, "创建访问令牌失败")
+		return
+	}
+
+	resp := toAccessTokenResponse(token)
+	resp.Token = plain
+	c.Success(ctx, resp)
+}
+
+// Revoke 吊销个人访问令牌
+// @Summary 吊销个人访问令牌
+// @Description 吊销当前用户指定的访问令牌，吊销后立即失效
+// @Tags Customer - Access Tokens
+// @Produce json
+// @Param id path int true "令牌 ID"
+// @Security Bearer
+// @Success 200 {object} common.SuccessResponse
+// @Failure 400 {object} common.ErrorResponse
+// @Failure 403 {object} common.ErrorResponse
+// @Failure 404 {object} common.ErrorResponse
+// @Failure 500 {object} common.ErrorResponse
+// @Router /customer/tokens/{id} [delete]
+func (c *AccessTokenController) Revoke(ctx *gin.Context) {
+	userID := ctx.GetUint("userID")
+	if userID == 0 {
+		c.Error(ctx, 401, "未授权")
+		return
+	}
+
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(ctx, 400, "无效的令牌 ID")
+		return
+	}
+
+	if err := c.tokenService.Revoke(ctx, userID, uint(id)); err != nil {
+		if errors.Is(err, accesstoken.ErrTokenNotFound) {
+			c.Error(ctx, 404, "访问令牌不存在")
+			return
+		}
+		if errors.Is(err, accesstoken.ErrTokenNotOwnedByUser) {
+			c.Error(ctx, 403, "无权吊销此访问令牌")
+			return
+		}
+		c.Error(ctx, 500, "吊销访问令牌失败")
+		return
+	}
+
+	c.Success(ctx, nil)
+}
@@ -0,0 +1,272 @@
+package customer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/YoungBoyGod/remotegpu/internal/middleware"
+	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+	"github.com/YoungBoyGod/remotegpu/internal/service/accesstoken"
+	pkgAuth "github.com/YoungBoyGod/remotegpu/pkg/auth"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type accessTokenTestEnv struct {
+	db     *gorm.DB
+	router *gin.Engine
+	jwt    string
+}
+
+// setupAccessTokenTestEnv 使用真实的 Auth 中间件，便于验证访问令牌的认证和权限范围
+func setupAccessTokenTestEnv(t *testing.T) *accessTokenTestEnv {
+	gin.SetMode(gin.TestMode)
+	require.NoError(t, pkgAuth.InitJWT("test-secret-key-must-be-at-least-32-characters", 1))
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+
+	err = db.Exec(`CREATE TABLE customers (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		created_at DATETIME,
+		updated_at DATETIME,
+		deleted_at DATETIME,
+		uuid TEXT,
+		username TEXT NOT NULL,
+		email TEXT NOT NULL,
+		password_hash TEXT NOT NULL DEFAULT '',
+		display_name TEXT,
+		full_name TEXT,
+		company_code TEXT,
+		company TEXT,
+		phone TEXT,
+		avatar_url TEXT,
+		role TEXT DEFAULT 'customer_owner',
+		user_type TEXT DEFAULT 'external',
+		account_type TEXT DEFAULT 'individual',
+		status TEXT DEFAULT 'active',
+		email_verified INTEGER DEFAULT 0,
+		phone_verified INTEGER DEFAULT 0,
+		must_change_password INTEGER DEFAULT 0,
+		quota_gpu INTEGER DEFAULT 0,
+		quota_storage INTEGER DEFAULT 0,
+		balance REAL DEFAULT 0,
+		currency TEXT DEFAULT 'CNY',
+		credit_limit REAL DEFAULT 0,
+		billing_plan_id INTEGER,
+		last_login_at DATETIME,
+		failed_login_attempts INTEGER DEFAULT 0,
+		lockout_count INTEGER DEFAULT 0,
+		locked_until DATETIME,
+		mfa_enabled INTEGER DEFAULT 0,
+		mfa_secret TEXT DEFAULT '',
+		mfa_last_step INTEGER DEFAULT 0,
+		mfa_enabled_at DATETIME
+	)`).Error
+	require.NoError(t, err)
+
+	err = db.Exec(`CREATE TABLE personal_access_tokens (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		customer_id INTEGER NOT NULL,
+		name VARCHAR(64) NOT NULL,
+		token_prefix VARCHAR(16) NOT NULL,
+		token_hash VARCHAR(64) NOT NULL UNIQUE,
+		scopes VARCHAR(512) NOT NULL,
+		expires_at DATETIME,
+		last_used_at DATETIME,
+		revoked_at DATETIME,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`).Error
+	require.NoError(t, err)
+
+	customer := &entity.Customer{Username: "patuser", Email: "pat@example.com", Role: "customer_owner", Status: "active"}
+	require.NoError(t, db.Create(customer).Error)
+	jwt, err := pkgAuth.GenerateToken(customer.ID, customer.Username, customer.Role)
+	require.NoError(t, err)
+
+	ctrl := NewAccessTokenController(accesstoken.NewAccessTokenService(db))
+	echo := func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, gin.H{"code": 0, "data": gin.H{"user_id": ctx.GetUint("userID")}})
+	}
+
+	r := gin.New()
+	cust := r.Group("/api/v1/customer", middleware.Auth(db))
+	{
+		cust.GET("/tokens", middleware.RejectAccessToken(), ctrl.List)
+		cust.POST("/tokens", middleware.RejectAccessToken(), ctrl.Create)
+		cust.DELETE("/tokens/:id", middleware.RejectAccessToken(), ctrl.Revoke)
+
+		machines := cust.Group("", middleware.RequireScope(pkgAuth.ScopeMachines))
+		machines.GET("/machines", echo)
+		machines.POST("/machines/:id/renew", echo)
+
+		tasks := cust.Group("", middleware.RequireScope(pkgAuth.ScopeTasks))
+		tasks.GET("/tasks", echo)
+	}
+
+	return &accessTokenTestEnv{db: db, router: r, jwt: jwt}
+}
+
+func (env *accessTokenTestEnv) do(method, path, bearer string, body interface{}) map[string]interface{} {
+	var buf bytes.Buffer
+	if body != nil {
+		_ = json.NewEncoder(&buf).Encode(body)
+	}
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	if bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+	w := httptest.NewRecorder()
+	env.router.ServeHTTP(w, req)
+
+	var resp map[string]interface{}
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	return resp
+}
+
+// createToken 通过接口创建令牌，返回令牌 ID 和明文
+func (env *accessTokenTestEnv) createToken(t *testing.T, scopes ...string) (uint, string) {
+	resp := env.do(http.MethodPost, "/api/v1/customer/tokens", env.jwt, map[string]interface{}{
+		"name":   "ci",
+		"scopes": scopes,
+	})
+	require.Equal(t, float64(0), resp["code"], resp["msg"])
+	data := resp["data"].(map[string]interface{})
+	return uint(data["id"].(float64)), data["token"].(string)
+}
+
+func TestAccessToken_CreateShowsTokenOnce(t *testing.T) {
+	env := setupAccessTokenTestEnv(t)
+
+	id, plain := env.createToken(t, "machines:read", "tasks:write", "machines:read")
+	assert.Contains(t, plain, pkgAuth.AccessTokenPrefix)
+
+	var stored entity.PersonalAccessToken
+	require.NoError(t, env.db.First(&stored, id).Error)
+	assert.Equal(t, pkgAuth.HashAccessToken(plain), stored.TokenHash)
+	assert.NotContains(t, stored.TokenHash, plain)
+	assert.Equal(t, "machines:read,tasks:write", stored.Scopes)
+	require.NotNil(t, stored.ExpiresAt)
+	assert.WithinDuration(t, time.Now().AddDate(0, 0, 30), *stored.ExpiresAt, time.Minute)
+
+	// 列表中不再返回明文
+	resp := env.do(http.MethodGet, "/api/v1/customer/tokens", env.jwt, nil)
+	require.Equal(t, float64(0), resp["code"])
+	list := resp["data"].(map[string]interface{})["list"].([]interface{})
+	require.Len(t, list, 1)
+	item := list[0].(map[string]interface{})
+	assert.NotContains(t, item, "token")
+	assert.Equal(t, stored.TokenPrefix, item["token_prefix"])
+}
+
+func TestAccessToken_CreateValidation(t *testing.T) {
+	env := setupAccessTokenTestEnv(t)
+
+	resp := env.do(http.MethodPost, "/api/v1/customer/tokens", env.jwt, map[string]interface{}{
+		"name": "bad", "scopes": []string{"admin:write"},
+	})
+	assert.Equal(t, float64(400), resp["code"])
+
+	resp = env.do(http.MethodPost, "/api/v1/customer/tokens", env.jwt, map[string]interface{}{
+		"name": "bad", "scopes": []string{"tasks:read"}, "expire_days": 400,
+	})
+	assert.Equal(t, float64(400), resp["code"])
+}
+
+func TestAccessToken_ScopeEnforcement(t *testing.T) {
+	env := setupAccessTokenTestEnv(t)
+	_, plain := env.createToken(t, "machines:read")
+
+	resp := env.do(http.MethodGet, "/api/v1/customer/machines", plain, nil)
+	assert.Equal(t, float64(0), resp["code"])
+
+	// 只读令牌不能执行写操作
+	resp = env.do(http.MethodPost, "/api/v1/customer/machines/m1/renew", plain, nil)
+	assert.Equal(t, float64(403), resp["code"])
+
+	// 未授权的资源
+	resp = env.do(http.MethodGet, "/api/v1/customer/tasks", plain, nil)
+	assert.Equal(t, float64(403), resp["code"])
+
+	// 令牌不能管理令牌
+	resp = env.do(http.MethodGet, "/api/v1/customer/tokens", plain, nil)
+	assert.Equal(t, float64(403), resp["code"])
+
+	// 登录会话不受权限范围限制
+	resp = env.do(http.MethodGet, "/api/v1/customer/tasks", env.jwt, nil)
+	assert.Equal(t, float64(0), resp["code"])
+}
+
+func TestAccessToken_WriteImpliesRead(t *testing.T) {
+	env := setupAccessTokenTestEnv(t)
+	_, plain := env.createToken(t, "machines:write")
+
+	assert.Equal(t, float64(0), env.do(http.MethodGet, "/api/v1/customer/machines", plain, nil)["code"])
+	assert.Equal(t, float64(0), env.do(http.MethodPost, "/api/v1/customer/machines/m1/renew", plain, nil)["code"])
+}
+
+func TestAccessToken_LastUsedAt(t *testing.T) {
+	env := setupAccessTokenTestEnv(t)
+	id, plain := env.createToken(t, "machines:read")
+
+	resp := env.do(http.MethodGet, "/api/v1/customer/machines", plain, nil)
+	require.Equal(t, float64(0), resp["code"])
+
+	var stored entity.PersonalAccessToken
+	require.NoError(t, env.db.First(&stored, id).Error)
+	require.NotNil(t, stored.LastUsedAt)
+	assert.WithinDuration(t, time.Now(), *stored.LastUsedAt, time.Minute)
+}
+
+func TestAccessToken_RevokedAndExpired(t *testing.T) {
+	env := setupAccessTokenTestEnv(t)
+
+	id, plain := env.createToken(t, "machines:read")
+	resp := env.do(http.MethodDelete, fmt.Sprintf("/api/v1/customer/tokens/%d", id), env.jwt, nil)
+	require.Equal(t, float64(0), resp["code"])
+	resp = env.do(http.MethodGet, "/api/v1/customer/machines", plain, nil)
+	assert.Equal(t, float64(401), resp["code"])
+
+	id, plain = env.createToken(t, "machines:read")
+	require.NoError(t, env.db.Model(&entity.PersonalAccessToken{}).Where("id = ?", id).
+		Update("expires_at", time.Now().Add(-time.Hour)).Error)
+	resp = env.do(http.MethodGet, "/api/v1/customer/machines", plain, nil)
+	assert.Equal(t, float64(401), resp["code"])
+
+	// 伪造的令牌
+	resp = env.do(http.MethodGet, "/api/v1/customer/machines", pkgAuth.AccessTokenPrefix+"deadbeef", nil)
+	assert.Equal(t, float64(401), resp["code"])
+}
+
+func TestAccessToken_RevokeOtherCustomer(t *testing.T) {
+	env := setupAccessTokenTestEnv(t)
+	id, _ := env.createToken(t, "machines:read")
+
+	other := &entity.Customer{Username: "other", Email: "other@example.com", Role: "customer_owner", Status: "active"}
+	require.NoError(t, env.db.Create(other).Error)
+	otherJWT, err := pkgAuth.GenerateToken(other.ID, other.Username, other.Role)
+	require.NoError(t, err)
+
+	resp := env.do(http.MethodDelete, fmt.Sprintf("/api/v1/customer/tokens/%d", id), otherJWT, nil)
+	assert.Equal(t, float64(403), resp["code"])
+	resp = env.do(http.MethodDelete, "/api/v1/customer/tokens/999", env.jwt, nil)
+	assert.Equal(t, float64(404), resp["code"])
+}
+
+func TestAccessToken_DisabledCustomer(t *testing.T) {
+	env := setupAccessTokenTestEnv(t)
+	_, plain := env.createToken(t, "machines:read")
+
+	require.NoError(t, env.db.Model(&entity.Customer{}).Where("username = ?", "patuser").Update("status", "disabled").Error)
+	resp := env.do(http.MethodGet, "/api/v1/customer/machines", plain, nil)
+	assert.Equal(t, float64(403), resp["code"])
+}
@@ -1,6 +1,7 @@
 package customer
 
 import (
+	"errors"
 	"strconv"
 
 	apiV1 "github.com/YoungBoyGod/remotegpu/api/v1"
@@ -8,6 +9,7 @@ import (
 	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
 	serviceCustomer "github.com/YoungBoyGod/remotegpu/internal/service/customer"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type CustomerController struct {
@@ -243,6 +245,70 @@ func (c *CustomerController) Enable(ctx *gin.Context) {
 	c.Success(ctx, nil)
 }
 
+// Unlock 解锁客户
+// @Summary 解锁客户
+// @Description 解除连续登录失败导致的账号锁定，清零失败次数
+// @Tags Admin - Customers
+// @Accept json
+// @Produce json
+// @Param id path int true "客户 ID"
+// @Security Bearer
+// @Success 200 {object} common.SuccessResponse
+// @Failure 400 {object} common.ErrorResponse
+// @Failure 404 {object} common.ErrorResponse
+// @Failure 500 {object} common.ErrorResponse
+// @Router /admin/customers/{id}/unlock [post]
+func (c *CustomerController) Unlock(ctx *gin.Context) {
+	idStr := ctx.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		c.Error(ctx, 400, "Invalid customer ID")
+		return
+	}
+
+	if err := c.customerService.Unlock(ctx, uint(id)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.Error(ctx, 404, "Customer not found")
+			return
+		}
+		c.Error(ctx, 500, "Failed to unlock customer")
+		return
+	}
+	c.Success(ctx, nil)
+}
+
+// ResetMFA 重置客户两步验证
+// @Summary 重置客户两步验证
+// @Description 关闭客户的两步验证并清除密钥和恢复码，用于客户丢失验证器的情况
+// @Tags Admin - Customers
+// @Accept json
+// @Produce json
+// @Param id path int true "客户 ID"
+// @Security Bearer
+// @Success 200 {object} common.SuccessResponse
+// @Failure 400 {object} common.ErrorResponse
+// @Failure 404 {object} common.ErrorResponse
+// @Failure 500 {object} common.ErrorResponse
+// @Router /admin/customers/{id}/mfa/reset [post]
+func (c *CustomerController) ResetMFA(ctx *gin.Context) {
+	idStr := ctx.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		c.Error(ctx, 400, "Invalid customer ID")
+		return
+	}
+
+	if err := c.customerService.ResetMFA(ctx, uint(id)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.Error(ctx, 404, "Customer not found")
+			return
+		}
+		c.Error(ctx, 500, "Failed to reset customer mfa")
+		return
+	}
+	c.Success(ctx, nil)
+}
+
 // UpdateQuota 更新客户配额
 // @Summary 更新客户配额
 // @Description 更新指定客户的 GPU 和存储配额
@@ -304,3 +370,54 @@ func (c *CustomerController) ResourceUsage(ctx *gin.Context) {
 	}
 	c.Success(ctx, usage)
 }
+
+// StorageUsage 获取客户存储用量明细
+// @Summary 获取客户存储用量明细
+// @Description 按数据集、上传中预留、文档和工作空间分类统计指定客户的存储用量
+// @Tags Admin - Customers
+// @Produce json
+// @Param id path int true "客户 ID"
+// @Security Bearer
+// @Success 200 {object} serviceCustomer.StorageUsage
+// @Failure 400 {object} common.ErrorResponse
+// @Failure 404 {object} common.ErrorResponse
+// @Router /admin/customers/{id}/storage [get]
+func (c *CustomerController) StorageUsage(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(ctx, 400, "无效的客户 ID")
+		return
+	}
+
+	usage, err := c.customerService.GetStorageUsage(ctx, uint(id))
+	if err != nil {
+		c.Error(ctx, 404, "客户不存在")
+		return
+	}
+	c.Success(ctx, usage)
+}
+
+// MyStorageUsage 获取当前用户的存储用量明细
+// @Summary 获取我的存储用量
+// @Description 按数据集、上传中预留、文档和工作空间分类统计当前用户的存储用量
+// @Tags Customer - Storage
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} serviceCustomer.StorageUsage
+// @Failure 401 {object} common.ErrorResponse
+// @Failure 500 {object} common.ErrorResponse
+// @Router /customer/storage/usage [get]
+func (c *CustomerController) MyStorageUsage(ctx *gin.Context) {
+	userID := ctx.GetUint("userID")
+	if userID == 0 {
+		c.Error(ctx, 401, "用户未认证")
+		return
+	}
+
+	usage, err := c.customerService.GetStorageUsage(ctx, userID)
+	if err != nil {
+		c.Error(ctx, 500, "获取存储用量失败")
+		return
+	}
+	c.Success(ctx, usage)
+}
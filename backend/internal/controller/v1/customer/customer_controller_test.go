@@ -70,9 +70,12 @@ func setupCustomerTestEnv(t *testing.T) *customerTestEnv {
 		customer_id INTEGER NOT NULL,
 		host_id VARCHAR(64) NOT NULL,
 		workspace_id INTEGER,
+		gpu_indexes TEXT,
+		gpu_count INTEGER DEFAULT 0,
 		start_time DATETIME NOT NULL,
 		end_time DATETIME NOT NULL,
 		actual_end_time DATETIME,
+		expiry_warned_at DATETIME,
 		status VARCHAR(32) DEFAULT 'active',
 		remark TEXT,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
@@ -344,7 +347,7 @@ func TestUpdateCustomer_Success(t *testing.T) {
 
 	env.db.Create(&entity.Customer{
 		Username: "update_user", Email: "old@test.com",
-		PasswordHash: "hash", CompanyCode: "C1",
+		PasswordHash: "hash", CompanyCode: "C1", EmailVerified: true,
 	})
 
 	reqBody := apiV1.UpdateCustomerRequest{
@@ -369,6 +372,32 @@ func TestUpdateCustomer_Success(t *testing.T) {
 	env.db.First(&customer, 1)
 	assert.Equal(t, "new@test.com", customer.Email)
 	assert.Equal(t, "新名称", customer.DisplayName)
+	// 邮箱变更后需要重新验证
+	assert.False(t, customer.EmailVerified)
+}
+
+func TestUpdateCustomer_SameEmailKeepsVerified(t *testing.T) {
+	env := setupCustomerTestEnv(t)
+
+	env.db.Create(&entity.Customer{
+		Username: "same_email", Email: "same@test.com",
+		PasswordHash: "hash", CompanyCode: "C1", EmailVerified: true,
+	})
+
+	body, _ := json.Marshal(apiV1.UpdateCustomerRequest{Email: "same@test.com", DisplayName: "新名称"})
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/admin/customers/1", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	env.router.ServeHTTP(w, req)
+
+	var resp map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, float64(0), resp["code"])
+
+	var customer entity.Customer
+	env.db.First(&customer, 1)
+	assert.True(t, customer.EmailVerified)
 }
 
 func TestUpdateCustomer_NoFields(t *testing.T) {
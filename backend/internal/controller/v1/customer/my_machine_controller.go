@@ -3,10 +3,12 @@ package customer
 import (
 	"strconv"
 
+	apiV1 "github.com/YoungBoyGod/remotegpu/api/v1"
 	"github.com/YoungBoyGod/remotegpu/internal/controller/v1/common"
 	serviceAllocation "github.com/YoungBoyGod/remotegpu/internal/service/allocation"
 	serviceMachine "github.com/YoungBoyGod/remotegpu/internal/service/machine"
 	serviceOps "github.com/YoungBoyGod/remotegpu/internal/service/ops"
+	"github.com/YoungBoyGod/remotegpu/pkg/errors"
 	"github.com/gin-gonic/gin"
 )
 
@@ -71,6 +73,9 @@ func (c *MyMachineController) List(ctx *gin.Context) {
 			"allocation_status": alloc.Host.AllocationStatus,
 			"total_cpu":         alloc.Host.TotalCPU,
 			"total_memory_gb":   alloc.Host.TotalMemoryGB,
+			"allocation_id":     alloc.ID,
+			"gpu_indexes":       alloc.GPUIndexes, // 为空表示整机分配
+			"gpu_count":         alloc.GPUCount,
 			"start_time":        alloc.StartTime,
 			"end_time":          alloc.EndTime,
 		})
@@ -86,7 +91,7 @@ func (c *MyMachineController) List(ctx *gin.Context) {
 
 // GetConnection 获取机器连接信息
 // @Summary 获取机器连接信息
-// @Description 获取指定机器的 SSH/Jupyter/VNC 等连接信息
+// @Description 获取指定机器的 SSH/Jupyter/VNC 等连接信息；GPU 粒度分配时只返回客户独立账号的 SSH 信息
 // @Tags Customer - Machines
 // @Produce json
 // @Param id path string true "机器 ID"
@@ -109,7 +114,18 @@ func (c *MyMachineController) GetConnection(ctx *gin.Context) {
 		return
 	}
 
-	info, err := c.machineService.GetConnectionInfo(ctx, hostID)
+	// GPU 粒度分配的客户使用独立账号登录，不能拿到机器共享账号的凭证
+	tenantUser, err := c.allocationService.HostTenantUsername(ctx, hostID, userID)
+	if err != nil {
+		c.Error(ctx, 500, "Failed to get connection info")
+		return
+	}
+	var info map[string]interface{}
+	if tenantUser != "" {
+		info, err = c.machineService.GetTenantConnectionInfo(ctx, hostID, tenantUser)
+	} else {
+		info, err = c.machineService.GetConnectionInfo(ctx, hostID)
+	}
 	if err != nil {
 		c.Error(ctx, 500, "Failed to get connection info")
 		return
@@ -147,4 +163,59 @@ func (c *MyMachineController) ResetSSH(ctx *gin.Context) {
 		return
 	}
 	c.Success(ctx, gin.H{"message": "SSH reset triggered"})
-}
\ No newline at end of file
+}
+
+// Renew 续期机器租约
+// @Summary 续期我的机器
+// @Description 将当前用户在该机器上的活跃分配到期时间顺延指定月数
+// @Tags Customer - Machines
+// @Accept json
+// @Produce json
+// @Param id path string true "机器 ID"
+// @Param request body v1.RenewAllocationRequest true "续期请求"
+// @Security Bearer
+// @Success 200 {object} entity.Allocation
+// @Failure 400 {object} common.ErrorResponse
+// @Failure 401 {object} common.ErrorResponse
+// @Failure 403 {object} common.ErrorResponse
+// @Failure 409 {object} common.ErrorResponse
+// @Failure 500 {object} common.ErrorResponse
+// @Router /customer/machines/{id}/renew [post]
+func (c *MyMachineController) Renew(ctx *gin.Context) {
+	userID := ctx.GetUint("userID")
+	if userID == 0 {
+		c.Error(ctx, 401, "用户未认证")
+		return
+	}
+
+	var req apiV1.RenewAllocationRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		c.Error(ctx, 400, "Invalid request parameters")
+		return
+	}
+
+	alloc, err := c.allocationService.RenewByHost(ctx, ctx.Param("id"), userID, req.Months, req.Remark, serviceAllocation.RenewOperator{
+		ID:       userID,
+		Username: ctx.GetString("username"),
+		Role:     serviceAllocation.RenewRoleCustomer,
+		IP:       ctx.ClientIP(),
+	})
+	if err != nil {
+		if appErr := errors.GetAppError(err); appErr != nil {
+			switch appErr.Code {
+			case errors.ErrorForbidden:
+				c.Error(ctx, 403, "无权访问该机器")
+				return
+			case errors.ErrorInvalidParams:
+				c.Error(ctx, 400, appErr.Message)
+				return
+			case errors.ErrorMachineNotAvailable:
+				c.Error(ctx, 409, appErr.Message)
+				return
+			}
+		}
+		c.Error(ctx, 500, "Failed to renew machine")
+		return
+	}
+	c.Success(ctx, alloc)
+}
@@ -1,29 +1,32 @@
 package dataset
 
 import (
+	"errors"
 	"strconv"
 
 	apiV1 "github.com/YoungBoyGod/remotegpu/api/v1"
 	"github.com/YoungBoyGod/remotegpu/internal/controller/v1/common"
+	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
 	serviceAllocation "github.com/YoungBoyGod/remotegpu/internal/service/allocation"
+	serviceCustomer "github.com/YoungBoyGod/remotegpu/internal/service/customer"
 	serviceDataset "github.com/YoungBoyGod/remotegpu/internal/service/dataset"
 	serviceOps "github.com/YoungBoyGod/remotegpu/internal/service/ops"
-	serviceStorage "github.com/YoungBoyGod/remotegpu/internal/service/storage"
+	pkgStorage "github.com/YoungBoyGod/remotegpu/pkg/storage"
 	"github.com/gin-gonic/gin"
 )
 
 type DatasetController struct {
 	common.BaseController
 	datasetService    *serviceDataset.DatasetService
-	storageService    *serviceStorage.StorageService
+	uploadService     *serviceDataset.UploadService
 	agentService      *serviceOps.AgentService
 	allocationService *serviceAllocation.AllocationService
 }
 
-func NewDatasetController(ds *serviceDataset.DatasetService, ss *serviceStorage.StorageService, as *serviceOps.AgentService, alloc *serviceAllocation.AllocationService) *DatasetController {
+func NewDatasetController(ds *serviceDataset.DatasetService, us *serviceDataset.UploadService, as *serviceOps.AgentService, alloc *serviceAllocation.AllocationService) *DatasetController {
 	return &DatasetController{
 		datasetService:    ds,
-		storageService:    ss,
+		uploadService:     us,
 		agentService:      as,
 		allocationService: alloc,
 	}
@@ -72,92 +75,269 @@ func (c *DatasetController) List(ctx *gin.Context) {
 
 // InitUpload 初始化分片上传
 // @Summary 初始化分片上传
-// @Description 初始化数据集文件的分片上传，返回 upload_id 和分片大小
+// @Description 创建上传中的数据集并初始化分片上传，返回 upload_id、分片大小和分片数量
 // @Tags Customer - Datasets
 // @Accept json
 // @Produce json
 // @Param request body v1.InitMultipartRequest true "初始化上传请求"
 // @Security Bearer
-// @Success 200 {object} map[string]interface{}
+// @Success 200 {object} serviceDataset.UploadSession
 // @Failure 400 {object} common.ErrorResponse
+// @Failure 401 {object} common.ErrorResponse
+// @Failure 403 {object} common.ErrorResponse
 // @Failure 500 {object} common.ErrorResponse
 // @Router /customer/datasets/init-multipart [post]
 func (c *DatasetController) InitUpload(ctx *gin.Context) {
+	userID := ctx.GetUint("userID")
+	if userID == 0 {
+		c.Error(ctx, 401, "用户未认证")
+		return
+	}
+
 	var req apiV1.InitMultipartRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		c.Error(ctx, 400, err.Error())
 		return
 	}
 
-	// Bucket name strategy could be tenant specific
-	uploadID, err := c.storageService.InitMultipart(ctx, "datasets", req.Filename)
+	session, err := c.uploadService.InitUpload(ctx, userID, req.WorkspaceID, req.Name, req.Description, req.Filename, req.Size)
 	if err != nil {
-		c.Error(ctx, 500, "Failed to init upload")
+		c.uploadError(ctx, err, "初始化上传失败")
 		return
 	}
 
-	c.Success(ctx, gin.H{
-		"upload_id":  uploadID,
-		"chunk_size": 5 * 1024 * 1024,
-	})
+	c.Success(ctx, session)
+}
+
+// PartURLs 获取分片上传地址
+// @Summary 获取分片上传地址
+// @Description 为指定分片生成上传地址。存储后端支持预签名时返回预签名地址，否则返回平台代理上传接口
+// @Tags Customer - Datasets
+// @Accept json
+// @Produce json
+// @Param id path int true "数据集 ID"
+// @Param upload_id path string true "上传 ID"
+// @Param request body v1.PartURLsRequest true "分片编号"
+// @Security Bearer
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} common.ErrorResponse
+// @Failure 403 {object} common.ErrorResponse
+// @Failure 404 {object} common.ErrorResponse
+// @Failure 409 {object} common.ErrorResponse
+// @Router /customer/datasets/{id}/uploads/{upload_id}/part-urls [post]
+func (c *DatasetController) PartURLs(ctx *gin.Context) {
+	datasetID, ok := c.ownedDataset(ctx)
+	if !ok {
+		return
+	}
+
+	var req apiV1.PartURLsRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		c.Error(ctx, 400, err.Error())
+		return
+	}
+
+	urls, err := c.uploadService.PartURLs(ctx, datasetID, ctx.Param("upload_id"), req.PartNumbers)
+	if err != nil {
+		c.uploadError(ctx, err, "生成分片上传地址失败")
+		return
+	}
+
+	c.Success(ctx, gin.H{"parts": urls})
+}
+
+// UploadPart 代理上传分片
+// @Summary 代理上传分片
+// @Description 存储后端不支持预签名时，由平台接收分片内容并写入存储，请求体为分片原始内容
+// @Tags Customer - Datasets
+// @Accept application/octet-stream
+// @Produce json
+// @Param id path int true "数据集 ID"
+// @Param upload_id path string true "上传 ID"
+// @Param part_number path int true "分片编号"
+// @Security Bearer
+// @Success 200 {object} storage.Part
+// @Failure 400 {object} common.ErrorResponse
+// @Failure 403 {object} common.ErrorResponse
+// @Failure 404 {object} common.ErrorResponse
+// @Failure 409 {object} common.ErrorResponse
+// @Router /customer/datasets/{id}/uploads/{upload_id}/parts/{part_number} [put]
+func (c *DatasetController) UploadPart(ctx *gin.Context) {
+	datasetID, ok := c.ownedDataset(ctx)
+	if !ok {
+		return
+	}
+
+	partNumber, err := strconv.Atoi(ctx.Param("part_number"))
+	if err != nil {
+		c.Error(ctx, 400, "无效的分片编号")
+		return
+	}
+	if ctx.Request.ContentLength <= 0 {
+		c.Error(ctx, 400, "缺少 Content-Length")
+		return
+	}
+
+	part, err := c.uploadService.UploadPart(ctx, datasetID, ctx.Param("upload_id"), partNumber, ctx.Request.Body, ctx.Request.ContentLength)
+	if err != nil {
+		c.uploadError(ctx, err, "上传分片失败")
+		return
+	}
+
+	ctx.Header("ETag", `"`+part.ETag+`"`)
+	c.Success(ctx, part)
+}
+
+// ListParts 列出已上传的分片
+// @Summary 列出已上传的分片
+// @Description 列出已上传的分片，用于断点续传
+// @Tags Customer - Datasets
+// @Produce json
+// @Param id path int true "数据集 ID"
+// @Param upload_id path string true "上传 ID"
+// @Security Bearer
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} common.ErrorResponse
+// @Failure 404 {object} common.ErrorResponse
+// @Failure 409 {object} common.ErrorResponse
+// @Router /customer/datasets/{id}/uploads/{upload_id}/parts [get]
+func (c *DatasetController) ListParts(ctx *gin.Context) {
+	datasetID, ok := c.ownedDataset(ctx)
+	if !ok {
+		return
+	}
+
+	parts, err := c.uploadService.ListParts(ctx, datasetID, ctx.Param("upload_id"))
+	if err != nil {
+		c.uploadError(ctx, err, "获取分片列表失败")
+		return
+	}
+
+	c.Success(ctx, gin.H{"parts": parts})
 }
 
 // CompleteUpload 完成分片上传
 // @Summary 完成分片上传
-// @Description 完成数据集文件的分片上传，合并所有分片
+// @Description 校验分片 ETag 后合并所有分片，数据集状态变为 ready
 // @Tags Customer - Datasets
 // @Accept json
 // @Produce json
 // @Param id path int true "数据集 ID"
 // @Param request body v1.CompleteMultipartRequest true "完成上传请求"
 // @Security Bearer
-// @Success 200 {object} map[string]string
+// @Success 200 {object} entity.Dataset
 // @Failure 400 {object} common.ErrorResponse
 // @Failure 403 {object} common.ErrorResponse
 // @Failure 404 {object} common.ErrorResponse
+// @Failure 409 {object} common.ErrorResponse
 // @Failure 500 {object} common.ErrorResponse
 // @Router /customer/datasets/{id}/complete [post]
 func (c *DatasetController) CompleteUpload(ctx *gin.Context) {
+	datasetID, ok := c.ownedDataset(ctx)
+	if !ok {
+		return
+	}
+
+	var req apiV1.CompleteMultipartRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		c.Error(ctx, 400, err.Error())
+		return
+	}
+
+	parts := make([]pkgStorage.Part, 0, len(req.Parts))
+	for _, p := range req.Parts {
+		parts = append(parts, pkgStorage.Part{PartNumber: p.PartNumber, ETag: p.ETag})
+	}
+
+	dataset, err := c.uploadService.CompleteUpload(ctx, datasetID, req.UploadID, req.Name, parts)
+	if err != nil {
+		c.uploadError(ctx, err, "完成上传失败")
+		return
+	}
+
+	c.Success(ctx, dataset)
+}
+
+// AbortUpload 中止分片上传
+// @Summary 中止分片上传
+// @Description 中止分片上传并清理已上传的分片，数据集状态变为 aborted
+// @Tags Customer - Datasets
+// @Produce json
+// @Param id path int true "数据集 ID"
+// @Param upload_id path string true "上传 ID"
+// @Security Bearer
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} common.ErrorResponse
+// @Failure 404 {object} common.ErrorResponse
+// @Failure 409 {object} common.ErrorResponse
+// @Router /customer/datasets/{id}/uploads/{upload_id} [delete]
+func (c *DatasetController) AbortUpload(ctx *gin.Context) {
+	datasetID, ok := c.ownedDataset(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.uploadService.AbortUpload(ctx, datasetID, ctx.Param("upload_id")); err != nil {
+		c.uploadError(ctx, err, "中止上传失败")
+		return
+	}
+
+	c.Success(ctx, gin.H{"message": "上传已中止"})
+}
+
+// ownedDataset 解析路径中的数据集 ID 并校验归属，失败时已写入响应
+func (c *DatasetController) ownedDataset(ctx *gin.Context) (uint, bool) {
 	userID := ctx.GetUint("userID")
 	if userID == 0 {
 		c.Error(ctx, 401, "用户未认证")
-		return
+		return 0, false
 	}
 
-	idStr := ctx.Param("id")
-	datasetID, err := strconv.ParseUint(idStr, 10, 64)
+	datasetID, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
 	if err != nil {
 		c.Error(ctx, 400, "无效的数据集 ID")
-		return
+		return 0, false
 	}
 
-	// 验证数据集所有权
 	if err := c.datasetService.ValidateOwnership(ctx, uint(datasetID), userID); err != nil {
-		if err.Error() == "无权限访问该资源" {
+		if errors.Is(err, entity.ErrUnauthorized) {
 			c.Error(ctx, 403, "无权限操作该数据集")
-			return
+			return 0, false
 		}
 		c.Error(ctx, 404, "数据集不存在")
-		return
+		return 0, false
 	}
+	return uint(datasetID), true
+}
 
-	var req apiV1.CompleteMultipartRequest
-	if err := ctx.ShouldBindJSON(&req); err != nil {
+// uploadError 将分片上传错误映射为响应
+func (c *DatasetController) uploadError(ctx *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, serviceDataset.ErrInvalidFilename),
+		errors.Is(err, serviceDataset.ErrInvalidUploadSize),
+		errors.Is(err, serviceDataset.ErrInvalidPartNumber),
+		errors.Is(err, serviceDataset.ErrPartMismatch),
+		errors.Is(err, pkgStorage.ErrInvalidPart):
 		c.Error(ctx, 400, err.Error())
-		return
+	case errors.Is(err, serviceCustomer.ErrQuotaExceeded),
+		errors.Is(err, serviceCustomer.ErrWorkspaceQuotaExceeded),
+		errors.Is(err, serviceDataset.ErrWorkspaceForbidden):
+		c.Error(ctx, 403, err.Error())
+	case errors.Is(err, serviceDataset.ErrUploadNotActive),
+		errors.Is(err, serviceDataset.ErrUploadExpired),
+		errors.Is(err, pkgStorage.ErrUploadNotFound):
+		c.Error(ctx, 409, err.Error())
+	case errors.Is(err, serviceDataset.ErrMultipartDisabled),
+		errors.Is(err, serviceDataset.ErrStorageUnavailable):
+		c.Error(ctx, 503, err.Error())
+	default:
+		c.Error(ctx, 500, fallback)
 	}
-
-	if err := c.datasetService.CompleteUpload(ctx, uint(datasetID), req.Name, req.Size); err != nil {
-		c.Error(ctx, 500, "完成上传失败")
-		return
-	}
-
-	c.Success(ctx, gin.H{"message": "上传完成"})
 }
 
 // Mount 挂载数据集到机器
 // @Summary 挂载数据集
-// @Description 将数据集挂载到指定机器的指定路径，异步发送挂载命令到 Agent
+// @Description 将数据集挂载到指定机器的指定路径，由 Agent 执行挂载并返回挂载结果
 // @Tags Customer - Datasets
 // @Accept json
 // @Produce json
@@ -223,13 +403,25 @@ func (c *DatasetController) Mount(ctx *gin.Context) {
 		return
 	}
 
-	// 异步发送挂载命令到 Agent
-	if err := c.agentService.MountDataset(ctx, req.MachineID, uint(datasetID), req.MountPoint); err != nil {
+	dataset, err := c.datasetService.GetDataset(ctx, uint(datasetID))
+	if err != nil {
 		c.Error(ctx, 500, "挂载数据集失败")
 		return
 	}
+	sourceType, sourcePath := serviceDataset.MountSource(dataset)
 
-	c.Success(ctx, gin.H{"message": "挂载命令已发送", "mount_id": mount.ID})
+	// 由 Agent 执行挂载，并按执行结果更新挂载状态
+	if err := c.agentService.MountDataset(ctx, req.MachineID, uint(datasetID), sourceType, sourcePath, mount.MountPath, mount.ReadOnly); err != nil {
+		_ = c.datasetService.UpdateMountStatus(ctx, mount.ID, "error", err.Error())
+		c.Error(ctx, 500, "挂载数据集失败")
+		return
+	}
+	if err := c.datasetService.UpdateMountStatus(ctx, mount.ID, "mounted", ""); err != nil {
+		c.Error(ctx, 500, "更新挂载状态失败")
+		return
+	}
+
+	c.Success(ctx, gin.H{"message": "挂载成功", "mount_id": mount.ID, "status": "mounted"})
 }
 
 // Unmount 卸载数据集
@@ -272,6 +464,24 @@ func (c *DatasetController) Unmount(ctx *gin.Context) {
 		return
 	}
 
+	mount, err := c.datasetService.GetMount(ctx, uint(datasetID), uint(mountID))
+	if err != nil {
+		if errors.Is(err, serviceDataset.ErrMountNotFound) {
+			c.Error(ctx, 404, err.Error())
+			return
+		}
+		c.Error(ctx, 500, "查询挂载记录失败")
+		return
+	}
+
+	// 仅对可能已挂载的记录下发卸载命令
+	if mount.Status == "mounted" || mount.Status == "mounting" {
+		if err := c.agentService.UnmountDataset(ctx, mount.HostID, mount.DatasetID, mount.MountPath); err != nil {
+			c.Error(ctx, 500, "卸载数据集失败")
+			return
+		}
+	}
+
 	if err := c.datasetService.UnmountDataset(ctx, uint(mountID)); err != nil {
 		c.Error(ctx, 500, err.Error())
 		return
@@ -1,6 +1,7 @@
 package environment
 
 import (
+	"errors"
 	"strconv"
 
 	apiV1 "github.com/YoungBoyGod/remotegpu/api/v1"
@@ -32,7 +33,7 @@ func (c *EnvironmentController) getCustomerID(ctx *gin.Context) (uint, bool) {
 
 // Create 创建环境
 // @Summary 创建开发环境
-// @Description 用户创建新的容器化开发环境
+// @Description 用户在已分配的主机上创建容器化开发环境，由 Agent 拉起容器
 // @Tags Customer - Environments
 // @Accept json
 // @Produce json
@@ -58,6 +59,7 @@ func (c *EnvironmentController) Create(ctx *gin.Context) {
 	env := &entity.Environment{
 		UserID:      customerID,
 		WorkspaceID: req.WorkspaceID,
+		HostID:      req.HostID,
 		Name:        req.Name,
 		Description: req.Description,
 		Image:       req.Image,
@@ -68,7 +70,11 @@ func (c *EnvironmentController) Create(ctx *gin.Context) {
 		Status:      "creating",
 	}
 
-	if err := c.environmentService.Create(ctx, env); err != nil {
+	if err := c.environmentService.Create(ctx, env, req.Env); err != nil {
+		if errors.Is(err, serviceEnvironment.ErrInvalidImage) {
+			c.Error(ctx, 400, err.Error())
+			return
+		}
 		c.Error(ctx, 500, "创建环境失败: "+err.Error())
 		return
 	}
@@ -8,6 +8,7 @@ import (
 	"github.com/YoungBoyGod/remotegpu/internal/controller/v1/common"
 	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
 	serviceAllocation "github.com/YoungBoyGod/remotegpu/internal/service/allocation"
+	serviceCredential "github.com/YoungBoyGod/remotegpu/internal/service/credential"
 	serviceMachine "github.com/YoungBoyGod/remotegpu/internal/service/machine"
 	serviceOps "github.com/YoungBoyGod/remotegpu/internal/service/ops"
 	"github.com/gin-gonic/gin"
@@ -18,6 +19,7 @@ type MachineController struct {
 	machineService    *serviceMachine.MachineService
 	allocationService *serviceAllocation.AllocationService
 	agentService      *serviceOps.AgentService
+	credentialService *serviceCredential.AgentCredentialService
 }
 
 func NewMachineController(ms *serviceMachine.MachineService, as *serviceAllocation.AllocationService, agentSvc *serviceOps.AgentService) *MachineController {
@@ -28,6 +30,11 @@ func NewMachineController(ms *serviceMachine.MachineService, as *serviceAllocati
 	}
 }
 
+// SetCredentialService 设置 Agent 凭证服务，创建机器时自动签发注册令牌
+func (c *MachineController) SetCredentialService(svc *serviceCredential.AgentCredentialService) {
+	c.credentialService = svc
+}
+
 // List 获取机器列表
 // @Summary 获取机器列表
 // @Description 获取所有机器的列表，支持分页和筛选
@@ -175,7 +182,21 @@ func (c *MachineController) Create(ctx *gin.Context) {
 		return
 	}
 
-	c.Success(ctx, host)
+	// 签发一次性 Agent 注册令牌，明文只在此返回一次
+	result := struct {
+		entity.Host
+		AgentEnrollmentToken string `json:"agent_enrollment_token,omitempty"`
+	}{Host: host}
+	if c.credentialService != nil {
+		token, _, err := c.credentialService.IssueEnrollmentToken(ctx, host.ID)
+		if err != nil {
+			c.Error(ctx, 500, "Failed to issue agent enrollment token")
+			return
+		}
+		result.AgentEnrollmentToken = token
+	}
+
+	c.Success(ctx, result)
 }
 
 // CollectSpec 触发采集机器硬件信息
@@ -261,7 +282,7 @@ func applySystemInfo(host *entity.Host, info *serviceOps.SystemInfoSnapshot) {
 
 // Allocate 分配机器
 // @Summary 分配机器
-// @Description 将机器分配给客户
+// @Description 将机器分配给客户，指定 gpu_indexes 或 gpu_uuids 时只分配这些 GPU，同一台机器可分配给多个客户
 // @Tags Admin - Machines
 // @Accept json
 // @Produce json
@@ -288,7 +309,15 @@ func (c *MachineController) Allocate(ctx *gin.Context) {
 		return
 	}
 
-	alloc, err := c.allocationService.AllocateMachine(ctx, req.CustomerID, hostID, req.DurationMonths, req.Remark)
+	var (
+		alloc *entity.Allocation
+		err   error
+	)
+	if len(req.GPUIndexes) > 0 || len(req.GPUUUIDs) > 0 {
+		alloc, err = c.allocationService.AllocateGPUs(ctx, req.CustomerID, hostID, req.GPUIndexes, req.GPUUUIDs, req.DurationMonths, req.Remark)
+	} else {
+		alloc, err = c.allocationService.AllocateMachine(ctx, req.CustomerID, hostID, req.DurationMonths, req.Remark)
+	}
 	if err != nil {
 		c.Error(ctx, 500, err.Error())
 		return
@@ -495,7 +524,7 @@ func (c *MachineController) Delete(ctx *gin.Context) {
 		c.Error(ctx, 404, "Machine not found")
 		return
 	}
-	if host.AllocationStatus == "allocated" {
+	if host.AllocationStatus == "allocated" || host.AllocationStatus == "partial" {
 		c.Error(ctx, 400, "无法删除已分配的机器，请先回收后再删除")
 		return
 	}
@@ -659,3 +688,71 @@ func (c *MachineController) BatchReclaim(ctx *gin.Context) {
 
 	c.Success(ctx, result)
 }
+
+// GetAgentCredential 查询机器的 Agent 凭证状态
+// @Summary 查询 Agent 凭证状态
+// @Description 查询机器级 Agent 凭证的状态、绑定的 Agent 与最近使用时间（不返回密钥）
+// @Tags Admin - Machines
+// @Param id path string true "机器ID"
+// @Security Bearer
+// @Success 200 {object} entity.AgentCredential
+// @Failure 404 {object} common.ErrorResponse
+// @Router /admin/machines/{id}/agent-credential [get]
+func (c *MachineController) GetAgentCredential(ctx *gin.Context) {
+	if c.credentialService == nil {
+		c.Error(ctx, 500, "Agent credential service not available")
+		return
+	}
+	cred, err := c.credentialService.GetCredential(ctx, ctx.Param("id"))
+	if err != nil {
+		c.Error(ctx, 404, "Agent credential not found")
+		return
+	}
+	c.Success(ctx, cred)
+}
+
+// IssueAgentEnrollmentToken 签发或轮换 Agent 注册令牌
+// @Summary 签发 Agent 注册令牌
+// @Description 为机器签发一次性注册令牌，Agent 使用该令牌注册后换取新的机器密钥，原密钥随之失效
+// @Tags Admin - Machines
+// @Param id path string true "机器ID"
+// @Security Bearer
+// @Success 200 {object} common.SuccessResponse
+// @Failure 404 {object} common.ErrorResponse
+// @Router /admin/machines/{id}/agent-credential/rotate [post]
+func (c *MachineController) IssueAgentEnrollmentToken(ctx *gin.Context) {
+	if c.credentialService == nil {
+		c.Error(ctx, 500, "Agent credential service not available")
+		return
+	}
+	token, cred, err := c.credentialService.IssueEnrollmentToken(ctx, ctx.Param("id"))
+	if err != nil {
+		c.Error(ctx, 404, err.Error())
+		return
+	}
+	c.Success(ctx, gin.H{
+		"enrollment_token": token,
+		"expires_at":       cred.EnrollmentExpiresAt,
+	})
+}
+
+// RevokeAgentCredential 吊销机器的 Agent 凭证
+// @Summary 吊销 Agent 凭证
+// @Description 立即吊销机器密钥和未使用的注册令牌，Agent 需重新签发令牌后才能接入
+// @Tags Admin - Machines
+// @Param id path string true "机器ID"
+// @Security Bearer
+// @Success 200 {object} common.SuccessResponse
+// @Failure 404 {object} common.ErrorResponse
+// @Router /admin/machines/{id}/agent-credential [delete]
+func (c *MachineController) RevokeAgentCredential(ctx *gin.Context) {
+	if c.credentialService == nil {
+		c.Error(ctx, 500, "Agent credential service not available")
+		return
+	}
+	if err := c.credentialService.Revoke(ctx, ctx.Param("id")); err != nil {
+		c.Error(ctx, 404, "Agent credential not found")
+		return
+	}
+	c.Success(ctx, gin.H{"status": "revoked"})
+}
@@ -103,9 +103,12 @@ func setupMachineTestEnv(t *testing.T) *machineTestEnv {
 		customer_id INTEGER NOT NULL,
 		host_id VARCHAR(64) NOT NULL,
 		workspace_id INTEGER,
+		gpu_indexes TEXT,
+		gpu_count INTEGER DEFAULT 0,
 		start_time DATETIME NOT NULL,
 		end_time DATETIME NOT NULL,
 		actual_end_time DATETIME,
+		expiry_warned_at DATETIME,
 		status VARCHAR(32) DEFAULT 'active',
 		remark TEXT,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
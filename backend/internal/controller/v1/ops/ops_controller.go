@@ -128,6 +128,7 @@ func NewAlertController(os *serviceOps.OpsService) *AlertController {
 // @Param page query int false "页码" default(1)
 // @Param page_size query int false "每页数量" default(10)
 // @Param severity query string false "严重程度筛选"
+// @Param status query string false "告警状态：firing（默认）、resolved、all"
 // @Param acknowledged query boolean false "是否已确认"
 // @Security Bearer
 // @Success 200 {object} map[string]interface{}
@@ -137,6 +138,16 @@ func (c *AlertController) List(ctx *gin.Context) {
 	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(ctx.DefaultQuery("page_size", "10"))
 	severity := ctx.Query("severity")
+	// 默认只返回触发中的告警，已恢复的告警需显式查询
+	status := ctx.DefaultQuery("status", "firing")
+	switch status {
+	case "firing", "resolved":
+	case "all":
+		status = ""
+	default:
+		c.Error(ctx, 400, "无效的告警状态")
+		return
+	}
 
 	var acknowledged *bool
 	if ackStr := ctx.Query("acknowledged"); ackStr != "" {
@@ -144,7 +155,7 @@ func (c *AlertController) List(ctx *gin.Context) {
 		acknowledged = &ack
 	}
 
-	alerts, total, err := c.opsService.ListAlerts(ctx, page, pageSize, severity, acknowledged)
+	alerts, total, err := c.opsService.ListAlerts(ctx, page, pageSize, severity, status, acknowledged)
 	if err != nil {
 		c.Error(ctx, 500, "获取告警列表失败")
 		return
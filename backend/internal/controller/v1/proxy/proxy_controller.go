@@ -1,10 +1,14 @@
 package proxy
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/gin-gonic/gin"
 
 	"github.com/YoungBoyGod/remotegpu/internal/controller/v1/common"
 	serviceProxy "github.com/YoungBoyGod/remotegpu/internal/service/proxy"
+	"github.com/YoungBoyGod/remotegpu/pkg/logger"
 )
 
 // ProxyController Proxy 管理控制器
@@ -30,6 +34,14 @@ func (c *ProxyController) Register(ctx *gin.Context) {
 		c.Error(ctx, 500, err.Error())
 		return
 	}
+
+	// Proxy 重启后重新注册，异步下发期望映射以恢复转发
+	go func(id string) {
+		if _, err := c.proxySvc.ReconcileNode(context.Background(), id); err != nil {
+			logger.GetLogger().Warn(fmt.Sprintf("Proxy %s 注册后对账失败: %v", id, err))
+		}
+	}(node.ID)
+
 	c.Success(ctx, node)
 }
 
@@ -79,6 +91,17 @@ func (c *ProxyController) DeleteNode(ctx *gin.Context) {
 	c.Success(ctx, gin.H{"status": "deleted"})
 }
 
+// ReconcileNode 手动触发 Proxy 映射对账（Admin）
+func (c *ProxyController) ReconcileNode(ctx *gin.Context) {
+	id := ctx.Param("id")
+	result, err := c.proxySvc.ReconcileNode(ctx, id)
+	if err != nil {
+		c.Error(ctx, 500, err.Error())
+		return
+	}
+	c.Success(ctx, result)
+}
+
 // ListMappings 列出所有端口映射（Admin）
 func (c *ProxyController) ListMappings(ctx *gin.Context) {
 	mappings, err := c.proxySvc.ListMappings(ctx)
@@ -0,0 +1,100 @@
+package reservation
+
+import (
+	"strconv"
+
+	apiV1 "github.com/YoungBoyGod/remotegpu/api/v1"
+	"github.com/YoungBoyGod/remotegpu/internal/controller/v1/common"
+	serviceReservation "github.com/YoungBoyGod/remotegpu/internal/service/reservation"
+	"github.com/gin-gonic/gin"
+)
+
+// AdminReservationController 管理员机器预约控制器
+type AdminReservationController struct {
+	common.BaseController
+	reservationService *serviceReservation.ReservationService
+}
+
+func NewAdminReservationController(rs *serviceReservation.ReservationService) *AdminReservationController {
+	return &AdminReservationController{reservationService: rs}
+}
+
+// List 管理员查询预约
+// @Summary 管理员获取预约列表
+// @Tags Admin - Reservations
+// @Produce json
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(10)
+// @Param customer_id query int false "客户ID筛选"
+// @Param status query string false "状态筛选"
+// @Security Bearer
+// @Success 200 {object} map[string]interface{}
+// @Failure 500 {object} common.ErrorResponse
+// @Router /admin/reservations [get]
+func (c *AdminReservationController) List(ctx *gin.Context) {
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(ctx.DefaultQuery("page_size", "10"))
+
+	filters := make(map[string]interface{})
+	if customerID := ctx.Query("customer_id"); customerID != "" {
+		if id, err := strconv.ParseUint(customerID, 10, 64); err == nil {
+			filters["customer_id"] = uint(id)
+		}
+	}
+	if status := ctx.Query("status"); status != "" {
+		filters["status"] = status
+	}
+
+	reservations, total, err := c.reservationService.ListReservations(ctx, page, pageSize, filters)
+	if err != nil {
+		c.Error(ctx, 500, "获取预约列表失败")
+		return
+	}
+	c.Success(ctx, gin.H{
+		"list":      reservations,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// Detail 管理员查看预约详情
+// @Summary 管理员获取预约详情
+// @Tags Admin - Reservations
+// @Produce json
+// @Param id path string true "预约 ID"
+// @Security Bearer
+// @Success 200 {object} entity.Reservation
+// @Failure 404 {object} common.ErrorResponse
+// @Router /admin/reservations/{id} [get]
+func (c *AdminReservationController) Detail(ctx *gin.Context) {
+	r, err := c.reservationService.GetReservation(ctx, ctx.Param("id"), nil)
+	if err != nil {
+		reservationError(&c.BaseController, ctx, err)
+		return
+	}
+	c.Success(ctx, r)
+}
+
+// Cancel 管理员取消预约
+// @Summary 管理员取消预约
+// @Tags Admin - Reservations
+// @Accept json
+// @Produce json
+// @Param id path string true "预约 ID"
+// @Param request body v1.CancelReservationRequest false "取消原因"
+// @Security Bearer
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} common.ErrorResponse
+// @Failure 409 {object} common.ErrorResponse
+// @Router /admin/reservations/{id}/cancel [post]
+func (c *AdminReservationController) Cancel(ctx *gin.Context) {
+	var req apiV1.CancelReservationRequest
+	_ = ctx.ShouldBindJSON(&req)
+
+	if err := c.reservationService.CancelReservation(ctx, ctx.Param("id"), nil, req.Reason); err != nil {
+		reservationError(&c.BaseController, ctx, err)
+		return
+	}
+	c.Success(ctx, gin.H{"message": "预约已取消"})
+}
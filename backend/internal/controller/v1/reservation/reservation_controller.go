@@ -0,0 +1,143 @@
+package reservation
+
+import (
+	"errors"
+	"strconv"
+
+	apiV1 "github.com/YoungBoyGod/remotegpu/api/v1"
+	"github.com/YoungBoyGod/remotegpu/internal/controller/v1/common"
+	serviceReservation "github.com/YoungBoyGod/remotegpu/internal/service/reservation"
+	"github.com/gin-gonic/gin"
+)
+
+// ReservationController 客户机器预约控制器
+type ReservationController struct {
+	common.BaseController
+	reservationService *serviceReservation.ReservationService
+}
+
+func NewReservationController(rs *serviceReservation.ReservationService) *ReservationController {
+	return &ReservationController{reservationService: rs}
+}
+
+// Create 创建机器预约
+// @Summary 创建机器预约
+// @Description 按 GPU 型号和数量预约时间窗口；有空闲机器时锁定机器（pending），否则排队（queued），时间窗口开始时自动分配
+// @Tags Customer - Reservations
+// @Accept json
+// @Produce json
+// @Param request body v1.CreateReservationRequest true "预约请求"
+// @Security Bearer
+// @Success 200 {object} entity.Reservation
+// @Failure 400 {object} common.ErrorResponse
+// @Failure 403 {object} common.ErrorResponse
+// @Failure 409 {object} common.ErrorResponse
+// @Router /customer/reservations [post]
+func (c *ReservationController) Create(ctx *gin.Context) {
+	var req apiV1.CreateReservationRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		c.Error(ctx, 400, "Invalid request parameters")
+		return
+	}
+
+	r, err := c.reservationService.CreateReservation(ctx, ctx.GetUint("userID"), req.GPUModel, req.GPUCount, req.StartTime, req.EndTime, req.Remark)
+	if err != nil {
+		reservationError(&c.BaseController, ctx, err)
+		return
+	}
+	c.Success(ctx, r)
+}
+
+// List 获取我的预约列表
+// @Summary 获取我的预约列表
+// @Tags Customer - Reservations
+// @Produce json
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(10)
+// @Param status query string false "状态筛选 (queued, pending, active, cancelled, failed, expired)"
+// @Security Bearer
+// @Success 200 {object} map[string]interface{}
+// @Failure 500 {object} common.ErrorResponse
+// @Router /customer/reservations [get]
+func (c *ReservationController) List(ctx *gin.Context) {
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(ctx.DefaultQuery("page_size", "10"))
+
+	filters := map[string]interface{}{"customer_id": ctx.GetUint("userID")}
+	if status := ctx.Query("status"); status != "" {
+		filters["status"] = status
+	}
+
+	reservations, total, err := c.reservationService.ListReservations(ctx, page, pageSize, filters)
+	if err != nil {
+		c.Error(ctx, 500, "获取预约列表失败")
+		return
+	}
+	c.Success(ctx, gin.H{
+		"list":      reservations,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// Detail 获取预约详情
+// @Summary 获取预约详情
+// @Tags Customer - Reservations
+// @Produce json
+// @Param id path string true "预约 ID"
+// @Security Bearer
+// @Success 200 {object} entity.Reservation
+// @Failure 404 {object} common.ErrorResponse
+// @Router /customer/reservations/{id} [get]
+func (c *ReservationController) Detail(ctx *gin.Context) {
+	userID := ctx.GetUint("userID")
+	r, err := c.reservationService.GetReservation(ctx, ctx.Param("id"), &userID)
+	if err != nil {
+		reservationError(&c.BaseController, ctx, err)
+		return
+	}
+	c.Success(ctx, r)
+}
+
+// Cancel 取消预约
+// @Summary 取消预约
+// @Description 取消排队中或等待开始的预约，释放锁定的机器
+// @Tags Customer - Reservations
+// @Accept json
+// @Produce json
+// @Param id path string true "预约 ID"
+// @Param request body v1.CancelReservationRequest false "取消原因"
+// @Security Bearer
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} common.ErrorResponse
+// @Failure 409 {object} common.ErrorResponse
+// @Router /customer/reservations/{id}/cancel [post]
+func (c *ReservationController) Cancel(ctx *gin.Context) {
+	var req apiV1.CancelReservationRequest
+	_ = ctx.ShouldBindJSON(&req)
+
+	userID := ctx.GetUint("userID")
+	if err := c.reservationService.CancelReservation(ctx, ctx.Param("id"), &userID, req.Reason); err != nil {
+		reservationError(&c.BaseController, ctx, err)
+		return
+	}
+	c.Success(ctx, gin.H{"message": "预约已取消"})
+}
+
+// reservationError 将预约服务错误映射为 HTTP 状态码
+func reservationError(c *common.BaseController, ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, serviceReservation.ErrInvalidWindow):
+		c.Error(ctx, 400, err.Error())
+	case errors.Is(err, serviceReservation.ErrGPUQuotaExceeded):
+		c.Error(ctx, 403, err.Error())
+	case errors.Is(err, serviceReservation.ErrReservationNotFound):
+		c.Error(ctx, 404, err.Error())
+	case errors.Is(err, serviceReservation.ErrInsufficientCapacity),
+		errors.Is(err, serviceReservation.ErrReservationNotCancellable):
+		c.Error(ctx, 409, err.Error())
+	default:
+		c.Error(ctx, 500, "预约操作失败")
+	}
+}
@@ -1,6 +1,7 @@
 package system_config
 
 import (
+	"errors"
 	"strconv"
 
 	apiV1 "github.com/YoungBoyGod/remotegpu/api/v1"
@@ -72,6 +73,10 @@ func (c *SystemConfigController) UpdateConfigs(ctx *gin.Context) {
 
 	operator := ctx.GetString("username")
 	if err := c.configService.UpdateConfigs(ctx, req.Configs, operator); err != nil {
+		if errors.Is(err, serviceConfig.ErrInvalidConfigValue) {
+			c.Error(ctx, 400, err.Error())
+			return
+		}
 		c.Error(ctx, 500, "更新配置失败")
 		return
 	}
@@ -158,6 +163,10 @@ func (c *SystemConfigController) CreateConfig(ctx *gin.Context) {
 			c.Error(ctx, 409, err.Error())
 			return
 		}
+		if errors.Is(err, serviceConfig.ErrInvalidConfigValue) {
+			c.Error(ctx, 400, err.Error())
+			return
+		}
 		c.Error(ctx, 500, "创建配置失败")
 		return
 	}
@@ -220,6 +229,10 @@ func (c *SystemConfigController) UpdateConfig(ctx *gin.Context) {
 			c.Error(ctx, 404, err.Error())
 			return
 		}
+		if errors.Is(err, serviceConfig.ErrInvalidConfigValue) {
+			c.Error(ctx, 400, err.Error())
+			return
+		}
 		c.Error(ctx, 500, "更新配置失败")
 		return
 	}
@@ -155,7 +155,7 @@ func (c *AdminTaskController) Retry(ctx *gin.Context) {
 
 // Logs 获取任务日志
 // @Summary 管理员获取任务日志
-// @Description 管理员获取指定任务的日志信息
+// @Description 管理员获取指定任务的日志信息，参数同 /customer/tasks/{id}/logs，支持区间读取与 follow=true SSE 跟随
 // @Tags Admin - Tasks
 // @Produce json
 // @Param id path string true "任务 ID"
@@ -170,11 +170,7 @@ func (c *AdminTaskController) Logs(ctx *gin.Context) {
 		c.Error(ctx, 404, "任务不存在")
 		return
 	}
-	c.Success(ctx, gin.H{
-		"task_id":  task.ID,
-		"status":   task.Status,
-		"error_msg": task.ErrorMsg,
-	})
+	serveTaskLogs(ctx, &c.BaseController, c.taskService, task)
 }
 
 // Result 获取任务结果
@@ -1,8 +1,12 @@
 package task
 
 import (
+	"errors"
+
 	"github.com/YoungBoyGod/remotegpu/internal/controller/v1/common"
+	"github.com/YoungBoyGod/remotegpu/internal/dao"
 	serviceTask "github.com/YoungBoyGod/remotegpu/internal/service/task"
+	"github.com/YoungBoyGod/remotegpu/pkg/response"
 	"github.com/gin-gonic/gin"
 )
 
@@ -167,12 +171,16 @@ func (c *AgentTaskController) ReportProgress(ctx *gin.Context) {
 		AgentID   string `json:"agent_id" binding:"required"`
 		AttemptID string `json:"attempt_id" binding:"required"`
 		Percent   int    `json:"percent"`
-		Message   string `json:"message"`
+		Message   string `json:"message" binding:"max=500"`
 	}
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		c.Error(ctx, 400, err.Error())
 		return
 	}
+	if req.Percent < 0 || req.Percent > 100 {
+		c.Error(ctx, 400, "percent 必须在 0-100 之间")
+		return
+	}
 
 	if err := c.taskService.ReportProgress(ctx, id, req.AgentID, req.AttemptID, req.Percent, req.Message); err != nil {
 		c.Error(ctx, 409, err.Error())
@@ -180,3 +188,45 @@ func (c *AgentTaskController) ReportProgress(ctx *gin.Context) {
 	}
 	c.Success(ctx, gin.H{"task_id": id, "progress": req.Percent})
 }
+
+// AppendLog 增量上报任务日志
+// @Summary Agent 上报任务日志
+// @Description Agent 按字节偏移增量上报 stdout/stderr，重传同一偏移幂等；偏移不连续时返回 409 及服务端已持久化的 next_offset
+// @Tags Agent - Tasks
+// @Accept json
+// @Produce json
+// @Param id path string true "任务 ID"
+// @Param request body object true "日志请求（agent_id, attempt_id, stream, offset, data(base64)）"
+// @Security AgentToken
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} common.ErrorResponse
+// @Failure 409 {object} common.ErrorResponse
+// @Router /agent/tasks/{id}/logs [post]
+func (c *AgentTaskController) AppendLog(ctx *gin.Context) {
+	id := ctx.Param("id")
+	var req struct {
+		AgentID   string `json:"agent_id" binding:"required"`
+		AttemptID string `json:"attempt_id" binding:"required"`
+		Stream    string `json:"stream" binding:"required"`
+		Offset    int64  `json:"offset"`
+		Data      []byte `json:"data"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		c.Error(ctx, 400, err.Error())
+		return
+	}
+
+	next, err := c.taskService.AppendLog(ctx, id, req.AgentID, req.AttemptID, req.Stream, req.Offset, req.Data)
+	if err != nil {
+		switch {
+		case errors.Is(err, dao.ErrLogOffsetGap):
+			response.ErrorWithData(ctx, 409, "日志偏移不连续", gin.H{"next_offset": next})
+		case errors.Is(err, serviceTask.ErrInvalidLogStream), errors.Is(err, serviceTask.ErrLogChunkTooLarge):
+			c.Error(ctx, 400, err.Error())
+		default:
+			c.Error(ctx, 409, err.Error())
+		}
+		return
+	}
+	c.Success(ctx, gin.H{"task_id": id, "next_offset": next})
+}
@@ -5,11 +5,13 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/YoungBoyGod/remotegpu/config"
 	"github.com/YoungBoyGod/remotegpu/internal/middleware"
 	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+	serviceNotification "github.com/YoungBoyGod/remotegpu/internal/service/notification"
 	serviceTask "github.com/YoungBoyGod/remotegpu/internal/service/task"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -24,6 +26,7 @@ const testAgentToken = "test-agent-token-for-testing"
 type agentTaskTestEnv struct {
 	db     *gorm.DB
 	router *gin.Engine
+	hub    *serviceNotification.SSEHub
 }
 
 // setupAgentTaskTestEnv 初始化 Agent 任务接口测试环境
@@ -32,8 +35,9 @@ func setupAgentTaskTestEnv(t *testing.T) *agentTaskTestEnv {
 
 	config.GlobalConfig = &config.Config{
 		Agent: config.AgentConfig{
-			Enabled: true,
-			Token:   testAgentToken,
+			Enabled:          true,
+			Token:            testAgentToken,
+			AllowSharedToken: true,
 		},
 	}
 
@@ -63,10 +67,18 @@ func setupAgentTaskTestEnv(t *testing.T) *agentTaskTestEnv {
 		progress INTEGER DEFAULT 0,
 		progress_message VARCHAR(500),
 		machine_id VARCHAR(64),
+		gpu_count INTEGER DEFAULT 0,
+		gpu_model VARCHAR(128),
+		min_gpu_memory_mb INTEGER DEFAULT 0,
+		allowed_hosts TEXT,
+		assigned_gpus TEXT,
+		placed BOOLEAN DEFAULT 0,
 		group_id VARCHAR(64),
 		parent_id VARCHAR(64),
+		depends_on TEXT,
 		assigned_agent_id VARCHAR(64),
 		lease_expires_at DATETIME,
+		next_attempt_at DATETIME,
 		attempt_id VARCHAR(64),
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		assigned_at DATETIME,
@@ -93,21 +105,38 @@ func setupAgentTaskTestEnv(t *testing.T) *agentTaskTestEnv {
 	)`).Error
 	require.NoError(t, err)
 
+	// 创建 task_log_chunks 表
+	err = db.Exec(`CREATE TABLE task_log_chunks (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		task_id VARCHAR(64) NOT NULL,
+		attempt_id VARCHAR(64) NOT NULL,
+		stream VARCHAR(10) NOT NULL,
+		byte_offset INTEGER NOT NULL,
+		size INTEGER NOT NULL,
+		content BLOB,
+		created_at DATETIME,
+		UNIQUE (task_id, attempt_id, stream, byte_offset)
+	)`).Error
+	require.NoError(t, err)
+
 	taskService := serviceTask.NewTaskService(db, nil)
+	hub := serviceNotification.NewSSEHub()
+	taskService.SetProgressNotifier(serviceNotification.NewNotificationService(db, hub))
 	controller := NewAgentTaskController(taskService)
 
 	router := gin.New()
 	agentGroup := router.Group("/api/v1/agent")
-	agentGroup.Use(middleware.AgentAuth())
+	agentGroup.Use(middleware.AgentAuth(nil))
 	{
 		agentGroup.POST("/tasks/claim", controller.ClaimTasks)
 		agentGroup.POST("/tasks/:id/start", controller.StartTask)
 		agentGroup.POST("/tasks/:id/lease/renew", controller.RenewLease)
 		agentGroup.POST("/tasks/:id/complete", controller.CompleteTask)
 		agentGroup.POST("/tasks/:id/progress", controller.ReportProgress)
+		agentGroup.POST("/tasks/:id/logs", controller.AppendLog)
 	}
 
-	return &agentTaskTestEnv{db: db, router: router}
+	return &agentTaskTestEnv{db: db, router: router, hub: hub}
 }
 
 // ==================== 任务认领测试 ====================
@@ -405,6 +434,38 @@ func TestReportProgress_Success(t *testing.T) {
 	assert.Equal(t, "训练进度 50%", task.ProgressMessage)
 }
 
+func TestReportProgress_MessageTooLong(t *testing.T) {
+	env := setupAgentTaskTestEnv(t)
+
+	env.db.Create(&entity.Task{
+		ID: "t-prog-long", CustomerID: 1, Name: "进度任务",
+		Command: "train.py", Status: "running",
+		AssignedAgentID: "agent-001", AttemptID: "attempt-001",
+	})
+
+	post := func(message string) int {
+		body, _ := json.Marshal(map[string]any{
+			"agent_id":   "agent-001",
+			"attempt_id": "attempt-001",
+			"percent":    10,
+			"message":    message,
+		})
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/agent/tasks/t-prog-long/progress", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Agent-Token", testAgentToken)
+		w := httptest.NewRecorder()
+		env.router.ServeHTTP(w, req)
+
+		var resp testResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp.Code
+	}
+
+	// 长度按字符计算，500 个汉字可以保存
+	assert.Equal(t, 0, post(strings.Repeat("训", 500)))
+	assert.Equal(t, 400, post(strings.Repeat("训", 501)))
+}
+
 func TestReportProgress_NotRunning(t *testing.T) {
 	// 非 running 状态的任务不能上报进度
 	env := setupAgentTaskTestEnv(t)
@@ -434,4 +495,140 @@ func TestReportProgress_NotRunning(t *testing.T) {
 	err := json.Unmarshal(w.Body.Bytes(), &resp)
 	require.NoError(t, err)
 	assert.Equal(t, 409, resp.Code)
-}
\ No newline at end of file
+}
+func TestReportProgress_PushesSSE(t *testing.T) {
+	env := setupAgentTaskTestEnv(t)
+
+	env.db.Create(&entity.Task{
+		ID: "t-prog-sse", CustomerID: 7, Name: "进度推送任务",
+		Command: "train.py", Status: "running",
+		AssignedAgentID: "agent-001", AttemptID: "attempt-001",
+	})
+
+	client := &serviceNotification.SSEClient{CustomerID: 7, Channel: make(chan serviceNotification.SSEEvent, 1)}
+	env.hub.Register(client)
+	defer env.hub.Unregister(client)
+
+	body, _ := json.Marshal(map[string]any{
+		"agent_id":   "agent-001",
+		"attempt_id": "attempt-001",
+		"percent":    42,
+		"message":    "epoch 3/10",
+	})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/agent/tasks/t-prog-sse/progress", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Agent-Token", testAgentToken)
+	w := httptest.NewRecorder()
+	env.router.ServeHTTP(w, req)
+
+	var resp testResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, 0, resp.Code)
+
+	select {
+	case ev := <-client.Channel:
+		assert.Equal(t, "task_progress", ev.Event)
+		var data map[string]any
+		require.NoError(t, json.Unmarshal([]byte(ev.Data), &data))
+		assert.Equal(t, "t-prog-sse", data["task_id"])
+		assert.Equal(t, float64(42), data["percent"])
+		assert.Equal(t, "epoch 3/10", data["message"])
+	default:
+		t.Fatal("expected task_progress event")
+	}
+}
+
+func TestReportProgress_InvalidPercent(t *testing.T) {
+	env := setupAgentTaskTestEnv(t)
+
+	body, _ := json.Marshal(map[string]any{
+		"agent_id":   "agent-001",
+		"attempt_id": "attempt-001",
+		"percent":    150,
+	})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/agent/tasks/t-any/progress", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Agent-Token", testAgentToken)
+	w := httptest.NewRecorder()
+	env.router.ServeHTTP(w, req)
+
+	var resp testResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, 400, resp.Code)
+}
+
+// ==================== 日志上报测试 ====================
+
+// postLog 上报一段日志，返回业务码与服务端 next_offset
+func postLog(t *testing.T, env *agentTaskTestEnv, taskID string, offset int64, data string) (int, int64) {
+	body, _ := json.Marshal(map[string]any{
+		"agent_id":   "agent-001",
+		"attempt_id": "attempt-001",
+		"stream":     "stdout",
+		"offset":     offset,
+		"data":       []byte(data),
+	})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/agent/tasks/"+taskID+"/logs", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Agent-Token", testAgentToken)
+	w := httptest.NewRecorder()
+
+	env.router.ServeHTTP(w, req)
+
+	var resp testResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	var result struct {
+		NextOffset int64 `json:"next_offset"`
+	}
+	if len(resp.Data) > 0 {
+		require.NoError(t, json.Unmarshal(resp.Data, &result))
+	}
+	return resp.Code, result.NextOffset
+}
+
+func TestAppendLog_IdempotentAndGap(t *testing.T) {
+	env := setupAgentTaskTestEnv(t)
+
+	env.db.Create(&entity.Task{
+		ID: "t-log", CustomerID: 1, Name: "日志任务",
+		Command: "train.py", Status: "running",
+		AssignedAgentID: "agent-001", AttemptID: "attempt-001",
+	})
+
+	code, next := postLog(t, env, "t-log", 0, "epoch 1\n")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, int64(8), next)
+
+	// 重传同一分片不会重复写入
+	code, next = postLog(t, env, "t-log", 0, "epoch 1\n")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, int64(8), next)
+
+	// 与已持久化部分重叠的分片只追加新增部分
+	code, next = postLog(t, env, "t-log", 6, "1\nepoch 2\n")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, int64(16), next)
+
+	// 偏移跳跃时返回服务端已持久化的偏移
+	code, next = postLog(t, env, "t-log", 100, "lost")
+	assert.Equal(t, 409, code)
+	assert.Equal(t, int64(16), next)
+
+	var chunks []entity.TaskLogChunk
+	env.db.Order("byte_offset").Find(&chunks)
+	require.Len(t, chunks, 2)
+	assert.Equal(t, "epoch 1\nepoch 2\n", string(chunks[0].Content)+string(chunks[1].Content))
+}
+
+func TestAppendLog_WrongAttempt(t *testing.T) {
+	env := setupAgentTaskTestEnv(t)
+
+	env.db.Create(&entity.Task{
+		ID: "t-log-old", CustomerID: 1, Name: "日志任务",
+		Command: "train.py", Status: "running",
+		AssignedAgentID: "agent-001", AttemptID: "attempt-002",
+	})
+
+	code, _ := postLog(t, env, "t-log-old", 0, "stale attempt")
+	assert.Equal(t, 409, code)
+}
@@ -0,0 +1,97 @@
+package task
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/YoungBoyGod/remotegpu/internal/controller/v1/common"
+	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+	serviceTask "github.com/YoungBoyGod/remotegpu/internal/service/task"
+	"github.com/gin-gonic/gin"
+)
+
+// logFollowInterval 跟随读取时轮询新日志的间隔
+const logFollowInterval = time.Second
+
+// serveTaskLogs 按查询参数返回任务日志
+// 支持 stream/offset/limit/attempt_id 区间读取，offset 为负数时读取末尾；follow=true 时通过 SSE 持续推送
+func serveTaskLogs(ctx *gin.Context, c *common.BaseController, svc *serviceTask.TaskService, task *entity.Task) {
+	stream := ctx.DefaultQuery("stream", "stdout")
+	attemptID := ctx.Query("attempt_id")
+	offset, _ := strconv.ParseInt(ctx.DefaultQuery("offset", "0"), 10, 64)
+	limit, _ := strconv.Atoi(ctx.Query("limit"))
+
+	page, err := svc.ReadLog(ctx, task, attemptID, stream, offset, limit)
+	if err != nil {
+		if errors.Is(err, serviceTask.ErrInvalidLogStream) {
+			c.Error(ctx, 400, err.Error())
+			return
+		}
+		c.Error(ctx, 500, "读取任务日志失败")
+		return
+	}
+
+	if ctx.Query("follow") != "true" {
+		c.Success(ctx, gin.H{
+			"task_id":     task.ID,
+			"status":      task.Status,
+			"error_msg":   task.ErrorMsg,
+			"attempt_id":  page.AttemptID,
+			"stream":      page.Stream,
+			"offset":      page.Offset,
+			"next_offset": page.NextOffset,
+			"size":        page.Size,
+			"content":     page.Content,
+			"finished":    page.Finished,
+		})
+		return
+	}
+
+	ctx.Writer.Header().Set("Content-Type", "text/event-stream")
+	ctx.Writer.Header().Set("Cache-Control", "no-cache")
+	ctx.Writer.Header().Set("Connection", "keep-alive")
+	ctx.Writer.Header().Set("X-Accel-Buffering", "no")
+
+	// 跟随时固定读取首次解析出的尝试，避免重试后混入新尝试的日志；该尝试被取代时读完剩余日志后结束
+	attemptID = page.AttemptID
+	ticker := time.NewTicker(logFollowInterval)
+	defer ticker.Stop()
+	clientGone := ctx.Request.Context().Done()
+
+	for {
+		if page.Content != "" {
+			data, _ := json.Marshal(page)
+			fmt.Fprintf(ctx.Writer, "event: log\ndata: %s\n\n", data)
+			ctx.Writer.Flush()
+		}
+		if page.Finished {
+			fmt.Fprintf(ctx.Writer, "event: end\ndata: {\"status\":%q,\"superseded\":%t}\n\n", task.Status, page.Superseded)
+			ctx.Writer.Flush()
+			return
+		}
+		// 读到末尾时等待新日志，否则立即读取下一页
+		if page.NextOffset >= page.Size {
+			select {
+			case <-clientGone:
+				return
+			case <-ticker.C:
+			}
+			if latest, err := svc.GetTask(ctx, task.ID); err == nil {
+				task = latest
+			}
+			// 开始跟随时任务尚未被认领，跟随之后的第一次尝试
+			if attemptID == "" {
+				attemptID = task.AttemptID
+			}
+		}
+
+		if page, err = svc.ReadLog(ctx, task, attemptID, stream, page.NextOffset, limit); err != nil {
+			fmt.Fprintf(ctx.Writer, "event: error\ndata: {\"msg\":%q}\n\n", err.Error())
+			ctx.Writer.Flush()
+			return
+		}
+	}
+}
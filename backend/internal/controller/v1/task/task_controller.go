@@ -1,12 +1,15 @@
 package task
 
 import (
+	"errors"
 	"strconv"
 
 	"github.com/YoungBoyGod/remotegpu/internal/controller/v1/common"
 	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
 	serviceTask "github.com/YoungBoyGod/remotegpu/internal/service/task"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
 )
 
 type TaskController struct {
@@ -20,6 +23,28 @@ func NewTaskController(ts *serviceTask.TaskService) *TaskController {
 	}
 }
 
+// trainingTaskRequest 创建训练任务的请求，只接受用户可设置的字段，
+// 状态、调度、租约及工作流字段（group_id、depends_on 等）由服务端维护
+type trainingTaskRequest struct {
+	ID             string         `json:"id"`
+	Name           string         `json:"name"`
+	Command        string         `json:"command"`
+	Args           datatypes.JSON `json:"args"`
+	WorkDir        string         `json:"workdir"`
+	EnvVars        datatypes.JSON `json:"env_vars"`
+	Timeout        int            `json:"timeout"`
+	Priority       int            `json:"priority"`
+	RetryDelay     int            `json:"retry_delay"`
+	MaxRetries     int            `json:"max_retries"`
+	MachineID      string         `json:"machine_id"`
+	GPUCount       int            `json:"gpu_count"`
+	GPUModel       string         `json:"gpu_model"`
+	MinGPUMemoryMB int            `json:"min_gpu_memory_mb"`
+	AllowedHosts   datatypes.JSON `json:"allowed_hosts"`
+	HostID         string         `json:"host_id"`
+	ImageID        *uint          `json:"image_id"`
+}
+
 // List 获取当前用户的任务列表
 // @Summary 获取任务列表
 // @Description 根据当前登录用户获取其任务列表，支持分页
@@ -63,11 +88,11 @@ func (c *TaskController) List(ctx *gin.Context) {
 
 // CreateTraining 创建训练任务
 // @Summary 创建训练任务
-// @Description 创建新的训练任务并绑定当前用户
+// @Description 创建新的训练任务并绑定当前用户；未指定 machine_id 时按 gpu_count、gpu_model、min_gpu_memory_mb 和 allowed_hosts 在客户已分配的机器中调度
 // @Tags Customer - Tasks
 // @Accept json
 // @Produce json
-// @Param request body entity.Task true "任务信息"
+// @Param request body trainingTaskRequest true "任务信息"
 // @Security Bearer
 // @Success 200 {object} entity.Task
 // @Failure 400 {object} common.ErrorResponse
@@ -86,17 +111,47 @@ func (c *TaskController) CreateTraining(ctx *gin.Context) {
 		return
 	}
 
-	var task entity.Task
-	if err := ctx.ShouldBindJSON(&task); err != nil {
+	var req trainingTaskRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
 		c.Error(ctx, 400, err.Error())
 		return
 	}
+	if req.ID == "" {
+		req.ID = uuid.NewString()
+	}
 
-	task.Type = "training"
-	task.CustomerID = userID
+	// GPU 由调度器分配
+	task := entity.Task{
+		ID:             req.ID,
+		CustomerID:     userID,
+		Name:           req.Name,
+		Type:           "training",
+		Command:        req.Command,
+		Args:           req.Args,
+		WorkDir:        req.WorkDir,
+		EnvVars:        req.EnvVars,
+		Timeout:        req.Timeout,
+		Priority:       req.Priority,
+		RetryDelay:     req.RetryDelay,
+		MaxRetries:     req.MaxRetries,
+		MachineID:      req.MachineID,
+		GPUCount:       req.GPUCount,
+		GPUModel:       req.GPUModel,
+		MinGPUMemoryMB: req.MinGPUMemoryMB,
+		AllowedHosts:   req.AllowedHosts,
+		HostID:         req.HostID,
+		ImageID:        req.ImageID,
+	}
 
 	if err := c.taskService.SubmitTask(ctx, &task); err != nil {
-		c.Error(ctx, 500, "创建任务失败")
+		switch {
+		case errors.Is(err, serviceTask.ErrInvalidResourceRequest):
+			c.Error(ctx, 400, "资源需求参数无效")
+		case errors.Is(err, serviceTask.ErrHostNotAllocated):
+			c.Error(ctx, 400, "指定的机器不在当前有效分配中")
+		default:
+			c.Error(ctx, 500, "创建任务失败")
+		}
 		return
 	}
 	c.Success(ctx, task)
@@ -237,10 +292,15 @@ func (c *TaskController) Retry(ctx *gin.Context) {
 
 // Logs 获取任务日志（带权限校验）
 // @Summary 获取任务日志
-// @Description 获取指定任务的日志信息，校验任务归属
+// @Description 获取指定任务的日志信息，校验任务归属；支持按偏移区间读取、负偏移读取末尾，follow=true 时通过 SSE 跟随输出
 // @Tags Customer - Tasks
 // @Produce json
 // @Param id path string true "任务 ID"
+// @Param stream query string false "输出流 stdout/stderr" default(stdout)
+// @Param offset query int false "起始字节偏移，负数表示从末尾倒数" default(0)
+// @Param limit query int false "读取字节数" default(65536)
+// @Param attempt_id query string false "执行尝试 ID，默认当前尝试"
+// @Param follow query bool false "是否通过 SSE 跟随输出"
 // @Security Bearer
 // @Success 200 {object} map[string]interface{}
 // @Failure 401 {object} common.ErrorResponse
@@ -265,11 +325,7 @@ func (c *TaskController) Logs(ctx *gin.Context) {
 		return
 	}
 
-	c.Success(ctx, gin.H{
-		"task_id":   task.ID,
-		"status":    task.Status,
-		"error_msg": task.ErrorMsg,
-	})
+	serveTaskLogs(ctx, &c.BaseController, c.taskService, task)
 }
 
 // Result 获取任务结果（带权限校验）
@@ -2,12 +2,15 @@ package task
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/YoungBoyGod/remotegpu/internal/dao"
 	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
 	serviceTask "github.com/YoungBoyGod/remotegpu/internal/service/task"
 	"github.com/gin-gonic/gin"
@@ -60,10 +63,18 @@ func setupTaskTestEnv(t *testing.T) *taskTestEnv {
 		progress INTEGER DEFAULT 0,
 		progress_message TEXT,
 		machine_id VARCHAR(64),
+		gpu_count INTEGER DEFAULT 0,
+		gpu_model VARCHAR(128),
+		min_gpu_memory_mb INTEGER DEFAULT 0,
+		allowed_hosts TEXT,
+		assigned_gpus TEXT,
+		placed BOOLEAN DEFAULT 0,
 		group_id VARCHAR(64),
 		parent_id VARCHAR(64),
+		depends_on TEXT,
 		assigned_agent_id VARCHAR(64),
 		lease_expires_at DATETIME,
+		next_attempt_at DATETIME,
 		attempt_id VARCHAR(64),
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		assigned_at DATETIME,
@@ -91,6 +102,20 @@ func setupTaskTestEnv(t *testing.T) *taskTestEnv {
 	require.NoError(t, err)
 
 	// agentService 传 nil，任务停止相关测试会走 "agent service unavailable" 分支
+	// 创建 task_log_chunks 表
+	err = db.Exec(`CREATE TABLE task_log_chunks (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		task_id VARCHAR(64) NOT NULL,
+		attempt_id VARCHAR(64) NOT NULL,
+		stream VARCHAR(10) NOT NULL,
+		byte_offset INTEGER NOT NULL,
+		size INTEGER NOT NULL,
+		content BLOB,
+		created_at DATETIME,
+		UNIQUE (task_id, attempt_id, stream, byte_offset)
+	)`).Error
+	require.NoError(t, err)
+
 	taskService := serviceTask.NewTaskService(db, nil)
 	controller := NewTaskController(taskService)
 
@@ -161,6 +186,45 @@ func TestCreateTraining_Success(t *testing.T) {
 	assert.Equal(t, uint(1), task.CustomerID)
 }
 
+func TestCreateTraining_IgnoresServerFields(t *testing.T) {
+	env := setupTaskTestEnv(t)
+
+	// 服务端维护的字段不能由请求设置
+	body, _ := json.Marshal(map[string]interface{}{
+		"name":              "训练任务",
+		"command":           "python train.py",
+		"machine_id":        "host-001",
+		"group_id":          "wf-other",
+		"depends_on":        []string{"task-other"},
+		"customer_id":       2,
+		"status":            "running",
+		"assigned_agent_id": "agent-x",
+		"attempt_id":        "attempt-x",
+		"placed":            true,
+	})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/tasks/training", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-User-ID", "1")
+	w := httptest.NewRecorder()
+	env.router.ServeHTTP(w, req)
+
+	var resp testResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Equal(t, 0, resp.Code)
+
+	var task entity.Task
+	require.NoError(t, env.db.First(&task, "name = ?", "训练任务").Error)
+	assert.NotEmpty(t, task.ID)
+	assert.Equal(t, uint(1), task.CustomerID)
+	assert.Equal(t, "queued", task.Status)
+	assert.Empty(t, task.GroupID)
+	assert.Empty(t, task.DependsOn)
+	assert.Empty(t, task.AssignedAgentID)
+	assert.Empty(t, task.AttemptID)
+	assert.False(t, task.Placed)
+	assert.Equal(t, "host-001", task.MachineID)
+}
+
 func TestCreateTraining_NoAuth(t *testing.T) {
 	env := setupTaskTestEnv(t)
 
@@ -500,6 +564,119 @@ func TestLogs_Forbidden(t *testing.T) {
 	assert.Equal(t, 403, resp.Code)
 }
 
+func TestLogs_RangeAndTail(t *testing.T) {
+	env := setupTaskTestEnv(t)
+
+	env.db.Create(&entity.Task{
+		ID: "t-logs-r", CustomerID: 1, Name: "日志任务",
+		Command: "train.py", Status: "completed", AttemptID: "attempt-001",
+	})
+	logDao := dao.NewTaskLogDao(env.db)
+	_, err := logDao.Append(context.Background(), "t-logs-r", "attempt-001", "stdout", 0, []byte("line-1\n"))
+	require.NoError(t, err)
+	_, err = logDao.Append(context.Background(), "t-logs-r", "attempt-001", "stdout", 7, []byte("line-2\n"))
+	require.NoError(t, err)
+
+	read := func(query string) map[string]any {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/tasks/t-logs-r/logs?"+query, nil)
+		req.Header.Set("X-User-ID", "1")
+		w := httptest.NewRecorder()
+		env.router.ServeHTTP(w, req)
+
+		var resp testResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		require.Equal(t, 0, resp.Code)
+		var data map[string]any
+		require.NoError(t, json.Unmarshal(resp.Data, &data))
+		return data
+	}
+
+	// 跨分片区间读取
+	data := read("offset=4&limit=6")
+	assert.Equal(t, "-1\nlin", data["content"])
+	assert.Equal(t, float64(10), data["next_offset"])
+	assert.Equal(t, false, data["finished"])
+
+	// 负偏移读取末尾
+	data = read("offset=-7")
+	assert.Equal(t, "line-2\n", data["content"])
+	assert.Equal(t, float64(14), data["size"])
+	assert.Equal(t, true, data["finished"])
+}
+
+func TestLogs_LegacyOutput(t *testing.T) {
+	env := setupTaskTestEnv(t)
+
+	// 未增量上报的任务回退到完成时写入的输出
+	env.db.Create(&entity.Task{
+		ID: "t-logs-l", CustomerID: 1, Name: "旧任务",
+		Command: "echo", Status: "completed", Stdout: "hello\n",
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/tasks/t-logs-l/logs", nil)
+	req.Header.Set("X-User-ID", "1")
+	w := httptest.NewRecorder()
+	env.router.ServeHTTP(w, req)
+
+	var resp testResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	var data map[string]any
+	require.NoError(t, json.Unmarshal(resp.Data, &data))
+	assert.Equal(t, "hello\n", data["content"])
+	assert.Equal(t, true, data["finished"])
+}
+
+func TestLogs_FollowStreamsUntilFinished(t *testing.T) {
+	env := setupTaskTestEnv(t)
+
+	env.db.Create(&entity.Task{
+		ID: "t-logs-f2", CustomerID: 1, Name: "跟随任务",
+		Command: "train.py", Status: "completed", AttemptID: "attempt-001",
+	})
+	_, err := dao.NewTaskLogDao(env.db).Append(context.Background(), "t-logs-f2", "attempt-001", "stdout", 0, []byte("done\n"))
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/tasks/t-logs-f2/logs?follow=true", nil)
+	req.Header.Set("X-User-ID", "1")
+	w := httptest.NewRecorder()
+	env.router.ServeHTTP(w, req)
+
+	assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "event: log")
+	assert.Contains(t, w.Body.String(), `"content":"done\n"`)
+	assert.Contains(t, w.Body.String(), "event: end")
+}
+
+func TestLogs_FollowEndsWhenAttemptSuperseded(t *testing.T) {
+	env := setupTaskTestEnv(t)
+
+	// 租约过期后任务重新排队，跟随旧尝试的日志流读完剩余日志后结束
+	env.db.Create(&entity.Task{
+		ID: "t-logs-f3", CustomerID: 1, Name: "重新排队任务",
+		Command: "train.py", Status: "queued", AttemptID: "attempt-002",
+	})
+	_, err := dao.NewTaskLogDao(env.db).Append(context.Background(), "t-logs-f3", "attempt-001", "stdout", 0, []byte("partial\n"))
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/tasks/t-logs-f3/logs?follow=true&attempt_id=attempt-001", nil)
+	req.Header.Set("X-User-ID", "1")
+	w := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		env.router.ServeHTTP(w, req)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("follow stream did not end after the attempt was superseded")
+	}
+	assert.Contains(t, w.Body.String(), `"content":"partial\n"`)
+	assert.Contains(t, w.Body.String(), `event: end`)
+	assert.Contains(t, w.Body.String(), `"superseded":true`)
+}
+
 // ==================== 任务结果测试 ====================
 
 func TestResult_Success(t *testing.T) {
@@ -0,0 +1,178 @@
+package task
+
+import (
+	"errors"
+
+	"github.com/YoungBoyGod/remotegpu/internal/controller/v1/common"
+	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+	serviceTask "github.com/YoungBoyGod/remotegpu/internal/service/task"
+	"github.com/gin-gonic/gin"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// WorkflowController 工作流（任务 DAG）控制器
+type WorkflowController struct {
+	common.BaseController
+	taskService *serviceTask.TaskService
+}
+
+func NewWorkflowController(ts *serviceTask.TaskService) *WorkflowController {
+	return &WorkflowController{taskService: ts}
+}
+
+// workflowTaskRequest 工作流中的单个任务，depends_on 引用同一请求内其他任务的 key
+type workflowTaskRequest struct {
+	Key            string         `json:"key" binding:"required"`
+	DependsOn      []string       `json:"depends_on"`
+	Name           string         `json:"name"`
+	Type           string         `json:"type"`
+	Command        string         `json:"command" binding:"required"`
+	Args           datatypes.JSON `json:"args"`
+	WorkDir        string         `json:"workdir"`
+	EnvVars        datatypes.JSON `json:"env_vars"`
+	Timeout        int            `json:"timeout"`
+	Priority       int            `json:"priority"`
+	MaxRetries     int            `json:"max_retries"`
+	MachineID      string         `json:"machine_id"`
+	GPUCount       int            `json:"gpu_count"`
+	GPUModel       string         `json:"gpu_model"`
+	MinGPUMemoryMB int            `json:"min_gpu_memory_mb"`
+	AllowedHosts   datatypes.JSON `json:"allowed_hosts"`
+	ImageID        *uint          `json:"image_id"`
+}
+
+// Create 提交工作流
+// @Summary 提交工作流
+// @Description 提交由多个任务组成的 DAG，上游任务全部成功后下游任务才会被调度；上游失败或取消时下游级联取消
+// @Tags Customer - Workflows
+// @Accept json
+// @Produce json
+// @Param request body object true "工作流请求（tasks: [{key, depends_on, command, ...}]）"
+// @Security Bearer
+// @Success 200 {object} serviceTask.WorkflowStatus
+// @Failure 400 {object} common.ErrorResponse
+// @Failure 401 {object} common.ErrorResponse
+// @Failure 500 {object} common.ErrorResponse
+// @Router /customer/workflows [post]
+func (c *WorkflowController) Create(ctx *gin.Context) {
+	userID := ctx.GetUint("userID")
+	if userID == 0 {
+		c.Error(ctx, 401, "用户未认证")
+		return
+	}
+
+	var req struct {
+		Tasks []workflowTaskRequest `json:"tasks" binding:"required,min=1,dive"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		c.Error(ctx, 400, err.Error())
+		return
+	}
+
+	specs := make([]serviceTask.WorkflowTask, 0, len(req.Tasks))
+	for _, t := range req.Tasks {
+		specs = append(specs, serviceTask.WorkflowTask{
+			Key:       t.Key,
+			DependsOn: t.DependsOn,
+			Task: entity.Task{
+				Name:           t.Name,
+				Type:           t.Type,
+				Command:        t.Command,
+				Args:           t.Args,
+				WorkDir:        t.WorkDir,
+				EnvVars:        t.EnvVars,
+				Timeout:        t.Timeout,
+				Priority:       t.Priority,
+				MaxRetries:     t.MaxRetries,
+				MachineID:      t.MachineID,
+				GPUCount:       t.GPUCount,
+				GPUModel:       t.GPUModel,
+				MinGPUMemoryMB: t.MinGPUMemoryMB,
+				AllowedHosts:   t.AllowedHosts,
+				ImageID:        t.ImageID,
+			},
+		})
+	}
+
+	wf, err := c.taskService.CreateWorkflow(ctx, userID, specs)
+	if err != nil {
+		switch {
+		case errors.Is(err, serviceTask.ErrInvalidWorkflow):
+			c.Error(ctx, 400, err.Error())
+		case errors.Is(err, serviceTask.ErrInvalidResourceRequest):
+			c.Error(ctx, 400, "资源需求参数无效")
+		case errors.Is(err, serviceTask.ErrHostNotAllocated):
+			c.Error(ctx, 400, "指定的机器不在当前有效分配中")
+		default:
+			c.Error(ctx, 500, "创建工作流失败")
+		}
+		return
+	}
+	c.Success(ctx, wf)
+}
+
+// Detail 查询工作流状态
+// @Summary 查询工作流状态
+// @Description 返回工作流聚合状态及各任务状态
+// @Tags Customer - Workflows
+// @Produce json
+// @Param id path string true "工作流 ID"
+// @Security Bearer
+// @Success 200 {object} serviceTask.WorkflowStatus
+// @Failure 401 {object} common.ErrorResponse
+// @Failure 403 {object} common.ErrorResponse
+// @Failure 404 {object} common.ErrorResponse
+// @Router /customer/workflows/{id} [get]
+func (c *WorkflowController) Detail(ctx *gin.Context) {
+	userID := ctx.GetUint("userID")
+	if userID == 0 {
+		c.Error(ctx, 401, "用户未认证")
+		return
+	}
+
+	wf, err := c.taskService.GetWorkflowWithAuth(ctx, ctx.Param("id"), userID)
+	if err != nil {
+		c.workflowError(ctx, err, "查询工作流失败")
+		return
+	}
+	c.Success(ctx, wf)
+}
+
+// Cancel 取消工作流
+// @Summary 取消工作流
+// @Description 取消工作流中尚未开始执行的任务，运行中的任务继续执行至结束
+// @Tags Customer - Workflows
+// @Produce json
+// @Param id path string true "工作流 ID"
+// @Security Bearer
+// @Success 200 {object} serviceTask.WorkflowStatus
+// @Failure 401 {object} common.ErrorResponse
+// @Failure 403 {object} common.ErrorResponse
+// @Failure 404 {object} common.ErrorResponse
+// @Router /customer/workflows/{id}/cancel [post]
+func (c *WorkflowController) Cancel(ctx *gin.Context) {
+	userID := ctx.GetUint("userID")
+	if userID == 0 {
+		c.Error(ctx, 401, "用户未认证")
+		return
+	}
+
+	wf, err := c.taskService.CancelWorkflowWithAuth(ctx, ctx.Param("id"), userID)
+	if err != nil {
+		c.workflowError(ctx, err, "取消工作流失败")
+		return
+	}
+	c.Success(ctx, wf)
+}
+
+func (c *WorkflowController) workflowError(ctx *gin.Context, err error, msg string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.Error(ctx, 404, "工作流不存在")
+	case errors.Is(err, entity.ErrUnauthorized):
+		c.Error(ctx, 403, "无权访问该工作流")
+	default:
+		c.Error(ctx, 500, msg)
+	}
+}
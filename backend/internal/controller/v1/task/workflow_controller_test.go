@@ -0,0 +1,93 @@
+package task
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	serviceTask "github.com/YoungBoyGod/remotegpu/internal/service/task"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupWorkflowRouter 复用任务测试数据库，注册工作流路由
+func setupWorkflowRouter(t *testing.T) *gin.Engine {
+	env := setupTaskTestEnv(t)
+	controller := NewWorkflowController(serviceTask.NewTaskService(env.db, nil))
+
+	router := gin.New()
+	group := router.Group("/api/v1/workflows", func(c *gin.Context) {
+		if c.GetHeader("X-User-ID") == "2" {
+			c.Set("userID", uint(2))
+		} else {
+			c.Set("userID", uint(1))
+		}
+		c.Next()
+	})
+	group.POST("", controller.Create)
+	group.GET("/:id", controller.Detail)
+	group.POST("/:id/cancel", controller.Cancel)
+	return router
+}
+
+func doWorkflowRequest(t *testing.T, router *gin.Engine, method, path, userID string, body any) testResponse {
+	var buf bytes.Buffer
+	if body != nil {
+		require.NoError(t, json.NewEncoder(&buf).Encode(body))
+	}
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-User-ID", userID)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var resp testResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return resp
+}
+
+func TestWorkflowController_CreateAndDetail(t *testing.T) {
+	router := setupWorkflowRouter(t)
+
+	resp := doWorkflowRequest(t, router, http.MethodPost, "/api/v1/workflows", "1", map[string]any{
+		"tasks": []map[string]any{
+			{"key": "preprocess", "command": "python prep.py"},
+			{"key": "train", "command": "python train.py", "depends_on": []string{"preprocess"}, "gpu_count": 1},
+		},
+	})
+	require.Equal(t, 0, resp.Code, resp.Msg)
+
+	var wf struct {
+		ID     string         `json:"id"`
+		Status string         `json:"status"`
+		Counts map[string]int `json:"counts"`
+	}
+	require.NoError(t, json.Unmarshal(resp.Data, &wf))
+	assert.NotEmpty(t, wf.ID)
+	assert.Equal(t, "pending", wf.Status)
+	assert.Equal(t, 1, wf.Counts["queued"])
+	assert.Equal(t, 1, wf.Counts["waiting"])
+
+	resp = doWorkflowRequest(t, router, http.MethodGet, "/api/v1/workflows/"+wf.ID, "1", nil)
+	assert.Equal(t, 0, resp.Code)
+	resp = doWorkflowRequest(t, router, http.MethodGet, "/api/v1/workflows/"+wf.ID, "2", nil)
+	assert.Equal(t, 403, resp.Code)
+	resp = doWorkflowRequest(t, router, http.MethodPost, "/api/v1/workflows/missing/cancel", "1", nil)
+	assert.Equal(t, 404, resp.Code)
+}
+
+func TestWorkflowController_RejectsCycle(t *testing.T) {
+	router := setupWorkflowRouter(t)
+
+	resp := doWorkflowRequest(t, router, http.MethodPost, "/api/v1/workflows", "1", map[string]any{
+		"tasks": []map[string]any{
+			{"key": "a", "command": "echo a", "depends_on": []string{"b"}},
+			{"key": "b", "command": "echo b", "depends_on": []string{"a"}},
+		},
+	})
+	assert.Equal(t, 400, resp.Code)
+	assert.Contains(t, resp.Msg, "cycle")
+}
@@ -220,3 +220,24 @@ func (c *WorkspaceController) ListMembers(ctx *gin.Context) {
 	}
 	c.Success(ctx, members)
 }
+
+// UpdateQuota 更新工作空间存储配额（管理员）
+func (c *WorkspaceController) UpdateQuota(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(ctx, 400, "无效的工作空间 ID")
+		return
+	}
+
+	var req apiV1.UpdateWorkspaceQuotaRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		c.Error(ctx, 400, err.Error())
+		return
+	}
+
+	if err := c.workspaceService.UpdateStorageQuota(ctx, uint(id), req.QuotaStorage); err != nil {
+		c.Error(ctx, 404, "工作空间不存在")
+		return
+	}
+	c.Success(ctx, nil)
+}
@@ -0,0 +1,72 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+	"gorm.io/gorm"
+)
+
+// AccessTokenDao 个人访问令牌数据访问层
+type AccessTokenDao struct {
+	db *gorm.DB
+}
+
+func NewAccessTokenDao(db *gorm.DB) *AccessTokenDao {
+	return &AccessTokenDao{db: db}
+}
+
+func (d *AccessTokenDao) Create(ctx context.Context, token *entity.PersonalAccessToken) error {
+	return d.db.WithContext(ctx).Create(token).Error
+}
+
+func (d *AccessTokenDao) FindByID(ctx context.Context, id uint) (*entity.PersonalAccessToken, error) {
+	var token entity.PersonalAccessToken
+	if err := d.db.WithContext(ctx).First(&token, id).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// FindByHash 按令牌哈希查找，不存在时返回 gorm.ErrRecordNotFound
+func (d *AccessTokenDao) FindByHash(ctx context.Context, hash string) (*entity.PersonalAccessToken, error) {
+	var tokens []entity.PersonalAccessToken
+	if err := d.db.WithContext(ctx).Where("token_hash = ?", hash).Limit(1).Find(&tokens).Error; err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &tokens[0], nil
+}
+
+// ListByCustomerID 列出客户的令牌（含已吊销和已过期），按创建时间倒序
+func (d *AccessTokenDao) ListByCustomerID(ctx context.Context, customerID uint) ([]entity.PersonalAccessToken, error) {
+	var tokens []entity.PersonalAccessToken
+	err := d.db.WithContext(ctx).Where("customer_id = ?", customerID).Order("created_at DESC, id DESC").Find(&tokens).Error
+	return tokens, err
+}
+
+// CountActive 统计客户未吊销且未过期的令牌数量
+func (d *AccessTokenDao) CountActive(ctx context.Context, customerID uint, now time.Time) (int64, error) {
+	var count int64
+	err := d.db.WithContext(ctx).Model(&entity.PersonalAccessToken{}).
+		Where("customer_id = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", customerID, now).
+		Count(&count).Error
+	return count, err
+}
+
+// Revoke 吊销令牌
+func (d *AccessTokenDao) Revoke(ctx context.Context, id uint, now time.Time) error {
+	return d.db.WithContext(ctx).Model(&entity.PersonalAccessToken{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", now).Error
+}
+
+// TouchLastUsed 更新最后使用时间
+func (d *AccessTokenDao) TouchLastUsed(ctx context.Context, id uint, now time.Time) error {
+	return d.db.WithContext(ctx).Model(&entity.PersonalAccessToken{}).
+		Where("id = ?", id).
+		Update("last_used_at", now).Error
+}
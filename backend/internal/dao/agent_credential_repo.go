@@ -0,0 +1,66 @@
+package dao
+
+import (
+	"context"
+
+	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+	"gorm.io/gorm"
+)
+
+// AgentCredentialDao Agent 凭证数据访问层
+type AgentCredentialDao struct {
+	db *gorm.DB
+}
+
+func NewAgentCredentialDao(db *gorm.DB) *AgentCredentialDao {
+	return &AgentCredentialDao{db: db}
+}
+
+// Create 创建凭证
+func (d *AgentCredentialDao) Create(ctx context.Context, cred *entity.AgentCredential) error {
+	return d.db.WithContext(ctx).Create(cred).Error
+}
+
+// FindByHostID 根据主机 ID 查询凭证
+func (d *AgentCredentialDao) FindByHostID(ctx context.Context, hostID string) (*entity.AgentCredential, error) {
+	var cred entity.AgentCredential
+	if err := d.db.WithContext(ctx).First(&cred, "host_id = ?", hostID).Error; err != nil {
+		return nil, err
+	}
+	return &cred, nil
+}
+
+// FindBySecretHash 根据密钥摘要查询生效中的凭证
+func (d *AgentCredentialDao) FindBySecretHash(ctx context.Context, hash string) (*entity.AgentCredential, error) {
+	var cred entity.AgentCredential
+	if err := d.db.WithContext(ctx).
+		Where("secret_hash = ? AND status = ?", hash, "active").
+		First(&cred).Error; err != nil {
+		return nil, err
+	}
+	return &cred, nil
+}
+
+// FindByEnrollmentTokenHash 根据注册令牌摘要查询凭证
+func (d *AgentCredentialDao) FindByEnrollmentTokenHash(ctx context.Context, hash string) (*entity.AgentCredential, error) {
+	var cred entity.AgentCredential
+	if err := d.db.WithContext(ctx).
+		Where("enrollment_token_hash = ? AND status <> ?", hash, "revoked").
+		First(&cred).Error; err != nil {
+		return nil, err
+	}
+	return &cred, nil
+}
+
+// ConsumeEnrollment 以注册令牌摘要为条件更新凭证，保证令牌只能被兑换一次
+func (d *AgentCredentialDao) ConsumeEnrollment(ctx context.Context, id uint, tokenHash string, fields map[string]interface{}) (bool, error) {
+	result := d.db.WithContext(ctx).Model(&entity.AgentCredential{}).
+		Where("id = ? AND enrollment_token_hash = ?", id, tokenHash).
+		Updates(fields)
+	return result.RowsAffected > 0, result.Error
+}
+
+// Updates 更新凭证指定字段
+func (d *AgentCredentialDao) Updates(ctx context.Context, id uint, fields map[string]interface{}) error {
+	return d.db.WithContext(ctx).Model(&entity.AgentCredential{}).Where("id = ?", id).Updates(fields).Error
+}
@@ -0,0 +1,30 @@
+package dao
+
+import (
+	"context"
+
+	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+	"gorm.io/gorm"
+)
+
+type AllocationRenewalDao struct {
+	db *gorm.DB
+}
+
+func NewAllocationRenewalDao(db *gorm.DB) *AllocationRenewalDao {
+	return &AllocationRenewalDao{db: db}
+}
+
+func (d *AllocationRenewalDao) Create(ctx context.Context, renewal *entity.AllocationRenewal) error {
+	return d.db.WithContext(ctx).Create(renewal).Error
+}
+
+// ListByAllocationID 查询分配的续期记录（按时间倒序）
+func (d *AllocationRenewalDao) ListByAllocationID(ctx context.Context, allocationID string) ([]entity.AllocationRenewal, error) {
+	var renewals []entity.AllocationRenewal
+	err := d.db.WithContext(ctx).
+		Where("allocation_id = ?", allocationID).
+		Order("created_at desc, id desc").
+		Find(&renewals).Error
+	return renewals, err
+}
@@ -2,6 +2,7 @@ package dao
 
 import (
 	"context"
+	"time"
 
 	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
 	"gorm.io/gorm"
@@ -120,6 +121,15 @@ func (d *AllocationDao) CountActiveByCustomerID(ctx context.Context, customerID
 	return count, err
 }
 
+// HasActiveByHostAndCustomer 判断客户在指定机器上是否有活跃分配
+func (d *AllocationDao) HasActiveByHostAndCustomer(ctx context.Context, hostID string, customerID uint) (bool, error) {
+	var count int64
+	err := d.db.WithContext(ctx).Model(&entity.Allocation{}).
+		Where("host_id = ? AND customer_id = ? AND status = ?", hostID, customerID, "active").
+		Count(&count).Error
+	return count > 0, err
+}
+
 // List 分页查询分配记录（管理端），支持按客户/机器/状态筛选
 func (d *AllocationDao) List(ctx context.Context, page, pageSize int, filters map[string]interface{}) ([]entity.Allocation, int64, error) {
 	var allocations []entity.Allocation
@@ -153,12 +163,119 @@ func (d *AllocationDao) List(ctx context.Context, page, pageSize int, filters ma
 	return allocations, total, err
 }
 
-// CountGPUsByCustomerID 统计客户已分配的 GPU 数量（通过活跃分配关联的主机上的 GPU）
+// CountGPUsByCustomerID 统计客户已分配的 GPU 数量（整机分配计机器上的全部 GPU，GPU 粒度分配计绑定的 GPU）
 func (d *AllocationDao) CountGPUsByCustomerID(ctx context.Context, customerID uint) (int64, error) {
 	var count int64
 	err := d.db.WithContext(ctx).Model(&entity.GPU{}).
 		Joins("JOIN allocations ON allocations.host_id = gpus.host_id").
 		Where("allocations.customer_id = ? AND allocations.status = ?", customerID, "active").
+		Where("allocations.gpu_indexes IS NULL OR gpus.allocated_to = allocations.id").
+		Count(&count).Error
+	return count, err
+}
+
+// CountGPUsByCustomerInWindow 统计客户在 [start, end) 时间窗口内有效分配占用的 GPU 数量，计数口径同 CountGPUsByCustomerID
+func (d *AllocationDao) CountGPUsByCustomerInWindow(ctx context.Context, customerID uint, start, end time.Time) (int64, error) {
+	var count int64
+	err := d.db.WithContext(ctx).Model(&entity.GPU{}).
+		Joins("JOIN allocations ON allocations.host_id = gpus.host_id").
+		Where("allocations.customer_id = ? AND allocations.status IN ?", customerID, []string{"active", "pending"}).
+		Where("allocations.start_time < ? AND allocations.end_time > ?", end, start).
+		Where("allocations.gpu_indexes IS NULL OR gpus.allocated_to = allocations.id").
 		Count(&count).Error
 	return count, err
 }
+
+// FindAllActiveByHostID 查询机器上的所有活跃分配（GPU 粒度分配时可能有多个）
+func (d *AllocationDao) FindAllActiveByHostID(ctx context.Context, hostID string) ([]entity.Allocation, error) {
+	var allocations []entity.Allocation
+	err := d.db.WithContext(ctx).
+		Preload("Customer").
+		Where("host_id = ? AND status = ?", hostID, "active").
+		Order("created_at asc").
+		Find(&allocations).Error
+	return allocations, err
+}
+
+// FindAllActiveByHostAndCustomer 查询客户在指定机器上的所有活跃分配
+func (d *AllocationDao) FindAllActiveByHostAndCustomer(ctx context.Context, hostID string, customerID uint) ([]entity.Allocation, error) {
+	var allocations []entity.Allocation
+	err := d.db.WithContext(ctx).
+		Where("host_id = ? AND customer_id = ? AND status = ?", hostID, customerID, "active").
+		Find(&allocations).Error
+	return allocations, err
+}
+
+// HostAllocationStatus 根据机器上的活跃分配计算分配状态：
+// 无分配为 idle，有整机分配或 GPU 已全部分配为 allocated，否则为 partial
+func (d *AllocationDao) HostAllocationStatus(ctx context.Context, hostID string) (string, error) {
+	var allocations []entity.Allocation
+	if err := d.db.WithContext(ctx).Select("id", "gpu_indexes").
+		Where("host_id = ? AND status = ?", hostID, "active").
+		Find(&allocations).Error; err != nil {
+		return "", err
+	}
+	if len(allocations) == 0 {
+		return "idle", nil
+	}
+	for _, a := range allocations {
+		if len(a.GPUIndexes) == 0 || string(a.GPUIndexes) == "null" {
+			return "allocated", nil
+		}
+	}
+
+	var free int64
+	if err := d.db.WithContext(ctx).Model(&entity.GPU{}).
+		Where("host_id = ? AND status = ? AND (allocated_to IS NULL OR allocated_to = '')", hostID, "available").
+		Count(&free).Error; err != nil {
+		return "", err
+	}
+	if free > 0 {
+		return "partial", nil
+	}
+	return "allocated", nil
+}
+
+// ListExpiring 查询即将到期且尚未提醒的活跃分配（now < end_time <= before）
+func (d *AllocationDao) ListExpiring(ctx context.Context, now, before time.Time, limit int) ([]entity.Allocation, error) {
+	var allocations []entity.Allocation
+	err := d.db.WithContext(ctx).
+		Preload("Customer").
+		Preload("Host").
+		Where("status = ? AND end_time > ? AND end_time <= ? AND expiry_warned_at IS NULL", "active", now, before).
+		Order("end_time asc").
+		Limit(limit).
+		Find(&allocations).Error
+	return allocations, err
+}
+
+// ListExpired 查询已到期仍为活跃状态的分配
+func (d *AllocationDao) ListExpired(ctx context.Context, now time.Time, limit int) ([]entity.Allocation, error) {
+	var allocations []entity.Allocation
+	err := d.db.WithContext(ctx).
+		Preload("Customer").
+		Preload("Host").
+		Where("status = ? AND end_time <= ?", "active", now).
+		Order("end_time asc").
+		Limit(limit).
+		Find(&allocations).Error
+	return allocations, err
+}
+
+// MarkExpiryWarned 记录已发送到期提醒
+func (d *AllocationDao) MarkExpiryWarned(ctx context.Context, id string, at time.Time) error {
+	return d.db.WithContext(ctx).Model(&entity.Allocation{}).Where("id = ?", id).Update("expiry_warned_at", at).Error
+}
+
+// OverlappingHostIDs 查询在 [start, end) 时间窗口内存在活跃分配的机器
+func (d *AllocationDao) OverlappingHostIDs(ctx context.Context, hostIDs []string, start, end time.Time) ([]string, error) {
+	var ids []string
+	if len(hostIDs) == 0 {
+		return ids, nil
+	}
+	err := d.db.WithContext(ctx).Model(&entity.Allocation{}).
+		Distinct("host_id").
+		Where("host_id IN ? AND status IN ? AND start_time < ? AND end_time > ?", hostIDs, []string{"active", "pending"}, end, start).
+		Pluck("host_id", &ids).Error
+	return ids, err
+}
@@ -0,0 +1,250 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+	"gorm.io/gorm"
+)
+
+type BillingDao struct {
+	db *gorm.DB
+}
+
+func NewBillingDao(db *gorm.DB) *BillingDao {
+	return &BillingDao{db: db}
+}
+
+// UsageFilter 用量记录查询条件，记录按开始时间归属到 [Start, End) 区间，零值表示不限
+type UsageFilter struct {
+	CustomerID  uint
+	WorkspaceID *uint
+	SourceType  string
+	Start       time.Time
+	End         time.Time
+}
+
+// UsageSummary 按来源、工作空间、GPU 型号和单价汇总的用量
+type UsageSummary struct {
+	SourceType  string  `json:"source_type"`
+	WorkspaceID *uint   `json:"workspace_id,omitempty"`
+	GPUModel    string  `json:"gpu_model"`
+	UnitPrice   float64 `json:"unit_price"`
+	GPUHours    float64 `json:"gpu_hours"`
+	Amount      float64 `json:"amount"`
+	RecordCount int     `json:"record_count"`
+}
+
+func (d *BillingDao) usageQuery(ctx context.Context, f UsageFilter) *gorm.DB {
+	query := d.db.WithContext(ctx).Model(&entity.UsageRecord{})
+	if f.CustomerID != 0 {
+		query = query.Where("customer_id = ?", f.CustomerID)
+	}
+	if f.WorkspaceID != nil {
+		query = query.Where("workspace_id = ?", *f.WorkspaceID)
+	}
+	if f.SourceType != "" {
+		query = query.Where("source_type = ?", f.SourceType)
+	}
+	if !f.Start.IsZero() {
+		query = query.Where("start_time >= ?", f.Start)
+	}
+	if !f.End.IsZero() {
+		query = query.Where("start_time < ?", f.End)
+	}
+	return query
+}
+
+// ListUsage 分页查询用量记录，pageSize <= 0 时返回全部（用于导出）
+func (d *BillingDao) ListUsage(ctx context.Context, f UsageFilter, page, pageSize int) ([]entity.UsageRecord, int64, error) {
+	var records []entity.UsageRecord
+	var total int64
+
+	query := d.usageQuery(ctx, f)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	query = query.Order("start_time asc, id asc")
+	if pageSize > 0 {
+		query = query.Offset((page - 1) * pageSize).Limit(pageSize)
+	}
+	err := query.Find(&records).Error
+	return records, total, err
+}
+
+// SummarizeUsage 汇总用量记录
+func (d *BillingDao) SummarizeUsage(ctx context.Context, f UsageFilter) ([]UsageSummary, error) {
+	var summary []UsageSummary
+	err := d.usageQuery(ctx, f).
+		Select("source_type, workspace_id, gpu_model, unit_price, SUM(gpu_hours) AS gpu_hours, SUM(amount) AS amount, COUNT(*) AS record_count").
+		Group("source_type, workspace_id, gpu_model, unit_price").
+		Order("source_type, gpu_model").
+		Scan(&summary).Error
+	return summary, err
+}
+
+// LastUsage 查询资源最近的一条用量记录，没有记录时返回 nil
+func (d *BillingDao) LastUsage(ctx context.Context, sourceType, sourceID string) (*entity.UsageRecord, error) {
+	var records []entity.UsageRecord
+	err := d.db.WithContext(ctx).
+		Where("source_type = ? AND source_id = ?", sourceType, sourceID).
+		Order("end_time desc, id desc").
+		Limit(1).
+		Find(&records).Error
+	if err != nil || len(records) == 0 {
+		return nil, err
+	}
+	return &records[0], nil
+}
+
+func (d *BillingDao) CreateUsage(ctx context.Context, record *entity.UsageRecord) error {
+	return d.db.WithContext(ctx).Create(record).Error
+}
+
+// ExtendUsage 延长未出账的用量记录，已出账的记录不会被修改，返回是否更新成功
+func (d *BillingDao) ExtendUsage(ctx context.Context, record *entity.UsageRecord) (bool, error) {
+	result := d.db.WithContext(ctx).Model(&entity.UsageRecord{}).
+		Where("id = ? AND invoice_id IS NULL", record.ID).
+		Updates(map[string]interface{}{
+			"end_time":         record.EndTime,
+			"duration_seconds": record.DurationSeconds,
+			"gpu_hours":        record.GPUHours,
+			"amount":           record.Amount,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// ListMeterableAllocations 查询需要计量的分配：活跃中或在 since 之后结束
+func (d *BillingDao) ListMeterableAllocations(ctx context.Context, since time.Time) ([]entity.Allocation, error) {
+	var allocs []entity.Allocation
+	err := d.db.WithContext(ctx).
+		Where("status = ? OR actual_end_time >= ?", "active", since).
+		Find(&allocs).Error
+	return allocs, err
+}
+
+// ListMeterableEnvironments 查询需要计量的环境：运行中或在 since 之后停止
+func (d *BillingDao) ListMeterableEnvironments(ctx context.Context, since time.Time) ([]entity.Environment, error) {
+	var envs []entity.Environment
+	err := d.db.WithContext(ctx).
+		Where("started_at IS NOT NULL AND gpu > 0").
+		Where("status = ? OR stopped_at >= ?", "running", since).
+		Find(&envs).Error
+	return envs, err
+}
+
+// ListMeterableTasks 查询需要计量的任务：运行中或在 since 之后结束
+func (d *BillingDao) ListMeterableTasks(ctx context.Context, since time.Time) ([]entity.Task, error) {
+	var tasks []entity.Task
+	err := d.db.WithContext(ctx).
+		Where("started_at IS NOT NULL").
+		Where("(status = ? AND ended_at IS NULL) OR ended_at >= ?", "running", since).
+		Find(&tasks).Error
+	return tasks, err
+}
+
+// HostGPUs 查询机器的 GPU 型号（按序号取第一张卡）和数量
+func (d *BillingDao) HostGPUs(ctx context.Context, hostID string) (string, int, error) {
+	var gpus []entity.GPU
+	if err := d.db.WithContext(ctx).Where("host_id = ?", hostID).Order(`"index"`).Find(&gpus).Error; err != nil {
+		return "", 0, err
+	}
+	if len(gpus) == 0 {
+		return "", 0, nil
+	}
+	return gpus[0].Name, len(gpus), nil
+}
+
+// CustomersWithUnbilledUsage 查询在 before 之前有未出账用量的客户
+func (d *BillingDao) CustomersWithUnbilledUsage(ctx context.Context, before time.Time) ([]uint, error) {
+	var ids []uint
+	err := d.db.WithContext(ctx).Model(&entity.UsageRecord{}).
+		Distinct("customer_id").
+		Where("invoice_id IS NULL AND end_time <= ?", before).
+		Order("customer_id").
+		Pluck("customer_id", &ids).Error
+	return ids, err
+}
+
+// ListUnbilledUsage 查询客户在 before 之前结束的未出账用量记录
+func (d *BillingDao) ListUnbilledUsage(ctx context.Context, customerID uint, before time.Time) ([]entity.UsageRecord, error) {
+	var records []entity.UsageRecord
+	err := d.db.WithContext(ctx).
+		Where("customer_id = ? AND invoice_id IS NULL AND end_time <= ?", customerID, before).
+		Order("start_time asc, id asc").
+		Find(&records).Error
+	return records, err
+}
+
+// InvoiceExists 客户在该账期是否已有账单
+func (d *BillingDao) InvoiceExists(ctx context.Context, customerID uint, periodStart time.Time) (bool, error) {
+	var count int64
+	err := d.db.WithContext(ctx).Model(&entity.Invoice{}).
+		Where("customer_id = ? AND billing_period_start = ?", customerID, periodStart).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// CreateInvoice 创建账单及明细，并将用量记录标记为已出账，只标记仍未出账的记录，数量不一致时返回错误
+func (d *BillingDao) CreateInvoice(ctx context.Context, invoice *entity.Invoice, recordIDs []uint) error {
+	if err := d.db.WithContext(ctx).Create(invoice).Error; err != nil {
+		return err
+	}
+	if len(recordIDs) == 0 {
+		return nil
+	}
+	result := d.db.WithContext(ctx).Model(&entity.UsageRecord{}).
+		Where("id IN ? AND invoice_id IS NULL", recordIDs).
+		Update("invoice_id", invoice.ID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected != int64(len(recordIDs)) {
+		return errors.New("用量记录已被其他账单计入")
+	}
+	return nil
+}
+
+func (d *BillingDao) FindInvoice(ctx context.Context, id uint) (*entity.Invoice, error) {
+	var invoice entity.Invoice
+	if err := d.db.WithContext(ctx).Preload("Items").First(&invoice, id).Error; err != nil {
+		return nil, err
+	}
+	return &invoice, nil
+}
+
+// ListInvoices 分页查询账单，支持按客户/状态筛选
+func (d *BillingDao) ListInvoices(ctx context.Context, page, pageSize int, filters map[string]interface{}) ([]entity.Invoice, int64, error) {
+	var invoices []entity.Invoice
+	var total int64
+
+	query := d.db.WithContext(ctx).Model(&entity.Invoice{})
+	if customerID, ok := filters["customer_id"]; ok {
+		query = query.Where("customer_id = ?", customerID)
+	}
+	if status, ok := filters["status"]; ok {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.
+		Order("billing_period_start desc, id desc").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&invoices).Error
+	return invoices, total, err
+}
+
+// ListInvoiceUsage 查询账单计入的用量记录
+func (d *BillingDao) ListInvoiceUsage(ctx context.Context, invoiceID uint) ([]entity.UsageRecord, error) {
+	var records []entity.UsageRecord
+	err := d.db.WithContext(ctx).
+		Where("invoice_id = ?", invoiceID).
+		Order("start_time asc, id asc").
+		Find(&records).Error
+	return records, err
+}
@@ -0,0 +1,42 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+	"gorm.io/gorm"
+)
+
+// CustomerIdentityDao 外部身份数据访问层
+type CustomerIdentityDao struct {
+	db *gorm.DB
+}
+
+func NewCustomerIdentityDao(db *gorm.DB) *CustomerIdentityDao {
+	return &CustomerIdentityDao{db: db}
+}
+
+// Create 创建外部身份关联
+func (d *CustomerIdentityDao) Create(ctx context.Context, identity *entity.CustomerIdentity) error {
+	return d.db.WithContext(ctx).Create(identity).Error
+}
+
+// FindByProviderSubject 按身份源和用户标识查询
+func (d *CustomerIdentityDao) FindByProviderSubject(ctx context.Context, provider, subject string) (*entity.CustomerIdentity, error) {
+	var identity entity.CustomerIdentity
+	err := d.db.WithContext(ctx).
+		Where("provider = ? AND subject = ?", provider, subject).
+		First(&identity).Error
+	if err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+// TouchLastLogin 更新最后登录时间
+func (d *CustomerIdentityDao) TouchLastLogin(ctx context.Context, id uint, at time.Time) error {
+	return d.db.WithContext(ctx).Model(&entity.CustomerIdentity{}).
+		Where("id = ?", id).
+		Update("last_login_at", at).Error
+}
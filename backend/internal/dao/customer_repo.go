@@ -2,6 +2,7 @@ package dao
 
 import (
 	"context"
+	"time"
 
 	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
 	"gorm.io/gorm"
@@ -25,6 +26,15 @@ func (d *CustomerDao) FindByUsername(ctx context.Context, username string) (*ent
 	return &customer, nil
 }
 
+// FindByEmail 按邮箱查询客户
+func (d *CustomerDao) FindByEmail(ctx context.Context, email string) (*entity.Customer, error) {
+	var customer entity.Customer
+	if err := d.db.WithContext(ctx).Where("email = ?", email).First(&customer).Error; err != nil {
+		return nil, err
+	}
+	return &customer, nil
+}
+
 func (d *CustomerDao) List(ctx context.Context, page, pageSize int) ([]entity.Customer, int64, error) {
 	var customers []entity.Customer
 	var total int64
@@ -76,3 +86,92 @@ func (d *CustomerDao) UpdateQuota(ctx context.Context, id uint, quotaGPU int, qu
 			"quota_storage": quotaStorage,
 		}).Error
 }
+
+// ListActiveByRole 查询指定角色下所有活跃客户
+func (d *CustomerDao) ListActiveByRole(ctx context.Context, role string) ([]entity.Customer, error) {
+	var customers []entity.Customer
+	err := d.db.WithContext(ctx).
+		Where("role = ? AND status = ?", role, "active").
+		Order("id asc").
+		Find(&customers).Error
+	return customers, err
+}
+
+// IncrementLoginFailures 登录失败次数加一，返回累计失败次数
+func (d *CustomerDao) IncrementLoginFailures(ctx context.Context, id uint) (int, error) {
+	err := d.db.WithContext(ctx).Model(&entity.Customer{}).Where("id = ?", id).
+		Update("failed_login_attempts", gorm.Expr("failed_login_attempts + 1")).Error
+	if err != nil {
+		return 0, err
+	}
+	var attempts int
+	err = d.db.WithContext(ctx).Model(&entity.Customer{}).Where("id = ?", id).
+		Pluck("failed_login_attempts", &attempts).Error
+	return attempts, err
+}
+
+// LockAccount 失败次数达到阈值时锁定账号并清零失败次数，返回是否锁定（并发请求只有一个会成功）
+func (d *CustomerDao) LockAccount(ctx context.Context, id uint, until time.Time, threshold int) (bool, error) {
+	result := d.db.WithContext(ctx).Model(&entity.Customer{}).
+		Where("id = ? AND failed_login_attempts >= ?", id, threshold).
+		Updates(map[string]interface{}{
+			"locked_until":          until,
+			"lockout_count":         gorm.Expr("lockout_count + 1"),
+			"failed_login_attempts": 0,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// ResetLoginFailures 清除登录失败记录并解除锁定
+func (d *CustomerDao) ResetLoginFailures(ctx context.Context, id uint) error {
+	return d.db.WithContext(ctx).Model(&entity.Customer{}).Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"failed_login_attempts": 0,
+			"lockout_count":         0,
+			"locked_until":          nil,
+		}).Error
+}
+
+// SetMFASecret 保存开通中的两步验证密钥（尚未启用）
+func (d *CustomerDao) SetMFASecret(ctx context.Context, id uint, encryptedSecret string) error {
+	return d.db.WithContext(ctx).Model(&entity.Customer{}).Where("id = ? AND mfa_enabled = ?", id, false).
+		Updates(map[string]interface{}{
+			"mfa_secret":    encryptedSecret,
+			"mfa_last_step": 0,
+		}).Error
+}
+
+// EnableMFA 启用两步验证
+func (d *CustomerDao) EnableMFA(ctx context.Context, id uint, step int64) error {
+	return d.db.WithContext(ctx).Model(&entity.Customer{}).Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"mfa_enabled":    true,
+			"mfa_enabled_at": time.Now(),
+			"mfa_last_step":  step,
+		}).Error
+}
+
+// AdvanceMFAStep 记录通过校验的时间步，只允许递增，返回是否更新成功（同一验证码不能重复使用）
+func (d *CustomerDao) AdvanceMFAStep(ctx context.Context, id uint, step int64) (bool, error) {
+	result := d.db.WithContext(ctx).Model(&entity.Customer{}).
+		Where("id = ? AND mfa_last_step < ?", id, step).
+		Update("mfa_last_step", step)
+	return result.RowsAffected > 0, result.Error
+}
+
+// ResetMFA 关闭两步验证，清除密钥和恢复码
+func (d *CustomerDao) ResetMFA(ctx context.Context, id uint) error {
+	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&entity.Customer{}).Where("id = ?", id).
+			Updates(map[string]interface{}{
+				"mfa_enabled":    false,
+				"mfa_secret":     "",
+				"mfa_last_step":  0,
+				"mfa_enabled_at": nil,
+			}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Where("customer_id = ?", id).Delete(&entity.MFARecoveryCode{}).Error
+	})
+}
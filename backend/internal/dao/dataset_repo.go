@@ -2,6 +2,7 @@ package dao
 
 import (
 	"context"
+	"time"
 
 	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
 	"gorm.io/gorm"
@@ -51,11 +52,15 @@ func (d *DatasetDao) FindByID(ctx context.Context, id uint) (*entity.Dataset, er
 	return &dataset, nil
 }
 
+// storageExcludedStatuses 不计入存储用量的数据集状态
+// 上传中的数据集按声明大小计入，为进行中的上传预留配额
+var storageExcludedStatuses = []string{"deleted", "aborted"}
+
 // SumStorageByCustomerID 统计客户数据集总存储用量（字节）
 func (d *DatasetDao) SumStorageByCustomerID(ctx context.Context, customerID uint) (int64, error) {
 	var total *int64
 	err := d.db.WithContext(ctx).Model(&entity.Dataset{}).
-		Where("customer_id = ? AND status != ?", customerID, "deleted").
+		Where("customer_id = ? AND status NOT IN ?", customerID, storageExcludedStatuses).
 		Select("COALESCE(SUM(total_size), 0)").
 		Scan(&total).Error
 	if err != nil {
@@ -65,4 +70,55 @@ func (d *DatasetDao) SumStorageByCustomerID(ctx context.Context, customerID uint
 		return 0, nil
 	}
 	return *total, nil
-}
\ No newline at end of file
+}
+
+// SumStorageByWorkspaceID 统计工作空间内所有成员数据集的存储用量（字节）
+func (d *DatasetDao) SumStorageByWorkspaceID(ctx context.Context, workspaceID uint) (int64, error) {
+	var total int64
+	err := d.db.WithContext(ctx).Model(&entity.Dataset{}).
+		Where("workspace_id = ? AND status NOT IN ?", workspaceID, storageExcludedStatuses).
+		Select("COALESCE(SUM(total_size), 0)").
+		Scan(&total).Error
+	return total, err
+}
+
+// DatasetStorageStat 按工作空间和状态分组的数据集存储统计
+type DatasetStorageStat struct {
+	WorkspaceID *uint
+	Status      string
+	Count       int64
+	Bytes       int64
+}
+
+// StorageStatsByCustomerID 按工作空间和状态统计客户数据集存储用量
+func (d *DatasetDao) StorageStatsByCustomerID(ctx context.Context, customerID uint) ([]DatasetStorageStat, error) {
+	var stats []DatasetStorageStat
+	err := d.db.WithContext(ctx).Model(&entity.Dataset{}).
+		Where("customer_id = ? AND status NOT IN ?", customerID, storageExcludedStatuses).
+		Select("workspace_id, status, COUNT(*) AS count, COALESCE(SUM(total_size), 0) AS bytes").
+		Group("workspace_id, status").
+		Scan(&stats).Error
+	return stats, err
+}
+
+// ListExpiredUploads 查询上传已过期但仍处于 uploading 状态的数据集
+func (d *DatasetDao) ListExpiredUploads(ctx context.Context, now time.Time, limit int) ([]entity.Dataset, error) {
+	var datasets []entity.Dataset
+	err := d.db.WithContext(ctx).
+		Where("status = ? AND upload_expires_at IS NOT NULL AND upload_expires_at < ?", "uploading", now).
+		Order("upload_expires_at ASC").
+		Limit(limit).
+		Find(&datasets).Error
+	return datasets, err
+}
+
+// FinishUpload 结束分片上传：仅当数据集仍处于该上传时更新，返回是否更新成功
+func (d *DatasetDao) FinishUpload(ctx context.Context, id uint, uploadID string, fields map[string]interface{}) (bool, error) {
+	fields["upload_id"] = ""
+	fields["upload_key"] = ""
+	fields["upload_expires_at"] = nil
+	res := d.db.WithContext(ctx).Model(&entity.Dataset{}).
+		Where("id = ? AND status = ? AND upload_id = ?", id, "uploading", uploadID).
+		Updates(fields)
+	return res.RowsAffected > 0, res.Error
+}
@@ -0,0 +1,143 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GPUMetricDao GPU 监控指标数据访问层
+type GPUMetricDao struct {
+	db *gorm.DB
+}
+
+func NewGPUMetricDao(db *gorm.DB) *GPUMetricDao {
+	return &GPUMetricDao{db: db}
+}
+
+// GPUTrendBucket 所有 GPU 在一个时间桶内的平均利用率
+type GPUTrendBucket struct {
+	BucketStart time.Time
+	UtilAvg     float64
+}
+
+// BatchCreate 批量写入原始指标
+func (d *GPUMetricDao) BatchCreate(ctx context.Context, metrics []entity.GPUMetric) error {
+	if len(metrics) == 0 {
+		return nil
+	}
+	return d.db.WithContext(ctx).Create(&metrics).Error
+}
+
+// ListRaw 按采集时间升序查询 [start, end) 内的原始指标
+func (d *GPUMetricDao) ListRaw(ctx context.Context, start, end time.Time) ([]entity.GPUMetric, error) {
+	var metrics []entity.GPUMetric
+	err := d.db.WithContext(ctx).
+		Where("collected_at >= ? AND collected_at < ?", start, end).
+		Order("collected_at asc").
+		Find(&metrics).Error
+	return metrics, err
+}
+
+// FirstRawSince 不早于 since 的最早原始指标采集时间，没有数据时返回 nil
+func (d *GPUMetricDao) FirstRawSince(ctx context.Context, since time.Time) (*time.Time, error) {
+	var metrics []entity.GPUMetric
+	err := d.db.WithContext(ctx).
+		Where("collected_at >= ?", since).
+		Order("collected_at asc").
+		Limit(1).
+		Find(&metrics).Error
+	if err != nil || len(metrics) == 0 {
+		return nil, err
+	}
+	return &metrics[0].CollectedAt, nil
+}
+
+// ListRollups 按时间桶升序查询指定粒度在 [start, end) 内的降采样数据
+func (d *GPUMetricDao) ListRollups(ctx context.Context, resolution string, start, end time.Time) ([]entity.GPUMetricRollup, error) {
+	var rollups []entity.GPUMetricRollup
+	err := d.db.WithContext(ctx).
+		Where("resolution = ? AND bucket_start >= ? AND bucket_start < ?", resolution, start, end).
+		Order("bucket_start asc").
+		Find(&rollups).Error
+	return rollups, err
+}
+
+// LatestRollup 指定粒度最新的时间桶，没有数据时返回 nil
+func (d *GPUMetricDao) LatestRollup(ctx context.Context, resolution string) (*time.Time, error) {
+	var rollups []entity.GPUMetricRollup
+	err := d.db.WithContext(ctx).
+		Where("resolution = ?", resolution).
+		Order("bucket_start desc").
+		Limit(1).
+		Find(&rollups).Error
+	if err != nil || len(rollups) == 0 {
+		return nil, err
+	}
+	return &rollups[0].BucketStart, nil
+}
+
+// FirstRollupSince 指定粒度不早于 since 的最早时间桶，没有数据时返回 nil
+func (d *GPUMetricDao) FirstRollupSince(ctx context.Context, resolution string, since time.Time) (*time.Time, error) {
+	var rollups []entity.GPUMetricRollup
+	err := d.db.WithContext(ctx).
+		Where("resolution = ? AND bucket_start >= ?", resolution, since).
+		Order("bucket_start asc").
+		Limit(1).
+		Find(&rollups).Error
+	if err != nil || len(rollups) == 0 {
+		return nil, err
+	}
+	return &rollups[0].BucketStart, nil
+}
+
+// SaveRollups 写入降采样数据，已存在的时间桶跳过（重复汇总时幂等）
+func (d *GPUMetricDao) SaveRollups(ctx context.Context, rollups []entity.GPUMetricRollup) error {
+	if len(rollups) == 0 {
+		return nil
+	}
+	return d.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		CreateInBatches(&rollups, 500).Error
+}
+
+// UtilizationTrend 按时间桶汇总所有 GPU 的平均利用率（按采样数加权）
+func (d *GPUMetricDao) UtilizationTrend(ctx context.Context, resolution string, since time.Time) ([]GPUTrendBucket, error) {
+	var buckets []GPUTrendBucket
+	err := d.db.WithContext(ctx).Model(&entity.GPUMetricRollup{}).
+		Select("bucket_start, SUM(util_avg * sample_count) / SUM(sample_count) AS util_avg").
+		Where("resolution = ? AND bucket_start >= ? AND util_avg IS NOT NULL AND sample_count > 0", resolution, since).
+		Group("bucket_start").
+		Order("bucket_start asc").
+		Scan(&buckets).Error
+	return buckets, err
+}
+
+// AvgUtilizationSince 所有 GPU 自指定时间以来原始指标的平均利用率，没有数据时返回 nil
+func (d *GPUMetricDao) AvgUtilizationSince(ctx context.Context, since time.Time) (*float64, error) {
+	var avg []*float64
+	err := d.db.WithContext(ctx).Model(&entity.GPUMetric{}).
+		Where("collected_at >= ? AND utilization_percent IS NOT NULL", since).
+		Pluck("AVG(utilization_percent)", &avg).Error
+	if err != nil || len(avg) == 0 {
+		return nil, err
+	}
+	return avg[0], nil
+}
+
+// DeleteRawBefore 删除指定时间之前的原始指标
+func (d *GPUMetricDao) DeleteRawBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := d.db.WithContext(ctx).Where("collected_at < ?", before).Delete(&entity.GPUMetric{})
+	return result.RowsAffected, result.Error
+}
+
+// DeleteRollupsBefore 删除指定粒度在指定时间之前的降采样数据
+func (d *GPUMetricDao) DeleteRollupsBefore(ctx context.Context, resolution string, before time.Time) (int64, error) {
+	result := d.db.WithContext(ctx).
+		Where("resolution = ? AND bucket_start < ?", resolution, before).
+		Delete(&entity.GPUMetricRollup{})
+	return result.RowsAffected, result.Error
+}
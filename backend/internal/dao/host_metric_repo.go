@@ -57,6 +57,16 @@ func (d *HostMetricDao) GetHistory(ctx context.Context, hostID string, start, en
 	return metrics, err
 }
 
+// ListSince 按采集时间升序获取机器自指定时间以来的监控数据
+func (d *HostMetricDao) ListSince(ctx context.Context, hostID string, since time.Time) ([]*entity.HostMetric, error) {
+	var metrics []*entity.HostMetric
+	err := d.db.WithContext(ctx).
+		Where("host_id = ? AND collected_at >= ?", hostID, since).
+		Order("collected_at ASC").
+		Find(&metrics).Error
+	return metrics, err
+}
+
 // DeleteOldRecords 删除旧的监控记录
 func (d *HostMetricDao) DeleteOldRecords(ctx context.Context, before time.Time) error {
 	return d.db.WithContext(ctx).
@@ -260,6 +260,32 @@ func (d *MachineDao) BatchUpdateAllocationStatus(ctx context.Context, ids []stri
 	return result.RowsAffected, result.Error
 }
 
+// ListGPUsByHostID 查询机器上的 GPU（按序号排序）
+func (d *MachineDao) ListGPUsByHostID(ctx context.Context, hostID string) ([]entity.GPU, error) {
+	var gpus []entity.GPU
+	err := d.db.WithContext(ctx).Where("host_id = ?", hostID).Order(`"index"`).Find(&gpus).Error
+	return gpus, err
+}
+
+// AssignGPUs 将 GPU 绑定到分配，状态异常的 GPU 保持原状态
+func (d *MachineDao) AssignGPUs(ctx context.Context, ids []uint, allocationID string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return d.db.WithContext(ctx).Model(&entity.GPU{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+		"allocated_to": allocationID,
+		"status":       gorm.Expr("CASE WHEN status = ? THEN ? ELSE status END", "available", "allocated"),
+	}).Error
+}
+
+// ReleaseGPUs 释放分配绑定的 GPU
+func (d *MachineDao) ReleaseGPUs(ctx context.Context, allocationID string) error {
+	return d.db.WithContext(ctx).Model(&entity.GPU{}).Where("allocated_to = ?", allocationID).Updates(map[string]interface{}{
+		"allocated_to": "",
+		"status":       gorm.Expr("CASE WHEN status = ? THEN ? ELSE status END", "allocated", "available"),
+	}).Error
+}
+
 // ListOnline 获取所有在线的机器
 func (d *MachineDao) ListOnline(ctx context.Context) ([]entity.Host, error) {
 	var hosts []entity.Host
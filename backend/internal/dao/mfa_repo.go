@@ -0,0 +1,52 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+	"gorm.io/gorm"
+)
+
+// MFARecoveryCodeDao 两步验证恢复码数据访问层
+type MFARecoveryCodeDao struct {
+	db *gorm.DB
+}
+
+func NewMFARecoveryCodeDao(db *gorm.DB) *MFARecoveryCodeDao {
+	return &MFARecoveryCodeDao{db: db}
+}
+
+// Replace 删除客户的全部恢复码并写入新的恢复码哈希
+func (d *MFARecoveryCodeDao) Replace(ctx context.Context, customerID uint, hashes []string) error {
+	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("customer_id = ?", customerID).Delete(&entity.MFARecoveryCode{}).Error; err != nil {
+			return err
+		}
+		if len(hashes) == 0 {
+			return nil
+		}
+		codes := make([]entity.MFARecoveryCode, 0, len(hashes))
+		for _, h := range hashes {
+			codes = append(codes, entity.MFARecoveryCode{CustomerID: customerID, CodeHash: h})
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+// Consume 将未使用的恢复码标记为已使用，返回是否匹配（并发请求只有一个会成功）
+func (d *MFARecoveryCodeDao) Consume(ctx context.Context, customerID uint, hash string) (bool, error) {
+	result := d.db.WithContext(ctx).Model(&entity.MFARecoveryCode{}).
+		Where("customer_id = ? AND code_hash = ? AND used_at IS NULL", customerID, hash).
+		Update("used_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
+
+// CountUnused 统计客户剩余可用的恢复码数量
+func (d *MFARecoveryCodeDao) CountUnused(ctx context.Context, customerID uint) (int64, error) {
+	var count int64
+	err := d.db.WithContext(ctx).Model(&entity.MFARecoveryCode{}).
+		Where("customer_id = ? AND used_at IS NULL", customerID).
+		Count(&count).Error
+	return count, err
+}
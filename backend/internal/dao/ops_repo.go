@@ -2,6 +2,7 @@ package dao
 
 import (
 	"context"
+	"time"
 
 	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
 	"gorm.io/gorm"
@@ -35,9 +36,10 @@ func (d *OpsDao) GetActiveAlerts(ctx context.Context) ([]entity.ActiveAlert, err
 // @author Claude
 // @description 支持分页和筛选的告警列表查询
 // @param severity 告警级别筛选（可选）
+// @param status 告警状态筛选 firing / resolved（可选）
 // @param acknowledged 是否已确认筛选（可选）
 // @modified 2026-02-04
-func (d *OpsDao) ListAlerts(ctx context.Context, page, pageSize int, severity string, acknowledged *bool) ([]entity.ActiveAlert, int64, error) {
+func (d *OpsDao) ListAlerts(ctx context.Context, page, pageSize int, severity, status string, acknowledged *bool) ([]entity.ActiveAlert, int64, error) {
 	var alerts []entity.ActiveAlert
 	var total int64
 
@@ -48,6 +50,9 @@ func (d *OpsDao) ListAlerts(ctx context.Context, page, pageSize int, severity st
 		query = query.Joins("JOIN alert_rules ON alert_rules.id = active_alerts.rule_id").
 			Where("alert_rules.severity = ?", severity)
 	}
+	if status != "" {
+		query = query.Where("active_alerts.status = ?", status)
+	}
 	if acknowledged != nil {
 		query = query.Where("acknowledged = ?", *acknowledged)
 	}
@@ -82,6 +87,30 @@ func (d *OpsDao) CreateAlert(ctx context.Context, alert *entity.ActiveAlert) err
 	return d.db.WithContext(ctx).Create(alert).Error
 }
 
+// FindFiringAlert 查询指定规则在指定机器上仍处于触发状态的告警
+func (d *OpsDao) FindFiringAlert(ctx context.Context, ruleID uint, hostID string) (*entity.ActiveAlert, error) {
+	var alert entity.ActiveAlert
+	err := d.db.WithContext(ctx).
+		Where("rule_id = ? AND host_id = ? AND status = ?", ruleID, hostID, "firing").
+		Order("triggered_at desc").
+		First(&alert).Error
+	if err != nil {
+		return nil, err
+	}
+	return &alert, nil
+}
+
+// ResolveAlert 将告警标记为已恢复
+func (d *OpsDao) ResolveAlert(ctx context.Context, id uint, resolvedAt time.Time) error {
+	return d.db.WithContext(ctx).
+		Model(&entity.ActiveAlert{}).
+		Where("id = ? AND status = ?", id, "firing").
+		Updates(map[string]any{
+			"status":      "resolved",
+			"resolved_at": resolvedAt,
+		}).Error
+}
+
 // --- AlertRule CRUD ---
 
 // CreateAlertRule 创建告警规则
@@ -120,6 +149,13 @@ func (d *OpsDao) ToggleAlertRule(ctx context.Context, id uint) (*entity.AlertRul
 	return &rule, nil
 }
 
+// ListEnabledAlertRules 查询所有已启用的告警规则
+func (d *OpsDao) ListEnabledAlertRules(ctx context.Context) ([]entity.AlertRule, error) {
+	var rules []entity.AlertRule
+	err := d.db.WithContext(ctx).Where("enabled = ?", true).Order("id asc").Find(&rules).Error
+	return rules, err
+}
+
 // ListAlertRules 分页查询告警规则
 func (d *OpsDao) ListAlertRules(ctx context.Context, page, pageSize int, severity string, enabled *bool) ([]entity.AlertRule, int64, error) {
 	var rules []entity.AlertRule
@@ -0,0 +1,60 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+	"gorm.io/gorm"
+)
+
+// PortMappingDao 端口映射数据访问层
+type PortMappingDao struct {
+	db *gorm.DB
+}
+
+func NewPortMappingDao(db *gorm.DB) *PortMappingDao {
+	return &PortMappingDao{db: db}
+}
+
+// Create 创建端口映射
+// external_port 为唯一索引，先清理占用同一端口的已释放记录
+func (d *PortMappingDao) Create(ctx context.Context, mapping *entity.PortMapping) error {
+	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("external_port = ? AND status = ?", mapping.ExternalPort, "released").
+			Delete(&entity.PortMapping{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(mapping).Error
+	})
+}
+
+// ListActiveByEnvID 查询环境的活跃端口映射
+func (d *PortMappingDao) ListActiveByEnvID(ctx context.Context, envID string) ([]entity.PortMapping, error) {
+	var mappings []entity.PortMapping
+	err := d.db.WithContext(ctx).
+		Where("env_id = ? AND status = ?", envID, "active").
+		Order("id asc").
+		Find(&mappings).Error
+	return mappings, err
+}
+
+// ListActiveByProxyID 查询 Proxy 节点上的活跃端口映射
+func (d *PortMappingDao) ListActiveByProxyID(ctx context.Context, proxyID string) ([]entity.PortMapping, error) {
+	var mappings []entity.PortMapping
+	err := d.db.WithContext(ctx).
+		Where("proxy_id = ? AND status = ?", proxyID, "active").
+		Order("id asc").
+		Find(&mappings).Error
+	return mappings, err
+}
+
+// ReleaseByEnvID 将环境的活跃端口映射标记为已释放
+func (d *PortMappingDao) ReleaseByEnvID(ctx context.Context, envID string, releasedAt time.Time) error {
+	return d.db.WithContext(ctx).Model(&entity.PortMapping{}).
+		Where("env_id = ? AND status = ?", envID, "active").
+		Updates(map[string]interface{}{
+			"status":      "released",
+			"released_at": releasedAt,
+		}).Error
+}
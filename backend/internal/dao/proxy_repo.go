@@ -79,3 +79,13 @@ func (d *ProxyDao) UpdateStatus(ctx context.Context, id string, status string) e
 	return d.db.WithContext(ctx).Model(&entity.ProxyNode{}).Where("id = ?", id).
 		Update("status", status).Error
 }
+
+// ListHealthy 获取在线且最近有心跳的 Proxy 节点，按活跃映射数升序
+func (d *ProxyDao) ListHealthy(ctx context.Context, heartbeatSince time.Time) ([]entity.ProxyNode, error) {
+	var nodes []entity.ProxyNode
+	err := d.db.WithContext(ctx).
+		Where("status = ? AND last_heartbeat >= ?", "online", heartbeatSince).
+		Order("active_mappings asc").
+		Find(&nodes).Error
+	return nodes, err
+}
@@ -0,0 +1,193 @@
+package dao
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ReservationDao struct {
+	db *gorm.DB
+}
+
+func NewReservationDao(db *gorm.DB) *ReservationDao {
+	return &ReservationDao{db: db}
+}
+
+// HostGPUCount 机器上指定型号的 GPU 数量
+type HostGPUCount struct {
+	HostID   string `json:"host_id"`
+	GPUCount int    `json:"gpu_count"`
+}
+
+// Create 创建预约（同时创建锁定的机器记录）
+func (d *ReservationDao) Create(ctx context.Context, r *entity.Reservation) error {
+	return d.db.WithContext(ctx).Create(r).Error
+}
+
+func (d *ReservationDao) FindByID(ctx context.Context, id string) (*entity.Reservation, error) {
+	var r entity.Reservation
+	if err := d.db.WithContext(ctx).Preload("Hosts").First(&r, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// LockByID 在事务内对预约加行级锁并返回最新状态（不含锁定的机器）
+func (d *ReservationDao) LockByID(ctx context.Context, id string) (*entity.Reservation, error) {
+	var r entity.Reservation
+	if err := d.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&r, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// ListHosts 查询预约锁定的机器
+func (d *ReservationDao) ListHosts(ctx context.Context, reservationID string) ([]entity.ReservationHost, error) {
+	var hosts []entity.ReservationHost
+	err := d.db.WithContext(ctx).Where("reservation_id = ?", reservationID).Order("id").Find(&hosts).Error
+	return hosts, err
+}
+
+// List 分页查询预约，支持按客户/状态筛选
+func (d *ReservationDao) List(ctx context.Context, page, pageSize int, filters map[string]interface{}) ([]entity.Reservation, int64, error) {
+	var reservations []entity.Reservation
+	var total int64
+
+	query := d.db.WithContext(ctx).Model(&entity.Reservation{})
+	if customerID, ok := filters["customer_id"]; ok {
+		query = query.Where("customer_id = ?", customerID)
+	}
+	if status, ok := filters["status"]; ok {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.
+		Preload("Hosts").
+		Order("created_at desc").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&reservations).Error
+	return reservations, total, err
+}
+
+// ListQueued 按提交顺序查询排队中的预约
+func (d *ReservationDao) ListQueued(ctx context.Context, limit int) ([]entity.Reservation, error) {
+	var reservations []entity.Reservation
+	err := d.db.WithContext(ctx).
+		Where("status = ?", entity.ReservationStatusQueued).
+		Order("created_at asc, id asc").
+		Limit(limit).
+		Find(&reservations).Error
+	return reservations, err
+}
+
+// ListStartable 查询时间窗口已开始、等待激活的预约
+func (d *ReservationDao) ListStartable(ctx context.Context, now time.Time, limit int) ([]entity.Reservation, error) {
+	var reservations []entity.Reservation
+	err := d.db.WithContext(ctx).
+		Preload("Hosts").
+		Where("status = ? AND start_time <= ? AND end_time > ?", entity.ReservationStatusPending, now, now).
+		Order("start_time asc").
+		Limit(limit).
+		Find(&reservations).Error
+	return reservations, err
+}
+
+// ListEnded 查询时间窗口已结束仍未激活的预约
+func (d *ReservationDao) ListEnded(ctx context.Context, now time.Time, limit int) ([]entity.Reservation, error) {
+	var reservations []entity.Reservation
+	err := d.db.WithContext(ctx).
+		Where("status IN ? AND end_time <= ?", []string{entity.ReservationStatusQueued, entity.ReservationStatusPending}, now).
+		Limit(limit).
+		Find(&reservations).Error
+	return reservations, err
+}
+
+// UpdateFields 更新预约指定字段，status 非空时仅在当前状态匹配时更新，返回是否更新成功
+func (d *ReservationDao) UpdateFields(ctx context.Context, id, status string, fields map[string]interface{}) (bool, error) {
+	query := d.db.WithContext(ctx).Model(&entity.Reservation{}).Where("id = ?", id)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	result := query.Updates(fields)
+	return result.RowsAffected > 0, result.Error
+}
+
+// AddHosts 记录预约锁定的机器
+func (d *ReservationDao) AddHosts(ctx context.Context, hosts []entity.ReservationHost) error {
+	if len(hosts) == 0 {
+		return nil
+	}
+	return d.db.WithContext(ctx).Create(&hosts).Error
+}
+
+// SetHostAllocation 记录锁定机器激活后的分配
+func (d *ReservationDao) SetHostAllocation(ctx context.Context, reservationID, hostID, allocationID string) error {
+	return d.db.WithContext(ctx).Model(&entity.ReservationHost{}).
+		Where("reservation_id = ? AND host_id = ?", reservationID, hostID).
+		Update("allocation_id", allocationID).Error
+}
+
+// HeldHostIDs 查询在 [start, end) 时间窗口内被其他 pending 预约锁定且尚未激活的机器
+func (d *ReservationDao) HeldHostIDs(ctx context.Context, hostIDs []string, start, end time.Time, excludeID string) ([]string, error) {
+	var ids []string
+	if len(hostIDs) == 0 {
+		return ids, nil
+	}
+	err := d.db.WithContext(ctx).Table("reservation_hosts").
+		Joins("JOIN reservations ON reservations.id = reservation_hosts.reservation_id").
+		Distinct("reservation_hosts.host_id").
+		Where("reservation_hosts.host_id IN ? AND reservation_hosts.allocation_id IS NULL", hostIDs).
+		Where("reservations.status = ? AND reservations.id <> ?", entity.ReservationStatusPending, excludeID).
+		Where("reservations.start_time < ? AND reservations.end_time > ?", end, start).
+		Pluck("reservation_hosts.host_id", &ids).Error
+	return ids, err
+}
+
+// CountHeldGPUsByCustomer 统计客户在 [start, end) 时间窗口内的预约占用的 GPU 数量
+// 已锁定机器（pending）且尚未激活的机器计整机 GPU，排队中（queued）的预约计申请数量；已激活的机器计入分配
+func (d *ReservationDao) CountHeldGPUsByCustomer(ctx context.Context, customerID uint, start, end time.Time) (int64, error) {
+	var held int64
+	err := d.db.WithContext(ctx).Model(&entity.GPU{}).
+		Joins("JOIN reservation_hosts ON reservation_hosts.host_id = gpus.host_id").
+		Joins("JOIN reservations ON reservations.id = reservation_hosts.reservation_id").
+		Where("reservations.customer_id = ? AND reservations.status = ?", customerID, entity.ReservationStatusPending).
+		Where("reservations.start_time < ? AND reservations.end_time > ?", end, start).
+		Where("reservation_hosts.allocation_id IS NULL").
+		Count(&held).Error
+	if err != nil {
+		return 0, err
+	}
+
+	var queued int64
+	err = d.db.WithContext(ctx).Model(&entity.Reservation{}).
+		Select("COALESCE(SUM(gpu_count), 0)").
+		Where("customer_id = ? AND status = ?", customerID, entity.ReservationStatusQueued).
+		Where("start_time < ? AND end_time > ?", end, start).
+		Scan(&queued).Error
+	return held + queued, err
+}
+
+// likeEscaper 转义 LIKE 通配符，使型号按字面子串匹配
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// CandidateHosts 查询装有指定型号 GPU 且不在维护中的机器及该型号 GPU 数量
+func (d *ReservationDao) CandidateHosts(ctx context.Context, gpuModel string) ([]HostGPUCount, error) {
+	var hosts []HostGPUCount
+	err := d.db.WithContext(ctx).Table("hosts").
+		Select("hosts.id AS host_id, COUNT(gpus.id) AS gpu_count").
+		Joins("JOIN gpus ON gpus.host_id = hosts.id").
+		Where(`gpus.name LIKE ? ESCAPE '\' AND hosts.allocation_status <> ?`, "%"+likeEscaper.Replace(gpuModel)+"%", "maintenance").
+		Group("hosts.id").
+		Order("hosts.id").
+		Scan(&hosts).Error
+	return hosts, err
+}
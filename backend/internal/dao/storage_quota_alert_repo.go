@@ -0,0 +1,38 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// StorageQuotaAlertDao 存储用量提醒状态数据访问层
+type StorageQuotaAlertDao struct {
+	db *gorm.DB
+}
+
+func NewStorageQuotaAlertDao(db *gorm.DB) *StorageQuotaAlertDao {
+	return &StorageQuotaAlertDao{db: db}
+}
+
+// GetLevel 获取已提醒的级别，无记录时返回 0
+func (d *StorageQuotaAlertDao) GetLevel(ctx context.Context, scope string, scopeID uint) (int, error) {
+	var alerts []entity.StorageQuotaAlert
+	err := d.db.WithContext(ctx).Where("scope = ? AND scope_id = ?", scope, scopeID).Limit(1).Find(&alerts).Error
+	if err != nil || len(alerts) == 0 {
+		return 0, err
+	}
+	return alerts[0].Level, nil
+}
+
+// SetLevel 更新已提醒的级别
+func (d *StorageQuotaAlertDao) SetLevel(ctx context.Context, scope string, scopeID uint, level int) error {
+	alert := &entity.StorageQuotaAlert{Scope: scope, ScopeID: scopeID, Level: level, UpdatedAt: time.Now()}
+	return d.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "scope"}, {Name: "scope_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"level", "updated_at"}),
+	}).Create(alert).Error
+}
@@ -0,0 +1,100 @@
+package dao
+
+import (
+	"context"
+	"errors"
+
+	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+	"gorm.io/gorm"
+)
+
+// ErrLogOffsetGap 上报的日志起始偏移超过已持久化长度，中间存在缺失
+var ErrLogOffsetGap = errors.New("log offset gap")
+
+// TaskLogDao 任务日志分片数据访问层
+type TaskLogDao struct {
+	db *gorm.DB
+}
+
+func NewTaskLogDao(db *gorm.DB) *TaskLogDao {
+	return &TaskLogDao{db: db}
+}
+
+// Append 从 offset 处追加日志，返回追加后该输出流的总长度
+// 已持久化的部分会被跳过，因此 Agent 重传同一分片是幂等的
+func (d *TaskLogDao) Append(ctx context.Context, taskID, attemptID, stream string, offset int64, data []byte) (int64, error) {
+	var end int64
+	err := d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		if end, err = streamSize(tx, taskID, attemptID, stream); err != nil {
+			return err
+		}
+		if offset > end {
+			return ErrLogOffsetGap
+		}
+		skip := end - offset
+		if skip >= int64(len(data)) {
+			return nil
+		}
+		data = data[skip:]
+
+		if err := tx.Create(&entity.TaskLogChunk{
+			TaskID:     taskID,
+			AttemptID:  attemptID,
+			Stream:     stream,
+			ByteOffset: end,
+			Size:       len(data),
+			Content:    data,
+		}).Error; err != nil {
+			return err
+		}
+		end += int64(len(data))
+		return nil
+	})
+	return end, err
+}
+
+// Size 查询输出流已持久化的长度
+func (d *TaskLogDao) Size(ctx context.Context, taskID, attemptID, stream string) (int64, error) {
+	return streamSize(d.db.WithContext(ctx), taskID, attemptID, stream)
+}
+
+// Read 读取 [offset, offset+limit) 范围内的日志
+func (d *TaskLogDao) Read(ctx context.Context, taskID, attemptID, stream string, offset int64, limit int) ([]byte, error) {
+	var chunks []entity.TaskLogChunk
+	if err := d.db.WithContext(ctx).
+		Where("task_id = ? AND attempt_id = ? AND stream = ?", taskID, attemptID, stream).
+		Where("byte_offset + size > ? AND byte_offset < ?", offset, offset+int64(limit)).
+		Order("byte_offset asc").
+		Find(&chunks).Error; err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 0, limit)
+	for _, c := range chunks {
+		start := int64(0)
+		if offset > c.ByteOffset {
+			start = offset - c.ByteOffset
+		}
+		content := c.Content[start:]
+		if remaining := limit - len(buf); len(content) > remaining {
+			content = content[:remaining]
+		}
+		buf = append(buf, content...)
+	}
+	return buf, nil
+}
+
+// streamSize 输出流长度即最后一个分片的结束偏移
+func streamSize(db *gorm.DB, taskID, attemptID, stream string) (int64, error) {
+	var last entity.TaskLogChunk
+	err := db.Select("byte_offset", "size").
+		Where("task_id = ? AND attempt_id = ? AND stream = ?", taskID, attemptID, stream).
+		Order("byte_offset desc").
+		Limit(1).
+		Find(&last).Error
+	if err != nil {
+		return 0, err
+	}
+	return last.ByteOffset + int64(last.Size), nil
+}
@@ -5,6 +5,8 @@ import (
 	"time"
 
 	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
 	"gorm.io/gorm"
 )
 
@@ -20,6 +22,31 @@ func (d *TaskDao) Create(ctx context.Context, task *entity.Task) error {
 	return d.db.WithContext(ctx).Create(task).Error
 }
 
+// CreateBatch 在同一事务中批量创建任务（工作流提交）
+func (d *TaskDao) CreateBatch(ctx context.Context, tasks []entity.Task) error {
+	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return tx.Create(&tasks).Error
+	})
+}
+
+// ListByGroupID 查询工作流内的全部任务
+func (d *TaskDao) ListByGroupID(ctx context.Context, groupID string) ([]entity.Task, error) {
+	var tasks []entity.Task
+	err := d.db.WithContext(ctx).
+		Where("group_id = ?", groupID).
+		Order("created_at, id").
+		Find(&tasks).Error
+	return tasks, err
+}
+
+// TransitionStatus 仅当任务处于 from 中的某个状态时更新，返回是否更新成功
+func (d *TaskDao) TransitionStatus(ctx context.Context, id string, from []string, updates map[string]interface{}) (bool, error) {
+	result := d.db.WithContext(ctx).Model(&entity.Task{}).
+		Where("id = ? AND status IN ?", id, from).
+		Updates(updates)
+	return result.RowsAffected > 0, result.Error
+}
+
 func (d *TaskDao) ListByCustomerID(ctx context.Context, customerID uint, page, pageSize int) ([]entity.Task, int64, error) {
 	var tasks []entity.Task
 	var total int64
@@ -59,6 +86,9 @@ func (d *TaskDao) FindByID(ctx context.Context, id string) (*entity.Task, error)
 	return &task, nil
 }
 
+// ClaimableTaskStatuses 可被 Agent 认领的任务状态
+var ClaimableTaskStatuses = []string{"queued", "pending"}
+
 // ClaimTasks 原子性认领任务
 func (d *TaskDao) ClaimTasks(ctx context.Context, machineID, agentID string, limit int) ([]entity.Task, error) {
 	var tasks []entity.Task
@@ -66,7 +96,8 @@ func (d *TaskDao) ClaimTasks(ctx context.Context, machineID, agentID string, lim
 	err := d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		// 1. 查询待认领的任务 ID
 		var pendingTasks []entity.Task
-		if err := tx.Where("machine_id = ? AND status = ?", machineID, "pending").
+		if err := tx.Where("machine_id = ? AND status IN ?", machineID, ClaimableTaskStatuses).
+			Where("next_attempt_at IS NULL OR next_attempt_at <= ?", time.Now()).
 			Order("priority, created_at").
 			Limit(limit).
 			Find(&pendingTasks).Error; err != nil {
@@ -86,7 +117,7 @@ func (d *TaskDao) ClaimTasks(ctx context.Context, machineID, agentID string, lim
 		now := time.Now()
 		leaseExpires := now.Add(5 * time.Minute)
 		if err := tx.Model(&entity.Task{}).
-			Where("id IN ? AND status = ?", ids, "pending").
+			Where("id IN ? AND status IN ?", ids, ClaimableTaskStatuses).
 			Updates(map[string]interface{}{
 				"status":            "assigned",
 				"assigned_agent_id": agentID,
@@ -107,6 +138,76 @@ func (d *TaskDao) ClaimTasks(ctx context.Context, machineID, agentID string, lim
 	return tasks, nil
 }
 
+// ListUnplaced 查询未指定机器、等待调度的任务，只包含在 hostID 上有活跃分配的客户的任务
+// 在 limit 之前按客户过滤，其他客户排队的任务不会挤占扫描窗口
+func (d *TaskDao) ListUnplaced(ctx context.Context, hostID string, limit int) ([]entity.Task, error) {
+	var tasks []entity.Task
+	customers := d.db.Model(&entity.Allocation{}).Select("customer_id").
+		Where("host_id = ? AND status = ?", hostID, "active")
+	err := d.db.WithContext(ctx).
+		Where("(machine_id IS NULL OR machine_id = '') AND status IN ?", ClaimableTaskStatuses).
+		Where("customer_id IN (?)", customers).
+		Where("next_attempt_at IS NULL OR next_attempt_at <= ?", time.Now()).
+		Order("priority, created_at").
+		Limit(limit).
+		Find(&tasks).Error
+	return tasks, err
+}
+
+// ListActiveByMachineID 查询指定机器上已认领或运行中的任务
+func (d *TaskDao) ListActiveByMachineID(ctx context.Context, machineID string) ([]entity.Task, error) {
+	var tasks []entity.Task
+	err := d.db.WithContext(ctx).
+		Where("machine_id = ? AND status IN ?", machineID, []string{"assigned", "running"}).
+		Find(&tasks).Error
+	return tasks, err
+}
+
+// AssignPlaced 将调度选中的任务绑定到机器并由 Agent 认领
+// 任务已被其他 Agent 认领时返回 gorm.ErrRecordNotFound
+func (d *TaskDao) AssignPlaced(ctx context.Context, id, machineID, agentID string, gpus datatypes.JSON) (*entity.Task, error) {
+	now := time.Now()
+	result := d.db.WithContext(ctx).Model(&entity.Task{}).
+		Where("id = ? AND (machine_id IS NULL OR machine_id = '') AND status IN ?", id, ClaimableTaskStatuses).
+		Updates(map[string]interface{}{
+			"machine_id":        machineID,
+			"placed":            true,
+			"assigned_gpus":     gpus,
+			"status":            "assigned",
+			"assigned_agent_id": agentID,
+			"assigned_at":       now,
+			"lease_expires_at":  now.Add(5 * time.Minute),
+			"attempt_id":        uuid.NewString(),
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return d.FindByID(ctx, id)
+}
+
+// ListExpiredLeases 查询租约已过期但仍处于 assigned/running 的任务
+func (d *TaskDao) ListExpiredLeases(ctx context.Context, now time.Time, limit int) ([]entity.Task, error) {
+	var tasks []entity.Task
+	err := d.db.WithContext(ctx).
+		Where("status IN ? AND lease_expires_at IS NOT NULL AND lease_expires_at < ?", []string{"assigned", "running"}, now).
+		Order("lease_expires_at").
+		Limit(limit).
+		Find(&tasks).Error
+	return tasks, err
+}
+
+// ReapExpiredLease 回收租约过期的任务
+// 以 attempt_id 和租约时间为条件，Agent 在此期间续约或完成时不会被覆盖
+func (d *TaskDao) ReapExpiredLease(ctx context.Context, id, attemptID string, now time.Time, updates map[string]interface{}) (bool, error) {
+	result := d.db.WithContext(ctx).Model(&entity.Task{}).
+		Where("id = ? AND attempt_id = ? AND status IN ? AND lease_expires_at < ?", id, attemptID, []string{"assigned", "running"}, now).
+		Updates(updates)
+	return result.RowsAffected > 0, result.Error
+}
+
 // StartTask 标记任务开始
 func (d *TaskDao) StartTask(ctx context.Context, id, agentID, attemptID string) error {
 	now := time.Now()
@@ -200,11 +301,11 @@ func (d *TaskDao) ListAll(ctx context.Context, status string, page, pageSize int
 	return tasks, total, nil
 }
 
-// CancelTask 取消任务（仅限 queued/pending/assigned 状态）
+// CancelTask 取消任务（仅限 waiting/queued/pending/assigned 状态）
 func (d *TaskDao) CancelTask(ctx context.Context, id string) error {
 	now := time.Now()
 	result := d.db.WithContext(ctx).Model(&entity.Task{}).
-		Where("id = ? AND status IN ?", id, []string{"queued", "pending", "assigned"}).
+		Where("id = ? AND status IN ?", id, []string{"waiting", "queued", "pending", "assigned"}).
 		Updates(map[string]interface{}{
 			"status":   "cancelled",
 			"ended_at": now,
@@ -216,18 +317,23 @@ func (d *TaskDao) CancelTask(ctx context.Context, id string) error {
 }
 
 // RetryTask 重试任务（重置状态为 queued，增加重试计数）
-func (d *TaskDao) RetryTask(ctx context.Context, id string) error {
+func (d *TaskDao) RetryTask(ctx context.Context, id, status string) error {
 	result := d.db.WithContext(ctx).Model(&entity.Task{}).
 		Where("id = ? AND status IN ?", id, []string{"failed", "cancelled", "stopped"}).
 		Updates(map[string]interface{}{
-			"status":      "queued",
-			"retry_count": gorm.Expr("retry_count + 1"),
-			"exit_code":   0,
-			"error_msg":   "",
-			"ended_at":    nil,
-			"started_at":  nil,
-			"assigned_at": nil,
-			"attempt_id":  "",
+			"status":          status,
+			"retry_count":     gorm.Expr("retry_count + 1"),
+			"exit_code":       0,
+			"error_msg":       "",
+			"ended_at":        nil,
+			"started_at":      nil,
+			"assigned_at":     nil,
+			"attempt_id":      "",
+			"next_attempt_at": nil,
+			// 调度器选择的机器重新调度
+			"machine_id":    gorm.Expr("CASE WHEN placed THEN '' ELSE machine_id END"),
+			"assigned_gpus": gorm.Expr("CASE WHEN placed THEN NULL ELSE assigned_gpus END"),
+			"placed":        false,
 		})
 	if result.RowsAffected == 0 {
 		return gorm.ErrRecordNotFound
@@ -248,3 +354,12 @@ func (d *TaskDao) CountRunningTasksByMachineID(ctx context.Context, machineID st
 		Count(&count).Error
 	return count, err
 }
+
+// CountRunningTasksByMachineAndCustomer 统计客户在指定机器上运行中或已分配的任务数
+func (d *TaskDao) CountRunningTasksByMachineAndCustomer(ctx context.Context, machineID string, customerID uint) (int64, error) {
+	var count int64
+	err := d.db.WithContext(ctx).Model(&entity.Task{}).
+		Where("machine_id = ? AND customer_id = ? AND status IN ?", machineID, customerID, []string{"running", "assigned"}).
+		Count(&count).Error
+	return count, err
+}
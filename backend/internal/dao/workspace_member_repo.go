@@ -60,3 +60,10 @@ func (d *WorkspaceMemberDao) ListWorkspaceIDsByCustomer(ctx context.Context, cus
 		Pluck("workspace_id", &ids).Error
 	return ids, err
 }
+
+// UpdateRole 更新成员角色
+func (d *WorkspaceMemberDao) UpdateRole(ctx context.Context, workspaceID, customerID uint, role string) error {
+	return d.db.WithContext(ctx).Model(&entity.WorkspaceMember{}).
+		Where("workspace_id = ? AND customer_id = ?", workspaceID, customerID).
+		Update("role", role).Error
+}
@@ -35,3 +35,9 @@ func (d *WorkspaceDao) FindByUUID(ctx context.Context, uuid string) (*entity.Wor
 	}
 	return &ws, nil
 }
+
+// UpdateQuotaStorage 更新工作空间存储配额（MB）
+func (d *WorkspaceDao) UpdateQuotaStorage(ctx context.Context, id uint, quotaStorage int64) error {
+	return d.db.WithContext(ctx).Model(&entity.Workspace{}).Where("id = ?", id).
+		Update("quota_storage", quotaStorage).Error
+}
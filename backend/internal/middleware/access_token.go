@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/YoungBoyGod/remotegpu/internal/service/accesstoken"
+	"github.com/YoungBoyGod/remotegpu/pkg/auth"
+	"github.com/YoungBoyGod/remotegpu/pkg/response"
+	"github.com/gin-gonic/gin"
+)
+
+// tokenScopesKey 使用个人访问令牌认证时，上下文中保存令牌权限范围的 key
+const tokenScopesKey = "tokenScopes"
+
+// authenticateAccessToken 使用个人访问令牌认证，通过后注入与 JWT 相同的用户信息和令牌权限范围
+func authenticateAccessToken(c *gin.Context, svc *accesstoken.AccessTokenService, token string) {
+	if svc == nil {
+		response.Error(c, http.StatusUnauthorized, "无效的访问令牌")
+		c.Abort()
+		return
+	}
+
+	identity, err := svc.Authenticate(c.Request.Context(), token)
+	if err != nil {
+		switch {
+		case errors.Is(err, accesstoken.ErrTokenInvalid), errors.Is(err, accesstoken.ErrTokenExpired):
+			response.Error(c, http.StatusUnauthorized, err.Error())
+		case errors.Is(err, accesstoken.ErrCustomerInactive):
+			response.Error(c, http.StatusForbidden, err.Error())
+		default:
+			response.Error(c, http.StatusInternalServerError, "认证失败")
+		}
+		c.Abort()
+		return
+	}
+
+	c.Set("userID", identity.Customer.ID)
+	c.Set("username", identity.Customer.Username)
+	c.Set("role", identity.Customer.Role)
+	c.Set(tokenScopesKey, identity.Scopes)
+	c.Next()
+}
+
+// RequireScope 个人访问令牌权限范围校验：GET/HEAD 需要 <resource>:read，其他方法需要 <resource>:write
+// 登录会话（JWT）不受限制
+func RequireScope(resource string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scopes, ok := c.Get(tokenScopesKey)
+		if !ok {
+			c.Next()
+			return
+		}
+		write := c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead
+		required := auth.ScopeFor(resource, write)
+		granted, _ := scopes.([]string)
+		if !auth.HasScope(granted, required) {
+			response.Error(c, http.StatusForbidden, "访问令牌缺少权限 "+required)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RejectAccessToken 禁止使用个人访问令牌访问（管理端、账号安全和令牌管理接口只接受登录会话）
+func RejectAccessToken() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := c.Get(tokenScopesKey); ok {
+			response.Error(c, http.StatusForbidden, "该接口不支持使用访问令牌")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
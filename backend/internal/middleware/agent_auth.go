@@ -1,42 +1,120 @@
 package middleware
 
 import (
+	"bytes"
 	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
+	"strings"
 
 	"github.com/YoungBoyGod/remotegpu/config"
+	serviceCredential "github.com/YoungBoyGod/remotegpu/internal/service/credential"
 	"github.com/YoungBoyGod/remotegpu/pkg/response"
 	"github.com/gin-gonic/gin"
 )
 
-// AgentAuth Agent Token 认证中间件
-// 同时支持 X-Agent-Token 和 Authorization: Bearer 两种认证方式
-func AgentAuth() gin.HandlerFunc {
+// AgentAuth Agent 认证中间件
+// 使用机器级密钥认证，并校验请求体中的 machine_id/agent_id 与凭证绑定的身份一致；
+// 一次性注册令牌只能调用 /agent/register 换取机器密钥
+func AgentAuth(credentialSvc *serviceCredential.AgentCredentialService) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		token := c.GetHeader("X-Agent-Token")
-		// 兼容 Agent 客户端使用 Authorization: Bearer 发送 Token
-		if token == "" {
-			if auth := c.GetHeader("Authorization"); len(auth) > 7 && auth[:7] == "Bearer " {
-				token = auth[7:]
-			}
-		}
+		token := agentTokenFromRequest(c)
 		if token == "" {
 			response.Error(c, http.StatusUnauthorized, "缺少 Agent 认证令牌")
 			c.Abort()
 			return
 		}
 
-		expectedToken := ""
-		if config.GlobalConfig != nil {
-			expectedToken = config.GlobalConfig.Agent.Token
+		// 迁移期兼容：允许旧 Agent 继续使用共享 Token
+		if config.GlobalConfig != nil && config.GlobalConfig.Agent.AllowSharedToken && matchSharedToken(token) {
+			c.Next()
+			return
 		}
 
-		if expectedToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(expectedToken)) != 1 {
+		if credentialSvc == nil {
 			response.Error(c, http.StatusUnauthorized, "无效的 Agent 认证令牌")
 			c.Abort()
 			return
 		}
 
+		identity, err := credentialSvc.Authenticate(c.Request.Context(), token)
+		if err != nil {
+			msg := "无效的 Agent 认证令牌"
+			if errors.Is(err, serviceCredential.ErrEnrollmentTokenExpired) {
+				msg = "Agent 注册令牌已过期"
+			}
+			response.Error(c, http.StatusUnauthorized, msg)
+			c.Abort()
+			return
+		}
+		if identity.Enrollment && !strings.HasSuffix(c.FullPath(), "/agent/register") {
+			response.Error(c, http.StatusUnauthorized, "注册令牌仅可用于 Agent 注册")
+			c.Abort()
+			return
+		}
+
+		// 将认证身份与请求体中声明的机器和 Agent 绑定
+		var body struct {
+			MachineID string `json:"machine_id"`
+			AgentID   string `json:"agent_id"`
+		}
+		if c.Request.Body != nil {
+			bodyBytes, _ := io.ReadAll(c.Request.Body)
+			c.Request.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
+			_ = json.Unmarshal(bodyBytes, &body)
+		}
+		if body.MachineID != "" && body.MachineID != identity.HostID {
+			response.Error(c, http.StatusForbidden, "machine_id 与 Agent 凭证不匹配")
+			c.Abort()
+			return
+		}
+		if !identity.Enrollment && identity.AgentID != "" && body.AgentID != identity.AgentID {
+			response.Error(c, http.StatusForbidden, "agent_id 与 Agent 凭证不匹配")
+			c.Abort()
+			return
+		}
+
+		c.Set("agentIdentity", identity)
 		c.Next()
 	}
 }
+
+// ProxyAuth Proxy 共享 Token 认证中间件
+func ProxyAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := agentTokenFromRequest(c)
+		if token == "" {
+			response.Error(c, http.StatusUnauthorized, "缺少 Agent 认证令牌")
+			c.Abort()
+			return
+		}
+		if !matchSharedToken(token) {
+			response.Error(c, http.StatusUnauthorized, "无效的 Agent 认证令牌")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// agentTokenFromRequest 同时支持 X-Agent-Token 和 Authorization: Bearer 两种认证方式
+func agentTokenFromRequest(c *gin.Context) string {
+	token := c.GetHeader("X-Agent-Token")
+	if token == "" {
+		if auth := c.GetHeader("Authorization"); len(auth) > 7 && auth[:7] == "Bearer " {
+			token = auth[7:]
+		}
+	}
+	return token
+}
+
+// matchSharedToken 校验全局共享 Token
+func matchSharedToken(token string) bool {
+	expectedToken := ""
+	if config.GlobalConfig != nil {
+		expectedToken = config.GlobalConfig.Agent.Token
+	}
+	return expectedToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(expectedToken)) == 1
+}
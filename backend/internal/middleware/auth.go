@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+	"github.com/YoungBoyGod/remotegpu/internal/service/accesstoken"
 	"github.com/YoungBoyGod/remotegpu/pkg/auth"
 	"github.com/YoungBoyGod/remotegpu/pkg/cache"
 	"github.com/YoungBoyGod/remotegpu/pkg/response"
@@ -15,8 +16,12 @@ import (
 
 const tokenBlacklistPrefix = "auth:token:blacklist:"
 
-// Auth JWT 认证中间件
+// Auth JWT 认证中间件，同时接受个人访问令牌（权限范围由 RequireScope 校验）
 func Auth(db *gorm.DB) gin.HandlerFunc {
+	var tokenSvc *accesstoken.AccessTokenService
+	if db != nil {
+		tokenSvc = accesstoken.NewAccessTokenService(db)
+	}
 	return func(c *gin.Context) {
 		// CodeX 2026-02-04: enforce account status check with DB when available.
 		// 从 Header 获取 token，SSE 等场景回退到 query 参数
@@ -41,6 +46,12 @@ func Auth(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
+		// 个人访问令牌
+		if auth.IsAccessToken(parts[1]) {
+			authenticateAccessToken(c, tokenSvc, parts[1])
+			return
+		}
+
 		// 解析 token
 		claims, err := auth.ParseToken(parts[1])
 		if err != nil {
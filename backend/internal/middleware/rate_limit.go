@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/YoungBoyGod/remotegpu/pkg/cache"
+	"github.com/YoungBoyGod/remotegpu/pkg/logger"
+	"github.com/YoungBoyGod/remotegpu/pkg/response"
+	"github.com/gin-gonic/gin"
+)
+
+// rateLimitPrefix 限流计数 key 前缀
+const rateLimitPrefix = "ratelimit:"
+
+// RateLimitRule 限流规则：Window 内最多 Limit 次请求，Limit <= 0 表示不限制
+type RateLimitRule struct {
+	Limit  int
+	Window time.Duration
+}
+
+// RateLimitKeyFunc 返回限流计数的维度，返回空字符串时不计数
+type RateLimitKeyFunc func(c *gin.Context) string
+
+// RateLimit 基于缓存计数的固定窗口限流中间件
+// 超过限制时返回 429 并设置 Retry-After；缓存不可用时放行，避免缓存故障导致接口不可用
+func RateLimit(store cache.Cache, scope string, rule RateLimitRule, keyFunc RateLimitKeyFunc) gin.HandlerFunc {
+	if store == nil || rule.Limit <= 0 || rule.Window <= 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+	return func(c *gin.Context) {
+		key := keyFunc(c)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		cacheKey := rateLimitPrefix + scope + ":" + key
+		count, err := store.Incr(c.Request.Context(), cacheKey, rule.Window)
+		if err != nil {
+			logger.GetLogger().Warn(fmt.Sprintf("限流计数失败 %s: %v", cacheKey, err))
+			c.Next()
+			return
+		}
+		if count > int64(rule.Limit) {
+			retryAfter := rule.Window
+			if ttl, err := store.TTL(c.Request.Context(), cacheKey); err == nil && ttl > 0 {
+				retryAfter = ttl
+			}
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			response.Error(c, http.StatusTooManyRequests, "请求过于频繁，请稍后再试")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// ClientIPKey 按客户端 IP 限流
+func ClientIPKey(c *gin.Context) string {
+	return c.ClientIP()
+}
+
+// UserIDKey 按已登录用户限流，需放在 Auth 中间件之后
+func UserIDKey(c *gin.Context) string {
+	userID := c.GetUint("userID")
+	if userID == 0 {
+		return ""
+	}
+	return strconv.FormatUint(uint64(userID), 10)
+}
+
+// JSONFieldKey 按 JSON 请求体中的字符串字段限流（不区分大小写），读取后恢复请求体
+func JSONFieldKey(field string) RateLimitKeyFunc {
+	return func(c *gin.Context) string {
+		if c.Request.Body == nil {
+			return ""
+		}
+		bodyBytes, _ := io.ReadAll(c.Request.Body)
+		c.Request.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
+
+		var body map[string]interface{}
+		if err := json.Unmarshal(bodyBytes, &body); err != nil {
+			return ""
+		}
+		value, _ := body[field].(string)
+		return strings.ToLower(strings.TrimSpace(value))
+	}
+}
@@ -0,0 +1,37 @@
+package entity
+
+import (
+	"strings"
+	"time"
+)
+
+// PersonalAccessToken 个人访问令牌，用于脚本和 CLI 调用 API，只保存令牌哈希
+type PersonalAccessToken struct {
+	ID          uint       `gorm:"primarykey" json:"id"`
+	CustomerID  uint       `gorm:"not null;index" json:"customer_id"`
+	Name        string     `gorm:"type:varchar(64);not null" json:"name"`
+	TokenPrefix string     `gorm:"type:varchar(16);not null" json:"token_prefix"` // 令牌明文的前几位，便于用户在列表中识别
+	TokenHash   string     `gorm:"type:varchar(64);not null;uniqueIndex" json:"-"`
+	Scopes      string     `gorm:"type:varchar(512);not null" json:"-"` // 逗号分隔，如 tasks:write,datasets:read
+	ExpiresAt   *time.Time `json:"expires_at"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+	RevokedAt   *time.Time `json:"revoked_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+func (PersonalAccessToken) TableName() string {
+	return "personal_access_tokens"
+}
+
+// ScopeList 返回权限范围列表
+func (t *PersonalAccessToken) ScopeList() []string {
+	if t.Scopes == "" {
+		return nil
+	}
+	return strings.Split(t.Scopes, ",")
+}
+
+// Active 令牌未吊销且未过期
+func (t *PersonalAccessToken) Active(now time.Time) bool {
+	return t.RevokedAt == nil && (t.ExpiresAt == nil || now.Before(*t.ExpiresAt))
+}
@@ -0,0 +1,25 @@
+package entity
+
+import "time"
+
+// AgentCredential 机器级 Agent 凭证
+// 只保存令牌的 SHA-256 摘要，明文仅在签发时返回一次
+type AgentCredential struct {
+	ID                  uint       `gorm:"primarykey" json:"id"`
+	HostID              string     `gorm:"type:varchar(64);uniqueIndex;not null" json:"host_id"`
+	AgentID             string     `gorm:"type:varchar(64)" json:"agent_id"`
+	Status              string     `gorm:"type:varchar(20);default:'pending'" json:"status"` // pending, active, revoked
+	SecretHash          string     `gorm:"type:varchar(64);index" json:"-"`
+	EnrollmentTokenHash string     `gorm:"type:varchar(64);index" json:"-"`
+	ControlKey          string     `gorm:"type:text" json:"-"` // 由机器密钥派生的控制接口签名密钥，加密保存
+	EnrollmentExpiresAt *time.Time `json:"enrollment_expires_at,omitempty"`
+	SecretIssuedAt      *time.Time `json:"secret_issued_at,omitempty"`
+	RevokedAt           *time.Time `json:"revoked_at,omitempty"`
+	LastUsedAt          *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+}
+
+func (AgentCredential) TableName() string {
+	return "agent_credentials"
+}
@@ -2,6 +2,8 @@ package entity
 
 import (
 	"time"
+
+	"gorm.io/datatypes"
 )
 
 // Allocation 资源分配实体，表示分配给客户的资源（租约）
@@ -11,10 +13,15 @@ type Allocation struct {
 	HostID      string `gorm:"type:varchar(64);not null;index" json:"host_id"`
 	WorkspaceID *uint  `json:"workspace_id,omitempty"`
 
+	// GPU 粒度分配：为空表示整机分配，否则只分配列出的 GPU 序号，同一机器可分配给多个客户
+	GPUIndexes datatypes.JSON `gorm:"column:gpu_indexes;type:jsonb" json:"gpu_indexes,omitempty"`
+	GPUCount   int            `gorm:"default:0" json:"gpu_count"` // 分配时的 GPU 卡数
+
 	// Time
 	StartTime     time.Time  `gorm:"not null" json:"start_time"`
 	EndTime       time.Time  `gorm:"not null" json:"end_time"`
 	ActualEndTime *time.Time `json:"actual_end_time,omitempty"`
+	ExpiryWarnedAt *time.Time `json:"expiry_warned_at,omitempty"` // 已发送到期提醒的时间，续期后清空
 
 	// Status
 	Status string `gorm:"type:varchar(32);default:'active';index" json:"status"` // active, expired, reclaimed, pending
@@ -29,3 +36,21 @@ type Allocation struct {
 	Host     Host      `gorm:"foreignKey:HostID" json:"host,omitempty"`
 	Workspace *Workspace `gorm:"foreignKey:WorkspaceID" json:"workspace,omitempty"`
 }
+
+// AllocationRenewal 分配续期记录
+type AllocationRenewal struct {
+	ID           uint      `gorm:"primarykey" json:"id"`
+	AllocationID string    `gorm:"type:varchar(64);not null;index" json:"allocation_id"`
+	CustomerID   uint      `gorm:"not null" json:"customer_id"`
+	OperatorID   uint      `json:"operator_id"`
+	OperatorRole string    `gorm:"type:varchar(20);not null" json:"operator_role"` // customer, admin
+	Months       int       `gorm:"not null" json:"months"`
+	OldEndTime   time.Time `gorm:"not null" json:"old_end_time"`
+	NewEndTime   time.Time `gorm:"not null" json:"new_end_time"`
+	Remark       string    `gorm:"type:text" json:"remark"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (AllocationRenewal) TableName() string {
+	return "allocation_renewals"
+}
@@ -0,0 +1,83 @@
+package entity
+
+import "time"
+
+// 用量来源
+const (
+	UsageSourceAllocation  = "allocation"  // 机器分配（租约）
+	UsageSourceEnvironment = "environment" // 开发环境
+	UsageSourceTask        = "task"        // 任务
+)
+
+// 账单状态
+const (
+	InvoiceStatusPending   = "pending"   // 待支付
+	InvoiceStatusPaid      = "paid"      // 已支付
+	InvoiceStatusOverdue   = "overdue"   // 逾期
+	InvoiceStatusCancelled = "cancelled" // 已取消
+)
+
+// UsageRecord GPU 用量记录，按自然日切分，同一资源当天的连续用量合并为一条
+type UsageRecord struct {
+	ID              uint      `gorm:"primarykey" json:"id"`
+	CustomerID      uint      `gorm:"not null;index" json:"customer_id"`
+	WorkspaceID     *uint     `json:"workspace_id,omitempty"`
+	SourceType      string    `gorm:"type:varchar(20);not null" json:"source_type"`
+	SourceID        string    `gorm:"type:varchar(64);not null" json:"source_id"`
+	HostID          string    `gorm:"type:varchar(64)" json:"host_id"`
+	GPUModel        string    `gorm:"column:gpu_model;type:varchar(128)" json:"gpu_model"`
+	GPUCount        int       `gorm:"column:gpu_count;not null" json:"gpu_count"`
+	StartTime       time.Time `gorm:"not null" json:"start_time"`
+	EndTime         time.Time `gorm:"not null" json:"end_time"`
+	DurationSeconds int64     `gorm:"not null;default:0" json:"duration_seconds"`
+	GPUHours        float64   `gorm:"column:gpu_hours;type:decimal(14,4);not null;default:0" json:"gpu_hours"`
+	UnitPrice       float64   `gorm:"type:decimal(10,4);not null;default:0" json:"unit_price"` // 每 GPU 小时单价，不计费的来源为 0
+	Amount          float64   `gorm:"type:decimal(12,4);not null;default:0" json:"amount"`
+	Currency        string    `gorm:"type:varchar(10);default:'CNY'" json:"currency"`
+	InvoiceID       *uint     `json:"invoice_id,omitempty"` // 为空表示未出账
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+func (UsageRecord) TableName() string {
+	return "usage_records"
+}
+
+// Invoice 月度账单，汇总账期结束前所有未出账的用量记录
+type Invoice struct {
+	ID                 uint       `gorm:"primarykey" json:"id"`
+	InvoiceNo          string     `gorm:"type:varchar(64);uniqueIndex;not null" json:"invoice_no"`
+	CustomerID         uint       `gorm:"not null;index" json:"customer_id"`
+	BillingPeriodStart time.Time  `gorm:"not null" json:"billing_period_start"`
+	BillingPeriodEnd   time.Time  `gorm:"not null" json:"billing_period_end"`
+	TotalGPUHours      float64    `gorm:"column:total_gpu_hours;type:decimal(14,4);not null;default:0" json:"total_gpu_hours"`
+	TotalAmount        float64    `gorm:"type:decimal(10,4);not null" json:"total_amount"`
+	Currency           string     `gorm:"type:varchar(10);default:'CNY'" json:"currency"`
+	Status             string     `gorm:"type:varchar(20);default:'pending'" json:"status"` // pending, paid, overdue, cancelled
+	PaidAt             *time.Time `json:"paid_at,omitempty"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+
+	Items []InvoiceItem `gorm:"foreignKey:InvoiceID" json:"items,omitempty"`
+}
+
+func (Invoice) TableName() string {
+	return "invoices"
+}
+
+// InvoiceItem 账单明细，按来源、工作空间、GPU 型号和单价汇总
+type InvoiceItem struct {
+	ID          uint    `gorm:"primarykey" json:"id"`
+	InvoiceID   uint    `gorm:"not null;index" json:"invoice_id"`
+	SourceType  string  `gorm:"type:varchar(20);not null" json:"source_type"`
+	WorkspaceID *uint   `json:"workspace_id,omitempty"`
+	GPUModel    string  `gorm:"column:gpu_model;type:varchar(128)" json:"gpu_model"`
+	UnitPrice   float64 `gorm:"type:decimal(10,4);not null;default:0" json:"unit_price"`
+	GPUHours    float64 `gorm:"column:gpu_hours;type:decimal(14,4);not null;default:0" json:"gpu_hours"`
+	Amount      float64 `gorm:"type:decimal(12,4);not null;default:0" json:"amount"`
+	RecordCount int     `gorm:"not null;default:0" json:"record_count"`
+}
+
+func (InvoiceItem) TableName() string {
+	return "invoice_items"
+}
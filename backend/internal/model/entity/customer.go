@@ -38,6 +38,22 @@ type Customer struct {
 
 	LastLoginAt *time.Time `json:"last_login_at"`
 
+	// 登录失败锁定：连续失败达到阈值后锁定，锁定时长随 LockoutCount 翻倍，登录成功或管理员解锁后清零
+	// 创建时使用数据库默认值，只在登录和解锁时更新
+	FailedLoginAttempts int        `gorm:"<-:update;default:0" json:"failed_login_attempts"`
+	LockoutCount        int        `gorm:"<-:update;default:0" json:"lockout_count"`
+	LockedUntil         *time.Time `gorm:"<-:update" json:"locked_until,omitempty"`
+
+	// 两步验证（TOTP）：MFASecret 为 AES 加密后的密钥，开通流程中已生成密钥但 MFAEnabled 仍为 false
+	// MFALastStep 记录最近一次通过校验的时间步，防止同一验证码重放
+	MFAEnabled   bool       `gorm:"<-:update;default:false" json:"mfa_enabled"`
+	MFASecret    string     `gorm:"<-:update" json:"-"`
+	MFALastStep  int64      `gorm:"<-:update;default:0" json:"-"`
+	MFAEnabledAt *time.Time `gorm:"<-:update" json:"mfa_enabled_at,omitempty"`
+
+	// 账号来源：local 为本地密码登录，oidc/ldap 为企业单点登录（不能使用本地密码登录和找回密码）
+	AuthSource string `gorm:"<-:update;type:varchar(32);default:'local'" json:"auth_source"`
+
 	// Relations
 	SSHKeys     []SSHKey     `gorm:"foreignKey:CustomerID" json:"ssh_keys,omitempty"`
 	Allocations []Allocation `gorm:"foreignKey:CustomerID" json:"allocations,omitempty"`
@@ -0,0 +1,17 @@
+package entity
+
+import "time"
+
+// CustomerIdentity 客户关联的外部身份（企业单点登录），Provider 为 <类型>:<企业编码>，Subject 为身份源中的用户唯一标识
+type CustomerIdentity struct {
+	ID          uint       `gorm:"primarykey" json:"id"`
+	CustomerID  uint       `gorm:"not null;index" json:"customer_id"`
+	Provider    string     `gorm:"type:varchar(128);not null;uniqueIndex:idx_identity_provider_subject" json:"provider"`
+	Subject     string     `gorm:"type:varchar(512);not null;uniqueIndex:idx_identity_provider_subject" json:"subject"`
+	LastLoginAt *time.Time `json:"last_login_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+func (CustomerIdentity) TableName() string {
+	return "customer_identities"
+}
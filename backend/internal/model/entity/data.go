@@ -42,9 +42,15 @@ type Dataset struct {
 	TotalSize   int64  `gorm:"default:0" json:"total_size"`
 	FileCount   int    `gorm:"default:0" json:"file_count"`
 
-	Status     string `gorm:"type:varchar(20);default:'uploading'" json:"status"` // uploading, ready, error
+	Status     string `gorm:"type:varchar(20);default:'uploading'" json:"status"` // uploading, ready, error, aborted
 	Visibility string `gorm:"type:varchar(20);default:'private'" json:"visibility"`
 
+	// 分片上传状态，上传完成或中止后清空
+	StorageBackend  string     `gorm:"type:varchar(64)" json:"storage_backend,omitempty"`
+	UploadID        string     `gorm:"type:varchar(256)" json:"-"`
+	UploadKey       string     `gorm:"type:varchar(1024)" json:"-"`
+	UploadExpiresAt *time.Time `json:"upload_expires_at,omitempty"`
+
 	// Relations
 	DatasetMounts []DatasetMount `gorm:"foreignKey:DatasetID" json:"mounts,omitempty"`
 }
@@ -0,0 +1,49 @@
+package entity
+
+import "time"
+
+// GPU 指标降采样粒度
+const (
+	GPUMetricResolutionMinute = "1m"
+	GPUMetricResolutionHour   = "1h"
+	GPUMetricResolutionDay    = "1d"
+)
+
+// GPUMetric 心跳上报的单个 GPU 原始指标，按机器和 GPU UUID 区分（UUID 为空时按序号）
+type GPUMetric struct {
+	ID                 uint64    `gorm:"primaryKey;autoIncrement" json:"id"`
+	HostID             string    `gorm:"type:varchar(64);not null" json:"host_id"`
+	GPUUUID            string    `gorm:"column:gpu_uuid;type:varchar(128);not null;default:''" json:"gpu_uuid"`
+	GPUIndex           int       `gorm:"column:gpu_index;not null;default:0" json:"gpu_index"`
+	UtilizationPercent *float64  `json:"utilization_percent,omitempty"`
+	MemoryUsed         *int64    `json:"memory_used,omitempty"` // 显存使用量(MB)
+	MemoryUsagePercent *float64  `json:"memory_usage_percent,omitempty"`
+	Temperature        *float64  `json:"temperature,omitempty"`
+	PowerDraw          *float64  `json:"power_draw,omitempty"`
+	CollectedAt        time.Time `gorm:"not null" json:"collected_at"`
+}
+
+func (GPUMetric) TableName() string {
+	return "gpu_metrics"
+}
+
+// GPUMetricRollup GPU 指标降采样数据，BucketStart 为时间桶起点（UTC 对齐）
+type GPUMetricRollup struct {
+	ID              uint64    `gorm:"primaryKey;autoIncrement" json:"id"`
+	Resolution      string    `gorm:"type:varchar(4);not null" json:"resolution"`
+	HostID          string    `gorm:"type:varchar(64);not null" json:"host_id"`
+	GPUUUID         string    `gorm:"column:gpu_uuid;type:varchar(128);not null;default:''" json:"gpu_uuid"`
+	GPUIndex        int       `gorm:"column:gpu_index;not null;default:0" json:"gpu_index"`
+	BucketStart     time.Time `gorm:"not null" json:"bucket_start"`
+	SampleCount     int       `gorm:"not null;default:0" json:"sample_count"`
+	UtilAvg         *float64  `json:"util_avg,omitempty"`
+	UtilMax         *float64  `json:"util_max,omitempty"`
+	MemoryUsedAvgMB *float64  `gorm:"column:memory_used_avg_mb" json:"memory_used_avg_mb,omitempty"`
+	MemoryUsageAvg  *float64  `json:"memory_usage_avg,omitempty"`
+	TemperatureMax  *float64  `json:"temperature_max,omitempty"`
+	PowerAvg        *float64  `json:"power_avg,omitempty"`
+}
+
+func (GPUMetricRollup) TableName() string {
+	return "gpu_metric_rollups"
+}
@@ -0,0 +1,16 @@
+package entity
+
+import "time"
+
+// MFARecoveryCode 两步验证恢复码，只保存哈希，每个恢复码只能使用一次
+type MFARecoveryCode struct {
+	ID         uint       `gorm:"primarykey" json:"id"`
+	CustomerID uint       `gorm:"not null;index" json:"customer_id"`
+	CodeHash   string     `gorm:"type:varchar(64);not null;uniqueIndex" json:"-"`
+	UsedAt     *time.Time `json:"used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func (MFARecoveryCode) TableName() string {
+	return "mfa_recovery_codes"
+}
@@ -49,8 +49,11 @@ type ActiveAlert struct {
 	Value   float64 `gorm:"not null" json:"value"`
 	Message string  `gorm:"type:text" json:"message"`
 
-	TriggeredAt  time.Time `json:"triggered_at"`
-	Acknowledged bool      `gorm:"default:false" json:"acknowledged"`
+	// Status: firing, resolved
+	Status       string     `gorm:"type:varchar(20);default:'firing';index" json:"status"`
+	TriggeredAt  time.Time  `json:"triggered_at"`
+	ResolvedAt   *time.Time `json:"resolved_at,omitempty"`
+	Acknowledged bool       `gorm:"default:false" json:"acknowledged"`
 
 	// Relations
 	Rule AlertRule `gorm:"foreignKey:RuleID" json:"rule"`
@@ -0,0 +1,49 @@
+package entity
+
+import "time"
+
+// 预约状态
+const (
+	ReservationStatusQueued    = "queued"    // 排队中：暂无满足条件的机器
+	ReservationStatusPending   = "pending"   // 已锁定机器，等待时间窗口开始
+	ReservationStatusActive    = "active"    // 已按正常分配流程分配机器
+	ReservationStatusCancelled = "cancelled" // 已取消
+	ReservationStatusFailed    = "failed"    // 激活失败
+	ReservationStatusExpired   = "expired"   // 时间窗口结束仍未激活
+)
+
+// Reservation 机器预约，客户按 GPU 型号和数量预约未来的时间窗口
+type Reservation struct {
+	ID          string     `gorm:"primarykey;type:varchar(64)" json:"id"`
+	CustomerID  uint       `gorm:"not null;index" json:"customer_id"`
+	GPUModel    string     `gorm:"column:gpu_model;type:varchar(128);not null" json:"gpu_model"`
+	GPUCount    int        `gorm:"column:gpu_count;not null" json:"gpu_count"`
+	StartTime   time.Time  `gorm:"not null" json:"start_time"`
+	EndTime     time.Time  `gorm:"not null" json:"end_time"`
+	Status      string     `gorm:"type:varchar(20);not null;default:'queued';index" json:"status"`
+	Remark      string     `gorm:"type:text" json:"remark"`
+	Reason      string     `gorm:"type:text" json:"reason,omitempty"` // 取消/失败/过期原因
+	ActivatedAt *time.Time `json:"activated_at,omitempty"`
+	CancelledAt *time.Time `json:"cancelled_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+
+	Hosts []ReservationHost `gorm:"foreignKey:ReservationID" json:"hosts,omitempty"`
+}
+
+func (Reservation) TableName() string {
+	return "reservations"
+}
+
+// ReservationHost 预约锁定的机器，激活后记录对应的分配
+type ReservationHost struct {
+	ID            uint    `gorm:"primarykey" json:"id"`
+	ReservationID string  `gorm:"type:varchar(64);not null;index" json:"reservation_id"`
+	HostID        string  `gorm:"type:varchar(64);not null;index" json:"host_id"`
+	GPUCount      int     `gorm:"column:gpu_count;not null" json:"gpu_count"`
+	AllocationID  *string `gorm:"type:varchar(64)" json:"allocation_id,omitempty"`
+}
+
+func (ReservationHost) TableName() string {
+	return "reservation_hosts"
+}
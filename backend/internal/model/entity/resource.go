@@ -46,7 +46,7 @@ type Host struct {
 	// Status
 	Status           string `gorm:"type:varchar(20);default:'offline'" json:"status"`             // 兼容旧字段
 	DeviceStatus     string `gorm:"type:varchar(20);default:'offline'" json:"device_status"`      // online, offline
-	AllocationStatus string `gorm:"type:varchar(20);default:'idle'" json:"allocation_status"`     // idle, allocated, maintenance
+	AllocationStatus string `gorm:"type:varchar(20);default:'idle'" json:"allocation_status"`     // idle, partial（部分 GPU 已分配）, allocated, maintenance
 	HealthStatus     string `gorm:"type:varchar(20);default:'unknown'" json:"health_status"`
 	DeploymentMode string `gorm:"type:varchar(20);default:'traditional'" json:"deployment_mode"`
 	NeedsCollect   bool   `gorm:"default:false" json:"needs_collect"`
@@ -0,0 +1,22 @@
+package entity
+
+import "time"
+
+// 存储配额范围
+const (
+	StorageScopeCustomer  = "customer"
+	StorageScopeWorkspace = "workspace"
+)
+
+// StorageQuotaAlert 存储用量提醒状态，记录已提醒的百分比级别
+type StorageQuotaAlert struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	Scope     string    `gorm:"type:varchar(16);not null;uniqueIndex:idx_storage_quota_alert_scope" json:"scope"`
+	ScopeID   uint      `gorm:"not null;uniqueIndex:idx_storage_quota_alert_scope" json:"scope_id"`
+	Level     int       `gorm:"not null;default:0" json:"level"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (StorageQuotaAlert) TableName() string {
+	return "storage_quota_alerts"
+}
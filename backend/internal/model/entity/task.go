@@ -39,13 +39,28 @@ type Task struct {
 
 	// 关联
 	MachineID string `gorm:"type:varchar(64)" json:"machine_id"`
-	GroupID   string `gorm:"type:varchar(64)" json:"group_id"`
+	GroupID   string `gorm:"type:varchar(64)" json:"group_id"` // 所属工作流 ID
 	ParentID  string `gorm:"type:varchar(64)" json:"parent_id"`
 
+	// 上游依赖（同一工作流内的任务 ID 列表），全部成功完成后任务才可被认领
+	DependsOn datatypes.JSON `gorm:"type:jsonb" json:"depends_on,omitempty"`
+
+	// 资源需求（未指定 MachineID 时，在 Agent 认领时按需求选择机器）
+	GPUCount       int            `gorm:"column:gpu_count;default:0" json:"gpu_count"`
+	GPUModel       string         `gorm:"column:gpu_model;type:varchar(128)" json:"gpu_model,omitempty"`
+	MinGPUMemoryMB int            `gorm:"column:min_gpu_memory_mb;default:0" json:"min_gpu_memory_mb"`
+	AllowedHosts   datatypes.JSON `gorm:"type:jsonb" json:"allowed_hosts,omitempty"`
+	AssignedGPUs   datatypes.JSON `gorm:"column:assigned_gpus;type:jsonb" json:"assigned_gpus,omitempty"`
+	// RunAs 认领时填写：客户在该机器上只分配了部分 GPU 时，Agent 以该客户的系统账号运行任务
+	RunAs string `gorm:"-" json:"run_as,omitempty"`
+	// Placed MachineID 由调度器在认领时选择（而非创建时指定），重新排队时清空以便调度到其他机器
+	Placed bool `gorm:"default:false" json:"placed"`
+
 	// 调度与租约
 	AssignedAgentID string     `gorm:"type:varchar(64)" json:"assigned_agent_id"`
 	LeaseExpiresAt  *time.Time `json:"lease_expires_at"`
 	AttemptID       string     `gorm:"type:varchar(64)" json:"attempt_id"`
+	NextAttemptAt   *time.Time `json:"next_attempt_at,omitempty"` // 租约过期重新排队后，早于该时间不可被认领
 
 	// 时间戳
 	CreatedAt  time.Time  `json:"created_at"`
@@ -61,3 +76,20 @@ type Task struct {
 	// Relations
 	Image *Image `gorm:"foreignKey:ImageID" json:"image,omitempty"`
 }
+
+// TaskLogChunk 任务日志分片，按 (任务, 尝试, 输出流) 追加写入
+// ByteOffset 为分片在该输出流中的起始字节偏移，Agent 重传同一偏移时幂等
+type TaskLogChunk struct {
+	ID         uint      `gorm:"primarykey" json:"id"`
+	TaskID     string    `gorm:"type:varchar(64);not null;uniqueIndex:idx_task_log_chunks_pos,priority:1" json:"task_id"`
+	AttemptID  string    `gorm:"type:varchar(64);not null;uniqueIndex:idx_task_log_chunks_pos,priority:2" json:"attempt_id"`
+	Stream     string    `gorm:"type:varchar(10);not null;uniqueIndex:idx_task_log_chunks_pos,priority:3" json:"stream"` // stdout, stderr
+	ByteOffset int64     `gorm:"not null;uniqueIndex:idx_task_log_chunks_pos,priority:4" json:"byte_offset"`
+	Size       int       `gorm:"not null" json:"size"`
+	Content    []byte    `gorm:"type:bytea" json:"-"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (TaskLogChunk) TableName() string {
+	return "task_log_chunks"
+}
@@ -14,6 +14,7 @@ type Workspace struct {
 	Type        string    `gorm:"type:varchar(32);default:'personal'" json:"type"`   // personal, team, enterprise
 	MemberCount int       `gorm:"default:1" json:"member_count"`
 	Status      string    `gorm:"type:varchar(32);default:'active'" json:"status"`   // active, archived
+	QuotaStorage int64    `gorm:"default:0" json:"quota_storage"`                     // 存储容量配额（MB），0 表示不限制
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 
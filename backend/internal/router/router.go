@@ -9,8 +9,10 @@ import (
 
 	"github.com/YoungBoyGod/remotegpu/config"
 	"github.com/YoungBoyGod/remotegpu/internal/middleware"
+	"github.com/YoungBoyGod/remotegpu/pkg/auth"
 	"github.com/YoungBoyGod/remotegpu/pkg/cache"
 	"github.com/YoungBoyGod/remotegpu/pkg/database"
+	"github.com/YoungBoyGod/remotegpu/pkg/mail"
 	"github.com/YoungBoyGod/remotegpu/pkg/prometheus"
 	"github.com/YoungBoyGod/remotegpu/pkg/storage"
 
@@ -21,9 +23,12 @@ import (
 	serviceCustomer "github.com/YoungBoyGod/remotegpu/internal/service/customer"
 	serviceDataset "github.com/YoungBoyGod/remotegpu/internal/service/dataset"
 	serviceImage "github.com/YoungBoyGod/remotegpu/internal/service/image"
+	serviceCredential "github.com/YoungBoyGod/remotegpu/internal/service/credential"
 	serviceMachine "github.com/YoungBoyGod/remotegpu/internal/service/machine"
 	serviceOps "github.com/YoungBoyGod/remotegpu/internal/service/ops"
 	serviceSSHKey "github.com/YoungBoyGod/remotegpu/internal/service/sshkey"
+	serviceAccessToken "github.com/YoungBoyGod/remotegpu/internal/service/accesstoken"
+	serviceSSO "github.com/YoungBoyGod/remotegpu/internal/service/sso"
 	serviceStorage "github.com/YoungBoyGod/remotegpu/internal/service/storage"
 	serviceSystemConfig "github.com/YoungBoyGod/remotegpu/internal/service/system_config"
 	serviceDocument "github.com/YoungBoyGod/remotegpu/internal/service/document"
@@ -32,6 +37,8 @@ import (
 	serviceWorkspace "github.com/YoungBoyGod/remotegpu/internal/service/workspace"
 	serviceEnvironment "github.com/YoungBoyGod/remotegpu/internal/service/environment"
 	serviceProxy "github.com/YoungBoyGod/remotegpu/internal/service/proxy"
+	serviceReservation "github.com/YoungBoyGod/remotegpu/internal/service/reservation"
+	serviceBilling "github.com/YoungBoyGod/remotegpu/internal/service/billing"
 
 	// 控制器层
 	ctrlAuth "github.com/YoungBoyGod/remotegpu/internal/controller/v1/auth"
@@ -46,6 +53,8 @@ import (
 	ctrlDocument "github.com/YoungBoyGod/remotegpu/internal/controller/v1/document"
 	ctrlNotification "github.com/YoungBoyGod/remotegpu/internal/controller/v1/notification"
 	ctrlStorage "github.com/YoungBoyGod/remotegpu/internal/controller/v1/storage"
+	ctrlReservation "github.com/YoungBoyGod/remotegpu/internal/controller/v1/reservation"
+	ctrlBilling "github.com/YoungBoyGod/remotegpu/internal/controller/v1/billing"
 	ctrlWorkspace "github.com/YoungBoyGod/remotegpu/internal/controller/v1/workspace"
 	ctrlEnvironment "github.com/YoungBoyGod/remotegpu/internal/controller/v1/environment"
 	ctrlAllocation "github.com/YoungBoyGod/remotegpu/internal/controller/v1/allocation"
@@ -77,7 +86,7 @@ func InitRouter(r *gin.Engine) {
 
 	// Prometheus metrics 中间件和端点
 	r.Use(middleware.PrometheusMetrics())
-	r.GET("/metrics", middleware.Auth(db), middleware.RequireAdmin(), gin.WrapH(promhttp.Handler()))
+	r.GET("/metrics", middleware.Auth(db), middleware.RejectAccessToken(), middleware.RequireAdmin(), gin.WrapH(promhttp.Handler()))
 
 	// 注册数据库连接池指标
 	if sqlDB, err := db.DB(); err == nil {
@@ -106,7 +115,15 @@ func InitRouter(r *gin.Engine) {
 	}
 
 	// --- 服务层初始化 ---
+	// 邮件发送器：未启用时为 nil，依赖邮件的功能（密码重置、邮箱验证、到期邮件）不可用或跳过
+	var mailSender mail.Sender
+	if config.GlobalConfig.Mail.Enabled {
+		mailSender = mail.NewSMTPSender(config.GlobalConfig.Mail)
+	}
 	authSvc := serviceAuth.NewAuthService(db, cache.GetCache())
+	if mailSender != nil {
+		authSvc.SetMailer(mailSender, config.GlobalConfig.Mail.BaseURL)
+	}
 	machineSvc := serviceMachine.NewMachineService(db)
 	// 注入 Redis 设备状态缓存
 	// 使用心跳监控配置的超时值作为 Redis TTL，确保超时判断一致
@@ -122,16 +139,28 @@ func InitRouter(r *gin.Engine) {
 		go syncer.Start(context.Background())
 	}
 	auditSvc := serviceAudit.NewAuditService(db)
+	authSvc.SetAuditService(auditSvc) // 注入审计服务，账号锁定时记录审计日志
+	if config.GlobalConfig.LoginLockout.Enabled {
+		authSvc.SetLoginLockout(config.GlobalConfig.LoginLockout)
+	}
+	// 企业单点登录：身份源按企业编码保存在系统配置 sso_idp_<企业编码> 中
+	ssoSvc := serviceSSO.NewSSOService(db, cache.GetCache(), authSvc)
+	ssoSvc.SetAuditService(auditSvc)
 	agentSvc := serviceOps.NewAgentService(db, &config.GlobalConfig.Agent)
 	allocSvc := serviceAllocation.NewAllocationService(db, auditSvc, agentSvc)
 	allocSvc.StartWorker(context.Background())
 	custSvc := serviceCustomer.NewCustomerService(db)
 	opsSvc := serviceOps.NewOpsService(db)
 	taskSvc := serviceTask.NewTaskService(db, agentSvc)
+	taskPlacer := serviceTask.NewPlacer(db)
+	taskSvc.SetPlacer(taskPlacer)
+	machineSvc.AddHeartbeatObserver(taskPlacer) // 心跳上报的 GPU 状态用于跨机器任务调度
 	datasetSvc := serviceDataset.NewDatasetService(db)
 	documentSvc := serviceDocument.NewDocumentService(db, storageMgr)
 	sseHub := serviceNotification.NewSSEHub()
 	notificationSvc := serviceNotification.NewNotificationService(db, sseHub)
+	taskSvc.SetProgressNotifier(notificationSvc)    // Agent 上报进度时实时推送 SSE
+	custSvc.SetNotificationService(notificationSvc) // 存储用量达到 80%/95% 时提醒
 
 	// Prometheus 客户端
 	promClient := prometheus.NewClient(&prometheus.Config{
@@ -144,6 +173,8 @@ func InitRouter(r *gin.Engine) {
 	sshKeySvc := serviceSSHKey.NewSSHKeyService(db)
 	sshKeySvc.SetKeySyncer(allocSvc) // 注入密钥同步器，密钥变更时自动同步到已分配机器
 	imageSvc := serviceImage.NewImageService(db)
+	agentCredentialSvc := serviceCredential.NewAgentCredentialService(db)
+	agentSvc.SetControlKeyProvider(agentCredentialSvc) // 调用 Agent 控制接口时按机器密钥签名
 	enrollmentSvc := serviceMachine.NewMachineEnrollmentService(db, machineSvc, &agentAdapter{svc: agentSvc})
 	enrollmentSvc.StartWorker(context.Background())
 
@@ -159,25 +190,94 @@ func InitRouter(r *gin.Engine) {
 
 	// 启动监控数据采集服务
 	if config.GlobalConfig.MetricsCollector.Enabled {
-		metricsCollector := serviceMachine.NewMetricsCollector(
+		metricsCollector := serviceMachine.NewMetricsCollector(db, config.GlobalConfig.MetricsCollector)
+		go metricsCollector.Start(context.Background())
+	}
+
+	// 启动告警评估引擎
+	if config.GlobalConfig.AlertEvaluator.Enabled {
+		alertEvaluator := serviceOps.NewAlertEvaluator(
 			db,
-			time.Duration(config.GlobalConfig.MetricsCollector.Interval)*time.Second,
-			config.GlobalConfig.MetricsCollector.RetentionDays,
+			notificationSvc,
+			time.Duration(config.GlobalConfig.AlertEvaluator.Interval)*time.Second,
 		)
-		go metricsCollector.Start(context.Background())
+		machineSvc.AddHeartbeatObserver(alertEvaluator)
+		go alertEvaluator.Start(context.Background())
+	}
+
+	// 启动任务租约回收：Agent 停止续约的任务重新排队或标记失败
+	if config.GlobalConfig.TaskLeaseReaper.Enabled {
+		leaseReaper := serviceTask.NewLeaseReaper(
+			taskSvc,
+			notificationSvc,
+			time.Duration(config.GlobalConfig.TaskLeaseReaper.Interval)*time.Second,
+		)
+		go leaseReaper.Start(context.Background())
+	}
+
+	// 数据集分片上传，过期未完成的上传由清理任务中止
+	uploadSvc := serviceDataset.NewUploadService(db, storageMgr, config.GlobalConfig.DatasetUpload, config.GlobalConfig.Storage.MaxUploadSize)
+	uploadSvc.SetQuotaService(custSvc) // 初始化和完成上传时校验存储配额
+	if config.GlobalConfig.DatasetUpload.JanitorEnabled {
+		uploadJanitor := serviceDataset.NewUploadJanitor(
+			uploadSvc,
+			time.Duration(config.GlobalConfig.DatasetUpload.JanitorInterval)*time.Second,
+		)
+		go uploadJanitor.Start(context.Background())
+	}
+
+	// 启动机器分配到期处理：到期前提醒客户，到期后自动回收
+	if config.GlobalConfig.AllocationExpiry.Enabled {
+		expiryScheduler := serviceAllocation.NewExpiryScheduler(
+			allocSvc,
+			notificationSvc,
+			time.Duration(config.GlobalConfig.AllocationExpiry.Interval)*time.Second,
+			config.GlobalConfig.AllocationExpiry.WarnDays,
+		)
+		if mailSender != nil {
+			expiryScheduler.SetMailSender(mailSender)
+		}
+		go expiryScheduler.Start(context.Background())
+	}
+
+	// 机器预约：排队中的预约按顺序锁定机器，时间窗口开始时按正常分配流程激活
+	reservationSvc := serviceReservation.NewReservationService(db, allocSvc, config.GlobalConfig.Reservation)
+	reservationSvc.SetNotifier(notificationSvc) // 预约锁定、激活、失败、过期时通知客户
+	if config.GlobalConfig.Reservation.Enabled {
+		reservationScheduler := serviceReservation.NewScheduler(
+			reservationSvc,
+			time.Duration(config.GlobalConfig.Reservation.Interval)*time.Second,
+		)
+		go reservationScheduler.Start(context.Background())
+	}
+
+	// 用量计量：定时记录分配/环境/任务的 GPU 用量，每月初生成上月账单
+	billingSvc := serviceBilling.NewBillingService(db, config.GlobalConfig.Billing)
+	if config.GlobalConfig.Billing.Enabled {
+		billingScheduler := serviceBilling.NewScheduler(
+			billingSvc,
+			time.Duration(config.GlobalConfig.Billing.Interval)*time.Second,
+		)
+		go billingScheduler.Start(context.Background())
 	}
 
 	systemConfigSvc := serviceSystemConfig.NewSystemConfigService(db)
 	systemConfigSvc.SetAuditService(auditSvc) // 注入审计服务，配置变更时记录审计日志
+	systemConfigSvc.SetSecretCodec(serviceSSO.ConfigKeyPrefix, serviceSSO.ConfigCodec{}) // 身份源密钥加密保存，展示和审计时脱敏
 	dashboardSvc := serviceOps.NewDashboardService(machineSvc, custSvc, allocSvc, promClient)
 	workspaceSvc := serviceWorkspace.NewWorkspaceService(db)
 	environmentSvc := serviceEnvironment.NewEnvironmentService(db)
 	proxySvc := serviceProxy.NewProxyService(db)
+	proxySvc.SetToken(config.GlobalConfig.Agent.Token) // Proxy 管理 API 与 Proxy 上报接口共用共享 Token
+	environmentSvc.SetContainerManager(agentSvc)
+	environmentSvc.SetPortMapper(proxySvc) // 环境启停时自动编排 Proxy 端口映射
 
 	// --- 控制器层初始化 ---
 	authController := ctrlAuth.NewAuthController(authSvc)
+	ssoController := ctrlAuth.NewSSOController(ssoSvc)
 	dashboardController := ctrlOps.NewDashboardController(dashboardSvc)
 	machineController := ctrlMachine.NewMachineController(machineSvc, allocSvc, agentSvc)
+	machineController.SetCredentialService(agentCredentialSvc)
 	customerController := ctrlCustomer.NewCustomerController(custSvc)
 	monitorController := ctrlOps.NewMonitorController(monitorSvc)
 	alertController := ctrlOps.NewAlertController(opsSvc)
@@ -187,9 +287,12 @@ func InitRouter(r *gin.Engine) {
 	taskController := ctrlTask.NewTaskController(taskSvc)
 	adminTaskController := ctrlTask.NewAdminTaskController(taskSvc)
 	agentTaskController := ctrlTask.NewAgentTaskController(taskSvc)
+	workflowController := ctrlTask.NewWorkflowController(taskSvc)
 	agentHeartbeatController := ctrlAgent.NewHeartbeatController(machineSvc)
-	datasetController := ctrlDataset.NewDatasetController(datasetSvc, storageSvc, agentSvc, allocSvc)
+	agentHeartbeatController.SetCredentialService(agentCredentialSvc)
+	datasetController := ctrlDataset.NewDatasetController(datasetSvc, uploadSvc, agentSvc, allocSvc)
 	sshKeyController := ctrlCustomer.NewSSHKeyController(sshKeySvc)
+	accessTokenController := ctrlCustomer.NewAccessTokenController(serviceAccessToken.NewAccessTokenService(db))
 	enrollmentController := ctrlCustomer.NewMachineEnrollmentController(enrollmentSvc)
 	auditController := ctrlOps.NewAuditController(auditSvc)
 	imageController := ctrlOps.NewImageController(imageSvc)
@@ -200,9 +303,34 @@ func InitRouter(r *gin.Engine) {
 	workspaceController := ctrlWorkspace.NewWorkspaceController(workspaceSvc)
 	environmentController := ctrlEnvironment.NewEnvironmentController(environmentSvc)
 	allocationController := ctrlAllocation.NewAllocationController(allocSvc)
+	reservationController := ctrlReservation.NewReservationController(reservationSvc)
+	adminReservationController := ctrlReservation.NewAdminReservationController(reservationSvc)
+	billingController := ctrlBilling.NewBillingController(billingSvc)
+	adminBillingController := ctrlBilling.NewAdminBillingController(billingSvc)
 	proxyController := ctrlProxy.NewProxyController(proxySvc)
 
 	// API v1 路由
+	// 限流：计数存放在全局缓存（Redis），未启用时各规则直接放行
+	var rateLimitStore cache.Cache
+	rateLimitCfg := config.GlobalConfig.RateLimit
+	if rateLimitCfg.Enabled {
+		rateLimitStore = cache.GetCache()
+	}
+	rateLimitWindow := time.Duration(rateLimitCfg.Window) * time.Second
+	if rateLimitWindow <= 0 {
+		rateLimitWindow = time.Minute
+	}
+	rateLimitRule := func(limit int) middleware.RateLimitRule {
+		return middleware.RateLimitRule{Limit: limit, Window: rateLimitWindow}
+	}
+	loginIPLimit := middleware.RateLimit(rateLimitStore, "login:ip", rateLimitRule(rateLimitCfg.LoginPerIP), middleware.ClientIPKey)
+	loginAccountLimit := middleware.RateLimit(rateLimitStore, "login:account", rateLimitRule(rateLimitCfg.LoginPerAccount), middleware.JSONFieldKey("username"))
+	resetIPLimit := middleware.RateLimit(rateLimitStore, "password_reset:ip", rateLimitRule(rateLimitCfg.PasswordResetPerIP), middleware.ClientIPKey)
+	resetAccountLimit := middleware.RateLimit(rateLimitStore, "password_reset:account", rateLimitRule(rateLimitCfg.PasswordResetPerAccount), middleware.JSONFieldKey("username"))
+	mfaManageLimit := middleware.RateLimit(rateLimitStore, "mfa_manage:user", rateLimitRule(rateLimitCfg.MFAManagePerAccount), middleware.UserIDKey)
+	// machine_id 已由 AgentAuth 校验与凭证一致
+	agentClaimLimit := middleware.RateLimit(rateLimitStore, "agent_claim:machine", rateLimitRule(rateLimitCfg.AgentClaimPerMachine), middleware.JSONFieldKey("machine_id"))
+
 	apiV1 := r.Group("/api/v1")
 	{
 		apiV1.GET("/health", func(c *gin.Context) {
@@ -210,33 +338,69 @@ func InitRouter(r *gin.Engine) {
 		})
 
 		// SSE 实时通知推送（匹配 Nginx 代理路径）
-		apiV1.GET("/notifications/stream", middleware.Auth(db), notificationController.SSE)
+		apiV1.GET("/notifications/stream", middleware.Auth(db), middleware.RequireScope(auth.ScopeNotifications), notificationController.SSE)
 
 		// 1. Auth Module
 		authGroup := apiV1.Group("/auth")
+		// 账号安全相关接口只接受登录会话，不接受个人访问令牌
+		authSession := authGroup.Group("", middleware.Auth(db), middleware.RejectAccessToken())
 		{
-			authGroup.POST("/login", authController.Login)
-			authGroup.POST("/admin/login", authController.AdminLogin)
+			authGroup.POST("/login", loginIPLimit, loginAccountLimit, authController.Login)
+			authGroup.POST("/admin/login", loginIPLimit, loginAccountLimit, authController.AdminLogin)
 			authGroup.POST("/refresh", authController.Refresh)
 			authGroup.POST("/logout", authController.Logout)
 
 			// 受保护的个人资料
-			authGroup.GET("/profile", middleware.Auth(db), authController.GetProfile)
-			authGroup.PUT("/profile", middleware.Auth(db), authController.UpdateProfile)
-			authGroup.POST("/password/change", middleware.Auth(db), authController.ChangePassword)
-			authGroup.POST("/password/request", authController.RequestPasswordReset)
+			authSession.GET("/profile", authController.GetProfile)
+			authSession.PUT("/profile", authController.UpdateProfile)
+			authSession.POST("/password/change", authController.ChangePassword)
+			authGroup.POST("/password/request", resetIPLimit, resetAccountLimit, authController.RequestPasswordReset)
 			authGroup.POST("/password/confirm", authController.ConfirmPasswordReset)
+			authSession.POST("/email/verify", authController.RequestEmailVerification)
+			authGroup.POST("/email/verify/confirm", authController.ConfirmEmailVerification)
+
+			// 两步验证
+			authGroup.POST("/mfa/verify", loginIPLimit, authController.VerifyMFALogin)
+			authGroup.POST("/mfa/enroll", loginIPLimit, authController.EnrollMFAWithToken)
+			authSession.POST("/mfa/setup", authController.SetupMFA)
+			authSession.POST("/mfa/enable", authController.EnableMFA)
+			authSession.POST("/mfa/disable", mfaManageLimit, authController.DisableMFA)
+			authSession.POST("/mfa/recovery-codes", mfaManageLimit, authController.RegenerateRecoveryCodes)
+
+			// 企业单点登录
+			authGroup.GET("/sso/providers/:company_code", ssoController.GetProvider)
+			authGroup.POST("/sso/oidc/authorize", loginIPLimit, ssoController.AuthorizeOIDC)
+			authGroup.POST("/sso/oidc/callback", loginIPLimit, ssoController.OIDCCallback)
+			authGroup.POST("/sso/ldap/login", loginIPLimit, loginAccountLimit, ssoController.LDAPLogin)
 		}
 
 		// 2. Admin Module (Protected + Role Check)
 		adminGroup := apiV1.Group("/admin")
-		adminGroup.Use(middleware.Auth(db), middleware.RequireAdmin(), middleware.AuditMiddleware(auditSvc))
+		adminGroup.Use(middleware.Auth(db), middleware.RejectAccessToken(), middleware.RequireAdmin(), middleware.AuditMiddleware(auditSvc))
 		{
 			// 仪表板
 			adminGroup.GET("/dashboard/stats", dashboardController.GetStats)
 			adminGroup.GET("/dashboard/gpu-trend", dashboardController.GetGPUTrend)
 			adminGroup.GET("/allocations/recent", dashboardController.GetRecentAllocations)
 			adminGroup.GET("/allocations", allocationController.List)
+			adminGroup.POST("/allocations/:id/renew", allocationController.Renew)
+			adminGroup.GET("/allocations/:id/renewals", allocationController.Renewals)
+			adminGroup.POST("/allocations/:id/reclaim", allocationController.Reclaim)
+
+			// 机器预约
+			adminGroup.GET("/reservations", adminReservationController.List)
+			adminGroup.GET("/reservations/:id", adminReservationController.Detail)
+			adminGroup.POST("/reservations/:id/cancel", adminReservationController.Cancel)
+
+			// 用量与账单
+			adminGroup.GET("/billing/usage", adminBillingController.Usage)
+			adminGroup.GET("/billing/usage/export", adminBillingController.ExportUsage)
+			adminGroup.GET("/billing/statement", adminBillingController.Statement)
+			adminGroup.GET("/billing/prices", adminBillingController.Prices)
+			adminGroup.GET("/billing/invoices", adminBillingController.Invoices)
+			adminGroup.POST("/billing/invoices/generate", adminBillingController.GenerateInvoices)
+			adminGroup.GET("/billing/invoices/:id", adminBillingController.InvoiceDetail)
+			adminGroup.GET("/billing/invoices/:id/export", adminBillingController.ExportInvoice)
 
 			// 机器管理
 			adminGroup.GET("/machines", machineController.List)
@@ -250,6 +414,9 @@ func InitRouter(r *gin.Engine) {
 			adminGroup.POST("/machines/:id/reclaim", machineController.Reclaim)
 			adminGroup.POST("/machines/:id/maintenance", machineController.SetMaintenance)
 			adminGroup.GET("/machines/:id/usage", machineController.Usage)
+			adminGroup.GET("/machines/:id/agent-credential", machineController.GetAgentCredential)
+			adminGroup.POST("/machines/:id/agent-credential/rotate", machineController.IssueAgentEnrollmentToken)
+			adminGroup.DELETE("/machines/:id/agent-credential", machineController.RevokeAgentCredential)
 
 			// 机器批量操作
 			adminGroup.POST("/machines/batch/maintenance", machineController.BatchSetMaintenance)
@@ -263,8 +430,12 @@ func InitRouter(r *gin.Engine) {
 			adminGroup.PUT("/customers/:id", customerController.Update)
 			adminGroup.POST("/customers/:id/disable", customerController.Disable)
 			adminGroup.POST("/customers/:id/enable", customerController.Enable)
+			adminGroup.POST("/customers/:id/unlock", customerController.Unlock)
+			adminGroup.POST("/customers/:id/mfa/reset", customerController.ResetMFA)
 			adminGroup.PUT("/customers/:id/quota", customerController.UpdateQuota)
 			adminGroup.GET("/customers/:id/usage", customerController.ResourceUsage)
+			adminGroup.GET("/customers/:id/storage", customerController.StorageUsage)
+			adminGroup.PUT("/workspaces/:id/quota", workspaceController.UpdateQuota)
 
 			// 监控与运维
 			adminGroup.GET("/monitoring/realtime", monitorController.GetRealtime)
@@ -329,90 +500,130 @@ func InitRouter(r *gin.Engine) {
 			adminGroup.GET("/proxy/nodes", proxyController.ListNodes)
 			adminGroup.GET("/proxy/nodes/:id", proxyController.GetNode)
 			adminGroup.DELETE("/proxy/nodes/:id", proxyController.DeleteNode)
+			adminGroup.POST("/proxy/nodes/:id/reconcile", proxyController.ReconcileNode)
 			adminGroup.GET("/proxy/mappings", proxyController.ListMappings)
 		}
 
 		// 3. Customer Module (Protected)
 		custGroup := apiV1.Group("/customer")
 		custGroup.Use(middleware.Auth(db))
+		// 个人访问令牌按路由分组校验权限范围，登录会话不受限制
+		custMachines := custGroup.Group("", middleware.RequireScope(auth.ScopeMachines))
+		custBilling := custGroup.Group("", middleware.RequireScope(auth.ScopeBilling))
+		custTasks := custGroup.Group("", middleware.RequireScope(auth.ScopeTasks))
+		custDatasets := custGroup.Group("", middleware.RequireScope(auth.ScopeDatasets))
+		custNotifications := custGroup.Group("", middleware.RequireScope(auth.ScopeNotifications))
+		custWorkspaces := custGroup.Group("", middleware.RequireScope(auth.ScopeWorkspaces))
+		custEnvironments := custGroup.Group("", middleware.RequireScope(auth.ScopeEnvironments))
 		{
+			// 个人访问令牌管理（只接受登录会话）
+			custGroup.GET("/tokens", middleware.RejectAccessToken(), accessTokenController.List)
+			custGroup.POST("/tokens", middleware.RejectAccessToken(), accessTokenController.Create)
+			custGroup.DELETE("/tokens/:id", middleware.RejectAccessToken(), accessTokenController.Revoke)
+
 			// 机器管理
-			custGroup.GET("/machines", myMachineController.List)
-			custGroup.POST("/machines", enrollmentController.Create)
-			custGroup.GET("/machines/:id/connection", myMachineController.GetConnection)
-			custGroup.POST("/machines/:id/ssh-reset", myMachineController.ResetSSH)
+			custMachines.GET("/machines", myMachineController.List)
+			custMachines.POST("/machines", enrollmentController.Create)
+			custMachines.GET("/machines/:id/connection", myMachineController.GetConnection)
+			custMachines.POST("/machines/:id/ssh-reset", myMachineController.ResetSSH)
+			custMachines.POST("/machines/:id/renew", myMachineController.Renew)
+
+			// 机器预约
+			custMachines.POST("/reservations", reservationController.Create)
+			custMachines.GET("/reservations", reservationController.List)
+			custMachines.GET("/reservations/:id", reservationController.Detail)
+			custMachines.POST("/reservations/:id/cancel", reservationController.Cancel)
+
+			// 用量与账单
+			custBilling.GET("/billing/usage", billingController.Usage)
+			custBilling.GET("/billing/usage/export", billingController.ExportUsage)
+			custBilling.GET("/billing/statement", billingController.Statement)
+			custBilling.GET("/billing/invoices", billingController.Invoices)
+			custBilling.GET("/billing/invoices/:id", billingController.InvoiceDetail)
+			custBilling.GET("/billing/invoices/:id/export", billingController.ExportInvoice)
 
 			// 任务管理
-			custGroup.GET("/tasks", taskController.List)
-			custGroup.GET("/tasks/:id", taskController.Detail)
-			custGroup.POST("/tasks/training", taskController.CreateTraining)
-			custGroup.POST("/tasks/:id/stop", taskController.Stop)
-			custGroup.POST("/tasks/:id/cancel", taskController.Cancel)
-			custGroup.POST("/tasks/:id/retry", taskController.Retry)
-			custGroup.GET("/tasks/:id/logs", taskController.Logs)
-			custGroup.GET("/tasks/:id/result", taskController.Result)
+			custTasks.GET("/tasks", taskController.List)
+			custTasks.GET("/tasks/:id", taskController.Detail)
+			custTasks.POST("/tasks/training", taskController.CreateTraining)
+			custTasks.POST("/tasks/:id/stop", taskController.Stop)
+			custTasks.POST("/tasks/:id/cancel", taskController.Cancel)
+			custTasks.POST("/tasks/:id/retry", taskController.Retry)
+			custTasks.GET("/tasks/:id/logs", taskController.Logs)
+			custTasks.GET("/tasks/:id/result", taskController.Result)
+
+			// 工作流（任务 DAG）
+			custTasks.POST("/workflows", workflowController.Create)
+			custTasks.GET("/workflows/:id", workflowController.Detail)
+			custTasks.POST("/workflows/:id/cancel", workflowController.Cancel)
 
 			// 数据集管理
-			custGroup.GET("/datasets", datasetController.List)
-			custGroup.POST("/datasets/init-multipart", datasetController.InitUpload)
-			custGroup.POST("/datasets/:id/complete", datasetController.CompleteUpload)
-			custGroup.POST("/datasets/:id/mount", datasetController.Mount)
-			custGroup.GET("/datasets/:id/mounts", datasetController.ListMounts)
-			custGroup.POST("/datasets/:id/mounts/:mount_id/unmount", datasetController.Unmount)
+			custDatasets.GET("/storage/usage", customerController.MyStorageUsage)
+			custDatasets.GET("/datasets", datasetController.List)
+			custDatasets.POST("/datasets/init-multipart", datasetController.InitUpload)
+			custDatasets.POST("/datasets/:id/uploads/:upload_id/part-urls", datasetController.PartURLs)
+			custDatasets.PUT("/datasets/:id/uploads/:upload_id/parts/:part_number", datasetController.UploadPart)
+			custDatasets.GET("/datasets/:id/uploads/:upload_id/parts", datasetController.ListParts)
+			custDatasets.DELETE("/datasets/:id/uploads/:upload_id", datasetController.AbortUpload)
+			custDatasets.POST("/datasets/:id/complete", datasetController.CompleteUpload)
+			custDatasets.POST("/datasets/:id/mount", datasetController.Mount)
+			custDatasets.GET("/datasets/:id/mounts", datasetController.ListMounts)
+			custDatasets.POST("/datasets/:id/mounts/:mount_id/unmount", datasetController.Unmount)
 
 			// SSH 密钥管理
-			custGroup.GET("/keys", sshKeyController.List)
-			custGroup.POST("/keys", sshKeyController.Create)
-			custGroup.DELETE("/keys/:id", sshKeyController.Delete)
+			custMachines.GET("/keys", sshKeyController.List)
+			custMachines.POST("/keys", sshKeyController.Create)
+			custMachines.DELETE("/keys/:id", sshKeyController.Delete)
 
 			// 用户添加机器
-			custGroup.POST("/machines/enroll", enrollmentController.Create)
-			custGroup.GET("/machines/enrollments", enrollmentController.List)
-			custGroup.GET("/machines/enrollments/:id", enrollmentController.Detail)
+			custMachines.POST("/machines/enroll", enrollmentController.Create)
+			custMachines.GET("/machines/enrollments", enrollmentController.List)
+			custMachines.GET("/machines/enrollments/:id", enrollmentController.Detail)
 
 			// 通知管理
-			custGroup.GET("/notifications/sse", notificationController.SSE)
-			custGroup.GET("/notifications", notificationController.List)
-			custGroup.GET("/notifications/unread-count", notificationController.UnreadCount)
-			custGroup.POST("/notifications/:id/read", notificationController.MarkRead)
-			custGroup.POST("/notifications/read-all", notificationController.MarkAllRead)
+			custNotifications.GET("/notifications/sse", notificationController.SSE)
+			custNotifications.GET("/notifications", notificationController.List)
+			custNotifications.GET("/notifications/unread-count", notificationController.UnreadCount)
+			custNotifications.POST("/notifications/:id/read", notificationController.MarkRead)
+			custNotifications.POST("/notifications/read-all", notificationController.MarkAllRead)
 
 			// 工作空间管理
-			custGroup.POST("/workspaces", workspaceController.Create)
-			custGroup.GET("/workspaces", workspaceController.List)
-			custGroup.GET("/workspaces/:id", workspaceController.Detail)
-			custGroup.PUT("/workspaces/:id", workspaceController.Update)
-			custGroup.DELETE("/workspaces/:id", workspaceController.Delete)
-			custGroup.POST("/workspaces/:id/members", workspaceController.AddMember)
-			custGroup.DELETE("/workspaces/:id/members/:userId", workspaceController.RemoveMember)
-			custGroup.GET("/workspaces/:id/members", workspaceController.ListMembers)
+			custWorkspaces.POST("/workspaces", workspaceController.Create)
+			custWorkspaces.GET("/workspaces", workspaceController.List)
+			custWorkspaces.GET("/workspaces/:id", workspaceController.Detail)
+			custWorkspaces.PUT("/workspaces/:id", workspaceController.Update)
+			custWorkspaces.DELETE("/workspaces/:id", workspaceController.Delete)
+			custWorkspaces.POST("/workspaces/:id/members", workspaceController.AddMember)
+			custWorkspaces.DELETE("/workspaces/:id/members/:userId", workspaceController.RemoveMember)
+			custWorkspaces.GET("/workspaces/:id/members", workspaceController.ListMembers)
 
 			// 环境管理
-			custGroup.POST("/environments", environmentController.Create)
-			custGroup.GET("/environments", environmentController.List)
-			custGroup.GET("/environments/:id", environmentController.Detail)
-			custGroup.POST("/environments/:id/start", environmentController.Start)
-			custGroup.POST("/environments/:id/stop", environmentController.Stop)
-			custGroup.DELETE("/environments/:id", environmentController.Delete)
-			custGroup.GET("/environments/:id/access", environmentController.AccessInfo)
+			custEnvironments.POST("/environments", environmentController.Create)
+			custEnvironments.GET("/environments", environmentController.List)
+			custEnvironments.GET("/environments/:id", environmentController.Detail)
+			custEnvironments.POST("/environments/:id/start", environmentController.Start)
+			custEnvironments.POST("/environments/:id/stop", environmentController.Stop)
+			custEnvironments.DELETE("/environments/:id", environmentController.Delete)
+			custEnvironments.GET("/environments/:id/access", environmentController.AccessInfo)
 		}
 
-		// 4. Agent Module (Agent 专用 API，需要 Agent Token 认证)
+		// 4. Agent Module (Agent 专用 API，需要机器级 Agent 凭证认证)
 		agentGroup := apiV1.Group("/agent")
-		agentGroup.Use(middleware.AgentAuth())
+		agentGroup.Use(middleware.AgentAuth(agentCredentialSvc))
 		{
 			agentGroup.POST("/register", agentHeartbeatController.Register)
 			agentGroup.POST("/heartbeat", agentHeartbeatController.Heartbeat)
-			agentGroup.POST("/tasks/claim", agentTaskController.ClaimTasks)
+			agentGroup.POST("/tasks/claim", agentClaimLimit, agentTaskController.ClaimTasks)
 			agentGroup.POST("/tasks/:id/start", agentTaskController.StartTask)
 			agentGroup.POST("/tasks/:id/lease/renew", agentTaskController.RenewLease)
 			agentGroup.POST("/tasks/:id/complete", agentTaskController.CompleteTask)
 			agentGroup.POST("/tasks/:id/progress", agentTaskController.ReportProgress)
+			agentGroup.POST("/tasks/:id/logs", agentTaskController.AppendLog)
 		}
 
-		// 5. Proxy Module (Proxy 专用 API，需要 Agent Token 认证)
+		// 5. Proxy Module (Proxy 专用 API，需要共享 Token 认证)
 		proxyGroup := apiV1.Group("/proxy")
-		proxyGroup.Use(middleware.AgentAuth())
+		proxyGroup.Use(middleware.ProxyAuth())
 		{
 			proxyGroup.POST("/register", proxyController.Register)
 			proxyGroup.POST("/heartbeat", proxyController.Heartbeat)
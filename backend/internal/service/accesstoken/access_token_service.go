@@ -0,0 +1,174 @@
+package accesstoken
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/YoungBoyGod/remotegpu/internal/dao"
+	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+	"github.com/YoungBoyGod/remotegpu/pkg/auth"
+	"github.com/YoungBoyGod/remotegpu/pkg/logger"
+	"gorm.io/gorm"
+)
+
+const (
+	// 未指定有效期时的默认有效期（天）
+	defaultExpireDays = 30
+	// 有效期上限（天）
+	maxExpireDays = 365
+	// 每个客户最多持有的有效令牌数
+	maxActiveTokens = 20
+	// 最后使用时间的更新间隔，避免每个请求都写库
+	lastUsedInterval = time.Minute
+	// 列表中展示的令牌明文长度（含前缀）
+	displayPrefixLen = len(auth.AccessTokenPrefix) + 6
+)
+
+var (
+	ErrInvalidScope        = errors.New("无效的权限范围")
+	ErrInvalidExpiry       = errors.New("有效期必须在 1 到 365 天之间")
+	ErrTooManyTokens       = errors.New("有效的访问令牌数量已达上限")
+	ErrTokenNotFound       = errors.New("访问令牌不存在")
+	ErrTokenNotOwnedByUser = errors.New("无权操作此访问令牌")
+	ErrTokenInvalid        = errors.New("无效的访问令牌")
+	ErrTokenExpired        = errors.New("访问令牌已过期或已吊销")
+	ErrCustomerInactive    = errors.New("账号已停用")
+)
+
+// Identity 访问令牌认证结果
+type Identity struct {
+	Customer *entity.Customer
+	Token    *entity.PersonalAccessToken
+	Scopes   []string
+}
+
+type AccessTokenService struct {
+	tokenDao    *dao.AccessTokenDao
+	customerDao *dao.CustomerDao
+}
+
+func NewAccessTokenService(db *gorm.DB) *AccessTokenService {
+	return &AccessTokenService{
+		tokenDao:    dao.NewAccessTokenDao(db),
+		customerDao: dao.NewCustomerDao(db),
+	}
+}
+
+// Create 创建个人访问令牌，返回令牌记录和明文（明文只在创建时返回一次）
+// expireDays 为 0 时使用默认有效期
+func (s *AccessTokenService) Create(ctx context.Context, customerID uint, name string, scopes []string, expireDays int) (*entity.PersonalAccessToken, string, error) {
+	normalized, err := normalizeScopes(scopes)
+	if err != nil {
+		return nil, "", err
+	}
+	if expireDays == 0 {
+		expireDays = defaultExpireDays
+	}
+	if expireDays < 0 || expireDays > maxExpireDays {
+		return nil, "", ErrInvalidExpiry
+	}
+
+	now := time.Now()
+	count, err := s.tokenDao.CountActive(ctx, customerID, now)
+	if err != nil {
+		return nil, "", err
+	}
+	if count >= maxActiveTokens {
+		return nil, "", ErrTooManyTokens
+	}
+
+	plain, err := auth.GenerateAccessToken()
+	if err != nil {
+		return nil, "", err
+	}
+	expiresAt := now.AddDate(0, 0, expireDays)
+	token := &entity.PersonalAccessToken{
+		CustomerID:  customerID,
+		Name:        strings.TrimSpace(name),
+		TokenPrefix: plain[:displayPrefixLen],
+		TokenHash:   auth.HashAccessToken(plain),
+		Scopes:      strings.Join(normalized, ","),
+		ExpiresAt:   &expiresAt,
+	}
+	if err := s.tokenDao.Create(ctx, token); err != nil {
+		return nil, "", err
+	}
+	return token, plain, nil
+}
+
+// List 列出客户的访问令牌
+func (s *AccessTokenService) List(ctx context.Context, customerID uint) ([]entity.PersonalAccessToken, error) {
+	return s.tokenDao.ListByCustomerID(ctx, customerID)
+}
+
+// Revoke 吊销客户自己的访问令牌
+func (s *AccessTokenService) Revoke(ctx context.Context, customerID, id uint) error {
+	token, err := s.tokenDao.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrTokenNotFound
+		}
+		return err
+	}
+	if token.CustomerID != customerID {
+		return ErrTokenNotOwnedByUser
+	}
+	return s.tokenDao.Revoke(ctx, id, time.Now())
+}
+
+// Authenticate 校验访问令牌并返回所属用户和权限范围，同时更新最后使用时间
+func (s *AccessTokenService) Authenticate(ctx context.Context, plain string) (*Identity, error) {
+	token, err := s.tokenDao.FindByHash(ctx, auth.HashAccessToken(plain))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTokenInvalid
+		}
+		return nil, err
+	}
+	now := time.Now()
+	if !token.Active(now) {
+		return nil, ErrTokenExpired
+	}
+
+	customer, err := s.customerDao.FindByID(ctx, token.CustomerID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTokenInvalid
+		}
+		return nil, err
+	}
+	if customer.Status != "active" {
+		return nil, ErrCustomerInactive
+	}
+
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= lastUsedInterval {
+		if err := s.tokenDao.TouchLastUsed(ctx, token.ID, now); err != nil {
+			logger.GetLogger().Warn(fmt.Sprintf("更新访问令牌 %d 最后使用时间失败: %v", token.ID, err))
+		}
+	}
+
+	return &Identity{Customer: customer, Token: token, Scopes: token.ScopeList()}, nil
+}
+
+// normalizeScopes 校验并去重权限范围
+func normalizeScopes(scopes []string) ([]string, error) {
+	seen := make(map[string]bool, len(scopes))
+	result := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		if !auth.IsValidScope(scope) {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidScope, scope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			result = append(result, scope)
+		}
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("%w: 至少需要一个权限范围", ErrInvalidScope)
+	}
+	return result, nil
+}
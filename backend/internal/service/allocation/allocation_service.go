@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/YoungBoyGod/remotegpu/config"
+	"github.com/YoungBoyGod/remotegpu/internal/agent"
 	"github.com/YoungBoyGod/remotegpu/internal/dao"
 	"github.com/YoungBoyGod/remotegpu/internal/middleware"
 	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
@@ -25,7 +26,7 @@ import (
 type AgentClient interface {
 	ResetSSH(ctx context.Context, hostID string) error
 	CleanupMachine(ctx context.Context, hostID string) error
-	SyncSSHKeys(ctx context.Context, hostID string, publicKeys []string, username string) error
+	SyncSSHKeys(ctx context.Context, hostID string, publicKeys []string, tenants []agent.TenantAccount, username string) error
 }
 
 const (
@@ -41,28 +42,32 @@ const (
 )
 
 type machineActionPayload struct {
-	Action     string   `json:"action"`
-	HostID     string   `json:"host_id"`
-	PublicKeys []string `json:"public_keys,omitempty"` // sync_keys 时携带公钥列表
-	Username   string   `json:"username,omitempty"`    // sync_keys 时携带用户名
+	Action     string                `json:"action"`
+	HostID     string                `json:"host_id"`
+	PublicKeys []string              `json:"public_keys,omitempty"` // sync_keys 时携带整机分配客户的公钥
+	Tenants    []agent.TenantAccount `json:"tenants,omitempty"`     // sync_keys 时携带 GPU 粒度分配客户的独立账号
+	Username   string                `json:"username,omitempty"`    // sync_keys 时携带用户名
 }
 
 type AllocationService struct {
-	db            *gorm.DB
-	allocationDao *dao.AllocationDao
-	machineDao    *dao.MachineDao
-	sshKeyDao     *dao.SSHKeyDao
-	customerDao   *dao.CustomerDao
-	auditService  *audit.AuditService
-	agentClient   AgentClient
-	redisClient   *redis.Client
-	actionRetries int
-	actionDelay   time.Duration
+	db             *gorm.DB
+	allocationDao  *dao.AllocationDao
+	renewalDao     *dao.AllocationRenewalDao
+	machineDao     *dao.MachineDao
+	sshKeyDao      *dao.SSHKeyDao
+	customerDao    *dao.CustomerDao
+	auditService   *audit.AuditService
+	agentClient    AgentClient
+	redisClient    *redis.Client
+	actionRetries  int
+	actionDelay    time.Duration
+	maxRenewMonths int
 }
 
 func NewAllocationService(db *gorm.DB, auditSvc *audit.AuditService, agentClient AgentClient) *AllocationService {
 	actionRetries := 3
 	actionDelay := 10 * time.Second
+	maxRenewMonths := 12
 	if config.GlobalConfig != nil {
 		if config.GlobalConfig.MachineAction.MaxRetries >= 0 {
 			actionRetries = config.GlobalConfig.MachineAction.MaxRetries
@@ -70,18 +75,23 @@ func NewAllocationService(db *gorm.DB, auditSvc *audit.AuditService, agentClient
 		if config.GlobalConfig.MachineAction.RetryDelay > 0 {
 			actionDelay = time.Duration(config.GlobalConfig.MachineAction.RetryDelay) * time.Second
 		}
+		if config.GlobalConfig.AllocationExpiry.MaxRenewMonths > 0 {
+			maxRenewMonths = config.GlobalConfig.AllocationExpiry.MaxRenewMonths
+		}
 	}
 	return &AllocationService{
-		db:            db,
-		allocationDao: dao.NewAllocationDao(db),
-		machineDao:    dao.NewMachineDao(db),
-		sshKeyDao:     dao.NewSSHKeyDao(db),
-		customerDao:   dao.NewCustomerDao(db),
-		auditService:  auditSvc,
-		agentClient:   agentClient,
-		redisClient:   cache.GetRedis(),
-		actionRetries: actionRetries,
-		actionDelay:   actionDelay,
+		db:             db,
+		allocationDao:  dao.NewAllocationDao(db),
+		renewalDao:     dao.NewAllocationRenewalDao(db),
+		machineDao:     dao.NewMachineDao(db),
+		sshKeyDao:      dao.NewSSHKeyDao(db),
+		customerDao:    dao.NewCustomerDao(db),
+		auditService:   auditSvc,
+		agentClient:    agentClient,
+		redisClient:    cache.GetRedis(),
+		actionRetries:  actionRetries,
+		actionDelay:    actionDelay,
+		maxRenewMonths: maxRenewMonths,
 	}
 }
 
@@ -171,22 +181,12 @@ func (s *AllocationService) executeAction(ctx context.Context, payload machineAc
 	case machineActionCleanup:
 		return s.agentClient.CleanupMachine(ctx, payload.HostID)
 	case machineActionSyncKeys:
-		return s.agentClient.SyncSSHKeys(ctx, payload.HostID, payload.PublicKeys, payload.Username)
+		return s.agentClient.SyncSSHKeys(ctx, payload.HostID, payload.PublicKeys, payload.Tenants, payload.Username)
 	default:
 		return fmt.Errorf("unknown machine action: %s", payload.Action)
 	}
 }
 
-// EnqueueSyncKeys 入队 SSH 密钥同步操作（供 SSHKeyService 调用）
-func (s *AllocationService) EnqueueSyncKeys(ctx context.Context, hostID string, publicKeys []string, username string) error {
-	return s.enqueueAction(ctx, machineActionPayload{
-		Action:     machineActionSyncKeys,
-		HostID:     hostID,
-		PublicKeys: publicKeys,
-		Username:   username,
-	})
-}
-
 func (s *AllocationService) handleActionFailure(ctx context.Context, payload machineActionPayload, err error) {
 	if s.redisClient == nil {
 		logger.GetLogger().Warn(fmt.Sprintf("Machine action failed: %v", err))
@@ -248,13 +248,37 @@ func (s *AllocationService) AllocateMachine(ctx context.Context, customerID uint
 		return nil, errors.New(errors.ErrorInvalidParams, "lease duration must be at least 1 month")
 	}
 
+	startTime := time.Now()
+	return s.allocate(ctx, customerID, hostID, nil, startTime, startTime.AddDate(0, durationMonths, 0), remark, "",
+		map[string]interface{}{"duration_months": durationMonths})
+}
+
+// ErrReservationNotPending 激活预约时预约已不是 pending 状态（如已被取消）
+var ErrReservationNotPending = stderrors.New("reservation is no longer pending")
+
+// AllocateReservedMachine 预约时间窗口开始时分配预约锁定的机器，租期到预约结束时间为止
+// 在同一事务内确认预约仍为 pending 并记录机器的分配，预约已被取消时返回 ErrReservationNotPending
+func (s *AllocationService) AllocateReservedMachine(ctx context.Context, reservationID string, customerID uint, hostID string, endTime time.Time, remark string) (*entity.Allocation, error) {
+	return s.allocate(ctx, customerID, hostID, nil, time.Now(), endTime, remark, reservationID,
+		map[string]interface{}{"reservation_id": reservationID, "end_time": endTime})
+}
+
+// allocate 分配机器给客户，sel 为空时整机分配，否则只分配选中的 GPU；reservationID 为激活的预约（该预约锁定的机器不视为冲突）
+func (s *AllocationService) allocate(ctx context.Context, customerID uint, hostID string, sel *gpuSelector, startTime, endTime time.Time, remark, reservationID string, detail map[string]interface{}) (*entity.Allocation, error) {
 	// 配额校验：检查客户 GPU 配额是否允许新增分配
-	if err := s.checkGPUQuota(ctx, customerID, hostID); err != nil {
+	if sel == nil {
+		if err := s.checkGPUQuota(ctx, customerID, hostID); err != nil {
+			return nil, err
+		}
+	} else if err := s.checkGPUCount(ctx, customerID, int64(sel.count())); err != nil {
 		return nil, err
 	}
 
 	// 使用事务确保原子性
-	var allocation *entity.Allocation
+	var (
+		allocation *entity.Allocation
+		prevStatus string
+	)
 
 	err := s.db.Transaction(func(tx *gorm.DB) error {
 		machineDao := dao.NewMachineDao(tx)
@@ -271,30 +295,81 @@ func (s *AllocationService) AllocateMachine(ctx context.Context, customerID uint
 			}
 			return errors.Wrap(errors.ErrorDatabase, err)
 		}
-		if host.AllocationStatus != "idle" {
+		// 激活预约时锁定预约并确认未被取消，与取消预约互斥
+		reservationDao := dao.NewReservationDao(tx)
+		if reservationID != "" {
+			r, err := reservationDao.LockByID(ctx, reservationID)
+			if err != nil {
+				return errors.Wrap(errors.ErrorDatabase, err)
+			}
+			if r.Status != entity.ReservationStatusPending {
+				return ErrReservationNotPending
+			}
+		}
+		// 租期内机器已被其他预约锁定
+		held, err := reservationDao.HeldHostIDs(ctx, []string{hostID}, startTime, endTime, reservationID)
+		if err != nil {
+			return errors.Wrap(errors.ErrorDatabase, err)
+		}
+		if len(held) > 0 {
+			return errors.New(errors.ErrorMachineNotAvailable, "machine is reserved by another reservation during the requested lease")
+		}
+		// 整机分配要求机器空闲，GPU 粒度分配允许机器上已有其他 GPU 分配
+		prevStatus = host.AllocationStatus
+		if host.AllocationStatus != "idle" && (sel == nil || host.AllocationStatus != "partial") {
 			return errors.New(errors.ErrorMachineNotAvailable, "machine is not available for allocation, current allocation_status: "+host.AllocationStatus)
 		}
 
-		// 2. 更新机器分配状态
-		if err := machineDao.UpdateAllocationStatus(ctx, hostID, "allocated"); err != nil {
+		// 2. 选择要绑定的 GPU（机器行锁保证 GPU 不会被并发分配）
+		gpus, err := machineDao.ListGPUsByHostID(ctx, hostID)
+		if err != nil {
 			return errors.Wrap(errors.ErrorDatabase, err)
 		}
+		chosen := gpus
+		if sel != nil {
+			if chosen, err = sel.pick(gpus); err != nil {
+				return err
+			}
+		}
 
-		// 3. 创建分配记录
-		startTime := time.Now()
-		endTime := startTime.AddDate(0, durationMonths, 0)
+		// 3. 创建分配记录并绑定 GPU
 		allocation = &entity.Allocation{
 			ID:         "alloc-" + uuid.New().String(),
 			CustomerID: customerID,
 			HostID:     hostID,
+			GPUCount:   len(chosen),
 			StartTime:  startTime,
 			EndTime:    endTime,
 			Status:     "active",
 			Remark:     remark,
 		}
+		if sel != nil {
+			allocation.GPUIndexes = encodeGPUIndexes(chosen)
+		}
 		if err := allocationDao.Create(ctx, allocation); err != nil {
 			return errors.Wrap(errors.ErrorDatabase, err)
 		}
+		ids := make([]uint, 0, len(chosen))
+		for _, g := range chosen {
+			ids = append(ids, g.ID)
+		}
+		if err := machineDao.AssignGPUs(ctx, ids, allocation.ID); err != nil {
+			return errors.Wrap(errors.ErrorDatabase, err)
+		}
+		if reservationID != "" {
+			if err := reservationDao.SetHostAllocation(ctx, reservationID, hostID, allocation.ID); err != nil {
+				return errors.Wrap(errors.ErrorDatabase, err)
+			}
+		}
+
+		// 4. 更新机器分配状态
+		status, err := allocationDao.HostAllocationStatus(ctx, hostID)
+		if err != nil {
+			return errors.Wrap(errors.ErrorDatabase, err)
+		}
+		if err := machineDao.UpdateAllocationStatus(ctx, hostID, status); err != nil {
+			return errors.Wrap(errors.ErrorDatabase, err)
+		}
 
 		return nil
 	})
@@ -304,24 +379,25 @@ func (s *AllocationService) AllocateMachine(ctx context.Context, customerID uint
 	}
 
 	// 记录审计日志
+	detail["allocation_id"] = allocation.ID
+	detail["customer_id"] = customerID
+	if len(allocation.GPUIndexes) > 0 {
+		detail["gpu_indexes"] = decodeGPUIndexes(allocation.GPUIndexes)
+	}
 	_ = s.auditService.CreateLog(
 		ctx,
 		&customerID,
 		"system", "127.0.0.1", "POST", fmt.Sprintf("/admin/machines/%s/allocate", hostID),
 		"allocate_machine", "machine", hostID,
-		map[string]interface{}{
-			"allocation_id":   allocation.ID,
-			"customer_id":     customerID,
-			"duration_months": durationMonths,
-		},
+		detail,
 		200,
 	)
 
 	// 记录 Prometheus 指标
 	middleware.MachineAllocationsTotal.Inc()
 
-	// 异步触发 Agent 重置 SSH
-	if s.agentClient != nil {
+	// 异步触发 Agent 重置 SSH（机器上已有其他客户的 GPU 分配时不重置）
+	if s.agentClient != nil && prevStatus == "idle" {
 		enqueueCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		if err := s.enqueueAction(enqueueCtx, machineActionPayload{Action: machineActionResetSSH, HostID: hostID}); err != nil {
 			logger.GetLogger().Warn(fmt.Sprintf("Failed to enqueue reset ssh: %v", err))
@@ -330,7 +406,7 @@ func (s *AllocationService) AllocateMachine(ctx context.Context, customerID uint
 	}
 
 	// 异步注入客户 SSH 公钥到新分配的机器
-	go s.syncCustomerKeysToHost(context.Background(), customerID, hostID)
+	go s.syncHostKeysAsync(hostID)
 
 	return allocation, nil
 }
@@ -340,44 +416,106 @@ func (s *AllocationService) AllocateMachine(ctx context.Context, customerID uint
 // @description 回收已分配的机器，更新分配状态和机器状态，并记录审计日志
 // @reason 修复原实现中审计代码不可达的bug
 // @modified 2026-02-04
+// 机器上有多个 GPU 粒度分配时全部回收
 func (s *AllocationService) ReclaimMachine(ctx context.Context, hostID string) error {
-	var allocID string
+	allocs, err := s.allocationDao.FindAllActiveByHostID(ctx, hostID)
+	if err != nil {
+		return errors.Wrap(errors.ErrorDatabase, err)
+	}
+	if len(allocs) == 0 {
+		return errors.New(errors.ErrorAllocationNotFound, "no active allocation found for this host")
+	}
+	for i := range allocs {
+		if _, err := s.reclaim(ctx, allocs[i].ID, "reclaimed", "admin_request", nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReclaimAllocation 回收单个分配，GPU 粒度分配只释放该分配绑定的 GPU
+func (s *AllocationService) ReclaimAllocation(ctx context.Context, allocationID string) error {
+	_, err := s.reclaim(ctx, allocationID, "reclaimed", "admin_request", nil)
+	return err
+}
+
+// ReclaimExpired 回收已到期的分配，分配在此期间被续期时返回 ErrAllocationRenewed
+func (s *AllocationService) ReclaimExpired(ctx context.Context, alloc *entity.Allocation, now time.Time) error {
+	_, err := s.reclaim(ctx, alloc.ID, "expired", "lease_expired", &now)
+	return err
+}
 
-	// 0. 检查是否有运行中的任务（修复 P0 问题）
+// reclaim 结束活跃分配并释放绑定的 GPU，机器上没有其他分配时置为空闲并异步触发清理，
+// 否则重新同步机器上的 SSH 公钥以移除该客户的访问权限
+// expiredAt 非空时仅回收在该时间前到期的分配（到期回收与续期并发时以续期为准）
+func (s *AllocationService) reclaim(ctx context.Context, allocationID, status, reason string, expiredAt *time.Time) (*entity.Allocation, error) {
+	var alloc entity.Allocation
+	if err := s.db.WithContext(ctx).Where("id = ?", allocationID).First(&alloc).Error; err != nil {
+		if stderrors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New(errors.ErrorAllocationNotFound, "allocation not found")
+		}
+		return nil, errors.Wrap(errors.ErrorDatabase, err)
+	}
+
+	// 0. 检查是否有运行中的任务（修复 P0 问题），GPU 粒度分配只检查该客户的任务
 	taskDao := dao.NewTaskDao(s.db)
-	runningCount, err := taskDao.CountRunningTasksByMachineID(ctx, hostID)
+	var (
+		runningCount int64
+		err          error
+	)
+	if len(alloc.GPUIndexes) == 0 {
+		runningCount, err = taskDao.CountRunningTasksByMachineID(ctx, alloc.HostID)
+	} else {
+		runningCount, err = taskDao.CountRunningTasksByMachineAndCustomer(ctx, alloc.HostID, alloc.CustomerID)
+	}
 	if err != nil {
-		return errors.Wrap(errors.ErrorDatabase, err)
+		return nil, errors.Wrap(errors.ErrorDatabase, err)
 	}
 	if runningCount > 0 {
-		return errors.New(errors.ErrorMachineHasRunningTasks,
+		return nil, errors.New(errors.ErrorMachineHasRunningTasks,
 			fmt.Sprintf("cannot reclaim machine: %d task(s) still running or assigned", runningCount))
 	}
 
+	hostStatus := ""
 	err = s.db.Transaction(func(tx *gorm.DB) error {
 		machineDao := dao.NewMachineDao(tx)
 		allocationDao := dao.NewAllocationDao(tx)
 
-		// 1. 查找活跃分配
-		alloc, err := allocationDao.FindActiveByHostID(ctx, hostID)
+		// 1. 分配加行级锁，避免与续期并发；再锁定机器，避免与同机器上的其他分配/回收并发
+		err := tx.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", allocationID).First(&alloc).Error
 		if err != nil {
-			if stderrors.Is(err, gorm.ErrRecordNotFound) {
-				return errors.New(errors.ErrorAllocationNotFound, "no active allocation found for this host")
-			}
 			return errors.Wrap(errors.ErrorDatabase, err)
 		}
-		allocID = alloc.ID
+		if alloc.Status != "active" {
+			return errors.New(errors.ErrorAllocationNotFound, "no active allocation found for this host")
+		}
+		if expiredAt != nil && alloc.EndTime.After(*expiredAt) {
+			return ErrAllocationRenewed
+		}
+		if err := tx.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id").Where("id = ?", alloc.HostID).First(&entity.Host{}).Error; err != nil {
+			return errors.Wrap(errors.ErrorDatabase, err)
+		}
 
 		// 2. 更新分配状态
 		now := time.Now()
-		alloc.Status = "reclaimed"
-		alloc.ActualEndTime = &now
-		if err := tx.Save(alloc).Error; err != nil {
+		if err := tx.WithContext(ctx).Model(&alloc).Updates(map[string]interface{}{
+			"status":          status,
+			"actual_end_time": now,
+		}).Error; err != nil {
 			return errors.Wrap(errors.ErrorDatabase, err)
 		}
 
-		// 3. 更新机器分配状态为空闲
-		if err := machineDao.UpdateAllocationStatus(ctx, hostID, "idle"); err != nil {
+		// 3. 释放 GPU 并按剩余分配更新机器状态
+		if err := machineDao.ReleaseGPUs(ctx, alloc.ID); err != nil {
+			return errors.Wrap(errors.ErrorDatabase, err)
+		}
+		hostStatus, err = allocationDao.HostAllocationStatus(ctx, alloc.HostID)
+		if err != nil {
+			return errors.Wrap(errors.ErrorDatabase, err)
+		}
+		if err := machineDao.UpdateAllocationStatus(ctx, alloc.HostID, hostStatus); err != nil {
 			return errors.Wrap(errors.ErrorDatabase, err)
 		}
 
@@ -385,32 +523,40 @@ func (s *AllocationService) ReclaimMachine(ctx context.Context, hostID string) e
 	})
 
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// 4. 记录审计日志
+	detail := map[string]interface{}{"allocation_id": alloc.ID, "customer_id": alloc.CustomerID, "reason": reason}
+	if len(alloc.GPUIndexes) > 0 {
+		detail["gpu_indexes"] = decodeGPUIndexes(alloc.GPUIndexes)
+	}
 	_ = s.auditService.CreateLog(
 		ctx,
 		nil, // System action, no customer ID
-		"system", "127.0.0.1", "POST", fmt.Sprintf("/admin/machines/%s/reclaim", hostID),
-		"reclaim_machine", "machine", hostID,
-		map[string]interface{}{"allocation_id": allocID, "reason": "admin_request"},
+		"system", "127.0.0.1", "POST", fmt.Sprintf("/admin/machines/%s/reclaim", alloc.HostID),
+		"reclaim_machine", "machine", alloc.HostID,
+		detail,
 		200,
 	)
 
 	// 记录 Prometheus 指标
 	middleware.MachineReclamationsTotal.Inc()
 
-	// 5. 异步触发清理流程（重置SSH、清理用户数据等）
+	// 5. 机器空闲时异步触发清理流程（重置SSH、清理用户数据等），仍有其他分配时只移除该客户的公钥
 	if s.agentClient != nil {
-		enqueueCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		if err := s.enqueueAction(enqueueCtx, machineActionPayload{Action: machineActionCleanup, HostID: hostID}); err != nil {
-			logger.GetLogger().Warn(fmt.Sprintf("Failed to enqueue cleanup: %v", err))
+		if hostStatus == "idle" {
+			enqueueCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			if err := s.enqueueAction(enqueueCtx, machineActionPayload{Action: machineActionCleanup, HostID: alloc.HostID}); err != nil {
+				logger.GetLogger().Warn(fmt.Sprintf("Failed to enqueue cleanup: %v", err))
+			}
+			cancel()
+		} else {
+			go s.syncHostKeysAsync(alloc.HostID)
 		}
-		cancel()
 	}
 
-	return nil
+	return &alloc, nil
 }
 
 func (s *AllocationService) GetRecent(ctx context.Context) ([]entity.Allocation, error) {
@@ -491,33 +637,19 @@ func (s *AllocationService) BatchReclaim(ctx context.Context, hostIDs []string)
 	return result, nil
 }
 
-// syncCustomerKeysToHost 将客户的所有 SSH 公钥同步到指定机器
-func (s *AllocationService) syncCustomerKeysToHost(ctx context.Context, customerID uint, hostID string) {
-	if s.sshKeyDao == nil {
-		return
-	}
-
-	keys, err := s.sshKeyDao.ListByCustomerID(ctx, customerID)
-	if err != nil {
-		logger.GetLogger().Warn(fmt.Sprintf("获取客户 SSH 密钥失败: %v", err))
-		return
-	}
-	if len(keys) == 0 {
-		return
-	}
-
-	publicKeys := make([]string, 0, len(keys))
-	for _, k := range keys {
-		publicKeys = append(publicKeys, k.PublicKey)
-	}
-
-	if err := s.EnqueueSyncKeys(ctx, hostID, publicKeys, ""); err != nil {
-		logger.GetLogger().Warn(fmt.Sprintf("入队 SSH 密钥同步失败: %v", err))
+// checkGPUQuota 检查客户 GPU 配额是否允许整机分配指定机器
+func (s *AllocationService) checkGPUQuota(ctx context.Context, customerID uint, hostID string) error {
+	// 统计该机器上的 GPU 数量
+	var gpuCount int64
+	if err := s.db.WithContext(ctx).Model(&entity.GPU{}).
+		Where("host_id = ?", hostID).Count(&gpuCount).Error; err != nil {
+		return errors.Wrap(errors.ErrorDatabase, err)
 	}
+	return s.checkGPUCount(ctx, customerID, gpuCount)
 }
 
-// checkGPUQuota 检查客户 GPU 配额是否允许新增分配
-func (s *AllocationService) checkGPUQuota(ctx context.Context, customerID uint, hostID string) error {
+// checkGPUCount 检查客户 GPU 配额是否允许新增 gpuCount 张卡
+func (s *AllocationService) checkGPUCount(ctx context.Context, customerID uint, gpuCount int64) error {
 	customer, err := s.customerDao.FindByID(ctx, customerID)
 	if err != nil {
 		return errors.Wrap(errors.ErrorDatabase, err)
@@ -528,13 +660,6 @@ func (s *AllocationService) checkGPUQuota(ctx context.Context, customerID uint,
 		return nil
 	}
 
-	// 统计该机器上的 GPU 数量
-	var gpuCount int64
-	if err := s.db.WithContext(ctx).Model(&entity.GPU{}).
-		Where("host_id = ?", hostID).Count(&gpuCount).Error; err != nil {
-		return errors.Wrap(errors.ErrorDatabase, err)
-	}
-
 	// 统计客户已分配的 GPU 数量
 	currentGPUs, err := s.allocationDao.CountGPUsByCustomerID(ctx, customerID)
 	if err != nil {
@@ -88,9 +88,12 @@ func setupAllocationTestDB(t *testing.T) *gorm.DB {
 		customer_id INTEGER NOT NULL,
 		host_id VARCHAR(64) NOT NULL,
 		workspace_id INTEGER,
+		gpu_indexes TEXT,
+		gpu_count INTEGER DEFAULT 0,
 		start_time DATETIME NOT NULL,
 		end_time DATETIME NOT NULL,
 		actual_end_time DATETIME,
+		expiry_warned_at DATETIME,
 		status VARCHAR(32) DEFAULT 'active',
 		remark TEXT,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
@@ -133,6 +136,46 @@ func setupAllocationTestDB(t *testing.T) *gorm.DB {
 	)`).Error
 	require.NoError(t, err)
 
+	err = db.Exec(`CREATE TABLE allocation_renewals (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		allocation_id VARCHAR(64) NOT NULL,
+		customer_id INTEGER NOT NULL,
+		operator_id INTEGER,
+		operator_role VARCHAR(20) NOT NULL,
+		months INTEGER NOT NULL,
+		old_end_time DATETIME NOT NULL,
+		new_end_time DATETIME NOT NULL,
+		remark TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`).Error
+	require.NoError(t, err)
+
+	err = db.Exec(`CREATE TABLE reservations (
+		id VARCHAR(64) PRIMARY KEY,
+		customer_id INTEGER NOT NULL,
+		gpu_model VARCHAR(128) NOT NULL,
+		gpu_count INTEGER NOT NULL,
+		start_time DATETIME NOT NULL,
+		end_time DATETIME NOT NULL,
+		status VARCHAR(20) NOT NULL DEFAULT 'queued',
+		remark TEXT,
+		reason TEXT,
+		activated_at DATETIME,
+		cancelled_at DATETIME,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`).Error
+	require.NoError(t, err)
+
+	err = db.Exec(`CREATE TABLE reservation_hosts (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		reservation_id VARCHAR(64) NOT NULL,
+		host_id VARCHAR(64) NOT NULL,
+		gpu_count INTEGER NOT NULL,
+		allocation_id VARCHAR(64)
+	)`).Error
+	require.NoError(t, err)
+
 	err = db.Exec(`CREATE TABLE audit_logs (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		customer_id INTEGER,
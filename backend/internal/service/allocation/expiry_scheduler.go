@@ -0,0 +1,178 @@
+package allocation
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"time"
+
+	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+	"github.com/YoungBoyGod/remotegpu/pkg/errors"
+	"github.com/YoungBoyGod/remotegpu/pkg/logger"
+	"github.com/YoungBoyGod/remotegpu/pkg/mail"
+)
+
+// expiryBatchSize 单轮最多处理的分配数
+const expiryBatchSize = 100
+
+// ExpiryNotifier 租约到期通知（由 NotificationService 实现）
+type ExpiryNotifier interface {
+	PushAllocationExpiring(ctx context.Context, customerID uint, machine string, endTime time.Time) error
+	PushAllocationExpired(ctx context.Context, customerID uint, machine string) error
+}
+
+// ExpiryScheduler 机器分配到期处理
+// 定期扫描活跃分配：到期前 warnBefore 内提醒客户（站内通知 + 邮件，每个租期只提醒一次），
+// 已到期的分配自动回收并通过机器操作队列清理；机器上仍有运行中的任务时跳过，下一轮重试
+type ExpiryScheduler struct {
+	allocationService *AllocationService
+	notifier          ExpiryNotifier
+	mailer            mail.Sender
+	interval          time.Duration
+	warnBefore        time.Duration
+	now               func() time.Time
+}
+
+// NewExpiryScheduler 创建到期处理器，notifier 可为 nil
+func NewExpiryScheduler(allocSvc *AllocationService, notifier ExpiryNotifier, interval time.Duration, warnDays int) *ExpiryScheduler {
+	if interval <= 0 {
+		interval = 5 * time.Minute
+	}
+	if warnDays <= 0 {
+		warnDays = 7
+	}
+	return &ExpiryScheduler{
+		allocationService: allocSvc,
+		notifier:          notifier,
+		interval:          interval,
+		warnBefore:        time.Duration(warnDays) * 24 * time.Hour,
+		now:               time.Now,
+	}
+}
+
+// SetMailSender 注入邮件发送器，未注入时只发送站内通知
+func (s *ExpiryScheduler) SetMailSender(m mail.Sender) {
+	s.mailer = m
+}
+
+// Start 启动到期处理
+func (s *ExpiryScheduler) Start(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	logger.GetLogger().Info("机器分配到期处理服务已启动")
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.GetLogger().Info("机器分配到期处理服务已停止")
+			return
+		case <-ticker.C:
+			s.warn(ctx)
+			s.reclaim(ctx)
+		}
+	}
+}
+
+// warn 提醒即将到期的分配，返回提醒数
+func (s *ExpiryScheduler) warn(ctx context.Context) int {
+	now := s.now()
+	allocs, err := s.allocationService.allocationDao.ListExpiring(ctx, now, now.Add(s.warnBefore), expiryBatchSize)
+	if err != nil {
+		logger.GetLogger().Warn(fmt.Sprintf("查询即将到期分配失败: %v", err))
+		return 0
+	}
+
+	warned := 0
+	for i := range allocs {
+		alloc := &allocs[i]
+		// 先记录再通知，避免通知失败导致每轮重复提醒
+		if err := s.allocationService.allocationDao.MarkExpiryWarned(ctx, alloc.ID, now); err != nil {
+			logger.GetLogger().Warn(fmt.Sprintf("记录分配 %s 到期提醒失败: %v", alloc.ID, err))
+			continue
+		}
+		warned++
+
+		machine := machineLabel(alloc)
+		if s.notifier != nil {
+			if err := s.notifier.PushAllocationExpiring(ctx, alloc.CustomerID, machine, alloc.EndTime); err != nil {
+				logger.GetLogger().Warn(fmt.Sprintf("推送分配 %s 到期提醒失败: %v", alloc.ID, err))
+			}
+		}
+		s.sendMail(ctx, alloc, mail.TemplateAllocationExpiring)
+	}
+	return warned
+}
+
+// reclaim 回收已到期的分配，返回回收数
+func (s *ExpiryScheduler) reclaim(ctx context.Context) int {
+	now := s.now()
+	allocs, err := s.allocationService.allocationDao.ListExpired(ctx, now, expiryBatchSize)
+	if err != nil {
+		logger.GetLogger().Warn(fmt.Sprintf("查询已到期分配失败: %v", err))
+		return 0
+	}
+
+	reclaimed := 0
+	for i := range allocs {
+		alloc := &allocs[i]
+		if err := s.allocationService.ReclaimExpired(ctx, alloc, now); err != nil {
+			if stderrors.Is(err, ErrAllocationRenewed) {
+				continue
+			}
+			if appErr := errors.GetAppError(err); appErr != nil && appErr.Code == errors.ErrorMachineHasRunningTasks {
+				logger.GetLogger().Warn(fmt.Sprintf("分配 %s 已到期，机器 %s 上仍有运行中的任务，暂不回收", alloc.ID, alloc.HostID))
+				continue
+			}
+			logger.GetLogger().Warn(fmt.Sprintf("回收到期分配 %s 失败: %v", alloc.ID, err))
+			continue
+		}
+		reclaimed++
+		logger.GetLogger().Info(fmt.Sprintf("分配 %s 已到期，机器 %s 已回收", alloc.ID, alloc.HostID))
+
+		machine := machineLabel(alloc)
+		if s.notifier != nil {
+			if err := s.notifier.PushAllocationExpired(ctx, alloc.CustomerID, machine); err != nil {
+				logger.GetLogger().Warn(fmt.Sprintf("推送分配 %s 到期回收通知失败: %v", alloc.ID, err))
+			}
+		}
+		s.sendMail(ctx, alloc, mail.TemplateAllocationExpired)
+	}
+	return reclaimed
+}
+
+func (s *ExpiryScheduler) sendMail(ctx context.Context, alloc *entity.Allocation, tmpl string) {
+	if s.mailer == nil || alloc.Customer.Email == "" {
+		return
+	}
+	msg, err := mail.Render(tmpl, mail.AllocationExpiryData{
+		Name:    customerName(alloc),
+		Machine: machineLabel(alloc),
+		EndTime: alloc.EndTime,
+	})
+	if err != nil {
+		logger.GetLogger().Warn(fmt.Sprintf("生成分配 %s 到期邮件失败: %v", alloc.ID, err))
+		return
+	}
+	msg.To = []string{alloc.Customer.Email}
+
+	sendCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	if err := s.mailer.Send(sendCtx, msg); err != nil {
+		logger.GetLogger().Warn(fmt.Sprintf("发送分配 %s 到期邮件失败: %v", alloc.ID, err))
+	}
+}
+
+func machineLabel(alloc *entity.Allocation) string {
+	if alloc.Host.Name != "" {
+		return alloc.Host.Name
+	}
+	return alloc.HostID
+}
+
+func customerName(alloc *entity.Allocation) string {
+	if alloc.Customer.DisplayName != "" {
+		return alloc.Customer.DisplayName
+	}
+	return alloc.Customer.Username
+}
@@ -0,0 +1,202 @@
+package allocation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+	"github.com/YoungBoyGod/remotegpu/pkg/errors"
+	"github.com/YoungBoyGod/remotegpu/pkg/mail"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type fakeExpiryNotifier struct {
+	expiring []string
+	expired  []string
+}
+
+func (n *fakeExpiryNotifier) PushAllocationExpiring(_ context.Context, _ uint, machine string, _ time.Time) error {
+	n.expiring = append(n.expiring, machine)
+	return nil
+}
+
+func (n *fakeExpiryNotifier) PushAllocationExpired(_ context.Context, _ uint, machine string) error {
+	n.expired = append(n.expired, machine)
+	return nil
+}
+
+type fakeMailer struct {
+	sent []*mail.Message
+}
+
+func (m *fakeMailer) Send(_ context.Context, msg *mail.Message) error {
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+// createTestAllocation 创建活跃分配及其机器
+func createTestAllocation(t *testing.T, db *gorm.DB, id, hostID string, endTime time.Time) {
+	require.NoError(t, db.Exec(`INSERT INTO hosts (id, name, ip_address, allocation_status) VALUES (?, ?, '10.0.0.1', 'allocated')`, hostID, "name-"+hostID).Error)
+	require.NoError(t, db.Create(&entity.Allocation{
+		ID:         id,
+		CustomerID: 1,
+		HostID:     hostID,
+		StartTime:  endTime.AddDate(0, -1, 0),
+		EndTime:    endTime,
+		Status:     "active",
+	}).Error)
+}
+
+func newTestExpiryScheduler(svc *AllocationService, now time.Time) (*ExpiryScheduler, *fakeExpiryNotifier, *fakeMailer) {
+	notifier := &fakeExpiryNotifier{}
+	mailer := &fakeMailer{}
+	s := NewExpiryScheduler(svc, notifier, time.Minute, 7)
+	s.SetMailSender(mailer)
+	s.now = func() time.Time { return now }
+	return s, notifier, mailer
+}
+
+func TestExpiryScheduler_Warn(t *testing.T) {
+	db := setupAllocationTestDB(t)
+	svc := newTestAllocationService(t, db)
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	db.Exec(`INSERT INTO customers (username, email) VALUES ('u1', 'u1@test.com')`)
+	createTestAllocation(t, db, "a1", "h1", now.Add(3*24*time.Hour))
+	createTestAllocation(t, db, "a2", "h2", now.Add(30*24*time.Hour))
+
+	s, notifier, mailer := newTestExpiryScheduler(svc, now)
+	assert.Equal(t, 1, s.warn(ctx))
+	assert.Equal(t, []string{"name-h1"}, notifier.expiring)
+	require.Len(t, mailer.sent, 1)
+	assert.Equal(t, []string{"u1@test.com"}, mailer.sent[0].To)
+	assert.Contains(t, mailer.sent[0].Body, "name-h1")
+
+	var alloc entity.Allocation
+	require.NoError(t, db.First(&alloc, "id = ?", "a1").Error)
+	assert.NotNil(t, alloc.ExpiryWarnedAt)
+
+	// 同一租期只提醒一次
+	assert.Equal(t, 0, s.warn(ctx))
+	assert.Len(t, mailer.sent, 1)
+}
+
+func TestExpiryScheduler_Reclaim(t *testing.T) {
+	db := setupAllocationTestDB(t)
+	svc := newTestAllocationService(t, db)
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	db.Exec(`INSERT INTO customers (username, email) VALUES ('u1', 'u1@test.com')`)
+	createTestAllocation(t, db, "a1", "h1", now.Add(-time.Hour))
+	createTestAllocation(t, db, "a2", "h2", now.Add(-time.Hour))
+	createTestAllocation(t, db, "a3", "h3", now.Add(time.Hour))
+	// h2 上仍有运行中的任务，暂不回收
+	db.Exec(`INSERT INTO tasks (customer_id, machine_id, status) VALUES (1, 'h2', 'running')`)
+
+	s, notifier, mailer := newTestExpiryScheduler(svc, now)
+	assert.Equal(t, 1, s.reclaim(ctx))
+	assert.Equal(t, []string{"name-h1"}, notifier.expired)
+	assert.Len(t, mailer.sent, 1)
+
+	var alloc entity.Allocation
+	require.NoError(t, db.First(&alloc, "id = ?", "a1").Error)
+	assert.Equal(t, "expired", alloc.Status)
+	assert.NotNil(t, alloc.ActualEndTime)
+
+	var host entity.Host
+	require.NoError(t, db.First(&host, "id = ?", "h1").Error)
+	assert.Equal(t, "idle", host.AllocationStatus)
+
+	var busy, pending entity.Allocation
+	require.NoError(t, db.First(&busy, "id = ?", "a2").Error)
+	assert.Equal(t, "active", busy.Status)
+	require.NoError(t, db.First(&pending, "id = ?", "a3").Error)
+	assert.Equal(t, "active", pending.Status)
+
+	// 任务结束后下一轮回收
+	db.Exec(`UPDATE tasks SET status = 'completed' WHERE machine_id = 'h2'`)
+	assert.Equal(t, 1, s.reclaim(ctx))
+}
+
+func TestReclaimExpired_Renewed(t *testing.T) {
+	db := setupAllocationTestDB(t)
+	svc := newTestAllocationService(t, db)
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	db.Exec(`INSERT INTO customers (username, email) VALUES ('u1', 'u1@test.com')`)
+	createTestAllocation(t, db, "a1", "h1", now.Add(-time.Hour))
+	var alloc entity.Allocation
+	require.NoError(t, db.First(&alloc, "id = ?", "a1").Error)
+
+	// 扫描后、回收前被续期
+	_, err := svc.RenewAllocation(ctx, "a1", 1, "", RenewOperator{ID: 9, Username: "admin", Role: RenewRoleAdmin})
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, svc.ReclaimExpired(ctx, &alloc, now), ErrAllocationRenewed)
+	var stored entity.Allocation
+	require.NoError(t, db.First(&stored, "id = ?", "a1").Error)
+	assert.Equal(t, "active", stored.Status)
+}
+
+func TestRenewAllocation(t *testing.T) {
+	db := setupAllocationTestDB(t)
+	svc := newTestAllocationService(t, db)
+	ctx := context.Background()
+	end := time.Now().UTC().Add(2 * 24 * time.Hour)
+
+	db.Exec(`INSERT INTO customers (username, email) VALUES ('u1', 'u1@test.com')`)
+	createTestAllocation(t, db, "a1", "h1", end)
+	require.NoError(t, svc.allocationDao.MarkExpiryWarned(ctx, "a1", time.Now()))
+
+	alloc, err := svc.RenewByHost(ctx, "h1", 1, 2, "续两个月", RenewOperator{ID: 1, Username: "u1", Role: RenewRoleCustomer})
+	require.NoError(t, err)
+	assert.True(t, alloc.EndTime.Equal(end.AddDate(0, 2, 0)))
+
+	var stored entity.Allocation
+	require.NoError(t, db.First(&stored, "id = ?", "a1").Error)
+	assert.True(t, stored.EndTime.Equal(end.AddDate(0, 2, 0)))
+	assert.Nil(t, stored.ExpiryWarnedAt)
+
+	renewals, err := svc.ListRenewals(ctx, "a1")
+	require.NoError(t, err)
+	require.Len(t, renewals, 1)
+	assert.Equal(t, 2, renewals[0].Months)
+	assert.Equal(t, RenewRoleCustomer, renewals[0].OperatorRole)
+	assert.True(t, renewals[0].OldEndTime.Equal(end))
+
+	var auditCount int64
+	db.Table("audit_logs").Where("action = ? AND resource_id = ?", "renew_allocation", "a1").Count(&auditCount)
+	assert.Equal(t, int64(1), auditCount)
+}
+
+func TestRenewAllocation_Rejected(t *testing.T) {
+	db := setupAllocationTestDB(t)
+	svc := newTestAllocationService(t, db)
+	ctx := context.Background()
+	op := RenewOperator{ID: 9, Username: "admin", Role: RenewRoleAdmin}
+
+	db.Exec(`INSERT INTO customers (username, email) VALUES ('u1', 'u1@test.com')`)
+	createTestAllocation(t, db, "a1", "h1", time.Now().UTC().Add(time.Hour))
+
+	_, err := svc.RenewAllocation(ctx, "a1", 0, "", op)
+	assert.Equal(t, errors.ErrorInvalidParams, errors.GetAppError(err).Code)
+	_, err = svc.RenewAllocation(ctx, "a1", svc.maxRenewMonths+1, "", op)
+	assert.Equal(t, errors.ErrorInvalidParams, errors.GetAppError(err).Code)
+	_, err = svc.RenewAllocation(ctx, "missing", 1, "", op)
+	assert.Equal(t, errors.ErrorAllocationNotFound, errors.GetAppError(err).Code)
+
+	// 非本人的机器
+	_, err = svc.RenewByHost(ctx, "h1", 2, 1, "", RenewOperator{ID: 2, Role: RenewRoleCustomer})
+	assert.Equal(t, errors.ErrorForbidden, errors.GetAppError(err).Code)
+
+	// 已回收的分配不能续期
+	require.NoError(t, svc.ReclaimMachine(ctx, "h1"))
+	_, err = svc.RenewAllocation(ctx, "a1", 1, "", op)
+	assert.Equal(t, errors.ErrorInvalidParams, errors.GetAppError(err).Code)
+}
@@ -0,0 +1,186 @@
+package allocation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/YoungBoyGod/remotegpu/internal/agent"
+	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+	"github.com/YoungBoyGod/remotegpu/pkg/errors"
+	"github.com/YoungBoyGod/remotegpu/pkg/logger"
+	"gorm.io/datatypes"
+)
+
+// gpuSelector GPU 粒度分配时选中的 GPU，可按序号或 UUID 指定
+type gpuSelector struct {
+	indexes []int
+	uuids   []string
+}
+
+// count 选中的 GPU 数量（去重后，用于配额预检查）
+func (g *gpuSelector) count() int {
+	seen := make(map[string]bool, len(g.indexes)+len(g.uuids))
+	for _, idx := range g.indexes {
+		seen[fmt.Sprintf("#%d", idx)] = true
+	}
+	for _, u := range g.uuids {
+		seen[u] = true
+	}
+	return len(seen)
+}
+
+// pick 从机器的 GPU 中选出指定的 GPU，GPU 不存在或已被分配时返回错误
+func (g *gpuSelector) pick(gpus []entity.GPU) ([]entity.GPU, error) {
+	byIndex := make(map[int]*entity.GPU, len(gpus))
+	byUUID := make(map[string]*entity.GPU, len(gpus))
+	for i := range gpus {
+		byIndex[gpus[i].Index] = &gpus[i]
+		if gpus[i].UUID != "" {
+			byUUID[gpus[i].UUID] = &gpus[i]
+		}
+	}
+
+	chosen := make(map[uint]*entity.GPU)
+	for _, idx := range g.indexes {
+		gpu, ok := byIndex[idx]
+		if !ok {
+			return nil, errors.New(errors.ErrorInvalidParams, fmt.Sprintf("GPU %d not found on this machine", idx))
+		}
+		chosen[gpu.ID] = gpu
+	}
+	for _, u := range g.uuids {
+		gpu, ok := byUUID[u]
+		if !ok {
+			return nil, errors.New(errors.ErrorInvalidParams, fmt.Sprintf("GPU %s not found on this machine", u))
+		}
+		chosen[gpu.ID] = gpu
+	}
+
+	result := make([]entity.GPU, 0, len(chosen))
+	for _, gpu := range chosen {
+		if gpu.AllocatedTo != "" || gpu.Status != "available" {
+			return nil, errors.New(errors.ErrorMachineNotAvailable,
+				fmt.Sprintf("GPU %d is not available, current status: %s", gpu.Index, gpu.Status))
+		}
+		result = append(result, *gpu)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Index < result[j].Index })
+	return result, nil
+}
+
+// AllocateGPUs 将机器上的部分 GPU 分配给客户（按序号或 UUID 指定），同一台机器可分配给多个客户
+// 客户通过 SSH 登录后只能看到分配的 GPU，任务也只会调度到这些 GPU 上
+func (s *AllocationService) AllocateGPUs(ctx context.Context, customerID uint, hostID string, gpuIndexes []int, gpuUUIDs []string, durationMonths int, remark string) (*entity.Allocation, error) {
+	if durationMonths < 1 {
+		return nil, errors.New(errors.ErrorInvalidParams, "lease duration must be at least 1 month")
+	}
+	if len(gpuIndexes) == 0 && len(gpuUUIDs) == 0 {
+		return nil, errors.New(errors.ErrorInvalidParams, "at least one GPU must be specified")
+	}
+
+	startTime := time.Now()
+	return s.allocate(ctx, customerID, hostID, &gpuSelector{indexes: gpuIndexes, uuids: gpuUUIDs},
+		startTime, startTime.AddDate(0, durationMonths, 0), remark, "",
+		map[string]interface{}{"duration_months": durationMonths})
+}
+
+// TenantUsername GPU 粒度分配客户在机器上的系统账号，客户之间通过独立账号隔离文件和进程
+func TenantUsername(customerID uint) string {
+	return fmt.Sprintf("rg-c%d", customerID)
+}
+
+// HostTenantUsername 客户在机器上只有 GPU 粒度分配时返回其独立账号，整机分配或没有分配时返回空串
+func (s *AllocationService) HostTenantUsername(ctx context.Context, hostID string, customerID uint) (string, error) {
+	allocs, err := s.allocationDao.FindAllActiveByHostAndCustomer(ctx, hostID, customerID)
+	if err != nil || len(allocs) == 0 {
+		return "", err
+	}
+	for _, a := range allocs {
+		if len(decodeGPUIndexes(a.GPUIndexes)) == 0 {
+			return "", nil
+		}
+	}
+	return TenantUsername(customerID), nil
+}
+
+// SyncHostKeys 按机器上的活跃分配全量同步 SSH 公钥（供 SSHKeyService 调用）
+func (s *AllocationService) SyncHostKeys(ctx context.Context, hostID string) error {
+	payload, err := s.hostKeysPayload(ctx, hostID)
+	if err != nil {
+		return err
+	}
+	return s.enqueueAction(ctx, *payload)
+}
+
+// hostKeysPayload 汇总机器上的公钥：整机分配客户的公钥写入共享账号；
+// GPU 粒度分配客户各自使用独立账号（没有公钥时也保留账号和数据），回收后 Agent 删除其账号、进程和数据
+func (s *AllocationService) hostKeysPayload(ctx context.Context, hostID string) (*machineActionPayload, error) {
+	allocs, err := s.allocationDao.FindAllActiveByHostID(ctx, hostID)
+	if err != nil {
+		return nil, err
+	}
+
+	payload := &machineActionPayload{Action: machineActionSyncKeys, HostID: hostID}
+	tenants := make(map[uint]*agent.TenantAccount)
+	var order []uint
+	for i := range allocs {
+		keys, err := s.sshKeyDao.ListByCustomerID(ctx, allocs[i].CustomerID)
+		if err != nil {
+			return nil, err
+		}
+		indexes := decodeGPUIndexes(allocs[i].GPUIndexes)
+		if len(indexes) == 0 {
+			for _, k := range keys {
+				payload.PublicKeys = append(payload.PublicKeys, k.PublicKey)
+			}
+			continue
+		}
+
+		// 同一客户在机器上可能有多个分配，合并到一个账号
+		t, ok := tenants[allocs[i].CustomerID]
+		if !ok {
+			t = &agent.TenantAccount{Username: TenantUsername(allocs[i].CustomerID)}
+			for _, k := range keys {
+				t.PublicKeys = append(t.PublicKeys, k.PublicKey)
+			}
+			tenants[allocs[i].CustomerID] = t
+			order = append(order, allocs[i].CustomerID)
+		}
+		t.GPUIndexes = append(t.GPUIndexes, indexes...)
+	}
+	for _, id := range order {
+		sort.Ints(tenants[id].GPUIndexes)
+		payload.Tenants = append(payload.Tenants, *tenants[id])
+	}
+	return payload, nil
+}
+
+func (s *AllocationService) syncHostKeysAsync(hostID string) {
+	if err := s.SyncHostKeys(context.Background(), hostID); err != nil {
+		logger.GetLogger().Warn(fmt.Sprintf("入队 SSH 密钥同步失败: %v", err))
+	}
+}
+
+func encodeGPUIndexes(gpus []entity.GPU) datatypes.JSON {
+	indexes := make([]int, 0, len(gpus))
+	for _, g := range gpus {
+		indexes = append(indexes, g.Index)
+	}
+	data, _ := json.Marshal(indexes)
+	return data
+}
+
+// decodeGPUIndexes 解析分配绑定的 GPU 序号，整机分配返回 nil
+func decodeGPUIndexes(raw datatypes.JSON) []int {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+	var indexes []int
+	if err := json.Unmarshal(raw, &indexes); err != nil {
+		return nil
+	}
+	return indexes
+}
@@ -0,0 +1,214 @@
+package allocation
+
+import (
+	"context"
+	"testing"
+
+	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+	"github.com/YoungBoyGod/remotegpu/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// seedSharedHost 创建一台 4 卡机器和三个客户
+func seedSharedHost(t *testing.T, db *gorm.DB, quota int) {
+	for _, name := range []string{"u1", "u2", "u3"} {
+		require.NoError(t, db.Exec(`INSERT INTO customers (username, email, quota_gpu) VALUES (?, ?, ?)`, name, name+"@test.com", quota).Error)
+	}
+	require.NoError(t, db.Exec(`INSERT INTO hosts (id, name, ip_address, allocation_status) VALUES ('h1', 'host1', '10.0.0.1', 'idle')`).Error)
+	for i := 0; i < 4; i++ {
+		require.NoError(t, db.Exec(`INSERT INTO gpus (host_id, "index", uuid, name, memory_total_mb) VALUES ('h1', ?, ?, 'A100', 40960)`,
+			i, "GPU-"+string(rune('a'+i))).Error)
+	}
+}
+
+func hostStatus(t *testing.T, db *gorm.DB, hostID string) string {
+	var host entity.Host
+	require.NoError(t, db.First(&host, "id = ?", hostID).Error)
+	return host.AllocationStatus
+}
+
+func gpuOwners(t *testing.T, db *gorm.DB, hostID string) map[int]string {
+	var gpus []entity.GPU
+	require.NoError(t, db.Where("host_id = ?", hostID).Find(&gpus).Error)
+	owners := make(map[int]string, len(gpus))
+	for _, g := range gpus {
+		owners[g.Index] = g.AllocatedTo
+	}
+	return owners
+}
+
+func appErrorCode(err error) int {
+	if appErr := errors.GetAppError(err); appErr != nil {
+		return appErr.Code
+	}
+	return 0
+}
+
+func TestAllocateGPUs_SharesHost(t *testing.T) {
+	db := setupAllocationTestDB(t)
+	svc := newTestAllocationService(t, db)
+	seedSharedHost(t, db, 0)
+	ctx := context.Background()
+
+	a1, err := svc.AllocateGPUs(ctx, 1, "h1", []int{1, 0}, nil, 1, "")
+	require.NoError(t, err)
+	assert.JSONEq(t, `[0,1]`, string(a1.GPUIndexes))
+	assert.Equal(t, 2, a1.GPUCount)
+	assert.Equal(t, "partial", hostStatus(t, db, "h1"))
+
+	a2, err := svc.AllocateGPUs(ctx, 2, "h1", nil, []string{"GPU-c"}, 1, "")
+	require.NoError(t, err)
+	assert.JSONEq(t, `[2]`, string(a2.GPUIndexes))
+
+	owners := gpuOwners(t, db, "h1")
+	assert.Equal(t, a1.ID, owners[0])
+	assert.Equal(t, a1.ID, owners[1])
+	assert.Equal(t, a2.ID, owners[2])
+	assert.Empty(t, owners[3])
+
+	// 已被其他客户占用的 GPU 不能再分配，部分分配的机器不能整机分配
+	_, err = svc.AllocateGPUs(ctx, 3, "h1", []int{1}, nil, 1, "")
+	assert.Equal(t, errors.ErrorMachineNotAvailable, appErrorCode(err))
+	_, err = svc.AllocateMachine(ctx, 3, "h1", 1, "")
+	assert.Equal(t, errors.ErrorMachineNotAvailable, appErrorCode(err))
+	_, err = svc.AllocateGPUs(ctx, 3, "h1", []int{7}, nil, 1, "")
+	assert.Equal(t, errors.ErrorInvalidParams, appErrorCode(err))
+
+	// 最后一张卡分配后机器变为 allocated
+	_, err = svc.AllocateGPUs(ctx, 3, "h1", []int{3}, nil, 1, "")
+	require.NoError(t, err)
+	assert.Equal(t, "allocated", hostStatus(t, db, "h1"))
+}
+
+func TestAllocateGPUs_QuotaCountsCards(t *testing.T) {
+	db := setupAllocationTestDB(t)
+	svc := newTestAllocationService(t, db)
+	seedSharedHost(t, db, 3)
+	ctx := context.Background()
+
+	_, err := svc.AllocateGPUs(ctx, 1, "h1", []int{0, 1}, nil, 1, "")
+	require.NoError(t, err)
+	count, err := svc.allocationDao.CountGPUsByCustomerID(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	_, err = svc.AllocateGPUs(ctx, 1, "h1", []int{2, 3}, nil, 1, "")
+	assert.Equal(t, errors.ErrorQuotaExceeded, appErrorCode(err))
+	_, err = svc.AllocateGPUs(ctx, 1, "h1", []int{2}, nil, 1, "")
+	require.NoError(t, err)
+
+	// 其他客户的配额单独计算
+	_, err = svc.AllocateGPUs(ctx, 2, "h1", []int{3}, nil, 1, "")
+	require.NoError(t, err)
+	count, err = svc.allocationDao.CountGPUsByCustomerID(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestReclaimAllocation_ReleasesOnlyItsGPUs(t *testing.T) {
+	db := setupAllocationTestDB(t)
+	svc := newTestAllocationService(t, db)
+	seedSharedHost(t, db, 0)
+	ctx := context.Background()
+
+	a1, err := svc.AllocateGPUs(ctx, 1, "h1", []int{0, 1}, nil, 1, "")
+	require.NoError(t, err)
+	a2, err := svc.AllocateGPUs(ctx, 2, "h1", []int{2, 3}, nil, 1, "")
+	require.NoError(t, err)
+	assert.Equal(t, "allocated", hostStatus(t, db, "h1"))
+
+	// 客户 2 的任务不影响回收客户 1 的分配
+	require.NoError(t, db.Exec(`INSERT INTO tasks (customer_id, machine_id, status) VALUES (2, 'h1', 'running')`).Error)
+	require.NoError(t, svc.ReclaimAllocation(ctx, a1.ID))
+
+	var reclaimed entity.Allocation
+	require.NoError(t, db.First(&reclaimed, "id = ?", a1.ID).Error)
+	assert.Equal(t, "reclaimed", reclaimed.Status)
+	owners := gpuOwners(t, db, "h1")
+	assert.Empty(t, owners[0])
+	assert.Empty(t, owners[1])
+	assert.Equal(t, a2.ID, owners[2])
+	assert.Equal(t, "partial", hostStatus(t, db, "h1"))
+
+	err = svc.ReclaimAllocation(ctx, a2.ID)
+	assert.Equal(t, errors.ErrorMachineHasRunningTasks, appErrorCode(err))
+
+	require.NoError(t, db.Exec(`UPDATE tasks SET status = 'completed'`).Error)
+	require.NoError(t, svc.ReclaimMachine(ctx, "h1"))
+	assert.Equal(t, "idle", hostStatus(t, db, "h1"))
+	for idx, owner := range gpuOwners(t, db, "h1") {
+		assert.Empty(t, owner, "GPU %d should be released", idx)
+	}
+
+	err = svc.ReclaimAllocation(ctx, a1.ID)
+	assert.Equal(t, errors.ErrorAllocationNotFound, appErrorCode(err))
+}
+
+func TestAllocateMachine_BindsAllGPUs(t *testing.T) {
+	db := setupAllocationTestDB(t)
+	svc := newTestAllocationService(t, db)
+	seedSharedHost(t, db, 0)
+	ctx := context.Background()
+
+	alloc, err := svc.AllocateMachine(ctx, 1, "h1", 1, "")
+	require.NoError(t, err)
+	assert.Empty(t, alloc.GPUIndexes)
+	assert.Equal(t, 4, alloc.GPUCount)
+	assert.Equal(t, "allocated", hostStatus(t, db, "h1"))
+	for _, owner := range gpuOwners(t, db, "h1") {
+		assert.Equal(t, alloc.ID, owner)
+	}
+
+	_, err = svc.AllocateGPUs(ctx, 2, "h1", []int{0}, nil, 1, "")
+	assert.Equal(t, errors.ErrorMachineNotAvailable, appErrorCode(err))
+
+	require.NoError(t, svc.ReclaimMachine(ctx, "h1"))
+	assert.Equal(t, "idle", hostStatus(t, db, "h1"))
+}
+
+func TestHostKeysPayload_TenantAccounts(t *testing.T) {
+	db := setupAllocationTestDB(t)
+	svc := newTestAllocationService(t, db)
+	seedSharedHost(t, db, 0)
+	ctx := context.Background()
+
+	require.NoError(t, db.Exec(`INSERT INTO ssh_keys (customer_id, name, public_key) VALUES (1, 'k1', 'ssh-ed25519 AAAA u1')`).Error)
+	_, err := svc.AllocateGPUs(ctx, 1, "h1", []int{2}, nil, 1, "")
+	require.NoError(t, err)
+	_, err = svc.AllocateGPUs(ctx, 1, "h1", []int{0}, nil, 1, "")
+	require.NoError(t, err)
+	_, err = svc.AllocateGPUs(ctx, 2, "h1", []int{1}, nil, 1, "")
+	require.NoError(t, err)
+
+	payload, err := svc.hostKeysPayload(ctx, "h1")
+	require.NoError(t, err)
+	assert.Empty(t, payload.PublicKeys)
+	require.Len(t, payload.Tenants, 2)
+	// 同一客户的多个分配合并到一个账号，没有公钥的客户也创建账号
+	assert.Equal(t, "rg-c1", payload.Tenants[0].Username)
+	assert.Equal(t, []string{"ssh-ed25519 AAAA u1"}, payload.Tenants[0].PublicKeys)
+	assert.Equal(t, []int{0, 2}, payload.Tenants[0].GPUIndexes)
+	assert.Equal(t, "rg-c2", payload.Tenants[1].Username)
+	assert.Empty(t, payload.Tenants[1].PublicKeys)
+
+	username, err := svc.HostTenantUsername(ctx, "h1", 1)
+	require.NoError(t, err)
+	assert.Equal(t, "rg-c1", username)
+	username, err = svc.HostTenantUsername(ctx, "h1", 3)
+	require.NoError(t, err)
+	assert.Empty(t, username)
+
+	// 整机分配的客户使用机器共享账号
+	require.NoError(t, svc.ReclaimMachine(ctx, "h1"))
+	_, err = svc.AllocateMachine(ctx, 1, "h1", 1, "")
+	require.NoError(t, err)
+	payload, err = svc.hostKeysPayload(ctx, "h1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ssh-ed25519 AAAA u1"}, payload.PublicKeys)
+	assert.Empty(t, payload.Tenants)
+	username, err = svc.HostTenantUsername(ctx, "h1", 1)
+	require.NoError(t, err)
+	assert.Empty(t, username)
+}
@@ -0,0 +1,149 @@
+package allocation
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+
+	"github.com/YoungBoyGod/remotegpu/internal/dao"
+	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+	"github.com/YoungBoyGod/remotegpu/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrAllocationRenewed 到期回收时分配已被续期
+var ErrAllocationRenewed = stderrors.New("allocation has been renewed")
+
+const (
+	RenewRoleCustomer = "customer"
+	RenewRoleAdmin    = "admin"
+)
+
+// RenewOperator 续期操作人，用于续期记录和审计日志
+type RenewOperator struct {
+	ID       uint
+	Username string
+	Role     string // customer, admin
+	IP       string
+}
+
+// RenewAllocation 管理员按分配 ID 续期
+func (s *AllocationService) RenewAllocation(ctx context.Context, allocationID string, months int, remark string, op RenewOperator) (*entity.Allocation, error) {
+	return s.renew(ctx, allocationID, months, remark, op)
+}
+
+// RenewByHost 客户续期自己名下机器的活跃分配
+func (s *AllocationService) RenewByHost(ctx context.Context, hostID string, customerID uint, months int, remark string, op RenewOperator) (*entity.Allocation, error) {
+	alloc, err := s.allocationDao.FindActiveByHostAndCustomer(ctx, hostID, customerID)
+	if err != nil {
+		if stderrors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New(errors.ErrorForbidden, "无权访问该机器")
+		}
+		return nil, errors.Wrap(errors.ErrorDatabase, err)
+	}
+	return s.renew(ctx, alloc.ID, months, remark, op)
+}
+
+// ListRenewals 查询分配的续期记录
+func (s *AllocationService) ListRenewals(ctx context.Context, allocationID string) ([]entity.AllocationRenewal, error) {
+	if _, err := s.allocationDao.FindByID(ctx, allocationID); err != nil {
+		if stderrors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New(errors.ErrorAllocationNotFound, "allocation not found")
+		}
+		return nil, errors.Wrap(errors.ErrorDatabase, err)
+	}
+	return s.renewalDao.ListByAllocationID(ctx, allocationID)
+}
+
+// renew 将活跃分配的到期时间顺延 months 个月，清除到期提醒标记并记录续期和审计日志
+func (s *AllocationService) renew(ctx context.Context, allocationID string, months int, remark string, op RenewOperator) (*entity.Allocation, error) {
+	if months < 1 || months > s.maxRenewMonths {
+		return nil, errors.New(errors.ErrorInvalidParams,
+			fmt.Sprintf("renewal must be between 1 and %d months", s.maxRenewMonths))
+	}
+
+	var (
+		alloc   entity.Allocation
+		renewal *entity.AllocationRenewal
+	)
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// 加行级锁，避免与到期回收并发
+		err := tx.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", allocationID).First(&alloc).Error
+		if err != nil {
+			if stderrors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New(errors.ErrorAllocationNotFound, "allocation not found")
+			}
+			return errors.Wrap(errors.ErrorDatabase, err)
+		}
+		if alloc.Status != "active" {
+			return errors.New(errors.ErrorInvalidParams, "only active allocations can be renewed, current status: "+alloc.Status)
+		}
+
+		oldEnd := alloc.EndTime
+		newEnd := oldEnd.AddDate(0, months, 0)
+
+		// 锁定机器后检查续期后的租期是否与其他预约冲突
+		if err := tx.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id").Where("id = ?", alloc.HostID).First(&entity.Host{}).Error; err != nil {
+			return errors.Wrap(errors.ErrorDatabase, err)
+		}
+		held, err := dao.NewReservationDao(tx).HeldHostIDs(ctx, []string{alloc.HostID}, oldEnd, newEnd, "")
+		if err != nil {
+			return errors.Wrap(errors.ErrorDatabase, err)
+		}
+		if len(held) > 0 {
+			return errors.New(errors.ErrorMachineNotAvailable, "machine is reserved by another reservation during the renewal period")
+		}
+		if err := tx.WithContext(ctx).Model(&alloc).Updates(map[string]interface{}{
+			"end_time":         newEnd,
+			"expiry_warned_at": nil,
+		}).Error; err != nil {
+			return errors.Wrap(errors.ErrorDatabase, err)
+		}
+		alloc.EndTime = newEnd
+		alloc.ExpiryWarnedAt = nil
+
+		renewal = &entity.AllocationRenewal{
+			AllocationID: alloc.ID,
+			CustomerID:   alloc.CustomerID,
+			OperatorID:   op.ID,
+			OperatorRole: op.Role,
+			Months:       months,
+			OldEndTime:   oldEnd,
+			NewEndTime:   newEnd,
+			Remark:       remark,
+		}
+		if err := dao.NewAllocationRenewalDao(tx).Create(ctx, renewal); err != nil {
+			return errors.Wrap(errors.ErrorDatabase, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	path := fmt.Sprintf("/admin/allocations/%s/renew", alloc.ID)
+	if op.Role == RenewRoleCustomer {
+		path = fmt.Sprintf("/customer/machines/%s/renew", alloc.HostID)
+	}
+	_ = s.auditService.CreateLog(
+		ctx,
+		&alloc.CustomerID,
+		op.Username, op.IP, "POST", path,
+		"renew_allocation", "allocation", alloc.ID,
+		map[string]interface{}{
+			"host_id":       alloc.HostID,
+			"months":        months,
+			"old_end_time":  renewal.OldEndTime,
+			"new_end_time":  renewal.NewEndTime,
+			"operator_id":   op.ID,
+			"operator_role": op.Role,
+			"remark":        remark,
+		},
+		200,
+	)
+
+	return &alloc, nil
+}
@@ -2,14 +2,22 @@ package auth
 
 import (
 	"context"
+	"fmt"
+	"math"
+	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/YoungBoyGod/remotegpu/config"
 	"github.com/YoungBoyGod/remotegpu/internal/dao"
 	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+	"github.com/YoungBoyGod/remotegpu/internal/service/audit"
 	"github.com/YoungBoyGod/remotegpu/pkg/auth"
 	"github.com/YoungBoyGod/remotegpu/pkg/cache"
 	"github.com/YoungBoyGod/remotegpu/pkg/errors"
+	"github.com/YoungBoyGod/remotegpu/pkg/logger"
+	"github.com/YoungBoyGod/remotegpu/pkg/mail"
 	"gorm.io/gorm"
 )
 
@@ -26,22 +34,66 @@ const (
 	passwordResetPrefix = "auth:password_reset:"
 	// 密码重置 token 过期时间 (30分钟)
 	passwordResetTTL = 30 * time.Minute
+	// 邮箱验证 token key 前缀
+	emailVerifyPrefix = "auth:email_verify:"
+	// 邮箱验证 token 过期时间 (24小时)
+	emailVerifyTTL = 24 * time.Hour
+	// 邮件发送超时时间
+	mailSendTimeout = 30 * time.Second
 )
 
 type AuthService struct {
-	customerDao *dao.CustomerDao
-	db          *gorm.DB
-	cache       cache.Cache
+	customerDao  *dao.CustomerDao
+	recoveryDao  *dao.MFARecoveryCodeDao
+	configDao    *dao.SystemConfigDao
+	db           *gorm.DB
+	cache        cache.Cache
+	mailer       mail.Sender
+	mailBaseURL  string
+	lockout      config.LoginLockoutConfig
+	auditService *audit.AuditService
 }
 
 func NewAuthService(db *gorm.DB, c cache.Cache) *AuthService {
 	return &AuthService{
 		customerDao: dao.NewCustomerDao(db),
+		recoveryDao: dao.NewMFARecoveryCodeDao(db),
+		configDao:   dao.NewSystemConfigDao(db),
 		db:          db,
 		cache:       c,
 	}
 }
 
+// SetMailer 注入邮件发送器，baseURL 为邮件中链接指向的控制台地址；未注入时无法发送密码重置和邮箱验证邮件
+func (s *AuthService) SetMailer(m mail.Sender, baseURL string) {
+	s.mailer = m
+	s.mailBaseURL = strings.TrimRight(baseURL, "/")
+}
+
+// SetLoginLockout 设置登录失败锁定策略，未设置时不锁定
+func (s *AuthService) SetLoginLockout(cfg config.LoginLockoutConfig) {
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 5
+	}
+	if cfg.LockMinutes <= 0 {
+		cfg.LockMinutes = 15
+	}
+	if cfg.MaxLockMinutes < cfg.LockMinutes {
+		cfg.MaxLockMinutes = 24 * 60
+	}
+	s.lockout = cfg
+}
+
+// SetAuditService 注入审计服务，账号锁定时记录审计日志
+func (s *AuthService) SetAuditService(a *audit.AuditService) {
+	s.auditService = a
+}
+
+// mailLink 生成邮件中带 token 的控制台链接
+func (s *AuthService) mailLink(path, token string) string {
+	return s.mailBaseURL + path + "?token=" + url.QueryEscape(token)
+}
+
 // storeRefreshToken 存储刷新 token
 func (s *AuthService) storeRefreshToken(ctx context.Context, refreshToken string, userID uint) error {
 	if s.cache == nil {
@@ -52,43 +104,105 @@ func (s *AuthService) storeRefreshToken(ctx context.Context, refreshToken string
 	return s.cache.Set(ctx, key, userID, refreshTokenTTL)
 }
 
-func (s *AuthService) Login(ctx context.Context, username, password string) (string, string, int64, bool, error) {
+// LoginResult 登录结果：需要两步验证时只返回 MFAToken，验证通过后才签发访问令牌
+type LoginResult struct {
+	AccessToken        string
+	RefreshToken       string
+	ExpiresIn          int64
+	MustChangePassword bool
+	// MFARequired 需要调用 VerifyMFALogin 提交验证码；MFAEnrollmentRequired 表示策略要求开通但尚未开通
+	MFARequired           bool
+	MFAEnrollmentRequired bool
+	MFAToken              string
+	// RecoveryCodes 登录时完成强制开通才返回，只展示一次
+	RecoveryCodes []string
+}
+
+// Login 用户登录，clientIP 用于账号锁定时的审计记录
+func (s *AuthService) Login(ctx context.Context, username, password, clientIP string) (*LoginResult, error) {
 	customer, err := s.customerDao.FindByUsername(ctx, username)
 	if err != nil {
-		return "", "", 0, false, errors.New(errors.ErrorPasswordIncorrect, "invalid credentials")
+		return nil, errors.New(errors.ErrorPasswordIncorrect, "invalid credentials")
+	}
+	if isExternalAccount(customer) {
+		return nil, errors.New(errors.ErrorSSOLoginRequired, "please sign in with your organization's single sign-on")
 	}
 
-	if !auth.CheckPasswordHash(password, customer.PasswordHash) {
-		return "", "", 0, false, errors.New(errors.ErrorPasswordIncorrect, "invalid credentials")
+	if err := s.verifyPassword(ctx, customer, password, clientIP, "/auth/login"); err != nil {
+		return nil, err
 	}
 
 	// 验证账号状态
 	if customer.Status != "active" {
-		return "", "", 0, false, errors.New(errors.ErrorUserDisabled, "")
+		return nil, errors.New(errors.ErrorUserDisabled, "")
+	}
+
+	return s.completeLogin(ctx, customer)
+}
+
+// completeLogin 密码校验通过后：需要两步验证时签发 MFA 待验证令牌，否则直接签发访问令牌
+func (s *AuthService) completeLogin(ctx context.Context, customer *entity.Customer) (*LoginResult, error) {
+	if customer.MFAEnabled {
+		return s.startMFAChallenge(ctx, customer, false)
+	}
+	enforced, err := s.mfaEnforced(ctx, customer)
+	if err != nil {
+		return nil, err
+	}
+	if enforced {
+		return s.startMFAChallenge(ctx, customer, true)
+	}
+	return s.issueTokens(ctx, customer)
+}
+
+// CompleteExternalLogin 外部身份源（企业单点登录）认证通过后完成登录，与密码登录一样遵循两步验证策略
+func (s *AuthService) CompleteExternalLogin(ctx context.Context, customer *entity.Customer) (*LoginResult, error) {
+	if customer.Status != "active" {
+		return nil, errors.New(errors.ErrorUserDisabled, "account is disabled")
 	}
+	return s.completeLogin(ctx, customer)
+}
 
+// isExternalAccount 是否为单点登录账号，此类账号没有可用的本地密码
+func isExternalAccount(customer *entity.Customer) bool {
+	return customer.AuthSource != "" && customer.AuthSource != "local"
+}
+
+// issueTokens 更新最后登录时间并签发访问令牌和刷新令牌，登录成功后清除登录失败记录
+func (s *AuthService) issueTokens(ctx context.Context, customer *entity.Customer) (*LoginResult, error) {
 	// 更新最后登录时间
 	now := time.Now()
 	s.db.Model(customer).Update("last_login_at", now)
 
+	if customer.FailedLoginAttempts > 0 || customer.LockoutCount > 0 || customer.LockedUntil != nil {
+		if err := s.customerDao.ResetLoginFailures(ctx, customer.ID); err != nil {
+			logger.GetLogger().Warn(fmt.Sprintf("清除用户 %d 登录失败记录失败: %v", customer.ID, err))
+		}
+	}
+
 	// 生成 Token
 	accessToken, err := auth.GenerateToken(customer.ID, customer.Username, customer.Role)
 	if err != nil {
-		return "", "", 0, false, err
+		return nil, err
 	}
 
 	// 生成刷新 Token
 	refreshToken, err := auth.GenerateRefreshToken()
 	if err != nil {
-		return "", "", 0, false, err
+		return nil, err
 	}
 
 	// 存储刷新 Token
 	if err := s.storeRefreshToken(ctx, refreshToken, customer.ID); err != nil {
-		return "", "", 0, false, err
+		return nil, err
 	}
 
-	return accessToken, refreshToken, 3600, customer.MustChangePassword, nil // 1小时过期
+	return &LoginResult{
+		AccessToken:        accessToken,
+		RefreshToken:       refreshToken,
+		ExpiresIn:          3600, // 1小时过期
+		MustChangePassword: customer.MustChangePassword,
+	}, nil
 }
 
 func (s *AuthService) GetProfile(ctx context.Context, userID uint) (*entity.Customer, error) {
@@ -104,49 +218,109 @@ func (s *AuthService) UpdateProfile(ctx context.Context, userID uint, fields map
 }
 
 // AdminLogin Admin 专用登录，验证角色
-func (s *AuthService) AdminLogin(ctx context.Context, username, password string) (string, string, int64, bool, error) {
+func (s *AuthService) AdminLogin(ctx context.Context, username, password, clientIP string) (*LoginResult, error) {
 	customer, err := s.customerDao.FindByUsername(ctx, username)
 	if err != nil {
-		return "", "", 0, false, errors.New(errors.ErrorPasswordIncorrect, "invalid credentials")
+		return nil, errors.New(errors.ErrorPasswordIncorrect, "invalid credentials")
+	}
+	if isExternalAccount(customer) {
+		return nil, errors.New(errors.ErrorSSOLoginRequired, "please sign in with your organization's single sign-on")
 	}
 
 	// 验证密码
-	if !auth.CheckPasswordHash(password, customer.PasswordHash) {
-		return "", "", 0, false, errors.New(errors.ErrorPasswordIncorrect, "invalid credentials")
+	if err := s.verifyPassword(ctx, customer, password, clientIP, "/auth/admin/login"); err != nil {
+		return nil, err
 	}
 
 	// 验证是否是 admin 角色
 	if customer.Role != "admin" {
-		return "", "", 0, false, errors.New(errors.ErrorForbidden, "permission denied: admin role required")
+		return nil, errors.New(errors.ErrorForbidden, "permission denied: admin role required")
 	}
 
 	// 验证账号状态
 	if customer.Status != "active" {
-		return "", "", 0, false, errors.New(errors.ErrorUserDisabled, "account is disabled")
+		return nil, errors.New(errors.ErrorUserDisabled, "account is disabled")
 	}
 
-	// 更新最后登录时间
+	return s.completeLogin(ctx, customer)
+}
+
+// verifyPassword 校验登录密码：账号锁定期间直接拒绝，密码错误时累计失败次数，达到阈值后锁定账号
+// 密码正确时不清除失败次数，需要两步验证的账号在验证码通过、签发令牌时才清除
+func (s *AuthService) verifyPassword(ctx context.Context, customer *entity.Customer, password, clientIP, path string) error {
 	now := time.Now()
-	s.db.Model(customer).Update("last_login_at", now)
+	if err := s.checkLocked(customer, now); err != nil {
+		return err
+	}
 
-	// 生成 Token
-	accessToken, err := auth.GenerateToken(customer.ID, customer.Username, customer.Role)
-	if err != nil {
-		return "", "", 0, false, err
+	if auth.CheckPasswordHash(password, customer.PasswordHash) {
+		return nil
 	}
 
-	// 生成刷新 Token
-	refreshToken, err := auth.GenerateRefreshToken()
+	if s.lockout.Enabled {
+		s.recordLoginFailure(ctx, customer, clientIP, path, now)
+	}
+	return errors.New(errors.ErrorPasswordIncorrect, "invalid credentials")
+}
+
+// checkLocked 账号锁定期间拒绝登录
+func (s *AuthService) checkLocked(customer *entity.Customer, now time.Time) error {
+	if s.lockout.Enabled && customer.LockedUntil != nil && now.Before(*customer.LockedUntil) {
+		minutes := int(math.Ceil(customer.LockedUntil.Sub(now).Minutes()))
+		return errors.New(errors.ErrorAccountLocked, fmt.Sprintf("account is locked, try again in %d minutes", minutes))
+	}
+	return nil
+}
+
+// recordLoginFailure 累计登录失败次数，达到阈值时锁定账号，锁定时长随连续锁定次数翻倍
+func (s *AuthService) recordLoginFailure(ctx context.Context, customer *entity.Customer, clientIP, path string, now time.Time) {
+	attempts, err := s.customerDao.IncrementLoginFailures(ctx, customer.ID)
 	if err != nil {
-		return "", "", 0, false, err
+		logger.GetLogger().Warn(fmt.Sprintf("记录用户 %d 登录失败次数失败: %v", customer.ID, err))
+		return
+	}
+	if attempts < s.lockout.MaxAttempts {
+		return
 	}
 
-	// 存储刷新 Token
-	if err := s.storeRefreshToken(ctx, refreshToken, customer.ID); err != nil {
-		return "", "", 0, false, err
+	lockoutCount := customer.LockoutCount + 1
+	until := now.Add(s.lockDuration(lockoutCount))
+	locked, err := s.customerDao.LockAccount(ctx, customer.ID, until, s.lockout.MaxAttempts)
+	if err != nil {
+		logger.GetLogger().Warn(fmt.Sprintf("锁定用户 %d 失败: %v", customer.ID, err))
+		return
+	}
+	if !locked {
+		return
+	}
+	logger.GetLogger().Warn(fmt.Sprintf("用户 %s 连续登录失败 %d 次，账号锁定至 %s", customer.Username, attempts, until.Format(time.RFC3339)))
+
+	if s.auditService != nil {
+		_ = s.auditService.CreateLog(
+			ctx,
+			&customer.ID,
+			customer.Username, clientIP, "POST", path,
+			"account_locked", "customer", strconv.FormatUint(uint64(customer.ID), 10),
+			map[string]interface{}{
+				"failed_attempts": attempts,
+				"lockout_count":   lockoutCount,
+				"locked_until":    until,
+			},
+			200,
+		)
 	}
+}
 
-	return accessToken, refreshToken, 3600, customer.MustChangePassword, nil
+// lockDuration 第 n 次锁定的时长：首次 LockMinutes，之后每次翻倍，不超过 MaxLockMinutes
+func (s *AuthService) lockDuration(n int) time.Duration {
+	minutes := s.lockout.LockMinutes
+	for i := 1; i < n && minutes < s.lockout.MaxLockMinutes; i++ {
+		minutes *= 2
+	}
+	if minutes > s.lockout.MaxLockMinutes {
+		minutes = s.lockout.MaxLockMinutes
+	}
+	return time.Duration(minutes) * time.Minute
 }
 
 // RefreshToken 使用刷新令牌获取新的访问令牌
@@ -240,33 +414,51 @@ func (s *AuthService) IsTokenBlacklisted(ctx context.Context, token string) bool
 	return count > 0
 }
 
-// RequestPasswordReset 请求密码重置，生成重置 token
-func (s *AuthService) RequestPasswordReset(ctx context.Context, username string) (string, error) {
+// RequestPasswordReset 请求密码重置，生成重置 token 并通过邮件发送重置链接
+// 账号不存在、已禁用或未设置邮箱时同样返回成功，避免通过该接口探测账号；邮件异步发送，发送失败只记录日志
+func (s *AuthService) RequestPasswordReset(ctx context.Context, username string) error {
 	if s.cache == nil {
-		return "", errors.New(errors.ErrorServerError, "cache service not available")
+		return errors.New(errors.ErrorServerError, "cache service not available")
+	}
+	if s.mailer == nil {
+		return errors.New(errors.ErrorServerError, "mail service not available")
 	}
 
 	customer, err := s.customerDao.FindByUsername(ctx, username)
-	if err != nil {
-		return "", errors.New(errors.ErrorUserNotFound, "user not found")
-	}
-	if customer.Status != "active" {
-		return "", errors.New(errors.ErrorUserDisabled, "account is disabled")
+	if err != nil || customer.Status != "active" || customer.Email == "" || isExternalAccount(customer) {
+		return nil
 	}
 
 	// 生成重置 token
 	token, err := auth.GenerateRefreshToken()
 	if err != nil {
-		return "", errors.WrapWithMessage(errors.ErrorServerError, "failed to generate reset token", err)
+		return errors.WrapWithMessage(errors.ErrorServerError, "failed to generate reset token", err)
 	}
 
 	// 存储 token -> userID 映射
 	key := passwordResetPrefix + token
 	if err := s.cache.Set(ctx, key, customer.ID, passwordResetTTL); err != nil {
-		return "", errors.WrapWithMessage(errors.ErrorServerError, "failed to store reset token", err)
+		return errors.WrapWithMessage(errors.ErrorServerError, "failed to store reset token", err)
+	}
+
+	msg, err := mail.Render(mail.TemplatePasswordReset, mail.PasswordResetData{
+		Name:          displayName(customer),
+		Link:          s.mailLink("/forgot-password", token),
+		ExpireMinutes: int(passwordResetTTL / time.Minute),
+	})
+	if err != nil {
+		return errors.WrapWithMessage(errors.ErrorServerError, "failed to render reset mail", err)
 	}
+	msg.To = []string{customer.Email}
+	go func() {
+		sendCtx, cancel := context.WithTimeout(context.Background(), mailSendTimeout)
+		defer cancel()
+		if err := s.mailer.Send(sendCtx, msg); err != nil {
+			logger.GetLogger().Warn(fmt.Sprintf("发送用户 %d 密码重置邮件失败: %v", customer.ID, err))
+		}
+	}()
 
-	return token, nil
+	return nil
 }
 
 // ConfirmPasswordReset 确认密码重置
@@ -306,3 +498,92 @@ func (s *AuthService) ConfirmPasswordReset(ctx context.Context, token, newPasswo
 
 	return nil
 }
+
+// RequestEmailVerification 向用户当前邮箱发送验证链接
+func (s *AuthService) RequestEmailVerification(ctx context.Context, userID uint) error {
+	if s.cache == nil {
+		return errors.New(errors.ErrorServerError, "cache service not available")
+	}
+	if s.mailer == nil {
+		return errors.New(errors.ErrorServerError, "mail service not available")
+	}
+
+	customer, err := s.customerDao.FindByID(ctx, userID)
+	if err != nil {
+		return errors.New(errors.ErrorUserNotFound, "user not found")
+	}
+	if customer.Email == "" {
+		return errors.New(errors.ErrorInvalidParams, "email not set")
+	}
+	if customer.EmailVerified {
+		return errors.New(errors.ErrorInvalidParams, "email already verified")
+	}
+
+	token, err := auth.GenerateRefreshToken()
+	if err != nil {
+		return errors.WrapWithMessage(errors.ErrorServerError, "failed to generate verification token", err)
+	}
+	// 同时记录邮箱，验证前邮箱被修改时 token 失效
+	key := emailVerifyPrefix + token
+	if err := s.cache.Set(ctx, key, fmt.Sprintf("%d:%s", customer.ID, customer.Email), emailVerifyTTL); err != nil {
+		return errors.WrapWithMessage(errors.ErrorServerError, "failed to store verification token", err)
+	}
+
+	msg, err := mail.Render(mail.TemplateEmailVerification, mail.EmailVerificationData{
+		Name:        displayName(customer),
+		Email:       customer.Email,
+		Link:        s.mailLink("/verify-email", token),
+		ExpireHours: int(emailVerifyTTL / time.Hour),
+	})
+	if err != nil {
+		return errors.WrapWithMessage(errors.ErrorServerError, "failed to render verification mail", err)
+	}
+	msg.To = []string{customer.Email}
+
+	sendCtx, cancel := context.WithTimeout(ctx, mailSendTimeout)
+	defer cancel()
+	if err := s.mailer.Send(sendCtx, msg); err != nil {
+		_ = s.cache.Delete(ctx, key)
+		return errors.WrapWithMessage(errors.ErrorServerError, "failed to send verification mail", err)
+	}
+	return nil
+}
+
+// ConfirmEmailVerification 使用验证 token 将邮箱标记为已验证
+func (s *AuthService) ConfirmEmailVerification(ctx context.Context, token string) error {
+	if s.cache == nil {
+		return errors.New(errors.ErrorServerError, "cache service not available")
+	}
+
+	key := emailVerifyPrefix + token
+	value, err := s.cache.Get(ctx, key)
+	if err != nil || value == "" {
+		return errors.New(errors.ErrorTokenInvalid, "invalid or expired verification token")
+	}
+	idStr, email, ok := strings.Cut(value, ":")
+	if !ok {
+		return errors.New(errors.ErrorTokenInvalid, "invalid token data")
+	}
+	userID, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		return errors.New(errors.ErrorTokenInvalid, "invalid token data")
+	}
+
+	customer, err := s.customerDao.FindByID(ctx, uint(userID))
+	if err != nil || customer.Email != email {
+		return errors.New(errors.ErrorTokenInvalid, "invalid or expired verification token")
+	}
+	if err := s.customerDao.UpdateFields(ctx, customer.ID, map[string]interface{}{"email_verified": true}); err != nil {
+		return errors.WrapWithMessage(errors.ErrorServerError, "failed to verify email", err)
+	}
+
+	_ = s.cache.Delete(ctx, key)
+	return nil
+}
+
+func displayName(customer *entity.Customer) string {
+	if customer.DisplayName != "" {
+		return customer.DisplayName
+	}
+	return customer.Username
+}
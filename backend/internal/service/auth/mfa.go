@@ -0,0 +1,342 @@
+package auth
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+	"github.com/YoungBoyGod/remotegpu/pkg/auth"
+	"github.com/YoungBoyGod/remotegpu/pkg/crypto"
+	"github.com/YoungBoyGod/remotegpu/pkg/errors"
+	"github.com/YoungBoyGod/remotegpu/pkg/logger"
+	"gorm.io/gorm"
+)
+
+const (
+	// MFA 待验证 token key 前缀，密码校验通过后签发，验证码通过后换取访问令牌
+	mfaPendingPrefix = "auth:mfa_pending:"
+	// MFA 待验证 token 过期时间
+	mfaPendingTTL = 5 * time.Minute
+	// 每个 MFA 待验证 token 允许提交验证码的次数
+	mfaMaxAttempts = 5
+	// 已登录用户关闭两步验证、重新生成恢复码时提交验证码的计数 key 前缀
+	mfaManageAttemptsPrefix = "auth:mfa_manage_attempts:"
+	// 已登录用户每个窗口内允许提交错误验证码的次数
+	mfaManageMaxAttempts = 5
+	mfaManageWindow      = 15 * time.Minute
+	// 验证器中显示的发行方
+	mfaIssuer = "RemoteGPU"
+	// 每次生成的恢复码数量
+	mfaRecoveryCodeCount = 10
+	// 系统配置：为 true 时管理员必须启用两步验证
+	configKeyMFAEnforceAdmin = "security_mfa_enforce_admin"
+)
+
+// MFAEnrollment 两步验证开通信息，前端根据 ProvisioningURI 渲染二维码
+type MFAEnrollment struct {
+	Secret          string
+	ProvisioningURI string
+}
+
+// mfaEnforced 系统策略是否要求该用户启用两步验证
+func (s *AuthService) mfaEnforced(ctx context.Context, customer *entity.Customer) (bool, error) {
+	if !auth.IsAdmin(customer.Role) {
+		return false, nil
+	}
+	config, err := s.configDao.GetByKey(ctx, configKeyMFAEnforceAdmin)
+	if err != nil {
+		if stderrors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, errors.Wrap(errors.ErrorDatabase, err)
+	}
+	enforced, _ := strconv.ParseBool(config.ConfigValue)
+	return enforced, nil
+}
+
+// startMFAChallenge 签发 MFA 待验证 token，enroll 表示需要先完成开通
+func (s *AuthService) startMFAChallenge(ctx context.Context, customer *entity.Customer, enroll bool) (*LoginResult, error) {
+	if s.cache == nil {
+		return nil, errors.New(errors.ErrorServerError, "cache service not available")
+	}
+	token, err := auth.GenerateRefreshToken()
+	if err != nil {
+		return nil, errors.WrapWithMessage(errors.ErrorServerError, "failed to generate mfa token", err)
+	}
+	if err := s.cache.Set(ctx, mfaPendingPrefix+token, customer.ID, mfaPendingTTL); err != nil {
+		return nil, errors.WrapWithMessage(errors.ErrorServerError, "failed to store mfa token", err)
+	}
+	return &LoginResult{
+		MFARequired:           true,
+		MFAEnrollmentRequired: enroll,
+		MFAToken:              token,
+		MustChangePassword:    customer.MustChangePassword,
+	}, nil
+}
+
+// pendingCustomer 根据 MFA 待验证 token 查找用户
+func (s *AuthService) pendingCustomer(ctx context.Context, mfaToken string) (*entity.Customer, error) {
+	if s.cache == nil {
+		return nil, errors.New(errors.ErrorServerError, "cache service not available")
+	}
+	userIDStr, err := s.cache.Get(ctx, mfaPendingPrefix+mfaToken)
+	if err != nil || userIDStr == "" {
+		return nil, errors.New(errors.ErrorTokenInvalid, "invalid or expired mfa token")
+	}
+	userID, err := strconv.ParseUint(userIDStr, 10, 64)
+	if err != nil {
+		return nil, errors.New(errors.ErrorTokenInvalid, "invalid token data")
+	}
+	customer, err := s.customerDao.FindByID(ctx, uint(userID))
+	if err != nil {
+		return nil, errors.New(errors.ErrorUserNotFound, "user not found")
+	}
+	if customer.Status != "active" {
+		return nil, errors.New(errors.ErrorUserDisabled, "account is disabled")
+	}
+	return customer, nil
+}
+
+// VerifyMFALogin 提交两步验证码（或恢复码）完成登录；策略要求开通的用户在此完成开通并返回恢复码
+// 验证码错误与密码错误一样计入账号的登录失败次数，避免通过反复重新登录绕过单个 token 的尝试次数限制
+func (s *AuthService) VerifyMFALogin(ctx context.Context, mfaToken, code, clientIP string) (*LoginResult, error) {
+	customer, err := s.pendingCustomer(ctx, mfaToken)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkLocked(customer, time.Now()); err != nil {
+		return nil, err
+	}
+
+	// 限制每个 token 的尝试次数，超过后需要重新输入密码
+	key := mfaPendingPrefix + mfaToken
+	attempts, err := s.cache.Incr(ctx, key+":attempts", mfaPendingTTL)
+	if err != nil {
+		return nil, errors.WrapWithMessage(errors.ErrorServerError, "failed to record mfa attempt", err)
+	}
+	if attempts > mfaMaxAttempts {
+		_ = s.cache.Delete(ctx, key, key+":attempts")
+		return nil, errors.New(errors.ErrorTokenInvalid, "too many attempts, please log in again")
+	}
+
+	var recoveryCodes []string
+	if customer.MFAEnabled {
+		err = s.verifyMFACode(ctx, customer, code, true)
+	} else {
+		if customer.MFASecret == "" {
+			return nil, errors.New(errors.ErrorInvalidParams, "mfa enrollment not started")
+		}
+		recoveryCodes, err = s.activateMFA(ctx, customer, code)
+	}
+	if err != nil {
+		if appErr := errors.GetAppError(err); appErr != nil && appErr.Code == errors.ErrorMFACodeInvalid && s.lockout.Enabled {
+			s.recordLoginFailure(ctx, customer, clientIP, "/auth/mfa/verify", time.Now())
+		}
+		return nil, err
+	}
+
+	_ = s.cache.Delete(ctx, key, key+":attempts")
+	result, err := s.issueTokens(ctx, customer)
+	if err != nil {
+		return nil, err
+	}
+	result.RecoveryCodes = recoveryCodes
+	return result, nil
+}
+
+// BeginMFAEnrollmentWithToken 策略要求开通两步验证的用户在登录过程中使用 MFA 待验证 token 开通
+func (s *AuthService) BeginMFAEnrollmentWithToken(ctx context.Context, mfaToken string) (*MFAEnrollment, error) {
+	customer, err := s.pendingCustomer(ctx, mfaToken)
+	if err != nil {
+		return nil, err
+	}
+	if customer.MFAEnabled {
+		return nil, errors.New(errors.ErrorInvalidParams, "mfa already enabled")
+	}
+	return s.beginEnrollment(ctx, customer)
+}
+
+// BeginMFAEnrollment 生成新的 TOTP 密钥，提交验证码后才启用
+func (s *AuthService) BeginMFAEnrollment(ctx context.Context, userID uint) (*MFAEnrollment, error) {
+	customer, err := s.customerDao.FindByID(ctx, userID)
+	if err != nil {
+		return nil, errors.New(errors.ErrorUserNotFound, "user not found")
+	}
+	if customer.MFAEnabled {
+		return nil, errors.New(errors.ErrorInvalidParams, "mfa already enabled")
+	}
+	return s.beginEnrollment(ctx, customer)
+}
+
+func (s *AuthService) beginEnrollment(ctx context.Context, customer *entity.Customer) (*MFAEnrollment, error) {
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		return nil, errors.WrapWithMessage(errors.ErrorServerError, "failed to generate mfa secret", err)
+	}
+	encrypted, err := crypto.EncryptAES256GCM(secret)
+	if err != nil {
+		return nil, errors.WrapWithMessage(errors.ErrorServerError, "failed to encrypt mfa secret", err)
+	}
+	if err := s.customerDao.SetMFASecret(ctx, customer.ID, encrypted); err != nil {
+		return nil, errors.Wrap(errors.ErrorDatabase, err)
+	}
+	return &MFAEnrollment{
+		Secret:          secret,
+		ProvisioningURI: auth.TOTPProvisioningURI(mfaIssuer, customer.Username, secret),
+	}, nil
+}
+
+// EnableMFA 提交验证器生成的验证码启用两步验证，返回恢复码（只展示一次）
+func (s *AuthService) EnableMFA(ctx context.Context, userID uint, code string) ([]string, error) {
+	customer, err := s.customerDao.FindByID(ctx, userID)
+	if err != nil {
+		return nil, errors.New(errors.ErrorUserNotFound, "user not found")
+	}
+	if customer.MFAEnabled {
+		return nil, errors.New(errors.ErrorInvalidParams, "mfa already enabled")
+	}
+	if customer.MFASecret == "" {
+		return nil, errors.New(errors.ErrorInvalidParams, "mfa enrollment not started")
+	}
+	return s.activateMFA(ctx, customer, code)
+}
+
+// activateMFA 校验开通中的密钥并启用两步验证
+func (s *AuthService) activateMFA(ctx context.Context, customer *entity.Customer, code string) ([]string, error) {
+	secret, err := crypto.DecryptAES256GCM(customer.MFASecret)
+	if err != nil {
+		return nil, errors.WrapWithMessage(errors.ErrorServerError, "failed to decrypt mfa secret", err)
+	}
+	step, ok := auth.ValidateTOTP(secret, code, time.Now())
+	if !ok {
+		return nil, errors.New(errors.ErrorMFACodeInvalid, "invalid mfa code")
+	}
+	codes, err := s.newRecoveryCodes(ctx, customer.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.customerDao.EnableMFA(ctx, customer.ID, step); err != nil {
+		return nil, errors.Wrap(errors.ErrorDatabase, err)
+	}
+	return codes, nil
+}
+
+// DisableMFA 用户关闭两步验证，需要提交当前验证码；策略要求启用时不允许关闭
+func (s *AuthService) DisableMFA(ctx context.Context, userID uint, code, clientIP string) error {
+	customer, err := s.customerDao.FindByID(ctx, userID)
+	if err != nil {
+		return errors.New(errors.ErrorUserNotFound, "user not found")
+	}
+	if !customer.MFAEnabled {
+		return errors.New(errors.ErrorInvalidParams, "mfa not enabled")
+	}
+	enforced, err := s.mfaEnforced(ctx, customer)
+	if err != nil {
+		return err
+	}
+	if enforced {
+		return errors.New(errors.ErrorForbidden, "mfa is required for your role")
+	}
+	if err := s.verifyManagedMFACode(ctx, customer, code, clientIP, "/auth/mfa/disable"); err != nil {
+		return err
+	}
+	if err := s.customerDao.ResetMFA(ctx, customer.ID); err != nil {
+		return errors.Wrap(errors.ErrorDatabase, err)
+	}
+	return nil
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码，旧恢复码全部作废
+func (s *AuthService) RegenerateRecoveryCodes(ctx context.Context, userID uint, code, clientIP string) ([]string, error) {
+	customer, err := s.customerDao.FindByID(ctx, userID)
+	if err != nil {
+		return nil, errors.New(errors.ErrorUserNotFound, "user not found")
+	}
+	if !customer.MFAEnabled {
+		return nil, errors.New(errors.ErrorInvalidParams, "mfa not enabled")
+	}
+	if err := s.verifyManagedMFACode(ctx, customer, code, clientIP, "/auth/mfa/recovery-codes"); err != nil {
+		return nil, err
+	}
+	return s.newRecoveryCodes(ctx, customer.ID)
+}
+
+// verifyManagedMFACode 校验已登录用户管理两步验证时提交的验证码
+// 与登录时一样限制尝试次数并计入登录失败次数，避免会话被盗后暴力破解验证码关闭两步验证
+func (s *AuthService) verifyManagedMFACode(ctx context.Context, customer *entity.Customer, code, clientIP, path string) error {
+	now := time.Now()
+	if err := s.checkLocked(customer, now); err != nil {
+		return err
+	}
+
+	key := mfaManageAttemptsPrefix + strconv.FormatUint(uint64(customer.ID), 10)
+	if s.cache != nil {
+		attempts, err := s.cache.Incr(ctx, key, mfaManageWindow)
+		if err != nil {
+			return errors.WrapWithMessage(errors.ErrorServerError, "failed to record mfa attempt", err)
+		}
+		if attempts > mfaManageMaxAttempts {
+			return errors.New(errors.ErrorAccountLocked, "too many mfa attempts, please try again later")
+		}
+	}
+
+	if err := s.verifyMFACode(ctx, customer, code, false); err != nil {
+		if appErr := errors.GetAppError(err); appErr != nil && appErr.Code == errors.ErrorMFACodeInvalid && s.lockout.Enabled {
+			s.recordLoginFailure(ctx, customer, clientIP, path, now)
+		}
+		return err
+	}
+	if s.cache != nil {
+		_ = s.cache.Delete(ctx, key)
+	}
+	return nil
+}
+
+func (s *AuthService) newRecoveryCodes(ctx context.Context, customerID uint) ([]string, error) {
+	codes, err := auth.GenerateRecoveryCodes(mfaRecoveryCodeCount)
+	if err != nil {
+		return nil, errors.WrapWithMessage(errors.ErrorServerError, "failed to generate recovery codes", err)
+	}
+	hashes := make([]string, 0, len(codes))
+	for _, c := range codes {
+		hashes = append(hashes, auth.HashRecoveryCode(c))
+	}
+	if err := s.recoveryDao.Replace(ctx, customerID, hashes); err != nil {
+		return nil, errors.Wrap(errors.ErrorDatabase, err)
+	}
+	return codes, nil
+}
+
+// verifyMFACode 校验 TOTP 验证码，同一时间步的验证码只能使用一次；allowRecovery 时也接受恢复码
+func (s *AuthService) verifyMFACode(ctx context.Context, customer *entity.Customer, code string, allowRecovery bool) error {
+	secret, err := crypto.DecryptAES256GCM(customer.MFASecret)
+	if err != nil {
+		return errors.WrapWithMessage(errors.ErrorServerError, "failed to decrypt mfa secret", err)
+	}
+	if step, ok := auth.ValidateTOTP(secret, code, time.Now()); ok {
+		advanced, err := s.customerDao.AdvanceMFAStep(ctx, customer.ID, step)
+		if err != nil {
+			return errors.Wrap(errors.ErrorDatabase, err)
+		}
+		if !advanced {
+			return errors.New(errors.ErrorMFACodeInvalid, "mfa code already used")
+		}
+		return nil
+	}
+
+	if allowRecovery {
+		used, err := s.recoveryDao.Consume(ctx, customer.ID, auth.HashRecoveryCode(code))
+		if err != nil {
+			return errors.Wrap(errors.ErrorDatabase, err)
+		}
+		if used {
+			remaining, _ := s.recoveryDao.CountUnused(ctx, customer.ID)
+			logger.GetLogger().Info(fmt.Sprintf("用户 %s 使用恢复码登录，剩余 %d 个", customer.Username, remaining))
+			return nil
+		}
+	}
+	return errors.New(errors.ErrorMFACodeInvalid, "invalid mfa code")
+}
@@ -0,0 +1,121 @@
+package billing
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/YoungBoyGod/remotegpu/config"
+	"github.com/YoungBoyGod/remotegpu/internal/dao"
+	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvoiceNotFound    = errors.New("账单不存在")
+	ErrPeriodNotEnded     = errors.New("账期尚未结束")
+	ErrInvalidPriceTable  = errors.New("价格表配置不合法")
+	ErrInvalidUsageFilter = errors.New("用量查询条件不合法")
+)
+
+// BillingService 用量计量与账单服务
+// 定时将分配、环境、任务的 GPU 使用区间记录为用量（按自然日切分，单价取计量时的价格表），
+// 每月初汇总上月及之前未出账的用量生成账单
+type BillingService struct {
+	db         *gorm.DB
+	billingDao *dao.BillingDao
+	configDao  *dao.SystemConfigDao
+	lookback   time.Duration
+	loc        *time.Location
+	now        func() time.Time
+}
+
+// NewBillingService 创建计量与账单服务
+func NewBillingService(db *gorm.DB, cfg config.BillingConfig) *BillingService {
+	lookbackHours := cfg.LookbackHours
+	if lookbackHours <= 0 {
+		lookbackHours = 72
+	}
+	return &BillingService{
+		db:         db,
+		billingDao: dao.NewBillingDao(db),
+		configDao:  dao.NewSystemConfigDao(db),
+		lookback:   time.Duration(lookbackHours) * time.Hour,
+		loc:        time.Local,
+		now:        time.Now,
+	}
+}
+
+// ListUsage 分页查询用量记录，pageSize <= 0 时返回全部
+func (s *BillingService) ListUsage(ctx context.Context, filter dao.UsageFilter, page, pageSize int) ([]entity.UsageRecord, int64, error) {
+	if err := validateFilter(filter); err != nil {
+		return nil, 0, err
+	}
+	return s.billingDao.ListUsage(ctx, filter, page, pageSize)
+}
+
+// Statement 用量对账单：按来源、工作空间、GPU 型号汇总任意时间范围内的用量（含未出账部分）
+func (s *BillingService) Statement(ctx context.Context, filter dao.UsageFilter) (map[string]interface{}, error) {
+	if err := validateFilter(filter); err != nil {
+		return nil, err
+	}
+	items, err := s.billingDao.SummarizeUsage(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	var gpuHours, amount float64
+	for i := range items {
+		items[i].GPUHours = round4(items[i].GPUHours)
+		items[i].Amount = round4(items[i].Amount)
+		gpuHours += items[i].GPUHours
+		amount += items[i].Amount
+	}
+	prices, err := s.Prices(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"start_time":      filter.Start,
+		"end_time":        filter.End,
+		"items":           items,
+		"total_gpu_hours": round4(gpuHours),
+		"total_amount":    round4(amount),
+		"currency":        prices.Currency,
+	}, nil
+}
+
+// ListInvoices 分页查询账单
+func (s *BillingService) ListInvoices(ctx context.Context, page, pageSize int, filters map[string]interface{}) ([]entity.Invoice, int64, error) {
+	return s.billingDao.ListInvoices(ctx, page, pageSize, filters)
+}
+
+// GetInvoice 获取账单（含明细），customerID 非空时校验归属
+func (s *BillingService) GetInvoice(ctx context.Context, id uint, customerID *uint) (*entity.Invoice, error) {
+	invoice, err := s.billingDao.FindInvoice(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvoiceNotFound
+		}
+		return nil, err
+	}
+	if customerID != nil && invoice.CustomerID != *customerID {
+		return nil, ErrInvoiceNotFound
+	}
+	return invoice, nil
+}
+
+// InvoiceUsage 查询账单计入的用量记录
+func (s *BillingService) InvoiceUsage(ctx context.Context, invoiceID uint) ([]entity.UsageRecord, error) {
+	return s.billingDao.ListInvoiceUsage(ctx, invoiceID)
+}
+
+func validateFilter(f dao.UsageFilter) error {
+	if !f.Start.IsZero() && !f.End.IsZero() && !f.End.After(f.Start) {
+		return ErrInvalidUsageFilter
+	}
+	switch f.SourceType {
+	case "", entity.UsageSourceAllocation, entity.UsageSourceEnvironment, entity.UsageSourceTask:
+		return nil
+	}
+	return ErrInvalidUsageFilter
+}
@@ -0,0 +1,369 @@
+package billing
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/YoungBoyGod/remotegpu/config"
+	"github.com/YoungBoyGod/remotegpu/internal/dao"
+	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupBillingTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+
+	for _, stmt := range []string{
+		`CREATE TABLE system_configs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			config_key TEXT NOT NULL UNIQUE,
+			config_value TEXT NOT NULL,
+			config_type TEXT NOT NULL DEFAULT 'string',
+			config_group TEXT NOT NULL DEFAULT 'general',
+			description TEXT,
+			is_public INTEGER DEFAULT 0,
+			created_at DATETIME,
+			updated_at DATETIME
+		)`,
+		`CREATE TABLE gpus (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			host_id TEXT NOT NULL,
+			"index" INTEGER NOT NULL,
+			uuid TEXT,
+			name TEXT NOT NULL,
+			memory_total_mb INTEGER NOT NULL DEFAULT 0,
+			status TEXT DEFAULT 'available',
+			allocated_to TEXT,
+			updated_at DATETIME
+		)`,
+		`CREATE TABLE allocations (
+			id TEXT PRIMARY KEY,
+			customer_id INTEGER NOT NULL,
+			host_id TEXT NOT NULL,
+			workspace_id INTEGER,
+			gpu_indexes TEXT,
+			gpu_count INTEGER DEFAULT 0,
+			start_time DATETIME NOT NULL,
+			end_time DATETIME NOT NULL,
+			actual_end_time DATETIME,
+			status TEXT DEFAULT 'active'
+		)`,
+		`CREATE TABLE environments (
+			id TEXT PRIMARY KEY,
+			user_id INTEGER NOT NULL,
+			workspace_id INTEGER,
+			host_id TEXT NOT NULL,
+			status TEXT DEFAULT 'creating',
+			gpu INTEGER DEFAULT 0,
+			started_at DATETIME,
+			stopped_at DATETIME
+		)`,
+		`CREATE TABLE tasks (
+			id TEXT PRIMARY KEY,
+			customer_id INTEGER NOT NULL,
+			machine_id TEXT,
+			host_id TEXT,
+			status TEXT DEFAULT 'pending',
+			gpu_count INTEGER DEFAULT 0,
+			assigned_gpus TEXT,
+			started_at DATETIME,
+			ended_at DATETIME
+		)`,
+		`CREATE TABLE usage_records (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			customer_id INTEGER NOT NULL,
+			workspace_id INTEGER,
+			source_type TEXT NOT NULL,
+			source_id TEXT NOT NULL,
+			host_id TEXT,
+			gpu_model TEXT,
+			gpu_count INTEGER NOT NULL,
+			start_time DATETIME NOT NULL,
+			end_time DATETIME NOT NULL,
+			duration_seconds INTEGER NOT NULL DEFAULT 0,
+			gpu_hours REAL NOT NULL DEFAULT 0,
+			unit_price REAL NOT NULL DEFAULT 0,
+			amount REAL NOT NULL DEFAULT 0,
+			currency TEXT DEFAULT 'CNY',
+			invoice_id INTEGER,
+			created_at DATETIME,
+			updated_at DATETIME
+		)`,
+		`CREATE TABLE invoices (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			invoice_no TEXT NOT NULL UNIQUE,
+			customer_id INTEGER NOT NULL,
+			billing_period_start DATETIME NOT NULL,
+			billing_period_end DATETIME NOT NULL,
+			total_gpu_hours REAL NOT NULL DEFAULT 0,
+			total_amount REAL NOT NULL,
+			currency TEXT DEFAULT 'CNY',
+			status TEXT DEFAULT 'pending',
+			paid_at DATETIME,
+			created_at DATETIME,
+			updated_at DATETIME,
+			UNIQUE (customer_id, billing_period_start)
+		)`,
+		`CREATE TABLE invoice_items (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			invoice_id INTEGER NOT NULL,
+			source_type TEXT NOT NULL,
+			workspace_id INTEGER,
+			gpu_model TEXT,
+			unit_price REAL NOT NULL DEFAULT 0,
+			gpu_hours REAL NOT NULL DEFAULT 0,
+			amount REAL NOT NULL DEFAULT 0,
+			record_count INTEGER NOT NULL DEFAULT 0
+		)`,
+	} {
+		require.NoError(t, db.Exec(stmt).Error)
+	}
+	return db
+}
+
+func newTestBillingService(db *gorm.DB, now *time.Time) *BillingService {
+	svc := NewBillingService(db, config.BillingConfig{})
+	svc.loc = time.UTC
+	svc.now = func() time.Time { return *now }
+	return svc
+}
+
+func setConfig(t *testing.T, db *gorm.DB, key, value string) {
+	require.NoError(t, db.Exec(`INSERT INTO system_configs (config_key, config_value, config_group) VALUES (?, ?, 'billing')
+		ON CONFLICT (config_key) DO UPDATE SET config_value = excluded.config_value`, key, value).Error)
+}
+
+func seedGPUs(t *testing.T, db *gorm.DB, hostID, name string, count int) {
+	for i := 0; i < count; i++ {
+		require.NoError(t, db.Exec(`INSERT INTO gpus (host_id, "index", name) VALUES (?, ?, ?)`, hostID, i, name).Error)
+	}
+}
+
+func usageOf(t *testing.T, db *gorm.DB, sourceType, sourceID string) []entity.UsageRecord {
+	var records []entity.UsageRecord
+	require.NoError(t, db.Where("source_type = ? AND source_id = ?", sourceType, sourceID).Order("start_time").Find(&records).Error)
+	return records
+}
+
+func TestPriceTable_UnitPrice(t *testing.T) {
+	db := setupBillingTestDB(t)
+	now := time.Now()
+	svc := newTestBillingService(db, &now)
+	ctx := context.Background()
+
+	prices, err := svc.Prices(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "CNY", prices.Currency)
+	assert.Equal(t, 0.0, prices.UnitPrice(entity.UsageSourceAllocation, "NVIDIA A100"))
+
+	setConfig(t, db, configKeyGPUPrices, `{"default": 2, "A100": 10, "A100-SXM4-80GB": 15}`)
+	setConfig(t, db, configKeySources, "allocation, environment")
+	setConfig(t, db, configKeyCurrency, "USD")
+	prices, err = svc.Prices(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "USD", prices.Currency)
+	assert.Equal(t, 10.0, prices.UnitPrice(entity.UsageSourceAllocation, "NVIDIA A100-PCIE-40GB"))
+	assert.Equal(t, 15.0, prices.UnitPrice(entity.UsageSourceEnvironment, "NVIDIA a100-sxm4-80gb"))
+	assert.Equal(t, 2.0, prices.UnitPrice(entity.UsageSourceAllocation, "NVIDIA RTX 4090"))
+	assert.Equal(t, 0.0, prices.UnitPrice(entity.UsageSourceTask, "NVIDIA A100-PCIE-40GB"))
+
+	setConfig(t, db, configKeyGPUPrices, `{"A100": "ten"}`)
+	_, err = svc.Prices(ctx)
+	assert.ErrorIs(t, err, ErrInvalidPriceTable)
+	setConfig(t, db, configKeyGPUPrices, `{"A100": -1}`)
+	_, err = svc.Prices(ctx)
+	assert.ErrorIs(t, err, ErrInvalidPriceTable)
+}
+
+func TestMeter_SplitsByDayAndExtendsOpenRecord(t *testing.T) {
+	db := setupBillingTestDB(t)
+	now := time.Date(2026, 9, 2, 1, 0, 0, 0, time.UTC)
+	svc := newTestBillingService(db, &now)
+	ctx := context.Background()
+
+	setConfig(t, db, configKeyGPUPrices, `{"default": 1, "A100": 3}`)
+	seedGPUs(t, db, "h1", "NVIDIA A100-SXM4-40GB", 4)
+	require.NoError(t, db.Exec(`INSERT INTO allocations (id, customer_id, host_id, workspace_id, gpu_count, start_time, end_time, status)
+		VALUES ('a1', 1, 'h1', 7, 2, ?, ?, 'active')`, time.Date(2026, 9, 1, 22, 0, 0, 0, time.UTC), now.AddDate(0, 1, 0)).Error)
+
+	metered, err := svc.Meter(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, metered)
+
+	records := usageOf(t, db, entity.UsageSourceAllocation, "a1")
+	require.Len(t, records, 2)
+	assert.Equal(t, int64(2*3600), records[0].DurationSeconds)
+	assert.Equal(t, 4.0, records[0].GPUHours)
+	assert.Equal(t, 12.0, records[0].Amount)
+	assert.Equal(t, "NVIDIA A100-SXM4-40GB", records[0].GPUModel)
+	require.NotNil(t, records[0].WorkspaceID)
+	assert.Equal(t, uint(7), *records[0].WorkspaceID)
+	assert.True(t, records[1].StartTime.Equal(time.Date(2026, 9, 2, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, 2.0, records[1].GPUHours)
+
+	// 同一天内继续使用时延长当天的记录
+	now = now.Add(90 * time.Minute)
+	_, err = svc.Meter(ctx)
+	require.NoError(t, err)
+	records = usageOf(t, db, entity.UsageSourceAllocation, "a1")
+	require.Len(t, records, 2)
+	assert.Equal(t, int64(150*60), records[1].DurationSeconds)
+	assert.Equal(t, 5.0, records[1].GPUHours)
+	assert.Equal(t, 15.0, records[1].Amount)
+
+	// 单价变化后另起一条记录，已记录的用量保持原单价
+	setConfig(t, db, configKeyGPUPrices, `{"A100": 4}`)
+	now = now.Add(time.Hour)
+	_, err = svc.Meter(ctx)
+	require.NoError(t, err)
+	records = usageOf(t, db, entity.UsageSourceAllocation, "a1")
+	require.Len(t, records, 3)
+	assert.Equal(t, 15.0, records[1].Amount)
+	assert.Equal(t, 4.0, records[2].UnitPrice)
+	assert.Equal(t, 8.0, records[2].Amount)
+
+	// 回收后记录到实际结束时间为止
+	end := now.Add(30 * time.Minute)
+	require.NoError(t, db.Exec(`UPDATE allocations SET status = 'reclaimed', actual_end_time = ? WHERE id = 'a1'`, end).Error)
+	now = now.Add(2 * time.Hour)
+	_, err = svc.Meter(ctx)
+	require.NoError(t, err)
+	records = usageOf(t, db, entity.UsageSourceAllocation, "a1")
+	require.Len(t, records, 3)
+	assert.True(t, records[2].EndTime.Equal(end))
+	metered, err = svc.Meter(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, metered)
+}
+
+func TestMeter_EnvironmentsAndTasks(t *testing.T) {
+	db := setupBillingTestDB(t)
+	now := time.Date(2026, 9, 10, 12, 0, 0, 0, time.UTC)
+	svc := newTestBillingService(db, &now)
+	ctx := context.Background()
+
+	setConfig(t, db, configKeyGPUPrices, `{"default": 2}`)
+	seedGPUs(t, db, "h1", "NVIDIA RTX 4090", 8)
+	started := now.Add(-time.Hour)
+	stopped := now.Add(-30 * time.Minute)
+	require.NoError(t, db.Exec(`INSERT INTO environments (id, user_id, workspace_id, host_id, status, gpu, started_at, stopped_at) VALUES
+		('env-run', 1, 3, 'h1', 'running', 1, ?, NULL),
+		('env-stop', 1, NULL, 'h1', 'stopped', 2, ?, ?),
+		('env-cpu', 1, NULL, 'h1', 'running', 0, ?, NULL)`, started, started, stopped, started).Error)
+	require.NoError(t, db.Exec(`INSERT INTO tasks (id, customer_id, machine_id, status, gpu_count, assigned_gpus, started_at, ended_at) VALUES
+		('t-done', 2, 'h1', 'completed', 0, '[0,1]', ?, ?),
+		('t-cpu', 2, 'h1', 'running', 0, NULL, ?, NULL),
+		('t-old', 2, 'h1', 'completed', 1, NULL, ?, ?)`,
+		started, stopped, started, now.AddDate(0, 0, -10), now.AddDate(0, 0, -9)).Error)
+
+	_, err := svc.Meter(ctx)
+	require.NoError(t, err)
+
+	running := usageOf(t, db, entity.UsageSourceEnvironment, "env-run")
+	require.Len(t, running, 1)
+	assert.Equal(t, 1.0, running[0].GPUHours)
+	assert.Equal(t, 0.0, running[0].Amount, "默认只对机器分配计费")
+	stoppedEnv := usageOf(t, db, entity.UsageSourceEnvironment, "env-stop")
+	require.Len(t, stoppedEnv, 1)
+	assert.Equal(t, 1.0, stoppedEnv[0].GPUHours)
+	assert.Empty(t, usageOf(t, db, entity.UsageSourceEnvironment, "env-cpu"))
+
+	done := usageOf(t, db, entity.UsageSourceTask, "t-done")
+	require.Len(t, done, 1)
+	assert.Equal(t, 2, done[0].GPUCount)
+	assert.Equal(t, uint(2), done[0].CustomerID)
+	assert.Empty(t, usageOf(t, db, entity.UsageSourceTask, "t-cpu"))
+	assert.Empty(t, usageOf(t, db, entity.UsageSourceTask, "t-old"), "超出回看时长的资源不再计量")
+
+	setConfig(t, db, configKeySources, "environment,task")
+	now = now.Add(time.Hour)
+	_, err = svc.Meter(ctx)
+	require.NoError(t, err)
+	running = usageOf(t, db, entity.UsageSourceEnvironment, "env-run")
+	require.Len(t, running, 2)
+	assert.Equal(t, 2.0, running[1].Amount)
+
+	statement, err := svc.Statement(ctx, dao.UsageFilter{CustomerID: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 3.0, statement["total_gpu_hours"])
+	assert.Equal(t, 2.0, statement["total_amount"])
+}
+
+func TestGenerateInvoices(t *testing.T) {
+	db := setupBillingTestDB(t)
+	now := time.Date(2026, 10, 1, 0, 30, 0, 0, time.UTC)
+	svc := newTestBillingService(db, &now)
+	ctx := context.Background()
+
+	setConfig(t, db, configKeyGPUPrices, `{"default": 1}`)
+	seedGPUs(t, db, "h1", "NVIDIA A100", 2)
+	require.NoError(t, db.Exec(`INSERT INTO allocations (id, customer_id, host_id, gpu_count, start_time, end_time, status)
+		VALUES ('a1', 1, 'h1', 2, ?, ?, 'active')`, time.Date(2026, 9, 30, 20, 0, 0, 0, time.UTC), now.AddDate(0, 1, 0)).Error)
+	_, err := svc.Meter(ctx)
+	require.NoError(t, err)
+
+	_, err = svc.GenerateInvoices(ctx, now)
+	assert.ErrorIs(t, err, ErrPeriodNotEnded)
+
+	assert.Equal(t, 1, svc.generateDue(ctx))
+	assert.Equal(t, 0, svc.generateDue(ctx), "同一账期不重复生成")
+
+	var invoices []entity.Invoice
+	require.NoError(t, db.Preload("Items").Find(&invoices).Error)
+	require.Len(t, invoices, 1)
+	inv := invoices[0]
+	assert.Equal(t, "INV-202609-000001", inv.InvoiceNo)
+	assert.Equal(t, 8.0, inv.TotalGPUHours)
+	assert.Equal(t, 8.0, inv.TotalAmount)
+	require.Len(t, inv.Items, 1)
+	assert.Equal(t, entity.UsageSourceAllocation, inv.Items[0].SourceType)
+	assert.Equal(t, 1, inv.Items[0].RecordCount)
+
+	// 10 月的用量不计入 9 月账单，已出账的记录不再被延长
+	records := usageOf(t, db, entity.UsageSourceAllocation, "a1")
+	require.Len(t, records, 2)
+	require.NotNil(t, records[0].InvoiceID)
+	assert.Equal(t, inv.ID, *records[0].InvoiceID)
+	assert.Nil(t, records[1].InvoiceID)
+
+	// 账单生成后才记录的 9 月用量计入下一期
+	require.NoError(t, db.Exec(`INSERT INTO tasks (id, customer_id, machine_id, status, gpu_count, started_at, ended_at)
+		VALUES ('t1', 1, 'h1', 'completed', 1, ?, ?)`, time.Date(2026, 9, 30, 22, 0, 0, 0, time.UTC), time.Date(2026, 9, 30, 23, 0, 0, 0, time.UTC)).Error)
+	_, err = svc.Meter(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, svc.generateDue(ctx))
+
+	now = time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+	generated, err := svc.GenerateInvoices(ctx, time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, 1, generated)
+	var october entity.Invoice
+	require.NoError(t, db.Preload("Items").Where("invoice_no = ?", "INV-202610-000001").First(&october).Error)
+	assert.Len(t, october.Items, 2)
+	assert.Equal(t, 2.0, october.TotalGPUHours, "10 月 00:00-00:30 的分配用量加上补记的 9 月任务用量")
+
+	// 客户只能查看自己的账单
+	other := uint(2)
+	_, err = svc.GetInvoice(ctx, inv.ID, &other)
+	assert.ErrorIs(t, err, ErrInvoiceNotFound)
+	owner := uint(1)
+	got, err := svc.GetInvoice(ctx, inv.ID, &owner)
+	require.NoError(t, err)
+	assert.Len(t, got.Items, 1)
+
+	usage, err := svc.InvoiceUsage(ctx, inv.ID)
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, WriteUsageCSV(&buf, usage))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.True(t, strings.HasPrefix(lines[0], "id,customer_id,workspace_id,source_type"))
+	assert.Contains(t, lines[1], ",allocation,a1,h1,NVIDIA A100,2,2026-09-30T20:00:00Z,2026-10-01T00:00:00Z,14400,8.0000,1.0000,8.0000,CNY,")
+}
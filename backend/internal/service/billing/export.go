@@ -0,0 +1,59 @@
+package billing
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+)
+
+var usageCSVHeader = []string{
+	"id", "customer_id", "workspace_id", "source_type", "source_id", "host_id", "gpu_model", "gpu_count",
+	"start_time", "end_time", "duration_seconds", "gpu_hours", "unit_price", "amount", "currency", "invoice_id",
+}
+
+// WriteUsageCSV 将用量记录导出为 CSV
+func WriteUsageCSV(w io.Writer, records []entity.UsageRecord) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(usageCSVHeader); err != nil {
+		return err
+	}
+	for _, r := range records {
+		row := []string{
+			strconv.FormatUint(uint64(r.ID), 10),
+			strconv.FormatUint(uint64(r.CustomerID), 10),
+			optionalID(r.WorkspaceID),
+			r.SourceType,
+			r.SourceID,
+			r.HostID,
+			r.GPUModel,
+			strconv.Itoa(r.GPUCount),
+			r.StartTime.Format(time.RFC3339),
+			r.EndTime.Format(time.RFC3339),
+			strconv.FormatInt(r.DurationSeconds, 10),
+			formatDecimal(r.GPUHours),
+			formatDecimal(r.UnitPrice),
+			formatDecimal(r.Amount),
+			r.Currency,
+			optionalID(r.InvoiceID),
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func optionalID(id *uint) string {
+	if id == nil {
+		return ""
+	}
+	return strconv.FormatUint(uint64(*id), 10)
+}
+
+func formatDecimal(v float64) string {
+	return strconv.FormatFloat(v, 'f', 4, 64)
+}
@@ -0,0 +1,127 @@
+package billing
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/YoungBoyGod/remotegpu/internal/dao"
+	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+	"github.com/YoungBoyGod/remotegpu/pkg/logger"
+	"gorm.io/gorm"
+)
+
+// itemKey 账单明细的汇总维度
+type itemKey struct {
+	sourceType  string
+	workspaceID uint // 0 表示不属于工作空间
+	gpuModel    string
+	unitPrice   float64
+}
+
+// GenerateInvoices 为 period 所在月份生成账单，返回生成数
+// 每个客户汇总账期结束前所有未出账的用量（账期开始前遗漏的用量一并计入），已有该账期账单的客户跳过，
+// 账单生成后才记录的该月用量计入下一期账单
+func (s *BillingService) GenerateInvoices(ctx context.Context, period time.Time) (int, error) {
+	periodStart := s.monthStart(period)
+	periodEnd := periodStart.AddDate(0, 1, 0)
+	if periodEnd.After(s.now()) {
+		return 0, ErrPeriodNotEnded
+	}
+
+	customerIDs, err := s.billingDao.CustomersWithUnbilledUsage(ctx, periodEnd)
+	if err != nil {
+		return 0, err
+	}
+
+	generated := 0
+	for _, customerID := range customerIDs {
+		ok, err := s.generateInvoice(ctx, customerID, periodStart, periodEnd)
+		if err != nil {
+			logger.GetLogger().Warn(fmt.Sprintf("生成客户 %d %s 账单失败: %v", customerID, periodStart.Format("2006-01"), err))
+			continue
+		}
+		if ok {
+			generated++
+		}
+	}
+	return generated, nil
+}
+
+func (s *BillingService) generateInvoice(ctx context.Context, customerID uint, periodStart, periodEnd time.Time) (bool, error) {
+	exists, err := s.billingDao.InvoiceExists(ctx, customerID, periodStart)
+	if err != nil || exists {
+		return false, err
+	}
+	records, err := s.billingDao.ListUnbilledUsage(ctx, customerID, periodEnd)
+	if err != nil || len(records) == 0 {
+		return false, err
+	}
+
+	invoice := &entity.Invoice{
+		InvoiceNo:          fmt.Sprintf("INV-%s-%06d", periodStart.Format("200601"), customerID),
+		CustomerID:         customerID,
+		BillingPeriodStart: periodStart,
+		BillingPeriodEnd:   periodEnd,
+		Currency:           records[0].Currency,
+		Status:             entity.InvoiceStatusPending,
+	}
+	items := make(map[itemKey]*entity.InvoiceItem)
+	var order []itemKey
+	recordIDs := make([]uint, 0, len(records))
+	for _, r := range records {
+		key := itemKey{sourceType: r.SourceType, gpuModel: r.GPUModel, unitPrice: r.UnitPrice}
+		if r.WorkspaceID != nil {
+			key.workspaceID = *r.WorkspaceID
+		}
+		item, ok := items[key]
+		if !ok {
+			item = &entity.InvoiceItem{
+				SourceType:  r.SourceType,
+				WorkspaceID: r.WorkspaceID,
+				GPUModel:    r.GPUModel,
+				UnitPrice:   r.UnitPrice,
+			}
+			items[key] = item
+			order = append(order, key)
+		}
+		item.GPUHours += r.GPUHours
+		item.Amount += r.Amount
+		item.RecordCount++
+		recordIDs = append(recordIDs, r.ID)
+	}
+	for _, key := range order {
+		item := items[key]
+		item.GPUHours = round4(item.GPUHours)
+		item.Amount = round4(item.Amount)
+		invoice.TotalGPUHours += item.GPUHours
+		invoice.TotalAmount += item.Amount
+		invoice.Items = append(invoice.Items, *item)
+	}
+	invoice.TotalGPUHours = round4(invoice.TotalGPUHours)
+	invoice.TotalAmount = round4(invoice.TotalAmount)
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		return dao.NewBillingDao(tx).CreateInvoice(ctx, invoice, recordIDs)
+	})
+	if err != nil {
+		return false, err
+	}
+	logger.GetLogger().Info(fmt.Sprintf("已生成账单 %s，客户 %d，金额 %.4f %s", invoice.InvoiceNo, customerID, invoice.TotalAmount, invoice.Currency))
+	return true, nil
+}
+
+// generateDue 为上一个自然月生成账单
+func (s *BillingService) generateDue(ctx context.Context) int {
+	lastMonth := s.monthStart(s.now()).AddDate(0, -1, 0)
+	generated, err := s.GenerateInvoices(ctx, lastMonth)
+	if err != nil {
+		logger.GetLogger().Warn(fmt.Sprintf("生成月度账单失败: %v", err))
+	}
+	return generated
+}
+
+func (s *BillingService) monthStart(t time.Time) time.Time {
+	t = t.In(s.loc)
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, s.loc)
+}
@@ -0,0 +1,244 @@
+package billing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+	"github.com/YoungBoyGod/remotegpu/pkg/logger"
+)
+
+// usageSpan 资源的 GPU 使用区间，结束时间为当前时间表示仍在使用
+type usageSpan struct {
+	customerID  uint
+	workspaceID *uint
+	sourceType  string
+	sourceID    string
+	hostID      string
+	gpuCount    int // 为 0 时按机器 GPU 数量计（整机分配的旧数据）
+	start       time.Time
+	end         time.Time
+}
+
+// hostGPUs 机器的 GPU 型号和数量
+type hostGPUs struct {
+	model string
+	count int
+}
+
+// Meter 计量一轮：从每个资源上次记录的结束时间记录到当前（或资源结束）时间，返回新增或延长的资源数
+func (s *BillingService) Meter(ctx context.Context) (int, error) {
+	prices, err := s.Prices(ctx)
+	if err != nil {
+		return 0, err
+	}
+	now := s.now().Truncate(time.Second)
+	spans, err := s.collectSpans(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+
+	hosts := make(map[string]hostGPUs)
+	metered := 0
+	for i := range spans {
+		ok, err := s.record(ctx, prices, &spans[i], now, hosts)
+		if err != nil {
+			logger.GetLogger().Warn(fmt.Sprintf("记录 %s %s 用量失败: %v", spans[i].sourceType, spans[i].sourceID, err))
+			continue
+		}
+		if ok {
+			metered++
+		}
+	}
+	return metered, nil
+}
+
+// collectSpans 收集活跃中或最近结束的分配、环境、任务的使用区间
+func (s *BillingService) collectSpans(ctx context.Context, now time.Time) ([]usageSpan, error) {
+	since := now.Add(-s.lookback)
+	var spans []usageSpan
+
+	allocs, err := s.billingDao.ListMeterableAllocations(ctx, since)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range allocs {
+		end := now
+		if a.Status != "active" && a.ActualEndTime != nil {
+			end = *a.ActualEndTime
+		}
+		spans = append(spans, usageSpan{
+			customerID:  a.CustomerID,
+			workspaceID: a.WorkspaceID,
+			sourceType:  entity.UsageSourceAllocation,
+			sourceID:    a.ID,
+			hostID:      a.HostID,
+			gpuCount:    a.GPUCount,
+			start:       a.StartTime,
+			end:         end,
+		})
+	}
+
+	envs, err := s.billingDao.ListMeterableEnvironments(ctx, since)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range envs {
+		end := now
+		if e.Status != "running" {
+			if e.StoppedAt == nil {
+				continue
+			}
+			end = *e.StoppedAt
+		}
+		spans = append(spans, usageSpan{
+			customerID:  e.UserID,
+			workspaceID: e.WorkspaceID,
+			sourceType:  entity.UsageSourceEnvironment,
+			sourceID:    e.ID,
+			hostID:      e.HostID,
+			gpuCount:    e.GPU,
+			start:       *e.StartedAt,
+			end:         end,
+		})
+	}
+
+	tasks, err := s.billingDao.ListMeterableTasks(ctx, since)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range tasks {
+		gpuCount := t.GPUCount
+		if gpuCount == 0 && len(t.AssignedGPUs) > 0 {
+			var assigned []int
+			if json.Unmarshal(t.AssignedGPUs, &assigned) == nil {
+				gpuCount = len(assigned)
+			}
+		}
+		if gpuCount == 0 {
+			continue // 不占用 GPU 的任务不计量
+		}
+		hostID := t.MachineID
+		if hostID == "" {
+			hostID = t.HostID
+		}
+		end := now
+		if t.EndedAt != nil {
+			end = *t.EndedAt
+		}
+		spans = append(spans, usageSpan{
+			customerID: t.CustomerID,
+			sourceType: entity.UsageSourceTask,
+			sourceID:   t.ID,
+			hostID:     hostID,
+			gpuCount:   gpuCount,
+			start:      *t.StartedAt,
+			end:        end,
+		})
+	}
+	return spans, nil
+}
+
+// record 记录资源未计量的使用区间，按自然日切分；与上一条记录连续、同一天且单价不变时延长上一条记录
+func (s *BillingService) record(ctx context.Context, prices *PriceTable, span *usageSpan, now time.Time, hosts map[string]hostGPUs) (bool, error) {
+	last, err := s.billingDao.LastUsage(ctx, span.sourceType, span.sourceID)
+	if err != nil {
+		return false, err
+	}
+	start := span.start.Truncate(time.Second)
+	if last != nil && last.EndTime.After(start) {
+		start = last.EndTime
+	}
+	end := span.end.Truncate(time.Second)
+	if end.After(now) {
+		end = now
+	}
+	if !end.After(start) {
+		return false, nil
+	}
+
+	host, ok := hosts[span.hostID]
+	if !ok {
+		host.model, host.count, err = s.billingDao.HostGPUs(ctx, span.hostID)
+		if err != nil {
+			return false, err
+		}
+		hosts[span.hostID] = host
+	}
+	gpuCount := span.gpuCount
+	if gpuCount == 0 {
+		gpuCount = host.count
+	}
+	if gpuCount == 0 {
+		return false, nil
+	}
+	price := round4(prices.UnitPrice(span.sourceType, host.model))
+
+	for start.Before(end) {
+		segEnd := s.nextDay(start)
+		if segEnd.After(end) {
+			segEnd = end
+		}
+
+		if last != nil && last.InvoiceID == nil && last.EndTime.Equal(start) &&
+			s.dayStart(last.StartTime).Equal(s.dayStart(start)) &&
+			last.UnitPrice == price && last.GPUCount == gpuCount && last.GPUModel == host.model {
+			last.EndTime = segEnd
+			fillUsage(last)
+			extended, err := s.billingDao.ExtendUsage(ctx, last)
+			if err != nil {
+				return false, err
+			}
+			if extended {
+				start = segEnd
+				continue
+			}
+			// 上一条记录已出账，剩余用量另起一条
+		}
+
+		rec := &entity.UsageRecord{
+			CustomerID:  span.customerID,
+			WorkspaceID: span.workspaceID,
+			SourceType:  span.sourceType,
+			SourceID:    span.sourceID,
+			HostID:      span.hostID,
+			GPUModel:    host.model,
+			GPUCount:    gpuCount,
+			StartTime:   start,
+			EndTime:     segEnd,
+			UnitPrice:   price,
+			Currency:    prices.Currency,
+		}
+		fillUsage(rec)
+		if err := s.billingDao.CreateUsage(ctx, rec); err != nil {
+			return false, err
+		}
+		last = rec
+		start = segEnd
+	}
+	return true, nil
+}
+
+// fillUsage 根据起止时间计算时长、GPU 小时和金额
+func fillUsage(r *entity.UsageRecord) {
+	r.DurationSeconds = int64(r.EndTime.Sub(r.StartTime) / time.Second)
+	gpuHours := float64(r.DurationSeconds) * float64(r.GPUCount) / 3600
+	r.GPUHours = round4(gpuHours)
+	r.Amount = round4(gpuHours * r.UnitPrice)
+}
+
+func (s *BillingService) dayStart(t time.Time) time.Time {
+	t = t.In(s.loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, s.loc)
+}
+
+func (s *BillingService) nextDay(t time.Time) time.Time {
+	return s.dayStart(t).AddDate(0, 0, 1)
+}
+
+func round4(v float64) float64 {
+	return math.Round(v*10000) / 10000
+}
@@ -0,0 +1,118 @@
+package billing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+	"gorm.io/gorm"
+)
+
+// 价格表相关的系统配置键（billing 分组）
+const (
+	configKeyGPUPrices = "billing_gpu_prices"
+	configKeyCurrency  = "billing_currency"
+	configKeySources   = "billing_sources"
+)
+
+const defaultCurrency = "CNY"
+
+// PriceTable 价格表，单价为每 GPU 小时
+// GPU 型号名包含 Models 中的键即匹配（不区分大小写，取最长的键），未匹配时使用 Default；
+// 不在 Sources 中的用量来源只计量不计费（如任务运行在已分配的机器上，费用已包含在分配中）
+type PriceTable struct {
+	Currency string             `json:"currency"`
+	Default  float64            `json:"default"`
+	Models   map[string]float64 `json:"models"`
+	Sources  []string           `json:"sources"`
+}
+
+// UnitPrice 计算用量来源和 GPU 型号对应的单价
+func (p *PriceTable) UnitPrice(sourceType, gpuModel string) float64 {
+	billable := false
+	for _, s := range p.Sources {
+		if s == sourceType {
+			billable = true
+			break
+		}
+	}
+	if !billable {
+		return 0
+	}
+
+	price, matched := p.Default, ""
+	model := strings.ToLower(gpuModel)
+	for key, v := range p.Models {
+		if len(key) > len(matched) && strings.Contains(model, strings.ToLower(key)) {
+			price, matched = v, key
+		}
+	}
+	return price
+}
+
+// Prices 从系统配置读取当前价格表，配置项不存在时使用默认值（不计费）
+func (s *BillingService) Prices(ctx context.Context) (*PriceTable, error) {
+	table := &PriceTable{
+		Currency: defaultCurrency,
+		Models:   map[string]float64{},
+		Sources:  []string{entity.UsageSourceAllocation},
+	}
+
+	raw, err := s.configValue(ctx, configKeyGPUPrices)
+	if err != nil {
+		return nil, err
+	}
+	if raw != "" {
+		var prices map[string]float64
+		if err := json.Unmarshal([]byte(raw), &prices); err != nil {
+			return nil, fmt.Errorf("%w: %s 不是合法的 JSON 对象: %v", ErrInvalidPriceTable, configKeyGPUPrices, err)
+		}
+		for key, v := range prices {
+			if v < 0 {
+				return nil, fmt.Errorf("%w: %s 的单价不能为负数", ErrInvalidPriceTable, key)
+			}
+			if key == "default" {
+				table.Default = v
+			} else {
+				table.Models[key] = v
+			}
+		}
+	}
+
+	currency, err := s.configValue(ctx, configKeyCurrency)
+	if err != nil {
+		return nil, err
+	}
+	if currency = strings.TrimSpace(currency); currency != "" {
+		table.Currency = currency
+	}
+
+	sources, err := s.configValue(ctx, configKeySources)
+	if err != nil {
+		return nil, err
+	}
+	if sources != "" {
+		table.Sources = nil
+		for _, src := range strings.Split(sources, ",") {
+			if src = strings.TrimSpace(src); src != "" {
+				table.Sources = append(table.Sources, src)
+			}
+		}
+	}
+	return table, nil
+}
+
+// configValue 读取系统配置值，不存在时返回空字符串
+func (s *BillingService) configValue(ctx context.Context, key string) (string, error) {
+	config, err := s.configDao.GetByKey(ctx, key)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", nil
+		}
+		return "", err
+	}
+	return config.ConfigValue, nil
+}
@@ -0,0 +1,46 @@
+package billing
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/YoungBoyGod/remotegpu/pkg/logger"
+)
+
+// Scheduler 用量计量调度，每轮先计量再为上一个自然月生成账单
+type Scheduler struct {
+	service  *BillingService
+	interval time.Duration
+}
+
+// NewScheduler 创建计量调度
+func NewScheduler(svc *BillingService, interval time.Duration) *Scheduler {
+	if interval <= 0 {
+		interval = 5 * time.Minute
+	}
+	return &Scheduler{service: svc, interval: interval}
+}
+
+// Start 启动计量调度
+func (s *Scheduler) Start(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	logger.GetLogger().Info("用量计量服务已启动")
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.GetLogger().Info("用量计量服务已停止")
+			return
+		case <-ticker.C:
+			if _, err := s.service.Meter(ctx); err != nil {
+				// 价格表不合法时不计量，避免按错误单价记录
+				logger.GetLogger().Warn(fmt.Sprintf("用量计量失败: %v", err))
+				continue
+			}
+			s.service.generateDue(ctx)
+		}
+	}
+}
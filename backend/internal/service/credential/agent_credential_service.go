@@ -0,0 +1,252 @@
+package credential
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/YoungBoyGod/remotegpu/config"
+	"github.com/YoungBoyGod/remotegpu/internal/dao"
+	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+	"github.com/YoungBoyGod/remotegpu/pkg/crypto"
+	"github.com/YoungBoyGod/remotegpu/pkg/logger"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrAgentCredentialInvalid = errors.New("invalid agent credential")
+	ErrEnrollmentTokenExpired = errors.New("enrollment token expired")
+	ErrEnrollmentTokenUsed    = errors.New("enrollment token already used")
+)
+
+const (
+	defaultEnrollmentTokenTTL = 24 * time.Hour
+	// lastUsedInterval 最近使用时间的刷新间隔，避免每次心跳都写库
+	lastUsedInterval = time.Minute
+	// controlKeyLabel 派生控制接口签名密钥的用途标识，与 Agent 端 security 包保持一致
+	controlKeyLabel = "remotegpu-agent-control"
+)
+
+// AgentIdentity 通过认证的 Agent 身份
+type AgentIdentity struct {
+	CredentialID uint
+	HostID       string
+	AgentID      string
+	// Enrollment 使用一次性注册令牌认证，只能调用注册接口换取机器密钥
+	Enrollment bool
+	tokenHash  string
+}
+
+// AgentCredentialService 机器级 Agent 凭证管理
+type AgentCredentialService struct {
+	db            *gorm.DB
+	credentialDao *dao.AgentCredentialDao
+	enrollmentTTL time.Duration
+	now           func() time.Time
+}
+
+func NewAgentCredentialService(db *gorm.DB) *AgentCredentialService {
+	ttl := defaultEnrollmentTokenTTL
+	if config.GlobalConfig != nil && config.GlobalConfig.Agent.EnrollmentTokenTTL > 0 {
+		ttl = time.Duration(config.GlobalConfig.Agent.EnrollmentTokenTTL) * time.Second
+	}
+	return &AgentCredentialService{
+		db:            db,
+		credentialDao: dao.NewAgentCredentialDao(db),
+		enrollmentTTL: ttl,
+		now:           time.Now,
+	}
+}
+
+// IssueEnrollmentToken 为主机签发一次性注册令牌
+// 已有的机器密钥在 Agent 兑换新令牌前保持有效、兑换后立即失效，因此也用于密钥轮换
+func (s *AgentCredentialService) IssueEnrollmentToken(ctx context.Context, hostID string) (string, *entity.AgentCredential, error) {
+	var host entity.Host
+	if err := s.db.WithContext(ctx).Select("id").First(&host, "id = ?", hostID).Error; err != nil {
+		return "", nil, fmt.Errorf("查询主机失败: %w", err)
+	}
+
+	token, err := generateAgentToken("ret_")
+	if err != nil {
+		return "", nil, err
+	}
+
+	expiresAt := s.now().Add(s.enrollmentTTL)
+	tokenHash := hashAgentToken(token)
+
+	cred, err := s.credentialDao.FindByHostID(ctx, hostID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		cred = &entity.AgentCredential{
+			HostID:              hostID,
+			Status:              "pending",
+			EnrollmentTokenHash: tokenHash,
+			EnrollmentExpiresAt: &expiresAt,
+		}
+		if err := s.credentialDao.Create(ctx, cred); err != nil {
+			return "", nil, err
+		}
+		return token, cred, nil
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	fields := map[string]interface{}{
+		"enrollment_token_hash": tokenHash,
+		"enrollment_expires_at": expiresAt,
+		"revoked_at":            nil,
+	}
+	if cred.Status != "active" {
+		fields["status"] = "pending"
+	}
+	if err := s.credentialDao.Updates(ctx, cred.ID, fields); err != nil {
+		return "", nil, err
+	}
+	if cred, err = s.credentialDao.FindByHostID(ctx, hostID); err != nil {
+		return "", nil, err
+	}
+	return token, cred, nil
+}
+
+// Enroll 使用注册令牌换取机器专属密钥，并绑定 Agent ID
+// 新密钥覆盖主机原有的机器密钥，旧密钥及由其派生的控制签名密钥随之作废
+func (s *AgentCredentialService) Enroll(ctx context.Context, identity *AgentIdentity, agentID string) (string, error) {
+	if identity == nil || !identity.Enrollment {
+		return "", ErrAgentCredentialInvalid
+	}
+
+	secret, err := generateAgentToken("ras_")
+	if err != nil {
+		return "", err
+	}
+	controlKey, err := crypto.EncryptAES256GCM(deriveControlKey(secret))
+	if err != nil {
+		return "", fmt.Errorf("encrypt control key: %w", err)
+	}
+
+	now := s.now()
+	ok, err := s.credentialDao.ConsumeEnrollment(ctx, identity.CredentialID, identity.tokenHash, map[string]interface{}{
+		"agent_id":              agentID,
+		"status":                "active",
+		"secret_hash":           hashAgentToken(secret),
+		"control_key":           controlKey,
+		"secret_issued_at":      now,
+		"enrollment_token_hash": "",
+		"enrollment_expires_at": nil,
+		"last_used_at":          now,
+	})
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", ErrEnrollmentTokenUsed
+	}
+	return secret, nil
+}
+
+// Authenticate 校验机器密钥或注册令牌，返回对应的 Agent 身份
+func (s *AgentCredentialService) Authenticate(ctx context.Context, token string) (*AgentIdentity, error) {
+	hash := hashAgentToken(token)
+
+	cred, err := s.credentialDao.FindBySecretHash(ctx, hash)
+	if err == nil {
+		now := s.now()
+		if cred.LastUsedAt == nil || now.Sub(*cred.LastUsedAt) > lastUsedInterval {
+			_ = s.credentialDao.Updates(ctx, cred.ID, map[string]interface{}{"last_used_at": now})
+		}
+		return &AgentIdentity{CredentialID: cred.ID, HostID: cred.HostID, AgentID: cred.AgentID}, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	cred, err = s.credentialDao.FindByEnrollmentTokenHash(ctx, hash)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAgentCredentialInvalid
+		}
+		return nil, err
+	}
+	if cred.EnrollmentExpiresAt != nil && s.now().After(*cred.EnrollmentExpiresAt) {
+		return nil, ErrEnrollmentTokenExpired
+	}
+	return &AgentIdentity{
+		CredentialID: cred.ID,
+		HostID:       cred.HostID,
+		AgentID:      cred.AgentID,
+		Enrollment:   true,
+		tokenHash:    hash,
+	}, nil
+}
+
+// Revoke 吊销主机的机器密钥和未使用的注册令牌
+func (s *AgentCredentialService) Revoke(ctx context.Context, hostID string) error {
+	cred, err := s.credentialDao.FindByHostID(ctx, hostID)
+	if err != nil {
+		return err
+	}
+	return s.credentialDao.Updates(ctx, cred.ID, map[string]interface{}{
+		"status":                "revoked",
+		"secret_hash":           "",
+		"control_key":           "",
+		"enrollment_token_hash": "",
+		"enrollment_expires_at": nil,
+		"revoked_at":            s.now(),
+	})
+}
+
+// GetCredential 查询主机凭证状态
+func (s *AgentCredentialService) GetCredential(ctx context.Context, hostID string) (*entity.AgentCredential, error) {
+	return s.credentialDao.FindByHostID(ctx, hostID)
+}
+
+// ControlKey 返回主机 Agent 控制接口的签名密钥（注册时由机器密钥派生并加密保存），
+// Agent 端由本地保存的机器密钥派生出同一密钥；主机没有生效中的机器密钥时返回空字符串
+func (s *AgentCredentialService) ControlKey(ctx context.Context, hostID string) (string, error) {
+	cred, err := s.credentialDao.FindByHostID(ctx, hostID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", nil
+		}
+		return "", err
+	}
+	if cred.Status != "active" {
+		return "", nil
+	}
+	if cred.ControlKey == "" {
+		// 早于签名密钥加密保存的版本注册，需重新签发注册令牌
+		logger.GetLogger().Warn(fmt.Sprintf("主机 %s 的 Agent 凭证没有控制签名密钥，请重新签发注册令牌", hostID))
+		return "", nil
+	}
+	key, err := crypto.DecryptAES256GCM(cred.ControlKey)
+	if err != nil {
+		return "", fmt.Errorf("decrypt control key: %w", err)
+	}
+	return key, nil
+}
+
+// deriveControlKey 由机器密钥派生控制接口签名密钥：HMAC-SHA256(secret, label)，与数据库中的密钥摘要不同
+func deriveControlKey(secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(controlKeyLabel))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// generateAgentToken 生成带前缀的随机令牌，前缀便于在日志和配置中区分令牌类型
+func generateAgentToken(prefix string) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate token: %w", err)
+	}
+	return prefix + hex.EncodeToString(b), nil
+}
+
+func hashAgentToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
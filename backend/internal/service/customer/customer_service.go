@@ -6,6 +6,7 @@ import (
 
 	"github.com/YoungBoyGod/remotegpu/internal/dao"
 	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+	serviceNotification "github.com/YoungBoyGod/remotegpu/internal/service/notification"
 	"github.com/YoungBoyGod/remotegpu/pkg/auth"
 	"gorm.io/gorm"
 )
@@ -19,6 +20,9 @@ type CustomerService struct {
 	allocationDao *dao.AllocationDao
 	datasetDao    *dao.DatasetDao
 	auditDao      *dao.AuditDao
+	workspaceDao  *dao.WorkspaceDao
+	quotaAlertDao *dao.StorageQuotaAlertDao
+	notifier      *serviceNotification.NotificationService
 	db            *gorm.DB
 }
 
@@ -28,6 +32,8 @@ func NewCustomerService(db *gorm.DB) *CustomerService {
 		allocationDao: dao.NewAllocationDao(db),
 		datasetDao:    dao.NewDatasetDao(db),
 		auditDao:      dao.NewAuditDao(db),
+		workspaceDao:  dao.NewWorkspaceDao(db),
+		quotaAlertDao: dao.NewStorageQuotaAlertDao(db),
 		db:            db,
 	}
 }
@@ -49,7 +55,17 @@ func (s *CustomerService) GetCustomer(ctx context.Context, id uint) (*entity.Cus
 	return s.customerDao.FindByID(ctx, id)
 }
 
+// UpdateCustomer 更新客户字段，邮箱变更时清除邮箱验证状态
 func (s *CustomerService) UpdateCustomer(ctx context.Context, id uint, fields map[string]interface{}) error {
+	if email, ok := fields["email"].(string); ok {
+		customer, err := s.customerDao.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if email != customer.Email {
+			fields["email_verified"] = false
+		}
+	}
 	return s.customerDao.UpdateFields(ctx, id, fields)
 }
 
@@ -57,6 +73,22 @@ func (s *CustomerService) UpdateStatus(ctx context.Context, id uint, status stri
 	return s.customerDao.UpdateStatus(ctx, id, status)
 }
 
+// Unlock 解除登录失败锁定，清零失败次数和锁定次数
+func (s *CustomerService) Unlock(ctx context.Context, id uint) error {
+	if _, err := s.customerDao.FindByID(ctx, id); err != nil {
+		return err
+	}
+	return s.customerDao.ResetLoginFailures(ctx, id)
+}
+
+// ResetMFA 重置两步验证（用户丢失验证器和恢复码时由管理员操作），用户下次登录后可重新开通
+func (s *CustomerService) ResetMFA(ctx context.Context, id uint) error {
+	if _, err := s.customerDao.FindByID(ctx, id); err != nil {
+		return err
+	}
+	return s.customerDao.ResetMFA(ctx, id)
+}
+
 // CountActive 统计活跃客户数量
 // @modified 2026-02-04
 func (s *CustomerService) CountActive(ctx context.Context) (int64, error) {
@@ -176,24 +208,5 @@ func (s *CustomerService) CheckGPUQuota(ctx context.Context, customerID uint, ad
 
 // CheckStorageQuota 检查客户存储配额是否允许新增存储
 func (s *CustomerService) CheckStorageQuota(ctx context.Context, customerID uint, additionalMB int64) error {
-	customer, err := s.customerDao.FindByID(ctx, customerID)
-	if err != nil {
-		return err
-	}
-
-	// quota_storage 为 0 表示不限制
-	if customer.QuotaStorage == 0 {
-		return nil
-	}
-
-	currentBytes, err := s.datasetDao.SumStorageByCustomerID(ctx, customerID)
-	if err != nil {
-		return err
-	}
-
-	currentMB := currentBytes / (1024 * 1024)
-	if currentMB+additionalMB > customer.QuotaStorage {
-		return ErrQuotaExceeded
-	}
-	return nil
+	return s.CheckStorageBytes(ctx, customerID, nil, additionalMB*bytesPerMB)
 }
\ No newline at end of file
@@ -62,9 +62,12 @@ func setupCustomerServiceTestDB(t *testing.T) *gorm.DB {
 		customer_id INTEGER NOT NULL,
 		host_id VARCHAR(64) NOT NULL,
 		workspace_id INTEGER,
+		gpu_indexes TEXT,
+		gpu_count INTEGER DEFAULT 0,
 		start_time DATETIME NOT NULL,
 		end_time DATETIME NOT NULL,
 		actual_end_time DATETIME,
+		expiry_warned_at DATETIME,
 		status VARCHAR(32) DEFAULT 'active',
 		remark TEXT,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
@@ -140,6 +143,59 @@ func setupCustomerServiceTestDB(t *testing.T) *gorm.DB {
 	)`).Error
 	require.NoError(t, err)
 
+	err = db.Exec(`CREATE TABLE documents (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		title TEXT NOT NULL,
+		category TEXT NOT NULL DEFAULT 'general',
+		file_name TEXT NOT NULL,
+		file_path TEXT NOT NULL,
+		file_size INTEGER NOT NULL DEFAULT 0,
+		content_type TEXT NOT NULL DEFAULT '',
+		storage_backend TEXT NOT NULL DEFAULT '',
+		uploaded_by INTEGER,
+		created_at DATETIME,
+		updated_at DATETIME
+	)`).Error
+	require.NoError(t, err)
+
+	err = db.Exec(`CREATE TABLE workspaces (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		uuid TEXT,
+		owner_id INTEGER NOT NULL,
+		name TEXT NOT NULL,
+		description TEXT,
+		type TEXT DEFAULT 'personal',
+		member_count INTEGER DEFAULT 1,
+		status TEXT DEFAULT 'active',
+		quota_storage INTEGER DEFAULT 0,
+		created_at DATETIME,
+		updated_at DATETIME
+	)`).Error
+	require.NoError(t, err)
+
+	err = db.Exec(`CREATE TABLE storage_quota_alerts (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		scope TEXT NOT NULL,
+		scope_id INTEGER NOT NULL,
+		level INTEGER NOT NULL DEFAULT 0,
+		updated_at DATETIME,
+		UNIQUE (scope, scope_id)
+	)`).Error
+	require.NoError(t, err)
+
+	err = db.Exec(`CREATE TABLE notifications (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		customer_id INTEGER NOT NULL,
+		title TEXT NOT NULL,
+		content TEXT,
+		type TEXT NOT NULL,
+		level TEXT DEFAULT 'info',
+		is_read INTEGER DEFAULT 0,
+		read_at DATETIME,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`).Error
+	require.NoError(t, err)
+
 	err = db.Exec(`CREATE TABLE audit_logs (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		customer_id INTEGER,
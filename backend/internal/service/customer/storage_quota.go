@@ -0,0 +1,227 @@
+package customer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+	serviceNotification "github.com/YoungBoyGod/remotegpu/internal/service/notification"
+	"github.com/YoungBoyGod/remotegpu/pkg/logger"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrWorkspaceQuotaExceeded = errors.New("已超出工作空间存储配额限制")
+
+const bytesPerMB = 1024 * 1024
+
+// 存储用量提醒级别（占配额百分比）
+var storageWarnLevels = []int{95, 80}
+
+// StorageUsage 客户存储用量明细
+type StorageUsage struct {
+	QuotaMB        int64                   `json:"quota_mb"`        // 存储配额（MB），0 表示不限制
+	UsedBytes      int64                   `json:"used_bytes"`      // 总用量（字节）
+	UsagePercent   float64                 `json:"usage_percent"`   // 占配额百分比，不限制时为 0
+	DatasetBytes   int64                   `json:"dataset_bytes"`   // 已就绪数据集
+	UploadingBytes int64                   `json:"uploading_bytes"` // 上传中的数据集（按声明大小预留）
+	DatasetCount   int64                   `json:"dataset_count"`
+	Workspaces     []WorkspaceStorageUsage `json:"workspaces"`
+}
+
+// WorkspaceStorageUsage 工作空间存储用量
+type WorkspaceStorageUsage struct {
+	WorkspaceID  uint    `json:"workspace_id"`
+	Name         string  `json:"name"`
+	QuotaMB      int64   `json:"quota_mb"`
+	UsedBytes    int64   `json:"used_bytes"` // 工作空间内所有成员的用量
+	OwnBytes     int64   `json:"own_bytes"`  // 当前客户在该工作空间的用量
+	UsagePercent float64 `json:"usage_percent"`
+}
+
+// SetNotificationService 注入通知服务，用于发送存储用量提醒
+func (s *CustomerService) SetNotificationService(n *serviceNotification.NotificationService) {
+	s.notifier = n
+}
+
+func usagePercent(usedBytes, quotaMB int64) float64 {
+	if quotaMB <= 0 {
+		return 0
+	}
+	return float64(usedBytes) * 100 / float64(quotaMB*bytesPerMB)
+}
+
+// storageUsedBytes 客户总存储用量：数据集（含上传中预留）
+// 管理员上传的平台文档不属于客户，不计入用量
+func (s *CustomerService) storageUsedBytes(ctx context.Context, customerID uint) (int64, error) {
+	return s.datasetDao.SumStorageByCustomerID(ctx, customerID)
+}
+
+// GetStorageUsage 获取客户存储用量明细（按数据集状态和工作空间分类）
+func (s *CustomerService) GetStorageUsage(ctx context.Context, customerID uint) (*StorageUsage, error) {
+	customer, err := s.customerDao.FindByID(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := s.datasetDao.StorageStatsByCustomerID(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := &StorageUsage{
+		QuotaMB:    customer.QuotaStorage,
+		Workspaces: []WorkspaceStorageUsage{},
+	}
+	ownByWorkspace := map[uint]int64{}
+	for _, st := range stats {
+		if st.Status == "uploading" {
+			usage.UploadingBytes += st.Bytes
+		} else {
+			usage.DatasetBytes += st.Bytes
+		}
+		usage.DatasetCount += st.Count
+		if st.WorkspaceID != nil {
+			ownByWorkspace[*st.WorkspaceID] += st.Bytes
+		}
+	}
+	usage.UsedBytes = usage.DatasetBytes + usage.UploadingBytes
+	usage.UsagePercent = usagePercent(usage.UsedBytes, usage.QuotaMB)
+
+	for wsID, own := range ownByWorkspace {
+		ws, err := s.workspaceDao.FindByID(ctx, wsID)
+		if err != nil {
+			continue
+		}
+		used, err := s.datasetDao.SumStorageByWorkspaceID(ctx, wsID)
+		if err != nil {
+			return nil, err
+		}
+		usage.Workspaces = append(usage.Workspaces, WorkspaceStorageUsage{
+			WorkspaceID:  wsID,
+			Name:         ws.Name,
+			QuotaMB:      ws.QuotaStorage,
+			UsedBytes:    used,
+			OwnBytes:     own,
+			UsagePercent: usagePercent(used, ws.QuotaStorage),
+		})
+	}
+	sort.Slice(usage.Workspaces, func(i, j int) bool {
+		return usage.Workspaces[i].WorkspaceID < usage.Workspaces[j].WorkspaceID
+	})
+	return usage, nil
+}
+
+// CheckStorageBytes 检查新增 additional 字节后是否超出客户（及工作空间）存储配额
+// additional 为 0 时仅校验当前用量（含上传中预留）是否仍在配额内
+func (s *CustomerService) CheckStorageBytes(ctx context.Context, customerID uint, workspaceID *uint, additional int64) error {
+	customer, err := s.customerDao.FindByID(ctx, customerID)
+	if err != nil {
+		return err
+	}
+
+	// quota_storage 为 0 表示不限制
+	if customer.QuotaStorage > 0 {
+		used, err := s.storageUsedBytes(ctx, customerID)
+		if err != nil {
+			return err
+		}
+		if used+additional > customer.QuotaStorage*bytesPerMB {
+			return ErrQuotaExceeded
+		}
+	}
+
+	if workspaceID == nil {
+		return nil
+	}
+	ws, err := s.workspaceDao.FindByID(ctx, *workspaceID)
+	if err != nil {
+		return err
+	}
+	if ws.QuotaStorage > 0 {
+		used, err := s.datasetDao.SumStorageByWorkspaceID(ctx, ws.ID)
+		if err != nil {
+			return err
+		}
+		if used+additional > ws.QuotaStorage*bytesPerMB {
+			return ErrWorkspaceQuotaExceeded
+		}
+	}
+	return nil
+}
+
+// ReserveStorage 在同一事务内校验存储配额并执行 create 写入占用记录
+// 锁定客户行（及工作空间行）使并发请求串行校验，避免同时通过检查后共同超出配额
+func (s *CustomerService) ReserveStorage(ctx context.Context, customerID uint, workspaceID *uint, additional int64, create func(tx *gorm.DB) error) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&entity.Customer{}, customerID).Error; err != nil {
+			return err
+		}
+		if workspaceID != nil {
+			if err := tx.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+				First(&entity.Workspace{}, *workspaceID).Error; err != nil {
+				return err
+			}
+		}
+		if err := NewCustomerService(tx).CheckStorageBytes(ctx, customerID, workspaceID, additional); err != nil {
+			return err
+		}
+		return create(tx)
+	})
+}
+
+// NotifyStorageUsage 用量变化后检查是否跨过提醒级别（80%/95%），每个级别只提醒一次
+// 用量回落到级别以下后重置，再次超过时重新提醒
+func (s *CustomerService) NotifyStorageUsage(ctx context.Context, customerID uint, workspaceID *uint) {
+	customer, err := s.customerDao.FindByID(ctx, customerID)
+	if err != nil {
+		return
+	}
+	if customer.QuotaStorage > 0 {
+		used, err := s.storageUsedBytes(ctx, customerID)
+		if err == nil {
+			s.checkWarnLevel(ctx, entity.StorageScopeCustomer, customerID, customerID, "您的账户", used, customer.QuotaStorage)
+		}
+	}
+
+	if workspaceID == nil {
+		return
+	}
+	ws, err := s.workspaceDao.FindByID(ctx, *workspaceID)
+	if err != nil || ws.QuotaStorage <= 0 {
+		return
+	}
+	used, err := s.datasetDao.SumStorageByWorkspaceID(ctx, ws.ID)
+	if err == nil {
+		s.checkWarnLevel(ctx, entity.StorageScopeWorkspace, ws.ID, ws.OwnerID, "工作空间 "+ws.Name+" ", used, ws.QuotaStorage)
+	}
+}
+
+// checkWarnLevel 比较当前级别与已提醒级别，升级时通知 recipient
+func (s *CustomerService) checkWarnLevel(ctx context.Context, scope string, scopeID, recipient uint, label string, usedBytes, quotaMB int64) {
+	percent := usagePercent(usedBytes, quotaMB)
+	level := 0
+	for _, l := range storageWarnLevels {
+		if percent >= float64(l) {
+			level = l
+			break
+		}
+	}
+
+	prev, err := s.quotaAlertDao.GetLevel(ctx, scope, scopeID)
+	if err != nil || level == prev {
+		return
+	}
+	if err := s.quotaAlertDao.SetLevel(ctx, scope, scopeID, level); err != nil {
+		logger.GetLogger().Warn(fmt.Sprintf("更新存储用量提醒状态失败 %s/%d: %v", scope, scopeID, err))
+		return
+	}
+	if level > prev && s.notifier != nil {
+		if err := s.notifier.PushStorageQuotaWarning(ctx, recipient, label, int(percent), usedBytes/bytesPerMB, quotaMB); err != nil {
+			logger.GetLogger().Warn(fmt.Sprintf("发送存储用量提醒失败 %s/%d: %v", scope, scopeID, err))
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package customer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+	serviceNotification "github.com/YoungBoyGod/remotegpu/internal/service/notification"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func insertDataset(t *testing.T, db *gorm.DB, customerID uint, workspaceID any, mb int64, status string) {
+	err := db.Exec(`INSERT INTO datasets (customer_id, workspace_id, name, storage_path, total_size, status)
+		VALUES (?, ?, 'ds', '/data/ds', ?, ?)`, customerID, workspaceID, mb*bytesPerMB, status).Error
+	require.NoError(t, err)
+}
+
+func countNotifications(t *testing.T, db *gorm.DB, customerID uint) int64 {
+	var n int64
+	require.NoError(t, db.Model(&entity.Notification{}).Where("customer_id = ? AND type = ?", customerID, "quota").Count(&n).Error)
+	return n
+}
+
+func TestGetStorageUsage_Breakdown(t *testing.T) {
+	db := setupCustomerServiceTestDB(t)
+	svc := NewCustomerService(db)
+	ctx := context.Background()
+
+	insertTestCustomer(t, db, &entity.Customer{Username: "u1", Email: "u1@test.com", QuotaStorage: 1000})
+	insertTestCustomer(t, db, &entity.Customer{Username: "u2", Email: "u2@test.com"})
+	require.NoError(t, db.Exec(`INSERT INTO workspaces (owner_id, name, quota_storage) VALUES (1, 'team', 500)`).Error)
+
+	insertDataset(t, db, 1, nil, 100, "ready")
+	insertDataset(t, db, 1, 1, 50, "ready")
+	insertDataset(t, db, 1, nil, 30, "uploading")
+	insertDataset(t, db, 1, nil, 999, "aborted") // 已中止不计入
+	insertDataset(t, db, 2, 1, 20, "ready")      // 其他成员的用量只计入工作空间
+	// 管理员上传的平台文档不计入客户用量
+	require.NoError(t, db.Exec(`INSERT INTO documents (title, file_name, file_path, file_size, uploaded_by)
+		VALUES ('doc', 'a.pdf', 'docs/a.pdf', ?, 1)`, 10*bytesPerMB).Error)
+
+	usage, err := svc.GetStorageUsage(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(150*bytesPerMB), usage.DatasetBytes)
+	assert.Equal(t, int64(30*bytesPerMB), usage.UploadingBytes)
+	assert.Equal(t, int64(180*bytesPerMB), usage.UsedBytes)
+	assert.Equal(t, int64(3), usage.DatasetCount)
+	assert.InDelta(t, 18.0, usage.UsagePercent, 0.001)
+
+	require.Len(t, usage.Workspaces, 1)
+	ws := usage.Workspaces[0]
+	assert.Equal(t, "team", ws.Name)
+	assert.Equal(t, int64(70*bytesPerMB), ws.UsedBytes)
+	assert.Equal(t, int64(50*bytesPerMB), ws.OwnBytes)
+	assert.InDelta(t, 14.0, ws.UsagePercent, 0.001)
+}
+
+func TestCheckStorageBytes(t *testing.T) {
+	db := setupCustomerServiceTestDB(t)
+	svc := NewCustomerService(db)
+	ctx := context.Background()
+
+	insertTestCustomer(t, db, &entity.Customer{Username: "u1", Email: "u1@test.com", QuotaStorage: 100})
+	require.NoError(t, db.Exec(`INSERT INTO workspaces (owner_id, name, quota_storage) VALUES (1, 'team', 40)`).Error)
+	wsID := uint(1)
+
+	insertDataset(t, db, 1, nil, 50, "ready")
+	require.NoError(t, db.Exec(`INSERT INTO documents (title, file_name, file_path, file_size, uploaded_by)
+		VALUES ('doc', 'a.pdf', 'docs/a.pdf', ?, 1)`, 20*bytesPerMB).Error)
+
+	// 平台文档不计入客户用量：50 + 50 = 100 恰好不超限
+	assert.NoError(t, svc.CheckStorageBytes(ctx, 1, nil, 50*bytesPerMB))
+	assert.ErrorIs(t, svc.CheckStorageBytes(ctx, 1, nil, 50*bytesPerMB+1), ErrQuotaExceeded)
+
+	// 工作空间配额独立校验
+	assert.ErrorIs(t, svc.CheckStorageBytes(ctx, 1, &wsID, 41*bytesPerMB), ErrWorkspaceQuotaExceeded)
+	insertDataset(t, db, 1, 1, 25, "uploading")
+	assert.NoError(t, svc.CheckStorageBytes(ctx, 1, &wsID, 0))
+	require.NoError(t, db.Model(&entity.Customer{}).Where("id = 1").Update("quota_storage", 0).Error)
+	assert.NoError(t, svc.CheckStorageBytes(ctx, 1, &wsID, 15*bytesPerMB))
+	assert.ErrorIs(t, svc.CheckStorageBytes(ctx, 1, &wsID, 15*bytesPerMB+1), ErrWorkspaceQuotaExceeded)
+}
+
+func TestReserveStorage(t *testing.T) {
+	db := setupCustomerServiceTestDB(t)
+	svc := NewCustomerService(db)
+	ctx := context.Background()
+
+	insertTestCustomer(t, db, &entity.Customer{Username: "u1", Email: "u1@test.com", QuotaStorage: 100})
+	create := func(mb int64) func(tx *gorm.DB) error {
+		return func(tx *gorm.DB) error {
+			return tx.Exec(`INSERT INTO datasets (customer_id, name, storage_path, total_size, status)
+				VALUES (1, 'ds', '/data/ds', ?, 'uploading')`, mb*bytesPerMB).Error
+		}
+	}
+
+	// 校验与写入在同一事务内，后一次预留能看到前一次写入的用量
+	require.NoError(t, svc.ReserveStorage(ctx, 1, nil, 60*bytesPerMB, create(60)))
+	called := false
+	err := svc.ReserveStorage(ctx, 1, nil, 60*bytesPerMB, func(tx *gorm.DB) error {
+		called = true
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrQuotaExceeded)
+	assert.False(t, called, "超出配额时不应写入")
+
+	// 写入失败时整个事务回滚
+	err = svc.ReserveStorage(ctx, 1, nil, 30*bytesPerMB, func(tx *gorm.DB) error {
+		require.NoError(t, create(30)(tx))
+		return assert.AnError
+	})
+	assert.ErrorIs(t, err, assert.AnError)
+	used, err := svc.storageUsedBytes(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(60*bytesPerMB), used)
+}
+
+func TestNotifyStorageUsage_WarnOncePerLevel(t *testing.T) {
+	db := setupCustomerServiceTestDB(t)
+	svc := NewCustomerService(db)
+	svc.SetNotificationService(serviceNotification.NewNotificationService(db, serviceNotification.NewSSEHub()))
+	ctx := context.Background()
+
+	insertTestCustomer(t, db, &entity.Customer{Username: "u1", Email: "u1@test.com", QuotaStorage: 100})
+	insertTestCustomer(t, db, &entity.Customer{Username: "u2", Email: "u2@test.com"})
+	require.NoError(t, db.Exec(`INSERT INTO workspaces (owner_id, name, quota_storage) VALUES (2, 'team', 100)`).Error)
+	wsID := uint(1)
+
+	insertDataset(t, db, 1, nil, 70, "ready")
+	svc.NotifyStorageUsage(ctx, 1, nil)
+	assert.Equal(t, int64(0), countNotifications(t, db, 1))
+
+	// 跨过 80%：提醒一次，重复检查不再提醒
+	insertDataset(t, db, 1, nil, 12, "ready")
+	svc.NotifyStorageUsage(ctx, 1, nil)
+	svc.NotifyStorageUsage(ctx, 1, nil)
+	assert.Equal(t, int64(1), countNotifications(t, db, 1))
+
+	// 跨过 95%
+	insertDataset(t, db, 1, nil, 14, "uploading")
+	svc.NotifyStorageUsage(ctx, 1, nil)
+	assert.Equal(t, int64(2), countNotifications(t, db, 1))
+	var last entity.Notification
+	require.NoError(t, db.Order("id desc").First(&last).Error)
+	assert.Equal(t, "error", last.Level)
+
+	// 用量回落后重置，再次超过时重新提醒
+	require.NoError(t, db.Exec(`UPDATE datasets SET status = 'aborted' WHERE status = 'uploading'`).Error)
+	svc.NotifyStorageUsage(ctx, 1, nil)
+	assert.Equal(t, int64(2), countNotifications(t, db, 1))
+	insertDataset(t, db, 1, nil, 14, "uploading")
+	svc.NotifyStorageUsage(ctx, 1, nil)
+	assert.Equal(t, int64(3), countNotifications(t, db, 1))
+
+	// 工作空间用量提醒发送给工作空间所有者
+	insertDataset(t, db, 1, 1, 85, "ready")
+	svc.NotifyStorageUsage(ctx, 1, &wsID)
+	assert.Equal(t, int64(1), countNotifications(t, db, 2))
+}
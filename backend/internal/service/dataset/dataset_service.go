@@ -9,6 +9,7 @@ import (
 
 	"github.com/YoungBoyGod/remotegpu/internal/dao"
 	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+	serviceAllocation "github.com/YoungBoyGod/remotegpu/internal/service/allocation"
 	"gorm.io/gorm"
 )
 
@@ -43,19 +44,6 @@ func (s *DatasetService) GetDataset(ctx context.Context, id uint) (*entity.Datas
 	return s.datasetDao.FindByID(ctx, id)
 }
 
-// CompleteUpload 完成分片上传，更新数据集状态为 ready
-func (s *DatasetService) CompleteUpload(ctx context.Context, id uint, name string, size int64) error {
-	fields := map[string]interface{}{
-		"status": "ready",
-	}
-	if name != "" {
-		fields["name"] = name
-	}
-	if size > 0 {
-		fields["total_size"] = size
-	}
-	return s.datasetDao.UpdateFields(ctx, id, fields)
-}
 // @author Claude
 // @description 验证数据集是否属于指定用户
 // @modified 2026-02-04
@@ -70,13 +58,8 @@ func (s *DatasetService) ValidateOwnership(ctx context.Context, datasetID uint,
 	return nil
 }
 
-// MountDataset 创建数据集挂载记录
-func (s *DatasetService) MountDataset(ctx context.Context, datasetID uint, hostID, mountPath string, readOnly bool) (*entity.DatasetMount, error) {
-	// 校验挂载路径合法性
-	if err := validateMountPath(mountPath); err != nil {
-		return nil, err
-	}
-
+// MountDataset 创建数据集挂载记录，挂载点解析到数据集所属客户的挂载根目录下
+func (s *DatasetService) MountDataset(ctx context.Context, datasetID uint, hostID, mountPoint string, readOnly bool) (*entity.DatasetMount, error) {
 	// 校验数据集状态
 	dataset, err := s.datasetDao.FindByID(ctx, datasetID)
 	if err != nil {
@@ -86,6 +69,11 @@ func (s *DatasetService) MountDataset(ctx context.Context, datasetID uint, hostI
 		return nil, ErrDatasetNotReady
 	}
 
+	mountPath, err := resolveMountPath(dataset.CustomerID, mountPoint)
+	if err != nil {
+		return nil, err
+	}
+
 	// 检查是否已存在活跃挂载
 	existing, err := s.datasetMountDao.FindActiveMount(ctx, datasetID, hostID)
 	if err == nil && existing != nil {
@@ -95,7 +83,7 @@ func (s *DatasetService) MountDataset(ctx context.Context, datasetID uint, hostI
 	mount := &entity.DatasetMount{
 		DatasetID: datasetID,
 		HostID:    hostID,
-		MountPath: filepath.Clean(mountPath),
+		MountPath: mountPath,
 		ReadOnly:  readOnly,
 		Status:    "mounting",
 	}
@@ -105,6 +93,21 @@ func (s *DatasetService) MountDataset(ctx context.Context, datasetID uint, hostI
 	return mount, nil
 }
 
+// GetMount 获取挂载记录，并校验其属于指定数据集
+func (s *DatasetService) GetMount(ctx context.Context, datasetID, mountID uint) (*entity.DatasetMount, error) {
+	mount, err := s.datasetMountDao.FindByID(ctx, mountID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrMountNotFound
+		}
+		return nil, err
+	}
+	if mount.DatasetID != datasetID {
+		return nil, ErrMountNotFound
+	}
+	return mount, nil
+}
+
 // UnmountDataset 卸载数据集
 func (s *DatasetService) UnmountDataset(ctx context.Context, mountID uint) error {
 	mount, err := s.datasetMountDao.FindByID(ctx, mountID)
@@ -156,26 +159,52 @@ func (s *DatasetService) CleanupMountsByHost(ctx context.Context, hostID string)
 	return nil
 }
 
-// validateMountPath 校验挂载路径合法性
-func validateMountPath(path string) error {
-	path = strings.TrimSpace(path)
-	if path == "" {
-		return ErrInvalidMountPath
-	}
-	if !strings.HasPrefix(path, "/") {
-		return fmt.Errorf("%w: 必须为绝对路径", ErrInvalidMountPath)
-	}
-	// 禁止挂载到系统关键目录
-	forbidden := []string{"/", "/bin", "/sbin", "/usr", "/etc", "/proc", "/sys", "/dev", "/boot", "/root"}
-	cleaned := filepath.Clean(path)
-	for _, f := range forbidden {
-		if cleaned == f {
-			return fmt.Errorf("%w: 不允许挂载到系统目录 %s", ErrInvalidMountPath, f)
-		}
+// datasetBucket 数据集上传使用的存储桶，与分片上传保持一致
+const datasetBucket = "datasets"
+
+// MountSource 根据数据集存储类型返回 Agent 挂载使用的数据源类型和路径
+func MountSource(dataset *entity.Dataset) (sourceType, sourcePath string) {
+	switch dataset.StorageType {
+	case "nfs":
+		return "nfs", dataset.StoragePath
+	case "local", "bind":
+		return "bind", dataset.StoragePath
+	default:
+		// minio / rustfs / s3 等对象存储通过 s3fs 挂载
+		return "s3", datasetBucket + "/" + strings.TrimPrefix(dataset.StoragePath, "/")
+	}
+}
+
+// mountBaseDir 机器上数据集挂载的根目录，每个客户使用其下以系统账号命名的子目录
+const mountBaseDir = "/data"
+
+// MountRoot 客户在机器上的数据集挂载根目录，如 /data/rg-c42
+func MountRoot(customerID uint) string {
+	return filepath.Join(mountBaseDir, serviceAllocation.TenantUsername(customerID))
+}
+
+// resolveMountPath 将客户指定的挂载点解析为其挂载根目录下的绝对路径
+// 相对路径拼接到根目录下，绝对路径必须已位于根目录之下
+func resolveMountPath(customerID uint, mountPoint string) (string, error) {
+	mountPoint = strings.TrimSpace(mountPoint)
+	if mountPoint == "" {
+		return "", ErrInvalidMountPath
 	}
 	// 禁止路径穿越
-	if strings.Contains(path, "..") {
-		return fmt.Errorf("%w: 路径不允许包含 ..", ErrInvalidMountPath)
+	if strings.Contains(mountPoint, "..") {
+		return "", fmt.Errorf("%w: 路径不允许包含 ..", ErrInvalidMountPath)
+	}
+	root := MountRoot(customerID)
+	rel := filepath.Clean(mountPoint)
+	if filepath.IsAbs(rel) {
+		trimmed, ok := strings.CutPrefix(rel, root+"/")
+		if !ok {
+			return "", fmt.Errorf("%w: 挂载点必须位于 %s 之下", ErrInvalidMountPath, root)
+		}
+		rel = trimmed
 	}
-	return nil
+	if rel == "." {
+		return "", fmt.Errorf("%w: 不能直接挂载到 %s", ErrInvalidMountPath, root)
+	}
+	return filepath.Join(root, rel), nil
 }
@@ -0,0 +1,56 @@
+package dataset
+
+import (
+	"testing"
+
+	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMountSource(t *testing.T) {
+	cases := []struct {
+		storageType string
+		storagePath string
+		wantType    string
+		wantPath    string
+	}{
+		{"minio", "/customer-1/ds1", "s3", "datasets/customer-1/ds1"},
+		{"", "customer-1/ds2", "s3", "datasets/customer-1/ds2"},
+		{"nfs", "nas:/export/ds3", "nfs", "nas:/export/ds3"},
+		{"local", "/data/shared/ds4", "bind", "/data/shared/ds4"},
+	}
+	for _, tc := range cases {
+		sourceType, sourcePath := MountSource(&entity.Dataset{StorageType: tc.storageType, StoragePath: tc.storagePath})
+		assert.Equal(t, tc.wantType, sourceType, tc.storageType)
+		assert.Equal(t, tc.wantPath, sourcePath, tc.storageType)
+	}
+}
+
+func TestResolveMountPath(t *testing.T) {
+	cases := []struct {
+		mountPoint string
+		want       string
+		ok         bool
+	}{
+		{"imagenet", "/data/rg-c7/imagenet", true},
+		{"train/imagenet/", "/data/rg-c7/train/imagenet", true},
+		{"/data/rg-c7/imagenet", "/data/rg-c7/imagenet", true},
+		{"", "", false},
+		{"/data/rg-c7", "", false},
+		{".", "", false},
+		{"/home/rg-c8", "", false},
+		{"/data/rg-c70/x", "", false},
+		{"/etc", "", false},
+		{"../rg-c8/x", "", false},
+		{"/data/rg-c7/../rg-c8", "", false},
+	}
+	for _, tc := range cases {
+		got, err := resolveMountPath(7, tc.mountPoint)
+		if !tc.ok {
+			assert.ErrorIs(t, err, ErrInvalidMountPath, tc.mountPoint)
+			continue
+		}
+		assert.NoError(t, err, tc.mountPoint)
+		assert.Equal(t, tc.want, got, tc.mountPoint)
+	}
+}
@@ -0,0 +1,65 @@
+package dataset
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/YoungBoyGod/remotegpu/pkg/logger"
+)
+
+// janitorBatchSize 单轮最多中止的上传数
+const janitorBatchSize = 100
+
+// UploadJanitor 过期上传清理器
+// 定期中止超过有效期仍未完成的分片上传，释放存储后端中的分片，数据集状态变为 aborted
+type UploadJanitor struct {
+	uploadService *UploadService
+	interval      time.Duration
+}
+
+// NewUploadJanitor 创建过期上传清理器
+func NewUploadJanitor(svc *UploadService, interval time.Duration) *UploadJanitor {
+	if interval <= 0 {
+		interval = 10 * time.Minute
+	}
+	return &UploadJanitor{uploadService: svc, interval: interval}
+}
+
+// Start 启动过期上传清理
+func (j *UploadJanitor) Start(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	logger.GetLogger().Info("数据集过期上传清理服务已启动")
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.GetLogger().Info("数据集过期上传清理服务已停止")
+			return
+		case <-ticker.C:
+			j.sweep(ctx)
+		}
+	}
+}
+
+// sweep 中止一轮过期上传，返回成功中止的数量
+func (j *UploadJanitor) sweep(ctx context.Context) int {
+	svc := j.uploadService
+	datasets, err := svc.datasetDao.ListExpiredUploads(ctx, svc.now(), janitorBatchSize)
+	if err != nil {
+		logger.GetLogger().Warn(fmt.Sprintf("查询过期数据集上传失败: %v", err))
+		return 0
+	}
+
+	aborted := 0
+	for i := range datasets {
+		if err := svc.abort(ctx, &datasets[i]); err != nil {
+			logger.GetLogger().Warn(fmt.Sprintf("中止过期数据集上传 %d 失败: %v", datasets[i].ID, err))
+			continue
+		}
+		aborted++
+	}
+	return aborted
+}
@@ -0,0 +1,413 @@
+package dataset
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/YoungBoyGod/remotegpu/config"
+	"github.com/YoungBoyGod/remotegpu/internal/dao"
+	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+	serviceCustomer "github.com/YoungBoyGod/remotegpu/internal/service/customer"
+	pkgStorage "github.com/YoungBoyGod/remotegpu/pkg/storage"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrUploadNotActive    = errors.New("数据集不在上传中或上传已结束")
+	ErrUploadExpired      = errors.New("上传已过期")
+	ErrInvalidFilename    = errors.New("文件名不合法")
+	ErrInvalidUploadSize  = errors.New("文件大小不合法")
+	ErrInvalidPartNumber  = errors.New("分片编号不合法")
+	ErrPartMismatch       = errors.New("分片校验失败")
+	ErrMultipartDisabled  = errors.New("存储后端不支持分片上传")
+	ErrStorageUnavailable = errors.New("存储后端未配置")
+	ErrWorkspaceForbidden = errors.New("不是该工作空间的成员")
+)
+
+const (
+	defaultPartSize  = 64 << 20
+	defaultUploadTTL = 72 * time.Hour
+	defaultURLExpire = time.Hour
+	// maxObjectSize 单个对象上限，与 S3 一致
+	maxObjectSize = 5 << 40
+)
+
+// UploadSession 分片上传会话
+type UploadSession struct {
+	Dataset   *entity.Dataset `json:"dataset"`
+	UploadID  string          `json:"upload_id"`
+	PartSize  int64           `json:"part_size"`
+	PartCount int             `json:"part_count"`
+	ExpiresAt time.Time       `json:"expires_at"`
+}
+
+// PartURL 分片上传地址，客户端以 HTTP PUT 上传分片内容并记录响应头中的 ETag
+type PartURL struct {
+	PartNumber int    `json:"part_number"`
+	URL        string `json:"url"`
+	// Proxied 为 true 时 URL 为平台接口（需携带登录凭证），否则为存储后端的预签名地址
+	Proxied bool `json:"proxied"`
+}
+
+// UploadService 数据集分片上传
+type UploadService struct {
+	datasetDao *dao.DatasetDao
+	memberDao  *dao.WorkspaceMemberDao
+	storageMgr *pkgStorage.Manager
+	quota      *serviceCustomer.CustomerService
+	cfg        config.DatasetUploadConfig
+	maxSize    int64
+	now        func() time.Time
+}
+
+// NewUploadService 创建分片上传服务，maxSize 为单个文件大小上限（<=0 表示不限制）
+func NewUploadService(db *gorm.DB, mgr *pkgStorage.Manager, cfg config.DatasetUploadConfig, maxSize int64) *UploadService {
+	return &UploadService{
+		datasetDao: dao.NewDatasetDao(db),
+		memberDao:  dao.NewWorkspaceMemberDao(db),
+		storageMgr: mgr,
+		cfg:        cfg,
+		maxSize:    maxSize,
+		now:        time.Now,
+	}
+}
+
+// SetQuotaService 注入客户服务，用于存储配额校验和用量提醒
+func (s *UploadService) SetQuotaService(q *serviceCustomer.CustomerService) {
+	s.quota = q
+}
+
+// checkQuota 校验客户及工作空间存储配额，未注入时不限制
+func (s *UploadService) checkQuota(ctx context.Context, customerID uint, workspaceID *uint, additional int64) error {
+	if s.quota == nil {
+		return nil
+	}
+	return s.quota.CheckStorageBytes(ctx, customerID, workspaceID, additional)
+}
+
+// createUploading 写入上传中的数据集，注入客户服务时与配额校验在同一事务内完成
+func (s *UploadService) createUploading(ctx context.Context, dataset *entity.Dataset) error {
+	if s.quota == nil {
+		return s.datasetDao.Create(ctx, dataset)
+	}
+	return s.quota.ReserveStorage(ctx, dataset.CustomerID, dataset.WorkspaceID, dataset.TotalSize, func(tx *gorm.DB) error {
+		return dao.NewDatasetDao(tx).Create(ctx, dataset)
+	})
+}
+
+// notifyUsage 存储用量变化后检查是否需要发送用量提醒
+func (s *UploadService) notifyUsage(ctx context.Context, dataset *entity.Dataset) {
+	if s.quota != nil {
+		s.quota.NotifyStorageUsage(ctx, dataset.CustomerID, dataset.WorkspaceID)
+	}
+}
+
+func (s *UploadService) uploadTTL() time.Duration {
+	if s.cfg.UploadTTL > 0 {
+		return time.Duration(s.cfg.UploadTTL) * time.Hour
+	}
+	return defaultUploadTTL
+}
+
+func (s *UploadService) urlExpire() time.Duration {
+	if s.cfg.URLExpire > 0 {
+		return time.Duration(s.cfg.URLExpire) * time.Second
+	}
+	return defaultURLExpire
+}
+
+// backend 获取分片上传使用的存储后端
+func (s *UploadService) backend(name string) (pkgStorage.MultipartStorage, error) {
+	if s.storageMgr == nil {
+		return nil, ErrStorageUnavailable
+	}
+	st, err := s.storageMgr.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	mp, ok := st.(pkgStorage.MultipartStorage)
+	if !ok {
+		return nil, ErrMultipartDisabled
+	}
+	return mp, nil
+}
+
+// partLayout 计算分片大小和分片数：分片数不超过上限，分片不小于 S3 最小分片
+func partLayout(size, partSize int64) (int64, int) {
+	if partSize < pkgStorage.MinPartSize {
+		partSize = pkgStorage.MinPartSize
+	}
+	if minSize := (size + pkgStorage.MaxPartCount - 1) / pkgStorage.MaxPartCount; partSize < minSize {
+		// 按 MB 向上取整
+		partSize = (minSize + (1<<20 - 1)) &^ (1<<20 - 1)
+	}
+	count := int((size + partSize - 1) / partSize)
+	if count == 0 {
+		count = 1
+	}
+	return partSize, count
+}
+
+// sanitizeFilename 只保留文件名部分，拒绝路径穿越
+func sanitizeFilename(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || strings.ContainsAny(name, "/\\\x00") || name == "." || name == ".." {
+		return "", ErrInvalidFilename
+	}
+	return name, nil
+}
+
+// InitUpload 创建 uploading 状态的数据集并初始化分片上传
+// 上传中的数据集按声明大小计入存储用量，workspaceID 不为空时数据集归属该工作空间
+// 初始化分片前先快速校验配额，写入数据集时在事务内加锁复核，并发上传不会共同超出配额
+func (s *UploadService) InitUpload(ctx context.Context, customerID uint, workspaceID *uint, name, description, filename string, size int64) (*UploadSession, error) {
+	filename, err := sanitizeFilename(filename)
+	if err != nil {
+		return nil, err
+	}
+	if size <= 0 || size > maxObjectSize || (s.maxSize > 0 && size > s.maxSize) {
+		return nil, ErrInvalidUploadSize
+	}
+	if workspaceID != nil {
+		member, err := s.memberDao.FindByWorkspaceAndCustomer(ctx, *workspaceID, customerID)
+		if err != nil || member.Status != "active" {
+			return nil, ErrWorkspaceForbidden
+		}
+	}
+	if err := s.checkQuota(ctx, customerID, workspaceID, size); err != nil {
+		return nil, err
+	}
+	backend, err := s.backend(s.cfg.Backend)
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		name = filename
+	}
+
+	id := uuid.NewString()
+	storagePath := fmt.Sprintf("customer-%d/%s", customerID, id)
+	key := path.Join(storagePath, filename)
+
+	uploadID, err := backend.InitMultipart(ctx, key, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := s.now().Add(s.uploadTTL())
+	dataset := &entity.Dataset{
+		UUID:            id,
+		CustomerID:      customerID,
+		WorkspaceID:     workspaceID,
+		Name:            name,
+		Description:     description,
+		StoragePath:     storagePath,
+		StorageType:     backend.Type(),
+		StorageBackend:  backend.Name(),
+		TotalSize:       size,
+		Status:          "uploading",
+		Visibility:      "private",
+		UploadID:        uploadID,
+		UploadKey:       key,
+		UploadExpiresAt: &expiresAt,
+	}
+	if err := s.createUploading(ctx, dataset); err != nil {
+		_ = backend.AbortMultipart(ctx, key, uploadID)
+		return nil, err
+	}
+	s.notifyUsage(ctx, dataset)
+
+	partSize := s.cfg.PartSize
+	if partSize <= 0 {
+		partSize = defaultPartSize
+	}
+	partSize, partCount := partLayout(size, partSize)
+	return &UploadSession{
+		Dataset:   dataset,
+		UploadID:  uploadID,
+		PartSize:  partSize,
+		PartCount: partCount,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// activeUpload 获取进行中的上传，并校验 uploadID 与有效期
+func (s *UploadService) activeUpload(ctx context.Context, datasetID uint, uploadID string) (*entity.Dataset, pkgStorage.MultipartStorage, error) {
+	dataset, err := s.datasetDao.FindByID(ctx, datasetID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if dataset.Status != "uploading" || dataset.UploadID == "" || dataset.UploadID != uploadID {
+		return nil, nil, ErrUploadNotActive
+	}
+	if dataset.UploadExpiresAt != nil && s.now().After(*dataset.UploadExpiresAt) {
+		return nil, nil, ErrUploadExpired
+	}
+	backend, err := s.backend(dataset.StorageBackend)
+	if err != nil {
+		return nil, nil, err
+	}
+	return dataset, backend, nil
+}
+
+// PartURLs 生成分片上传地址；存储后端不支持预签名时返回平台代理上传接口
+func (s *UploadService) PartURLs(ctx context.Context, datasetID uint, uploadID string, partNumbers []int) ([]PartURL, error) {
+	dataset, backend, err := s.activeUpload(ctx, datasetID, uploadID)
+	if err != nil {
+		return nil, err
+	}
+
+	urls := make([]PartURL, 0, len(partNumbers))
+	for _, n := range partNumbers {
+		if n < 1 || n > pkgStorage.MaxPartCount {
+			return nil, ErrInvalidPartNumber
+		}
+		u, err := backend.PresignPart(ctx, dataset.UploadKey, uploadID, n, s.urlExpire())
+		if errors.Is(err, pkgStorage.ErrPresignNotSupported) {
+			urls = append(urls, PartURL{
+				PartNumber: n,
+				URL:        fmt.Sprintf("/api/v1/customer/datasets/%d/uploads/%s/parts/%d", datasetID, uploadID, n),
+				Proxied:    true,
+			})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		urls = append(urls, PartURL{PartNumber: n, URL: u})
+	}
+	return urls, nil
+}
+
+// UploadPart 由平台代理上传分片（用于不支持预签名的本地存储）
+func (s *UploadService) UploadPart(ctx context.Context, datasetID uint, uploadID string, partNumber int, reader io.Reader, size int64) (*pkgStorage.Part, error) {
+	if partNumber < 1 || partNumber > pkgStorage.MaxPartCount {
+		return nil, ErrInvalidPartNumber
+	}
+	if size > pkgStorage.MaxPartSize {
+		return nil, ErrInvalidUploadSize
+	}
+	dataset, backend, err := s.activeUpload(ctx, datasetID, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	return backend.UploadPart(ctx, dataset.UploadKey, uploadID, partNumber, reader, size)
+}
+
+// ListParts 列出已上传的分片，客户端据此跳过已完成的分片续传
+func (s *UploadService) ListParts(ctx context.Context, datasetID uint, uploadID string) ([]pkgStorage.Part, error) {
+	dataset, backend, err := s.activeUpload(ctx, datasetID, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	return backend.ListParts(ctx, dataset.UploadKey, uploadID)
+}
+
+// CompleteUpload 校验分片 ETag 后合并分片，数据集状态变为 ready
+// parts 须按分片编号升序，覆盖全部已声明的文件大小
+func (s *UploadService) CompleteUpload(ctx context.Context, datasetID uint, uploadID, name string, parts []pkgStorage.Part) (*entity.Dataset, error) {
+	dataset, backend, err := s.activeUpload(ctx, datasetID, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	if len(parts) == 0 {
+		return nil, fmt.Errorf("%w: 分片列表为空", ErrPartMismatch)
+	}
+
+	uploaded, err := backend.ListParts(ctx, dataset.UploadKey, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	byNumber := make(map[int]pkgStorage.Part, len(uploaded))
+	for _, p := range uploaded {
+		byNumber[p.PartNumber] = p
+	}
+
+	var total int64
+	for i, p := range parts {
+		if p.PartNumber != i+1 {
+			return nil, fmt.Errorf("%w: 分片编号须从 1 开始连续递增", ErrPartMismatch)
+		}
+		got, ok := byNumber[p.PartNumber]
+		if !ok {
+			return nil, fmt.Errorf("%w: 分片 %d 未上传", ErrPartMismatch, p.PartNumber)
+		}
+		if pkgStorage.NormalizeETag(p.ETag) != got.ETag {
+			return nil, fmt.Errorf("%w: 分片 %d ETag 不一致", ErrPartMismatch, p.PartNumber)
+		}
+		if i < len(parts)-1 && got.Size < pkgStorage.MinPartSize {
+			return nil, fmt.Errorf("%w: 分片 %d 小于最小分片大小", ErrPartMismatch, p.PartNumber)
+		}
+		total += got.Size
+	}
+	if total != dataset.TotalSize {
+		return nil, fmt.Errorf("%w: 分片总大小 %d 与声明的 %d 不一致", ErrPartMismatch, total, dataset.TotalSize)
+	}
+
+	// 上传期间配额可能被调低，合并前按当前用量（已含本次预留）再次校验
+	if err := s.checkQuota(ctx, dataset.CustomerID, dataset.WorkspaceID, 0); err != nil {
+		return nil, err
+	}
+
+	info, err := backend.CompleteMultipart(ctx, dataset.UploadKey, uploadID, parts)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := map[string]interface{}{
+		"status":     "ready",
+		"total_size": info.Size,
+		"file_count": 1,
+	}
+	if name != "" {
+		fields["name"] = name
+	}
+	ok, err := s.datasetDao.FinishUpload(ctx, datasetID, uploadID, fields)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrUploadNotActive
+	}
+	s.notifyUsage(ctx, dataset)
+	return s.datasetDao.FindByID(ctx, datasetID)
+}
+
+// AbortUpload 中止上传并清理已上传的分片，数据集状态变为 aborted
+func (s *UploadService) AbortUpload(ctx context.Context, datasetID uint, uploadID string) error {
+	dataset, err := s.datasetDao.FindByID(ctx, datasetID)
+	if err != nil {
+		return err
+	}
+	if dataset.Status != "uploading" || dataset.UploadID != uploadID {
+		return ErrUploadNotActive
+	}
+	return s.abort(ctx, dataset)
+}
+
+func (s *UploadService) abort(ctx context.Context, dataset *entity.Dataset) error {
+	backend, err := s.backend(dataset.StorageBackend)
+	if err != nil {
+		return err
+	}
+	if err := backend.AbortMultipart(ctx, dataset.UploadKey, dataset.UploadID); err != nil && !errors.Is(err, pkgStorage.ErrUploadNotFound) {
+		return err
+	}
+	ok, err := s.datasetDao.FinishUpload(ctx, dataset.ID, dataset.UploadID, map[string]interface{}{
+		"status": "aborted",
+	})
+	if err != nil {
+		return err
+	}
+	if ok {
+		// 释放预留的存储用量，用量回落后重置提醒级别
+		s.notifyUsage(ctx, dataset)
+	}
+	return nil
+}
@@ -0,0 +1,250 @@
+package dataset
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/YoungBoyGod/remotegpu/config"
+	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+	serviceCustomer "github.com/YoungBoyGod/remotegpu/internal/service/customer"
+	pkgStorage "github.com/YoungBoyGod/remotegpu/pkg/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupUploadTest 初始化测试数据库和本地存储后端
+func setupUploadTest(t *testing.T) (*UploadService, *gorm.DB) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+
+	err = db.Exec(`CREATE TABLE datasets (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		created_at DATETIME,
+		updated_at DATETIME,
+		deleted_at DATETIME,
+		uuid TEXT,
+		customer_id INTEGER NOT NULL,
+		workspace_id INTEGER,
+		name TEXT NOT NULL,
+		description TEXT,
+		storage_path TEXT NOT NULL DEFAULT '',
+		storage_type TEXT DEFAULT 'minio',
+		total_size INTEGER DEFAULT 0,
+		file_count INTEGER DEFAULT 0,
+		status TEXT DEFAULT 'uploading',
+		visibility TEXT DEFAULT 'private',
+		storage_backend TEXT,
+		upload_id TEXT,
+		upload_key TEXT,
+		upload_expires_at DATETIME
+	)`).Error
+	require.NoError(t, err)
+
+	mgr, err := pkgStorage.NewManager(config.StorageConfig{
+		Default: "local",
+		Backends: []config.StorageBackend{
+			{Name: "local", Type: "local", Enabled: true, Path: t.TempDir()},
+		},
+	})
+	require.NoError(t, err)
+
+	svc := NewUploadService(db, mgr, config.DatasetUploadConfig{Backend: "local", PartSize: pkgStorage.MinPartSize}, 0)
+	return svc, db
+}
+
+func uploadParts(t *testing.T, svc *UploadService, session *UploadSession, content []byte) []pkgStorage.Part {
+	ctx := context.Background()
+	var parts []pkgStorage.Part
+	for n := 1; n <= session.PartCount; n++ {
+		start := int64(n-1) * session.PartSize
+		end := min(start+session.PartSize, int64(len(content)))
+		part, err := svc.UploadPart(ctx, session.Dataset.ID, session.UploadID, n, bytes.NewReader(content[start:end]), end-start)
+		require.NoError(t, err)
+		parts = append(parts, pkgStorage.Part{PartNumber: n, ETag: `"` + part.ETag + `"`})
+	}
+	return parts
+}
+
+func TestUploadService_MultipartFlow(t *testing.T) {
+	svc, _ := setupUploadTest(t)
+	ctx := context.Background()
+	content := append(bytes.Repeat([]byte("x"), pkgStorage.MinPartSize), []byte("tail")...)
+
+	session, err := svc.InitUpload(ctx, 7, nil, "", "imagenet", "train.tar", int64(len(content)))
+	require.NoError(t, err)
+	assert.Equal(t, 2, session.PartCount)
+	assert.Equal(t, "train.tar", session.Dataset.Name)
+	assert.Equal(t, "uploading", session.Dataset.Status)
+	assert.Equal(t, "local", session.Dataset.StorageBackend)
+
+	// 本地存储不支持预签名，返回平台代理上传接口
+	urls, err := svc.PartURLs(ctx, session.Dataset.ID, session.UploadID, []int{1, 2})
+	require.NoError(t, err)
+	require.Len(t, urls, 2)
+	assert.True(t, urls[0].Proxied)
+
+	parts := uploadParts(t, svc, session, content)
+
+	uploaded, err := svc.ListParts(ctx, session.Dataset.ID, session.UploadID)
+	require.NoError(t, err)
+	assert.Len(t, uploaded, 2)
+
+	// ETag 不一致时拒绝合并，上传保持进行中
+	bad := []pkgStorage.Part{parts[0], {PartNumber: 2, ETag: "deadbeef"}}
+	_, err = svc.CompleteUpload(ctx, session.Dataset.ID, session.UploadID, "", bad)
+	assert.ErrorIs(t, err, ErrPartMismatch)
+	_, err = svc.CompleteUpload(ctx, session.Dataset.ID, session.UploadID, "", parts[:1])
+	assert.ErrorIs(t, err, ErrPartMismatch)
+
+	dataset, err := svc.CompleteUpload(ctx, session.Dataset.ID, session.UploadID, "ImageNet", parts)
+	require.NoError(t, err)
+	assert.Equal(t, "ready", dataset.Status)
+	assert.Equal(t, "ImageNet", dataset.Name)
+	assert.Equal(t, int64(len(content)), dataset.TotalSize)
+	assert.Equal(t, 1, dataset.FileCount)
+	assert.Empty(t, dataset.UploadID)
+	assert.Nil(t, dataset.UploadExpiresAt)
+
+	backend, err := svc.storageMgr.Get("local")
+	require.NoError(t, err)
+	reader, _, err := backend.Download(ctx, session.Dataset.StoragePath+"/train.tar")
+	require.NoError(t, err)
+	defer reader.Close()
+	got, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, content, got)
+
+	// 已完成的上传不能再次完成或中止
+	_, err = svc.CompleteUpload(ctx, session.Dataset.ID, session.UploadID, "", parts)
+	assert.ErrorIs(t, err, ErrUploadNotActive)
+	assert.ErrorIs(t, svc.AbortUpload(ctx, session.Dataset.ID, session.UploadID), ErrUploadNotActive)
+}
+
+func TestUploadService_InitValidation(t *testing.T) {
+	svc, _ := setupUploadTest(t)
+	ctx := context.Background()
+
+	_, err := svc.InitUpload(ctx, 1, nil, "", "", "../etc/passwd", 10)
+	assert.ErrorIs(t, err, ErrInvalidFilename)
+	_, err = svc.InitUpload(ctx, 1, nil, "", "", "a.bin", 0)
+	assert.ErrorIs(t, err, ErrInvalidUploadSize)
+
+	svc.maxSize = 100
+	_, err = svc.InitUpload(ctx, 1, nil, "", "", "a.bin", 101)
+	assert.ErrorIs(t, err, ErrInvalidUploadSize)
+
+	svc.cfg.Backend = "missing"
+	_, err = svc.InitUpload(ctx, 1, nil, "", "", "a.bin", 10)
+	assert.Error(t, err)
+}
+
+func TestUploadService_AbortAndJanitor(t *testing.T) {
+	svc, db := setupUploadTest(t)
+	ctx := context.Background()
+
+	session, err := svc.InitUpload(ctx, 1, nil, "ds", "", "a.bin", 4)
+	require.NoError(t, err)
+	_, err = svc.UploadPart(ctx, session.Dataset.ID, session.UploadID, 1, bytes.NewReader([]byte("data")), 4)
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, svc.AbortUpload(ctx, session.Dataset.ID, "other"), ErrUploadNotActive)
+	require.NoError(t, svc.AbortUpload(ctx, session.Dataset.ID, session.UploadID))
+
+	var ds entity.Dataset
+	require.NoError(t, db.First(&ds, session.Dataset.ID).Error)
+	assert.Equal(t, "aborted", ds.Status)
+	_, err = svc.ListParts(ctx, session.Dataset.ID, session.UploadID)
+	assert.ErrorIs(t, err, ErrUploadNotActive)
+
+	// 过期上传：请求被拒绝，清理任务中止后分片被删除
+	fresh, err := svc.InitUpload(ctx, 1, nil, "fresh", "", "b.bin", 4)
+	require.NoError(t, err)
+	stale, err := svc.InitUpload(ctx, 1, nil, "stale", "", "c.bin", 4)
+	require.NoError(t, err)
+	require.NoError(t, db.Model(&entity.Dataset{}).Where("id = ?", stale.Dataset.ID).
+		Update("upload_expires_at", time.Now().Add(-time.Hour)).Error)
+
+	_, err = svc.PartURLs(ctx, stale.Dataset.ID, stale.UploadID, []int{1})
+	assert.ErrorIs(t, err, ErrUploadExpired)
+
+	janitor := NewUploadJanitor(svc, time.Minute)
+	assert.Equal(t, 1, janitor.sweep(ctx))
+	assert.Equal(t, 0, janitor.sweep(ctx))
+
+	var staleDs, freshDs entity.Dataset
+	require.NoError(t, db.First(&staleDs, stale.Dataset.ID).Error)
+	assert.Equal(t, "aborted", staleDs.Status)
+	require.NoError(t, db.First(&freshDs, fresh.Dataset.ID).Error)
+	assert.Equal(t, "uploading", freshDs.Status)
+
+	backend, err := svc.backend("local")
+	require.NoError(t, err)
+	_, err = backend.ListParts(ctx, stale.Dataset.UploadKey, stale.UploadID)
+	assert.ErrorIs(t, err, pkgStorage.ErrUploadNotFound)
+}
+
+func TestPartLayout(t *testing.T) {
+	size, count := partLayout(10, 1)
+	assert.Equal(t, int64(pkgStorage.MinPartSize), size)
+	assert.Equal(t, 1, count)
+
+	size, count = partLayout(100<<20, 64<<20)
+	assert.Equal(t, int64(64<<20), size)
+	assert.Equal(t, 2, count)
+
+	// 超过分片数上限时放大分片
+	size, count = partLayout(1<<40, 64<<20)
+	assert.LessOrEqual(t, count, pkgStorage.MaxPartCount)
+	assert.GreaterOrEqual(t, size*int64(count), int64(1<<40))
+}
+
+func TestUploadService_Quota(t *testing.T) {
+	svc, db := setupUploadTest(t)
+	ctx := context.Background()
+	for _, ddl := range []string{
+		`CREATE TABLE customers (id INTEGER PRIMARY KEY AUTOINCREMENT, created_at DATETIME, updated_at DATETIME,
+			deleted_at DATETIME, username TEXT, email TEXT, quota_storage INTEGER DEFAULT 0)`,
+		`CREATE TABLE workspaces (id INTEGER PRIMARY KEY AUTOINCREMENT, owner_id INTEGER, name TEXT, quota_storage INTEGER DEFAULT 0)`,
+		`CREATE TABLE workspace_members (id INTEGER PRIMARY KEY AUTOINCREMENT, workspace_id INTEGER, customer_id INTEGER,
+			role TEXT, status TEXT DEFAULT 'active', joined_at DATETIME, created_at DATETIME)`,
+		`CREATE TABLE storage_quota_alerts (id INTEGER PRIMARY KEY AUTOINCREMENT, scope TEXT, scope_id INTEGER,
+			level INTEGER DEFAULT 0, updated_at DATETIME, UNIQUE (scope, scope_id))`,
+		`INSERT INTO customers (id, username, email, quota_storage) VALUES (1, 'u1', 'u1@test.com', 10), (2, 'u2', 'u2@test.com', 0)`,
+		`INSERT INTO workspaces (id, owner_id, name, quota_storage) VALUES (1, 1, 'team', 0)`,
+		`INSERT INTO workspace_members (workspace_id, customer_id, role) VALUES (1, 1, 'owner')`,
+	} {
+		require.NoError(t, db.Exec(ddl).Error)
+	}
+	svc.SetQuotaService(serviceCustomer.NewCustomerService(db))
+	wsID := uint(1)
+
+	// 配额 10MB：上传中的数据集按声明大小预留
+	first, err := svc.InitUpload(ctx, 1, nil, "", "", "a.bin", 6<<20)
+	require.NoError(t, err)
+	_, err = svc.InitUpload(ctx, 1, nil, "", "", "b.bin", 5<<20)
+	assert.ErrorIs(t, err, serviceCustomer.ErrQuotaExceeded)
+
+	// 中止后释放预留
+	require.NoError(t, svc.AbortUpload(ctx, first.Dataset.ID, first.UploadID))
+	session, err := svc.InitUpload(ctx, 1, &wsID, "", "", "b.bin", 5<<20)
+	require.NoError(t, err)
+	assert.Equal(t, &wsID, session.Dataset.WorkspaceID)
+
+	// 非工作空间成员不能上传到该工作空间
+	_, err = svc.InitUpload(ctx, 2, &wsID, "", "", "c.bin", 1)
+	assert.ErrorIs(t, err, ErrWorkspaceForbidden)
+
+	// 上传期间配额被调低，完成时拒绝合并
+	parts := uploadParts(t, svc, session, bytes.Repeat([]byte("x"), 5<<20))
+	require.NoError(t, db.Exec(`UPDATE customers SET quota_storage = 4 WHERE id = 1`).Error)
+	_, err = svc.CompleteUpload(ctx, session.Dataset.ID, session.UploadID, "", parts)
+	assert.ErrorIs(t, err, serviceCustomer.ErrQuotaExceeded)
+	require.NoError(t, db.Exec(`UPDATE customers SET quota_storage = 0 WHERE id = 1`).Error)
+	_, err = svc.CompleteUpload(ctx, session.Dataset.ID, session.UploadID, "", parts)
+	require.NoError(t, err)
+}
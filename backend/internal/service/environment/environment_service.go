@@ -2,30 +2,209 @@ package environment
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"regexp"
+	"sort"
 	"strconv"
+	"time"
 
+	"github.com/YoungBoyGod/remotegpu/internal/agent"
 	"github.com/YoungBoyGod/remotegpu/internal/dao"
 	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+	"github.com/YoungBoyGod/remotegpu/pkg/logger"
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+// 容器内服务端口
+const (
+	containerSSHPort     = 22
+	containerJupyterPort = 8888
+)
+
+// ErrInvalidImage 镜像名不是合法的镜像引用
+var ErrInvalidImage = errors.New("镜像名不合法")
+
+// imageRefPattern 镜像引用：[registry[:port]/]repository[:tag][@digest]，不允许以 - 开头或包含空白，
+// 避免被 Agent 拼接到 docker run 参数中时解析为选项
+var imageRefPattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._/:@+-]{0,254}$`)
+
+// ContainerManager 容器生命周期管理（由 AgentService 实现）
+type ContainerManager interface {
+	CreateContainer(ctx context.Context, req *agent.CreateContainerRequest) (*agent.ContainerInfo, error)
+	StartContainer(ctx context.Context, hostID, containerID string) error
+	StopContainer(ctx context.Context, hostID, containerID string) error
+	RemoveContainer(ctx context.Context, hostID, containerID string) error
+}
+
+// PortMapper 环境端口映射编排（由 ProxyService 实现）
+type PortMapper interface {
+	SetupEnvironmentMappings(ctx context.Context, env *entity.Environment) error
+	ReleaseEnvironmentMappings(ctx context.Context, envID string) error
+}
+
 // EnvironmentService 环境管理业务逻辑层
 type EnvironmentService struct {
-	db     *gorm.DB
-	envDao *dao.EnvironmentDao
+	db            *gorm.DB
+	envDao        *dao.EnvironmentDao
+	allocationDao *dao.AllocationDao
+	proxyDao      *dao.ProxyDao
+	containers    ContainerManager
+	portMapper    PortMapper
 }
 
 func NewEnvironmentService(db *gorm.DB) *EnvironmentService {
 	return &EnvironmentService{
-		db:     db,
-		envDao: dao.NewEnvironmentDao(db),
+		db:            db,
+		envDao:        dao.NewEnvironmentDao(db),
+		allocationDao: dao.NewAllocationDao(db),
+		proxyDao:      dao.NewProxyDao(db),
+	}
+}
+
+// SetContainerManager 注入容器管理器
+func (s *EnvironmentService) SetContainerManager(m ContainerManager) {
+	s.containers = m
+}
+
+// SetPortMapper 注入端口映射编排器
+func (s *EnvironmentService) SetPortMapper(m PortMapper) {
+	s.portMapper = m
+}
+
+// setupMappings 为运行中的环境创建 Proxy 映射
+// 映射失败不影响环境状态，访问信息会回退到主机直连端口
+func (s *EnvironmentService) setupMappings(ctx context.Context, env *entity.Environment) {
+	if s.portMapper == nil {
+		return
+	}
+	if err := s.portMapper.SetupEnvironmentMappings(ctx, env); err != nil {
+		logger.GetLogger().Warn(fmt.Sprintf("环境 %s 创建端口映射失败: %v", env.ID, err))
+	}
+}
+
+// releaseMappings 释放环境的 Proxy 映射
+func (s *EnvironmentService) releaseMappings(ctx context.Context, envID string) {
+	if s.portMapper == nil {
+		return
+	}
+	if err := s.portMapper.ReleaseEnvironmentMappings(ctx, envID); err != nil {
+		logger.GetLogger().Warn(fmt.Sprintf("环境 %s 释放端口映射失败: %v", envID, err))
 	}
 }
 
-// Create 创建环境
-func (s *EnvironmentService) Create(ctx context.Context, env *entity.Environment) error {
-	return s.envDao.Create(ctx, env)
+// Create 创建环境：写入记录后通过 Agent 在目标主机上创建并启动容器
+func (s *EnvironmentService) Create(ctx context.Context, env *entity.Environment, envVars map[string]string) error {
+	if s.containers == nil {
+		return errors.New("容器运行时未配置")
+	}
+	if env.HostID == "" {
+		return errors.New("请指定主机")
+	}
+	if !imageRefPattern.MatchString(env.Image) {
+		return ErrInvalidImage
+	}
+	gpuDevices, err := s.resolveGPUDevices(ctx, env)
+	if err != nil {
+		return err
+	}
+
+	if env.ID == "" {
+		env.ID = "env-" + uuid.New().String()
+	}
+	env.Status = "creating"
+	if err := s.envDao.Create(ctx, env); err != nil {
+		return err
+	}
+
+	info, err := s.containers.CreateContainer(ctx, &agent.CreateContainerRequest{
+		HostID:     env.HostID,
+		Name:       env.ID,
+		Image:      env.Image,
+		Env:        envVars,
+		CPU:        env.CPU,
+		MemoryMB:   env.Memory,
+		GPU:        env.GPU,
+		GPUDevices: gpuDevices,
+		Ports: []agent.ContainerPort{
+			{ContainerPort: containerSSHPort},
+			{ContainerPort: containerJupyterPort},
+		},
+		Labels: map[string]string{"remotegpu.env_id": env.ID},
+	})
+	if err != nil {
+		env.Status = "error"
+		s.updateFields(ctx, env.ID, map[string]interface{}{"status": "error"})
+		return fmt.Errorf("创建容器失败: %w", err)
+	}
+
+	now := time.Now()
+	fields := map[string]interface{}{
+		"container_id": info.ContainerID,
+		"status":       "running",
+		"started_at":   now,
+	}
+	env.ContainerID = info.ContainerID
+	env.Status = "running"
+	env.StartedAt = &now
+	for _, p := range info.Ports {
+		hostPort := p.HostPort
+		switch p.ContainerPort {
+		case containerSSHPort:
+			env.SSHPort = &hostPort
+			fields["ssh_port"] = hostPort
+		case containerJupyterPort:
+			env.JupyterPort = &hostPort
+			fields["jupyter_port"] = hostPort
+		}
+	}
+	if err := s.updateFields(ctx, env.ID, fields); err != nil {
+		return err
+	}
+	s.setupMappings(ctx, env)
+	return nil
+}
+
+// resolveGPUDevices 根据客户在主机上的分配确定容器可用的 GPU
+// 整机分配返回 nil（按 env.GPU 分配卡数）；GPU 粒度分配只能使用分配的 GPU，申请卡数不能超过分配数量
+func (s *EnvironmentService) resolveGPUDevices(ctx context.Context, env *entity.Environment) ([]int, error) {
+	allocs, err := s.allocationDao.FindAllActiveByHostAndCustomer(ctx, env.HostID, env.UserID)
+	if err != nil || len(allocs) == 0 {
+		return nil, errors.New("未分配该主机，无法创建环境")
+	}
+
+	var devices []int
+	for _, a := range allocs {
+		if len(a.GPUIndexes) == 0 || string(a.GPUIndexes) == "null" {
+			return nil, nil
+		}
+		var indexes []int
+		if err := json.Unmarshal(a.GPUIndexes, &indexes); err != nil {
+			return nil, fmt.Errorf("解析分配 %s 的 GPU 失败: %w", a.ID, err)
+		}
+		devices = append(devices, indexes...)
+	}
+	sort.Ints(devices)
+
+	switch {
+	case env.GPU < 0:
+		// 申请全部 GPU 时只给分配的 GPU
+		env.GPU = len(devices)
+	case env.GPU > len(devices):
+		return nil, fmt.Errorf("申请 %d 张 GPU，超过该主机上分配的 %d 张", env.GPU, len(devices))
+	}
+	if env.GPU == 0 {
+		return nil, nil
+	}
+	return devices[:env.GPU], nil
+}
+
+// updateFields 更新环境字段
+func (s *EnvironmentService) updateFields(ctx context.Context, id string, fields map[string]interface{}) error {
+	return s.db.WithContext(ctx).Model(&entity.Environment{}).
+		Where("id = ?", id).Updates(fields).Error
 }
 
 // GetByID 获取环境详情
@@ -57,11 +236,29 @@ func (s *EnvironmentService) Start(ctx context.Context, id string, customerID ui
 	if env.UserID != customerID {
 		return errors.New("无权操作该环境")
 	}
-	if env.Status != "stopped" {
+	// 异常环境只要容器仍在，也允许重新启动
+	if env.Status != "stopped" && !(env.Status == "error" && env.ContainerID != "") {
 		return errors.New("仅已停止的环境可启动")
 	}
-	return s.db.WithContext(ctx).Model(&entity.Environment{}).
-		Where("id = ?", id).Update("status", "running").Error
+	if s.containers == nil {
+		return errors.New("容器运行时未配置")
+	}
+	if env.ContainerID == "" {
+		return errors.New("环境容器不存在")
+	}
+
+	if err := s.containers.StartContainer(ctx, env.HostID, env.ContainerID); err != nil {
+		s.updateFields(ctx, id, map[string]interface{}{"status": "error"})
+		return fmt.Errorf("启动容器失败: %w", err)
+	}
+	if err := s.updateFields(ctx, id, map[string]interface{}{
+		"status":     "running",
+		"started_at": time.Now(),
+	}); err != nil {
+		return err
+	}
+	s.setupMappings(ctx, env)
+	return nil
 }
 
 // Stop 停止环境
@@ -76,8 +273,21 @@ func (s *EnvironmentService) Stop(ctx context.Context, id string, customerID uin
 	if env.Status != "running" {
 		return errors.New("仅运行中的环境可停止")
 	}
-	return s.db.WithContext(ctx).Model(&entity.Environment{}).
-		Where("id = ?", id).Update("status", "stopped").Error
+	if s.containers == nil {
+		return errors.New("容器运行时未配置")
+	}
+
+	if env.ContainerID != "" {
+		if err := s.containers.StopContainer(ctx, env.HostID, env.ContainerID); err != nil {
+			s.updateFields(ctx, id, map[string]interface{}{"status": "error"})
+			return fmt.Errorf("停止容器失败: %w", err)
+		}
+	}
+	s.releaseMappings(ctx, id)
+	return s.updateFields(ctx, id, map[string]interface{}{
+		"status":     "stopped",
+		"stopped_at": time.Now(),
+	})
 }
 
 // Delete 删除环境
@@ -92,6 +302,20 @@ func (s *EnvironmentService) Delete(ctx context.Context, id string, customerID u
 	if env.Status != "stopped" && env.Status != "error" {
 		return errors.New("仅已停止或异常的环境可删除")
 	}
+
+	if env.ContainerID != "" {
+		if s.containers == nil {
+			return errors.New("容器运行时未配置")
+		}
+		if err := s.updateFields(ctx, id, map[string]interface{}{"status": "deleting"}); err != nil {
+			return err
+		}
+		if err := s.containers.RemoveContainer(ctx, env.HostID, env.ContainerID); err != nil {
+			s.updateFields(ctx, id, map[string]interface{}{"status": "error"})
+			return fmt.Errorf("删除容器失败: %w", err)
+		}
+	}
+	s.releaseMappings(ctx, id)
 	return s.db.WithContext(ctx).Delete(&entity.Environment{}, "id = ?", id).Error
 }
 
@@ -144,29 +368,51 @@ func (s *EnvironmentService) GetAccessInfo(ctx context.Context, id string, custo
 		}
 	}
 
-	// 根据端口映射组装访问信息
+	// 根据端口映射组装访问信息，经 Proxy 的映射使用 Proxy 节点地址
+	proxyHosts := make(map[string]string)
 	for _, pm := range env.PortMappings {
 		if pm.Status != "active" {
 			continue
 		}
+		addr := hostAddr
+		if pm.ProxyID != "" {
+			if _, ok := proxyHosts[pm.ProxyID]; !ok {
+				if node, err := s.proxyDao.FindByID(ctx, pm.ProxyID); err == nil {
+					proxyHosts[pm.ProxyID] = node.Host
+				} else {
+					proxyHosts[pm.ProxyID] = ""
+				}
+			}
+			if h := proxyHosts[pm.ProxyID]; h != "" {
+				addr = h
+			}
+		}
 		switch pm.ServiceType {
 		case "ssh":
 			info.SSH = &SSHAccess{
-				Host:     hostAddr,
+				Host:     addr,
 				Port:     pm.ExternalPort,
 				Username: "root",
 			}
 		case "jupyter":
 			info.Jupyter = &JupyterAccess{
-				URL: "http://" + hostAddr + ":" + intToStr(pm.ExternalPort),
+				URL: "http://" + addr + ":" + intToStr(pm.ExternalPort),
 			}
 		case "vnc", "rdp":
 			info.VNC = &VNCAccess{
-				URL: "http://" + hostAddr + ":" + intToStr(pm.ExternalPort),
+				URL: "http://" + addr + ":" + intToStr(pm.ExternalPort),
 			}
 		}
 	}
 
+	// 未建立 Proxy 映射时回退到主机直连端口
+	if info.SSH == nil && env.SSHPort != nil {
+		info.SSH = &SSHAccess{Host: hostAddr, Port: *env.SSHPort, Username: "root"}
+	}
+	if info.Jupyter == nil && env.JupyterPort != nil {
+		info.Jupyter = &JupyterAccess{URL: "http://" + hostAddr + ":" + intToStr(*env.JupyterPort)}
+	}
+
 	return info, nil
 }
 
@@ -0,0 +1,423 @@
+package environment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/YoungBoyGod/remotegpu/internal/agent"
+	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// fakeContainerManager 内存中的容器管理器
+type fakeContainerManager struct {
+	seq      int
+	running  map[string]bool
+	failNext error
+	lastReq  *agent.CreateContainerRequest
+}
+
+func newFakeContainerManager() *fakeContainerManager {
+	return &fakeContainerManager{running: make(map[string]bool)}
+}
+
+func (f *fakeContainerManager) takeErr() error {
+	err := f.failNext
+	f.failNext = nil
+	return err
+}
+
+func (f *fakeContainerManager) CreateContainer(ctx context.Context, req *agent.CreateContainerRequest) (*agent.ContainerInfo, error) {
+	if err := f.takeErr(); err != nil {
+		return nil, err
+	}
+	f.seq++
+	f.lastReq = req
+	id := fmt.Sprintf("ctr-%d", f.seq)
+	f.running[id] = true
+	info := &agent.ContainerInfo{ContainerID: id}
+	for i, p := range req.Ports {
+		info.Ports = append(info.Ports, agent.ContainerPort{ContainerPort: p.ContainerPort, HostPort: 32768 + i})
+	}
+	return info, nil
+}
+
+func (f *fakeContainerManager) StartContainer(ctx context.Context, hostID, containerID string) error {
+	if err := f.takeErr(); err != nil {
+		return err
+	}
+	f.running[containerID] = true
+	return nil
+}
+
+func (f *fakeContainerManager) StopContainer(ctx context.Context, hostID, containerID string) error {
+	if err := f.takeErr(); err != nil {
+		return err
+	}
+	f.running[containerID] = false
+	return nil
+}
+
+func (f *fakeContainerManager) RemoveContainer(ctx context.Context, hostID, containerID string) error {
+	if err := f.takeErr(); err != nil {
+		return err
+	}
+	delete(f.running, containerID)
+	return nil
+}
+
+func setupEnvTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+
+	err = db.Exec(`CREATE TABLE customers (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		created_at DATETIME,
+		updated_at DATETIME,
+		deleted_at DATETIME,
+		username TEXT NOT NULL,
+		email TEXT NOT NULL,
+		password_hash TEXT NOT NULL DEFAULT '',
+		role TEXT DEFAULT 'customer_owner',
+		status TEXT DEFAULT 'active'
+	)`).Error
+	require.NoError(t, err)
+
+	err = db.Exec(`CREATE TABLE hosts (
+		id VARCHAR(64) PRIMARY KEY,
+		name VARCHAR(128) NOT NULL DEFAULT '',
+		ip_address VARCHAR(64) NOT NULL DEFAULT '',
+		public_ip VARCHAR(64),
+		external_ip VARCHAR(64),
+		total_cpu INTEGER NOT NULL DEFAULT 0,
+		total_memory_gb INTEGER NOT NULL DEFAULT 0,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`).Error
+	require.NoError(t, err)
+
+	err = db.Exec(`CREATE TABLE allocations (
+		id VARCHAR(64) PRIMARY KEY,
+		customer_id INTEGER NOT NULL,
+		host_id VARCHAR(64) NOT NULL,
+		workspace_id INTEGER,
+		gpu_indexes TEXT,
+		gpu_count INTEGER DEFAULT 0,
+		start_time DATETIME NOT NULL,
+		end_time DATETIME NOT NULL,
+		actual_end_time DATETIME,
+		expiry_warned_at DATETIME,
+		status VARCHAR(32) DEFAULT 'active',
+		remark TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`).Error
+	require.NoError(t, err)
+
+	err = db.Exec(`CREATE TABLE environments (
+		id VARCHAR(64) PRIMARY KEY,
+		user_id INTEGER NOT NULL,
+		workspace_id INTEGER,
+		host_id VARCHAR(64) NOT NULL,
+		name VARCHAR(128) NOT NULL,
+		description TEXT,
+		image VARCHAR(256) NOT NULL,
+		status VARCHAR(20) DEFAULT 'creating',
+		cpu INTEGER NOT NULL,
+		memory INTEGER NOT NULL,
+		gpu INTEGER DEFAULT 0,
+		storage INTEGER,
+		ssh_port INTEGER,
+		rdp_port INTEGER,
+		jupyter_port INTEGER,
+		container_id VARCHAR(128),
+		pod_name VARCHAR(128),
+		created_at DATETIME,
+		updated_at DATETIME,
+		started_at DATETIME,
+		stopped_at DATETIME
+	)`).Error
+	require.NoError(t, err)
+
+	err = db.Exec(`CREATE TABLE port_mappings (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		env_id VARCHAR(64) NOT NULL,
+		service_type VARCHAR(32) NOT NULL,
+		external_port INTEGER NOT NULL UNIQUE,
+		internal_port INTEGER NOT NULL,
+		status VARCHAR(20) DEFAULT 'active',
+		allocated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		released_at DATETIME,
+		proxy_id VARCHAR(64),
+		target_host VARCHAR(256),
+		target_port INTEGER,
+		protocol VARCHAR(10) DEFAULT 'tcp'
+	)`).Error
+	require.NoError(t, err)
+
+	err = db.Exec(`CREATE TABLE proxy_nodes (
+		id VARCHAR(64) PRIMARY KEY,
+		name VARCHAR(128) NOT NULL,
+		host VARCHAR(256) NOT NULL,
+		api_port INTEGER DEFAULT 9090,
+		status VARCHAR(20) DEFAULT 'offline',
+		last_heartbeat DATETIME,
+		created_at DATETIME,
+		updated_at DATETIME
+	)`).Error
+	require.NoError(t, err)
+
+	require.NoError(t, db.Exec(`INSERT INTO customers (id, username, email) VALUES (1, 'alice', 'alice@test.com')`).Error)
+	require.NoError(t, db.Exec(`INSERT INTO hosts (id, name, ip_address) VALUES ('host-1', 'node-01', '10.0.0.1')`).Error)
+	require.NoError(t, db.Exec(`INSERT INTO allocations (id, customer_id, host_id, start_time, end_time, status)
+		VALUES ('alloc-1', 1, 'host-1', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, 'active')`).Error)
+
+	return db
+}
+
+func newTestEnvironment() *entity.Environment {
+	return &entity.Environment{
+		UserID: 1,
+		HostID: "host-1",
+		Name:   "dev",
+		Image:  "pytorch:latest",
+		CPU:    4,
+		Memory: 8192,
+		GPU:    1,
+	}
+}
+
+func TestEnvironmentService_Lifecycle(t *testing.T) {
+	db := setupEnvTestDB(t)
+	svc := NewEnvironmentService(db)
+	mgr := newFakeContainerManager()
+	svc.SetContainerManager(mgr)
+	ctx := context.Background()
+
+	env := newTestEnvironment()
+	require.NoError(t, svc.Create(ctx, env, map[string]string{"FOO": "bar"}))
+	assert.NotEmpty(t, env.ID)
+	assert.Equal(t, env.ID, mgr.lastReq.Name)
+	assert.Equal(t, int64(8192), mgr.lastReq.MemoryMB)
+	assert.Equal(t, "bar", mgr.lastReq.Env["FOO"])
+
+	got, err := svc.GetByID(ctx, env.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "running", got.Status)
+	assert.Equal(t, "ctr-1", got.ContainerID)
+	require.NotNil(t, got.SSHPort)
+	require.NotNil(t, got.JupyterPort)
+	assert.NotNil(t, got.StartedAt)
+
+	require.NoError(t, svc.Stop(ctx, env.ID, 1))
+	got, _ = svc.GetByID(ctx, env.ID)
+	assert.Equal(t, "stopped", got.Status)
+	assert.False(t, mgr.running["ctr-1"])
+
+	require.NoError(t, svc.Start(ctx, env.ID, 1))
+	got, _ = svc.GetByID(ctx, env.ID)
+	assert.Equal(t, "running", got.Status)
+	assert.True(t, mgr.running["ctr-1"])
+
+	require.NoError(t, svc.Stop(ctx, env.ID, 1))
+	require.NoError(t, svc.Delete(ctx, env.ID, 1))
+	_, err = svc.GetByID(ctx, env.ID)
+	assert.Error(t, err)
+	assert.NotContains(t, mgr.running, "ctr-1")
+}
+
+func TestEnvironmentService_CreateRequiresAllocation(t *testing.T) {
+	db := setupEnvTestDB(t)
+	svc := NewEnvironmentService(db)
+	svc.SetContainerManager(newFakeContainerManager())
+
+	env := newTestEnvironment()
+	env.HostID = "host-2"
+	err := svc.Create(context.Background(), env, nil)
+	assert.EqualError(t, err, "未分配该主机，无法创建环境")
+
+	var count int64
+	db.Model(&entity.Environment{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestEnvironmentService_CreateRejectsInvalidImage(t *testing.T) {
+	db := setupEnvTestDB(t)
+	svc := NewEnvironmentService(db)
+	mgr := newFakeContainerManager()
+	svc.SetContainerManager(mgr)
+
+	for _, image := range []string{"", "--privileged", "-v/:/host", "alpine --privileged", "alpine\n", "ubuntu;id"} {
+		env := newTestEnvironment()
+		env.Image = image
+		assert.ErrorIs(t, svc.Create(context.Background(), env, nil), ErrInvalidImage, image)
+	}
+	assert.Nil(t, mgr.lastReq)
+
+	for _, image := range []string{"pytorch/pytorch:2.1.0-cuda12.1", "registry.local:5000/team/img@sha256:abcd", "nvidia/cuda:12.2.0-base-ubuntu22.04"} {
+		env := newTestEnvironment()
+		env.Image = image
+		require.NoError(t, svc.Create(context.Background(), env, nil), image)
+	}
+}
+
+func TestEnvironmentService_CreateRestrictsAllocatedGPUs(t *testing.T) {
+	db := setupEnvTestDB(t)
+	require.NoError(t, db.Exec(`UPDATE allocations SET gpu_indexes = '[5]', gpu_count = 1 WHERE id = 'alloc-1'`).Error)
+	require.NoError(t, db.Exec(`INSERT INTO allocations (id, customer_id, host_id, gpu_indexes, gpu_count, start_time, end_time, status)
+		VALUES ('alloc-2', 1, 'host-1', '[2]', 1, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, 'active')`).Error)
+	svc := NewEnvironmentService(db)
+	mgr := newFakeContainerManager()
+	svc.SetContainerManager(mgr)
+	ctx := context.Background()
+
+	env := newTestEnvironment()
+	require.NoError(t, svc.Create(ctx, env, nil))
+	assert.Equal(t, []int{2}, mgr.lastReq.GPUDevices)
+
+	// 申请全部 GPU 时只分配客户名下的 GPU
+	env = newTestEnvironment()
+	env.GPU = -1
+	require.NoError(t, svc.Create(ctx, env, nil))
+	assert.Equal(t, []int{2, 5}, mgr.lastReq.GPUDevices)
+	assert.Equal(t, 2, env.GPU)
+
+	// 超过分配数量
+	env = newTestEnvironment()
+	env.GPU = 3
+	assert.EqualError(t, svc.Create(ctx, env, nil), "申请 3 张 GPU，超过该主机上分配的 2 张")
+	var count int64
+	db.Model(&entity.Environment{}).Count(&count)
+	assert.Equal(t, int64(2), count)
+}
+
+func TestEnvironmentService_CreateWholeHostAllocation(t *testing.T) {
+	db := setupEnvTestDB(t)
+	svc := NewEnvironmentService(db)
+	mgr := newFakeContainerManager()
+	svc.SetContainerManager(mgr)
+
+	env := newTestEnvironment()
+	env.GPU = -1
+	require.NoError(t, svc.Create(context.Background(), env, nil))
+	assert.Nil(t, mgr.lastReq.GPUDevices)
+	assert.Equal(t, -1, mgr.lastReq.GPU)
+}
+
+func TestEnvironmentService_ContainerFailureMarksError(t *testing.T) {
+	db := setupEnvTestDB(t)
+	svc := NewEnvironmentService(db)
+	mgr := newFakeContainerManager()
+	svc.SetContainerManager(mgr)
+	ctx := context.Background()
+
+	// 创建失败
+	mgr.failNext = errors.New("pull image failed")
+	env := newTestEnvironment()
+	assert.Error(t, svc.Create(ctx, env, nil))
+	got, err := svc.GetByID(ctx, env.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "error", got.Status)
+	assert.Empty(t, got.ContainerID)
+
+	// 无容器的异常环境可直接删除
+	require.NoError(t, svc.Delete(ctx, env.ID, 1))
+
+	// 停止失败
+	env = newTestEnvironment()
+	require.NoError(t, svc.Create(ctx, env, nil))
+	mgr.failNext = errors.New("daemon unavailable")
+	assert.Error(t, svc.Stop(ctx, env.ID, 1))
+	got, _ = svc.GetByID(ctx, env.ID)
+	assert.Equal(t, "error", got.Status)
+
+	// 容器仍在的异常环境可重新启动
+	require.NoError(t, svc.Start(ctx, env.ID, 1))
+	got, _ = svc.GetByID(ctx, env.ID)
+	assert.Equal(t, "running", got.Status)
+}
+
+// fakePortMapper 记录映射编排调用
+type fakePortMapper struct {
+	setup    []string
+	released []string
+}
+
+func (f *fakePortMapper) SetupEnvironmentMappings(ctx context.Context, env *entity.Environment) error {
+	f.setup = append(f.setup, env.ID)
+	return nil
+}
+
+func (f *fakePortMapper) ReleaseEnvironmentMappings(ctx context.Context, envID string) error {
+	f.released = append(f.released, envID)
+	return nil
+}
+
+func TestEnvironmentService_PortMappingLifecycle(t *testing.T) {
+	db := setupEnvTestDB(t)
+	svc := NewEnvironmentService(db)
+	svc.SetContainerManager(newFakeContainerManager())
+	mapper := &fakePortMapper{}
+	svc.SetPortMapper(mapper)
+	ctx := context.Background()
+
+	env := newTestEnvironment()
+	require.NoError(t, svc.Create(ctx, env, nil))
+	require.NoError(t, svc.Stop(ctx, env.ID, 1))
+	require.NoError(t, svc.Start(ctx, env.ID, 1))
+	require.NoError(t, svc.Stop(ctx, env.ID, 1))
+	require.NoError(t, svc.Delete(ctx, env.ID, 1))
+
+	assert.Equal(t, []string{env.ID, env.ID}, mapper.setup)
+	// 两次停止和一次删除均释放映射
+	assert.Equal(t, []string{env.ID, env.ID, env.ID}, mapper.released)
+}
+
+func TestEnvironmentService_GetAccessInfo(t *testing.T) {
+	db := setupEnvTestDB(t)
+	svc := NewEnvironmentService(db)
+	svc.SetContainerManager(newFakeContainerManager())
+	ctx := context.Background()
+
+	env := newTestEnvironment()
+	require.NoError(t, svc.Create(ctx, env, nil))
+
+	// 无 Proxy 映射时回退到主机直连端口
+	info, err := svc.GetAccessInfo(ctx, env.ID, 1)
+	require.NoError(t, err)
+	require.NotNil(t, info.SSH)
+	assert.Equal(t, "10.0.0.1", info.SSH.Host)
+	assert.Equal(t, *env.SSHPort, info.SSH.Port)
+	require.NotNil(t, info.Jupyter)
+
+	// 经 Proxy 的映射使用 Proxy 节点地址
+	require.NoError(t, db.Exec(`INSERT INTO proxy_nodes (id, name, host, status) VALUES ('proxy-1', 'proxy-1', '1.2.3.4', 'online')`).Error)
+	require.NoError(t, db.Create(&entity.PortMapping{
+		EnvID: env.ID, ServiceType: "ssh", ExternalPort: 20001, InternalPort: 22,
+		Status: "active", ProxyID: "proxy-1", TargetHost: "10.0.0.1", TargetPort: *env.SSHPort,
+	}).Error)
+
+	info, err = svc.GetAccessInfo(ctx, env.ID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "1.2.3.4", info.SSH.Host)
+	assert.Equal(t, 20001, info.SSH.Port)
+}
+
+func TestEnvironmentService_PermissionAndState(t *testing.T) {
+	db := setupEnvTestDB(t)
+	svc := NewEnvironmentService(db)
+	svc.SetContainerManager(newFakeContainerManager())
+	ctx := context.Background()
+
+	env := newTestEnvironment()
+	require.NoError(t, svc.Create(ctx, env, nil))
+
+	assert.EqualError(t, svc.Stop(ctx, env.ID, 2), "无权操作该环境")
+	assert.EqualError(t, svc.Start(ctx, env.ID, 1), "仅已停止的环境可启动")
+	assert.EqualError(t, svc.Delete(ctx, env.ID, 1), "仅已停止或异常的环境可删除")
+}
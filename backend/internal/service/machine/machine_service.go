@@ -17,15 +17,23 @@ import (
 type MachineService struct {
 	machineDao    *dao.MachineDao
 	hostMetricDao *dao.HostMetricDao
+	gpuMetricDao  *dao.GPUMetricDao
 	allocationDao *dao.AllocationDao
 	db            *gorm.DB
 	statusCache   *HostStatusCache
+	observers     []HeartbeatObserver
+}
+
+// HeartbeatObserver 心跳指标观察者，用于告警评估等需要实时指标的组件
+type HeartbeatObserver interface {
+	OnHeartbeat(ctx context.Context, hostID string, metrics *HeartbeatMetrics)
 }
 
 func NewMachineService(db *gorm.DB) *MachineService {
 	return &MachineService{
 		machineDao:    dao.NewMachineDao(db),
 		hostMetricDao: dao.NewHostMetricDao(db),
+		gpuMetricDao:  dao.NewGPUMetricDao(db),
 		allocationDao: dao.NewAllocationDao(db),
 		db:            db,
 	}
@@ -36,6 +44,11 @@ func (s *MachineService) SetStatusCache(c *HostStatusCache) {
 	s.statusCache = c
 }
 
+// AddHeartbeatObserver 注册心跳指标观察者
+func (s *MachineService) AddHeartbeatObserver(o HeartbeatObserver) {
+	s.observers = append(s.observers, o)
+}
+
 // GetStatusCache 获取设备状态缓存
 func (s *MachineService) GetStatusCache() *HostStatusCache {
 	return s.statusCache
@@ -287,6 +300,36 @@ func (s *MachineService) GetConnectionInfo(ctx context.Context, hostID string) (
 	}, nil
 }
 
+// GetTenantConnectionInfo GPU 粒度分配客户的连接信息：使用客户的独立账号和 SSH 公钥登录，
+// 不返回机器共享账号的密码以及 Jupyter、VNC 等整机凭证
+func (s *MachineService) GetTenantConnectionInfo(ctx context.Context, hostID, username string) (map[string]interface{}, error) {
+	host, err := s.machineDao.FindByID(ctx, hostID)
+	if err != nil {
+		return nil, err
+	}
+
+	connectHost := host.SSHHost
+	if connectHost == "" {
+		connectHost = host.PublicIP
+	}
+	if connectHost == "" {
+		connectHost = host.IPAddress
+	}
+	port := host.SSHPort
+	if port == 0 {
+		port = 22
+	}
+
+	return map[string]interface{}{
+		"ssh": map[string]interface{}{
+			"username": username,
+			"host":     connectHost,
+			"port":     port,
+		},
+		"ssh_command": fmt.Sprintf("ssh -p %d %s@%s", port, username, connectHost),
+	}, nil
+}
+
 // GetMachineDetail 获取机器详情（包含 SSH 连接信息）
 func (s *MachineService) GetMachineDetail(ctx context.Context, hostID string) (map[string]interface{}, error) {
 	host, err := s.machineDao.FindByID(ctx, hostID)
@@ -397,6 +440,16 @@ func (s *MachineService) GetStatusStats(ctx context.Context) (map[string]int64,
 	return s.machineDao.GetStatusStats(ctx)
 }
 
+// GetGPUTrend 获取所有 GPU 按小时汇总的平均利用率趋势（来自心跳指标降采样数据）
+func (s *MachineService) GetGPUTrend(ctx context.Context, since time.Time) ([]dao.GPUTrendBucket, error) {
+	return s.gpuMetricDao.UtilizationTrend(ctx, entity.GPUMetricResolutionHour, since)
+}
+
+// GetAvgGPUUtilization 获取所有 GPU 自指定时间以来的平均利用率，没有心跳指标时返回 nil
+func (s *MachineService) GetAvgGPUUtilization(ctx context.Context, since time.Time) (*float64, error) {
+	return s.gpuMetricDao.AvgUtilizationSince(ctx, since)
+}
+
 // UpdateMachine 更新机器信息
 func (s *MachineService) UpdateMachine(ctx context.Context, hostID string, fields map[string]interface{}) error {
 	// IP 唯一性校验：如果更新了 ip_address，检查是否与其他机器冲突
@@ -451,7 +504,7 @@ func (s *MachineService) DeleteMachine(ctx context.Context, hostID string) error
 	if err != nil {
 		return err
 	}
-	if host.AllocationStatus == "allocated" {
+	if host.AllocationStatus == "allocated" || host.AllocationStatus == "partial" {
 		return fmt.Errorf("cannot delete machine: currently allocated to a customer")
 	}
 	return s.machineDao.Delete(ctx, hostID)
@@ -472,16 +525,9 @@ func (s *MachineService) UpdateAllocationStatus(ctx context.Context, hostID stri
 	return s.machineDao.UpdateAllocationStatus(ctx, hostID, allocationStatus)
 }
 
-// ResolvePostMaintenanceStatus 取消维护时，根据是否有活跃分配决定恢复为 allocated 还是 idle
+// ResolvePostMaintenanceStatus 取消维护时，根据活跃分配决定恢复为 allocated、partial 还是 idle
 func (s *MachineService) ResolvePostMaintenanceStatus(ctx context.Context, hostID string) (string, error) {
-	_, err := s.allocationDao.FindActiveByHostID(ctx, hostID)
-	if err == nil {
-		return "allocated", nil
-	}
-	if errors.Is(err, gorm.ErrRecordNotFound) {
-		return "idle", nil
-	}
-	return "", err
+	return s.allocationDao.HostAllocationStatus(ctx, hostID)
 }
 
 // BatchSetMaintenance 批量设置维护状态
@@ -565,8 +611,9 @@ func (s *MachineService) HeartbeatWithMetrics(ctx context.Context, hostID string
 		}
 	}
 
-	// 监控指标仍然写入 host_metrics 表
+	// 监控指标仍然写入 host_metrics 表，GPU 指标逐卡写入 gpu_metrics 表
 	if metrics != nil {
+		now := time.Now()
 		metric := &entity.HostMetric{
 			HostID:             hostID,
 			CPUUsagePercent:    metrics.CPUUsagePercent,
@@ -574,16 +621,52 @@ func (s *MachineService) HeartbeatWithMetrics(ctx context.Context, hostID string
 			MemoryUsedGB:       metrics.MemoryUsedGB,
 			DiskUsagePercent:   metrics.DiskUsagePercent,
 			DiskUsedGB:         metrics.DiskUsedGB,
-			CollectedAt:        time.Now(),
+			CollectedAt:        now,
 		}
 		if err := s.hostMetricDao.Create(ctx, metric); err != nil {
 			return fmt.Errorf("保存心跳指标失败: %w", err)
 		}
+		if err := s.gpuMetricDao.BatchCreate(ctx, buildGPUMetrics(hostID, metrics.GPUMetrics, now)); err != nil {
+			return fmt.Errorf("保存 GPU 指标失败: %w", err)
+		}
+
+		for _, o := range s.observers {
+			o.OnHeartbeat(ctx, hostID, metrics)
+		}
 	}
 
 	return nil
 }
 
+// buildGPUMetrics 把心跳中的 GPU 指标转换为逐卡的原始指标记录
+func buildGPUMetrics(hostID string, data []GPUMetricData, collectedAt time.Time) []entity.GPUMetric {
+	metrics := make([]entity.GPUMetric, 0, len(data))
+	for _, g := range data {
+		m := entity.GPUMetric{
+			HostID:             hostID,
+			GPUUUID:            g.UUID,
+			GPUIndex:           g.Index,
+			UtilizationPercent: g.UtilPercent,
+			PowerDraw:          g.PowerUsageW,
+			CollectedAt:        collectedAt,
+		}
+		if g.MemoryUsedMB != nil {
+			used := int64(*g.MemoryUsedMB)
+			m.MemoryUsed = &used
+			if g.MemoryTotalMB != nil && *g.MemoryTotalMB > 0 {
+				pct := float64(*g.MemoryUsedMB) / float64(*g.MemoryTotalMB) * 100
+				m.MemoryUsagePercent = &pct
+			}
+		}
+		if g.TemperatureC != nil {
+			temp := float64(*g.TemperatureC)
+			m.Temperature = &temp
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics
+}
+
 // RegisterAgent 处理 Agent 注册
 func (s *MachineService) RegisterAgent(ctx context.Context, info *AgentRegistration) error {
 	fields := make(map[string]interface{})
@@ -5,34 +5,69 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/YoungBoyGod/remotegpu/config"
 	"github.com/YoungBoyGod/remotegpu/internal/dao"
 	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
 	"github.com/YoungBoyGod/remotegpu/pkg/logger"
 	"gorm.io/gorm"
 )
 
+// rollupGrace 原始指标降采样前等待的时长，避免遗漏延迟到达的心跳
+const rollupGrace = 2 * time.Minute
+
+// rollupLevel 一级降采样：把 source 粒度（为空表示原始指标）汇总为 resolution 粒度
+type rollupLevel struct {
+	resolution string
+	source     string
+	step       time.Duration
+	chunk      time.Duration // 单次查询的时间跨度
+}
+
+var rollupLevels = []rollupLevel{
+	{resolution: entity.GPUMetricResolutionMinute, step: time.Minute, chunk: time.Hour},
+	{resolution: entity.GPUMetricResolutionHour, source: entity.GPUMetricResolutionMinute, step: time.Hour, chunk: 24 * time.Hour},
+	{resolution: entity.GPUMetricResolutionDay, source: entity.GPUMetricResolutionHour, step: 24 * time.Hour, chunk: 30 * 24 * time.Hour},
+}
+
 // MetricsCollector 监控数据采集器
 // @author Claude
-// @description 定期采集机器的监控数据
-// @modified 2026-02-06
+// @description 心跳上报的 GPU 指标逐级降采样（1m/1h/1d），并按保留策略清理监控数据
+// @modified 2026-10-17
 type MetricsCollector struct {
-	db             *gorm.DB
-	metricDao      *dao.HostMetricDao
-	machineDao     *dao.MachineDao
-	interval       time.Duration
-	retentionDays  int
-	stopCh         chan struct{}
+	db           *gorm.DB
+	metricDao    *dao.HostMetricDao
+	gpuMetricDao *dao.GPUMetricDao
+	interval     time.Duration
+	cfg          config.MetricsCollectorConfig
+	now          func() time.Time
+	stopCh       chan struct{}
 }
 
 // NewMetricsCollector 创建监控数据采集器
-func NewMetricsCollector(db *gorm.DB, interval time.Duration, retentionDays int) *MetricsCollector {
+func NewMetricsCollector(db *gorm.DB, cfg config.MetricsCollectorConfig) *MetricsCollector {
+	if cfg.Interval <= 0 {
+		cfg.Interval = 60
+	}
+	if cfg.RawRetentionHours <= 0 {
+		cfg.RawRetentionHours = 24
+	}
+	if cfg.MinuteRetentionDays <= 0 {
+		cfg.MinuteRetentionDays = 7
+	}
+	if cfg.HourRetentionDays <= 0 {
+		cfg.HourRetentionDays = 90
+	}
+	if cfg.DayRetentionDays <= 0 {
+		cfg.DayRetentionDays = 730
+	}
 	return &MetricsCollector{
-		db:            db,
-		metricDao:     dao.NewHostMetricDao(db),
-		machineDao:    dao.NewMachineDao(db),
-		interval:      interval,
-		retentionDays: retentionDays,
-		stopCh:        make(chan struct{}),
+		db:           db,
+		metricDao:    dao.NewHostMetricDao(db),
+		gpuMetricDao: dao.NewGPUMetricDao(db),
+		interval:     time.Duration(cfg.Interval) * time.Second,
+		cfg:          cfg,
+		now:          time.Now,
+		stopCh:       make(chan struct{}),
 	}
 }
 
@@ -59,56 +94,207 @@ func (c *MetricsCollector) collectLoop(ctx context.Context) {
 		case <-c.stopCh:
 			return
 		case <-ticker.C:
-			c.collectMetrics(ctx)
+			c.rollupMetrics(ctx)
 		}
 	}
 }
 
-func (c *MetricsCollector) collectMetrics(ctx context.Context) {
-	// 获取所有在线的机器
-	hosts, err := c.machineDao.ListOnline(ctx)
-	if err != nil {
-		logger.GetLogger().Error(fmt.Sprintf("查询在线机器失败: %v", err))
-		return
+// rollupMetrics 依次执行各级降采样，上一级完成的时间桶决定下一级可汇总的范围
+func (c *MetricsCollector) rollupMetrics(ctx context.Context) {
+	for _, level := range rollupLevels {
+		count, err := c.rollupLevel(ctx, level)
+		if err != nil {
+			logger.GetLogger().Error(fmt.Sprintf("GPU 指标 %s 降采样失败: %v", level.resolution, err))
+			return
+		}
+		if count > 0 {
+			logger.GetLogger().Debug(fmt.Sprintf("GPU 指标 %s 降采样写入 %d 条", level.resolution, count))
+		}
 	}
+}
 
-	if len(hosts) == 0 {
-		return
+// rollupLevel 汇总一级降采样，从该级最新时间桶之后开始，到来源数据已完整的时间桶为止，跳过没有数据的区间
+func (c *MetricsCollector) rollupLevel(ctx context.Context, level rollupLevel) (int, error) {
+	end, err := c.sourceComplete(ctx, level)
+	if err != nil || end == nil {
+		return 0, err
+	}
+	var since time.Time
+	latest, err := c.gpuMetricDao.LatestRollup(ctx, level.resolution)
+	if err != nil {
+		return 0, err
+	}
+	if latest != nil {
+		since = latest.Add(level.step)
 	}
 
-	logger.GetLogger().Info(fmt.Sprintf("开始采集 %d 台机器的监控数据", len(hosts)))
+	total := 0
+	for {
+		next, err := c.nextSource(ctx, level, since)
+		if err != nil || next == nil {
+			return total, err
+		}
+		start := next.Truncate(level.step)
+		if !start.Before(*end) {
+			return total, nil
+		}
+		chunkEnd := start.Add(level.chunk)
+		if chunkEnd.After(*end) {
+			chunkEnd = *end
+		}
+		rollups, err := c.aggregate(ctx, level, start, chunkEnd)
+		if err != nil {
+			return total, err
+		}
+		if err := c.gpuMetricDao.SaveRollups(ctx, rollups); err != nil {
+			return total, err
+		}
+		total += len(rollups)
+		since = chunkEnd
+	}
+}
 
-	// TODO: 这里暂时生成模拟数据，后续需要从Agent获取真实数据
-	metrics := make([]*entity.HostMetric, 0, len(hosts))
-	now := time.Now()
+// sourceComplete 来源数据已完整的截止时间（按本级粒度对齐），没有来源数据时返回 nil
+func (c *MetricsCollector) sourceComplete(ctx context.Context, level rollupLevel) (*time.Time, error) {
+	if level.source == "" {
+		end := c.now().Add(-rollupGrace).Truncate(level.step)
+		return &end, nil
+	}
+	latest, err := c.gpuMetricDao.LatestRollup(ctx, level.source)
+	if err != nil || latest == nil {
+		return nil, err
+	}
+	var sourceStep time.Duration
+	for _, l := range rollupLevels {
+		if l.resolution == level.source {
+			sourceStep = l.step
+		}
+	}
+	end := latest.Add(sourceStep).Truncate(level.step)
+	return &end, nil
+}
 
-	for _, host := range hosts {
-		metric := c.generateMockMetric(host.ID, now)
-		metrics = append(metrics, metric)
+// nextSource 不早于 since 的第一条来源数据时间
+func (c *MetricsCollector) nextSource(ctx context.Context, level rollupLevel, since time.Time) (*time.Time, error) {
+	if level.source == "" {
+		return c.gpuMetricDao.FirstRawSince(ctx, since)
 	}
+	return c.gpuMetricDao.FirstRollupSince(ctx, level.source, since)
+}
+
+type rollupKey struct {
+	hostID      string
+	gpuUUID     string
+	gpuIndex    int
+	bucketStart int64
+}
+
+// weightedAvg 按权重累加的平均值，没有样本时结果为 nil
+type weightedAvg struct {
+	sum    float64
+	weight float64
+}
 
-	// 批量保存监控数据
-	if err := c.metricDao.BatchCreate(ctx, metrics); err != nil {
-		logger.GetLogger().Error(fmt.Sprintf("保存监控数据失败: %v", err))
+func (a *weightedAvg) add(v *float64, weight float64) {
+	if v == nil || weight <= 0 {
 		return
 	}
+	a.sum += *v * weight
+	a.weight += weight
+}
 
-	logger.GetLogger().Info(fmt.Sprintf("成功采集 %d 条监控数据", len(metrics)))
+func (a *weightedAvg) value() *float64 {
+	if a.weight == 0 {
+		return nil
+	}
+	v := a.sum / a.weight
+	return &v
+}
+
+func maxOf(cur, v *float64) *float64 {
+	if v == nil || (cur != nil && *cur >= *v) {
+		return cur
+	}
+	m := *v
+	return &m
 }
 
-// generateMockMetric 生成模拟监控数据（临时使用）
-func (c *MetricsCollector) generateMockMetric(hostID string, collectedAt time.Time) *entity.HostMetric {
-	cpuUsage := 45.5
-	memUsage := 60.2
-	diskUsage := 35.8
+type rollupAccumulator struct {
+	rollup      entity.GPUMetricRollup
+	util        weightedAvg
+	memoryUsed  weightedAvg
+	memoryUsage weightedAvg
+	power       weightedAvg
+}
+
+// aggregate 把 [start, end) 内的来源数据按 GPU 和时间桶汇总，均值按采样数加权，最大值取各样本最大值
+func (c *MetricsCollector) aggregate(ctx context.Context, level rollupLevel, start, end time.Time) ([]entity.GPUMetricRollup, error) {
+	accs := make(map[rollupKey]*rollupAccumulator)
+	var order []rollupKey
+	accumulator := func(hostID, gpuUUID string, gpuIndex int, at time.Time) *rollupAccumulator {
+		bucket := at.UTC().Truncate(level.step)
+		key := rollupKey{hostID: hostID, gpuUUID: gpuUUID, gpuIndex: gpuIndex, bucketStart: bucket.Unix()}
+		acc, ok := accs[key]
+		if !ok {
+			acc = &rollupAccumulator{rollup: entity.GPUMetricRollup{
+				Resolution:  level.resolution,
+				HostID:      hostID,
+				GPUUUID:     gpuUUID,
+				GPUIndex:    gpuIndex,
+				BucketStart: bucket,
+			}}
+			accs[key] = acc
+			order = append(order, key)
+		}
+		return acc
+	}
+
+	if level.source == "" {
+		metrics, err := c.gpuMetricDao.ListRaw(ctx, start, end)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range metrics {
+			acc := accumulator(m.HostID, m.GPUUUID, m.GPUIndex, m.CollectedAt)
+			acc.rollup.SampleCount++
+			acc.util.add(m.UtilizationPercent, 1)
+			if m.MemoryUsed != nil {
+				used := float64(*m.MemoryUsed)
+				acc.memoryUsed.add(&used, 1)
+			}
+			acc.memoryUsage.add(m.MemoryUsagePercent, 1)
+			acc.power.add(m.PowerDraw, 1)
+			acc.rollup.UtilMax = maxOf(acc.rollup.UtilMax, m.UtilizationPercent)
+			acc.rollup.TemperatureMax = maxOf(acc.rollup.TemperatureMax, m.Temperature)
+		}
+	} else {
+		sources, err := c.gpuMetricDao.ListRollups(ctx, level.source, start, end)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range sources {
+			acc := accumulator(r.HostID, r.GPUUUID, r.GPUIndex, r.BucketStart)
+			weight := float64(r.SampleCount)
+			acc.rollup.SampleCount += r.SampleCount
+			acc.util.add(r.UtilAvg, weight)
+			acc.memoryUsed.add(r.MemoryUsedAvgMB, weight)
+			acc.memoryUsage.add(r.MemoryUsageAvg, weight)
+			acc.power.add(r.PowerAvg, weight)
+			acc.rollup.UtilMax = maxOf(acc.rollup.UtilMax, r.UtilMax)
+			acc.rollup.TemperatureMax = maxOf(acc.rollup.TemperatureMax, r.TemperatureMax)
+		}
+	}
 
-	return &entity.HostMetric{
-		HostID:             hostID,
-		CPUUsagePercent:    &cpuUsage,
-		MemoryUsagePercent: &memUsage,
-		DiskUsagePercent:   &diskUsage,
-		CollectedAt:        collectedAt,
+	rollups := make([]entity.GPUMetricRollup, 0, len(order))
+	for _, key := range order {
+		acc := accs[key]
+		acc.rollup.UtilAvg = acc.util.value()
+		acc.rollup.MemoryUsedAvgMB = acc.memoryUsed.value()
+		acc.rollup.MemoryUsageAvg = acc.memoryUsage.value()
+		acc.rollup.PowerAvg = acc.power.value()
+		rollups = append(rollups, acc.rollup)
 	}
+	return rollups, nil
 }
 
 func (c *MetricsCollector) cleanupLoop(ctx context.Context) {
@@ -129,11 +315,27 @@ func (c *MetricsCollector) cleanupLoop(ctx context.Context) {
 }
 
 func (c *MetricsCollector) cleanupOldMetrics(ctx context.Context) {
-	before := time.Now().AddDate(0, 0, -c.retentionDays)
-	err := c.metricDao.DeleteOldRecords(ctx, before)
-	if err != nil {
-		logger.GetLogger().Error(fmt.Sprintf("清理旧监控数据失败: %v", err))
-		return
+	now := c.now()
+	if c.cfg.RetentionDays > 0 {
+		if err := c.metricDao.DeleteOldRecords(ctx, now.AddDate(0, 0, -c.cfg.RetentionDays)); err != nil {
+			logger.GetLogger().Error(fmt.Sprintf("清理旧监控数据失败: %v", err))
+		} else {
+			logger.GetLogger().Info(fmt.Sprintf("已清理 %d 天前的监控数据", c.cfg.RetentionDays))
+		}
+	}
+
+	if _, err := c.gpuMetricDao.DeleteRawBefore(ctx, now.Add(-time.Duration(c.cfg.RawRetentionHours)*time.Hour)); err != nil {
+		logger.GetLogger().Error(fmt.Sprintf("清理 GPU 原始指标失败: %v", err))
+	}
+	retention := map[string]int{
+		entity.GPUMetricResolutionMinute: c.cfg.MinuteRetentionDays,
+		entity.GPUMetricResolutionHour:   c.cfg.HourRetentionDays,
+		entity.GPUMetricResolutionDay:    c.cfg.DayRetentionDays,
+	}
+	for _, level := range rollupLevels {
+		before := now.AddDate(0, 0, -retention[level.resolution])
+		if _, err := c.gpuMetricDao.DeleteRollupsBefore(ctx, level.resolution, before); err != nil {
+			logger.GetLogger().Error(fmt.Sprintf("清理 GPU %s 降采样数据失败: %v", level.resolution, err))
+		}
 	}
-	logger.GetLogger().Info(fmt.Sprintf("已清理 %d 天前的监控数据", c.retentionDays))
 }
@@ -0,0 +1,191 @@
+package machine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/YoungBoyGod/remotegpu/config"
+	"github.com/YoungBoyGod/remotegpu/internal/dao"
+	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupMetricsTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+
+	err = db.Exec(`CREATE TABLE host_metrics (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		host_id VARCHAR(64) NOT NULL,
+		cpu_usage_percent REAL,
+		memory_usage_percent REAL,
+		memory_used_gb INTEGER,
+		disk_usage_percent REAL,
+		disk_used_gb INTEGER,
+		network_rx_bytes INTEGER,
+		network_tx_bytes INTEGER,
+		collected_at DATETIME NOT NULL
+	)`).Error
+	require.NoError(t, err)
+
+	err = db.Exec(`CREATE TABLE gpu_metrics (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		host_id VARCHAR(64) NOT NULL,
+		gpu_uuid VARCHAR(128) NOT NULL DEFAULT '',
+		gpu_index INTEGER NOT NULL DEFAULT 0,
+		utilization_percent REAL,
+		memory_used INTEGER,
+		memory_usage_percent REAL,
+		temperature REAL,
+		power_draw REAL,
+		collected_at DATETIME NOT NULL
+	)`).Error
+	require.NoError(t, err)
+
+	err = db.Exec(`CREATE TABLE gpu_metric_rollups (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		resolution VARCHAR(4) NOT NULL,
+		host_id VARCHAR(64) NOT NULL,
+		gpu_uuid VARCHAR(128) NOT NULL DEFAULT '',
+		gpu_index INTEGER NOT NULL DEFAULT 0,
+		bucket_start DATETIME NOT NULL,
+		sample_count INTEGER NOT NULL DEFAULT 0,
+		util_avg REAL,
+		util_max REAL,
+		memory_used_avg_mb REAL,
+		memory_usage_avg REAL,
+		temperature_max REAL,
+		power_avg REAL,
+		UNIQUE (resolution, host_id, gpu_uuid, gpu_index, bucket_start)
+	)`).Error
+	require.NoError(t, err)
+
+	return db
+}
+
+func intPtr(v int) *int { return &v }
+
+func floatPtr(v float64) *float64 { return &v }
+
+func TestBuildGPUMetrics(t *testing.T) {
+	at := time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)
+	metrics := buildGPUMetrics("host-1", []GPUMetricData{
+		{Index: 0, UUID: "GPU-a", UtilPercent: floatPtr(50), MemoryUsedMB: intPtr(4096), MemoryTotalMB: intPtr(16384), TemperatureC: intPtr(65), PowerUsageW: floatPtr(200)},
+		{Index: 1, UUID: "GPU-b"},
+	}, at)
+
+	require.Len(t, metrics, 2)
+	assert.Equal(t, "GPU-a", metrics[0].GPUUUID)
+	assert.Equal(t, int64(4096), *metrics[0].MemoryUsed)
+	assert.InDelta(t, 25, *metrics[0].MemoryUsagePercent, 0.001)
+	assert.InDelta(t, 65, *metrics[0].Temperature, 0.001)
+	assert.Equal(t, 1, metrics[1].GPUIndex)
+	assert.Nil(t, metrics[1].UtilizationPercent)
+	assert.Nil(t, metrics[1].MemoryUsagePercent)
+}
+
+func TestMetricsCollector_Rollup(t *testing.T) {
+	db := setupMetricsTestDB(t)
+	ctx := context.Background()
+	metricDao := dao.NewGPUMetricDao(db)
+
+	base := time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)
+	raw := []entity.GPUMetric{
+		// 08:00 这一分钟两个采样，温度取最大值
+		{HostID: "host-1", GPUUUID: "GPU-a", UtilizationPercent: floatPtr(20), Temperature: floatPtr(60), CollectedAt: base.Add(10 * time.Second)},
+		{HostID: "host-1", GPUUUID: "GPU-a", UtilizationPercent: floatPtr(40), Temperature: floatPtr(70), CollectedAt: base.Add(40 * time.Second)},
+		// 08:01 一个采样
+		{HostID: "host-1", GPUUUID: "GPU-a", UtilizationPercent: floatPtr(90), Temperature: floatPtr(50), CollectedAt: base.Add(70 * time.Second)},
+		// 另一张卡，利用率未上报
+		{HostID: "host-1", GPUUUID: "GPU-b", GPUIndex: 1, PowerDraw: floatPtr(100), CollectedAt: base.Add(20 * time.Second)},
+		// 09:00 的采样使 08 点的小时桶完整
+		{HostID: "host-1", GPUUUID: "GPU-a", UtilizationPercent: floatPtr(10), CollectedAt: base.Add(time.Hour)},
+	}
+	require.NoError(t, metricDao.BatchCreate(ctx, raw))
+
+	collector := NewMetricsCollector(db, config.MetricsCollectorConfig{})
+	collector.now = func() time.Time { return base.Add(time.Hour + 5*time.Minute) }
+	collector.rollupMetrics(ctx)
+
+	minutes, err := metricDao.ListRollups(ctx, entity.GPUMetricResolutionMinute, base, base.Add(2*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, minutes, 4)
+
+	var first entity.GPUMetricRollup
+	for _, r := range minutes {
+		if r.GPUUUID == "GPU-a" && r.BucketStart.Equal(base) {
+			first = r
+		}
+		if r.GPUUUID == "GPU-b" {
+			assert.Nil(t, r.UtilAvg)
+			assert.InDelta(t, 100, *r.PowerAvg, 0.001)
+		}
+	}
+	require.Equal(t, 2, first.SampleCount)
+	assert.InDelta(t, 30, *first.UtilAvg, 0.001)
+	assert.InDelta(t, 40, *first.UtilMax, 0.001)
+	assert.InDelta(t, 70, *first.TemperatureMax, 0.001)
+
+	// 小时桶按采样数加权：(20 + 40 + 90) / 3
+	hours, err := metricDao.ListRollups(ctx, entity.GPUMetricResolutionHour, base, base.Add(2*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, hours, 2)
+	for _, r := range hours {
+		assert.True(t, r.BucketStart.Equal(base))
+		if r.GPUUUID == "GPU-a" {
+			assert.Equal(t, 3, r.SampleCount)
+			assert.InDelta(t, 50, *r.UtilAvg, 0.001)
+			assert.InDelta(t, 90, *r.UtilMax, 0.001)
+		}
+	}
+
+	// 当天未结束，不生成天粒度数据
+	days, err := metricDao.ListRollups(ctx, entity.GPUMetricResolutionDay, base.Add(-24*time.Hour), base.Add(24*time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, days)
+
+	// 重复执行不会产生重复数据
+	collector.rollupMetrics(ctx)
+	minutes, err = metricDao.ListRollups(ctx, entity.GPUMetricResolutionMinute, base, base.Add(2*time.Hour))
+	require.NoError(t, err)
+	assert.Len(t, minutes, 4)
+
+	// GPU 利用率趋势只统计有利用率的 GPU
+	trend, err := metricDao.UtilizationTrend(ctx, entity.GPUMetricResolutionHour, base.Add(-time.Hour))
+	require.NoError(t, err)
+	require.Len(t, trend, 1)
+	assert.InDelta(t, 50, trend[0].UtilAvg, 0.001)
+	assert.True(t, trend[0].BucketStart.Equal(base))
+}
+
+func TestMetricsCollector_Cleanup(t *testing.T) {
+	db := setupMetricsTestDB(t)
+	ctx := context.Background()
+	metricDao := dao.NewGPUMetricDao(db)
+
+	now := time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)
+	require.NoError(t, metricDao.BatchCreate(ctx, []entity.GPUMetric{
+		{HostID: "host-1", CollectedAt: now.Add(-48 * time.Hour)},
+		{HostID: "host-1", CollectedAt: now.Add(-time.Hour)},
+	}))
+	require.NoError(t, metricDao.SaveRollups(ctx, []entity.GPUMetricRollup{
+		{Resolution: entity.GPUMetricResolutionMinute, HostID: "host-1", BucketStart: now.AddDate(0, 0, -10), SampleCount: 1},
+		{Resolution: entity.GPUMetricResolutionHour, HostID: "host-1", BucketStart: now.AddDate(0, 0, -10), SampleCount: 1},
+	}))
+
+	collector := NewMetricsCollector(db, config.MetricsCollectorConfig{RetentionDays: 30})
+	collector.now = func() time.Time { return now }
+	collector.cleanupOldMetrics(ctx)
+
+	var rawCount, minuteCount, hourCount int64
+	require.NoError(t, db.Model(&entity.GPUMetric{}).Count(&rawCount).Error)
+	require.NoError(t, db.Model(&entity.GPUMetricRollup{}).Where("resolution = ?", entity.GPUMetricResolutionMinute).Count(&minuteCount).Error)
+	require.NoError(t, db.Model(&entity.GPUMetricRollup{}).Where("resolution = ?", entity.GPUMetricResolutionHour).Count(&hourCount).Error)
+	assert.Equal(t, int64(1), rawCount)
+	assert.Equal(t, int64(0), minuteCount)
+	assert.Equal(t, int64(1), hourCount)
+}
@@ -3,6 +3,8 @@ package notification
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"time"
 
 	"github.com/YoungBoyGod/remotegpu/internal/dao"
 	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
@@ -76,6 +78,21 @@ func (s *NotificationService) PushTaskStatusChange(ctx context.Context, customer
 	return s.CreateAndPush(ctx, n)
 }
 
+// PushTaskProgress 推送任务进度更新
+// 进度上报频繁，仅通过 SSE 实时推送，不写入通知表
+func (s *NotificationService) PushTaskProgress(ctx context.Context, customerID uint, taskID string, percent int, message string) {
+	data, _ := json.Marshal(map[string]any{
+		"task_id": taskID,
+		"status":  "running",
+		"percent": percent,
+		"message": message,
+	})
+	s.hub.Send(customerID, SSEEvent{
+		Event: "task_progress",
+		Data:  string(data),
+	})
+}
+
 // PushAlert 推送告警通知
 func (s *NotificationService) PushAlert(ctx context.Context, customerID uint, title, content, level string) error {
 	n := &entity.Notification{
@@ -102,3 +119,61 @@ func (s *NotificationService) PushMachineStatusChange(ctx context.Context, custo
 	}
 	return s.CreateAndPush(ctx, n)
 }
+
+// PushStorageQuotaWarning 推送存储用量提醒，scope 为提醒对象描述（如 "您的账户"、"工作空间 xxx"）
+func (s *NotificationService) PushStorageQuotaWarning(ctx context.Context, customerID uint, scope string, percent int, usedMB, quotaMB int64) error {
+	n := &entity.Notification{
+		CustomerID: customerID,
+		Title:      "存储用量提醒",
+		Content:    fmt.Sprintf("%s的存储用量已达到配额的 %d%%（%d MB / %d MB），达到配额后将无法继续上传", scope, percent, usedMB, quotaMB),
+		Type:       "quota",
+		Level:      "warning",
+	}
+	if percent >= 95 {
+		n.Level = "error"
+	}
+	return s.CreateAndPush(ctx, n)
+}
+
+// PushAllocationExpiring 推送机器租约即将到期提醒
+func (s *NotificationService) PushAllocationExpiring(ctx context.Context, customerID uint, machine string, endTime time.Time) error {
+	n := &entity.Notification{
+		CustomerID: customerID,
+		Title:      "机器租约即将到期",
+		Content:    fmt.Sprintf("机器 %s 的租约将于 %s 到期，到期后机器将被自动回收并清理数据，如需继续使用请及时续期", machine, endTime.Format("2006-01-02 15:04")),
+		Type:       "machine",
+		Level:      "warning",
+	}
+	return s.CreateAndPush(ctx, n)
+}
+
+// PushAllocationExpired 推送机器租约到期回收通知
+func (s *NotificationService) PushAllocationExpired(ctx context.Context, customerID uint, machine string) error {
+	n := &entity.Notification{
+		CustomerID: customerID,
+		Title:      "机器租约已到期",
+		Content:    fmt.Sprintf("机器 %s 的租约已到期，机器已被回收", machine),
+		Type:       "machine",
+		Level:      "info",
+	}
+	return s.CreateAndPush(ctx, n)
+}
+
+// PushReservationStatusChange 推送机器预约状态变更通知
+func (s *NotificationService) PushReservationStatusChange(ctx context.Context, customerID uint, reservationID, status, reason string) error {
+	content := "预约 " + reservationID + " 状态变更为 " + status
+	if reason != "" {
+		content += "：" + reason
+	}
+	n := &entity.Notification{
+		CustomerID: customerID,
+		Title:      "预约状态变更",
+		Content:    content,
+		Type:       "reservation",
+		Level:      "info",
+	}
+	if status == entity.ReservationStatusFailed || status == entity.ReservationStatusExpired {
+		n.Level = "warning"
+	}
+	return s.CreateAndPush(ctx, n)
+}
@@ -33,6 +33,16 @@ func NewAgentService(db *gorm.DB, cfg *config.AgentConfig) *AgentService {
 	}
 }
 
+// SetControlKeyProvider 设置 Agent 控制接口签名密钥来源
+func (s *AgentService) SetControlKeyProvider(p agent.ControlKeyProvider) {
+	switch c := s.client.(type) {
+	case *agent.HTTPClient:
+		c.SetControlKeyProvider(p)
+	case *agent.GRPCClient:
+		c.SetControlKeyProvider(p)
+	}
+}
+
 type SystemInfoSnapshot struct {
 	Hostname      string
 	OSType        string
@@ -135,8 +145,8 @@ func (s *AgentService) StopProcess(ctx context.Context, hostID string, processID
 	return err
 }
 
-// MountDataset 挂载数据集
-func (s *AgentService) MountDataset(ctx context.Context, hostID string, datasetID uint, path string) error {
+// MountDataset 挂载数据集，sourceType 为 nfs/s3/bind
+func (s *AgentService) MountDataset(ctx context.Context, hostID string, datasetID uint, sourceType, sourcePath, mountPoint string, readOnly bool) error {
 	addr, err := s.getHostAddress(ctx, hostID)
 	if err != nil {
 		return err
@@ -149,7 +159,29 @@ func (s *AgentService) MountDataset(ctx context.Context, hostID string, datasetI
 	_, err = s.client.MountDataset(ctx, &agent.MountDatasetRequest{
 		HostID:     hostID,
 		DatasetID:  datasetID,
-		MountPoint: path,
+		SourceType: sourceType,
+		SourcePath: sourcePath,
+		MountPoint: mountPoint,
+		ReadOnly:   readOnly,
+	})
+	return err
+}
+
+// UnmountDataset 卸载数据集
+func (s *AgentService) UnmountDataset(ctx context.Context, hostID string, datasetID uint, mountPoint string) error {
+	addr, err := s.getHostAddress(ctx, hostID)
+	if err != nil {
+		return err
+	}
+
+	if httpClient, ok := s.client.(*agent.HTTPClient); ok {
+		httpClient.RegisterHost(hostID, addr)
+	}
+
+	_, err = s.client.UnmountDataset(ctx, &agent.UnmountDatasetRequest{
+		HostID:     hostID,
+		DatasetID:  datasetID,
+		MountPoint: mountPoint,
 	})
 	return err
 }
@@ -173,7 +205,8 @@ func (s *AgentService) CleanupMachine(ctx context.Context, hostID string) error
 }
 
 // SyncSSHKeys 同步客户SSH密钥到指定机器
-func (s *AgentService) SyncSSHKeys(ctx context.Context, hostID string, publicKeys []string, username string) error {
+// tenants 为 GPU 粒度分配客户的独立账号，Agent 全量同步，删除的账号会结束进程并删除数据
+func (s *AgentService) SyncSSHKeys(ctx context.Context, hostID string, publicKeys []string, tenants []agent.TenantAccount, username string) error {
 	addr, err := s.getHostAddress(ctx, hostID)
 	if err != nil {
 		return err
@@ -189,6 +222,7 @@ func (s *AgentService) SyncSSHKeys(ctx context.Context, hostID string, publicKey
 	_, err = s.client.SyncSSHKeys(ctx, &agent.SyncSSHKeysRequest{
 		HostID:     hostID,
 		PublicKeys: publicKeys,
+		Tenants:    tenants,
 		Username:   username,
 	})
 	return err
@@ -222,3 +256,60 @@ func (s *AgentService) CheckAgentHealth(ctx context.Context, hostID string) erro
 func (s *AgentService) IsEnabled() bool {
 	return s.config != nil && s.config.Enabled
 }
+
+// prepareHost 校验 Agent 可用并注册主机地址
+func (s *AgentService) prepareHost(ctx context.Context, hostID string) error {
+	if !s.IsEnabled() {
+		return fmt.Errorf("agent disabled")
+	}
+	if hostID == "" {
+		return fmt.Errorf("host id required")
+	}
+	addr, err := s.getHostAddress(ctx, hostID)
+	if err != nil {
+		return fmt.Errorf("get host address: %w", err)
+	}
+
+	if httpClient, ok := s.client.(*agent.HTTPClient); ok {
+		httpClient.RegisterHost(hostID, addr)
+	}
+	if grpcClient, ok := s.client.(*agent.GRPCClient); ok {
+		grpcClient.RegisterHost(hostID, addr)
+	}
+	return nil
+}
+
+// CreateContainer 在指定主机上创建并启动容器
+func (s *AgentService) CreateContainer(ctx context.Context, req *agent.CreateContainerRequest) (*agent.ContainerInfo, error) {
+	if err := s.prepareHost(ctx, req.HostID); err != nil {
+		return nil, err
+	}
+	return s.client.CreateContainer(ctx, req)
+}
+
+// StartContainer 启动容器
+func (s *AgentService) StartContainer(ctx context.Context, hostID, containerID string) error {
+	if err := s.prepareHost(ctx, hostID); err != nil {
+		return err
+	}
+	_, err := s.client.StartContainer(ctx, &agent.ContainerRequest{HostID: hostID, ContainerID: containerID})
+	return err
+}
+
+// StopContainer 停止容器
+func (s *AgentService) StopContainer(ctx context.Context, hostID, containerID string) error {
+	if err := s.prepareHost(ctx, hostID); err != nil {
+		return err
+	}
+	_, err := s.client.StopContainer(ctx, &agent.ContainerRequest{HostID: hostID, ContainerID: containerID})
+	return err
+}
+
+// RemoveContainer 删除容器
+func (s *AgentService) RemoveContainer(ctx context.Context, hostID, containerID string) error {
+	if err := s.prepareHost(ctx, hostID); err != nil {
+		return err
+	}
+	_, err := s.client.RemoveContainer(ctx, &agent.ContainerRequest{HostID: hostID, ContainerID: containerID})
+	return err
+}
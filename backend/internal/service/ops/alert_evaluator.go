@@ -0,0 +1,359 @@
+package ops
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/YoungBoyGod/remotegpu/internal/dao"
+	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+	"github.com/YoungBoyGod/remotegpu/internal/service/machine"
+	"github.com/YoungBoyGod/remotegpu/pkg/auth"
+	"github.com/YoungBoyGod/remotegpu/pkg/logger"
+	"gorm.io/gorm"
+)
+
+const (
+	alertStatusFiring = "firing"
+
+	// gpuSampleRetention GPU 心跳样本在内存中的保留时长
+	gpuSampleRetention = 24 * time.Hour
+)
+
+// AlertNotifier 告警通知推送接口（由 NotificationService 实现）
+type AlertNotifier interface {
+	PushAlert(ctx context.Context, customerID uint, title, content, level string) error
+}
+
+// metricSample 单个时间点的指标值
+type metricSample struct {
+	At    time.Time
+	Value float64
+}
+
+// gpuSnapshot 一次心跳上报的 GPU 指标
+type gpuSnapshot struct {
+	At   time.Time
+	GPUs []machine.GPUMetricData
+}
+
+// hostMetricExtractors 从 host_metrics 记录中提取指标值
+var hostMetricExtractors = map[string]func(m *entity.HostMetric) *float64{
+	"cpu_usage":    func(m *entity.HostMetric) *float64 { return m.CPUUsagePercent },
+	"memory_usage": func(m *entity.HostMetric) *float64 { return m.MemoryUsagePercent },
+	"disk_usage":   func(m *entity.HostMetric) *float64 { return m.DiskUsagePercent },
+}
+
+// gpuMetricExtractors 从单块 GPU 的心跳指标中提取指标值
+var gpuMetricExtractors = map[string]func(g machine.GPUMetricData) *float64{
+	"gpu_usage": func(g machine.GPUMetricData) *float64 { return g.UtilPercent },
+	"gpu_memory_usage": func(g machine.GPUMetricData) *float64 {
+		if g.MemoryUsedMB == nil || g.MemoryTotalMB == nil || *g.MemoryTotalMB <= 0 {
+			return nil
+		}
+		v := float64(*g.MemoryUsedMB) / float64(*g.MemoryTotalMB) * 100
+		return &v
+	},
+	"gpu_temperature": func(g machine.GPUMetricData) *float64 {
+		if g.TemperatureC == nil {
+			return nil
+		}
+		v := float64(*g.TemperatureC)
+		return &v
+	},
+	"gpu_power": func(g machine.GPUMetricData) *float64 { return g.PowerUsageW },
+}
+
+// AlertEvaluator 告警规则评估引擎
+// 定期按已启用的告警规则检查各机器的监控指标，条件在整个持续时间内成立时触发告警，
+// 条件解除后自动恢复，并通过 NotificationService 推送给管理员
+type AlertEvaluator struct {
+	opsDao      *dao.OpsDao
+	metricDao   *dao.HostMetricDao
+	machineDao  *dao.MachineDao
+	customerDao *dao.CustomerDao
+	notifier    AlertNotifier
+	interval    time.Duration
+
+	mu         sync.Mutex
+	gpuSamples map[string][]gpuSnapshot // hostID -> 按时间升序的 GPU 样本
+
+	now func() time.Time
+}
+
+// NewAlertEvaluator 创建告警评估引擎
+func NewAlertEvaluator(db *gorm.DB, notifier AlertNotifier, interval time.Duration) *AlertEvaluator {
+	if interval <= 0 {
+		interval = 60 * time.Second
+	}
+	return &AlertEvaluator{
+		opsDao:      dao.NewOpsDao(db),
+		metricDao:   dao.NewHostMetricDao(db),
+		machineDao:  dao.NewMachineDao(db),
+		customerDao: dao.NewCustomerDao(db),
+		notifier:    notifier,
+		interval:    interval,
+		gpuSamples:  make(map[string][]gpuSnapshot),
+		now:         time.Now,
+	}
+}
+
+// OnHeartbeat 实现 machine.HeartbeatObserver，缓存心跳携带的 GPU 指标
+func (e *AlertEvaluator) OnHeartbeat(ctx context.Context, hostID string, metrics *machine.HeartbeatMetrics) {
+	if metrics == nil || len(metrics.GPUMetrics) == 0 {
+		return
+	}
+	now := e.now()
+	gpus := make([]machine.GPUMetricData, len(metrics.GPUMetrics))
+	copy(gpus, metrics.GPUMetrics)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	samples := append(e.gpuSamples[hostID], gpuSnapshot{At: now, GPUs: gpus})
+	cutoff := now.Add(-gpuSampleRetention)
+	i := 0
+	for i < len(samples) && samples[i].At.Before(cutoff) {
+		i++
+	}
+	e.gpuSamples[hostID] = samples[i:]
+}
+
+// Start 启动告警评估循环
+func (e *AlertEvaluator) Start(ctx context.Context) {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	logger.GetLogger().Info("告警评估引擎已启动")
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.GetLogger().Info("告警评估引擎已停止")
+			return
+		case <-ticker.C:
+			e.evaluate(ctx)
+		}
+	}
+}
+
+// evaluate 执行一轮告警评估
+func (e *AlertEvaluator) evaluate(ctx context.Context) {
+	rules, err := e.opsDao.ListEnabledAlertRules(ctx)
+	if err != nil {
+		logger.GetLogger().Error(fmt.Sprintf("查询告警规则失败: %v", err))
+		return
+	}
+	if len(rules) == 0 {
+		return
+	}
+
+	hosts, err := e.machineDao.ListOnline(ctx)
+	if err != nil {
+		logger.GetLogger().Error(fmt.Sprintf("查询在线机器失败: %v", err))
+		return
+	}
+
+	for i := range rules {
+		rule := &rules[i]
+		if _, ok := compareOps[rule.Condition]; !ok {
+			logger.GetLogger().Warn(fmt.Sprintf("告警规则 %d 的比较条件 %q 不受支持，已跳过", rule.ID, rule.Condition))
+			continue
+		}
+		if !isSupportedMetric(rule.MetricType) {
+			logger.GetLogger().Warn(fmt.Sprintf("告警规则 %d 的指标类型 %q 不受支持，已跳过", rule.ID, rule.MetricType))
+			continue
+		}
+		for j := range hosts {
+			if err := e.evaluateRule(ctx, rule, &hosts[j]); err != nil {
+				logger.GetLogger().Error(fmt.Sprintf("评估告警规则 %d (机器 %s) 失败: %v", rule.ID, hosts[j].ID, err))
+			}
+		}
+	}
+}
+
+// evaluateRule 评估单条规则在单台机器上的状态，并触发或恢复告警
+func (e *AlertEvaluator) evaluateRule(ctx context.Context, rule *entity.AlertRule, host *entity.Host) error {
+	now := e.now()
+	duration := time.Duration(rule.Duration) * time.Second
+	if duration < 0 {
+		duration = 0
+	}
+	// 多取一个评估周期的样本，用于确认窗口起点之前条件已经成立
+	since := now.Add(-duration - 2*e.interval)
+
+	samples, err := e.loadSamples(ctx, rule.MetricType, host.ID, since)
+	if err != nil {
+		return err
+	}
+	latest, breached, ok := evaluateWindow(samples, rule, now.Add(-duration))
+	if !ok {
+		// 无数据时保持现状，不触发也不恢复
+		return nil
+	}
+
+	firing, err := e.opsDao.FindFiringAlert(ctx, rule.ID, host.ID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	switch {
+	case breached && firing == nil:
+		alert := &entity.ActiveAlert{
+			RuleID:      rule.ID,
+			HostID:      host.ID,
+			Value:       latest,
+			Message:     formatAlertMessage(rule, host, latest),
+			Status:      alertStatusFiring,
+			TriggeredAt: now,
+		}
+		if err := e.opsDao.CreateAlert(ctx, alert); err != nil {
+			return err
+		}
+		e.notifyAdmins(ctx, "告警触发: "+rule.Name, alert.Message, severityToLevel(rule.Severity))
+	case firing != nil && !compare(rule.Condition, latest, rule.Threshold):
+		if err := e.opsDao.ResolveAlert(ctx, firing.ID, now); err != nil {
+			return err
+		}
+		content := fmt.Sprintf("机器 %s 的 %s 已恢复，当前值 %.2f", hostLabel(host), rule.MetricType, latest)
+		e.notifyAdmins(ctx, "告警恢复: "+rule.Name, content, "info")
+	}
+	return nil
+}
+
+// loadSamples 按指标类型加载时间升序的样本
+func (e *AlertEvaluator) loadSamples(ctx context.Context, metricType, hostID string, since time.Time) ([]metricSample, error) {
+	if extract, ok := hostMetricExtractors[metricType]; ok {
+		metrics, err := e.metricDao.ListSince(ctx, hostID, since)
+		if err != nil {
+			return nil, err
+		}
+		samples := make([]metricSample, 0, len(metrics))
+		for _, m := range metrics {
+			if v := extract(m); v != nil {
+				samples = append(samples, metricSample{At: m.CollectedAt, Value: *v})
+			}
+		}
+		return samples, nil
+	}
+
+	extract := gpuMetricExtractors[metricType]
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var samples []metricSample
+	for _, snap := range e.gpuSamples[hostID] {
+		if snap.At.Before(since) {
+			continue
+		}
+		// 多卡机器取所有 GPU 中的最大值
+		var max *float64
+		for _, g := range snap.GPUs {
+			if v := extract(g); v != nil && (max == nil || *v > *max) {
+				max = v
+			}
+		}
+		if max != nil {
+			samples = append(samples, metricSample{At: snap.At, Value: *max})
+		}
+	}
+	return samples, nil
+}
+
+// notifyAdmins 向所有活跃管理员推送告警通知
+func (e *AlertEvaluator) notifyAdmins(ctx context.Context, title, content, level string) {
+	if e.notifier == nil {
+		return
+	}
+	admins, err := e.customerDao.ListActiveByRole(ctx, auth.RoleAdmin)
+	if err != nil {
+		logger.GetLogger().Error(fmt.Sprintf("查询管理员失败: %v", err))
+		return
+	}
+	for _, admin := range admins {
+		if err := e.notifier.PushAlert(ctx, admin.ID, title, content, level); err != nil {
+			logger.GetLogger().Error(fmt.Sprintf("推送告警通知失败 (customer %d): %v", admin.ID, err))
+		}
+	}
+}
+
+// evaluateWindow 判断样本是否在整个持续时间内满足告警条件
+// samples 需按时间升序；要求存在一个不晚于 windowStart 的样本，且从该样本起所有样本都满足条件。
+// 返回最新值、是否满足触发条件，以及是否有可用数据
+func evaluateWindow(samples []metricSample, rule *entity.AlertRule, windowStart time.Time) (latest float64, breached bool, ok bool) {
+	if len(samples) == 0 {
+		return 0, false, false
+	}
+	latest = samples[len(samples)-1].Value
+
+	// 找到窗口起点前（含）最后一个样本作为判定起点
+	start := -1
+	for i, s := range samples {
+		if s.At.After(windowStart) {
+			break
+		}
+		start = i
+	}
+	if start < 0 {
+		// 数据尚未覆盖整个持续时间
+		return latest, false, true
+	}
+	for _, s := range samples[start:] {
+		if !compare(rule.Condition, s.Value, rule.Threshold) {
+			return latest, false, true
+		}
+	}
+	return latest, true, true
+}
+
+var compareOps = map[string]func(v, threshold float64) bool{
+	">":  func(v, t float64) bool { return v > t },
+	">=": func(v, t float64) bool { return v >= t },
+	"<":  func(v, t float64) bool { return v < t },
+	"<=": func(v, t float64) bool { return v <= t },
+	"==": func(v, t float64) bool { return v == t },
+	"!=": func(v, t float64) bool { return v != t },
+}
+
+// compare 按规则条件比较指标值与阈值
+func compare(condition string, value, threshold float64) bool {
+	op, ok := compareOps[condition]
+	if !ok {
+		return false
+	}
+	return op(value, threshold)
+}
+
+func isSupportedMetric(metricType string) bool {
+	if _, ok := hostMetricExtractors[metricType]; ok {
+		return true
+	}
+	_, ok := gpuMetricExtractors[metricType]
+	return ok
+}
+
+// severityToLevel 将告警严重程度映射为通知级别
+func severityToLevel(severity string) string {
+	switch severity {
+	case "critical":
+		return "error"
+	case "info":
+		return "info"
+	default:
+		return "warning"
+	}
+}
+
+func hostLabel(host *entity.Host) string {
+	if host.Name != "" && host.Name != host.ID {
+		return fmt.Sprintf("%s (%s)", host.Name, host.ID)
+	}
+	return host.ID
+}
+
+func formatAlertMessage(rule *entity.AlertRule, host *entity.Host, value float64) string {
+	return fmt.Sprintf("机器 %s 的 %s 当前值 %.2f %s 阈值 %.2f，已持续 %d 秒",
+		hostLabel(host), rule.MetricType, value, rule.Condition, rule.Threshold, rule.Duration)
+}
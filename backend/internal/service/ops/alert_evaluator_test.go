@@ -0,0 +1,250 @@
+package ops
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+	"github.com/YoungBoyGod/remotegpu/internal/service/machine"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// fakeNotifier 记录推送的告警通知
+type fakeNotifier struct {
+	pushed []string
+}
+
+func (n *fakeNotifier) PushAlert(ctx context.Context, customerID uint, title, content, level string) error {
+	n.pushed = append(n.pushed, title)
+	return nil
+}
+
+func setupAlertTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+
+	err = db.Exec(`CREATE TABLE customers (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		created_at DATETIME,
+		updated_at DATETIME,
+		deleted_at DATETIME,
+		uuid TEXT,
+		username TEXT NOT NULL,
+		email TEXT NOT NULL,
+		password_hash TEXT NOT NULL DEFAULT '',
+		role TEXT DEFAULT 'customer_owner',
+		status TEXT DEFAULT 'active'
+	)`).Error
+	require.NoError(t, err)
+
+	err = db.Exec(`CREATE TABLE hosts (
+		id VARCHAR(64) PRIMARY KEY,
+		name VARCHAR(128) NOT NULL DEFAULT '',
+		ip_address VARCHAR(64) NOT NULL DEFAULT '',
+		total_cpu INTEGER NOT NULL DEFAULT 0,
+		total_memory_gb INTEGER NOT NULL DEFAULT 0,
+		device_status VARCHAR(20) DEFAULT 'offline',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`).Error
+	require.NoError(t, err)
+
+	err = db.Exec(`CREATE TABLE alert_rules (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name VARCHAR(128) NOT NULL,
+		description TEXT,
+		metric_type VARCHAR(64) NOT NULL,
+		threshold REAL NOT NULL,
+		comparison VARCHAR(10) NOT NULL,
+		duration INTEGER DEFAULT 60,
+		severity VARCHAR(20) DEFAULT 'warning',
+		enabled INTEGER DEFAULT 1,
+		created_at DATETIME,
+		updated_at DATETIME
+	)`).Error
+	require.NoError(t, err)
+
+	err = db.Exec(`CREATE TABLE active_alerts (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		rule_id INTEGER NOT NULL,
+		host_id VARCHAR(64) NOT NULL,
+		value REAL NOT NULL,
+		message TEXT,
+		status VARCHAR(20) DEFAULT 'firing',
+		triggered_at DATETIME,
+		resolved_at DATETIME,
+		acknowledged INTEGER DEFAULT 0
+	)`).Error
+	require.NoError(t, err)
+
+	err = db.Exec(`CREATE TABLE host_metrics (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		host_id VARCHAR(255) NOT NULL,
+		cpu_usage_percent REAL,
+		cpu_cores_used REAL,
+		memory_total_gb INTEGER,
+		memory_used_gb INTEGER,
+		memory_usage_percent REAL,
+		disk_total_gb INTEGER,
+		disk_used_gb INTEGER,
+		disk_usage_percent REAL,
+		network_rx_bytes INTEGER,
+		network_tx_bytes INTEGER,
+		collected_at DATETIME NOT NULL
+	)`).Error
+	require.NoError(t, err)
+
+	require.NoError(t, db.Exec(`INSERT INTO customers (username, email, role, status) VALUES ('admin', 'admin@test.com', 'admin', 'active')`).Error)
+	require.NoError(t, db.Exec(`INSERT INTO hosts (id, name, device_status) VALUES ('node-01', 'node-01', 'online')`).Error)
+
+	return db
+}
+
+func newTestEvaluator(t *testing.T, now time.Time) (*AlertEvaluator, *fakeNotifier, *gorm.DB) {
+	db := setupAlertTestDB(t)
+	notifier := &fakeNotifier{}
+	e := NewAlertEvaluator(db, notifier, 30*time.Second)
+	e.now = func() time.Time { return now }
+	return e, notifier, db
+}
+
+func insertCPUMetric(t *testing.T, db *gorm.DB, at time.Time, value float64) {
+	v := value
+	require.NoError(t, db.Create(&entity.HostMetric{HostID: "node-01", CPUUsagePercent: &v, CollectedAt: at}).Error)
+}
+
+func TestEvaluateWindow(t *testing.T) {
+	now := time.Now()
+	rule := &entity.AlertRule{Condition: ">", Threshold: 90}
+
+	// 无数据
+	_, _, ok := evaluateWindow(nil, rule, now.Add(-time.Minute))
+	assert.False(t, ok)
+
+	// 数据未覆盖整个窗口
+	samples := []metricSample{{At: now.Add(-30 * time.Second), Value: 95}}
+	_, breached, ok := evaluateWindow(samples, rule, now.Add(-time.Minute))
+	assert.True(t, ok)
+	assert.False(t, breached)
+
+	// 覆盖整个窗口且全部超过阈值
+	samples = []metricSample{
+		{At: now.Add(-70 * time.Second), Value: 92},
+		{At: now.Add(-30 * time.Second), Value: 96},
+	}
+	latest, breached, _ := evaluateWindow(samples, rule, now.Add(-time.Minute))
+	assert.True(t, breached)
+	assert.Equal(t, 96.0, latest)
+
+	// 窗口内有样本未超过阈值
+	samples = append(samples, metricSample{At: now, Value: 50})
+	_, breached, _ = evaluateWindow(samples, rule, now.Add(-time.Minute))
+	assert.False(t, breached)
+}
+
+func TestAlertEvaluator_FireAndResolve(t *testing.T) {
+	now := time.Now()
+	e, notifier, db := newTestEvaluator(t, now)
+	ctx := context.Background()
+
+	require.NoError(t, db.Create(&entity.AlertRule{
+		Name: "CPU 过高", MetricType: "cpu_usage", Condition: ">", Threshold: 90,
+		Duration: 60, Severity: "critical", Enabled: true,
+	}).Error)
+
+	insertCPUMetric(t, db, now.Add(-80*time.Second), 95)
+	insertCPUMetric(t, db, now.Add(-40*time.Second), 97)
+	insertCPUMetric(t, db, now.Add(-5*time.Second), 99)
+
+	e.evaluate(ctx)
+
+	var alerts []entity.ActiveAlert
+	require.NoError(t, db.Find(&alerts).Error)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, "firing", alerts[0].Status)
+	assert.Equal(t, 99.0, alerts[0].Value)
+	assert.Equal(t, []string{"告警触发: CPU 过高"}, notifier.pushed)
+
+	// 再次评估不应重复触发
+	e.evaluate(ctx)
+	require.NoError(t, db.Find(&alerts).Error)
+	assert.Len(t, alerts, 1)
+
+	// 指标恢复后自动恢复告警
+	later := now.Add(30 * time.Second)
+	e.now = func() time.Time { return later }
+	insertCPUMetric(t, db, later, 40)
+	e.evaluate(ctx)
+
+	require.NoError(t, db.Find(&alerts).Error)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, "resolved", alerts[0].Status)
+	assert.NotNil(t, alerts[0].ResolvedAt)
+	assert.Equal(t, "告警恢复: CPU 过高", notifier.pushed[len(notifier.pushed)-1])
+
+	// 告警列表按状态筛选，已恢复的告警不出现在触发中列表
+	opsSvc := NewOpsService(db)
+	_, total, err := opsSvc.ListAlerts(ctx, 1, 10, "", "firing", nil)
+	require.NoError(t, err)
+	assert.Zero(t, total)
+	_, total, err = opsSvc.ListAlerts(ctx, 1, 10, "critical", "resolved", nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	_, total, err = opsSvc.ListAlerts(ctx, 1, 10, "", "", nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+}
+
+func TestAlertEvaluator_NotFiredBeforeDuration(t *testing.T) {
+	now := time.Now()
+	e, notifier, db := newTestEvaluator(t, now)
+
+	require.NoError(t, db.Create(&entity.AlertRule{
+		Name: "CPU 过高", MetricType: "cpu_usage", Condition: ">", Threshold: 90,
+		Duration: 300, Enabled: true,
+	}).Error)
+	insertCPUMetric(t, db, now.Add(-60*time.Second), 95)
+	insertCPUMetric(t, db, now, 95)
+
+	e.evaluate(context.Background())
+
+	var count int64
+	db.Model(&entity.ActiveAlert{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+	assert.Empty(t, notifier.pushed)
+}
+
+func TestAlertEvaluator_GPUMetricsFromHeartbeat(t *testing.T) {
+	start := time.Now()
+	e, notifier, db := newTestEvaluator(t, start)
+	ctx := context.Background()
+
+	require.NoError(t, db.Create(&entity.AlertRule{
+		Name: "GPU 温度过高", MetricType: "gpu_temperature", Condition: ">=", Threshold: 85,
+		Duration: 60, Enabled: true,
+	}).Error)
+
+	hot, cool := 88, 60
+	for i := 0; i <= 3; i++ {
+		at := start.Add(time.Duration(i) * 30 * time.Second)
+		e.now = func() time.Time { return at }
+		e.OnHeartbeat(ctx, "node-01", &machine.HeartbeatMetrics{
+			GPUMetrics: []machine.GPUMetricData{
+				{Index: 0, TemperatureC: &cool},
+				{Index: 1, TemperatureC: &hot},
+			},
+		})
+	}
+
+	e.evaluate(ctx)
+
+	var alert entity.ActiveAlert
+	require.NoError(t, db.First(&alert).Error)
+	assert.Equal(t, 88.0, alert.Value)
+	assert.Equal(t, "firing", alert.Status)
+	assert.Len(t, notifier.pushed, 1)
+}
@@ -56,13 +56,14 @@ func (s *MonitorService) GetGlobalSnapshot(ctx context.Context) (map[string]inte
 	for _, count := range stats {
 		totalMachines += count
 	}
-	onlineMachines := stats["idle"] + stats["allocated"]
+	onlineMachines := stats["idle"] + stats["allocated"] + stats["partial"]
 
 	result := map[string]interface{}{
 		"total_machines":     totalMachines,
 		"online_machines":    onlineMachines,
 		"idle_machines":      stats["idle"],
 		"allocated_machines": stats["allocated"],
+		"partial_machines":   stats["partial"],
 		"offline_machines":   stats["offline"],
 		"avg_gpu_util":       s.getGPUUtilization(ctx),
 	}
@@ -90,6 +91,16 @@ func (s *MonitorService) GetGPUTrend(ctx context.Context) ([]map[string]interfac
 			}
 			return result, nil
 		}
+	} else if trend := gpuTrendFromMetrics(ctx, s.machineService); len(trend) > 0 {
+		// 未启用 Prometheus 时使用心跳上报的 GPU 指标
+		result := make([]map[string]interface{}, len(trend))
+		for i, p := range trend {
+			result[i] = map[string]interface{}{
+				"time":  p.Time,
+				"value": p.Usage,
+			}
+		}
+		return result, nil
 	}
 	// 返回默认数据
 	return []map[string]interface{}{
@@ -102,17 +113,35 @@ func (s *MonitorService) GetGPUTrend(ctx context.Context) ([]map[string]interfac
 	}, nil
 }
 
-// getGPUUtilization 从 Prometheus 获取 GPU 平均利用率
+// getGPUUtilization 获取 GPU 平均利用率，未启用 Prometheus 时使用最近 5 分钟的心跳指标
 func (s *MonitorService) getGPUUtilization(ctx context.Context) float64 {
 	if s.promClient != nil {
 		metrics, err := s.promClient.GetGPUUtilization(ctx)
 		if err == nil {
 			return metrics.AvgUtilization
 		}
+	} else if avg, err := s.machineService.GetAvgGPUUtilization(ctx, time.Now().Add(-5*time.Minute)); err == nil && avg != nil {
+		return *avg
 	}
 	return 0.0
 }
 
+// gpuTrendFromMetrics 从心跳指标降采样数据构建最近 24 小时的 GPU 利用率趋势，查询失败或没有数据时返回空
+func gpuTrendFromMetrics(ctx context.Context, ms *machine.MachineService) []prometheus.GPUTrendPoint {
+	buckets, err := ms.GetGPUTrend(ctx, time.Now().Add(-24*time.Hour))
+	if err != nil {
+		return nil
+	}
+	trend := make([]prometheus.GPUTrendPoint, len(buckets))
+	for i, b := range buckets {
+		trend[i] = prometheus.GPUTrendPoint{
+			Time:  b.BucketStart.Local().Format("15:04"),
+			Usage: b.UtilAvg,
+		}
+	}
+	return trend
+}
+
 type DashboardService struct {
 	machineService    *machine.MachineService
 	customerService   *customer.CustomerService
@@ -154,6 +183,7 @@ func (s *DashboardService) GetAggregatedStats(ctx context.Context) (map[string]a
 	return map[string]any{
 		"total_machines":     totalMachines,
 		"allocated_machines": machineStats["allocated"],
+		"partial_machines":   machineStats["partial"],
 		"idle_machines":      machineStats["idle"],
 		"offline_machines":   machineStats["offline"],
 		"active_customers":   activeCustomers,
@@ -163,8 +193,8 @@ func (s *DashboardService) GetAggregatedStats(ctx context.Context) (map[string]a
 
 
 // GetGPUTrend 获取 GPU 使用趋势数据
-// @description 返回最近时间段的 GPU 利用率趋势，从 Prometheus 获取
-// @modified 2026-02-05
+// @description 返回最近时间段的 GPU 利用率趋势，从 Prometheus 获取，未启用时使用心跳上报的 GPU 指标
+// @modified 2026-10-17
 func (s *DashboardService) GetGPUTrend(ctx context.Context) ([]map[string]any, error) {
 	if s.promClient != nil {
 		trend, err := s.promClient.GetGPUTrend(ctx, 24*time.Hour)
@@ -178,6 +208,16 @@ func (s *DashboardService) GetGPUTrend(ctx context.Context) ([]map[string]any, e
 			}
 			return result, nil
 		}
+	} else if trend := gpuTrendFromMetrics(ctx, s.machineService); len(trend) > 0 {
+		// 未启用 Prometheus 时使用心跳上报的 GPU 指标
+		result := make([]map[string]any, len(trend))
+		for i, p := range trend {
+			result[i] = map[string]any{
+				"time":  p.Time,
+				"usage": p.Usage,
+			}
+		}
+		return result, nil
 	}
 	// 返回默认数据
 	return []map[string]any{
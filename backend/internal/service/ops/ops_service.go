@@ -26,8 +26,8 @@ func (s *OpsService) ListActiveAlerts(ctx context.Context) ([]entity.ActiveAlert
 // @author Claude
 // @description 支持分页和筛选的告警列表查询
 // @modified 2026-02-04
-func (s *OpsService) ListAlerts(ctx context.Context, page, pageSize int, severity string, acknowledged *bool) ([]entity.ActiveAlert, int64, error) {
-	return s.opsDao.ListAlerts(ctx, page, pageSize, severity, acknowledged)
+func (s *OpsService) ListAlerts(ctx context.Context, page, pageSize int, severity, status string, acknowledged *bool) ([]entity.ActiveAlert, int64, error) {
+	return s.opsDao.ListAlerts(ctx, page, pageSize, severity, status, acknowledged)
 }
 
 // AcknowledgeAlert 确认告警
@@ -0,0 +1,149 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+)
+
+// MappingRequest 向 Proxy 申请端口映射的请求
+type MappingRequest struct {
+	EnvID       string `json:"env_id"`
+	ServiceType string `json:"service_type"`
+	TargetHost  string `json:"target_host"`
+	TargetPort  int    `json:"target_port"`
+	Protocol    string `json:"protocol,omitempty"`
+}
+
+// MappingResult Proxy 返回的映射信息
+type MappingResult struct {
+	ID           string `json:"id"`
+	EnvID        string `json:"env_id"`
+	ServiceType  string `json:"service_type"`
+	ExternalPort int    `json:"external_port"`
+	TargetHost   string `json:"target_host"`
+	TargetPort   int    `json:"target_port"`
+	Protocol     string `json:"protocol"`
+}
+
+// ReconcileMapping 对账时下发的期望映射
+type ReconcileMapping struct {
+	EnvID        string `json:"env_id"`
+	ServiceType  string `json:"service_type"`
+	ExternalPort int    `json:"external_port"`
+	TargetHost   string `json:"target_host"`
+	TargetPort   int    `json:"target_port"`
+	Protocol     string `json:"protocol"`
+}
+
+// ReconcileRequest 对账请求，包含该 Proxy 的全量期望映射
+type ReconcileRequest struct {
+	ProxyID  string             `json:"proxy_id"`
+	Mappings []ReconcileMapping `json:"mappings"`
+}
+
+// ReconcileFailure Proxy 未能恢复的映射
+type ReconcileFailure struct {
+	ExternalPort int    `json:"external_port"`
+	EnvID        string `json:"env_id"`
+	Error        string `json:"error"`
+}
+
+// ReconcileResult Proxy 返回的对账结果
+type ReconcileResult struct {
+	Added     int                `json:"added"`
+	Removed   int                `json:"removed"`
+	Unchanged int                `json:"unchanged"`
+	Failed    []ReconcileFailure `json:"failed,omitempty"`
+}
+
+// proxyResponse Proxy 管理 API 统一响应
+type proxyResponse struct {
+	Code int             `json:"code"`
+	Msg  string          `json:"msg"`
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+// mappingClient 调用 Proxy 管理 API 的 HTTP 客户端
+type mappingClient struct {
+	client *http.Client
+	// token 与 Proxy 共享的认证 Token（agent.token），Proxy 管理 API 要求携带
+	token string
+}
+
+func newMappingClient(timeout time.Duration) *mappingClient {
+	return &mappingClient{client: &http.Client{Timeout: timeout}}
+}
+
+// AddMapping 在指定 Proxy 节点上创建映射
+func (c *mappingClient) AddMapping(ctx context.Context, node *entity.ProxyNode, req *MappingRequest) (*MappingResult, error) {
+	var result MappingResult
+	if err := c.do(ctx, node, http.MethodPost, "/api/v1/mappings", req, &result); err != nil {
+		return nil, err
+	}
+	if result.ExternalPort == 0 {
+		return nil, fmt.Errorf("proxy %s returned empty external port", node.ID)
+	}
+	return &result, nil
+}
+
+// RemoveByEnvID 移除指定 Proxy 节点上环境的全部映射
+func (c *mappingClient) RemoveByEnvID(ctx context.Context, node *entity.ProxyNode, envID string) error {
+	return c.do(ctx, node, http.MethodDelete, "/api/v1/mappings/env/"+envID, nil, nil)
+}
+
+// Reconcile 下发期望映射，由 Proxy 增删转发以收敛
+func (c *mappingClient) Reconcile(ctx context.Context, node *entity.ProxyNode, req *ReconcileRequest) (*ReconcileResult, error) {
+	var result ReconcileResult
+	if err := c.do(ctx, node, http.MethodPost, "/api/v1/mappings/reconcile", req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// do 发送请求并解析统一响应
+func (c *mappingClient) do(ctx context.Context, node *entity.ProxyNode, method, path string, body, out any) error {
+	var reqBody []byte
+	if body != nil {
+		var err error
+		if reqBody, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("marshal request: %w", err)
+		}
+	}
+
+	url := fmt.Sprintf("http://%s:%d%s", node.Host, node.APIPort, path)
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(reqBody))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		req.Header.Set("X-Proxy-Token", c.token)
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("do request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result proxyResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	if result.Code != 0 {
+		return fmt.Errorf("proxy error: code=%d msg=%s", result.Code, result.Msg)
+	}
+	if out != nil && len(result.Data) > 0 {
+		if err := json.Unmarshal(result.Data, out); err != nil {
+			return fmt.Errorf("decode data: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+	"github.com/YoungBoyGod/remotegpu/pkg/logger"
+)
+
+// mappingTarget 需要通过 Proxy 暴露的环境服务
+type mappingTarget struct {
+	serviceType  string
+	internalPort int // 容器内端口
+	hostPort     int // 宿主机上发布的端口
+}
+
+// environmentTargets 根据环境已发布的宿主机端口生成映射目标
+func environmentTargets(env *entity.Environment) []mappingTarget {
+	var targets []mappingTarget
+	if env.SSHPort != nil && *env.SSHPort > 0 {
+		targets = append(targets, mappingTarget{serviceType: "ssh", internalPort: 22, hostPort: *env.SSHPort})
+	}
+	if env.JupyterPort != nil && *env.JupyterPort > 0 {
+		targets = append(targets, mappingTarget{serviceType: "jupyter", internalPort: 8888, hostPort: *env.JupyterPort})
+	}
+	if env.RDPPort != nil && *env.RDPPort > 0 {
+		targets = append(targets, mappingTarget{serviceType: "vnc", internalPort: 5901, hostPort: *env.RDPPort})
+	}
+	return targets
+}
+
+// SetupEnvironmentMappings 选择健康的 Proxy 节点，为环境创建 SSH/Jupyter/VNC 映射并持久化
+func (s *ProxyService) SetupEnvironmentMappings(ctx context.Context, env *entity.Environment) error {
+	targets := environmentTargets(env)
+	if len(targets) == 0 {
+		return nil
+	}
+
+	// 只需主机内网地址，不预加载关联数据
+	var host entity.Host
+	if err := s.db.WithContext(ctx).Select("id", "ip_address").First(&host, "id = ?", env.HostID).Error; err != nil {
+		return fmt.Errorf("查询主机失败: %w", err)
+	}
+
+	// 先释放旧映射，保证重复调用幂等
+	if err := s.ReleaseEnvironmentMappings(ctx, env.ID); err != nil {
+		return err
+	}
+
+	nodes, err := s.proxyDao.ListHealthy(ctx, s.now().Add(-proxyHeartbeatTimeout))
+	if err != nil {
+		return err
+	}
+
+	var lastErr error
+	for i := range nodes {
+		node := &nodes[i]
+		if capacity := node.RangeEnd - node.RangeStart + 1; capacity > 0 && node.UsedPorts+len(targets) > capacity {
+			continue
+		}
+		if err := s.createMappings(ctx, node, env.ID, host.IPAddress, targets); err != nil {
+			logger.GetLogger().Warn(fmt.Sprintf("Proxy %s 创建环境 %s 映射失败: %v", node.ID, env.ID, err))
+			lastErr = err
+			continue
+		}
+		return nil
+	}
+
+	if lastErr == nil {
+		return errors.New("没有可用的 Proxy 节点")
+	}
+	return fmt.Errorf("创建端口映射失败: %w", lastErr)
+}
+
+// createMappings 在指定节点上创建全部映射，任一失败则回滚
+func (s *ProxyService) createMappings(ctx context.Context, node *entity.ProxyNode, envID, targetHost string, targets []mappingTarget) error {
+	for _, t := range targets {
+		result, err := s.client.AddMapping(ctx, node, &MappingRequest{
+			EnvID:       envID,
+			ServiceType: t.serviceType,
+			TargetHost:  targetHost,
+			TargetPort:  t.hostPort,
+			Protocol:    "tcp",
+		})
+		if err == nil {
+			err = s.portMappingDao.Create(ctx, &entity.PortMapping{
+				EnvID:        envID,
+				ServiceType:  t.serviceType,
+				ExternalPort: result.ExternalPort,
+				InternalPort: t.internalPort,
+				Status:       "active",
+				AllocatedAt:  s.now(),
+				ProxyID:      node.ID,
+				TargetHost:   targetHost,
+				TargetPort:   t.hostPort,
+				Protocol:     "tcp",
+			})
+		}
+		if err != nil {
+			if rbErr := s.client.RemoveByEnvID(ctx, node, envID); rbErr != nil {
+				logger.GetLogger().Warn(fmt.Sprintf("回滚 Proxy %s 上环境 %s 的映射失败: %v", node.ID, envID, rbErr))
+			}
+			if rbErr := s.portMappingDao.ReleaseByEnvID(ctx, envID, s.now()); rbErr != nil {
+				logger.GetLogger().Warn(fmt.Sprintf("释放环境 %s 映射记录失败: %v", envID, rbErr))
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// ReleaseEnvironmentMappings 通知 Proxy 移除环境的映射，并将记录标记为已释放
+// Proxy 不可达时仍释放记录，避免阻塞环境停止和删除
+func (s *ProxyService) ReleaseEnvironmentMappings(ctx context.Context, envID string) error {
+	mappings, err := s.portMappingDao.ListActiveByEnvID(ctx, envID)
+	if err != nil {
+		return err
+	}
+	if len(mappings) == 0 {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	for _, m := range mappings {
+		if m.ProxyID == "" || seen[m.ProxyID] {
+			continue
+		}
+		seen[m.ProxyID] = true
+
+		node, err := s.proxyDao.FindByID(ctx, m.ProxyID)
+		if err != nil {
+			logger.GetLogger().Warn(fmt.Sprintf("Proxy 节点 %s 不存在，跳过移除环境 %s 的映射", m.ProxyID, envID))
+			continue
+		}
+		if err := s.client.RemoveByEnvID(ctx, node, envID); err != nil {
+			logger.GetLogger().Warn(fmt.Sprintf("Proxy %s 移除环境 %s 映射失败: %v", node.ID, envID, err))
+		}
+	}
+
+	return s.portMappingDao.ReleaseByEnvID(ctx, envID, s.now())
+}
@@ -0,0 +1,242 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// fakeProxyServer 模拟 Proxy 管理 API
+type fakeProxyServer struct {
+	mu       sync.Mutex
+	nextPort int
+	mappings map[int]MappingRequest
+	failAdd  bool
+	down     int // 前 down 次对账请求返回错误，模拟 API 尚未就绪
+	token    string
+	server   *httptest.Server
+}
+
+func newFakeProxyServer(t *testing.T) *fakeProxyServer {
+	f := &fakeProxyServer{nextPort: 20000, mappings: make(map[int]MappingRequest)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/mappings", func(w http.ResponseWriter, r *http.Request) {
+		var req MappingRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if f.failAdd {
+			json.NewEncoder(w).Encode(map[string]any{"code": 2, "msg": "端口池已满"})
+			return
+		}
+		port := f.nextPort
+		f.nextPort++
+		f.mappings[port] = req
+		json.NewEncoder(w).Encode(map[string]any{"code": 0, "msg": "success", "data": map[string]any{
+			"id": "m-" + strconv.Itoa(port), "env_id": req.EnvID, "service_type": req.ServiceType, "external_port": port,
+		}})
+	})
+	mux.HandleFunc("/api/v1/mappings/env/", func(w http.ResponseWriter, r *http.Request) {
+		envID := strings.TrimPrefix(r.URL.Path, "/api/v1/mappings/env/")
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		for port, m := range f.mappings {
+			if m.EnvID == envID {
+				delete(f.mappings, port)
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"code": 0, "msg": "success"})
+	})
+	mux.HandleFunc("/api/v1/mappings/reconcile", func(w http.ResponseWriter, r *http.Request) {
+		var req ReconcileRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if f.down > 0 {
+			f.down--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		result := ReconcileResult{}
+		desired := make(map[int]bool)
+		for _, m := range req.Mappings {
+			desired[m.ExternalPort] = true
+			if _, ok := f.mappings[m.ExternalPort]; ok {
+				result.Unchanged++
+				continue
+			}
+			f.mappings[m.ExternalPort] = MappingRequest{EnvID: m.EnvID, ServiceType: m.ServiceType, TargetHost: m.TargetHost, TargetPort: m.TargetPort}
+			result.Added++
+		}
+		for port := range f.mappings {
+			if !desired[port] {
+				delete(f.mappings, port)
+				result.Removed++
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"code": 0, "msg": "success", "data": result})
+	})
+	// 与 Proxy 一致：配置了 Token 时校验 X-Proxy-Token
+	f.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if f.token != "" && r.Header.Get("X-Proxy-Token") != f.token {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]any{"code": 401, "msg": "unauthorized"})
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+func (f *fakeProxyServer) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.mappings)
+}
+
+// insertNode 将模拟服务器注册为 Proxy 节点
+func (f *fakeProxyServer) insertNode(t *testing.T, db *gorm.DB, id string, heartbeat time.Time, activeMappings int) {
+	host, portStr, err := net.SplitHostPort(strings.TrimPrefix(f.server.URL, "http://"))
+	require.NoError(t, err)
+	port, _ := strconv.Atoi(portStr)
+	require.NoError(t, db.Create(&entity.ProxyNode{
+		ID: id, Name: id, Host: host, APIPort: port, RangeStart: 20000, RangeEnd: 20100,
+		Status: "online", ActiveMappings: activeMappings, LastHeartbeat: &heartbeat,
+	}).Error)
+}
+
+func setupProxyTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+
+	err = db.Exec(`CREATE TABLE proxy_nodes (
+		id VARCHAR(64) PRIMARY KEY,
+		name VARCHAR(128) NOT NULL,
+		host VARCHAR(256) NOT NULL,
+		api_port INTEGER DEFAULT 9090,
+		http_port INTEGER DEFAULT 9091,
+		range_start INTEGER DEFAULT 20000,
+		range_end INTEGER DEFAULT 60000,
+		version VARCHAR(32),
+		status VARCHAR(20) DEFAULT 'offline',
+		active_mappings INTEGER DEFAULT 0,
+		used_ports INTEGER DEFAULT 0,
+		last_heartbeat DATETIME,
+		created_at DATETIME,
+		updated_at DATETIME
+	)`).Error
+	require.NoError(t, err)
+
+	err = db.Exec(`CREATE TABLE port_mappings (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		env_id VARCHAR(64) NOT NULL,
+		service_type VARCHAR(32) NOT NULL,
+		external_port INTEGER NOT NULL UNIQUE,
+		internal_port INTEGER NOT NULL,
+		status VARCHAR(20) DEFAULT 'active',
+		allocated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		released_at DATETIME,
+		proxy_id VARCHAR(64),
+		target_host VARCHAR(256),
+		target_port INTEGER,
+		protocol VARCHAR(10) DEFAULT 'tcp'
+	)`).Error
+	require.NoError(t, err)
+
+	err = db.Exec(`CREATE TABLE hosts (
+		id VARCHAR(64) PRIMARY KEY,
+		name VARCHAR(128) NOT NULL DEFAULT '',
+		ip_address VARCHAR(64) NOT NULL DEFAULT '',
+		total_cpu INTEGER NOT NULL DEFAULT 0,
+		total_memory_gb INTEGER NOT NULL DEFAULT 0,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`).Error
+	require.NoError(t, err)
+
+	require.NoError(t, db.Exec(`INSERT INTO hosts (id, name, ip_address) VALUES ('host-1', 'node-01', '10.0.0.1')`).Error)
+	return db
+}
+
+func newMappingTestEnv() *entity.Environment {
+	ssh, jupyter := 32768, 32769
+	return &entity.Environment{ID: "env-1", HostID: "host-1", SSHPort: &ssh, JupyterPort: &jupyter}
+}
+
+func TestSetupAndReleaseEnvironmentMappings(t *testing.T) {
+	db := setupProxyTestDB(t)
+	svc := NewProxyService(db)
+	ctx := context.Background()
+
+	stale := newFakeProxyServer(t)
+	stale.insertNode(t, db, "proxy-stale", time.Now().Add(-10*time.Minute), 0)
+	healthy := newFakeProxyServer(t)
+	healthy.insertNode(t, db, "proxy-1", time.Now(), 5)
+
+	require.NoError(t, svc.SetupEnvironmentMappings(ctx, newMappingTestEnv()))
+	assert.Equal(t, 0, stale.count())
+	assert.Equal(t, 2, healthy.count())
+
+	var mappings []entity.PortMapping
+	require.NoError(t, db.Order("id").Find(&mappings).Error)
+	require.Len(t, mappings, 2)
+	assert.Equal(t, "ssh", mappings[0].ServiceType)
+	assert.Equal(t, "proxy-1", mappings[0].ProxyID)
+	assert.Equal(t, "10.0.0.1", mappings[0].TargetHost)
+	assert.Equal(t, 32768, mappings[0].TargetPort)
+	assert.Equal(t, 22, mappings[0].InternalPort)
+	assert.Equal(t, 20000, mappings[0].ExternalPort)
+
+	require.NoError(t, svc.ReleaseEnvironmentMappings(ctx, "env-1"))
+	assert.Equal(t, 0, healthy.count())
+	active, err := svc.portMappingDao.ListActiveByEnvID(ctx, "env-1")
+	require.NoError(t, err)
+	assert.Empty(t, active)
+}
+
+func TestSetupEnvironmentMappings_FailoverAndReuse(t *testing.T) {
+	db := setupProxyTestDB(t)
+	svc := NewProxyService(db)
+	ctx := context.Background()
+
+	broken := newFakeProxyServer(t)
+	broken.failAdd = true
+	broken.insertNode(t, db, "proxy-broken", time.Now(), 0)
+	good := newFakeProxyServer(t)
+	good.insertNode(t, db, "proxy-good", time.Now(), 3)
+
+	require.NoError(t, svc.SetupEnvironmentMappings(ctx, newMappingTestEnv()))
+	assert.Equal(t, 2, good.count())
+
+	// 再次建立映射时复用已释放的外部端口，不应违反唯一约束
+	good.mu.Lock()
+	good.nextPort = 20000
+	good.mu.Unlock()
+	require.NoError(t, svc.SetupEnvironmentMappings(ctx, newMappingTestEnv()))
+
+	var active int64
+	db.Model(&entity.PortMapping{}).Where("status = ?", "active").Count(&active)
+	assert.Equal(t, int64(2), active)
+}
+
+func TestSetupEnvironmentMappings_NoHealthyNode(t *testing.T) {
+	db := setupProxyTestDB(t)
+	svc := NewProxyService(db)
+
+	err := svc.SetupEnvironmentMappings(context.Background(), newMappingTestEnv())
+	assert.EqualError(t, err, "没有可用的 Proxy 节点")
+}
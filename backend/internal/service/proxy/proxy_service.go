@@ -9,19 +9,37 @@ import (
 	"gorm.io/gorm"
 )
 
+// proxyHeartbeatTimeout 超过该时间未心跳的 Proxy 节点视为不健康
+const proxyHeartbeatTimeout = 90 * time.Second
+
 // ProxyService Proxy 节点业务逻辑层
 type ProxyService struct {
-	proxyDao *dao.ProxyDao
-	db       *gorm.DB
+	proxyDao       *dao.ProxyDao
+	portMappingDao *dao.PortMappingDao
+	client         *mappingClient
+	db             *gorm.DB
+	now            func() time.Time
+
+	reconcileBackoff time.Duration
 }
 
 func NewProxyService(db *gorm.DB) *ProxyService {
 	return &ProxyService{
-		proxyDao: dao.NewProxyDao(db),
-		db:       db,
+		proxyDao:       dao.NewProxyDao(db),
+		portMappingDao: dao.NewPortMappingDao(db),
+		client:         newMappingClient(10 * time.Second),
+		db:             db,
+		now:            time.Now,
+
+		reconcileBackoff: 2 * time.Second,
 	}
 }
 
+// SetToken 设置调用 Proxy 管理 API 的共享 Token
+func (s *ProxyService) SetToken(token string) {
+	s.client.token = token
+}
+
 // RegisterRequest Proxy 注册请求
 type RegisterRequest struct {
 	ID         string `json:"id" binding:"required"`
@@ -0,0 +1,63 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/YoungBoyGod/remotegpu/pkg/logger"
+)
+
+// reconcileAttempts Proxy 注册时管理 API 可能尚未监听，对账失败后重试的次数
+const reconcileAttempts = 3
+
+// ReconcileNode 将数据库中该 Proxy 的活跃映射下发给 Proxy，使其增删转发与后端一致
+func (s *ProxyService) ReconcileNode(ctx context.Context, proxyID string) (*ReconcileResult, error) {
+	node, err := s.proxyDao.FindByID(ctx, proxyID)
+	if err != nil {
+		return nil, fmt.Errorf("查询 Proxy 节点失败: %w", err)
+	}
+
+	mappings, err := s.portMappingDao.ListActiveByProxyID(ctx, proxyID)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &ReconcileRequest{ProxyID: proxyID, Mappings: make([]ReconcileMapping, 0, len(mappings))}
+	for _, m := range mappings {
+		req.Mappings = append(req.Mappings, ReconcileMapping{
+			EnvID:        m.EnvID,
+			ServiceType:  m.ServiceType,
+			ExternalPort: m.ExternalPort,
+			TargetHost:   m.TargetHost,
+			TargetPort:   m.TargetPort,
+			Protocol:     m.Protocol,
+		})
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < reconcileAttempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(s.reconcileBackoff * time.Duration(attempt)):
+			}
+		}
+
+		result, err := s.client.Reconcile(ctx, node, req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		for _, f := range result.Failed {
+			logger.GetLogger().Warn(fmt.Sprintf("Proxy %s 对账恢复映射失败: env=%s port=%d err=%s",
+				proxyID, f.EnvID, f.ExternalPort, f.Error))
+		}
+		logger.GetLogger().Info(fmt.Sprintf("Proxy %s 对账完成: added=%d removed=%d unchanged=%d failed=%d",
+			proxyID, result.Added, result.Removed, result.Unchanged, len(result.Failed)))
+		return result, nil
+	}
+	return nil, fmt.Errorf("Proxy %s 对账失败: %w", proxyID, lastErr)
+}
@@ -0,0 +1,68 @@
+package proxy
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReconcileNode_RestoresAfterRestart(t *testing.T) {
+	db := setupProxyTestDB(t)
+	svc := NewProxyService(db)
+	svc.reconcileBackoff = time.Millisecond
+	ctx := context.Background()
+
+	fake := newFakeProxyServer(t)
+	fake.insertNode(t, db, "proxy-1", time.Now(), 0)
+	require.NoError(t, svc.SetupEnvironmentMappings(ctx, newMappingTestEnv()))
+
+	// 模拟 Proxy 重启：内存映射丢失，混入一条后端不认识的映射，且 API 首次请求未就绪
+	fake.mu.Lock()
+	fake.mappings = map[int]MappingRequest{30000: {EnvID: "env-gone"}}
+	fake.down = 1
+	fake.mu.Unlock()
+
+	result, err := svc.ReconcileNode(ctx, "proxy-1")
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Added)
+	assert.Equal(t, 1, result.Removed)
+
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	require.Len(t, fake.mappings, 2)
+	assert.Equal(t, "env-1", fake.mappings[20000].EnvID)
+	assert.Equal(t, 32768, fake.mappings[20000].TargetPort)
+}
+
+func TestReconcileNode_GivesUp(t *testing.T) {
+	db := setupProxyTestDB(t)
+	svc := NewProxyService(db)
+	svc.reconcileBackoff = time.Millisecond
+
+	fake := newFakeProxyServer(t)
+	fake.insertNode(t, db, "proxy-1", time.Now(), 0)
+	fake.down = reconcileAttempts
+
+	_, err := svc.ReconcileNode(context.Background(), "proxy-1")
+	assert.Error(t, err)
+}
+
+func TestReconcileNode_SendsToken(t *testing.T) {
+	db := setupProxyTestDB(t)
+	svc := NewProxyService(db)
+	svc.reconcileBackoff = time.Millisecond
+
+	fake := newFakeProxyServer(t)
+	fake.token = "proxy-secret"
+	fake.insertNode(t, db, "proxy-1", time.Now(), 0)
+
+	_, err := svc.ReconcileNode(context.Background(), "proxy-1")
+	assert.Error(t, err)
+
+	svc.SetToken("proxy-secret")
+	_, err = svc.ReconcileNode(context.Background(), "proxy-1")
+	require.NoError(t, err)
+}
@@ -0,0 +1,330 @@
+package reservation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/YoungBoyGod/remotegpu/config"
+	"github.com/YoungBoyGod/remotegpu/internal/dao"
+	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+	"github.com/YoungBoyGod/remotegpu/pkg/logger"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrReservationNotFound       = errors.New("预约不存在")
+	ErrInvalidWindow             = errors.New("预约时间窗口不合法")
+	ErrInsufficientCapacity      = errors.New("没有足够的该型号 GPU 满足预约")
+	ErrGPUQuotaExceeded          = errors.New("预约的 GPU 数量超出配额")
+	ErrReservationNotCancellable = errors.New("当前状态的预约不能取消")
+)
+
+// minWindow 预约时间窗口最短时长
+const minWindow = time.Hour
+
+// batchSize 调度单轮最多处理的预约数
+const batchSize = 100
+
+// MachineAllocator 预约激活时按正常流程分配机器（由 AllocationService 实现）
+type MachineAllocator interface {
+	AllocateReservedMachine(ctx context.Context, reservationID string, customerID uint, hostID string, endTime time.Time, remark string) (*entity.Allocation, error)
+}
+
+// StatusNotifier 预约状态变更通知（由 NotificationService 实现）
+type StatusNotifier interface {
+	PushReservationStatusChange(ctx context.Context, customerID uint, reservationID, status, reason string) error
+}
+
+// ReservationService 机器预约服务
+// 预约创建时在事务内锁定候选机器，检查与已有分配和其他预约的时间窗口冲突：有空闲机器则锁定（pending），否则排队（queued）；
+// 调度任务按提交顺序为排队预约分配机器，时间窗口开始时通过正常分配流程激活
+type ReservationService struct {
+	db             *gorm.DB
+	reservationDao *dao.ReservationDao
+	customerDao    *dao.CustomerDao
+	allocator      MachineAllocator
+	notifier       StatusNotifier
+	maxAdvance     time.Duration
+	maxDuration    time.Duration
+	now            func() time.Time
+}
+
+// NewReservationService 创建预约服务
+func NewReservationService(db *gorm.DB, allocator MachineAllocator, cfg config.ReservationConfig) *ReservationService {
+	maxAdvanceDays := cfg.MaxAdvanceDays
+	if maxAdvanceDays <= 0 {
+		maxAdvanceDays = 90
+	}
+	maxDurationDays := cfg.MaxDurationDays
+	if maxDurationDays <= 0 {
+		maxDurationDays = 365
+	}
+	return &ReservationService{
+		db:             db,
+		reservationDao: dao.NewReservationDao(db),
+		customerDao:    dao.NewCustomerDao(db),
+		allocator:      allocator,
+		maxAdvance:     time.Duration(maxAdvanceDays) * 24 * time.Hour,
+		maxDuration:    time.Duration(maxDurationDays) * 24 * time.Hour,
+		now:            time.Now,
+	}
+}
+
+// SetNotifier 注入状态变更通知
+func (s *ReservationService) SetNotifier(n StatusNotifier) {
+	s.notifier = n
+}
+
+// CreateReservation 创建预约，startTime 早于当前时间（或为零值）时从当前时间开始
+func (s *ReservationService) CreateReservation(ctx context.Context, customerID uint, gpuModel string, gpuCount int, startTime, endTime time.Time, remark string) (*entity.Reservation, error) {
+	now := s.now()
+	if startTime.Before(now) {
+		startTime = now
+	}
+	gpuModel = strings.TrimSpace(gpuModel)
+	if gpuModel == "" || gpuCount < 1 {
+		return nil, fmt.Errorf("%w: 需要指定 GPU 型号和数量", ErrInvalidWindow)
+	}
+	if endTime.Sub(startTime) < minWindow {
+		return nil, fmt.Errorf("%w: 结束时间需晚于开始时间至少 %s", ErrInvalidWindow, minWindow)
+	}
+	if startTime.Sub(now) > s.maxAdvance {
+		return nil, fmt.Errorf("%w: 最多提前 %d 天预约", ErrInvalidWindow, int(s.maxAdvance.Hours()/24))
+	}
+	if endTime.Sub(startTime) > s.maxDuration {
+		return nil, fmt.Errorf("%w: 时间窗口最长 %d 天", ErrInvalidWindow, int(s.maxDuration.Hours()/24))
+	}
+
+	customer, err := s.customerDao.FindByID(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	// quota_gpu 为 0 表示不限制
+	if customer.QuotaGPU > 0 && gpuCount > customer.QuotaGPU {
+		return nil, ErrGPUQuotaExceeded
+	}
+
+	// 该型号的 GPU 总量不足时排队也无法满足，直接拒绝
+	candidates, err := s.reservationDao.CandidateHosts(ctx, gpuModel)
+	if err != nil {
+		return nil, err
+	}
+	total := 0
+	for _, c := range candidates {
+		total += c.GPUCount
+	}
+	if total < gpuCount {
+		return nil, ErrInsufficientCapacity
+	}
+
+	r := &entity.Reservation{
+		ID:         "resv-" + uuid.New().String(),
+		CustomerID: customerID,
+		GPUModel:   gpuModel,
+		GPUCount:   gpuCount,
+		StartTime:  startTime,
+		EndTime:    endTime,
+		Status:     entity.ReservationStatusQueued,
+		Remark:     remark,
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// 锁定客户行，同一客户的并发预约串行校验配额
+		var locked entity.Customer
+		if err := tx.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&locked, customerID).Error; err != nil {
+			return err
+		}
+		hosts, err := s.pickHosts(ctx, tx, r)
+		if err != nil {
+			return err
+		}
+		if hosts != nil {
+			r.Status = entity.ReservationStatusPending
+			r.Hosts = hosts
+		}
+		if err := s.checkGPUQuota(ctx, tx, &locked, r); err != nil {
+			return err
+		}
+		return dao.NewReservationDao(tx).Create(ctx, r)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetReservation 获取预约详情，customerID 非空时校验归属
+func (s *ReservationService) GetReservation(ctx context.Context, id string, customerID *uint) (*entity.Reservation, error) {
+	r, err := s.reservationDao.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrReservationNotFound
+		}
+		return nil, err
+	}
+	if customerID != nil && r.CustomerID != *customerID {
+		return nil, ErrReservationNotFound
+	}
+	return r, nil
+}
+
+// ListReservations 分页查询预约
+func (s *ReservationService) ListReservations(ctx context.Context, page, pageSize int, filters map[string]interface{}) ([]entity.Reservation, int64, error) {
+	return s.reservationDao.List(ctx, page, pageSize, filters)
+}
+
+// CancelReservation 取消排队中或等待开始的预约，已开始分配机器的预约不能取消
+// customerID 非空时校验归属（客户取消），为空时为管理员取消
+func (s *ReservationService) CancelReservation(ctx context.Context, id string, customerID *uint, reason string) error {
+	r, err := s.GetReservation(ctx, id, customerID)
+	if err != nil {
+		return err
+	}
+
+	// 锁定预约后再检查状态和已分配的机器，与调度激活互斥
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		reservationDao := dao.NewReservationDao(tx)
+		locked, err := reservationDao.LockByID(ctx, r.ID)
+		if err != nil {
+			return err
+		}
+		if locked.Status != entity.ReservationStatusQueued && locked.Status != entity.ReservationStatusPending {
+			return ErrReservationNotCancellable
+		}
+		hosts, err := reservationDao.ListHosts(ctx, r.ID)
+		if err != nil {
+			return err
+		}
+		for _, h := range hosts {
+			if h.AllocationID != nil {
+				return ErrReservationNotCancellable
+			}
+		}
+
+		_, err = reservationDao.UpdateFields(ctx, r.ID, locked.Status, map[string]interface{}{
+			"status":       entity.ReservationStatusCancelled,
+			"cancelled_at": s.now(),
+			"reason":       reason,
+		})
+		return err
+	})
+}
+
+// pickHosts 在事务内锁定候选机器并选出时间窗口内空闲的机器，GPU 数量不足时返回 nil
+func (s *ReservationService) pickHosts(ctx context.Context, tx *gorm.DB, r *entity.Reservation) ([]entity.ReservationHost, error) {
+	reservationDao := dao.NewReservationDao(tx)
+	candidates, err := reservationDao.CandidateHosts(ctx, r.GPUModel)
+	if err != nil || len(candidates) == 0 {
+		return nil, err
+	}
+	hostIDs := make([]string, 0, len(candidates))
+	for _, c := range candidates {
+		hostIDs = append(hostIDs, c.HostID)
+	}
+
+	// 按 ID 顺序加行级锁，与分配、续期、其他预约互斥
+	var locked []entity.Host
+	if err := tx.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id").Where("id IN ?", hostIDs).Order("id").Find(&locked).Error; err != nil {
+		return nil, err
+	}
+
+	busy := map[string]bool{}
+	allocated, err := dao.NewAllocationDao(tx).OverlappingHostIDs(ctx, hostIDs, r.StartTime, r.EndTime)
+	if err != nil {
+		return nil, err
+	}
+	held, err := reservationDao.HeldHostIDs(ctx, hostIDs, r.StartTime, r.EndTime, r.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range append(allocated, held...) {
+		busy[id] = true
+	}
+
+	free := make([]dao.HostGPUCount, 0, len(candidates))
+	for _, c := range candidates {
+		if !busy[c.HostID] {
+			free = append(free, c)
+		}
+	}
+	chosen := chooseHosts(free, r.GPUCount)
+	if chosen == nil {
+		return nil, nil
+	}
+	hosts := make([]entity.ReservationHost, 0, len(chosen))
+	for _, c := range chosen {
+		hosts = append(hosts, entity.ReservationHost{ReservationID: r.ID, HostID: c.HostID, GPUCount: c.GPUCount})
+	}
+	return hosts, nil
+}
+
+// checkGPUQuota 检查客户 GPU 配额是否允许本次预约
+// 时间窗口内已分配的 GPU、其他预约占用的 GPU 与本次预约合计不超过配额；锁定机器时按整机 GPU 计
+func (s *ReservationService) checkGPUQuota(ctx context.Context, tx *gorm.DB, customer *entity.Customer, r *entity.Reservation) error {
+	// quota_gpu 为 0 表示不限制
+	if customer.QuotaGPU == 0 {
+		return nil
+	}
+	allocated, err := dao.NewAllocationDao(tx).CountGPUsByCustomerInWindow(ctx, customer.ID, r.StartTime, r.EndTime)
+	if err != nil {
+		return err
+	}
+	held, err := dao.NewReservationDao(tx).CountHeldGPUsByCustomer(ctx, customer.ID, r.StartTime, r.EndTime)
+	if err != nil {
+		return err
+	}
+
+	need := int64(r.GPUCount)
+	if len(r.Hosts) > 0 {
+		hostIDs := make([]string, 0, len(r.Hosts))
+		for _, h := range r.Hosts {
+			hostIDs = append(hostIDs, h.HostID)
+		}
+		if err := tx.WithContext(ctx).Model(&entity.GPU{}).
+			Where("host_id IN ?", hostIDs).Count(&need).Error; err != nil {
+			return err
+		}
+	}
+	if allocated+held+need > int64(customer.QuotaGPU) {
+		return fmt.Errorf("%w: 时间窗口内已占用 %d 个，本次需 %d 个，配额上限 %d 个",
+			ErrGPUQuotaExceeded, allocated+held, need, customer.QuotaGPU)
+	}
+	return nil
+}
+
+// chooseHosts 优先选择 GPU 数量足够的最小单台机器，否则按 GPU 数量从多到少组合，不足时返回 nil
+func chooseHosts(free []dao.HostGPUCount, need int) []dao.HostGPUCount {
+	sort.SliceStable(free, func(i, j int) bool { return free[i].GPUCount < free[j].GPUCount })
+	for _, h := range free {
+		if h.GPUCount >= need {
+			return []dao.HostGPUCount{h}
+		}
+	}
+
+	var chosen []dao.HostGPUCount
+	sum := 0
+	for i := len(free) - 1; i >= 0 && sum < need; i-- {
+		chosen = append(chosen, free[i])
+		sum += free[i].GPUCount
+	}
+	if sum < need {
+		return nil
+	}
+	return chosen
+}
+
+func (s *ReservationService) notify(ctx context.Context, r *entity.Reservation, status, reason string) {
+	if s.notifier == nil {
+		return
+	}
+	if err := s.notifier.PushReservationStatusChange(ctx, r.CustomerID, r.ID, status, reason); err != nil {
+		logger.GetLogger().Warn(fmt.Sprintf("推送预约 %s 状态通知失败: %v", r.ID, err))
+	}
+}
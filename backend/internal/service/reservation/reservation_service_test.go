@@ -0,0 +1,511 @@
+package reservation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/YoungBoyGod/remotegpu/config"
+	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+	"github.com/YoungBoyGod/remotegpu/internal/service/allocation"
+	"github.com/YoungBoyGod/remotegpu/internal/service/audit"
+	"github.com/YoungBoyGod/remotegpu/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupReservationTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+
+	err = db.Exec(`CREATE TABLE customers (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		created_at DATETIME,
+		updated_at DATETIME,
+		deleted_at DATETIME,
+		uuid TEXT,
+		username TEXT NOT NULL,
+		email TEXT NOT NULL,
+		password_hash TEXT NOT NULL DEFAULT '',
+		display_name TEXT,
+		full_name TEXT,
+		company_code TEXT,
+		company TEXT,
+		phone TEXT,
+		avatar_url TEXT,
+		role TEXT DEFAULT 'customer_owner',
+		user_type TEXT DEFAULT 'external',
+		account_type TEXT DEFAULT 'individual',
+		status TEXT DEFAULT 'active',
+		email_verified INTEGER DEFAULT 0,
+		phone_verified INTEGER DEFAULT 0,
+		must_change_password INTEGER DEFAULT 0,
+		quota_gpu INTEGER DEFAULT 0,
+		quota_storage INTEGER DEFAULT 0,
+		balance REAL DEFAULT 0,
+		currency TEXT DEFAULT 'CNY',
+		credit_limit REAL DEFAULT 0,
+		billing_plan_id INTEGER,
+		last_login_at DATETIME
+	)`).Error
+	require.NoError(t, err)
+
+	err = db.Exec(`CREATE TABLE hosts (
+		id VARCHAR(64) PRIMARY KEY,
+		name VARCHAR(128) NOT NULL DEFAULT '',
+		hostname VARCHAR(256),
+		region VARCHAR(64) DEFAULT 'default',
+		ip_address VARCHAR(64) NOT NULL DEFAULT '',
+		public_ip VARCHAR(64),
+		ssh_host VARCHAR(255),
+		ssh_port INTEGER DEFAULT 22,
+		agent_port INTEGER DEFAULT 8080,
+		ssh_username VARCHAR(128) DEFAULT 'root',
+		ssh_password TEXT,
+		ssh_key TEXT,
+		jupyter_url VARCHAR(255),
+		jupyter_token VARCHAR(255),
+		vnc_url VARCHAR(255),
+		vnc_password VARCHAR(255),
+		os_type VARCHAR(20) DEFAULT 'linux',
+		os_version VARCHAR(64),
+		cpu_info VARCHAR(256),
+		total_cpu INTEGER NOT NULL DEFAULT 0,
+		total_memory_gb INTEGER NOT NULL DEFAULT 0,
+		total_disk_gb INTEGER DEFAULT 0,
+		status VARCHAR(20) DEFAULT 'offline',
+		device_status VARCHAR(20) DEFAULT 'offline',
+		allocation_status VARCHAR(20) DEFAULT 'idle',
+		health_status VARCHAR(20) DEFAULT 'unknown',
+		deployment_mode VARCHAR(20) DEFAULT 'traditional',
+		needs_collect INTEGER DEFAULT 0,
+		last_heartbeat DATETIME,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`).Error
+	require.NoError(t, err)
+
+	err = db.Exec(`CREATE TABLE allocations (
+		id VARCHAR(64) PRIMARY KEY,
+		customer_id INTEGER NOT NULL,
+		host_id VARCHAR(64) NOT NULL,
+		workspace_id INTEGER,
+		gpu_indexes TEXT,
+		gpu_count INTEGER DEFAULT 0,
+		start_time DATETIME NOT NULL,
+		end_time DATETIME NOT NULL,
+		actual_end_time DATETIME,
+		expiry_warned_at DATETIME,
+		status VARCHAR(32) DEFAULT 'active',
+		remark TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`).Error
+	require.NoError(t, err)
+
+	err = db.Exec(`CREATE TABLE gpus (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		host_id VARCHAR(64) NOT NULL,
+		"index" INTEGER NOT NULL,
+		uuid VARCHAR(128),
+		name VARCHAR(128) NOT NULL,
+		memory_total_mb INTEGER NOT NULL,
+		brand VARCHAR(64),
+		status VARCHAR(20) DEFAULT 'available',
+		health_status VARCHAR(20) DEFAULT 'healthy',
+		allocated_to VARCHAR(64),
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`).Error
+	require.NoError(t, err)
+
+	err = db.Exec(`CREATE TABLE ssh_keys (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		customer_id INTEGER NOT NULL,
+		name VARCHAR(64) NOT NULL,
+		fingerprint VARCHAR(128),
+		public_key TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`).Error
+	require.NoError(t, err)
+
+	err = db.Exec(`CREATE TABLE tasks (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		customer_id INTEGER,
+		machine_id VARCHAR(64),
+		status VARCHAR(32) DEFAULT 'pending',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`).Error
+	require.NoError(t, err)
+
+	err = db.Exec(`CREATE TABLE allocation_renewals (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		allocation_id VARCHAR(64) NOT NULL,
+		customer_id INTEGER NOT NULL,
+		operator_id INTEGER,
+		operator_role VARCHAR(20) NOT NULL,
+		months INTEGER NOT NULL,
+		old_end_time DATETIME NOT NULL,
+		new_end_time DATETIME NOT NULL,
+		remark TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`).Error
+	require.NoError(t, err)
+
+	err = db.Exec(`CREATE TABLE reservations (
+		id VARCHAR(64) PRIMARY KEY,
+		customer_id INTEGER NOT NULL,
+		gpu_model VARCHAR(128) NOT NULL,
+		gpu_count INTEGER NOT NULL,
+		start_time DATETIME NOT NULL,
+		end_time DATETIME NOT NULL,
+		status VARCHAR(20) NOT NULL DEFAULT 'queued',
+		remark TEXT,
+		reason TEXT,
+		activated_at DATETIME,
+		cancelled_at DATETIME,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`).Error
+	require.NoError(t, err)
+
+	err = db.Exec(`CREATE TABLE reservation_hosts (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		reservation_id VARCHAR(64) NOT NULL,
+		host_id VARCHAR(64) NOT NULL,
+		gpu_count INTEGER NOT NULL,
+		allocation_id VARCHAR(64)
+	)`).Error
+	require.NoError(t, err)
+
+	err = db.Exec(`CREATE TABLE audit_logs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		customer_id INTEGER,
+		username TEXT,
+		ip_address TEXT,
+		method VARCHAR(10),
+		path VARCHAR(512),
+		action TEXT NOT NULL,
+		resource_type TEXT,
+		resource_id TEXT,
+		detail TEXT,
+		status_code INTEGER,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`).Error
+	require.NoError(t, err)
+
+	return db
+}
+
+type fakeStatusNotifier struct {
+	statuses []string
+}
+
+func (n *fakeStatusNotifier) PushReservationStatusChange(_ context.Context, _ uint, _ string, status, _ string) error {
+	n.statuses = append(n.statuses, status)
+	return nil
+}
+
+// newTestReservationService 创建测试用预约服务：客户 1、2，A100 机器 h1(2 卡)、h2(4 卡)，H100 机器 h3(8 卡)
+func newTestReservationService(t *testing.T, now time.Time) (*ReservationService, *allocation.AllocationService, *fakeStatusNotifier, *gorm.DB) {
+	db := setupReservationTestDB(t)
+	db.Exec(`INSERT INTO customers (username, email) VALUES ('u1', 'u1@test.com'), ('u2', 'u2@test.com')`)
+	for _, h := range []struct {
+		id    string
+		model string
+		gpus  int
+	}{{"h1", "NVIDIA A100-SXM4-80GB", 2}, {"h2", "NVIDIA A100-SXM4-80GB", 4}, {"h3", "NVIDIA H100", 8}} {
+		require.NoError(t, db.Exec(`INSERT INTO hosts (id, name, ip_address, allocation_status) VALUES (?, ?, '10.0.0.1', 'idle')`, h.id, h.id).Error)
+		for i := 0; i < h.gpus; i++ {
+			require.NoError(t, db.Exec(`INSERT INTO gpus (host_id, "index", name, memory_total_mb) VALUES (?, ?, ?, 81920)`, h.id, i, h.model).Error)
+		}
+	}
+
+	allocSvc := allocation.NewAllocationService(db, audit.NewAuditService(db), nil)
+	svc := NewReservationService(db, allocSvc, config.ReservationConfig{})
+	notifier := &fakeStatusNotifier{}
+	svc.SetNotifier(notifier)
+	svc.now = func() time.Time { return now }
+	return svc, allocSvc, notifier, db
+}
+
+func reservedHosts(r *entity.Reservation) []string {
+	ids := make([]string, 0, len(r.Hosts))
+	for _, h := range r.Hosts {
+		ids = append(ids, h.HostID)
+	}
+	return ids
+}
+
+func TestCreateReservation_HoldsFreeHosts(t *testing.T) {
+	now := time.Now().UTC()
+	svc, _, _, _ := newTestReservationService(t, now)
+	ctx := context.Background()
+	start, end := now.Add(24*time.Hour), now.Add(72*time.Hour)
+
+	// 优先选择 GPU 数量足够的最小机器
+	r1, err := svc.CreateReservation(ctx, 1, "A100", 2, start, end, "")
+	require.NoError(t, err)
+	assert.Equal(t, entity.ReservationStatusPending, r1.Status)
+	assert.Equal(t, []string{"h1"}, reservedHosts(r1))
+
+	r2, err := svc.CreateReservation(ctx, 1, "A100", 3, start, end, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"h2"}, reservedHosts(r2))
+
+	// 时间窗口重叠，A100 机器均已锁定，排队
+	r3, err := svc.CreateReservation(ctx, 2, "A100", 1, start.Add(time.Hour), end.Add(time.Hour), "")
+	require.NoError(t, err)
+	assert.Equal(t, entity.ReservationStatusQueued, r3.Status)
+	assert.Empty(t, r3.Hosts)
+
+	// 不重叠的时间窗口可以复用同一台机器
+	r4, err := svc.CreateReservation(ctx, 2, "A100", 2, end, end.Add(24*time.Hour), "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"h1"}, reservedHosts(r4))
+
+	// 单台机器不够时组合多台
+	r5, err := svc.CreateReservation(ctx, 2, "A100", 6, end.Add(48*time.Hour), end.Add(72*time.Hour), "")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"h1", "h2"}, reservedHosts(r5))
+}
+
+func TestCreateReservation_ConflictsWithAllocation(t *testing.T) {
+	now := time.Now().UTC()
+	svc, allocSvc, _, _ := newTestReservationService(t, now)
+	ctx := context.Background()
+
+	// h3 已分配一个月
+	_, err := allocSvc.AllocateMachine(ctx, 2, "h3", 1, "")
+	require.NoError(t, err)
+
+	r, err := svc.CreateReservation(ctx, 1, "H100", 8, now.Add(7*24*time.Hour), now.Add(10*24*time.Hour), "")
+	require.NoError(t, err)
+	assert.Equal(t, entity.ReservationStatusQueued, r.Status)
+
+	r, err = svc.CreateReservation(ctx, 1, "H100", 8, now.AddDate(0, 1, 1), now.AddDate(0, 1, 5), "")
+	require.NoError(t, err)
+	assert.Equal(t, entity.ReservationStatusPending, r.Status)
+	assert.Equal(t, []string{"h3"}, reservedHosts(r))
+}
+
+func TestCreateReservation_Rejected(t *testing.T) {
+	now := time.Now().UTC()
+	svc, _, _, db := newTestReservationService(t, now)
+	ctx := context.Background()
+
+	_, err := svc.CreateReservation(ctx, 1, "A100", 1, now.Add(time.Hour), now.Add(time.Hour+time.Minute), "")
+	assert.ErrorIs(t, err, ErrInvalidWindow)
+	_, err = svc.CreateReservation(ctx, 1, "A100", 1, now.Add(200*24*time.Hour), now.Add(201*24*time.Hour), "")
+	assert.ErrorIs(t, err, ErrInvalidWindow)
+	_, err = svc.CreateReservation(ctx, 1, "A100", 7, now, now.Add(24*time.Hour), "")
+	assert.ErrorIs(t, err, ErrInsufficientCapacity)
+	_, err = svc.CreateReservation(ctx, 1, "V100", 1, now, now.Add(24*time.Hour), "")
+	assert.ErrorIs(t, err, ErrInsufficientCapacity)
+	// 型号中的 LIKE 通配符按字面匹配
+	for _, model := range []string{"%", "_100", "A%-SXM4"} {
+		_, err = svc.CreateReservation(ctx, 1, model, 1, now, now.Add(24*time.Hour), "")
+		assert.ErrorIs(t, err, ErrInsufficientCapacity, model)
+	}
+
+	db.Exec(`UPDATE customers SET quota_gpu = 2 WHERE id = 1`)
+	_, err = svc.CreateReservation(ctx, 1, "A100", 4, now, now.Add(24*time.Hour), "")
+	assert.ErrorIs(t, err, ErrGPUQuotaExceeded)
+}
+
+func TestCreateReservation_QuotaCountsHeldGPUs(t *testing.T) {
+	now := time.Now().UTC()
+	svc, allocSvc, _, db := newTestReservationService(t, now)
+	ctx := context.Background()
+	start, end := now.Add(24*time.Hour), now.Add(72*time.Hour)
+
+	// 配额 4：第一次预约锁定 h1（2 卡），第二次只能锁定 h2（4 卡），合计超出配额
+	require.NoError(t, db.Exec(`UPDATE customers SET quota_gpu = 4 WHERE id = 1`).Error)
+	_, err := svc.CreateReservation(ctx, 1, "A100", 1, start, end, "")
+	require.NoError(t, err)
+	_, err = svc.CreateReservation(ctx, 1, "A100", 1, start, end, "")
+	assert.ErrorIs(t, err, ErrGPUQuotaExceeded)
+	var count int64
+	require.NoError(t, db.Table("reservations").Where("customer_id = 1").Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+
+	// 不重叠的时间窗口不受影响
+	_, err = svc.CreateReservation(ctx, 1, "A100", 2, end, end.Add(24*time.Hour), "")
+	require.NoError(t, err)
+
+	// 时间窗口内已分配的 GPU 计入配额，分配结束后的窗口不计
+	require.NoError(t, db.Exec(`UPDATE customers SET quota_gpu = 8 WHERE id = 2`).Error)
+	_, err = allocSvc.AllocateMachine(ctx, 2, "h2", 1, "")
+	require.NoError(t, err)
+	_, err = svc.CreateReservation(ctx, 2, "H100", 1, now.Add(7*24*time.Hour), now.Add(10*24*time.Hour), "")
+	assert.ErrorIs(t, err, ErrGPUQuotaExceeded)
+	r, err := svc.CreateReservation(ctx, 2, "H100", 1, now.AddDate(0, 2, 0), now.AddDate(0, 2, 4), "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"h3"}, reservedHosts(r))
+}
+
+func TestAllocateMachine_RespectsReservation(t *testing.T) {
+	now := time.Now().UTC()
+	svc, allocSvc, _, _ := newTestReservationService(t, now)
+	ctx := context.Background()
+
+	_, err := svc.CreateReservation(ctx, 1, "A100", 2, now.Add(7*24*time.Hour), now.Add(14*24*time.Hour), "")
+	require.NoError(t, err)
+
+	// 一个月的租期与 h1 的预约重叠
+	_, err = allocSvc.AllocateMachine(ctx, 2, "h1", 1, "")
+	require.Error(t, err)
+	assert.Equal(t, errors.ErrorMachineNotAvailable, errors.GetAppError(err).Code)
+	_, err = allocSvc.AllocateMachine(ctx, 2, "h2", 1, "")
+	assert.NoError(t, err)
+}
+
+func TestScheduler_ActivateReservation(t *testing.T) {
+	now := time.Now().UTC()
+	svc, _, notifier, db := newTestReservationService(t, now)
+	ctx := context.Background()
+
+	r, err := svc.CreateReservation(ctx, 1, "A100", 2, now.Add(time.Hour), now.Add(48*time.Hour), "")
+	require.NoError(t, err)
+
+	// 时间窗口未开始
+	assert.Equal(t, 0, svc.activate(ctx))
+
+	svc.now = func() time.Time { return now.Add(2 * time.Hour) }
+	assert.Equal(t, 1, svc.activate(ctx))
+
+	stored, err := svc.GetReservation(ctx, r.ID, nil)
+	require.NoError(t, err)
+	assert.Equal(t, entity.ReservationStatusActive, stored.Status)
+	require.Len(t, stored.Hosts, 1)
+	require.NotNil(t, stored.Hosts[0].AllocationID)
+
+	var alloc entity.Allocation
+	require.NoError(t, db.First(&alloc, "id = ?", *stored.Hosts[0].AllocationID).Error)
+	assert.Equal(t, uint(1), alloc.CustomerID)
+	assert.Equal(t, "h1", alloc.HostID)
+	assert.True(t, alloc.EndTime.Equal(r.EndTime))
+
+	var host entity.Host
+	require.NoError(t, db.First(&host, "id = ?", "h1").Error)
+	assert.Equal(t, "allocated", host.AllocationStatus)
+	assert.Equal(t, []string{entity.ReservationStatusActive}, notifier.statuses)
+
+	// 已开始分配的预约不能取消
+	assert.ErrorIs(t, svc.CancelReservation(ctx, r.ID, nil, ""), ErrReservationNotCancellable)
+}
+
+// cancelingAllocator 在分配前取消预约，模拟取消与激活并发
+type cancelingAllocator struct {
+	svc   *ReservationService
+	inner MachineAllocator
+}
+
+func (a *cancelingAllocator) AllocateReservedMachine(ctx context.Context, reservationID string, customerID uint, hostID string, endTime time.Time, remark string) (*entity.Allocation, error) {
+	if err := a.svc.CancelReservation(ctx, reservationID, nil, "用户取消"); err != nil {
+		return nil, err
+	}
+	return a.inner.AllocateReservedMachine(ctx, reservationID, customerID, hostID, endTime, remark)
+}
+
+func TestScheduler_CancelDuringActivation(t *testing.T) {
+	now := time.Now().UTC()
+	svc, allocSvc, notifier, db := newTestReservationService(t, now)
+	ctx := context.Background()
+
+	r, err := svc.CreateReservation(ctx, 1, "A100", 2, now.Add(time.Hour), now.Add(48*time.Hour), "")
+	require.NoError(t, err)
+
+	svc.allocator = &cancelingAllocator{svc: svc, inner: allocSvc}
+	svc.now = func() time.Time { return now.Add(2 * time.Hour) }
+	assert.Equal(t, 0, svc.activate(ctx))
+
+	stored, err := svc.GetReservation(ctx, r.ID, nil)
+	require.NoError(t, err)
+	assert.Equal(t, entity.ReservationStatusCancelled, stored.Status)
+	require.Len(t, stored.Hosts, 1)
+	assert.Nil(t, stored.Hosts[0].AllocationID)
+
+	var count int64
+	require.NoError(t, db.Model(&entity.Allocation{}).Count(&count).Error)
+	assert.Zero(t, count)
+	var host entity.Host
+	require.NoError(t, db.First(&host, "id = ?", "h1").Error)
+	assert.Equal(t, "idle", host.AllocationStatus)
+	assert.Empty(t, notifier.statuses)
+}
+
+func TestScheduler_ActivateWaitsForBusyHost(t *testing.T) {
+	now := time.Now().UTC()
+	svc, _, _, db := newTestReservationService(t, now)
+	ctx := context.Background()
+
+	r, err := svc.CreateReservation(ctx, 1, "H100", 8, now.Add(time.Hour), now.Add(48*time.Hour), "")
+	require.NoError(t, err)
+
+	// 上一个租约尚未回收
+	db.Exec(`UPDATE hosts SET allocation_status = 'allocated' WHERE id = 'h3'`)
+	svc.now = func() time.Time { return now.Add(2 * time.Hour) }
+	assert.Equal(t, 0, svc.activate(ctx))
+	stored, err := svc.GetReservation(ctx, r.ID, nil)
+	require.NoError(t, err)
+	assert.Equal(t, entity.ReservationStatusPending, stored.Status)
+
+	db.Exec(`UPDATE hosts SET allocation_status = 'idle' WHERE id = 'h3'`)
+	assert.Equal(t, 1, svc.activate(ctx))
+}
+
+func TestScheduler_QueueAndExpire(t *testing.T) {
+	now := time.Now().UTC()
+	svc, _, notifier, _ := newTestReservationService(t, now)
+	ctx := context.Background()
+	start, end := now.Add(24*time.Hour), now.Add(72*time.Hour)
+
+	first, err := svc.CreateReservation(ctx, 1, "H100", 8, start, end, "")
+	require.NoError(t, err)
+	queued, err := svc.CreateReservation(ctx, 2, "H100", 4, start, end, "")
+	require.NoError(t, err)
+	late, err := svc.CreateReservation(ctx, 2, "H100", 4, start, now.Add(25*time.Hour), "")
+	require.NoError(t, err)
+	assert.Equal(t, entity.ReservationStatusQueued, queued.Status)
+	assert.Equal(t, 0, svc.assignQueued(ctx))
+
+	// 其他客户不能取消
+	other := uint(2)
+	assert.ErrorIs(t, svc.CancelReservation(ctx, first.ID, &other, ""), ErrReservationNotFound)
+	owner := uint(1)
+	require.NoError(t, svc.CancelReservation(ctx, first.ID, &owner, "计划变更"))
+
+	// 按提交顺序为排队预约锁定机器
+	assert.Equal(t, 1, svc.assignQueued(ctx))
+	stored, err := svc.GetReservation(ctx, queued.ID, nil)
+	require.NoError(t, err)
+	assert.Equal(t, entity.ReservationStatusPending, stored.Status)
+	assert.Equal(t, []string{"h3"}, reservedHosts(stored))
+
+	// 时间窗口结束仍在排队的预约过期
+	svc.now = func() time.Time { return now.Add(26 * time.Hour) }
+	assert.Equal(t, 1, svc.expire(ctx))
+	stored, err = svc.GetReservation(ctx, late.ID, nil)
+	require.NoError(t, err)
+	assert.Equal(t, entity.ReservationStatusExpired, stored.Status)
+	assert.Equal(t, []string{entity.ReservationStatusPending, entity.ReservationStatusExpired}, notifier.statuses)
+}
+
+func TestRenewAllocation_RespectsReservation(t *testing.T) {
+	now := time.Now().UTC()
+	svc, allocSvc, _, _ := newTestReservationService(t, now)
+	ctx := context.Background()
+
+	alloc, err := allocSvc.AllocateMachine(ctx, 2, "h3", 1, "")
+	require.NoError(t, err)
+	_, err = svc.CreateReservation(ctx, 1, "H100", 8, alloc.EndTime.Add(24*time.Hour), alloc.EndTime.Add(72*time.Hour), "")
+	require.NoError(t, err)
+
+	// 续期后的租期覆盖其他客户的预约
+	_, err = allocSvc.RenewAllocation(ctx, alloc.ID, 1, "", allocation.RenewOperator{Role: allocation.RenewRoleAdmin})
+	require.Error(t, err)
+	assert.Equal(t, errors.ErrorMachineNotAvailable, errors.GetAppError(err).Code)
+}
@@ -0,0 +1,200 @@
+package reservation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/YoungBoyGod/remotegpu/internal/dao"
+	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+	"github.com/YoungBoyGod/remotegpu/internal/service/allocation"
+	appErrors "github.com/YoungBoyGod/remotegpu/pkg/errors"
+	"github.com/YoungBoyGod/remotegpu/pkg/logger"
+	"gorm.io/gorm"
+)
+
+// Scheduler 预约调度
+// 每轮依次：过期处理（时间窗口结束仍未激活）、按提交顺序为排队预约锁定机器、激活时间窗口已开始的预约
+type Scheduler struct {
+	service  *ReservationService
+	interval time.Duration
+}
+
+// NewScheduler 创建预约调度
+func NewScheduler(svc *ReservationService, interval time.Duration) *Scheduler {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	return &Scheduler{service: svc, interval: interval}
+}
+
+// Start 启动预约调度
+func (s *Scheduler) Start(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	logger.GetLogger().Info("机器预约调度服务已启动")
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.GetLogger().Info("机器预约调度服务已停止")
+			return
+		case <-ticker.C:
+			s.service.expire(ctx)
+			s.service.assignQueued(ctx)
+			s.service.activate(ctx)
+		}
+	}
+}
+
+// expire 将时间窗口已结束仍未激活的预约标记为过期，返回处理数
+func (s *ReservationService) expire(ctx context.Context) int {
+	reservations, err := s.reservationDao.ListEnded(ctx, s.now(), batchSize)
+	if err != nil {
+		logger.GetLogger().Warn(fmt.Sprintf("查询已过期预约失败: %v", err))
+		return 0
+	}
+
+	expired := 0
+	for i := range reservations {
+		r := &reservations[i]
+		reason := "时间窗口结束前未能分配机器"
+		ok, err := s.reservationDao.UpdateFields(ctx, r.ID, r.Status, map[string]interface{}{
+			"status": entity.ReservationStatusExpired,
+			"reason": reason,
+		})
+		if err != nil {
+			logger.GetLogger().Warn(fmt.Sprintf("更新预约 %s 状态失败: %v", r.ID, err))
+			continue
+		}
+		if ok {
+			expired++
+			s.notify(ctx, r, entity.ReservationStatusExpired, reason)
+		}
+	}
+	return expired
+}
+
+// assignQueued 按提交顺序为排队中的预约锁定机器，返回锁定成功数
+// 排在前面的预约暂时无法满足时不阻塞后续预约
+func (s *ReservationService) assignQueued(ctx context.Context) int {
+	reservations, err := s.reservationDao.ListQueued(ctx, batchSize)
+	if err != nil {
+		logger.GetLogger().Warn(fmt.Sprintf("查询排队预约失败: %v", err))
+		return 0
+	}
+
+	assigned := 0
+	for i := range reservations {
+		r := &reservations[i]
+		var hosts []entity.ReservationHost
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			picked, err := s.pickHosts(ctx, tx, r)
+			if err != nil || picked == nil {
+				return err
+			}
+			reservationDao := dao.NewReservationDao(tx)
+			ok, err := reservationDao.UpdateFields(ctx, r.ID, entity.ReservationStatusQueued, map[string]interface{}{
+				"status": entity.ReservationStatusPending,
+			})
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return nil // 已被取消或过期
+			}
+			if err := reservationDao.AddHosts(ctx, picked); err != nil {
+				return err
+			}
+			hosts = picked
+			return nil
+		})
+		if err != nil {
+			logger.GetLogger().Warn(fmt.Sprintf("为预约 %s 锁定机器失败: %v", r.ID, err))
+			continue
+		}
+		if hosts != nil {
+			assigned++
+			s.notify(ctx, r, entity.ReservationStatusPending, "已为预约锁定机器")
+		}
+	}
+	return assigned
+}
+
+// activate 时间窗口开始后逐台分配锁定的机器，全部分配完成后预约变为 active，返回激活数
+// 机器仍被上一个租约占用（如到期回收等待任务结束）时下一轮重试，其他分配错误视为激活失败
+func (s *ReservationService) activate(ctx context.Context) int {
+	reservations, err := s.reservationDao.ListStartable(ctx, s.now(), batchSize)
+	if err != nil {
+		logger.GetLogger().Warn(fmt.Sprintf("查询待激活预约失败: %v", err))
+		return 0
+	}
+
+	activated := 0
+	for i := range reservations {
+		if s.activateOne(ctx, &reservations[i]) {
+			activated++
+		}
+	}
+	return activated
+}
+
+func (s *ReservationService) activateOne(ctx context.Context, r *entity.Reservation) bool {
+	remaining := 0
+	for i := range r.Hosts {
+		h := &r.Hosts[i]
+		if h.AllocationID != nil {
+			continue
+		}
+		// 分配与预约状态校验在同一事务内完成，预约已被取消时不会分配机器
+		alloc, err := s.allocator.AllocateReservedMachine(ctx, r.ID, r.CustomerID, h.HostID, r.EndTime, "预约 "+r.ID)
+		if err != nil {
+			if errors.Is(err, allocation.ErrReservationNotPending) {
+				return false
+			}
+			if appErr := appErrors.GetAppError(err); appErr != nil && appErr.Code == appErrors.ErrorMachineNotAvailable {
+				logger.GetLogger().Info(fmt.Sprintf("预约 %s 的机器 %s 暂不可用，稍后重试: %v", r.ID, h.HostID, err))
+				remaining++
+				continue
+			}
+			s.fail(ctx, r, fmt.Sprintf("分配机器 %s 失败: %v", h.HostID, err))
+			return false
+		}
+		h.AllocationID = &alloc.ID
+	}
+	if remaining > 0 {
+		return false
+	}
+
+	ok, err := s.reservationDao.UpdateFields(ctx, r.ID, entity.ReservationStatusPending, map[string]interface{}{
+		"status":       entity.ReservationStatusActive,
+		"activated_at": s.now(),
+	})
+	if err != nil || !ok {
+		if err != nil {
+			logger.GetLogger().Warn(fmt.Sprintf("更新预约 %s 状态失败: %v", r.ID, err))
+		}
+		return false
+	}
+	logger.GetLogger().Info(fmt.Sprintf("预约 %s 已激活，分配 %d 台机器", r.ID, len(r.Hosts)))
+	s.notify(ctx, r, entity.ReservationStatusActive, "")
+	return true
+}
+
+// fail 标记预约激活失败，已分配的机器保留到预约结束时间
+func (s *ReservationService) fail(ctx context.Context, r *entity.Reservation, reason string) {
+	logger.GetLogger().Warn(fmt.Sprintf("预约 %s 激活失败: %s", r.ID, reason))
+	ok, err := s.reservationDao.UpdateFields(ctx, r.ID, entity.ReservationStatusPending, map[string]interface{}{
+		"status": entity.ReservationStatusFailed,
+		"reason": reason,
+	})
+	if err != nil {
+		logger.GetLogger().Warn(fmt.Sprintf("更新预约 %s 状态失败: %v", r.ID, err))
+		return
+	}
+	if ok {
+		s.notify(ctx, r, entity.ReservationStatusFailed, reason)
+	}
+}
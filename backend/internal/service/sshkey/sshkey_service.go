@@ -20,7 +20,7 @@ var (
 
 // KeySyncer 密钥同步接口（避免循环依赖）
 type KeySyncer interface {
-	EnqueueSyncKeys(ctx context.Context, hostID string, publicKeys []string, username string) error
+	SyncHostKeys(ctx context.Context, hostID string) error
 }
 
 type SSHKeyService struct {
@@ -143,23 +143,13 @@ func GetMD5Fingerprint(publicKey string) (string, error) {
 	return strings.TrimPrefix(fingerprint, "MD5:"), nil
 }
 
-// syncKeysToAllocatedMachines 将客户的所有公钥同步到其已分配的机器
+// syncKeysToAllocatedMachines 将客户的公钥变更同步到其已分配的机器
+// 同一台机器可能分配给多个客户，按机器重新汇总所有分配客户的公钥
 func (s *SSHKeyService) syncKeysToAllocatedMachines(ctx context.Context, customerID uint) {
 	if s.keySyncer == nil {
 		return
 	}
 
-	// 获取客户所有公钥
-	keys, err := s.sshKeyDao.ListByCustomerID(ctx, customerID)
-	if err != nil {
-		return
-	}
-
-	publicKeys := make([]string, 0, len(keys))
-	for _, k := range keys {
-		publicKeys = append(publicKeys, k.PublicKey)
-	}
-
 	// 获取客户所有活跃分配的机器
 	allocations, err := s.allocationDao.FindAllActiveByCustomerID(ctx, customerID)
 	if err != nil {
@@ -167,7 +157,12 @@ func (s *SSHKeyService) syncKeysToAllocatedMachines(ctx context.Context, custome
 	}
 
 	// 逐台机器入队同步任务
+	synced := make(map[string]bool, len(allocations))
 	for _, alloc := range allocations {
-		_ = s.keySyncer.EnqueueSyncKeys(ctx, alloc.HostID, publicKeys, "")
+		if synced[alloc.HostID] {
+			continue
+		}
+		synced[alloc.HostID] = true
+		_ = s.keySyncer.SyncHostKeys(ctx, alloc.HostID)
 	}
 }
@@ -0,0 +1,144 @@
+package sso
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"strings"
+
+	serviceAuth "github.com/YoungBoyGod/remotegpu/internal/service/auth"
+	"github.com/YoungBoyGod/remotegpu/pkg/errors"
+	"github.com/YoungBoyGod/remotegpu/pkg/logger"
+	"github.com/go-ldap/ldap/v3"
+)
+
+// LDAPConfig LDAP 绑定认证配置：先用服务账号（为空时匿名）搜索用户 DN，再用用户密码绑定
+type LDAPConfig struct {
+	URL                string `json:"url"` // ldap://host:389 或 ldaps://host:636
+	StartTLS           bool   `json:"start_tls"`
+	InsecureSkipVerify bool   `json:"insecure_skip_verify"`
+	BindDN             string `json:"bind_dn"`
+	BindPassword       string `json:"bind_password"`
+	BaseDN             string `json:"base_dn"`
+	// UserFilter 搜索用户的过滤器，%s 替换为转义后的用户名，默认 (uid=%s)
+	UserFilter        string `json:"user_filter"`
+	UsernameAttribute string `json:"username_attribute"` // 默认 uid
+	EmailAttribute    string `json:"email_attribute"`    // 默认 mail
+	NameAttribute     string `json:"name_attribute"`     // 默认 cn
+	GroupAttribute    string `json:"group_attribute"`    // 默认 memberOf
+}
+
+// ldapConn LDAP 连接中用到的操作，*ldap.Conn 实现了该接口
+type ldapConn interface {
+	Bind(username, password string) error
+	Search(req *ldap.SearchRequest) (*ldap.SearchResult, error)
+	Close() error
+}
+
+// dialLDAP 连接 LDAP 服务，按配置启用 StartTLS
+func dialLDAP(cfg *LDAPConfig) (ldapConn, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify}
+	conn, err := ldap.DialURL(cfg.URL, ldap.DialWithTLSConfig(tlsConfig))
+	if err != nil {
+		return nil, err
+	}
+	conn.SetTimeout(providerTimeout)
+	if cfg.StartTLS {
+		if err := conn.StartTLS(tlsConfig); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+// LDAPLogin 使用企业 LDAP 账号密码登录
+func (s *SSOService) LDAPLogin(ctx context.Context, companyCode, username, password string) (*serviceAuth.LoginResult, error) {
+	companyCode = normalizeCompanyCode(companyCode)
+	cfg, err := s.loadTypedConfig(ctx, companyCode, ProviderLDAP)
+	if err != nil {
+		return nil, err
+	}
+	// 空密码在 LDAP 中是匿名绑定，总会成功
+	username = strings.TrimSpace(username)
+	if username == "" || password == "" {
+		return nil, errors.New(errors.ErrorPasswordIncorrect, "invalid credentials")
+	}
+
+	ident, err := s.ldapAuthenticate(cfg.LDAP, username, password)
+	if err != nil {
+		return nil, err
+	}
+	return s.login(ctx, companyCode, cfg, ident)
+}
+
+// ldapAuthenticate 搜索用户并使用其 DN 和密码绑定，成功后返回用户属性和所属组
+func (s *SSOService) ldapAuthenticate(cfg *LDAPConfig, username, password string) (*ExternalIdentity, error) {
+	usernameAttr := attrOrDefault(cfg.UsernameAttribute, "uid")
+	emailAttr := attrOrDefault(cfg.EmailAttribute, "mail")
+	nameAttr := attrOrDefault(cfg.NameAttribute, "cn")
+	groupAttr := attrOrDefault(cfg.GroupAttribute, "memberOf")
+	filter := cfg.UserFilter
+	if filter == "" {
+		filter = "(" + usernameAttr + "=%s)"
+	}
+
+	conn, err := s.dialLDAP(cfg)
+	if err != nil {
+		logger.GetLogger().Warn(fmt.Sprintf("连接 LDAP %s 失败: %v", cfg.URL, err))
+		return nil, errors.New(errors.ErrorExternalAuth, "identity provider is unavailable")
+	}
+	defer conn.Close()
+
+	if cfg.BindDN != "" {
+		if err := conn.Bind(cfg.BindDN, cfg.BindPassword); err != nil {
+			logger.GetLogger().Warn(fmt.Sprintf("LDAP 服务账号 %s 绑定失败: %v", cfg.BindDN, err))
+			return nil, errors.New(errors.ErrorExternalAuth, "identity provider is unavailable")
+		}
+	}
+
+	result, err := conn.Search(ldap.NewSearchRequest(
+		cfg.BaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 2, int(providerTimeout.Seconds()), false,
+		fmt.Sprintf(filter, ldap.EscapeFilter(username)),
+		[]string{usernameAttr, emailAttr, nameAttr, groupAttr},
+		nil,
+	))
+	if err != nil {
+		logger.GetLogger().Warn(fmt.Sprintf("LDAP 搜索用户 %s 失败: %v", username, err))
+		return nil, errors.New(errors.ErrorExternalAuth, "identity provider is unavailable")
+	}
+	// 用户不存在或匹配多个时与密码错误返回相同结果
+	if len(result.Entries) != 1 {
+		return nil, errors.New(errors.ErrorPasswordIncorrect, "invalid credentials")
+	}
+	entry := result.Entries[0]
+
+	if err := conn.Bind(entry.DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return nil, errors.New(errors.ErrorPasswordIncorrect, "invalid credentials")
+		}
+		logger.GetLogger().Warn(fmt.Sprintf("LDAP 用户 %s 绑定失败: %v", entry.DN, err))
+		return nil, errors.New(errors.ErrorExternalAuth, "ldap authentication failed")
+	}
+
+	name := entry.GetAttributeValue(usernameAttr)
+	if name == "" {
+		name = username
+	}
+	return &ExternalIdentity{
+		Subject:  strings.ToLower(entry.DN),
+		Username: name,
+		Email:    strings.ToLower(entry.GetAttributeValue(emailAttr)),
+		// 目录中的邮箱由企业管理员维护，视为已验证
+		EmailVerified: entry.GetAttributeValue(emailAttr) != "",
+		Name:          entry.GetAttributeValue(nameAttr),
+		Groups:        entry.GetAttributeValues(groupAttr),
+	}, nil
+}
+
+func attrOrDefault(attr, def string) string {
+	if attr == "" {
+		return def
+	}
+	return attr
+}
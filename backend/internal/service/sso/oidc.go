@@ -0,0 +1,209 @@
+package sso
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	serviceAuth "github.com/YoungBoyGod/remotegpu/internal/service/auth"
+	"github.com/YoungBoyGod/remotegpu/pkg/auth"
+	"github.com/YoungBoyGod/remotegpu/pkg/errors"
+	"github.com/YoungBoyGod/remotegpu/pkg/logger"
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+const (
+	// OIDC 授权请求 state key 前缀，保存企业编码、nonce 和 PKCE verifier
+	oidcStatePrefix = "auth:sso_state:"
+	// 授权请求有效期，超时后需重新发起登录
+	oidcStateTTL = 10 * time.Minute
+)
+
+// OIDCConfig OIDC 授权码模式配置
+type OIDCConfig struct {
+	Issuer       string `json:"issuer"`
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	// RedirectURL 控制台的回调页面，回调页面将 state 和 code 提交到 /auth/sso/oidc/callback
+	RedirectURL string   `json:"redirect_url"`
+	Scopes      []string `json:"scopes"`
+	// GroupsClaim ID Token 中组信息的 claim，默认 groups
+	GroupsClaim string `json:"groups_claim"`
+	// UsernameClaim 用作用户名的 claim，默认 preferred_username
+	UsernameClaim string `json:"username_claim"`
+}
+
+// OIDCAuthorization 授权请求，前端跳转到 URL 完成身份源登录
+type OIDCAuthorization struct {
+	URL   string
+	State string
+}
+
+// oidcState 授权请求上下文，回调时校验
+type oidcState struct {
+	CompanyCode string `json:"company_code"`
+	Nonce       string `json:"nonce"`
+	Verifier    string `json:"verifier"`
+}
+
+// BeginOIDCLogin 生成身份源授权地址（授权码模式 + PKCE），state 只能使用一次
+func (s *SSOService) BeginOIDCLogin(ctx context.Context, companyCode string) (*OIDCAuthorization, error) {
+	if s.cache == nil {
+		return nil, errors.New(errors.ErrorServerError, "cache service not available")
+	}
+	companyCode = normalizeCompanyCode(companyCode)
+	cfg, err := s.loadTypedConfig(ctx, companyCode, ProviderOIDC)
+	if err != nil {
+		return nil, err
+	}
+	provider, err := s.oidcProvider(ctx, cfg.OIDC.Issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := auth.GenerateRefreshToken()
+	if err != nil {
+		return nil, errors.WrapWithMessage(errors.ErrorServerError, "failed to generate state", err)
+	}
+	nonce, err := auth.GenerateRefreshToken()
+	if err != nil {
+		return nil, errors.WrapWithMessage(errors.ErrorServerError, "failed to generate nonce", err)
+	}
+	pending := oidcState{CompanyCode: companyCode, Nonce: nonce, Verifier: oauth2.GenerateVerifier()}
+	data, _ := json.Marshal(pending)
+	if err := s.cache.Set(ctx, oidcStatePrefix+state, string(data), oidcStateTTL); err != nil {
+		return nil, errors.WrapWithMessage(errors.ErrorServerError, "failed to store state", err)
+	}
+
+	url := oauth2Config(cfg.OIDC, provider).AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(pending.Verifier))
+	return &OIDCAuthorization{URL: url, State: state}, nil
+}
+
+// CompleteOIDCLogin 处理身份源回调：用授权码换取 ID Token，校验签名和 nonce 后完成登录
+func (s *SSOService) CompleteOIDCLogin(ctx context.Context, state, code string) (*serviceAuth.LoginResult, error) {
+	if s.cache == nil {
+		return nil, errors.New(errors.ErrorServerError, "cache service not available")
+	}
+	key := oidcStatePrefix + state
+	data, err := s.cache.Get(ctx, key)
+	if err != nil || data == "" {
+		return nil, errors.New(errors.ErrorTokenInvalid, "invalid or expired sso state")
+	}
+	_ = s.cache.Delete(ctx, key)
+
+	var pending oidcState
+	if err := json.Unmarshal([]byte(data), &pending); err != nil {
+		return nil, errors.New(errors.ErrorTokenInvalid, "invalid or expired sso state")
+	}
+	cfg, err := s.loadTypedConfig(ctx, pending.CompanyCode, ProviderOIDC)
+	if err != nil {
+		return nil, err
+	}
+	provider, err := s.oidcProvider(ctx, cfg.OIDC.Issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	exchangeCtx, cancel := context.WithTimeout(context.WithValue(ctx, oauth2.HTTPClient, s.httpClient), providerTimeout)
+	defer cancel()
+	token, err := oauth2Config(cfg.OIDC, provider).Exchange(exchangeCtx, code, oauth2.VerifierOption(pending.Verifier))
+	if err != nil {
+		logger.GetLogger().Warn(fmt.Sprintf("企业 %s 的 OIDC 授权码换取令牌失败: %v", pending.CompanyCode, err))
+		return nil, errors.New(errors.ErrorExternalAuth, "failed to exchange authorization code")
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return nil, errors.New(errors.ErrorExternalAuth, "identity provider did not return an id token")
+	}
+	idToken, err := provider.Verifier(&oidc.Config{ClientID: cfg.OIDC.ClientID}).Verify(exchangeCtx, rawIDToken)
+	if err != nil {
+		logger.GetLogger().Warn(fmt.Sprintf("企业 %s 的 OIDC ID Token 校验失败: %v", pending.CompanyCode, err))
+		return nil, errors.New(errors.ErrorExternalAuth, "invalid id token")
+	}
+	if idToken.Nonce != pending.Nonce {
+		return nil, errors.New(errors.ErrorExternalAuth, "invalid id token nonce")
+	}
+
+	var claims map[string]interface{}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, errors.New(errors.ErrorExternalAuth, "invalid id token claims")
+	}
+	return s.login(ctx, pending.CompanyCode, cfg, oidcIdentity(cfg.OIDC, idToken.Subject, claims))
+}
+
+// oidcProvider 获取身份源元数据（按 issuer 缓存），JWKS 按需刷新
+func (s *SSOService) oidcProvider(ctx context.Context, issuer string) (*oidc.Provider, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if p, ok := s.providers[issuer]; ok {
+		return p, nil
+	}
+	// Provider 之后获取 JWKS 时使用创建时的 context，不能使用请求 context
+	discoverCtx := oidc.ClientContext(context.Background(), s.httpClient)
+	p, err := oidc.NewProvider(discoverCtx, issuer)
+	if err != nil {
+		logger.GetLogger().Warn(fmt.Sprintf("获取 OIDC 身份源 %s 元数据失败: %v", issuer, err))
+		return nil, errors.New(errors.ErrorExternalAuth, "identity provider is unavailable")
+	}
+	s.providers[issuer] = p
+	return p, nil
+}
+
+func oauth2Config(cfg *OIDCConfig, provider *oidc.Provider) *oauth2.Config {
+	scopes := cfg.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{oidc.ScopeOpenID, "profile", "email"}
+	}
+	return &oauth2.Config{
+		ClientID:     cfg.ClientID,
+		ClientSecret: cfg.ClientSecret,
+		RedirectURL:  cfg.RedirectURL,
+		Endpoint:     provider.Endpoint(),
+		Scopes:       scopes,
+	}
+}
+
+// oidcIdentity 从 ID Token claims 中提取用户信息
+func oidcIdentity(cfg *OIDCConfig, subject string, claims map[string]interface{}) *ExternalIdentity {
+	groupsClaim := cfg.GroupsClaim
+	if groupsClaim == "" {
+		groupsClaim = "groups"
+	}
+	usernameClaim := cfg.UsernameClaim
+	if usernameClaim == "" {
+		usernameClaim = "preferred_username"
+	}
+
+	ident := &ExternalIdentity{
+		Subject:  subject,
+		Username: claimString(claims, usernameClaim),
+		Email:    strings.ToLower(claimString(claims, "email")),
+		Name:     claimString(claims, "name"),
+	}
+	// 部分身份源以字符串形式返回 email_verified
+	switch v := claims["email_verified"].(type) {
+	case bool:
+		ident.EmailVerified = v
+	case string:
+		ident.EmailVerified = v == "true"
+	}
+	switch v := claims[groupsClaim].(type) {
+	case []interface{}:
+		for _, g := range v {
+			if name, ok := g.(string); ok {
+				ident.Groups = append(ident.Groups, name)
+			}
+		}
+	case string:
+		ident.Groups = strings.Split(v, ",")
+	}
+	return ident
+}
+
+func claimString(claims map[string]interface{}, name string) string {
+	v, _ := claims[name].(string)
+	return strings.TrimSpace(v)
+}
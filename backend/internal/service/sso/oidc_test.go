@@ -0,0 +1,318 @@
+package sso
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+	"github.com/YoungBoyGod/remotegpu/pkg/errors"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	mockClientID     = "remotegpu"
+	mockClientSecret = "client-secret"
+	mockKeyID        = "test-key"
+)
+
+// mockIssuer 本地模拟 OIDC 身份源：提供 discovery、JWKS 和 token 端点，授权端点由测试直接调用 authorize 模拟用户登录
+type mockIssuer struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu    sync.Mutex
+	codes map[string]mockGrant
+	// nonceOverride 非空时签发的 ID Token 使用该 nonce，用于模拟重放
+	nonceOverride string
+}
+
+type mockGrant struct {
+	nonce     string
+	challenge string
+	claims    map[string]interface{}
+}
+
+func newMockIssuer(t *testing.T) *mockIssuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	m := &mockIssuer{key: key, codes: make(map[string]mockGrant)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{
+			"issuer":                                m.server.URL,
+			"authorization_endpoint":                m.server.URL + "/authorize",
+			"token_endpoint":                        m.server.URL + "/token",
+			"jwks_uri":                              m.server.URL + "/jwks",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": mockKeyID,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(key.PublicKey.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.PublicKey.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", m.handleToken)
+	m.server = httptest.NewServer(mux)
+	t.Cleanup(m.server.Close)
+	return m
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// authorize 模拟用户在身份源完成登录，返回回调中的授权码
+func (m *mockIssuer) authorize(t *testing.T, authURL string, claims map[string]interface{}) string {
+	u, err := url.Parse(authURL)
+	require.NoError(t, err)
+	q := u.Query()
+	require.Equal(t, m.server.URL+"/authorize", u.Scheme+"://"+u.Host+u.Path)
+	require.Equal(t, mockClientID, q.Get("client_id"))
+	require.Equal(t, "S256", q.Get("code_challenge_method"))
+
+	code := "code-" + q.Get("state")
+	m.mu.Lock()
+	m.codes[code] = mockGrant{nonce: q.Get("nonce"), challenge: q.Get("code_challenge"), claims: claims}
+	m.mu.Unlock()
+	return code
+}
+
+func (m *mockIssuer) handleToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	clientID, secret, ok := r.BasicAuth()
+	if !ok {
+		clientID, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if clientID != mockClientID || secret != mockClientSecret {
+		w.WriteHeader(http.StatusUnauthorized)
+		writeJSON(w, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	m.mu.Lock()
+	grant, ok := m.codes[r.PostForm.Get("code")]
+	delete(m.codes, r.PostForm.Get("code"))
+	nonce := grant.nonce
+	if m.nonceOverride != "" {
+		nonce = m.nonceOverride
+	}
+	m.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !ok || base64.RawURLEncoding.EncodeToString(sum[:]) != grant.challenge {
+		w.WriteHeader(http.StatusBadRequest)
+		writeJSON(w, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":   m.server.URL,
+		"aud":   mockClientID,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+		"nonce": nonce,
+	}
+	for k, v := range grant.claims {
+		claims[k] = v
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = mockKeyID
+	idToken, err := token.SignedString(m.key)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, map[string]interface{}{
+		"access_token": "mock-access-token",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     idToken,
+	})
+}
+
+func setupOIDC(t *testing.T, env *ssoTestEnv) *mockIssuer {
+	issuer := newMockIssuer(t)
+	env.setIdP(t, "globex", IdPConfig{
+		Type:          ProviderOIDC,
+		Enabled:       true,
+		CompanyName:   "Globex",
+		AutoProvision: true,
+		OIDC: &OIDCConfig{
+			Issuer:       issuer.server.URL,
+			ClientID:     mockClientID,
+			ClientSecret: mockClientSecret,
+			RedirectURL:  "https://gpu.example.com/sso/callback",
+		},
+		GroupRoles:  map[string]string{"gpu-owners": "customer_owner"},
+		DefaultRole: "customer_member",
+		GroupWorkspaces: []WorkspaceMapping{
+			{Group: "ml-team", WorkspaceID: 1},
+			{Group: "researchers", WorkspaceID: 2, Role: "viewer"},
+		},
+	})
+	return issuer
+}
+
+// oidcLogin 完整走一遍授权码流程
+func oidcLogin(t *testing.T, env *ssoTestEnv, issuer *mockIssuer, claims map[string]interface{}) (string, string, error) {
+	ctx := context.Background()
+	authz, err := env.svc.BeginOIDCLogin(ctx, "globex")
+	require.NoError(t, err)
+	code := issuer.authorize(t, authz.URL, claims)
+	_, err = env.svc.CompleteOIDCLogin(ctx, authz.State, code)
+	return authz.State, code, err
+}
+
+func TestOIDCLogin_ProvisionsCustomer(t *testing.T) {
+	env := setupSSOTestEnv(t)
+	issuer := setupOIDC(t, env)
+
+	_, _, err := oidcLogin(t, env, issuer, map[string]interface{}{
+		"sub":                "user-42",
+		"email":              "carol@globex.com",
+		"email_verified":     true,
+		"name":               "Carol",
+		"preferred_username": "carol",
+		"groups":             []string{"gpu-owners", "ml-team", "researchers"},
+	})
+	require.NoError(t, err)
+
+	customer := env.customerByEmail(t, "carol@globex.com")
+	assert.Equal(t, "carol", customer.Username)
+	assert.Equal(t, "Carol", customer.DisplayName)
+	assert.Equal(t, "customer_owner", customer.Role)
+	assert.Equal(t, "globex", customer.CompanyCode)
+	assert.Equal(t, "enterprise", customer.AccountType)
+	assert.Equal(t, ProviderOIDC, customer.AuthSource)
+	assert.Equal(t, "member", env.memberRole(1, customer.ID))
+	assert.Equal(t, "viewer", env.memberRole(2, customer.ID))
+
+	var identity entity.CustomerIdentity
+	require.NoError(t, env.db.Where("customer_id = ?", customer.ID).First(&identity).Error)
+	assert.Equal(t, "oidc:globex", identity.Provider)
+	assert.Equal(t, "user-42", identity.Subject)
+
+	// 同一个 sub 再次登录，组变化后同步角色和工作空间
+	_, _, err = oidcLogin(t, env, issuer, map[string]interface{}{
+		"sub":    "user-42",
+		"email":  "carol@globex.com",
+		"groups": []string{"researchers"},
+	})
+	require.NoError(t, err)
+	customer = env.customerByEmail(t, "carol@globex.com")
+	assert.Equal(t, "customer_member", customer.Role)
+	assert.Empty(t, env.memberRole(1, customer.ID))
+	assert.Equal(t, "viewer", env.memberRole(2, customer.ID))
+}
+
+func TestOIDCLogin_UsernameConflict(t *testing.T) {
+	env := setupSSOTestEnv(t)
+	issuer := setupOIDC(t, env)
+	require.NoError(t, env.db.Create(&entity.Customer{Username: "dave", Email: "dave@other.com", PasswordHash: "x", Status: "active"}).Error)
+
+	_, _, err := oidcLogin(t, env, issuer, map[string]interface{}{
+		"sub": "user-7", "email": "dave@globex.com", "preferred_username": "dave",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "dave.globex", env.customerByEmail(t, "dave@globex.com").Username)
+}
+
+func TestOIDCLogin_StateIsSingleUse(t *testing.T) {
+	env := setupSSOTestEnv(t)
+	issuer := setupOIDC(t, env)
+	claims := map[string]interface{}{"sub": "user-1", "email": "erin@globex.com"}
+
+	state, _, err := oidcLogin(t, env, issuer, claims)
+	require.NoError(t, err)
+
+	_, err = env.svc.CompleteOIDCLogin(context.Background(), state, "code-"+state)
+	assert.Equal(t, errors.ErrorTokenInvalid, errorCode(err))
+	_, err = env.svc.CompleteOIDCLogin(context.Background(), "unknown", "code")
+	assert.Equal(t, errors.ErrorTokenInvalid, errorCode(err))
+}
+
+func TestOIDCLogin_RejectsInvalidTokens(t *testing.T) {
+	env := setupSSOTestEnv(t)
+	issuer := setupOIDC(t, env)
+	ctx := context.Background()
+	claims := map[string]interface{}{"sub": "user-1", "email": "frank@globex.com"}
+
+	// nonce 不匹配
+	issuer.nonceOverride = "replayed"
+	_, _, err := oidcLogin(t, env, issuer, claims)
+	assert.Equal(t, errors.ErrorExternalAuth, errorCode(err))
+	issuer.nonceOverride = ""
+
+	// 授权码不存在（PKCE 校验失败）
+	authz, err := env.svc.BeginOIDCLogin(ctx, "globex")
+	require.NoError(t, err)
+	_, err = env.svc.CompleteOIDCLogin(ctx, authz.State, "forged-code")
+	assert.Equal(t, errors.ErrorExternalAuth, errorCode(err))
+
+	// 其他密钥签名的 ID Token
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	issuer.key = otherKey
+	_, _, err = oidcLogin(t, env, issuer, claims)
+	assert.Equal(t, errors.ErrorExternalAuth, errorCode(err))
+
+	var count int64
+	env.db.Model(&entity.Customer{}).Count(&count)
+	assert.Zero(t, count)
+}
+
+func TestOIDCLogin_UnverifiedEmailNotLinked(t *testing.T) {
+	env := setupSSOTestEnv(t)
+	issuer := setupOIDC(t, env)
+	local := &entity.Customer{Username: "grace", Email: "grace@globex.com", PasswordHash: "x", CompanyCode: "globex", Status: "active"}
+	require.NoError(t, env.db.Create(local).Error)
+
+	_, _, err := oidcLogin(t, env, issuer, map[string]interface{}{
+		"sub": "user-9", "email": "grace@globex.com", "email_verified": false,
+	})
+	assert.Equal(t, errors.ErrorUserExists, errorCode(err))
+
+	_, _, err = oidcLogin(t, env, issuer, map[string]interface{}{
+		"sub": "user-9", "email": "grace@globex.com", "email_verified": "true",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, ProviderOIDC, env.customerByEmail(t, "grace@globex.com").AuthSource)
+}
+
+func TestOIDCLogin_DisabledCustomer(t *testing.T) {
+	env := setupSSOTestEnv(t)
+	issuer := setupOIDC(t, env)
+	claims := map[string]interface{}{"sub": "user-3", "email": "heidi@globex.com", "groups": []string{"ml-team"}}
+
+	_, _, err := oidcLogin(t, env, issuer, claims)
+	require.NoError(t, err)
+	customer := env.customerByEmail(t, "heidi@globex.com")
+	require.NoError(t, env.db.Model(customer).Update("status", "disabled").Error)
+
+	_, _, err = oidcLogin(t, env, issuer, claims)
+	assert.Equal(t, errors.ErrorUserDisabled, errorCode(err))
+}
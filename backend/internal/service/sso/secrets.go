@@ -0,0 +1,125 @@
+package sso
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/YoungBoyGod/remotegpu/pkg/crypto"
+)
+
+const (
+	// SecretMask 展示和审计时替换敏感字段的掩码，保存时提交掩码表示沿用原值
+	SecretMask = "******"
+	// 加密保存的敏感字段前缀，无前缀的值视为旧版本保存的明文
+	encryptedPrefix = "enc:"
+)
+
+// secretFields 身份源配置中需要加密保存的字段（所在对象、字段名）
+var secretFields = [][2]string{
+	{"oidc", "client_secret"},
+	{"ldap", "bind_password"},
+}
+
+// ConfigCodec 处理身份源配置中的敏感字段：保存前加密，展示和审计时脱敏
+type ConfigCodec struct{}
+
+// Seal 加密配置中的敏感字段，字段为掩码时沿用 old 中保存的值
+func (ConfigCodec) Seal(value, old string) (string, error) {
+	var doc map[string]interface{}
+	if err := json.Unmarshal([]byte(value), &doc); err != nil {
+		return "", fmt.Errorf("身份源配置不是合法的 JSON: %w", err)
+	}
+	var oldDoc map[string]interface{}
+	_ = json.Unmarshal([]byte(old), &oldDoc)
+
+	changed := false
+	for _, f := range secretFields {
+		section, secret := secretField(doc, f)
+		if secret == "" || strings.HasPrefix(secret, encryptedPrefix) {
+			continue
+		}
+		if secret == SecretMask {
+			if _, secret = secretField(oldDoc, f); secret == "" || secret == SecretMask {
+				return "", fmt.Errorf("%s.%s 没有可沿用的原值", f[0], f[1])
+			}
+		}
+		if !strings.HasPrefix(secret, encryptedPrefix) {
+			encrypted, err := crypto.EncryptAES256GCM(secret)
+			if err != nil {
+				return "", fmt.Errorf("加密 %s.%s 失败: %w", f[0], f[1], err)
+			}
+			secret = encryptedPrefix + encrypted
+		}
+		section[f[1]] = secret
+		changed = true
+	}
+	if !changed {
+		return value, nil
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// Mask 将配置中的敏感字段替换为掩码，配置无法解析时整体替换
+func (ConfigCodec) Mask(value string) string {
+	if value == "" {
+		return value
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal([]byte(value), &doc); err != nil {
+		return SecretMask
+	}
+	changed := false
+	for _, f := range secretFields {
+		if section, secret := secretField(doc, f); secret != "" {
+			section[f[1]] = SecretMask
+			changed = true
+		}
+	}
+	if !changed {
+		return value
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return SecretMask
+	}
+	return string(data)
+}
+
+// secretField 返回敏感字段所在对象及字段值
+func secretField(doc map[string]interface{}, f [2]string) (map[string]interface{}, string) {
+	section, ok := doc[f[0]].(map[string]interface{})
+	if !ok {
+		return nil, ""
+	}
+	secret, _ := section[f[1]].(string)
+	return section, secret
+}
+
+// decryptSecret 解密加密保存的敏感字段，兼容旧版本保存的明文
+func decryptSecret(value string) (string, error) {
+	if !strings.HasPrefix(value, encryptedPrefix) {
+		return value, nil
+	}
+	return crypto.DecryptAES256GCM(strings.TrimPrefix(value, encryptedPrefix))
+}
+
+// decryptSecrets 解密身份源配置中的敏感字段
+func (c *IdPConfig) decryptSecrets() error {
+	var err error
+	if c.OIDC != nil {
+		if c.OIDC.ClientSecret, err = decryptSecret(c.OIDC.ClientSecret); err != nil {
+			return fmt.Errorf("解密 oidc.client_secret 失败: %w", err)
+		}
+	}
+	if c.LDAP != nil {
+		if c.LDAP.BindPassword, err = decryptSecret(c.LDAP.BindPassword); err != nil {
+			return fmt.Errorf("解密 ldap.bind_password 失败: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package sso
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+	"github.com/YoungBoyGod/remotegpu/internal/service/audit"
+	"github.com/YoungBoyGod/remotegpu/internal/service/system_config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const ldapConfigJSON = `{"type":"ldap","enabled":true,"company_name":"Acme","auto_provision":true,"default_role":"customer_member",` +
+	`"ldap":{"url":"ldap://ldap.acme.com","base_dn":"dc=acme,dc=com","bind_dn":"cn=svc,dc=acme,dc=com","bind_password":"svc-secret"}}`
+
+func storedConfig(t *testing.T, env *ssoTestEnv, key string) string {
+	var record entity.SystemConfig
+	require.NoError(t, env.db.Where("config_key = ?", key).First(&record).Error)
+	return record.ConfigValue
+}
+
+func TestConfigCodec_SealAndMask(t *testing.T) {
+	codec := ConfigCodec{}
+
+	sealed, err := codec.Seal(`{"type":"oidc","oidc":{"issuer":"https://idp","client_id":"rg","client_secret":"top-secret"}}`, "")
+	require.NoError(t, err)
+	assert.NotContains(t, sealed, "top-secret")
+	assert.Contains(t, sealed, `"client_secret":"enc:`)
+
+	var cfg IdPConfig
+	require.NoError(t, json.Unmarshal([]byte(sealed), &cfg))
+	require.NoError(t, cfg.decryptSecrets())
+	assert.Equal(t, "top-secret", cfg.OIDC.ClientSecret)
+
+	// 提交掩码时沿用原值，已加密的值不重复加密
+	masked := codec.Mask(sealed)
+	assert.NotContains(t, masked, "enc:")
+	assert.Contains(t, masked, SecretMask)
+	resealed, err := codec.Seal(masked, sealed)
+	require.NoError(t, err)
+	assert.Equal(t, sealed, resealed)
+
+	_, err = codec.Seal(masked, "")
+	assert.Error(t, err)
+	_, err = codec.Seal("not json", "")
+	assert.Error(t, err)
+	assert.Equal(t, SecretMask, codec.Mask("not json"))
+
+	// 没有敏感字段的配置保持原样
+	plain := `{"type":"ldap","ldap":{"url":"ldap://x"}}`
+	sealed, err = codec.Seal(plain, "")
+	require.NoError(t, err)
+	assert.Equal(t, plain, sealed)
+	assert.Equal(t, plain, codec.Mask(plain))
+}
+
+func TestLDAPLogin_LegacyPlaintextSecret(t *testing.T) {
+	env := setupSSOTestEnv(t)
+	setupLDAP(t, env)
+	require.NoError(t, env.db.Model(&entity.SystemConfig{}).Where("config_key = ?", ConfigKeyPrefix+"acme").
+		Update("config_value", ldapConfigJSON).Error)
+
+	_, err := env.svc.LDAPLogin(context.Background(), "acme", "alice", "alice-pass")
+	assert.NoError(t, err)
+}
+
+func TestSystemConfig_SSOSecretsProtected(t *testing.T) {
+	env := setupSSOTestEnv(t)
+	setupLDAP(t, env)
+	require.NoError(t, env.db.Exec(`CREATE TABLE audit_logs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		customer_id INTEGER,
+		username TEXT,
+		ip_address TEXT,
+		method TEXT,
+		path TEXT,
+		action TEXT NOT NULL,
+		resource_type TEXT,
+		resource_id TEXT,
+		detail TEXT,
+		status_code INTEGER,
+		created_at DATETIME
+	)`).Error)
+	ctx := context.Background()
+	key := ConfigKeyPrefix + "acme"
+
+	configSvc := system_config.NewSystemConfigService(env.db)
+	configSvc.SetAuditService(audit.NewAuditService(env.db))
+	configSvc.SetSecretCodec(ConfigKeyPrefix, ConfigCodec{})
+
+	// 管理后台读取时脱敏
+	configs, err := configSvc.GetConfigsByGroup(ctx, "sso")
+	require.NoError(t, err)
+	require.Len(t, configs, 1)
+	assert.NotContains(t, configs[0].ConfigValue, "svc-secret")
+	assert.NotContains(t, configs[0].ConfigValue, "enc:")
+	assert.Contains(t, configs[0].ConfigValue, SecretMask)
+
+	// 原样提交脱敏后的配置不影响已保存的密钥
+	require.NoError(t, configSvc.UpdateConfigs(ctx, map[string]string{key: configs[0].ConfigValue}, "admin"))
+	_, err = env.svc.LDAPLogin(ctx, "acme", "alice", "alice-pass")
+	require.NoError(t, err)
+
+	// 提交新的明文密钥时加密保存
+	require.NoError(t, configSvc.UpdateConfigs(ctx, map[string]string{key: ldapConfigJSON}, "admin"))
+	stored := storedConfig(t, env, key)
+	assert.NotContains(t, stored, "svc-secret")
+	_, err = env.svc.LDAPLogin(ctx, "acme", "alice", "alice-pass")
+	require.NoError(t, err)
+
+	err = configSvc.UpdateConfigs(ctx, map[string]string{key: "{"}, "admin")
+	assert.ErrorIs(t, err, system_config.ErrInvalidConfigValue)
+	assert.Equal(t, stored, storedConfig(t, env, key))
+
+	// 审计日志中不出现密钥及其密文
+	var logs []entity.AuditLog
+	require.NoError(t, env.db.Where("action = ?", "update_config").Find(&logs).Error)
+	require.Len(t, logs, 2)
+	for _, l := range logs {
+		detail := string(l.Detail)
+		assert.False(t, strings.Contains(detail, "svc-secret") || strings.Contains(detail, "enc:"), detail)
+	}
+}
@@ -0,0 +1,519 @@
+package sso
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/YoungBoyGod/remotegpu/internal/dao"
+	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+	"github.com/YoungBoyGod/remotegpu/internal/service/audit"
+	serviceAuth "github.com/YoungBoyGod/remotegpu/internal/service/auth"
+	"github.com/YoungBoyGod/remotegpu/pkg/auth"
+	"github.com/YoungBoyGod/remotegpu/pkg/cache"
+	"github.com/YoungBoyGod/remotegpu/pkg/errors"
+	"github.com/YoungBoyGod/remotegpu/pkg/logger"
+	"github.com/coreos/go-oidc/v3/oidc"
+	"gorm.io/gorm"
+)
+
+const (
+	// 身份源配置 key 前缀，完整 key 为 sso_idp_<企业编码>
+	ConfigKeyPrefix = "sso_idp_"
+	// 身份源类型
+	ProviderOIDC = "oidc"
+	ProviderLDAP = "ldap"
+	// 访问身份源的超时时间
+	providerTimeout = 15 * time.Second
+	// 用户名冲突时追加序号的上限
+	maxUsernameSuffix = 100
+)
+
+// 客户角色及其优先级，命中多个组时取优先级最高的角色
+var roleRank = map[string]int{
+	"customer_member": 1,
+	"customer_owner":  2,
+}
+
+// 工作空间成员角色优先级（owner 只能由工作空间创建者持有，不由身份源分配）
+var workspaceRoleRank = map[string]int{
+	"viewer": 1,
+	"member": 2,
+	"admin":  3,
+}
+
+// IdPConfig 企业身份源配置，以 JSON 保存在系统配置 sso_idp_<企业编码> 中
+// 密钥类字段（OIDC client_secret、LDAP bind_password）由 ConfigCodec 加密保存
+type IdPConfig struct {
+	Type        string      `json:"type"` // oidc, ldap
+	Enabled     bool        `json:"enabled"`
+	CompanyName string      `json:"company_name"`
+	OIDC        *OIDCConfig `json:"oidc,omitempty"`
+	LDAP        *LDAPConfig `json:"ldap,omitempty"`
+	// AutoProvision 首次登录时自动创建账号；为 false 时只允许已关联的账号或同企业同邮箱的账号登录
+	AutoProvision bool `json:"auto_provision"`
+	// GroupRoles 组到客户角色（customer_owner / customer_member）的映射
+	GroupRoles map[string]string `json:"group_roles"`
+	// DefaultRole 未命中任何映射时的角色，为空时拒绝登录
+	DefaultRole string `json:"default_role"`
+	// GroupWorkspaces 组到工作空间的映射，列出的工作空间成员资格由身份源管理，每次登录同步
+	GroupWorkspaces []WorkspaceMapping `json:"group_workspaces"`
+}
+
+// WorkspaceMapping 组到工作空间成员的映射
+type WorkspaceMapping struct {
+	Group       string `json:"group"`
+	WorkspaceID uint   `json:"workspace_id"`
+	Role        string `json:"role"` // admin, member, viewer，默认 member
+}
+
+// ProviderInfo 登录页展示的身份源信息
+type ProviderInfo struct {
+	CompanyCode string
+	CompanyName string
+	Type        string
+}
+
+// ExternalIdentity 身份源认证通过后返回的用户信息
+type ExternalIdentity struct {
+	Subject       string
+	Username      string
+	Email         string
+	EmailVerified bool
+	Name          string
+	Groups        []string
+}
+
+type SSOService struct {
+	customerDao  *dao.CustomerDao
+	identityDao  *dao.CustomerIdentityDao
+	memberDao    *dao.WorkspaceMemberDao
+	configDao    *dao.SystemConfigDao
+	db           *gorm.DB
+	cache        cache.Cache
+	authService  *serviceAuth.AuthService
+	auditService *audit.AuditService
+
+	httpClient *http.Client
+	// dialLDAP 建立 LDAP 连接，测试中替换为模拟实现
+	dialLDAP func(cfg *LDAPConfig) (ldapConn, error)
+
+	mu        sync.Mutex
+	providers map[string]*oidc.Provider
+}
+
+func NewSSOService(db *gorm.DB, c cache.Cache, authService *serviceAuth.AuthService) *SSOService {
+	return &SSOService{
+		customerDao: dao.NewCustomerDao(db),
+		identityDao: dao.NewCustomerIdentityDao(db),
+		memberDao:   dao.NewWorkspaceMemberDao(db),
+		configDao:   dao.NewSystemConfigDao(db),
+		db:          db,
+		cache:       c,
+		authService: authService,
+		httpClient:  &http.Client{Timeout: providerTimeout},
+		dialLDAP:    dialLDAP,
+		providers:   make(map[string]*oidc.Provider),
+	}
+}
+
+// SetAuditService 注入审计服务，自动创建和关联账号时记录审计日志
+func (s *SSOService) SetAuditService(a *audit.AuditService) {
+	s.auditService = a
+}
+
+// normalizeCompanyCode 企业编码统一使用小写
+func normalizeCompanyCode(code string) string {
+	return strings.ToLower(strings.TrimSpace(code))
+}
+
+// loadConfig 读取企业的身份源配置，未配置或未启用时返回 404
+func (s *SSOService) loadConfig(ctx context.Context, companyCode string) (*IdPConfig, error) {
+	if companyCode == "" {
+		return nil, errors.New(errors.ErrorInvalidParams, "company code is required")
+	}
+	record, err := s.configDao.GetByKey(ctx, ConfigKeyPrefix+companyCode)
+	if err != nil {
+		if stderrors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New(errors.ErrorNotFound, "single sign-on is not configured for this company")
+		}
+		return nil, errors.Wrap(errors.ErrorDatabase, err)
+	}
+
+	var cfg IdPConfig
+	if err := json.Unmarshal([]byte(record.ConfigValue), &cfg); err != nil {
+		logger.GetLogger().Warn(fmt.Sprintf("企业 %s 的身份源配置解析失败: %v", companyCode, err))
+		return nil, errors.New(errors.ErrorServerError, "invalid single sign-on configuration")
+	}
+	if !cfg.Enabled {
+		return nil, errors.New(errors.ErrorNotFound, "single sign-on is not configured for this company")
+	}
+	if err := cfg.decryptSecrets(); err != nil {
+		logger.GetLogger().Warn(fmt.Sprintf("企业 %s 的身份源配置解密失败: %v", companyCode, err))
+		return nil, errors.New(errors.ErrorServerError, "invalid single sign-on configuration")
+	}
+	if err := cfg.validate(); err != nil {
+		logger.GetLogger().Warn(fmt.Sprintf("企业 %s 的身份源配置无效: %v", companyCode, err))
+		return nil, errors.New(errors.ErrorServerError, "invalid single sign-on configuration")
+	}
+	return &cfg, nil
+}
+
+// validate 校验配置必填项和角色取值
+func (c *IdPConfig) validate() error {
+	switch c.Type {
+	case ProviderOIDC:
+		if c.OIDC == nil || c.OIDC.Issuer == "" || c.OIDC.ClientID == "" || c.OIDC.RedirectURL == "" {
+			return fmt.Errorf("oidc 配置缺少 issuer、client_id 或 redirect_url")
+		}
+	case ProviderLDAP:
+		if c.LDAP == nil || c.LDAP.URL == "" || c.LDAP.BaseDN == "" {
+			return fmt.Errorf("ldap 配置缺少 url 或 base_dn")
+		}
+	default:
+		return fmt.Errorf("不支持的身份源类型 %q", c.Type)
+	}
+	for group, role := range c.GroupRoles {
+		if _, ok := roleRank[role]; !ok {
+			return fmt.Errorf("组 %s 映射到无效角色 %q", group, role)
+		}
+	}
+	if _, ok := roleRank[c.DefaultRole]; c.DefaultRole != "" && !ok {
+		return fmt.Errorf("无效的默认角色 %q", c.DefaultRole)
+	}
+	for _, m := range c.GroupWorkspaces {
+		if _, ok := workspaceRoleRank[m.Role]; m.Role != "" && !ok {
+			return fmt.Errorf("工作空间 %d 映射到无效角色 %q", m.WorkspaceID, m.Role)
+		}
+	}
+	return nil
+}
+
+// loadTypedConfig 读取配置并校验身份源类型
+func (s *SSOService) loadTypedConfig(ctx context.Context, companyCode, providerType string) (*IdPConfig, error) {
+	cfg, err := s.loadConfig(ctx, companyCode)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Type != providerType {
+		return nil, errors.New(errors.ErrorInvalidParams, fmt.Sprintf("this company uses %s single sign-on", cfg.Type))
+	}
+	return cfg, nil
+}
+
+// GetProvider 查询企业的身份源类型，供登录页选择登录方式
+func (s *SSOService) GetProvider(ctx context.Context, companyCode string) (*ProviderInfo, error) {
+	companyCode = normalizeCompanyCode(companyCode)
+	cfg, err := s.loadConfig(ctx, companyCode)
+	if err != nil {
+		return nil, err
+	}
+	return &ProviderInfo{CompanyCode: companyCode, CompanyName: cfg.CompanyName, Type: cfg.Type}, nil
+}
+
+// login 身份源认证通过后：查找或创建账号，同步角色和工作空间，完成登录
+func (s *SSOService) login(ctx context.Context, companyCode string, cfg *IdPConfig, ident *ExternalIdentity) (*serviceAuth.LoginResult, error) {
+	groups := groupSet(ident.Groups)
+	role := mapRole(cfg, groups)
+	if role == "" {
+		return nil, errors.New(errors.ErrorForbidden, "your account is not permitted to access this platform")
+	}
+
+	customer, identity, err := s.resolveCustomer(ctx, companyCode, cfg, ident, role)
+	if err != nil {
+		return nil, err
+	}
+	if customer.Status != "active" {
+		return nil, errors.New(errors.ErrorUserDisabled, "account is disabled")
+	}
+	if err := s.syncCustomer(ctx, customer, companyCode, cfg, role); err != nil {
+		return nil, err
+	}
+	s.syncWorkspaces(ctx, customer.ID, cfg, groups)
+
+	if err := s.identityDao.TouchLastLogin(ctx, identity.ID, time.Now()); err != nil {
+		logger.GetLogger().Warn(fmt.Sprintf("更新外部身份 %d 最后登录时间失败: %v", identity.ID, err))
+	}
+	return s.authService.CompleteExternalLogin(ctx, customer)
+}
+
+// resolveCustomer 按外部身份查找账号：已关联的直接返回；同企业同邮箱（身份源已验证邮箱）的账号自动关联；否则按配置自动创建
+func (s *SSOService) resolveCustomer(ctx context.Context, companyCode string, cfg *IdPConfig, ident *ExternalIdentity, role string) (*entity.Customer, *entity.CustomerIdentity, error) {
+	provider := cfg.Type + ":" + companyCode
+	identity, err := s.identityDao.FindByProviderSubject(ctx, provider, ident.Subject)
+	if err == nil {
+		customer, err := s.customerDao.FindByID(ctx, identity.CustomerID)
+		if err != nil {
+			return nil, nil, errors.Wrap(errors.ErrorDatabase, err)
+		}
+		return customer, identity, nil
+	}
+	if !stderrors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, errors.Wrap(errors.ErrorDatabase, err)
+	}
+
+	if ident.Email == "" {
+		return nil, nil, errors.New(errors.ErrorExternalAuth, "identity provider did not return an email address")
+	}
+	identity = &entity.CustomerIdentity{Provider: provider, Subject: ident.Subject}
+
+	existing, err := s.customerDao.FindByEmail(ctx, ident.Email)
+	if err == nil {
+		// 只关联同一企业且邮箱经身份源验证的账号，避免通过伪造邮箱接管其他账号
+		if !ident.EmailVerified || normalizeCompanyCode(existing.CompanyCode) != companyCode || auth.IsAdmin(existing.Role) {
+			return nil, nil, errors.New(errors.ErrorUserExists, "email is already used by another account")
+		}
+		identity.CustomerID = existing.ID
+		if err := s.identityDao.Create(ctx, identity); err != nil {
+			return nil, nil, errors.Wrap(errors.ErrorDatabase, err)
+		}
+		s.audit(ctx, existing, "sso_link", provider)
+		return existing, identity, nil
+	}
+	if !stderrors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, errors.Wrap(errors.ErrorDatabase, err)
+	}
+
+	if !cfg.AutoProvision {
+		return nil, nil, errors.New(errors.ErrorForbidden, "no account exists for this identity, contact your administrator")
+	}
+	customer, err := s.provision(ctx, companyCode, cfg, ident, role, identity)
+	if err != nil {
+		return nil, nil, err
+	}
+	s.audit(ctx, customer, "sso_provision", provider)
+	return customer, identity, nil
+}
+
+// provision 首次登录时创建企业账号并关联外部身份；账号没有可用的本地密码
+func (s *SSOService) provision(ctx context.Context, companyCode string, cfg *IdPConfig, ident *ExternalIdentity, role string, identity *entity.CustomerIdentity) (*entity.Customer, error) {
+	username, err := s.availableUsername(ctx, ident, companyCode)
+	if err != nil {
+		return nil, err
+	}
+	randomPassword, err := auth.GenerateRefreshToken()
+	if err != nil {
+		return nil, errors.WrapWithMessage(errors.ErrorServerError, "failed to generate password", err)
+	}
+	hash, err := auth.HashPassword(randomPassword)
+	if err != nil {
+		return nil, errors.WrapWithMessage(errors.ErrorServerError, "failed to hash password", err)
+	}
+
+	customer := &entity.Customer{
+		Username:      username,
+		Email:         ident.Email,
+		PasswordHash:  hash,
+		DisplayName:   ident.Name,
+		FullName:      ident.Name,
+		CompanyCode:   companyCode,
+		Company:       cfg.CompanyName,
+		Role:          role,
+		UserType:      "external",
+		AccountType:   "enterprise",
+		Status:        "active",
+		EmailVerified: ident.EmailVerified,
+	}
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(customer).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(customer).Update("auth_source", cfg.Type).Error; err != nil {
+			return err
+		}
+		identity.CustomerID = customer.ID
+		return tx.Create(identity).Error
+	})
+	if err != nil {
+		return nil, errors.WrapWithMessage(errors.ErrorDatabase, "failed to create account", err)
+	}
+	customer.AuthSource = cfg.Type
+	return customer, nil
+}
+
+// availableUsername 优先使用身份源的用户名，冲突时依次尝试 <用户名>.<企业编码>、<用户名>.<企业编码><序号>
+func (s *SSOService) availableUsername(ctx context.Context, ident *ExternalIdentity, companyCode string) (string, error) {
+	base := ident.Username
+	if base == "" {
+		base, _, _ = strings.Cut(ident.Email, "@")
+	}
+	candidates := []string{base, base + "." + companyCode}
+	for i := 2; i < maxUsernameSuffix; i++ {
+		candidates = append(candidates, base+"."+companyCode+strconv.Itoa(i))
+	}
+	for _, name := range candidates {
+		if len(name) > 64 {
+			name = name[:64]
+		}
+		// 已删除账号的用户名仍受唯一索引约束
+		var count int64
+		if err := s.db.WithContext(ctx).Unscoped().Model(&entity.Customer{}).
+			Where("username = ?", name).Count(&count).Error; err != nil {
+			return "", errors.Wrap(errors.ErrorDatabase, err)
+		}
+		if count == 0 {
+			return name, nil
+		}
+	}
+	return "", errors.New(errors.ErrorUserExists, "no available username for this identity")
+}
+
+// syncCustomer 每次登录按身份源更新角色和企业信息（管理员账号的角色不受身份源影响）
+func (s *SSOService) syncCustomer(ctx context.Context, customer *entity.Customer, companyCode string, cfg *IdPConfig, role string) error {
+	fields := map[string]interface{}{}
+	if !auth.IsAdmin(customer.Role) && customer.Role != role {
+		fields["role"] = role
+		customer.Role = role
+	}
+	if customer.CompanyCode != companyCode {
+		fields["company_code"] = companyCode
+		customer.CompanyCode = companyCode
+	}
+	if cfg.CompanyName != "" && customer.Company != cfg.CompanyName {
+		fields["company"] = cfg.CompanyName
+		customer.Company = cfg.CompanyName
+	}
+	if customer.AccountType != "enterprise" {
+		fields["account_type"] = "enterprise"
+		customer.AccountType = "enterprise"
+	}
+	if customer.AuthSource != cfg.Type {
+		fields["auth_source"] = cfg.Type
+		customer.AuthSource = cfg.Type
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	if err := s.customerDao.UpdateFields(ctx, customer.ID, fields); err != nil {
+		return errors.Wrap(errors.ErrorDatabase, err)
+	}
+	return nil
+}
+
+// syncWorkspaces 按组同步配置中列出的工作空间成员资格：在组内则加入（或调整角色），不在组内则移除；工作空间 owner 不受影响
+// 同步失败只记录日志，不影响登录
+func (s *SSOService) syncWorkspaces(ctx context.Context, customerID uint, cfg *IdPConfig, groups map[string]bool) {
+	desired := make(map[uint]string)
+	managed := make([]uint, 0, len(cfg.GroupWorkspaces))
+	for _, m := range cfg.GroupWorkspaces {
+		if _, seen := desired[m.WorkspaceID]; !seen {
+			desired[m.WorkspaceID] = ""
+			managed = append(managed, m.WorkspaceID)
+		}
+		if !groups[strings.ToLower(m.Group)] {
+			continue
+		}
+		role := m.Role
+		if role == "" {
+			role = "member"
+		}
+		if workspaceRoleRank[role] > workspaceRoleRank[desired[m.WorkspaceID]] {
+			desired[m.WorkspaceID] = role
+		}
+	}
+
+	for _, wsID := range managed {
+		if err := s.syncWorkspace(ctx, wsID, customerID, desired[wsID]); err != nil {
+			logger.GetLogger().Warn(fmt.Sprintf("同步用户 %d 的工作空间 %d 成员资格失败: %v", customerID, wsID, err))
+		}
+	}
+}
+
+// syncWorkspace 同步单个工作空间的成员资格，role 为空表示不应是成员
+func (s *SSOService) syncWorkspace(ctx context.Context, wsID, customerID uint, role string) error {
+	member, err := s.memberDao.FindByWorkspaceAndCustomer(ctx, wsID, customerID)
+	if err != nil && !stderrors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if member != nil && member.Role == "owner" {
+		return nil
+	}
+
+	switch {
+	case role == "" && member != nil:
+		return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := tx.Where("workspace_id = ? AND customer_id = ?", wsID, customerID).
+				Delete(&entity.WorkspaceMember{}).Error; err != nil {
+				return err
+			}
+			return tx.Model(&entity.Workspace{}).Where("id = ? AND member_count > 0", wsID).
+				UpdateColumn("member_count", gorm.Expr("member_count - 1")).Error
+		})
+	case role != "" && member == nil:
+		var count int64
+		if err := s.db.WithContext(ctx).Model(&entity.Workspace{}).Where("id = ?", wsID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return fmt.Errorf("工作空间不存在")
+		}
+		return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&entity.WorkspaceMember{
+				WorkspaceID: wsID,
+				CustomerID:  customerID,
+				Role:        role,
+				Status:      "active",
+				JoinedAt:    time.Now(),
+			}).Error; err != nil {
+				return err
+			}
+			return tx.Model(&entity.Workspace{}).Where("id = ?", wsID).
+				UpdateColumn("member_count", gorm.Expr("member_count + 1")).Error
+		})
+	case role != "" && member.Role != role:
+		return s.memberDao.UpdateRole(ctx, wsID, customerID, role)
+	}
+	return nil
+}
+
+// mapRole 按组映射客户角色，命中多个组时取优先级最高的角色，未命中时使用默认角色
+func mapRole(cfg *IdPConfig, groups map[string]bool) string {
+	role := ""
+	for group, mapped := range cfg.GroupRoles {
+		if groups[strings.ToLower(group)] && roleRank[mapped] > roleRank[role] {
+			role = mapped
+		}
+	}
+	if role == "" {
+		role = cfg.DefaultRole
+	}
+	return role
+}
+
+// groupSet 组名统一小写；LDAP 返回的组 DN（如 cn=ml-team,ou=groups,dc=acme,dc=com）同时登记完整 DN 和第一个 RDN 的值
+func groupSet(groups []string) map[string]bool {
+	set := make(map[string]bool, len(groups)*2)
+	for _, g := range groups {
+		g = strings.ToLower(strings.TrimSpace(g))
+		if g == "" {
+			continue
+		}
+		set[g] = true
+		first, _, _ := strings.Cut(g, ",")
+		if _, value, ok := strings.Cut(first, "="); ok {
+			set[strings.TrimSpace(value)] = true
+		}
+	}
+	return set
+}
+
+// audit 记录单点登录账号的创建和关联
+func (s *SSOService) audit(ctx context.Context, customer *entity.Customer, action, provider string) {
+	if s.auditService == nil {
+		return
+	}
+	_ = s.auditService.CreateLog(
+		ctx,
+		&customer.ID,
+		customer.Username, "", "POST", "/auth/sso",
+		action, "customer", strconv.FormatUint(uint64(customer.ID), 10),
+		map[string]interface{}{"provider": provider, "role": customer.Role},
+		200,
+	)
+}
@@ -0,0 +1,397 @@
+package sso
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"regexp"
+	"testing"
+
+	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+	serviceAuth "github.com/YoungBoyGod/remotegpu/internal/service/auth"
+	"github.com/YoungBoyGod/remotegpu/pkg/auth"
+	"github.com/YoungBoyGod/remotegpu/pkg/cache"
+	"github.com/YoungBoyGod/remotegpu/pkg/errors"
+	"github.com/go-ldap/ldap/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type ssoTestEnv struct {
+	db      *gorm.DB
+	authSvc *serviceAuth.AuthService
+	svc     *SSOService
+}
+
+func setupSSOTestEnv(t *testing.T) *ssoTestEnv {
+	require.NoError(t, auth.InitJWT("test-secret-key-must-be-at-least-32-characters", 1))
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+
+	for _, ddl := range []string{
+		`CREATE TABLE customers (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			created_at DATETIME,
+			updated_at DATETIME,
+			deleted_at DATETIME,
+			uuid TEXT,
+			username TEXT NOT NULL UNIQUE,
+			email TEXT NOT NULL UNIQUE,
+			password_hash TEXT NOT NULL,
+			display_name TEXT,
+			full_name TEXT,
+			company_code TEXT,
+			company TEXT,
+			phone TEXT,
+			avatar_url TEXT,
+			role TEXT DEFAULT 'customer_owner',
+			user_type TEXT DEFAULT 'external',
+			account_type TEXT DEFAULT 'individual',
+			status TEXT DEFAULT 'active',
+			email_verified INTEGER DEFAULT 0,
+			phone_verified INTEGER DEFAULT 0,
+			must_change_password INTEGER DEFAULT 0,
+			quota_gpu INTEGER DEFAULT 0,
+			quota_storage INTEGER DEFAULT 0,
+			last_login_at DATETIME,
+			failed_login_attempts INTEGER DEFAULT 0,
+			lockout_count INTEGER DEFAULT 0,
+			locked_until DATETIME,
+			mfa_enabled INTEGER DEFAULT 0,
+			mfa_secret TEXT DEFAULT '',
+			mfa_last_step INTEGER DEFAULT 0,
+			mfa_enabled_at DATETIME,
+			auth_source TEXT DEFAULT 'local'
+		)`,
+		`CREATE TABLE customer_identities (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			customer_id INTEGER NOT NULL,
+			provider TEXT NOT NULL,
+			subject TEXT NOT NULL,
+			last_login_at DATETIME,
+			created_at DATETIME,
+			UNIQUE (provider, subject)
+		)`,
+		`CREATE TABLE system_configs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			config_key TEXT NOT NULL UNIQUE,
+			config_value TEXT NOT NULL,
+			config_type TEXT NOT NULL DEFAULT 'string',
+			config_group TEXT NOT NULL DEFAULT 'general',
+			description TEXT,
+			is_public INTEGER DEFAULT 0,
+			created_at DATETIME,
+			updated_at DATETIME
+		)`,
+		`CREATE TABLE workspaces (id INTEGER PRIMARY KEY AUTOINCREMENT, uuid TEXT, owner_id INTEGER, name TEXT,
+			description TEXT, type TEXT, member_count INTEGER DEFAULT 1, status TEXT, quota_storage INTEGER DEFAULT 0,
+			created_at DATETIME, updated_at DATETIME)`,
+		`CREATE TABLE workspace_members (id INTEGER PRIMARY KEY AUTOINCREMENT, workspace_id INTEGER, customer_id INTEGER,
+			role TEXT, status TEXT DEFAULT 'active', joined_at DATETIME, created_at DATETIME, UNIQUE (workspace_id, customer_id))`,
+		`INSERT INTO workspaces (id, owner_id, name, member_count) VALUES (1, 100, 'ml-team', 1), (2, 100, 'research', 1)`,
+	} {
+		require.NoError(t, db.Exec(ddl).Error)
+	}
+
+	authSvc := serviceAuth.NewAuthService(db, cache.NewMemoryCache())
+	return &ssoTestEnv{db: db, authSvc: authSvc, svc: NewSSOService(db, cache.NewMemoryCache(), authSvc)}
+}
+
+// setIdP 保存企业身份源配置（密钥字段加密保存）
+func (env *ssoTestEnv) setIdP(t *testing.T, companyCode string, cfg IdPConfig) {
+	data, err := json.Marshal(cfg)
+	require.NoError(t, err)
+	value, err := ConfigCodec{}.Seal(string(data), "")
+	require.NoError(t, err)
+	key := ConfigKeyPrefix + companyCode
+	env.db.Where("config_key = ?", key).Delete(&entity.SystemConfig{})
+	require.NoError(t, env.db.Create(&entity.SystemConfig{
+		ConfigKey: key, ConfigValue: value, ConfigType: "json", ConfigGroup: "sso",
+	}).Error)
+}
+
+func (env *ssoTestEnv) customerByEmail(t *testing.T, email string) *entity.Customer {
+	var customer entity.Customer
+	require.NoError(t, env.db.Where("email = ?", email).First(&customer).Error)
+	return &customer
+}
+
+func (env *ssoTestEnv) memberRole(wsID, customerID uint) string {
+	var member entity.WorkspaceMember
+	if err := env.db.Where("workspace_id = ? AND customer_id = ?", wsID, customerID).First(&member).Error; err != nil {
+		return ""
+	}
+	return member.Role
+}
+
+func (env *ssoTestEnv) memberCount(t *testing.T, wsID uint) int {
+	var ws entity.Workspace
+	require.NoError(t, env.db.Select("member_count").First(&ws, wsID).Error)
+	return ws.MemberCount
+}
+
+func errorCode(err error) int {
+	if appErr := errors.GetAppError(err); appErr != nil {
+		return appErr.Code
+	}
+	return -1
+}
+
+// fakeLDAP 模拟 LDAP 目录：按 (uid=xxx) 过滤器查找用户，绑定时校验密码
+type fakeLDAP struct {
+	serviceDN       string
+	servicePassword string
+	users           map[string]*fakeLDAPUser // key 为 DN
+	filters         []string
+}
+
+type fakeLDAPUser struct {
+	password string
+	attrs    map[string][]string
+}
+
+var uidFilter = regexp.MustCompile(`^\(uid=(.*)\)$`)
+
+func (f *fakeLDAP) Bind(dn, password string) error {
+	if dn == f.serviceDN && password == f.servicePassword {
+		return nil
+	}
+	if u, ok := f.users[dn]; ok && u.password == password {
+		return nil
+	}
+	return ldap.NewError(ldap.LDAPResultInvalidCredentials, stderrors.New("invalid credentials"))
+}
+
+func (f *fakeLDAP) Search(req *ldap.SearchRequest) (*ldap.SearchResult, error) {
+	f.filters = append(f.filters, req.Filter)
+	result := &ldap.SearchResult{}
+	m := uidFilter.FindStringSubmatch(req.Filter)
+	if m == nil {
+		return result, nil
+	}
+	for dn, u := range f.users {
+		if len(u.attrs["uid"]) > 0 && u.attrs["uid"][0] == m[1] {
+			result.Entries = append(result.Entries, ldap.NewEntry(dn, u.attrs))
+		}
+	}
+	return result, nil
+}
+
+func (f *fakeLDAP) Close() error { return nil }
+
+func setupLDAP(t *testing.T, env *ssoTestEnv) *fakeLDAP {
+	directory := &fakeLDAP{
+		serviceDN:       "cn=svc,dc=acme,dc=com",
+		servicePassword: "svc-secret",
+		users: map[string]*fakeLDAPUser{
+			"uid=alice,ou=people,dc=acme,dc=com": {
+				password: "alice-pass",
+				attrs: map[string][]string{
+					"uid":      {"alice"},
+					"mail":     {"Alice@acme.com"},
+					"cn":       {"Alice Liu"},
+					"memberOf": {"cn=GPU-Owners,ou=groups,dc=acme,dc=com", "cn=ml-team,ou=groups,dc=acme,dc=com"},
+				},
+			},
+			"uid=bob,ou=people,dc=acme,dc=com": {
+				password: "bob-pass",
+				attrs: map[string][]string{
+					"uid":  {"bob"},
+					"mail": {"bob@acme.com"},
+				},
+			},
+		},
+	}
+	env.svc.dialLDAP = func(*LDAPConfig) (ldapConn, error) { return directory, nil }
+	env.setIdP(t, "acme", IdPConfig{
+		Type:          ProviderLDAP,
+		Enabled:       true,
+		CompanyName:   "Acme Inc.",
+		AutoProvision: true,
+		LDAP: &LDAPConfig{
+			URL:          "ldap://ldap.acme.com",
+			BindDN:       directory.serviceDN,
+			BindPassword: directory.servicePassword,
+			BaseDN:       "dc=acme,dc=com",
+		},
+		GroupRoles:      map[string]string{"gpu-owners": "customer_owner"},
+		DefaultRole:     "customer_member",
+		GroupWorkspaces: []WorkspaceMapping{{Group: "ml-team", WorkspaceID: 1, Role: "admin"}},
+	})
+	return directory
+}
+
+func TestLDAPLogin_ProvisionsAndMapsGroups(t *testing.T) {
+	env := setupSSOTestEnv(t)
+	setupLDAP(t, env)
+	ctx := context.Background()
+
+	result, err := env.svc.LDAPLogin(ctx, "ACME", "alice", "alice-pass")
+	require.NoError(t, err)
+	assert.NotEmpty(t, result.AccessToken)
+
+	customer := env.customerByEmail(t, "alice@acme.com")
+	assert.Equal(t, "alice", customer.Username)
+	assert.Equal(t, "customer_owner", customer.Role)
+	assert.Equal(t, "acme", customer.CompanyCode)
+	assert.Equal(t, "Acme Inc.", customer.Company)
+	assert.Equal(t, "enterprise", customer.AccountType)
+	assert.Equal(t, ProviderLDAP, customer.AuthSource)
+	assert.True(t, customer.EmailVerified)
+	assert.Equal(t, "admin", env.memberRole(1, customer.ID))
+	assert.Equal(t, 2, env.memberCount(t, 1))
+
+	var identity entity.CustomerIdentity
+	require.NoError(t, env.db.Where("customer_id = ?", customer.ID).First(&identity).Error)
+	assert.Equal(t, "ldap:acme", identity.Provider)
+	assert.Equal(t, "uid=alice,ou=people,dc=acme,dc=com", identity.Subject)
+	assert.NotNil(t, identity.LastLoginAt)
+
+	// 单点登录账号不能使用本地密码登录
+	_, err = env.authSvc.Login(ctx, "alice", "alice-pass", "127.0.0.1")
+	assert.Equal(t, errors.ErrorSSOLoginRequired, errorCode(err))
+}
+
+func TestLDAPLogin_InvalidCredentials(t *testing.T) {
+	env := setupSSOTestEnv(t)
+	directory := setupLDAP(t, env)
+	ctx := context.Background()
+
+	_, err := env.svc.LDAPLogin(ctx, "acme", "alice", "wrong")
+	assert.Equal(t, errors.ErrorPasswordIncorrect, errorCode(err))
+	_, err = env.svc.LDAPLogin(ctx, "acme", "nobody", "whatever")
+	assert.Equal(t, errors.ErrorPasswordIncorrect, errorCode(err))
+
+	// 空密码是匿名绑定，不能发往目录
+	searches := len(directory.filters)
+	_, err = env.svc.LDAPLogin(ctx, "acme", "alice", "")
+	assert.Equal(t, errors.ErrorPasswordIncorrect, errorCode(err))
+	assert.Len(t, directory.filters, searches)
+
+	// 用户名中的过滤器特殊字符被转义
+	_, err = env.svc.LDAPLogin(ctx, "acme", "*)(uid=*", "x")
+	assert.Equal(t, errors.ErrorPasswordIncorrect, errorCode(err))
+	assert.Equal(t, `(uid=\2a\29\28uid=\2a)`, directory.filters[len(directory.filters)-1])
+
+	var count int64
+	env.db.Model(&entity.Customer{}).Count(&count)
+	assert.Zero(t, count)
+}
+
+func TestLDAPLogin_SyncsRoleAndWorkspacesOnEachLogin(t *testing.T) {
+	env := setupSSOTestEnv(t)
+	directory := setupLDAP(t, env)
+	ctx := context.Background()
+
+	_, err := env.svc.LDAPLogin(ctx, "acme", "alice", "alice-pass")
+	require.NoError(t, err)
+	customer := env.customerByEmail(t, "alice@acme.com")
+	require.Equal(t, "admin", env.memberRole(1, customer.ID))
+
+	// 移出组后再次登录：角色降为默认角色，退出身份源管理的工作空间
+	directory.users["uid=alice,ou=people,dc=acme,dc=com"].attrs["memberOf"] = nil
+	_, err = env.svc.LDAPLogin(ctx, "acme", "alice", "alice-pass")
+	require.NoError(t, err)
+	customer = env.customerByEmail(t, "alice@acme.com")
+	assert.Equal(t, "customer_member", customer.Role)
+	assert.Empty(t, env.memberRole(1, customer.ID))
+	assert.Equal(t, 1, env.memberCount(t, 1))
+
+	var identities int64
+	env.db.Model(&entity.CustomerIdentity{}).Count(&identities)
+	assert.Equal(t, int64(1), identities)
+}
+
+func TestLDAPLogin_UnmappedGroupsDenied(t *testing.T) {
+	env := setupSSOTestEnv(t)
+	setupLDAP(t, env)
+	env.setIdP(t, "acme", IdPConfig{
+		Type:          ProviderLDAP,
+		Enabled:       true,
+		AutoProvision: true,
+		LDAP:          &LDAPConfig{URL: "ldap://ldap.acme.com", BaseDN: "dc=acme,dc=com"},
+		GroupRoles:    map[string]string{"gpu-owners": "customer_owner"},
+	})
+
+	_, err := env.svc.LDAPLogin(context.Background(), "acme", "bob", "bob-pass")
+	assert.Equal(t, errors.ErrorForbidden, errorCode(err))
+
+	// 命中映射的用户可以登录
+	_, err = env.svc.LDAPLogin(context.Background(), "acme", "alice", "alice-pass")
+	assert.NoError(t, err)
+}
+
+func TestLDAPLogin_AutoProvisionDisabled(t *testing.T) {
+	env := setupSSOTestEnv(t)
+	setupLDAP(t, env)
+	env.setIdP(t, "acme", IdPConfig{
+		Type:        ProviderLDAP,
+		Enabled:     true,
+		LDAP:        &LDAPConfig{URL: "ldap://ldap.acme.com", BaseDN: "dc=acme,dc=com"},
+		DefaultRole: "customer_member",
+	})
+	ctx := context.Background()
+
+	_, err := env.svc.LDAPLogin(ctx, "acme", "bob", "bob-pass")
+	assert.Equal(t, errors.ErrorForbidden, errorCode(err))
+
+	// 同企业同邮箱的本地账号自动关联，之后只能通过单点登录
+	local := &entity.Customer{Username: "bob.local", Email: "bob@acme.com", PasswordHash: "x", CompanyCode: "acme", Role: "customer_owner", Status: "active"}
+	require.NoError(t, env.db.Create(local).Error)
+	_, err = env.svc.LDAPLogin(ctx, "acme", "bob", "bob-pass")
+	require.NoError(t, err)
+	linked := env.customerByEmail(t, "bob@acme.com")
+	assert.Equal(t, local.ID, linked.ID)
+	assert.Equal(t, "customer_member", linked.Role)
+	assert.Equal(t, ProviderLDAP, linked.AuthSource)
+}
+
+func TestSSO_EmailOwnedByOtherCompany(t *testing.T) {
+	env := setupSSOTestEnv(t)
+	setupLDAP(t, env)
+	other := &entity.Customer{Username: "bob", Email: "bob@acme.com", PasswordHash: "x", CompanyCode: "globex", Status: "active"}
+	require.NoError(t, env.db.Create(other).Error)
+
+	_, err := env.svc.LDAPLogin(context.Background(), "acme", "bob", "bob-pass")
+	assert.Equal(t, errors.ErrorUserExists, errorCode(err))
+}
+
+func TestSSO_ProviderConfig(t *testing.T) {
+	env := setupSSOTestEnv(t)
+	ctx := context.Background()
+
+	_, err := env.svc.GetProvider(ctx, "acme")
+	assert.Equal(t, errors.ErrorNotFound, errorCode(err))
+
+	setupLDAP(t, env)
+	info, err := env.svc.GetProvider(ctx, " Acme ")
+	require.NoError(t, err)
+	assert.Equal(t, "acme", info.CompanyCode)
+	assert.Equal(t, ProviderLDAP, info.Type)
+
+	// 类型不匹配
+	_, err = env.svc.BeginOIDCLogin(ctx, "acme")
+	assert.Equal(t, errors.ErrorInvalidParams, errorCode(err))
+
+	// 未启用
+	env.setIdP(t, "acme", IdPConfig{Type: ProviderLDAP, LDAP: &LDAPConfig{URL: "ldap://x", BaseDN: "dc=x"}})
+	_, err = env.svc.GetProvider(ctx, "acme")
+	assert.Equal(t, errors.ErrorNotFound, errorCode(err))
+
+	// 无效角色
+	env.setIdP(t, "acme", IdPConfig{Type: ProviderLDAP, Enabled: true, LDAP: &LDAPConfig{URL: "ldap://x", BaseDN: "dc=x"}, DefaultRole: "admin"})
+	_, err = env.svc.GetProvider(ctx, "acme")
+	assert.Equal(t, errors.ErrorServerError, errorCode(err))
+}
+
+func TestGroupSet(t *testing.T) {
+	set := groupSet([]string{"CN=GPU-Owners,OU=Groups,DC=acme,DC=com", " ml-team ", ""})
+	assert.True(t, set["gpu-owners"])
+	assert.True(t, set["cn=gpu-owners,ou=groups,dc=acme,dc=com"])
+	assert.True(t, set["ml-team"])
+	assert.False(t, set[""])
+	assert.Len(t, set, 3)
+}
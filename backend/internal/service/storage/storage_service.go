@@ -97,8 +97,3 @@ func (s *StorageService) GetDownloadURL(ctx context.Context, backendName, path s
 	}
 	return backend.GetURL(ctx, path, 15*time.Minute)
 }
-
-// InitMultipart 初始化分片上传（占位，待对接具体实现）
-func (s *StorageService) InitMultipart(ctx context.Context, bucket, objectName string) (string, error) {
-	return "dummy_upload_id", nil
-}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/YoungBoyGod/remotegpu/internal/dao"
 	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
@@ -12,13 +13,23 @@ import (
 )
 
 var (
-	ErrConfigNotFound    = errors.New("配置项不存在")
-	ErrConfigKeyConflict = errors.New("配置键已存在")
+	ErrConfigNotFound     = errors.New("配置项不存在")
+	ErrConfigKeyConflict  = errors.New("配置键已存在")
+	ErrInvalidConfigValue = errors.New("配置值不合法")
 )
 
+// SecretCodec 处理配置值中的敏感字段（如 SSO 身份源密钥）
+type SecretCodec interface {
+	// Seal 保存前加密敏感字段，old 为当前保存的值
+	Seal(value, old string) (string, error)
+	// Mask 展示和审计时将敏感字段替换为掩码
+	Mask(value string) string
+}
+
 type SystemConfigService struct {
 	configDao    *dao.SystemConfigDao
 	auditService *audit.AuditService
+	codecs       map[string]SecretCodec
 }
 
 func NewSystemConfigService(db *gorm.DB) *SystemConfigService {
@@ -32,14 +43,24 @@ func (s *SystemConfigService) SetAuditService(auditSvc *audit.AuditService) {
 	s.auditService = auditSvc
 }
 
-// GetAllConfigs 获取所有配置项
+// SetSecretCodec 为指定前缀的配置键注册敏感字段处理（在 router 初始化时调用）
+func (s *SystemConfigService) SetSecretCodec(keyPrefix string, codec SecretCodec) {
+	if s.codecs == nil {
+		s.codecs = make(map[string]SecretCodec)
+	}
+	s.codecs[keyPrefix] = codec
+}
+
+// GetAllConfigs 获取所有配置项（敏感字段脱敏）
 func (s *SystemConfigService) GetAllConfigs(ctx context.Context) ([]entity.SystemConfig, error) {
-	return s.configDao.GetAll(ctx)
+	configs, err := s.configDao.GetAll(ctx)
+	return s.maskConfigs(configs), err
 }
 
-// GetConfigsByGroup 按分组获取配置项
+// GetConfigsByGroup 按分组获取配置项（敏感字段脱敏）
 func (s *SystemConfigService) GetConfigsByGroup(ctx context.Context, group string) ([]entity.SystemConfig, error) {
-	return s.configDao.GetByGroup(ctx, group)
+	configs, err := s.configDao.GetByGroup(ctx, group)
+	return s.maskConfigs(configs), err
 }
 
 // ListGroups 获取所有配置分组
@@ -56,32 +77,41 @@ func (s *SystemConfigService) GetConfig(ctx context.Context, id uint) (*entity.S
 		}
 		return nil, err
 	}
+	config.ConfigValue = s.mask(config.ConfigKey, config.ConfigValue)
 	return config, nil
 }
 
 // UpdateConfigs 批量更新配置值（带审计）
 func (s *SystemConfigService) UpdateConfigs(ctx context.Context, updates map[string]string, operator string) error {
-	// 记录变更前的值用于审计
-	if s.auditService != nil {
-		oldValues := make(map[string]string)
-		for key := range updates {
-			if old, err := s.configDao.GetByKey(ctx, key); err == nil {
-				oldValues[key] = old.ConfigValue
-			}
+	// 记录变更前的值用于审计，敏感字段提交掩码时沿用原值
+	oldValues := make(map[string]string)
+	for key := range updates {
+		if old, err := s.configDao.GetByKey(ctx, key); err == nil {
+			oldValues[key] = old.ConfigValue
+		}
+	}
+	sealed := make(map[string]string, len(updates))
+	for key, value := range updates {
+		v, err := s.seal(key, value, oldValues[key])
+		if err != nil {
+			return err
 		}
+		sealed[key] = v
+	}
+	if s.auditService != nil {
 		defer func() {
-			for key, newVal := range updates {
+			for key, newVal := range sealed {
 				detail := map[string]interface{}{
 					"config_key": key,
-					"old_value":  oldValues[key],
-					"new_value":  newVal,
+					"old_value":  s.mask(key, oldValues[key]),
+					"new_value":  s.mask(key, newVal),
 				}
 				_ = s.auditService.CreateLog(ctx, nil, operator, "", "PUT", "/admin/settings/configs",
 					"update_config", "system_config", key, detail, 200)
 			}
 		}()
 	}
-	return s.configDao.BatchUpdate(ctx, updates)
+	return s.configDao.BatchUpdate(ctx, sealed)
 }
 
 // CreateConfig 创建配置项（带审计）
@@ -96,6 +126,11 @@ func (s *SystemConfigService) CreateConfig(ctx context.Context, config *entity.S
 	if config.ConfigType == "" {
 		config.ConfigType = "string"
 	}
+	value, err := s.seal(config.ConfigKey, config.ConfigValue, "")
+	if err != nil {
+		return err
+	}
+	config.ConfigValue = value
 
 	if err := s.configDao.Create(ctx, config); err != nil {
 		return err
@@ -103,9 +138,10 @@ func (s *SystemConfigService) CreateConfig(ctx context.Context, config *entity.S
 
 	s.logAudit(ctx, operator, "create_config", config.ConfigKey, map[string]interface{}{
 		"config_key":   config.ConfigKey,
-		"config_value": config.ConfigValue,
+		"config_value": s.mask(config.ConfigKey, config.ConfigValue),
 		"config_group": config.ConfigGroup,
 	})
+	config.ConfigValue = s.mask(config.ConfigKey, config.ConfigValue)
 	return nil
 }
 
@@ -122,7 +158,9 @@ func (s *SystemConfigService) UpdateConfig(ctx context.Context, id uint, fields
 	oldValue := old.ConfigValue
 	if v, ok := fields["config_value"]; ok {
 		if str, ok := v.(string); ok {
-			old.ConfigValue = str
+			if old.ConfigValue, err = s.seal(old.ConfigKey, str, oldValue); err != nil {
+				return err
+			}
 		}
 	}
 	if v, ok := fields["description"]; ok {
@@ -152,8 +190,8 @@ func (s *SystemConfigService) UpdateConfig(ctx context.Context, id uint, fields
 
 	s.logAudit(ctx, operator, "update_config", old.ConfigKey, map[string]interface{}{
 		"config_key": old.ConfigKey,
-		"old_value":  oldValue,
-		"new_value":  old.ConfigValue,
+		"old_value":  s.mask(old.ConfigKey, oldValue),
+		"new_value":  s.mask(old.ConfigKey, old.ConfigValue),
 	})
 	return nil
 }
@@ -174,7 +212,7 @@ func (s *SystemConfigService) DeleteConfig(ctx context.Context, id uint, operato
 
 	s.logAudit(ctx, operator, "delete_config", config.ConfigKey, map[string]interface{}{
 		"config_key":   config.ConfigKey,
-		"config_value": config.ConfigValue,
+		"config_value": s.mask(config.ConfigKey, config.ConfigValue),
 	})
 	return nil
 }
@@ -188,3 +226,41 @@ func (s *SystemConfigService) logAudit(ctx context.Context, operator, action, re
 		fmt.Sprintf("/admin/settings/configs/%s", resourceID),
 		action, "system_config", resourceID, detail, 200)
 }
+
+// codecFor 返回配置键对应的敏感字段处理
+func (s *SystemConfigService) codecFor(key string) SecretCodec {
+	for prefix, codec := range s.codecs {
+		if strings.HasPrefix(key, prefix) {
+			return codec
+		}
+	}
+	return nil
+}
+
+// seal 保存前加密配置值中的敏感字段
+func (s *SystemConfigService) seal(key, value, old string) (string, error) {
+	codec := s.codecFor(key)
+	if codec == nil {
+		return value, nil
+	}
+	sealed, err := codec.Seal(value, old)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidConfigValue, err)
+	}
+	return sealed, nil
+}
+
+// mask 展示和审计时脱敏配置值
+func (s *SystemConfigService) mask(key, value string) string {
+	if codec := s.codecFor(key); codec != nil {
+		return codec.Mask(value)
+	}
+	return value
+}
+
+func (s *SystemConfigService) maskConfigs(configs []entity.SystemConfig) []entity.SystemConfig {
+	for i := range configs {
+		configs[i].ConfigValue = s.mask(configs[i].ConfigKey, configs[i].ConfigValue)
+	}
+	return configs
+}
@@ -0,0 +1,133 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+	"github.com/YoungBoyGod/remotegpu/pkg/logger"
+)
+
+// reapBatchSize 单轮最多回收的任务数
+const reapBatchSize = 100
+
+// TaskStatusNotifier 任务状态变更通知（由 NotificationService 实现）
+type TaskStatusNotifier interface {
+	PushTaskStatusChange(ctx context.Context, customerID uint, taskID, status string) error
+}
+
+// LeaseReaper 任务租约回收器
+// 定期扫描 Agent 停止续约导致租约过期的任务：未超过重试次数的重新排队（按 RetryDelay 延迟认领），否则标记失败，并通知任务所属用户
+type LeaseReaper struct {
+	taskService *TaskService
+	notifier    TaskStatusNotifier
+	interval    time.Duration
+	now         func() time.Time
+}
+
+// NewLeaseReaper 创建租约回收器，notifier 可为 nil
+func NewLeaseReaper(taskSvc *TaskService, notifier TaskStatusNotifier, interval time.Duration) *LeaseReaper {
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	return &LeaseReaper{
+		taskService: taskSvc,
+		notifier:    notifier,
+		interval:    interval,
+		now:         time.Now,
+	}
+}
+
+// Start 启动租约回收
+func (r *LeaseReaper) Start(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	logger.GetLogger().Info("任务租约回收服务已启动")
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.GetLogger().Info("任务租约回收服务已停止")
+			return
+		case <-ticker.C:
+			r.reap(ctx)
+		}
+	}
+}
+
+// reap 回收一轮过期租约，返回处理的任务数
+func (r *LeaseReaper) reap(ctx context.Context) int {
+	now := r.now()
+	tasks, err := r.taskService.taskDao.ListExpiredLeases(ctx, now, reapBatchSize)
+	if err != nil {
+		logger.GetLogger().Warn(fmt.Sprintf("查询租约过期任务失败: %v", err))
+		return 0
+	}
+
+	reaped := 0
+	for i := range tasks {
+		if r.reapTask(ctx, &tasks[i], now) {
+			reaped++
+		}
+	}
+	return reaped
+}
+
+func (r *LeaseReaper) reapTask(ctx context.Context, task *entity.Task, now time.Time) bool {
+	var (
+		status  string
+		updates map[string]interface{}
+	)
+	if task.RetryCount < task.MaxRetries {
+		status = "queued"
+		updates = map[string]interface{}{
+			"status":            status,
+			"retry_count":       task.RetryCount + 1,
+			"error_msg":         fmt.Sprintf("lease expired on agent %s (attempt %s), requeued for retry %d/%d", task.AssignedAgentID, task.AttemptID, task.RetryCount+1, task.MaxRetries),
+			"assigned_agent_id": "",
+			"attempt_id":        "",
+			"assigned_at":       nil,
+			"started_at":        nil,
+			"lease_expires_at":  nil,
+			"assigned_gpus":     nil,
+			"progress":          0,
+			"progress_message":  "",
+			"next_attempt_at":   now.Add(time.Duration(task.RetryDelay) * time.Second),
+		}
+		// 调度器选择的机器可能已失联，清空后重新调度；创建时指定的机器保持不变
+		if task.Placed {
+			updates["machine_id"] = ""
+			updates["placed"] = false
+		}
+	} else {
+		status = "failed"
+		updates = map[string]interface{}{
+			"status":    status,
+			"exit_code": -1,
+			"error_msg": fmt.Sprintf("lease expired on agent %s (attempt %s): agent stopped renewing and retries are exhausted (%d/%d)", task.AssignedAgentID, task.AttemptID, task.RetryCount, task.MaxRetries),
+			"ended_at":  now,
+		}
+	}
+
+	ok, err := r.taskService.taskDao.ReapExpiredLease(ctx, task.ID, task.AttemptID, now, updates)
+	if err != nil {
+		logger.GetLogger().Warn(fmt.Sprintf("回收任务 %s 租约失败: %v", task.ID, err))
+		return false
+	}
+	if !ok {
+		return false // Agent 已续约或完成
+	}
+	logger.GetLogger().Info(fmt.Sprintf("任务 %s 租约过期（Agent %s），状态更新为 %s", task.ID, task.AssignedAgentID, status))
+
+	if status == "failed" {
+		r.taskService.propagateDependencies(ctx, task.ID)
+	}
+	if r.notifier != nil {
+		if err := r.notifier.PushTaskStatusChange(ctx, task.CustomerID, task.ID, status); err != nil {
+			logger.GetLogger().Warn(fmt.Sprintf("推送任务 %s 状态通知失败: %v", task.ID, err))
+		}
+	}
+	return true
+}
@@ -0,0 +1,187 @@
+package task
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type fakeStatusNotifier struct {
+	pushed []string
+}
+
+func (n *fakeStatusNotifier) PushTaskStatusChange(ctx context.Context, customerID uint, taskID, status string) error {
+	n.pushed = append(n.pushed, taskID+":"+status)
+	return nil
+}
+
+// expireLease 模拟 Agent 认领任务后停止续约
+func expireLease(t *testing.T, db *gorm.DB, id string, retryCount, maxRetries int) {
+	expired := time.Now().Add(-time.Minute)
+	require.NoError(t, db.Model(&entity.Task{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":            "running",
+		"assigned_agent_id": "agent-1",
+		"attempt_id":        "attempt-" + id,
+		"lease_expires_at":  expired,
+		"retry_count":       retryCount,
+		"max_retries":       maxRetries,
+		"retry_delay":       60,
+	}).Error)
+}
+
+func findTask(t *testing.T, db *gorm.DB, id string) entity.Task {
+	var task entity.Task
+	require.NoError(t, db.First(&task, "id = ?", id).Error)
+	return task
+}
+
+func TestLeaseReaper_RequeuesWithDelay(t *testing.T) {
+	db := setupTaskTestDB(t)
+	svc := NewTaskService(db, nil)
+	notifier := &fakeStatusNotifier{}
+	placer := NewPlacer(db)
+	svc.SetPlacer(placer)
+	reaper := NewLeaseReaper(svc, notifier, time.Second)
+	ctx := context.Background()
+
+	createAllocation(t, db, "alloc-1", "host-1", 1)
+	reportGPUs(placer, "host-1", gpuMetric(0, "NVIDIA A100", 40960, 0))
+	createQueuedTask(t, db, entity.Task{ID: "t-1", CustomerID: 1})
+	expireLease(t, db, "t-1", 0, 3)
+
+	assert.Equal(t, 1, reaper.reap(ctx))
+	task := findTask(t, db, "t-1")
+	assert.Equal(t, "queued", task.Status)
+	assert.Equal(t, 1, task.RetryCount)
+	assert.Empty(t, task.AttemptID)
+	assert.Empty(t, task.AssignedAgentID)
+	assert.Nil(t, task.LeaseExpiresAt)
+	assert.Contains(t, task.ErrorMsg, "lease expired on agent agent-1")
+	require.NotNil(t, task.NextAttemptAt)
+	assert.Equal(t, []string{"t-1:queued"}, notifier.pushed)
+
+	// RetryDelay 未到之前不可被认领
+	tasks, err := svc.ClaimTasks(ctx, "host-1", "agent-2", 10)
+	require.NoError(t, err)
+	assert.Empty(t, tasks)
+
+	require.NoError(t, db.Model(&entity.Task{}).Where("id = ?", "t-1").
+		Update("next_attempt_at", time.Now().Add(-time.Second)).Error)
+	tasks, err = svc.ClaimTasks(ctx, "host-1", "agent-2", 10)
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, "agent-2", tasks[0].AssignedAgentID)
+}
+
+func TestLeaseReaper_FailsWhenRetriesExhausted(t *testing.T) {
+	db := setupTaskTestDB(t)
+	svc := NewTaskService(db, nil)
+	notifier := &fakeStatusNotifier{}
+	reaper := NewLeaseReaper(svc, notifier, time.Second)
+	ctx := context.Background()
+
+	ws, err := svc.CreateWorkflow(ctx, 1, pipelineSpecs())
+	require.NoError(t, err)
+	prepID := workflowTaskByName(t, ws, "preprocess").ID
+	expireLease(t, db, prepID, 3, 3)
+
+	assert.Equal(t, 1, reaper.reap(ctx))
+	task := findTask(t, db, prepID)
+	assert.Equal(t, "failed", task.Status)
+	assert.Equal(t, -1, task.ExitCode)
+	assert.Contains(t, task.ErrorMsg, "retries are exhausted")
+	assert.NotNil(t, task.EndedAt)
+	assert.Equal(t, []string{prepID + ":failed"}, notifier.pushed)
+
+	// 上游失败后下游任务级联取消
+	ws, err = svc.GetWorkflowWithAuth(ctx, ws.ID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "cancelled", workflowTaskByName(t, ws, "train").Status)
+}
+
+func TestLeaseReaper_SkipsLiveLeases(t *testing.T) {
+	db := setupTaskTestDB(t)
+	svc := NewTaskService(db, nil)
+	notifier := &fakeStatusNotifier{}
+	reaper := NewLeaseReaper(svc, notifier, time.Second)
+	ctx := context.Background()
+
+	createQueuedTask(t, db, entity.Task{ID: "t-live", CustomerID: 1})
+	expireLease(t, db, "t-live", 0, 3)
+	require.NoError(t, db.Model(&entity.Task{}).Where("id = ?", "t-live").
+		Update("lease_expires_at", time.Now().Add(time.Minute)).Error)
+
+	createQueuedTask(t, db, entity.Task{ID: "t-done", CustomerID: 1})
+	expireLease(t, db, "t-done", 0, 3)
+	require.NoError(t, db.Model(&entity.Task{}).Where("id = ?", "t-done").
+		Update("status", "completed").Error)
+
+	assert.Equal(t, 0, reaper.reap(ctx))
+	assert.Equal(t, "running", findTask(t, db, "t-live").Status)
+	assert.Equal(t, "completed", findTask(t, db, "t-done").Status)
+	assert.Empty(t, notifier.pushed)
+
+	// 查询后 Agent 抢先续约时，条件更新不会覆盖
+	createQueuedTask(t, db, entity.Task{ID: "t-race", CustomerID: 1})
+	expireLease(t, db, "t-race", 0, 3)
+	ok, err := svc.taskDao.ReapExpiredLease(ctx, "t-race", "attempt-other", time.Now(),
+		map[string]interface{}{"status": "queued"})
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, "running", findTask(t, db, "t-race").Status)
+}
+
+func TestLeaseReaper_PlacedTaskMovesToAnotherHost(t *testing.T) {
+	db := setupTaskTestDB(t)
+	svc := NewTaskService(db, nil)
+	placer := NewPlacer(db)
+	svc.SetPlacer(placer)
+	reaper := NewLeaseReaper(svc, nil, time.Second)
+	ctx := context.Background()
+
+	createAllocation(t, db, "alloc-1", "host-1", 1)
+	createAllocation(t, db, "alloc-2", "host-2", 1)
+	reportGPUs(placer, "host-1", gpuMetric(0, "NVIDIA A100", 40960, 0))
+	reportGPUs(placer, "host-2", gpuMetric(0, "NVIDIA A100", 40960, 0))
+	createQueuedTask(t, db, entity.Task{ID: "t-placed", CustomerID: 1, GPUCount: 1})
+
+	tasks, err := svc.ClaimTasks(ctx, "host-1", "agent-1", 10)
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	placed := findTask(t, db, "t-placed")
+	assert.Equal(t, "host-1", placed.MachineID)
+	assert.True(t, placed.Placed)
+
+	// host-1 失联，租约过期后重新排队
+	createQueuedTask(t, db, entity.Task{ID: "t-pinned", CustomerID: 1, MachineID: "host-1"})
+	expireLease(t, db, "t-placed", 0, 3)
+	expireLease(t, db, "t-pinned", 0, 3)
+	assert.Equal(t, 2, reaper.reap(ctx))
+	placed = findTask(t, db, "t-placed")
+	assert.Empty(t, placed.MachineID)
+	assert.False(t, placed.Placed)
+	assert.Empty(t, placed.AssignedGPUs)
+	assert.Equal(t, "host-1", findTask(t, db, "t-pinned").MachineID, "task pinned at creation keeps its machine")
+
+	require.NoError(t, db.Model(&entity.Task{}).Where("1 = 1").
+		Update("next_attempt_at", time.Now().Add(-time.Second)).Error)
+	tasks, err = svc.ClaimTasks(ctx, "host-2", "agent-2", 10)
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, "t-placed", tasks[0].ID)
+	assert.Equal(t, "host-2", tasks[0].MachineID)
+	assert.Equal(t, "agent-2", tasks[0].AssignedAgentID)
+
+	// 手动重试同样重新调度
+	require.NoError(t, db.Model(&entity.Task{}).Where("id = ?", "t-placed").Update("status", "failed").Error)
+	require.NoError(t, svc.taskDao.RetryTask(ctx, "t-placed", "queued"))
+	assert.Empty(t, findTask(t, db, "t-placed").MachineID)
+	require.NoError(t, db.Model(&entity.Task{}).Where("id = ?", "t-pinned").Update("status", "failed").Error)
+	require.NoError(t, svc.taskDao.RetryTask(ctx, "t-pinned", "queued"))
+	assert.Equal(t, "host-1", findTask(t, db, "t-pinned").MachineID)
+}
@@ -0,0 +1,293 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/YoungBoyGod/remotegpu/internal/dao"
+	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+	serviceMachine "github.com/YoungBoyGod/remotegpu/internal/service/machine"
+	"github.com/YoungBoyGod/remotegpu/pkg/logger"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+const (
+	// gpuSnapshotTTL 心跳 GPU 快照有效期，超时的主机视为状态未知，不参与 GPU 任务调度
+	gpuSnapshotTTL = 2 * time.Minute
+	// idleGPUMemoryMB 显存占用低于该值的 GPU 视为空闲
+	idleGPUMemoryMB = 1024
+	// placementScanLimit 单次认领最多检查的待调度任务数
+	placementScanLimit = 50
+)
+
+var (
+	ErrInvalidResourceRequest = errors.New("invalid resource request")
+	ErrHostNotAllocated       = errors.New("host not allocated to customer")
+)
+
+// hostGPUSnapshot 主机最近一次心跳上报的 GPU 状态
+type hostGPUSnapshot struct {
+	gpus       []serviceMachine.GPUMetricData
+	reportedAt time.Time
+}
+
+// Placer 跨机器任务调度器
+// 订阅 Agent 心跳记录各主机的 GPU 状态，在 Agent 认领时为未指定机器的任务判断该主机是否满足资源需求
+type Placer struct {
+	mu            sync.RWMutex
+	snapshots     map[string]hostGPUSnapshot
+	taskDao       *dao.TaskDao
+	allocationDao *dao.AllocationDao
+	now           func() time.Time
+}
+
+// NewPlacer 创建调度器
+func NewPlacer(db *gorm.DB) *Placer {
+	return &Placer{
+		snapshots:     make(map[string]hostGPUSnapshot),
+		taskDao:       dao.NewTaskDao(db),
+		allocationDao: dao.NewAllocationDao(db),
+		now:           time.Now,
+	}
+}
+
+// OnHeartbeat 实现 HeartbeatObserver，记录主机 GPU 快照
+func (p *Placer) OnHeartbeat(_ context.Context, hostID string, metrics *serviceMachine.HeartbeatMetrics) {
+	if metrics == nil {
+		return
+	}
+	gpus := make([]serviceMachine.GPUMetricData, len(metrics.GPUMetrics))
+	copy(gpus, metrics.GPUMetrics)
+
+	p.mu.Lock()
+	p.snapshots[hostID] = hostGPUSnapshot{gpus: gpus, reportedAt: p.now()}
+	p.mu.Unlock()
+}
+
+// gpuScope 客户在主机上可用的 GPU，gpus 为 nil 时表示整机分配
+type gpuScope struct {
+	gpus map[int]bool
+}
+
+func (s *gpuScope) allows(idx int) bool {
+	return s.gpus == nil || s.gpus[idx]
+}
+
+// ValidateRequest 校验任务的资源需求，指定的候选机器必须属于客户的有效分配
+// 指定机器的任务在客户只分配了该机器部分 GPU 时绑定到这些 GPU
+func (p *Placer) ValidateRequest(ctx context.Context, task *entity.Task) error {
+	if task.GPUCount < 0 || task.MinGPUMemoryMB < 0 {
+		return ErrInvalidResourceRequest
+	}
+	if task.MachineID != "" {
+		scope, err := p.scope(ctx, task.MachineID, task.CustomerID)
+		if err != nil {
+			return err
+		}
+		if scope != nil && scope.gpus != nil {
+			gpus := make([]int, 0, len(scope.gpus))
+			for idx := range scope.gpus {
+				gpus = append(gpus, idx)
+			}
+			sort.Ints(gpus)
+			task.AssignedGPUs, _ = json.Marshal(gpus)
+		}
+		return nil
+	}
+	hosts, err := decodeStrings(task.AllowedHosts)
+	if err != nil {
+		return ErrInvalidResourceRequest
+	}
+	for _, hostID := range hosts {
+		ok, err := p.allocationDao.HasActiveByHostAndCustomer(ctx, hostID, task.CustomerID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrHostNotAllocated
+		}
+	}
+	return nil
+}
+
+// Claim 在指定主机上为未指定机器的任务做调度，最多认领 limit 个
+func (p *Placer) Claim(ctx context.Context, hostID, agentID string, limit int) ([]entity.Task, error) {
+	if limit <= 0 {
+		return nil, nil
+	}
+	candidates, err := p.taskDao.ListUnplaced(ctx, hostID, placementScanLimit)
+	if err != nil || len(candidates) == 0 {
+		return nil, err
+	}
+
+	busy, err := p.busyGPUs(ctx, hostID)
+	if err != nil {
+		return nil, err
+	}
+	scopes := make(map[uint]*gpuScope)
+
+	var claimed []entity.Task
+	for i := range candidates {
+		task := &candidates[i]
+		scope := p.hostAllowed(ctx, task, hostID, scopes)
+		if scope == nil {
+			continue
+		}
+		gpus, ok := p.fit(task, hostID, busy, scope)
+		if !ok {
+			continue
+		}
+
+		var gpuJSON datatypes.JSON
+		if len(gpus) > 0 {
+			gpuJSON, _ = json.Marshal(gpus)
+		}
+		assigned, err := p.taskDao.AssignPlaced(ctx, task.ID, hostID, agentID, gpuJSON)
+		if err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				logger.GetLogger().Warn("assign placed task failed: " + err.Error())
+			}
+			continue // 已被其他 Agent 认领
+		}
+		for _, idx := range gpus {
+			busy[idx] = true
+		}
+		claimed = append(claimed, *assigned)
+		if len(claimed) >= limit {
+			break
+		}
+	}
+	return claimed, nil
+}
+
+// hostAllowed 主机必须属于任务所属客户的有效分配，且在任务指定的候选机器内，返回客户可用的 GPU，不允许时返回 nil
+func (p *Placer) hostAllowed(ctx context.Context, task *entity.Task, hostID string, scopes map[uint]*gpuScope) *gpuScope {
+	hosts, err := decodeStrings(task.AllowedHosts)
+	if err != nil {
+		return nil
+	}
+	if len(hosts) > 0 {
+		found := false
+		for _, h := range hosts {
+			if h == hostID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil
+		}
+	}
+
+	scope, checked := scopes[task.CustomerID]
+	if !checked {
+		scope, err = p.scope(ctx, hostID, task.CustomerID)
+		if err != nil {
+			return nil
+		}
+		scopes[task.CustomerID] = scope
+	}
+	return scope
+}
+
+// scope 汇总客户在主机上的活跃分配，没有分配时返回 nil
+func (p *Placer) scope(ctx context.Context, hostID string, customerID uint) (*gpuScope, error) {
+	return hostScope(ctx, p.allocationDao, hostID, customerID)
+}
+
+func hostScope(ctx context.Context, allocationDao *dao.AllocationDao, hostID string, customerID uint) (*gpuScope, error) {
+	allocs, err := allocationDao.FindAllActiveByHostAndCustomer(ctx, hostID, customerID)
+	if err != nil || len(allocs) == 0 {
+		return nil, err
+	}
+	scope := &gpuScope{gpus: make(map[int]bool)}
+	for _, a := range allocs {
+		if len(a.GPUIndexes) == 0 || string(a.GPUIndexes) == "null" {
+			return &gpuScope{}, nil
+		}
+		var gpus []int
+		if err := json.Unmarshal(a.GPUIndexes, &gpus); err != nil {
+			return nil, err
+		}
+		for _, idx := range gpus {
+			scope.gpus[idx] = true
+		}
+	}
+	return scope, nil
+}
+
+// fit 从客户可用的空闲 GPU 中选出满足任务需求的 GPU 序号
+func (p *Placer) fit(task *entity.Task, hostID string, busy map[int]bool, scope *gpuScope) ([]int, bool) {
+	if task.GPUCount == 0 {
+		return nil, true
+	}
+
+	p.mu.RLock()
+	snap, ok := p.snapshots[hostID]
+	p.mu.RUnlock()
+	if !ok || p.now().Sub(snap.reportedAt) > gpuSnapshotTTL {
+		return nil, false
+	}
+
+	model := strings.ToLower(task.GPUModel)
+	var free []int
+	for _, g := range snap.gpus {
+		if busy[g.Index] || !scope.allows(g.Index) {
+			continue
+		}
+		if model != "" && !strings.Contains(strings.ToLower(g.Name), model) {
+			continue
+		}
+		if task.MinGPUMemoryMB > 0 && (g.MemoryTotalMB == nil || *g.MemoryTotalMB < task.MinGPUMemoryMB) {
+			continue
+		}
+		if g.MemoryUsedMB != nil && *g.MemoryUsedMB >= idleGPUMemoryMB {
+			continue
+		}
+		free = append(free, g.Index)
+	}
+	if len(free) < task.GPUCount {
+		return nil, false
+	}
+	sort.Ints(free)
+	return free[:task.GPUCount], true
+}
+
+// busyGPUs 统计主机上已被认领或运行中任务占用的 GPU
+func (p *Placer) busyGPUs(ctx context.Context, hostID string) (map[int]bool, error) {
+	tasks, err := p.taskDao.ListActiveByMachineID(ctx, hostID)
+	if err != nil {
+		return nil, err
+	}
+	busy := make(map[int]bool)
+	for _, t := range tasks {
+		if len(t.AssignedGPUs) == 0 {
+			continue
+		}
+		var gpus []int
+		if err := json.Unmarshal(t.AssignedGPUs, &gpus); err != nil {
+			continue
+		}
+		for _, idx := range gpus {
+			busy[idx] = true
+		}
+	}
+	return busy, nil
+}
+
+func decodeStrings(raw datatypes.JSON) ([]string, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	var list []string
+	if err := json.Unmarshal(raw, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
@@ -0,0 +1,314 @@
+package task
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+	serviceMachine "github.com/YoungBoyGod/remotegpu/internal/service/machine"
+	"github.com/google/uuid"
+	"github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/datatypes"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// testSQLiteDriver 注册了 gen_random_uuid() 的 sqlite 驱动，用于覆盖认领任务的 SQL
+const testSQLiteDriver = "sqlite3_task_test"
+
+func init() {
+	sql.Register(testSQLiteDriver, &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			return conn.RegisterFunc("gen_random_uuid", uuid.NewString, false)
+		},
+	})
+}
+
+func setupTaskTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.New(sqlite.Config{DriverName: testSQLiteDriver, DSN: ":memory:"}), &gorm.Config{})
+	require.NoError(t, err)
+
+	err = db.Exec(`CREATE TABLE tasks (
+		id VARCHAR(64) PRIMARY KEY,
+		customer_id INTEGER NOT NULL,
+		name VARCHAR(256) NOT NULL,
+		type VARCHAR(32) NOT NULL DEFAULT 'shell',
+		command TEXT NOT NULL DEFAULT '',
+		args TEXT,
+		work_dir VARCHAR(500),
+		env_vars TEXT,
+		timeout INTEGER DEFAULT 3600,
+		priority INTEGER DEFAULT 5,
+		retry_count INTEGER DEFAULT 0,
+		retry_delay INTEGER DEFAULT 60,
+		max_retries INTEGER DEFAULT 3,
+		status VARCHAR(20) DEFAULT 'pending',
+		exit_code INTEGER DEFAULT 0,
+		error_msg TEXT,
+		stdout TEXT,
+		stderr TEXT,
+		progress INTEGER DEFAULT 0,
+		progress_message VARCHAR(500),
+		machine_id VARCHAR(64),
+		group_id VARCHAR(64),
+		parent_id VARCHAR(64),
+		depends_on TEXT,
+		gpu_count INTEGER DEFAULT 0,
+		gpu_model VARCHAR(128),
+		min_gpu_memory_mb INTEGER DEFAULT 0,
+		allowed_hosts TEXT,
+		assigned_gpus TEXT,
+		placed BOOLEAN DEFAULT 0,
+		assigned_agent_id VARCHAR(64),
+		lease_expires_at DATETIME,
+		next_attempt_at DATETIME,
+		attempt_id VARCHAR(64),
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		assigned_at DATETIME,
+		started_at DATETIME,
+		ended_at DATETIME,
+		host_id VARCHAR(64),
+		process_id INTEGER DEFAULT 0,
+		image_id INTEGER
+	)`).Error
+	require.NoError(t, err)
+
+	err = db.Exec(`CREATE TABLE allocations (
+		id VARCHAR(64) PRIMARY KEY,
+		customer_id INTEGER NOT NULL,
+		host_id VARCHAR(64) NOT NULL,
+		workspace_id INTEGER,
+		gpu_indexes TEXT,
+		gpu_count INTEGER DEFAULT 0,
+		start_time DATETIME,
+		end_time DATETIME,
+		actual_end_time DATETIME,
+		expiry_warned_at DATETIME,
+		status VARCHAR(32) DEFAULT 'active',
+		remark TEXT,
+		created_at DATETIME,
+		updated_at DATETIME
+	)`).Error
+	require.NoError(t, err)
+
+	return db
+}
+
+func gpuMetric(index int, name string, totalMB, usedMB int) serviceMachine.GPUMetricData {
+	return serviceMachine.GPUMetricData{Index: index, Name: name, MemoryTotalMB: &totalMB, MemoryUsedMB: &usedMB}
+}
+
+func reportGPUs(p *Placer, hostID string, gpus ...serviceMachine.GPUMetricData) {
+	p.OnHeartbeat(context.Background(), hostID, &serviceMachine.HeartbeatMetrics{GPUMetrics: gpus})
+}
+
+func createAllocation(t *testing.T, db *gorm.DB, id, hostID string, customerID uint) {
+	require.NoError(t, db.Exec(
+		"INSERT INTO allocations (id, customer_id, host_id, status) VALUES (?, ?, ?, 'active')",
+		id, customerID, hostID).Error)
+}
+
+func createQueuedTask(t *testing.T, db *gorm.DB, task entity.Task) {
+	task.Name = task.ID
+	task.Command = "python train.py"
+	task.Status = "queued"
+	task.CreatedAt = time.Now()
+	require.NoError(t, db.Create(&task).Error)
+}
+
+func TestPlacer_ClaimPicksFreeGPUs(t *testing.T) {
+	db := setupTaskTestDB(t)
+	placer := NewPlacer(db)
+	svc := NewTaskService(db, nil)
+	svc.SetPlacer(placer)
+	ctx := context.Background()
+
+	createAllocation(t, db, "alloc-1", "host-1", 1)
+	reportGPUs(placer, "host-1",
+		gpuMetric(0, "NVIDIA A100-SXM4-80GB", 81920, 30000), // 已被占用
+		gpuMetric(1, "NVIDIA A100-SXM4-80GB", 81920, 0),
+		gpuMetric(2, "NVIDIA A100-SXM4-80GB", 81920, 100),
+		gpuMetric(3, "NVIDIA A100-SXM4-80GB", 81920, 0),
+	)
+	createQueuedTask(t, db, entity.Task{ID: "t-a", CustomerID: 1, GPUCount: 2, GPUModel: "a100", Priority: 1})
+	createQueuedTask(t, db, entity.Task{ID: "t-b", CustomerID: 1, GPUCount: 2, Priority: 2})
+
+	tasks, err := svc.ClaimTasks(ctx, "host-1", "agent-1", 10)
+	require.NoError(t, err)
+	require.Len(t, tasks, 1, "only one task fits the three free GPUs")
+	assert.Equal(t, "t-a", tasks[0].ID)
+	assert.Equal(t, "host-1", tasks[0].MachineID)
+	assert.Equal(t, "assigned", tasks[0].Status)
+	assert.NotEmpty(t, tasks[0].AttemptID)
+
+	var gpus []int
+	require.NoError(t, json.Unmarshal(tasks[0].AssignedGPUs, &gpus))
+	assert.Equal(t, []int{1, 2}, gpus)
+
+	// 已分配的 GPU 在任务启动前也不会被重复分配
+	createQueuedTask(t, db, entity.Task{ID: "t-c", CustomerID: 1, GPUCount: 1, Priority: 3})
+	tasks, err = svc.ClaimTasks(ctx, "host-1", "agent-1", 10)
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, "t-c", tasks[0].ID)
+	require.NoError(t, json.Unmarshal(tasks[0].AssignedGPUs, &gpus))
+	assert.Equal(t, []int{3}, gpus)
+}
+
+func TestClaimTasks_PlacerErrorKeepsPinnedTasks(t *testing.T) {
+	db := setupTaskTestDB(t)
+	svc := NewTaskService(db, nil)
+	// 调度器使用没有建表的数据库，认领未指定机器的任务必然失败
+	brokenDB, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	svc.SetPlacer(NewPlacer(brokenDB))
+	ctx := context.Background()
+
+	_, err = svc.ClaimTasks(ctx, "host-1", "agent-1", 10)
+	assert.Error(t, err)
+
+	createQueuedTask(t, db, entity.Task{ID: "t-pinned", CustomerID: 1, MachineID: "host-1"})
+	tasks, err := svc.ClaimTasks(ctx, "host-1", "agent-1", 10)
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, "t-pinned", tasks[0].ID)
+	assert.Equal(t, "assigned", tasks[0].Status)
+}
+
+func TestClaimTasks_SharedHostRunsAsTenant(t *testing.T) {
+	db := setupTaskTestDB(t)
+	svc := NewTaskService(db, nil)
+	ctx := context.Background()
+
+	// 客户 1 在 host-1 上只分配了部分 GPU，客户 2 独占 host-2
+	require.NoError(t, db.Exec("INSERT INTO allocations (id, customer_id, host_id, status, gpu_indexes) VALUES ('alloc-1', 1, 'host-1', 'active', '[2,3]')").Error)
+	createAllocation(t, db, "alloc-2", "host-2", 2)
+	createQueuedTask(t, db, entity.Task{ID: "t-shared", CustomerID: 1, MachineID: "host-1"})
+	createQueuedTask(t, db, entity.Task{ID: "t-whole", CustomerID: 2, MachineID: "host-2"})
+
+	tasks, err := svc.ClaimTasks(ctx, "host-1", "agent-1", 10)
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, "rg-c1", tasks[0].RunAs)
+	payload, err := json.Marshal(tasks[0])
+	require.NoError(t, err)
+	assert.Contains(t, string(payload), `"run_as":"rg-c1"`)
+
+	tasks, err = svc.ClaimTasks(ctx, "host-2", "agent-2", 10)
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Empty(t, tasks[0].RunAs, "whole-machine allocations keep running tasks as the agent user")
+}
+
+func TestPlacer_RequirementsNotMet(t *testing.T) {
+	db := setupTaskTestDB(t)
+	placer := NewPlacer(db)
+	ctx := context.Background()
+
+	createAllocation(t, db, "alloc-1", "host-1", 1)
+	reportGPUs(placer, "host-1", gpuMetric(0, "NVIDIA RTX 4090", 24564, 0))
+
+	createQueuedTask(t, db, entity.Task{ID: "t-model", CustomerID: 1, GPUCount: 1, GPUModel: "H100"})
+	createQueuedTask(t, db, entity.Task{ID: "t-mem", CustomerID: 1, GPUCount: 1, MinGPUMemoryMB: 40000})
+	createQueuedTask(t, db, entity.Task{ID: "t-other-customer", CustomerID: 2, GPUCount: 1})
+	createQueuedTask(t, db, entity.Task{ID: "t-other-host", CustomerID: 1, GPUCount: 1,
+		AllowedHosts: datatypes.JSON(`["host-2"]`)})
+
+	tasks, err := placer.Claim(ctx, "host-1", "agent-1", 10)
+	require.NoError(t, err)
+	assert.Empty(t, tasks)
+}
+
+func TestPlacer_StaleSnapshot(t *testing.T) {
+	db := setupTaskTestDB(t)
+	placer := NewPlacer(db)
+	ctx := context.Background()
+
+	createAllocation(t, db, "alloc-1", "host-1", 1)
+	reportGPUs(placer, "host-1", gpuMetric(0, "NVIDIA A100", 40960, 0))
+	createQueuedTask(t, db, entity.Task{ID: "t-gpu", CustomerID: 1, GPUCount: 1})
+	createQueuedTask(t, db, entity.Task{ID: "t-cpu", CustomerID: 1})
+
+	placer.now = func() time.Time { return time.Now().Add(gpuSnapshotTTL + time.Minute) }
+
+	tasks, err := placer.Claim(ctx, "host-1", "agent-1", 10)
+	require.NoError(t, err)
+	require.Len(t, tasks, 1, "GPU task must wait for a fresh heartbeat")
+	assert.Equal(t, "t-cpu", tasks[0].ID)
+	assert.Empty(t, tasks[0].AssignedGPUs)
+}
+
+func TestPlacer_ValidateRequest(t *testing.T) {
+	db := setupTaskTestDB(t)
+	placer := NewPlacer(db)
+	ctx := context.Background()
+
+	createAllocation(t, db, "alloc-1", "host-1", 1)
+
+	assert.NoError(t, placer.ValidateRequest(ctx, &entity.Task{CustomerID: 1, GPUCount: 1,
+		AllowedHosts: datatypes.JSON(`["host-1"]`)}))
+	assert.ErrorIs(t, placer.ValidateRequest(ctx, &entity.Task{CustomerID: 1,
+		AllowedHosts: datatypes.JSON(`["host-1","host-2"]`)}), ErrHostNotAllocated)
+	assert.ErrorIs(t, placer.ValidateRequest(ctx, &entity.Task{CustomerID: 1, GPUCount: -1}), ErrInvalidResourceRequest)
+	assert.ErrorIs(t, placer.ValidateRequest(ctx, &entity.Task{CustomerID: 1,
+		AllowedHosts: datatypes.JSON(`"host-1"`)}), ErrInvalidResourceRequest)
+}
+
+func TestPlacer_SharedHostUsesAllocatedGPUs(t *testing.T) {
+	db := setupTaskTestDB(t)
+	placer := NewPlacer(db)
+	ctx := context.Background()
+
+	// 客户 1 分配了 GPU 2、3，客户 2 分配了 GPU 0、1
+	require.NoError(t, db.Exec("INSERT INTO allocations (id, customer_id, host_id, status, gpu_indexes) VALUES ('alloc-1', 1, 'host-1', 'active', '[2,3]')").Error)
+	require.NoError(t, db.Exec("INSERT INTO allocations (id, customer_id, host_id, status, gpu_indexes) VALUES ('alloc-2', 2, 'host-1', 'active', '[0,1]')").Error)
+	reportGPUs(placer, "host-1",
+		gpuMetric(0, "NVIDIA A100", 40960, 0),
+		gpuMetric(1, "NVIDIA A100", 40960, 0),
+		gpuMetric(2, "NVIDIA A100", 40960, 0),
+		gpuMetric(3, "NVIDIA A100", 40960, 30000), // 已被占用
+	)
+	createQueuedTask(t, db, entity.Task{ID: "t-two", CustomerID: 1, GPUCount: 2, Priority: 1})
+	createQueuedTask(t, db, entity.Task{ID: "t-one", CustomerID: 1, GPUCount: 1, Priority: 2})
+
+	tasks, err := placer.Claim(ctx, "host-1", "agent-1", 10)
+	require.NoError(t, err)
+	require.Len(t, tasks, 1, "the customer only has one free GPU of its own")
+	assert.Equal(t, "t-one", tasks[0].ID)
+	var gpus []int
+	require.NoError(t, json.Unmarshal(tasks[0].AssignedGPUs, &gpus))
+	assert.Equal(t, []int{2}, gpus)
+
+	// 指定机器的任务绑定到客户分配的 GPU
+	task := &entity.Task{CustomerID: 1, MachineID: "host-1"}
+	require.NoError(t, placer.ValidateRequest(ctx, task))
+	require.NoError(t, json.Unmarshal(task.AssignedGPUs, &gpus))
+	assert.Equal(t, []int{2, 3}, gpus)
+}
+
+func TestPlacer_OtherCustomersDoNotStarveScan(t *testing.T) {
+	db := setupTaskTestDB(t)
+	placer := NewPlacer(db)
+	ctx := context.Background()
+
+	createAllocation(t, db, "alloc-1", "host-1", 1)
+	createAllocation(t, db, "alloc-2", "host-2", 2)
+	reportGPUs(placer, "host-1", gpuMetric(0, "NVIDIA A100", 40960, 0))
+
+	// 其他客户的高优先级任务超过扫描窗口
+	for i := 0; i < placementScanLimit+10; i++ {
+		createQueuedTask(t, db, entity.Task{ID: fmt.Sprintf("t-other-%d", i), CustomerID: 2, Priority: 1})
+	}
+	createQueuedTask(t, db, entity.Task{ID: "t-mine", CustomerID: 1, GPUCount: 1, Priority: 9})
+
+	tasks, err := placer.Claim(ctx, "host-1", "agent-1", 10)
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, "t-mine", tasks[0].ID)
+}
@@ -0,0 +1,136 @@
+package task
+
+import (
+	"context"
+	"errors"
+
+	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+	"gorm.io/gorm"
+)
+
+const (
+	// maxLogChunkSize 单次上报的日志分片上限
+	maxLogChunkSize = 1 << 20
+	// defaultLogReadLimit 单次读取的默认字节数
+	defaultLogReadLimit = 64 << 10
+	maxLogReadLimit     = 1 << 20
+)
+
+var (
+	ErrInvalidLogStream = errors.New("invalid log stream")
+	ErrLogChunkTooLarge = errors.New("log chunk too large")
+)
+
+// TaskLogPage 一次日志读取的结果
+type TaskLogPage struct {
+	TaskID     string `json:"task_id"`
+	AttemptID  string `json:"attempt_id"`
+	Stream     string `json:"stream"`
+	Offset     int64  `json:"offset"`
+	NextOffset int64  `json:"next_offset"`
+	Size       int64  `json:"size"`
+	Content    string `json:"content"`
+	// Finished 任务已结束（或该尝试已被新的尝试取代）且已读到末尾，跟随读取可以停止
+	Finished bool `json:"finished"`
+	// Superseded 读取的尝试已不是任务的当前尝试（租约过期重新排队或重试）
+	Superseded bool `json:"superseded,omitempty"`
+}
+
+// AppendLog Agent 增量上报日志，返回该输出流已持久化的总长度
+func (s *TaskService) AppendLog(ctx context.Context, id, agentID, attemptID, stream string, offset int64, data []byte) (int64, error) {
+	if stream != "stdout" && stream != "stderr" {
+		return 0, ErrInvalidLogStream
+	}
+	if len(data) > maxLogChunkSize {
+		return 0, ErrLogChunkTooLarge
+	}
+
+	task, err := s.taskDao.FindByID(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	if task.AssignedAgentID != agentID || task.AttemptID != attemptID {
+		return 0, gorm.ErrRecordNotFound
+	}
+	return s.taskLogDao.Append(ctx, id, attemptID, stream, offset, data)
+}
+
+// ReadLog 读取任务日志
+// offset 为负数时从末尾倒数读取（tail）；attemptID 为空时读取当前尝试
+func (s *TaskService) ReadLog(ctx context.Context, task *entity.Task, attemptID, stream string, offset int64, limit int) (*TaskLogPage, error) {
+	if stream == "" {
+		stream = "stdout"
+	}
+	if stream != "stdout" && stream != "stderr" {
+		return nil, ErrInvalidLogStream
+	}
+	if limit <= 0 {
+		limit = defaultLogReadLimit
+	}
+	if limit > maxLogReadLimit {
+		limit = maxLogReadLimit
+	}
+	if attemptID == "" {
+		attemptID = task.AttemptID
+	}
+
+	size, err := s.taskLogDao.Size(ctx, task.ID, attemptID, stream)
+	if err != nil {
+		return nil, err
+	}
+
+	// 未增量上报的旧任务回退到完成时写入的输出
+	var legacy string
+	if size == 0 {
+		if stream == "stdout" {
+			legacy = task.Stdout
+		} else {
+			legacy = task.Stderr
+		}
+		size = int64(len(legacy))
+	}
+
+	if offset < 0 {
+		offset += size
+		if offset < 0 {
+			offset = 0
+		}
+	}
+	if offset > size {
+		offset = size
+	}
+
+	var content []byte
+	if legacy != "" {
+		end := offset + int64(limit)
+		if end > size {
+			end = size
+		}
+		content = []byte(legacy[offset:end])
+	} else if content, err = s.taskLogDao.Read(ctx, task.ID, attemptID, stream, offset, limit); err != nil {
+		return nil, err
+	}
+
+	next := offset + int64(len(content))
+	superseded := attemptID != "" && attemptID != task.AttemptID
+	return &TaskLogPage{
+		TaskID:     task.ID,
+		AttemptID:  attemptID,
+		Stream:     stream,
+		Offset:     offset,
+		NextOffset: next,
+		Size:       size,
+		Content:    string(content),
+		Finished:   (isTaskFinished(task.Status) || superseded) && next >= size,
+		Superseded: superseded,
+	}, nil
+}
+
+// isTaskFinished 任务是否已进入终态
+func isTaskFinished(status string) bool {
+	switch status {
+	case "completed", "failed", "cancelled", "stopped":
+		return true
+	}
+	return false
+}
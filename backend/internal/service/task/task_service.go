@@ -8,27 +8,55 @@ import (
 	"github.com/YoungBoyGod/remotegpu/internal/dao"
 	"github.com/YoungBoyGod/remotegpu/internal/middleware"
 	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+	serviceAllocation "github.com/YoungBoyGod/remotegpu/internal/service/allocation"
 	serviceOps "github.com/YoungBoyGod/remotegpu/internal/service/ops"
+	"github.com/YoungBoyGod/remotegpu/pkg/logger"
 	"gorm.io/gorm"
 )
 
+// ProgressNotifier 任务进度推送（由 NotificationService 实现）
+type ProgressNotifier interface {
+	PushTaskProgress(ctx context.Context, customerID uint, taskID string, percent int, message string)
+}
+
 type TaskService struct {
-	taskDao      *dao.TaskDao
-	agentService *serviceOps.AgentService
+	taskDao          *dao.TaskDao
+	taskLogDao       *dao.TaskLogDao
+	allocationDao    *dao.AllocationDao
+	agentService     *serviceOps.AgentService
+	progressNotifier ProgressNotifier
+	placer           *Placer
 }
 
 func NewTaskService(db *gorm.DB, agentSvc *serviceOps.AgentService) *TaskService {
 	return &TaskService{
-		taskDao:      dao.NewTaskDao(db),
-		agentService: agentSvc,
+		taskDao:       dao.NewTaskDao(db),
+		taskLogDao:    dao.NewTaskLogDao(db),
+		allocationDao: dao.NewAllocationDao(db),
+		agentService:  agentSvc,
 	}
 }
 
+// SetProgressNotifier 注入进度推送器，Agent 上报进度后实时推送给任务所属用户
+func (s *TaskService) SetProgressNotifier(n ProgressNotifier) {
+	s.progressNotifier = n
+}
+
+// SetPlacer 注入跨机器调度器，未指定机器的任务在 Agent 认领时按资源需求调度
+func (s *TaskService) SetPlacer(p *Placer) {
+	s.placer = p
+}
+
 func (s *TaskService) ListTasks(ctx context.Context, customerID uint, page, pageSize int) ([]entity.Task, int64, error) {
 	return s.taskDao.ListByCustomerID(ctx, customerID, page, pageSize)
 }
 
 func (s *TaskService) SubmitTask(ctx context.Context, task *entity.Task) error {
+	if s.placer != nil {
+		if err := s.placer.ValidateRequest(ctx, task); err != nil {
+			return err
+		}
+	}
 	task.Status = "queued"
 	if err := s.taskDao.Create(ctx, task); err != nil {
 		return err
@@ -47,7 +75,11 @@ func (s *TaskService) StopTask(ctx context.Context, id string) error {
 		return err
 	}
 
-	return s.taskDao.UpdateStatus(ctx, id, "stopped")
+	if err := s.taskDao.UpdateStatus(ctx, id, "stopped"); err != nil {
+		return err
+	}
+	s.propagateDependencies(ctx, id)
+	return nil
 }
 
 // GetTask 根据ID获取任务
@@ -78,7 +110,11 @@ func (s *TaskService) StopTaskWithAuth(ctx context.Context, id string, customerI
 		return err
 	}
 
-	return s.taskDao.UpdateStatus(ctx, id, "stopped")
+	if err := s.taskDao.UpdateStatus(ctx, id, "stopped"); err != nil {
+		return err
+	}
+	s.propagateDependencies(ctx, id)
+	return nil
 }
 
 func (s *TaskService) stopTaskProcess(ctx context.Context, task *entity.Task) error {
@@ -119,7 +155,11 @@ func (s *TaskService) ListAllTasks(ctx context.Context, status string, page, pag
 
 // CancelTask 取消任务
 func (s *TaskService) CancelTask(ctx context.Context, id string) error {
-	return s.taskDao.CancelTask(ctx, id)
+	if err := s.taskDao.CancelTask(ctx, id); err != nil {
+		return err
+	}
+	s.propagateDependencies(ctx, id)
+	return nil
 }
 
 // CancelTaskWithAuth 取消任务（带权限校验）
@@ -131,12 +171,21 @@ func (s *TaskService) CancelTaskWithAuth(ctx context.Context, id string, custome
 	if task.CustomerID != customerID {
 		return entity.ErrUnauthorized
 	}
-	return s.taskDao.CancelTask(ctx, id)
+	return s.CancelTask(ctx, id)
 }
 
-// RetryTask 重试任务
+// RetryTask 重试任务，工作流中上游未完成的任务直接回到 waiting，避免被 Agent 提前认领
 func (s *TaskService) RetryTask(ctx context.Context, id string) error {
-	return s.taskDao.RetryTask(ctx, id)
+	task, err := s.taskDao.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	status := s.retryStatus(ctx, task)
+	if err := s.taskDao.RetryTask(ctx, id, status); err != nil {
+		return err
+	}
+	s.reviveAfterRetry(ctx, task, status)
+	return nil
 }
 
 // RetryTaskWithAuth 重试任务（带权限校验）
@@ -148,14 +197,62 @@ func (s *TaskService) RetryTaskWithAuth(ctx context.Context, id string, customer
 	if task.CustomerID != customerID {
 		return entity.ErrUnauthorized
 	}
-	return s.taskDao.RetryTask(ctx, id)
+	return s.RetryTask(ctx, id)
 }
 
 // === Agent 专用 API ===
 
 // ClaimTasks Agent 认领任务
+// 优先认领指定到本机的任务，剩余名额交给调度器分配未指定机器的任务
 func (s *TaskService) ClaimTasks(ctx context.Context, machineID, agentID string, limit int) ([]entity.Task, error) {
-	return s.taskDao.ClaimTasks(ctx, machineID, agentID, limit)
+	tasks, err := s.claimTasks(ctx, machineID, agentID, limit)
+	if err != nil {
+		return nil, err
+	}
+	s.bindTenantAccounts(ctx, machineID, tasks)
+	return tasks, nil
+}
+
+func (s *TaskService) claimTasks(ctx context.Context, machineID, agentID string, limit int) ([]entity.Task, error) {
+	tasks, err := s.taskDao.ClaimTasks(ctx, machineID, agentID, limit)
+	if err != nil {
+		return nil, err
+	}
+	if s.placer == nil || len(tasks) >= limit {
+		return tasks, nil
+	}
+	placed, err := s.placer.Claim(ctx, machineID, agentID, limit-len(tasks))
+	if err != nil {
+		if len(tasks) == 0 {
+			return nil, err
+		}
+		// 指定到本机的任务已被租给该 Agent，必须返回，否则只能等租约过期后重新排队
+		logger.GetLogger().Warn(fmt.Sprintf("调度任务到机器 %s 失败: %v", machineID, err))
+		return tasks, nil
+	}
+	return append(tasks, placed...), nil
+}
+
+// bindTenantAccounts 客户在该机器上只分配了部分 GPU 时，任务以该客户的系统账号运行（Agent 同时限制其只能访问 assigned_gpus）
+func (s *TaskService) bindTenantAccounts(ctx context.Context, machineID string, tasks []entity.Task) {
+	scopes := make(map[uint]*gpuScope)
+	for i := range tasks {
+		task := &tasks[i]
+		scope, checked := scopes[task.CustomerID]
+		if !checked {
+			var err error
+			scope, err = hostScope(ctx, s.allocationDao, machineID, task.CustomerID)
+			if err != nil {
+				// 无法确认分配方式时按 GPU 粒度处理，避免以 root 运行
+				logger.GetLogger().Warn(fmt.Sprintf("查询客户 %d 在机器 %s 上的分配失败: %v", task.CustomerID, machineID, err))
+				scope = &gpuScope{gpus: make(map[int]bool)}
+			}
+			scopes[task.CustomerID] = scope
+		}
+		if scope != nil && scope.gpus != nil {
+			task.RunAs = serviceAllocation.TenantUsername(task.CustomerID)
+		}
+	}
 }
 
 // StartTask 标记任务开始
@@ -174,10 +271,21 @@ func (s *TaskService) CompleteTask(ctx context.Context, id, agentID, attemptID s
 		return err
 	}
 	middleware.TasksCompletedTotal.Inc()
+	s.propagateDependencies(ctx, id)
 	return nil
 }
 
 // ReportProgress 上报任务进度
 func (s *TaskService) ReportProgress(ctx context.Context, id, agentID, attemptID string, percent int, message string) error {
-	return s.taskDao.UpdateProgress(ctx, id, agentID, attemptID, percent, message)
+	if err := s.taskDao.UpdateProgress(ctx, id, agentID, attemptID, percent, message); err != nil {
+		return err
+	}
+	if s.progressNotifier != nil {
+		task, err := s.taskDao.FindByID(ctx, id)
+		if err != nil {
+			return nil // 进度已落库，推送失败不影响上报结果
+		}
+		s.progressNotifier.PushTaskProgress(ctx, task.CustomerID, id, percent, message)
+	}
+	return nil
 }
@@ -0,0 +1,405 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/YoungBoyGod/remotegpu/internal/middleware"
+	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+	"github.com/YoungBoyGod/remotegpu/pkg/logger"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// taskStatusWaiting 等待上游任务完成，不可被 Agent 认领
+	taskStatusWaiting = "waiting"
+	// upstreamFailedPrefix 因上游失败被级联取消时的错误信息前缀，重试上游任务时据此恢复下游
+	upstreamFailedPrefix = "upstream task "
+)
+
+var ErrInvalidWorkflow = errors.New("invalid workflow")
+
+// WorkflowTask 工作流中的任务定义，DependsOn 引用同一工作流内其他任务的 Key
+type WorkflowTask struct {
+	Key       string
+	DependsOn []string
+	Task      entity.Task
+}
+
+// WorkflowStatus 工作流状态，由同一 GroupID 下的任务聚合得出
+type WorkflowStatus struct {
+	ID     string         `json:"id"`
+	Status string         `json:"status"` // pending, running, completed, failed, cancelled
+	Total  int            `json:"total"`
+	Counts map[string]int `json:"counts"`
+	Tasks  []entity.Task  `json:"tasks"`
+}
+
+// CreateWorkflow 提交工作流：校验依赖构成 DAG 后在同一事务中创建全部任务
+// 无依赖的任务直接进入队列，其余任务处于 waiting 状态直到上游全部成功完成
+func (s *TaskService) CreateWorkflow(ctx context.Context, customerID uint, specs []WorkflowTask) (*WorkflowStatus, error) {
+	if len(specs) == 0 {
+		return nil, fmt.Errorf("%w: no tasks", ErrInvalidWorkflow)
+	}
+	if err := validateWorkflowGraph(specs); err != nil {
+		return nil, err
+	}
+
+	workflowID := uuid.NewString()
+	ids := make(map[string]string, len(specs))
+	for _, spec := range specs {
+		ids[spec.Key] = uuid.NewString()
+	}
+
+	tasks := make([]entity.Task, 0, len(specs))
+	for _, spec := range specs {
+		t := spec.Task
+		t.ID = ids[spec.Key]
+		t.CustomerID = customerID
+		t.GroupID = workflowID
+		t.AssignedGPUs = nil
+		if t.Name == "" {
+			t.Name = spec.Key
+		}
+		if t.Type == "" {
+			t.Type = "shell"
+		}
+		if s.placer != nil {
+			if err := s.placer.ValidateRequest(ctx, &t); err != nil {
+				return nil, err
+			}
+		}
+
+		t.Status = "queued"
+		if len(spec.DependsOn) > 0 {
+			deps := make([]string, len(spec.DependsOn))
+			for i, key := range spec.DependsOn {
+				deps[i] = ids[key]
+			}
+			t.DependsOn, _ = json.Marshal(deps)
+			t.Status = taskStatusWaiting
+		}
+		tasks = append(tasks, t)
+	}
+
+	if err := s.taskDao.CreateBatch(ctx, tasks); err != nil {
+		return nil, err
+	}
+	middleware.TasksCreatedTotal.Add(float64(len(tasks)))
+	return summarizeWorkflow(workflowID, tasks), nil
+}
+
+// validateWorkflowGraph 校验任务 Key 唯一、依赖存在且无环
+func validateWorkflowGraph(specs []WorkflowTask) error {
+	deps := make(map[string][]string, len(specs))
+	for _, spec := range specs {
+		if spec.Key == "" {
+			return fmt.Errorf("%w: task key is required", ErrInvalidWorkflow)
+		}
+		if _, dup := deps[spec.Key]; dup {
+			return fmt.Errorf("%w: duplicate task key %q", ErrInvalidWorkflow, spec.Key)
+		}
+		deps[spec.Key] = spec.DependsOn
+	}
+	for key, list := range deps {
+		for _, dep := range list {
+			if dep == key {
+				return fmt.Errorf("%w: task %q depends on itself", ErrInvalidWorkflow, key)
+			}
+			if _, ok := deps[dep]; !ok {
+				return fmt.Errorf("%w: task %q depends on unknown task %q", ErrInvalidWorkflow, key, dep)
+			}
+		}
+	}
+
+	// Kahn 拓扑排序，剩余未出队的节点即构成环
+	indegree := make(map[string]int, len(deps))
+	dependents := make(map[string][]string, len(deps))
+	for key, list := range deps {
+		indegree[key] = len(list)
+		for _, dep := range list {
+			dependents[dep] = append(dependents[dep], key)
+		}
+	}
+	queue := make([]string, 0, len(deps))
+	for key, n := range indegree {
+		if n == 0 {
+			queue = append(queue, key)
+		}
+	}
+	visited := 0
+	for len(queue) > 0 {
+		key := queue[0]
+		queue = queue[1:]
+		visited++
+		for _, next := range dependents[key] {
+			indegree[next]--
+			if indegree[next] == 0 {
+				queue = append(queue, next)
+			}
+		}
+	}
+	if visited != len(deps) {
+		return fmt.Errorf("%w: dependency cycle detected", ErrInvalidWorkflow)
+	}
+	return nil
+}
+
+// GetWorkflowWithAuth 查询工作流状态（带权限校验）
+func (s *TaskService) GetWorkflowWithAuth(ctx context.Context, id string, customerID uint) (*WorkflowStatus, error) {
+	tasks, err := s.taskDao.ListByGroupID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(tasks) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	if tasks[0].CustomerID != customerID {
+		return nil, entity.ErrUnauthorized
+	}
+	return summarizeWorkflow(id, tasks), nil
+}
+
+// CancelWorkflowWithAuth 取消工作流中尚未开始执行的任务，运行中的任务继续执行至结束
+func (s *TaskService) CancelWorkflowWithAuth(ctx context.Context, id string, customerID uint) (*WorkflowStatus, error) {
+	status, err := s.GetWorkflowWithAuth(ctx, id, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for _, t := range status.Tasks {
+		if _, err := s.taskDao.TransitionStatus(ctx, t.ID,
+			[]string{taskStatusWaiting, "queued", "pending", "assigned"},
+			map[string]interface{}{
+				"status":    "cancelled",
+				"error_msg": "workflow cancelled",
+				"ended_at":  now,
+			}); err != nil {
+			return nil, err
+		}
+	}
+	return s.GetWorkflowWithAuth(ctx, id, customerID)
+}
+
+// summarizeWorkflow 聚合工作流状态
+// 全部成功为 completed；仍有任务未结束时为 running/pending；否则存在失败为 failed，其余为 cancelled
+func summarizeWorkflow(id string, tasks []entity.Task) *WorkflowStatus {
+	ws := &WorkflowStatus{
+		ID:     id,
+		Total:  len(tasks),
+		Counts: make(map[string]int),
+		Tasks:  tasks,
+	}
+	for _, t := range tasks {
+		ws.Counts[t.Status]++
+	}
+
+	notStarted := ws.Counts[taskStatusWaiting] + ws.Counts["queued"] + ws.Counts["pending"]
+	active := notStarted + ws.Counts["assigned"] + ws.Counts["running"]
+	switch {
+	case ws.Counts["completed"] == ws.Total:
+		ws.Status = "completed"
+	case notStarted == ws.Total:
+		ws.Status = "pending"
+	case active > 0:
+		ws.Status = "running"
+	case ws.Counts["failed"] > 0:
+		ws.Status = "failed"
+	default:
+		ws.Status = "cancelled"
+	}
+	return ws
+}
+
+// propagateDependencies 任务结束后推进所在工作流：成功则释放满足依赖的下游，失败或取消则级联取消下游
+func (s *TaskService) propagateDependencies(ctx context.Context, id string) {
+	task, err := s.taskDao.FindByID(ctx, id)
+	if err != nil || task.GroupID == "" {
+		return
+	}
+	switch task.Status {
+	case "completed":
+		s.releaseDownstream(ctx, task.GroupID)
+	case "failed", "cancelled", "stopped":
+		s.cancelDownstream(ctx, task)
+	}
+}
+
+// releaseDownstream 将上游已全部成功的 waiting 任务放入队列
+func (s *TaskService) releaseDownstream(ctx context.Context, groupID string) {
+	tasks, err := s.taskDao.ListByGroupID(ctx, groupID)
+	if err != nil {
+		logger.GetLogger().Warn(fmt.Sprintf("load workflow %s failed: %v", groupID, err))
+		return
+	}
+	status := make(map[string]string, len(tasks))
+	for _, t := range tasks {
+		status[t.ID] = t.Status
+	}
+
+	for _, t := range tasks {
+		if t.Status != taskStatusWaiting {
+			continue
+		}
+		ready := true
+		for _, dep := range taskDependencies(&t) {
+			if status[dep] != "completed" {
+				ready = false
+				break
+			}
+		}
+		if !ready {
+			continue
+		}
+		if _, err := s.taskDao.TransitionStatus(ctx, t.ID, []string{taskStatusWaiting},
+			map[string]interface{}{"status": "queued"}); err != nil {
+			logger.GetLogger().Warn(fmt.Sprintf("release workflow task %s failed: %v", t.ID, err))
+		}
+	}
+}
+
+// cancelDownstream 级联取消失败任务的所有下游
+func (s *TaskService) cancelDownstream(ctx context.Context, root *entity.Task) {
+	tasks, err := s.taskDao.ListByGroupID(ctx, root.GroupID)
+	if err != nil {
+		logger.GetLogger().Warn(fmt.Sprintf("load workflow %s failed: %v", root.GroupID, err))
+		return
+	}
+
+	now := time.Now()
+	msg := fmt.Sprintf("%s%s %s", upstreamFailedPrefix, root.ID, root.Status)
+	dependents := workflowDependents(tasks)
+	queue := []string{root.ID}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, child := range dependents[id] {
+			ok, err := s.taskDao.TransitionStatus(ctx, child, []string{taskStatusWaiting},
+				map[string]interface{}{
+					"status":    "cancelled",
+					"error_msg": msg,
+					"ended_at":  now,
+				})
+			if err != nil {
+				logger.GetLogger().Warn(fmt.Sprintf("cancel workflow task %s failed: %v", child, err))
+				continue
+			}
+			if ok {
+				queue = append(queue, child)
+			}
+		}
+	}
+}
+
+// retryStatus 返回重试后任务的状态：工作流中上游未全部完成时为 waiting，否则为 queued
+func (s *TaskService) retryStatus(ctx context.Context, task *entity.Task) string {
+	deps := taskDependencies(task)
+	if task.GroupID == "" || len(deps) == 0 {
+		return "queued"
+	}
+	tasks, err := s.taskDao.ListByGroupID(ctx, task.GroupID)
+	if err != nil {
+		logger.GetLogger().Warn(fmt.Sprintf("load workflow %s failed: %v", task.GroupID, err))
+		return taskStatusWaiting
+	}
+	status := make(map[string]string, len(tasks))
+	for _, t := range tasks {
+		status[t.ID] = t.Status
+	}
+	for _, dep := range deps {
+		if status[dep] != "completed" {
+			return taskStatusWaiting
+		}
+	}
+	return "queued"
+}
+
+// reviveAfterRetry 重试工作流中的任务后：恢复因其失败被级联取消的下游
+func (s *TaskService) reviveAfterRetry(ctx context.Context, task *entity.Task, status string) {
+	if task.GroupID == "" {
+		return
+	}
+	// 计算状态后上游可能恰好完成，此时上游的完成回调看不到本任务，需要重新检查
+	if status == taskStatusWaiting {
+		s.releaseDownstream(ctx, task.GroupID)
+	}
+	tasks, err := s.taskDao.ListByGroupID(ctx, task.GroupID)
+	if err != nil {
+		logger.GetLogger().Warn(fmt.Sprintf("load workflow %s failed: %v", task.GroupID, err))
+		return
+	}
+
+	byID := make(map[string]*entity.Task, len(tasks))
+	for i := range tasks {
+		byID[tasks[i].ID] = &tasks[i]
+	}
+
+	dependents := workflowDependents(tasks)
+	queue := []string{task.ID}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, child := range dependents[cur] {
+			t := byID[child]
+			if t.Status != "cancelled" || !strings.HasPrefix(t.ErrorMsg, upstreamFailedPrefix) {
+				continue
+			}
+			if hasFailedDependency(t, byID) {
+				continue // 仍有其他上游失败，保持取消
+			}
+			ok, err := s.taskDao.TransitionStatus(ctx, child, []string{"cancelled"},
+				map[string]interface{}{
+					"status":    taskStatusWaiting,
+					"error_msg": "",
+					"ended_at":  nil,
+				})
+			if err != nil {
+				logger.GetLogger().Warn(fmt.Sprintf("revive workflow task %s failed: %v", child, err))
+				continue
+			}
+			if ok {
+				t.Status = taskStatusWaiting
+				queue = append(queue, child)
+			}
+		}
+	}
+}
+
+// hasFailedDependency 判断任务是否存在失败或被取消的上游
+func hasFailedDependency(t *entity.Task, byID map[string]*entity.Task) bool {
+	for _, dep := range taskDependencies(t) {
+		if d, ok := byID[dep]; ok {
+			switch d.Status {
+			case "failed", "cancelled", "stopped":
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// taskDependencies 解析任务的上游任务 ID
+func taskDependencies(t *entity.Task) []string {
+	deps, err := decodeStrings(t.DependsOn)
+	if err != nil {
+		return nil
+	}
+	return deps
+}
+
+// workflowDependents 构建上游任务 ID -> 下游任务 ID 列表
+func workflowDependents(tasks []entity.Task) map[string][]string {
+	dependents := make(map[string][]string)
+	for i := range tasks {
+		for _, dep := range taskDependencies(&tasks[i]) {
+			dependents[dep] = append(dependents[dep], tasks[i].ID)
+		}
+	}
+	return dependents
+}
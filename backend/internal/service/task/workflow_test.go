@@ -0,0 +1,175 @@
+package task
+
+import (
+	"context"
+	"testing"
+
+	"github.com/YoungBoyGod/remotegpu/internal/model/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// pipelineSpecs preprocess → train → evaluate，另有一个与 train 并行的 report 分支
+func pipelineSpecs() []WorkflowTask {
+	return []WorkflowTask{
+		{Key: "preprocess", Task: entity.Task{Command: "python prep.py"}},
+		{Key: "train", DependsOn: []string{"preprocess"}, Task: entity.Task{Command: "python train.py"}},
+		{Key: "evaluate", DependsOn: []string{"train"}, Task: entity.Task{Command: "python eval.py"}},
+		{Key: "report", DependsOn: []string{"preprocess"}, Task: entity.Task{Command: "python report.py"}},
+	}
+}
+
+// runWorkflowTask 模拟 Agent 认领并完成任务
+func runWorkflowTask(t *testing.T, db *gorm.DB, svc *TaskService, id string, exitCode int) {
+	require.NoError(t, db.Model(&entity.Task{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":            "running",
+		"assigned_agent_id": "agent-1",
+		"attempt_id":        "attempt-" + id,
+	}).Error)
+	require.NoError(t, svc.CompleteTask(context.Background(), id, "agent-1", "attempt-"+id, exitCode, "", "", ""))
+}
+
+func workflowTaskByName(t *testing.T, ws *WorkflowStatus, name string) entity.Task {
+	for _, task := range ws.Tasks {
+		if task.Name == name {
+			return task
+		}
+	}
+	t.Fatalf("task %s not found in workflow", name)
+	return entity.Task{}
+}
+
+func TestCreateWorkflow_InvalidGraph(t *testing.T) {
+	svc := NewTaskService(setupTaskTestDB(t), nil)
+	ctx := context.Background()
+
+	cases := [][]WorkflowTask{
+		{},
+		{{Key: "a"}, {Key: "a"}},
+		{{Key: "a", DependsOn: []string{"missing"}}},
+		{{Key: "a", DependsOn: []string{"a"}}},
+		{{Key: "a", DependsOn: []string{"c"}}, {Key: "b", DependsOn: []string{"a"}}, {Key: "c", DependsOn: []string{"b"}}},
+	}
+	for _, specs := range cases {
+		_, err := svc.CreateWorkflow(ctx, 1, specs)
+		assert.ErrorIs(t, err, ErrInvalidWorkflow)
+	}
+}
+
+func TestWorkflow_ReleasesDownstreamOnSuccess(t *testing.T) {
+	db := setupTaskTestDB(t)
+	svc := NewTaskService(db, nil)
+	ctx := context.Background()
+
+	ws, err := svc.CreateWorkflow(ctx, 1, pipelineSpecs())
+	require.NoError(t, err)
+	assert.Equal(t, "pending", ws.Status)
+	assert.Equal(t, 4, ws.Total)
+	assert.Equal(t, "queued", workflowTaskByName(t, ws, "preprocess").Status)
+	assert.Equal(t, "waiting", workflowTaskByName(t, ws, "train").Status)
+
+	runWorkflowTask(t, db, svc, workflowTaskByName(t, ws, "preprocess").ID, 0)
+
+	ws, err = svc.GetWorkflowWithAuth(ctx, ws.ID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "running", ws.Status)
+	assert.Equal(t, "queued", workflowTaskByName(t, ws, "train").Status)
+	assert.Equal(t, "queued", workflowTaskByName(t, ws, "report").Status)
+	assert.Equal(t, "waiting", workflowTaskByName(t, ws, "evaluate").Status)
+
+	for _, name := range []string{"train", "report", "evaluate"} {
+		runWorkflowTask(t, db, svc, workflowTaskByName(t, ws, name).ID, 0)
+	}
+	ws, err = svc.GetWorkflowWithAuth(ctx, ws.ID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "completed", ws.Status)
+	assert.Equal(t, 4, ws.Counts["completed"])
+}
+
+func TestWorkflow_FailureCascadesAndRetryRevives(t *testing.T) {
+	db := setupTaskTestDB(t)
+	svc := NewTaskService(db, nil)
+	ctx := context.Background()
+
+	ws, err := svc.CreateWorkflow(ctx, 1, pipelineSpecs())
+	require.NoError(t, err)
+	runWorkflowTask(t, db, svc, workflowTaskByName(t, ws, "preprocess").ID, 0)
+	trainID := workflowTaskByName(t, ws, "train").ID
+	runWorkflowTask(t, db, svc, trainID, 1)
+
+	ws, err = svc.GetWorkflowWithAuth(ctx, ws.ID, 1)
+	require.NoError(t, err)
+	evaluate := workflowTaskByName(t, ws, "evaluate")
+	assert.Equal(t, "cancelled", evaluate.Status)
+	assert.Contains(t, evaluate.ErrorMsg, trainID)
+	assert.Equal(t, "queued", workflowTaskByName(t, ws, "report").Status, "sibling branch keeps running")
+	assert.Equal(t, "running", ws.Status)
+
+	runWorkflowTask(t, db, svc, workflowTaskByName(t, ws, "report").ID, 0)
+	ws, err = svc.GetWorkflowWithAuth(ctx, ws.ID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "failed", ws.Status)
+
+	// 重试失败的 train 后，被级联取消的 evaluate 恢复等待
+	require.NoError(t, svc.RetryTask(ctx, trainID))
+	ws, err = svc.GetWorkflowWithAuth(ctx, ws.ID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "queued", workflowTaskByName(t, ws, "train").Status)
+	assert.Equal(t, "waiting", workflowTaskByName(t, ws, "evaluate").Status)
+	assert.Empty(t, workflowTaskByName(t, ws, "evaluate").ErrorMsg)
+}
+
+func TestWorkflow_RetryBeforeUpstreamCompletes(t *testing.T) {
+	db := setupTaskTestDB(t)
+	svc := NewTaskService(db, nil)
+	ctx := context.Background()
+
+	ws, err := svc.CreateWorkflow(ctx, 1, pipelineSpecs())
+	require.NoError(t, err)
+	runWorkflowTask(t, db, svc, workflowTaskByName(t, ws, "preprocess").ID, 0)
+	runWorkflowTask(t, db, svc, workflowTaskByName(t, ws, "train").ID, 1)
+	evaluateID := workflowTaskByName(t, ws, "evaluate").ID
+
+	// 记录重试过程中写入的任务状态，上游未完成的任务不能出现可被认领的 queued
+	var written []string
+	require.NoError(t, db.Callback().Update().Before("gorm:update").Register("test:record_status", func(tx *gorm.DB) {
+		if fields, ok := tx.Statement.Dest.(map[string]interface{}); ok {
+			if status, ok := fields["status"].(string); ok {
+				written = append(written, status)
+			}
+		}
+	}))
+
+	require.NoError(t, svc.RetryTask(ctx, evaluateID))
+	assert.NotContains(t, written, "queued")
+	assert.Equal(t, "waiting", findTask(t, db, evaluateID).Status)
+}
+
+func TestWorkflow_CancelAndAuth(t *testing.T) {
+	db := setupTaskTestDB(t)
+	svc := NewTaskService(db, nil)
+	ctx := context.Background()
+
+	ws, err := svc.CreateWorkflow(ctx, 1, pipelineSpecs())
+	require.NoError(t, err)
+
+	_, err = svc.GetWorkflowWithAuth(ctx, ws.ID, 2)
+	assert.ErrorIs(t, err, entity.ErrUnauthorized)
+	_, err = svc.GetWorkflowWithAuth(ctx, "missing", 1)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	// 取消单个上游任务同样会级联取消下游
+	require.NoError(t, svc.CancelTaskWithAuth(ctx, workflowTaskByName(t, ws, "preprocess").ID, 1))
+	ws, err = svc.GetWorkflowWithAuth(ctx, ws.ID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "cancelled", ws.Status)
+	assert.Equal(t, 4, ws.Counts["cancelled"])
+
+	ws, err = svc.CreateWorkflow(ctx, 1, pipelineSpecs())
+	require.NoError(t, err)
+	ws, err = svc.CancelWorkflowWithAuth(ctx, ws.ID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "cancelled", ws.Status)
+	assert.Equal(t, 4, ws.Counts["cancelled"])
+}
@@ -93,6 +93,14 @@ func (s *WorkspaceService) Update(ctx context.Context, wsID, customerID uint, fi
 	return s.db.WithContext(ctx).Model(&entity.Workspace{}).Where("id = ?", wsID).Updates(fields).Error
 }
 
+// UpdateStorageQuota 更新工作空间存储配额（MB），由管理员操作
+func (s *WorkspaceService) UpdateStorageQuota(ctx context.Context, wsID uint, quotaMB int64) error {
+	if _, err := s.wsDao.FindByID(ctx, wsID); err != nil {
+		return err
+	}
+	return s.wsDao.UpdateQuotaStorage(ctx, wsID, quotaMB)
+}
+
 // Delete 删除工作空间（仅 owner 可操作）
 func (s *WorkspaceService) Delete(ctx context.Context, wsID, customerID uint) error {
 	if err := s.requireRole(ctx, wsID, customerID, "owner"); err != nil {
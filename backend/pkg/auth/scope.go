@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// 个人访问令牌可授权的资源，权限范围格式为 <资源>:read 或 <资源>:write，write 包含 read
+const (
+	ScopeMachines      = "machines"
+	ScopeTasks         = "tasks"
+	ScopeDatasets      = "datasets"
+	ScopeBilling       = "billing"
+	ScopeEnvironments  = "environments"
+	ScopeWorkspaces    = "workspaces"
+	ScopeNotifications = "notifications"
+)
+
+var scopeResources = []string{
+	ScopeMachines, ScopeTasks, ScopeDatasets, ScopeBilling,
+	ScopeEnvironments, ScopeWorkspaces, ScopeNotifications,
+}
+
+// AccessTokenPrefix 个人访问令牌前缀，用于与 JWT 区分
+const AccessTokenPrefix = "rgpat_"
+
+// ScopeFor 返回资源在指定访问方式下的权限范围
+func ScopeFor(resource string, write bool) string {
+	if write {
+		return resource + ":write"
+	}
+	return resource + ":read"
+}
+
+// IsValidScope 检查权限范围是否有效
+func IsValidScope(scope string) bool {
+	resource, access, ok := strings.Cut(scope, ":")
+	if !ok || (access != "read" && access != "write") {
+		return false
+	}
+	for _, r := range scopeResources {
+		if r == resource {
+			return true
+		}
+	}
+	return false
+}
+
+// HasScope 检查已授权的权限范围是否包含 required，<资源>:write 同时授予 <资源>:read
+func HasScope(granted []string, required string) bool {
+	resource, _, _ := strings.Cut(required, ":")
+	for _, s := range granted {
+		if s == required || s == resource+":write" {
+			return true
+		}
+	}
+	return false
+}
+
+// GenerateAccessToken 生成个人访问令牌，明文只返回给用户一次
+func GenerateAccessToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return AccessTokenPrefix + hex.EncodeToString(b), nil
+}
+
+// IsAccessToken 判断 Bearer 凭证是否为个人访问令牌
+func IsAccessToken(token string) bool {
+	return strings.HasPrefix(token, AccessTokenPrefix)
+}
+
+// HashAccessToken 计算访问令牌哈希，数据库只保存哈希
+func HashAccessToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"encoding/base32"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestValidateTOTP_RFC6238 使用 RFC 6238 附录 B 的 SHA1 测试向量（取后 6 位）
+func TestValidateTOTP_RFC6238(t *testing.T) {
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+	cases := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, c := range cases {
+		step, ok := ValidateTOTP(secret, c.code, time.Unix(c.unix, 0))
+		assert.True(t, ok, "unix=%d", c.unix)
+		assert.Equal(t, c.unix/30, step)
+	}
+
+	// 允许前后一个时间步的误差
+	_, ok := ValidateTOTP(secret, "287082", time.Unix(59+30, 0))
+	assert.True(t, ok)
+	_, ok = ValidateTOTP(secret, "287082", time.Unix(59+90, 0))
+	assert.False(t, ok)
+
+	_, ok = ValidateTOTP(secret, "12345", time.Unix(59, 0))
+	assert.False(t, ok)
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	require.NoError(t, err)
+	assert.Len(t, secret, 32)
+
+	code, err := GenerateTOTPCode(secret, time.Now())
+	require.NoError(t, err)
+	_, ok := ValidateTOTP(secret, code, time.Now())
+	assert.True(t, ok)
+
+	uri := TOTPProvisioningURI("RemoteGPU", "alice", secret)
+	u, err := url.Parse(uri)
+	require.NoError(t, err)
+	assert.Equal(t, "otpauth", u.Scheme)
+	assert.Equal(t, "totp", u.Host)
+	assert.Equal(t, "/RemoteGPU:alice", u.Path)
+	assert.Equal(t, secret, u.Query().Get("secret"))
+	assert.Equal(t, "RemoteGPU", u.Query().Get("issuer"))
+}
+
+func TestGenerateRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(10)
+	require.NoError(t, err)
+	require.Len(t, codes, 10)
+
+	seen := map[string]bool{}
+	for _, c := range codes {
+		assert.Len(t, c, 11)
+		assert.Equal(t, byte('-'), c[5])
+		seen[c] = true
+	}
+	assert.Len(t, seen, 10)
+
+	assert.Equal(t, HashRecoveryCode(codes[0]), HashRecoveryCode(" "+strings.ToUpper(codes[0])+" "))
+	assert.NotEqual(t, HashRecoveryCode(codes[0]), HashRecoveryCode(codes[1]))
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"sync"
 	"time"
 )
@@ -171,6 +172,29 @@ func (c *MemoryCache) TTL(ctx context.Context, key string) (time.Duration, error
 	return ttl, nil
 }
 
+// Incr 计数器加一，不存在或已过期时从 1 开始并设置过期时间
+func (c *MemoryCache) Incr(ctx context.Context, key string, expiration time.Duration) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	item, ok := c.items[key]
+	if !ok || (!item.expiration.IsZero() && time.Now().After(item.expiration)) {
+		item = &cacheItem{value: "0"}
+		if expiration > 0 {
+			item.expiration = time.Now().Add(expiration)
+		}
+		c.items[key] = item
+	}
+
+	count, err := strconv.ParseInt(item.value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("键 %s 的值不是整数", key)
+	}
+	count++
+	item.value = strconv.FormatInt(count, 10)
+	return count, nil
+}
+
 // Close 关闭缓存连接
 func (c *MemoryCache) Close() error {
 	c.mu.Lock()
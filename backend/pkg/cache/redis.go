@@ -107,6 +107,20 @@ func (c *RedisCache) TTL(ctx context.Context, key string) (time.Duration, error)
 	return c.client.TTL(ctx, key).Result()
 }
 
+// incrScript 在一次脚本执行中完成 INCR 和 PEXPIRE，避免进程在两条命令之间退出留下永不过期的计数器；
+// 计数器没有过期时间时（包括新建）设置过期时间
+var incrScript = redis.NewScript(`
+local n = redis.call('INCR', KEYS[1])
+if tonumber(ARGV[1]) > 0 and redis.call('PTTL', KEYS[1]) == -1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return n
+`)
+
+func (c *RedisCache) Incr(ctx context.Context, key string, expiration time.Duration) (int64, error) {
+	return incrScript.Run(ctx, c.client, []string{key}, expiration.Milliseconds()).Int64()
+}
+
 func (c *RedisCache) Close() error {
 	return c.client.Close()
 }
\ No newline at end of file
@@ -35,6 +35,9 @@ type Cache interface {
 	// TTL 获取剩余过期时间
 	TTL(ctx context.Context, key string) (time.Duration, error)
 
+	// Incr 计数器加一并返回新值，计数器新建时设置过期时间（用于固定窗口限流）
+	Incr(ctx context.Context, key string, expiration time.Duration) (int64, error)
+
 	// Close 关闭缓存连接
 	Close() error
 }
@@ -24,6 +24,10 @@ const (
 	ErrorTokenInvalid      = 2004
 	ErrorTokenExpired      = 2005
 	ErrorUserDisabled      = 2006
+	ErrorAccountLocked     = 2007
+	ErrorMFACodeInvalid    = 2008
+	ErrorSSOLoginRequired  = 2009
+	ErrorExternalAuth      = 2010
 
 	// 工作空间相关错误 (3000-3999)
 	ErrorWorkspaceNotFound     = 3001
@@ -94,6 +98,10 @@ var ErrorMsg = map[int]string{
 	ErrorTokenInvalid:      "Token无效",
 	ErrorTokenExpired:      "Token已过期",
 	ErrorUserDisabled:      "账号已禁用",
+	ErrorAccountLocked:     "账号已锁定",
+	ErrorMFACodeInvalid:    "两步验证码错误",
+	ErrorSSOLoginRequired:  "请使用企业单点登录",
+	ErrorExternalAuth:      "外部身份认证失败",
 
 	// 工作空间相关错误
 	ErrorWorkspaceNotFound:       "工作空间不存在",
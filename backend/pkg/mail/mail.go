@@ -0,0 +1,21 @@
+package mail
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrMailDisabled = errors.New("邮件服务未启用")
+
+// Message 邮件内容
+type Message struct {
+	To      []string
+	Subject string
+	Body    string
+	HTML    bool // Body 是否为 HTML
+}
+
+// Sender 邮件发送接口
+type Sender interface {
+	Send(ctx context.Context, msg *Message) error
+}
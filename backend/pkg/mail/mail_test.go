@@ -0,0 +1,228 @@
+package mail
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"io"
+	"mime"
+	"net"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/YoungBoyGod/remotegpu/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// smtpSink 本地 SMTP 接收端，记录收到的信封和邮件原文
+type smtpSink struct {
+	ln   net.Listener
+	from string
+	rcpt []string
+	data chan string
+}
+
+func newSMTPSink(t *testing.T) *smtpSink {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := &smtpSink{ln: ln, data: make(chan string, 1)}
+	t.Cleanup(func() { ln.Close() })
+	go s.serve()
+	return s
+}
+
+func (s *smtpSink) port() int {
+	return s.ln.Addr().(*net.TCPAddr).Port
+}
+
+func (s *smtpSink) serve() {
+	conn, err := s.ln.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+	reply("220 sink ready")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.TrimSpace(line)
+		upper := strings.ToUpper(cmd)
+		switch {
+		case strings.HasPrefix(upper, "EHLO"), strings.HasPrefix(upper, "HELO"):
+			reply("250 sink")
+		case strings.HasPrefix(upper, "MAIL FROM:"):
+			s.from = strings.Trim(cmd[len("MAIL FROM:"):], "<> ")
+			reply("250 OK")
+		case strings.HasPrefix(upper, "RCPT TO:"):
+			to := strings.Trim(cmd[len("RCPT TO:"):], "<> ")
+			// 以 reject 开头的地址模拟服务器拒收
+			if strings.HasPrefix(to, "reject") {
+				reply("550 mailbox unavailable")
+				continue
+			}
+			s.rcpt = append(s.rcpt, to)
+			reply("250 OK")
+		case upper == "DATA":
+			reply("354 end with <CRLF>.<CRLF>")
+			var buf strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				buf.WriteString(l)
+			}
+			s.data <- buf.String()
+			reply("250 OK")
+		case upper == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 not implemented")
+		}
+	}
+}
+
+func TestSMTPSender_SendToSink(t *testing.T) {
+	sink := newSMTPSink(t)
+	sender := NewSMTPSender(config.MailConfig{
+		Enabled: true,
+		Host:    "127.0.0.1",
+		Port:    sink.port(),
+		User:    "noreply@remotegpu.test",
+		From:    "RemoteGPU",
+	})
+
+	msg, err := Render(TemplatePasswordReset, PasswordResetData{
+		Name:          "alice",
+		Link:          "https://gpu.example.com/forgot-password?token=abc123",
+		ExpireMinutes: 30,
+	})
+	require.NoError(t, err)
+	msg.To = []string{"alice@example.com"}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, sender.Send(ctx, msg))
+
+	var raw string
+	select {
+	case raw = <-sink.data:
+	case <-time.After(5 * time.Second):
+		t.Fatal("SMTP 接收端未收到邮件")
+	}
+	assert.Equal(t, "noreply@remotegpu.test", sink.from)
+	assert.Equal(t, []string{"alice@example.com"}, sink.rcpt)
+
+	parsed, err := mail.ReadMessage(strings.NewReader(raw))
+	require.NoError(t, err)
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	require.NoError(t, err)
+	assert.Equal(t, "重置您的 RemoteGPU 登录密码", subject)
+
+	body, err := io.ReadAll(base64.NewDecoder(base64.StdEncoding, parsed.Body))
+	require.NoError(t, err)
+	assert.Contains(t, string(body), "您好 alice")
+	assert.Contains(t, string(body), "https://gpu.example.com/forgot-password?token=abc123")
+}
+
+func TestSMTPSender_Disabled(t *testing.T) {
+	sender := NewSMTPSender(config.MailConfig{})
+	err := sender.Send(context.Background(), &Message{To: []string{"a@example.com"}})
+	assert.ErrorIs(t, err, ErrMailDisabled)
+}
+
+func TestSMTPSender_Errors(t *testing.T) {
+	sink := newSMTPSink(t)
+	sender := NewSMTPSender(config.MailConfig{Enabled: true, Host: "127.0.0.1", Port: sink.port(), User: "noreply@remotegpu.test"})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	assert.Error(t, sender.Send(ctx, &Message{Subject: "x", Body: "x"}))
+
+	err := sender.Send(ctx, &Message{To: []string{"reject@example.com"}, Subject: "x", Body: "x"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "reject@example.com")
+
+	// 连接不上邮件服务器
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+	sender = NewSMTPSender(config.MailConfig{Enabled: true, Host: "127.0.0.1", Port: port})
+	assert.Error(t, sender.Send(ctx, &Message{To: []string{"a@example.com"}}))
+}
+
+func TestSMTPSender_Build(t *testing.T) {
+	sender := NewSMTPSender(config.MailConfig{User: "noreply@remotegpu.test", From: "RemoteGPU"})
+	body := strings.Repeat("机器租约即将到期，", 20)
+	raw := sender.build(&Message{To: []string{"a@example.com", "b@example.com"}, Subject: "到期提醒", Body: body, HTML: true})
+
+	parsed, err := mail.ReadMessage(strings.NewReader(string(raw)))
+	require.NoError(t, err)
+	assert.Equal(t, "a@example.com, b@example.com", parsed.Header.Get("To"))
+	assert.Equal(t, "text/html; charset=UTF-8", parsed.Header.Get("Content-Type"))
+	from, err := parsed.Header.AddressList("From")
+	require.NoError(t, err)
+	assert.Equal(t, "RemoteGPU", from[0].Name)
+	assert.Equal(t, "noreply@remotegpu.test", from[0].Address)
+
+	// base64 正文按 76 字符换行
+	encoded, err := io.ReadAll(parsed.Body)
+	require.NoError(t, err)
+	for _, line := range strings.Split(strings.TrimRight(string(encoded), "\r\n"), "\r\n") {
+		assert.LessOrEqual(t, len(line), 76)
+	}
+	decoded, err := io.ReadAll(base64.NewDecoder(base64.StdEncoding, strings.NewReader(string(encoded))))
+	require.NoError(t, err)
+	assert.Equal(t, body, string(decoded))
+}
+
+func TestRender_AllocationExpired(t *testing.T) {
+	msg, err := Render(TemplateAllocationExpired, AllocationExpiryData{
+		Name:    "bob",
+		Machine: "gpu-node-1",
+		EndTime: time.Date(2026, 10, 20, 18, 30, 0, 0, time.UTC),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "机器租约已到期", msg.Subject)
+	assert.Contains(t, msg.Body, "您好 bob")
+	assert.Contains(t, msg.Body, "gpu-node-1")
+	assert.Contains(t, msg.Body, "2026-10-20 18:30")
+	assert.False(t, msg.HTML)
+
+	// 模板参数类型不匹配时返回错误
+	_, err = Render(TemplateAllocationExpired, PasswordResetData{Name: "bob"})
+	assert.Error(t, err)
+}
+
+func TestRender(t *testing.T) {
+	msg, err := Render(TemplateAllocationExpiring, AllocationExpiryData{
+		Name:    "bob",
+		Machine: "gpu-node-1",
+		EndTime: time.Date(2026, 10, 20, 18, 30, 0, 0, time.UTC),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "机器租约即将到期", msg.Subject)
+	assert.Contains(t, msg.Body, "gpu-node-1")
+	assert.Contains(t, msg.Body, "2026-10-20 18:30")
+
+	// 各模板的 subject 互不覆盖
+	msg, err = Render(TemplateEmailVerification, EmailVerificationData{Name: "bob", Email: "bob@example.com", Link: "https://x/verify", ExpireHours: 24})
+	require.NoError(t, err)
+	assert.Equal(t, "验证您的 RemoteGPU 邮箱", msg.Subject)
+
+	_, err = Render("unknown", nil)
+	assert.Error(t, err)
+}
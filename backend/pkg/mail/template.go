@@ -0,0 +1,75 @@
+package mail
+
+import (
+	"embed"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// 邮件模板名称，对应 templates 目录下的 <名称>.tmpl，每个模板定义 subject 和 body 两部分
+const (
+	TemplatePasswordReset      = "password_reset"
+	TemplateEmailVerification  = "email_verification"
+	TemplateAllocationExpiring = "allocation_expiring"
+	TemplateAllocationExpired  = "allocation_expired"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+// templates 按模板名称分别解析，避免各模板的 subject/body 定义互相覆盖
+var templates = func() map[string]*template.Template {
+	files, err := templateFS.ReadDir("templates")
+	if err != nil {
+		panic(err)
+	}
+	parsed := make(map[string]*template.Template, len(files))
+	for _, f := range files {
+		name := strings.TrimSuffix(f.Name(), ".tmpl")
+		parsed[name] = template.Must(template.ParseFS(templateFS, "templates/"+f.Name()))
+	}
+	return parsed
+}()
+
+// PasswordResetData 密码重置邮件参数
+type PasswordResetData struct {
+	Name          string
+	Link          string
+	ExpireMinutes int
+}
+
+// EmailVerificationData 邮箱验证邮件参数
+type EmailVerificationData struct {
+	Name        string
+	Email       string
+	Link        string
+	ExpireHours int
+}
+
+// AllocationExpiryData 机器租约到期提醒/回收邮件参数
+type AllocationExpiryData struct {
+	Name    string
+	Machine string
+	EndTime time.Time
+}
+
+// Render 渲染邮件模板，返回的邮件需要调用方填写收件人
+func Render(name string, data any) (*Message, error) {
+	tmpl, ok := templates[name]
+	if !ok {
+		return nil, fmt.Errorf("邮件模板 %s 不存在", name)
+	}
+	var subject, body strings.Builder
+	if err := tmpl.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return nil, fmt.Errorf("渲染邮件模板 %s 失败: %w", name, err)
+	}
+	if err := tmpl.ExecuteTemplate(&body, "body", data); err != nil {
+		return nil, fmt.Errorf("渲染邮件模板 %s 失败: %w", name, err)
+	}
+	return &Message{
+		Subject: strings.TrimSpace(subject.String()),
+		Body:    body.String(),
+	}, nil
+}
@@ -2,10 +2,14 @@ package storage
 
 import (
 	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"time"
 )
 
@@ -183,3 +187,196 @@ func (l *LocalStorage) Move(ctx context.Context, srcPath, dstPath string) error
 	}
 	return nil
 }
+
+// multipartDir 本地分片上传暂存目录
+const multipartDir = ".multipart"
+
+// uploadDir 返回分片暂存目录，uploadID 必须为 InitMultipart 生成的十六进制串
+func (l *LocalStorage) uploadDir(uploadID string) (string, error) {
+	if len(uploadID) != 32 {
+		return "", ErrUploadNotFound
+	}
+	if _, err := hex.DecodeString(uploadID); err != nil {
+		return "", ErrUploadNotFound
+	}
+	return filepath.Join(l.basePath, multipartDir, uploadID), nil
+}
+
+// openUpload 校验分片上传存在且属于 path
+func (l *LocalStorage) openUpload(path, uploadID string) (string, error) {
+	dir, err := l.uploadDir(uploadID)
+	if err != nil {
+		return "", err
+	}
+	target, err := os.ReadFile(filepath.Join(dir, "object"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", ErrUploadNotFound
+		}
+		return "", fmt.Errorf("读取分片上传信息失败: %w", err)
+	}
+	if string(target) != path {
+		return "", ErrUploadNotFound
+	}
+	return dir, nil
+}
+
+func partFileName(partNumber int) string {
+	return fmt.Sprintf("part-%05d", partNumber)
+}
+
+func (l *LocalStorage) InitMultipart(ctx context.Context, path string, opts *UploadOptions) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("生成 uploadID 失败: %w", err)
+	}
+	uploadID := hex.EncodeToString(b)
+	dir, _ := l.uploadDir(uploadID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("创建分片目录失败: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "object"), []byte(path), 0644); err != nil {
+		return "", fmt.Errorf("写入分片上传信息失败: %w", err)
+	}
+	return uploadID, nil
+}
+
+// PresignPart 本地存储无对外地址，分片由服务端通过 UploadPart 代理写入
+func (l *LocalStorage) PresignPart(ctx context.Context, path, uploadID string, partNumber int, expires time.Duration) (string, error) {
+	return "", ErrPresignNotSupported
+}
+
+func (l *LocalStorage) UploadPart(ctx context.Context, path, uploadID string, partNumber int, reader io.Reader, size int64) (*Part, error) {
+	if !validPartNumber(partNumber) {
+		return nil, ErrInvalidPart
+	}
+	dir, err := l.openUpload(path, uploadID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 先写临时文件再重命名，重传同一分片时覆盖旧分片
+	tmp, err := os.CreateTemp(dir, "tmp-*")
+	if err != nil {
+		return nil, fmt.Errorf("创建分片文件失败: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	h := md5.New()
+	n, err := io.Copy(io.MultiWriter(tmp, h), reader)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("写入分片失败: %w", err)
+	}
+	if size >= 0 && n != size {
+		return nil, fmt.Errorf("%w: 分片大小 %d 与声明的 %d 不一致", ErrInvalidPart, n, size)
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(dir, partFileName(partNumber))); err != nil {
+		return nil, fmt.Errorf("保存分片失败: %w", err)
+	}
+	etag := hex.EncodeToString(h.Sum(nil))
+	if err := os.WriteFile(filepath.Join(dir, partFileName(partNumber)+".etag"), []byte(etag), 0644); err != nil {
+		return nil, fmt.Errorf("保存分片 ETag 失败: %w", err)
+	}
+	return &Part{PartNumber: partNumber, ETag: etag, Size: n, LastModified: time.Now()}, nil
+}
+
+func (l *LocalStorage) ListParts(ctx context.Context, path, uploadID string) ([]Part, error) {
+	dir, err := l.openUpload(path, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("读取分片目录失败: %w", err)
+	}
+
+	var parts []Part
+	for _, entry := range entries {
+		var n int
+		if _, err := fmt.Sscanf(entry.Name(), "part-%05d", &n); err != nil || entry.Name() != partFileName(n) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		etag, err := os.ReadFile(filepath.Join(dir, entry.Name()+".etag"))
+		if err != nil {
+			continue
+		}
+		parts = append(parts, Part{PartNumber: n, ETag: string(etag), Size: info.Size(), LastModified: info.ModTime()})
+	}
+	sort.Slice(parts, func(i, j int) bool { return parts[i].PartNumber < parts[j].PartNumber })
+	return parts, nil
+}
+
+func (l *LocalStorage) CompleteMultipart(ctx context.Context, path, uploadID string, parts []Part) (*FileInfo, error) {
+	dir, err := l.openUpload(path, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	uploaded, err := l.ListParts(ctx, path, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	byNumber := make(map[int]Part, len(uploaded))
+	for _, p := range uploaded {
+		byNumber[p.PartNumber] = p
+	}
+	last := 0
+	for _, p := range parts {
+		got, ok := byNumber[p.PartNumber]
+		if p.PartNumber <= last || !ok || got.ETag != NormalizeETag(p.ETag) {
+			return nil, fmt.Errorf("%w: part %d", ErrInvalidPart, p.PartNumber)
+		}
+		last = p.PartNumber
+	}
+
+	fullPath := l.fullPath(path)
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		return nil, fmt.Errorf("创建目录失败: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(fullPath), ".upload-*")
+	if err != nil {
+		return nil, fmt.Errorf("创建文件失败: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	for _, p := range parts {
+		if err := appendFile(tmp, filepath.Join(dir, partFileName(p.PartNumber))); err != nil {
+			tmp.Close()
+			return nil, fmt.Errorf("合并分片失败: %w", err)
+		}
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, fmt.Errorf("合并分片失败: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), fullPath); err != nil {
+		return nil, fmt.Errorf("保存文件失败: %w", err)
+	}
+	os.RemoveAll(dir)
+	return l.Stat(ctx, path)
+}
+
+func (l *LocalStorage) AbortMultipart(ctx context.Context, path, uploadID string) error {
+	dir, err := l.openUpload(path, uploadID)
+	if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("清理分片失败: %w", err)
+	}
+	return nil
+}
+
+func appendFile(dst io.Writer, src string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(dst, f)
+	return err
+}
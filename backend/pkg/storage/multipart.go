@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrUploadNotFound 分片上传不存在（已完成、已中止或 uploadID 无效）
+	ErrUploadNotFound = errors.New("分片上传不存在")
+	// ErrPresignNotSupported 存储后端不支持预签名分片上传，需由服务端代理上传分片
+	ErrPresignNotSupported = errors.New("存储后端不支持预签名分片上传")
+	// ErrInvalidPart 分片编号或 ETag 不合法
+	ErrInvalidPart = errors.New("分片不合法")
+)
+
+// 分片上传限制，与 S3 协议一致
+const (
+	MinPartSize  = 5 << 20 // 除最后一个分片外，每个分片不小于 5MB
+	MaxPartSize  = 5 << 30
+	MaxPartCount = 10000
+)
+
+// Part 已上传的分片
+type Part struct {
+	PartNumber   int       `json:"part_number"`
+	ETag         string    `json:"etag"`
+	Size         int64     `json:"size"`
+	LastModified time.Time `json:"last_modified"`
+}
+
+// MultipartStorage 支持分片上传的存储后端
+type MultipartStorage interface {
+	Storage
+
+	// InitMultipart 初始化分片上传，返回 uploadID
+	InitMultipart(ctx context.Context, path string, opts *UploadOptions) (string, error)
+
+	// PresignPart 生成分片上传的预签名 URL（HTTP PUT），不支持时返回 ErrPresignNotSupported
+	PresignPart(ctx context.Context, path, uploadID string, partNumber int, expires time.Duration) (string, error)
+
+	// UploadPart 由服务端直接上传分片
+	UploadPart(ctx context.Context, path, uploadID string, partNumber int, reader io.Reader, size int64) (*Part, error)
+
+	// ListParts 列出已上传的分片（按分片编号升序），用于断点续传
+	ListParts(ctx context.Context, path, uploadID string) ([]Part, error)
+
+	// CompleteMultipart 按给定分片合并为最终文件
+	CompleteMultipart(ctx context.Context, path, uploadID string, parts []Part) (*FileInfo, error)
+
+	// AbortMultipart 中止分片上传并清理已上传的分片
+	AbortMultipart(ctx context.Context, path, uploadID string) error
+}
+
+// NormalizeETag 去掉 ETag 两端的引号，便于比较
+func NormalizeETag(etag string) string {
+	return strings.Trim(strings.TrimSpace(etag), `"`)
+}
+
+func validPartNumber(n int) bool {
+	return n >= 1 && n <= MaxPartCount
+}
+
+var (
+	_ MultipartStorage = (*S3Storage)(nil)
+	_ MultipartStorage = (*LocalStorage)(nil)
+)
@@ -0,0 +1,194 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/johannesboyne/gofakes3"
+	"github.com/johannesboyne/gofakes3/backend/s3mem"
+	"github.com/minio/minio-go/v7"
+)
+
+// multipartFlow 依次上传两个分片，校验续传列表后合并
+func multipartFlow(t *testing.T, s MultipartStorage, put func(uploadID string, n int, data []byte) string) {
+	t.Helper()
+	ctx := context.Background()
+	key := "customer-1/ds/data.bin"
+
+	uploadID, err := s.InitMultipart(ctx, key, nil)
+	if err != nil {
+		t.Fatalf("初始化分片上传失败: %v", err)
+	}
+
+	part1 := bytes.Repeat([]byte("a"), 1024)
+	part2 := []byte("tail")
+	etag1 := put(uploadID, 1, part1)
+
+	// 续传：只有第一个分片已上传
+	parts, err := s.ListParts(ctx, key, uploadID)
+	if err != nil {
+		t.Fatalf("列出分片失败: %v", err)
+	}
+	if len(parts) != 1 || parts[0].PartNumber != 1 || parts[0].ETag != NormalizeETag(etag1) || parts[0].Size != int64(len(part1)) {
+		t.Fatalf("分片列表不符合预期: %+v", parts)
+	}
+
+	etag2 := put(uploadID, 2, part2)
+
+	info, err := s.CompleteMultipart(ctx, key, uploadID, []Part{
+		{PartNumber: 1, ETag: etag1},
+		{PartNumber: 2, ETag: etag2},
+	})
+	if err != nil {
+		t.Fatalf("完成分片上传失败: %v", err)
+	}
+	if info.Size != int64(len(part1)+len(part2)) {
+		t.Fatalf("合并后大小不符合预期: %d", info.Size)
+	}
+
+	reader, _, err := s.Download(ctx, key)
+	if err != nil {
+		t.Fatalf("下载文件失败: %v", err)
+	}
+	defer reader.Close()
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("读取文件失败: %v", err)
+	}
+	if !bytes.Equal(data, append(part1, part2...)) {
+		t.Fatal("合并后内容不匹配")
+	}
+
+	if _, err := s.ListParts(ctx, key, uploadID); !errors.Is(err, ErrUploadNotFound) {
+		t.Fatalf("完成后上传应不存在, got %v", err)
+	}
+}
+
+func TestLocalStorage_Multipart(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewLocalStorage("test-local", dir)
+	if err != nil {
+		t.Fatalf("创建本地存储失败: %v", err)
+	}
+	ctx := context.Background()
+
+	if _, err := s.PresignPart(ctx, "x", "y", 1, time.Minute); !errors.Is(err, ErrPresignNotSupported) {
+		t.Fatalf("本地存储不应支持预签名, got %v", err)
+	}
+
+	multipartFlow(t, s, func(uploadID string, n int, data []byte) string {
+		part, err := s.UploadPart(ctx, "customer-1/ds/data.bin", uploadID, n, bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Fatalf("上传分片失败: %v", err)
+		}
+		return part.ETag
+	})
+
+	// 分片临时目录已清理
+	entries, _ := os.ReadDir(filepath.Join(dir, ".multipart"))
+	if len(entries) != 0 {
+		t.Fatalf("分片临时目录未清理: %d", len(entries))
+	}
+}
+
+func TestLocalStorage_MultipartRejectsBadParts(t *testing.T) {
+	s, err := NewLocalStorage("test-local", t.TempDir())
+	if err != nil {
+		t.Fatalf("创建本地存储失败: %v", err)
+	}
+	ctx := context.Background()
+	key := "a/b.bin"
+
+	uploadID, err := s.InitMultipart(ctx, key, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.UploadPart(ctx, key, uploadID, 0, bytes.NewReader([]byte("x")), 1); !errors.Is(err, ErrInvalidPart) {
+		t.Fatalf("分片编号 0 应被拒绝, got %v", err)
+	}
+	if _, err := s.UploadPart(ctx, key, "../../etc", 1, bytes.NewReader([]byte("x")), 1); !errors.Is(err, ErrUploadNotFound) {
+		t.Fatalf("非法 uploadID 应被拒绝, got %v", err)
+	}
+	if _, err := s.UploadPart(ctx, key, uploadID, 1, bytes.NewReader([]byte("x")), 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.CompleteMultipart(ctx, key, uploadID, []Part{{PartNumber: 1, ETag: "bad"}}); !errors.Is(err, ErrInvalidPart) {
+		t.Fatalf("ETag 不一致应被拒绝, got %v", err)
+	}
+
+	if err := s.AbortMultipart(ctx, key, uploadID); err != nil {
+		t.Fatalf("中止上传失败: %v", err)
+	}
+	if _, err := s.ListParts(ctx, key, uploadID); !errors.Is(err, ErrUploadNotFound) {
+		t.Fatalf("中止后上传应不存在, got %v", err)
+	}
+	if exists, _ := s.Exists(ctx, key); exists {
+		t.Fatal("中止后不应生成文件")
+	}
+}
+
+// newFakeS3 启动内存版 S3 兼容服务
+func newFakeS3(t *testing.T, bucket string) *S3Storage {
+	t.Helper()
+	srv := httptest.NewServer(gofakes3.New(s3mem.New()).Server())
+	t.Cleanup(srv.Close)
+
+	s, err := NewS3Storage("test-s3", "s3", srv.URL, "key", "secret", bucket, "us-east-1")
+	if err != nil {
+		t.Fatalf("创建S3存储失败: %v", err)
+	}
+	if err := s.client.MakeBucket(context.Background(), bucket, minio.MakeBucketOptions{}); err != nil {
+		t.Fatalf("创建存储桶失败: %v", err)
+	}
+	return s
+}
+
+func TestS3Storage_MultipartPresigned(t *testing.T) {
+	s := newFakeS3(t, "datasets")
+	ctx := context.Background()
+
+	multipartFlow(t, s, func(uploadID string, n int, data []byte) string {
+		url, err := s.PresignPart(ctx, "customer-1/ds/data.bin", uploadID, n, time.Minute)
+		if err != nil {
+			t.Fatalf("生成预签名地址失败: %v", err)
+		}
+		req, _ := http.NewRequest(http.MethodPut, url, bytes.NewReader(data))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("上传分片失败: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("上传分片失败: status=%d", resp.StatusCode)
+		}
+		return resp.Header.Get("ETag")
+	})
+}
+
+func TestS3Storage_MultipartAbort(t *testing.T) {
+	s := newFakeS3(t, "datasets")
+	ctx := context.Background()
+	key := "customer-1/ds/abort.bin"
+
+	uploadID, err := s.InitMultipart(ctx, key, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.UploadPart(ctx, key, uploadID, 1, bytes.NewReader([]byte("data")), 4); err != nil {
+		t.Fatalf("上传分片失败: %v", err)
+	}
+	if err := s.AbortMultipart(ctx, key, uploadID); err != nil {
+		t.Fatalf("中止上传失败: %v", err)
+	}
+	if _, err := s.ListParts(ctx, key, uploadID); !errors.Is(err, ErrUploadNotFound) {
+		t.Fatalf("中止后上传应不存在, got %v", err)
+	}
+}
@@ -4,6 +4,9 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net/http"
+	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/minio/minio-go/v7"
@@ -170,3 +173,98 @@ func (s *S3Storage) Move(ctx context.Context, srcPath, dstPath string) error {
 	}
 	return s.Delete(ctx, srcPath)
 }
+
+func (s *S3Storage) core() minio.Core {
+	return minio.Core{Client: s.client}
+}
+
+// isNoSuchUpload 判断是否为分片上传不存在错误
+func isNoSuchUpload(err error) bool {
+	return minio.ToErrorResponse(err).Code == "NoSuchUpload"
+}
+
+func (s *S3Storage) InitMultipart(ctx context.Context, path string, opts *UploadOptions) (string, error) {
+	putOpts := minio.PutObjectOptions{}
+	if opts != nil {
+		putOpts.ContentType = opts.ContentType
+		putOpts.UserMetadata = opts.Metadata
+	}
+	uploadID, err := s.core().NewMultipartUpload(ctx, s.bucket, path, putOpts)
+	if err != nil {
+		return "", fmt.Errorf("初始化分片上传失败: %w", err)
+	}
+	return uploadID, nil
+}
+
+func (s *S3Storage) PresignPart(ctx context.Context, path, uploadID string, partNumber int, expires time.Duration) (string, error) {
+	if !validPartNumber(partNumber) {
+		return "", ErrInvalidPart
+	}
+	params := url.Values{}
+	params.Set("partNumber", strconv.Itoa(partNumber))
+	params.Set("uploadId", uploadID)
+	u, err := s.client.Presign(ctx, http.MethodPut, s.bucket, path, expires, params)
+	if err != nil {
+		return "", fmt.Errorf("生成分片上传URL失败: %w", err)
+	}
+	return u.String(), nil
+}
+
+func (s *S3Storage) UploadPart(ctx context.Context, path, uploadID string, partNumber int, reader io.Reader, size int64) (*Part, error) {
+	if !validPartNumber(partNumber) {
+		return nil, ErrInvalidPart
+	}
+	p, err := s.core().PutObjectPart(ctx, s.bucket, path, uploadID, partNumber, reader, size, minio.PutObjectPartOptions{})
+	if err != nil {
+		if isNoSuchUpload(err) {
+			return nil, ErrUploadNotFound
+		}
+		return nil, fmt.Errorf("上传分片失败: %w", err)
+	}
+	return &Part{PartNumber: p.PartNumber, ETag: NormalizeETag(p.ETag), Size: p.Size, LastModified: p.LastModified}, nil
+}
+
+func (s *S3Storage) ListParts(ctx context.Context, path, uploadID string) ([]Part, error) {
+	var parts []Part
+	marker := 0
+	for {
+		res, err := s.core().ListObjectParts(ctx, s.bucket, path, uploadID, marker, 1000)
+		if err != nil {
+			if isNoSuchUpload(err) {
+				return nil, ErrUploadNotFound
+			}
+			return nil, fmt.Errorf("列出分片失败: %w", err)
+		}
+		for _, p := range res.ObjectParts {
+			parts = append(parts, Part{PartNumber: p.PartNumber, ETag: NormalizeETag(p.ETag), Size: p.Size, LastModified: p.LastModified})
+		}
+		if !res.IsTruncated || res.NextPartNumberMarker <= marker {
+			return parts, nil
+		}
+		marker = res.NextPartNumberMarker
+	}
+}
+
+func (s *S3Storage) CompleteMultipart(ctx context.Context, path, uploadID string, parts []Part) (*FileInfo, error) {
+	complete := make([]minio.CompletePart, 0, len(parts))
+	for _, p := range parts {
+		complete = append(complete, minio.CompletePart{PartNumber: p.PartNumber, ETag: NormalizeETag(p.ETag)})
+	}
+	if _, err := s.core().CompleteMultipartUpload(ctx, s.bucket, path, uploadID, complete, minio.PutObjectOptions{}); err != nil {
+		if isNoSuchUpload(err) {
+			return nil, ErrUploadNotFound
+		}
+		return nil, fmt.Errorf("合并分片失败: %w", err)
+	}
+	return s.Stat(ctx, path)
+}
+
+func (s *S3Storage) AbortMultipart(ctx context.Context, path, uploadID string) error {
+	if err := s.core().AbortMultipartUpload(ctx, s.bucket, path, uploadID); err != nil {
+		if isNoSuchUpload(err) {
+			return ErrUploadNotFound
+		}
+		return fmt.Errorf("中止分片上传失败: %w", err)
+	}
+	return nil
+}
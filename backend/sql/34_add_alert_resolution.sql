@@ -0,0 +1,5 @@
+-- 为 active_alerts 表添加告警状态与恢复时间，供告警评估引擎自动恢复告警
+ALTER TABLE active_alerts ADD COLUMN IF NOT EXISTS status VARCHAR(20) DEFAULT 'firing';
+ALTER TABLE active_alerts ADD COLUMN IF NOT EXISTS resolved_at TIMESTAMP WITH TIME ZONE;
+CREATE INDEX IF NOT EXISTS idx_active_alerts_status ON active_alerts(status);
+CREATE INDEX IF NOT EXISTS idx_active_alerts_rule_host ON active_alerts(rule_id, host_id);
@@ -0,0 +1,18 @@
+-- 机器级 Agent 凭证：一次性注册令牌换取机器专属密钥，替代全局共享 Token
+CREATE TABLE IF NOT EXISTS agent_credentials (
+    id                    SERIAL PRIMARY KEY,
+    host_id               VARCHAR(64) NOT NULL UNIQUE REFERENCES hosts(id) ON DELETE CASCADE,
+    agent_id              VARCHAR(64),
+    status                VARCHAR(20) DEFAULT 'pending',
+    secret_hash           VARCHAR(64),
+    enrollment_token_hash VARCHAR(64),
+    enrollment_expires_at TIMESTAMP WITH TIME ZONE,
+    secret_issued_at      TIMESTAMP WITH TIME ZONE,
+    revoked_at            TIMESTAMP WITH TIME ZONE,
+    last_used_at          TIMESTAMP WITH TIME ZONE,
+    created_at            TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at            TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_agent_credentials_secret_hash ON agent_credentials(secret_hash);
+CREATE INDEX IF NOT EXISTS idx_agent_credentials_enrollment_token_hash ON agent_credentials(enrollment_token_hash);
@@ -0,0 +1,13 @@
+-- 任务日志分片：Agent 运行期间按偏移增量上报 stdout/stderr，追加写入
+CREATE TABLE IF NOT EXISTS task_log_chunks (
+    id          BIGSERIAL PRIMARY KEY,
+    task_id     VARCHAR(64) NOT NULL,
+    attempt_id  VARCHAR(64) NOT NULL,
+    stream      VARCHAR(10) NOT NULL,
+    byte_offset BIGINT NOT NULL,
+    size        INT NOT NULL,
+    content     BYTEA,
+    created_at  TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_task_log_chunks_pos ON task_log_chunks(task_id, attempt_id, stream, byte_offset);
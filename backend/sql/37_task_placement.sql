@@ -0,0 +1,8 @@
+-- 跨机器任务调度：任务声明资源需求，由调度器在 Agent 认领时选择机器和 GPU
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS gpu_count INT DEFAULT 0;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS gpu_model VARCHAR(128);
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS min_gpu_memory_mb INT DEFAULT 0;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS allowed_hosts JSONB;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS assigned_gpus JSONB;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_unplaced ON tasks(status, priority, created_at) WHERE machine_id IS NULL OR machine_id = '';
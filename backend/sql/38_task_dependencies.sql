@@ -0,0 +1,4 @@
+-- 任务依赖与工作流：group_id 作为工作流 ID，depends_on 记录同一工作流内的上游任务
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS depends_on JSONB;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_group_id ON tasks(group_id);
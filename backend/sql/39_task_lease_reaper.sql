@@ -0,0 +1,4 @@
+-- 任务租约回收：租约过期重新排队的任务在 next_attempt_at 之前不可被认领
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS next_attempt_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_status_lease ON tasks(status, lease_expires_at);
@@ -0,0 +1,13 @@
+-- 数据集分片上传：记录进行中的上传，完成或中止后清空
+ALTER TABLE datasets ADD COLUMN IF NOT EXISTS storage_backend VARCHAR(64);
+ALTER TABLE datasets ADD COLUMN IF NOT EXISTS upload_id VARCHAR(256);
+ALTER TABLE datasets ADD COLUMN IF NOT EXISTS upload_key VARCHAR(1024);
+ALTER TABLE datasets ADD COLUMN IF NOT EXISTS upload_expires_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_datasets_upload_expires ON datasets(status, upload_expires_at);
+
+COMMENT ON COLUMN datasets.status IS '状态: uploading-上传中, ready-就绪, error-错误, aborted-上传已中止';
+COMMENT ON COLUMN datasets.storage_backend IS '存储后端名称';
+COMMENT ON COLUMN datasets.upload_id IS '进行中的分片上传 ID';
+COMMENT ON COLUMN datasets.upload_key IS '进行中的分片上传对象路径';
+COMMENT ON COLUMN datasets.upload_expires_at IS '分片上传过期时间，过期未完成由清理任务中止';
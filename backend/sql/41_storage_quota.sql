@@ -0,0 +1,18 @@
+-- 存储配额：工作空间配额与用量提醒状态
+ALTER TABLE workspaces ADD COLUMN IF NOT EXISTS quota_storage BIGINT DEFAULT 0;
+
+COMMENT ON COLUMN workspaces.quota_storage IS '存储容量配额（MB），0 表示不限制';
+
+-- 记录已发送的用量提醒级别，避免重复提醒；用量回落后重置
+CREATE TABLE IF NOT EXISTS storage_quota_alerts (
+    id BIGSERIAL PRIMARY KEY,
+    scope VARCHAR(16) NOT NULL,
+    scope_id BIGINT NOT NULL,
+    level INT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (scope, scope_id)
+);
+
+COMMENT ON TABLE storage_quota_alerts IS '存储用量提醒状态';
+COMMENT ON COLUMN storage_quota_alerts.scope IS '范围: customer-客户, workspace-工作空间';
+COMMENT ON COLUMN storage_quota_alerts.level IS '已提醒的用量百分比级别: 0, 80, 95';
@@ -0,0 +1,25 @@
+-- 机器分配到期处理：到期提醒与续期记录
+ALTER TABLE allocations ADD COLUMN IF NOT EXISTS expiry_warned_at TIMESTAMP WITH TIME ZONE;
+
+COMMENT ON COLUMN allocations.expiry_warned_at IS '已发送到期提醒的时间，续期后清空';
+
+CREATE INDEX IF NOT EXISTS idx_allocations_status_end_time ON allocations(status, end_time);
+
+CREATE TABLE IF NOT EXISTS allocation_renewals (
+    id BIGSERIAL PRIMARY KEY,
+    allocation_id VARCHAR(64) NOT NULL REFERENCES allocations(id) ON DELETE CASCADE,
+    customer_id BIGINT NOT NULL,
+    operator_id BIGINT,
+    operator_role VARCHAR(20) NOT NULL,
+    months INT NOT NULL,
+    old_end_time TIMESTAMP WITH TIME ZONE NOT NULL,
+    new_end_time TIMESTAMP WITH TIME ZONE NOT NULL,
+    remark TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_allocation_renewals_allocation ON allocation_renewals(allocation_id);
+
+COMMENT ON TABLE allocation_renewals IS '机器分配续期记录';
+COMMENT ON COLUMN allocation_renewals.operator_role IS '操作方: customer-客户, admin-管理员';
+COMMENT ON COLUMN allocation_renewals.months IS '续期月数';
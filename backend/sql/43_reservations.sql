@@ -0,0 +1,39 @@
+-- 机器预约：按 GPU 型号和数量预约未来时间窗口，窗口开始时按正常分配流程激活
+CREATE TABLE IF NOT EXISTS reservations (
+    id VARCHAR(64) PRIMARY KEY,
+    customer_id BIGINT NOT NULL REFERENCES customers(id) ON DELETE CASCADE,
+    gpu_model VARCHAR(128) NOT NULL,
+    gpu_count INT NOT NULL,
+    start_time TIMESTAMP WITH TIME ZONE NOT NULL,
+    end_time TIMESTAMP WITH TIME ZONE NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'queued',
+    remark TEXT,
+    reason TEXT,
+    activated_at TIMESTAMP WITH TIME ZONE,
+    cancelled_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CHECK (end_time > start_time),
+    CHECK (gpu_count > 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_reservations_customer ON reservations(customer_id);
+CREATE INDEX IF NOT EXISTS idx_reservations_status_start ON reservations(status, start_time);
+
+COMMENT ON TABLE reservations IS '机器预约';
+COMMENT ON COLUMN reservations.status IS '状态: queued-排队中, pending-已锁定机器等待开始, active-已激活, cancelled-已取消, failed-激活失败, expired-已过期';
+COMMENT ON COLUMN reservations.reason IS '取消/失败/过期原因';
+
+CREATE TABLE IF NOT EXISTS reservation_hosts (
+    id BIGSERIAL PRIMARY KEY,
+    reservation_id VARCHAR(64) NOT NULL REFERENCES reservations(id) ON DELETE CASCADE,
+    host_id VARCHAR(64) NOT NULL REFERENCES hosts(id) ON DELETE CASCADE,
+    gpu_count INT NOT NULL,
+    allocation_id VARCHAR(64) REFERENCES allocations(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_reservation_hosts_reservation ON reservation_hosts(reservation_id);
+CREATE INDEX IF NOT EXISTS idx_reservation_hosts_host ON reservation_hosts(host_id);
+
+COMMENT ON TABLE reservation_hosts IS '预约锁定的机器，pending 状态的预约在其时间窗口内独占这些机器';
+COMMENT ON COLUMN reservation_hosts.allocation_id IS '激活后对应的分配记录';
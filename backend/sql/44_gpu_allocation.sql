@@ -0,0 +1,19 @@
+-- GPU 粒度分配：分配可只绑定机器上的部分 GPU，同一台机器可分配给多个客户
+ALTER TABLE allocations ADD COLUMN IF NOT EXISTS gpu_indexes JSONB;
+ALTER TABLE allocations ADD COLUMN IF NOT EXISTS gpu_count INT DEFAULT 0;
+
+COMMENT ON COLUMN allocations.gpu_indexes IS '分配的 GPU 序号，为空表示整机分配';
+COMMENT ON COLUMN allocations.gpu_count IS '分配时的 GPU 卡数';
+
+-- 存量整机分配：补齐卡数，并将机器上的 GPU 标记为已分配
+UPDATE allocations a SET gpu_count = (SELECT COUNT(*) FROM gpus g WHERE g.host_id = a.host_id)
+WHERE a.gpu_indexes IS NULL AND a.gpu_count = 0;
+
+UPDATE gpus g SET allocated_to = a.id,
+    status = CASE WHEN g.status = 'available' THEN 'allocated' ELSE g.status END
+FROM allocations a
+WHERE a.host_id = g.host_id AND a.status = 'active' AND a.gpu_indexes IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_gpus_allocated_to ON gpus(allocated_to);
+
+COMMENT ON COLUMN hosts.allocation_status IS '分配状态: idle-空闲, partial-部分 GPU 已分配, allocated-已分配, maintenance-维护中';
@@ -0,0 +1,58 @@
+-- 用量计量：按客户/工作空间/GPU 型号记录分配、环境、任务的 GPU 使用区间，按月汇总生成账单
+CREATE TABLE IF NOT EXISTS usage_records (
+    id BIGSERIAL PRIMARY KEY,
+    customer_id BIGINT NOT NULL REFERENCES customers(id) ON DELETE CASCADE,
+    workspace_id BIGINT,
+    source_type VARCHAR(20) NOT NULL,
+    source_id VARCHAR(64) NOT NULL,
+    host_id VARCHAR(64),
+    gpu_model VARCHAR(128),
+    gpu_count INT NOT NULL,
+    start_time TIMESTAMP WITH TIME ZONE NOT NULL,
+    end_time TIMESTAMP WITH TIME ZONE NOT NULL,
+    duration_seconds BIGINT NOT NULL DEFAULT 0,
+    gpu_hours DECIMAL(14,4) NOT NULL DEFAULT 0,
+    unit_price DECIMAL(10,4) NOT NULL DEFAULT 0,
+    amount DECIMAL(12,4) NOT NULL DEFAULT 0,
+    currency VARCHAR(10) DEFAULT 'CNY',
+    invoice_id BIGINT REFERENCES invoices(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CHECK (end_time >= start_time)
+);
+
+CREATE INDEX IF NOT EXISTS idx_usage_records_source ON usage_records(source_type, source_id, end_time DESC);
+CREATE INDEX IF NOT EXISTS idx_usage_records_customer_time ON usage_records(customer_id, start_time);
+CREATE INDEX IF NOT EXISTS idx_usage_records_unbilled ON usage_records(customer_id, end_time) WHERE invoice_id IS NULL;
+
+COMMENT ON TABLE usage_records IS 'GPU 用量记录，按自然日切分，同一资源当天的连续用量合并为一条';
+COMMENT ON COLUMN usage_records.source_type IS '来源: allocation-机器分配, environment-开发环境, task-任务';
+COMMENT ON COLUMN usage_records.unit_price IS '计量时的单价（每 GPU 小时），不计费的来源为 0';
+COMMENT ON COLUMN usage_records.invoice_id IS '已计入的账单，为空表示未出账';
+
+-- 账单（08_billing.sql 已创建）补充 GPU 小时汇总，同一客户同一账期只生成一张账单
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS total_gpu_hours DECIMAL(14,4) NOT NULL DEFAULT 0;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_invoices_customer_period ON invoices(customer_id, billing_period_start);
+
+CREATE TABLE IF NOT EXISTS invoice_items (
+    id BIGSERIAL PRIMARY KEY,
+    invoice_id BIGINT NOT NULL REFERENCES invoices(id) ON DELETE CASCADE,
+    source_type VARCHAR(20) NOT NULL,
+    workspace_id BIGINT,
+    gpu_model VARCHAR(128),
+    unit_price DECIMAL(10,4) NOT NULL DEFAULT 0,
+    gpu_hours DECIMAL(14,4) NOT NULL DEFAULT 0,
+    amount DECIMAL(12,4) NOT NULL DEFAULT 0,
+    record_count INT NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_invoice_items_invoice ON invoice_items(invoice_id);
+
+COMMENT ON TABLE invoice_items IS '账单明细，按来源、工作空间、GPU 型号和单价汇总用量记录';
+
+-- 价格表：每 GPU 小时单价，按 GPU 型号匹配（型号名包含键名即匹配，取最长的键），未匹配时使用 default
+INSERT INTO system_configs (config_key, config_value, config_type, config_group, description, is_public) VALUES
+('billing_gpu_prices', '{"default": 0}', 'json', 'billing', 'GPU 单价（每 GPU 小时），如 {"default": 5, "A100": 20, "H100": 35}', false),
+('billing_currency', 'CNY', 'string', 'billing', '计费币种', true),
+('billing_sources', 'allocation', 'string', 'billing', '计费的用量来源（逗号分隔: allocation, environment, task），其他来源只计量不计费', false)
+ON CONFLICT (config_key) DO NOTHING;
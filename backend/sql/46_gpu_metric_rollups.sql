@@ -0,0 +1,33 @@
+-- GPU 监控指标：心跳上报的原始指标按机器和 GPU UUID 存储（07_monitoring.sql 已创建 gpu_metrics，按 gpus.id 关联，未使用）
+ALTER TABLE gpu_metrics ALTER COLUMN gpu_id DROP NOT NULL;
+ALTER TABLE gpu_metrics ADD COLUMN IF NOT EXISTS gpu_uuid VARCHAR(128) NOT NULL DEFAULT '';
+ALTER TABLE gpu_metrics ADD COLUMN IF NOT EXISTS gpu_index INT NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_gpu_metrics_host_uuid_time ON gpu_metrics(host_id, gpu_uuid, collected_at);
+
+COMMENT ON COLUMN gpu_metrics.gpu_uuid IS 'GPU UUID，Agent 未上报时为空，按 gpu_index 区分';
+COMMENT ON COLUMN gpu_metrics.memory_used IS '显存使用量(MB)';
+
+-- GPU 指标降采样：原始指标汇总为 1m，1m 汇总为 1h，1h 汇总为 1d，各级按保留策略清理
+CREATE TABLE IF NOT EXISTS gpu_metric_rollups (
+    id BIGSERIAL PRIMARY KEY,
+    resolution VARCHAR(4) NOT NULL,
+    host_id VARCHAR(64) NOT NULL,
+    gpu_uuid VARCHAR(128) NOT NULL DEFAULT '',
+    gpu_index INT NOT NULL DEFAULT 0,
+    bucket_start TIMESTAMP WITH TIME ZONE NOT NULL,
+    sample_count INT NOT NULL DEFAULT 0,
+    util_avg FLOAT,
+    util_max FLOAT,
+    memory_used_avg_mb FLOAT,
+    memory_usage_avg FLOAT,
+    temperature_max FLOAT,
+    power_avg FLOAT
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_gpu_metric_rollups_bucket ON gpu_metric_rollups(resolution, host_id, gpu_uuid, gpu_index, bucket_start);
+CREATE INDEX IF NOT EXISTS idx_gpu_metric_rollups_time ON gpu_metric_rollups(resolution, bucket_start);
+
+COMMENT ON TABLE gpu_metric_rollups IS 'GPU 指标降采样数据';
+COMMENT ON COLUMN gpu_metric_rollups.resolution IS '粒度: 1m, 1h, 1d（按 UTC 对齐）';
+COMMENT ON COLUMN gpu_metric_rollups.sample_count IS '汇总的原始采样数，上一级汇总时作为权重';
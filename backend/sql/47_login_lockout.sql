@@ -0,0 +1,8 @@
+-- 登录失败锁定：连续登录失败达到阈值后锁定账号，锁定时长随连续锁定次数翻倍
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS failed_login_attempts INT NOT NULL DEFAULT 0;
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS lockout_count INT NOT NULL DEFAULT 0;
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS locked_until TIMESTAMP WITH TIME ZONE;
+
+COMMENT ON COLUMN customers.failed_login_attempts IS '当前连续登录失败次数，锁定或登录成功后清零';
+COMMENT ON COLUMN customers.lockout_count IS '连续锁定次数，登录成功或管理员解锁后清零';
+COMMENT ON COLUMN customers.locked_until IS '锁定截止时间，为空或已过期表示未锁定';
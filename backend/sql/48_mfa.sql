@@ -0,0 +1,27 @@
+-- 两步验证（TOTP）：密钥使用 AES-256-GCM 加密存储，开通时生成密钥，验证通过后启用
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS mfa_enabled BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS mfa_secret TEXT NOT NULL DEFAULT '';
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS mfa_last_step BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS mfa_enabled_at TIMESTAMP WITH TIME ZONE;
+
+COMMENT ON COLUMN customers.mfa_enabled IS '是否启用两步验证';
+COMMENT ON COLUMN customers.mfa_secret IS 'TOTP 密钥（AES-256-GCM 加密），开通中或已启用时非空';
+COMMENT ON COLUMN customers.mfa_last_step IS '最近一次通过校验的 TOTP 时间步，防止验证码重放';
+
+-- 两步验证恢复码：只保存 SHA-256 哈希，每个恢复码只能使用一次
+CREATE TABLE IF NOT EXISTS mfa_recovery_codes (
+    id BIGSERIAL PRIMARY KEY,
+    customer_id BIGINT NOT NULL REFERENCES customers(id) ON DELETE CASCADE,
+    code_hash VARCHAR(64) NOT NULL UNIQUE,
+    used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_mfa_recovery_codes_customer ON mfa_recovery_codes(customer_id);
+
+COMMENT ON TABLE mfa_recovery_codes IS '两步验证恢复码，丢失验证器时代替验证码登录';
+
+-- 安全策略：为 true 时管理员必须启用两步验证，未启用的管理员登录后需先完成开通
+INSERT INTO system_configs (config_key, config_value, config_type, config_group, description, is_public) VALUES
+('security_mfa_enforce_admin', 'false', 'boolean', 'security', '强制管理员启用两步验证', false)
+ON CONFLICT (config_key) DO NOTHING;
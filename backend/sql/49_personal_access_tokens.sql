@@ -0,0 +1,20 @@
+-- 个人访问令牌：客户创建的长期凭证，用于脚本和 CLI 调用 API，按权限范围限制可访问的接口
+CREATE TABLE IF NOT EXISTS personal_access_tokens (
+    id BIGSERIAL PRIMARY KEY,
+    customer_id BIGINT NOT NULL REFERENCES customers(id) ON DELETE CASCADE,
+    name VARCHAR(64) NOT NULL,
+    token_prefix VARCHAR(16) NOT NULL,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    scopes VARCHAR(512) NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_personal_access_tokens_customer ON personal_access_tokens(customer_id);
+
+COMMENT ON TABLE personal_access_tokens IS '个人访问令牌，只保存令牌的 SHA-256 哈希，明文只在创建时返回一次';
+COMMENT ON COLUMN personal_access_tokens.token_prefix IS '令牌明文的前几位，用于在列表中识别';
+COMMENT ON COLUMN personal_access_tokens.scopes IS '权限范围，逗号分隔，格式 <资源>:read|write，write 包含 read';
+COMMENT ON COLUMN personal_access_tokens.last_used_at IS '最后使用时间（按分钟更新）';
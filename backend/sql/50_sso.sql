@@ -0,0 +1,26 @@
+-- 企业单点登录（OIDC / LDAP）：按企业编码在系统配置中保存身份源，首次登录时自动创建账号并关联外部身份
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS auth_source VARCHAR(32) NOT NULL DEFAULT 'local';
+
+COMMENT ON COLUMN customers.auth_source IS '账号来源：local 本地密码，oidc/ldap 企业单点登录（不能使用本地密码登录）';
+
+CREATE TABLE IF NOT EXISTS customer_identities (
+    id BIGSERIAL PRIMARY KEY,
+    customer_id BIGINT NOT NULL REFERENCES customers(id) ON DELETE CASCADE,
+    provider VARCHAR(128) NOT NULL,
+    subject VARCHAR(512) NOT NULL,
+    last_login_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (provider, subject)
+);
+
+CREATE INDEX IF NOT EXISTS idx_customer_identities_customer ON customer_identities(customer_id);
+
+COMMENT ON TABLE customer_identities IS '客户关联的外部身份';
+COMMENT ON COLUMN customer_identities.provider IS '身份源：<类型>:<企业编码>，如 oidc:acme';
+COMMENT ON COLUMN customer_identities.subject IS '身份源中的用户唯一标识（OIDC sub / LDAP DN）';
+
+-- 身份源配置保存在系统配置 sso_idp_<企业编码>（分组 sso，类型 json），示例：
+-- {"type":"oidc","enabled":true,"company_name":"Acme","auto_provision":true,
+--  "oidc":{"issuer":"https://idp.acme.com","client_id":"remotegpu","client_secret":"***","redirect_url":"https://gpu.example.com/sso/callback"},
+--  "group_roles":{"gpu-owners":"customer_owner"},"default_role":"customer_member",
+--  "group_workspaces":[{"group":"ml-team","workspace_id":3,"role":"member"}]}
@@ -0,0 +1,4 @@
+-- 调度器选择的机器：租约过期重新排队时清空 machine_id，任务可以被其他机器认领
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS placed BOOLEAN NOT NULL DEFAULT FALSE;
+
+COMMENT ON COLUMN tasks.placed IS 'machine_id 由调度器在认领时选择（true）还是创建时指定（false）';
@@ -0,0 +1,4 @@
+-- Agent 控制接口签名密钥：注册时由机器密钥派生并加密保存，不再使用机器密钥摘要签名
+ALTER TABLE agent_credentials ADD COLUMN IF NOT EXISTS control_key TEXT DEFAULT '';
+
+COMMENT ON COLUMN agent_credentials.control_key IS '控制接口签名密钥（HMAC-SHA256(机器密钥, "remotegpu-agent-control")），AES-256-GCM 加密；为空时需重新签发注册令牌';
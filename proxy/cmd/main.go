@@ -16,6 +16,7 @@ import (
 	"github.com/YoungBoyGod/remotegpu-proxy/internal/forwarder"
 	"github.com/YoungBoyGod/remotegpu-proxy/internal/handler"
 	"github.com/YoungBoyGod/remotegpu-proxy/internal/portpool"
+	"github.com/YoungBoyGod/remotegpu-proxy/internal/store"
 	"github.com/gin-gonic/gin"
 )
 
@@ -36,6 +37,13 @@ func main() {
 	// 初始化转发管理器
 	mgr := forwarder.NewManager(pool, httpProxy)
 
+	// 加载映射持久化存储
+	st, err := store.NewFileStore(cfg.Store.Path)
+	if err != nil {
+		log.Fatalf("加载映射存储失败: %v", err)
+	}
+	mgr.SetStore(st)
+
 	// 启动 HTTP 反向代理
 	if httpProxy != nil {
 		if err := httpProxy.Start(); err != nil {
@@ -44,6 +52,11 @@ func main() {
 		slog.Info("HTTP 反向代理已启动", "port", cfg.HTTPProxy.Port)
 	}
 
+	// 恢复持久化的映射（需在 HTTP 反向代理启动后，以便同时恢复路由）
+	if _, err := mgr.Restore(); err != nil {
+		slog.Error("恢复映射失败", "error", err)
+	}
+
 	// 启动后端通信客户端和心跳
 	var serverClient *client.ServerClient
 	var heartbeatTicker *time.Ticker
@@ -56,12 +69,23 @@ func main() {
 		})
 
 		// 注册 Proxy
-		if err := serverClient.Register(
-			cfg.Network.InnerIP,
-			cfg.Network.OuterIP,
-			cfg.PortPool.RangeStart,
-			cfg.PortPool.RangeEnd,
-		); err != nil {
+		// 对外地址优先使用外网 IP
+		host := cfg.Network.OuterIP
+		if host == "" {
+			host = cfg.Network.InnerIP
+		}
+		httpPort := 0
+		if cfg.HTTPProxy.Enabled {
+			httpPort = cfg.HTTPProxy.Port
+		}
+		if err := serverClient.Register(&client.RegisterInfo{
+			Host:       host,
+			APIPort:    cfg.Port,
+			HTTPPort:   httpPort,
+			RangeStart: cfg.PortPool.RangeStart,
+			RangeEnd:   cfg.PortPool.RangeEnd,
+			Version:    version,
+		}); err != nil {
 			slog.Error("注册 Proxy 失败", "error", err)
 		} else {
 			slog.Info("Proxy 已注册到后端")
@@ -71,13 +95,13 @@ func main() {
 		heartbeatTicker = time.NewTicker(cfg.Heartbeat.Interval)
 		go func() {
 			for range heartbeatTicker.C {
-				stats := pool.Stats()
+				stats := mgr.Stats()
 				usage := &client.PortUsage{
-					Total:     stats.Total,
-					Used:      stats.Used,
-					Available: stats.Available,
+					Total:     stats.PoolStats.Total,
+					Used:      stats.PoolStats.Used,
+					Available: stats.PoolStats.Available,
 				}
-				if err := serverClient.Heartbeat(usage); err != nil {
+				if err := serverClient.Heartbeat(stats.ActiveMappings, usage); err != nil {
 					slog.Error("心跳发送失败", "error", err)
 				} else {
 					slog.Debug("心跳已发送")
@@ -92,8 +116,11 @@ func main() {
 	r := gin.New()
 	r.Use(gin.Recovery())
 
-	mappingHandler := handler.NewMappingHandler(mgr)
-	registerRoutes(r, mappingHandler)
+	mappingHandler := handler.NewMappingHandler(mgr, cfg.Server.ProxyID)
+	if cfg.Server.Token == "" {
+		slog.Warn("未配置 server.token，管理 API 仅允许本机访问")
+	}
+	registerRoutes(r, mappingHandler, cfg.Server.Token)
 
 	port := strconv.Itoa(cfg.Port)
 	fmt.Printf("RemoteGPU Proxy v%s starting on :%s\n", version, port)
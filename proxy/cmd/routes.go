@@ -5,15 +5,19 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func registerRoutes(r *gin.Engine, h *handler.MappingHandler) {
+func registerRoutes(r *gin.Engine, h *handler.MappingHandler, token string) {
+	r.GET("/api/v1/ping", h.Ping)
+
+	// 管理接口需携带与后端共享的 Token
 	api := r.Group("/api/v1")
+	api.Use(handler.TokenAuth(token))
 	{
-		api.GET("/ping", h.Ping)
 		api.GET("/stats", h.GetStats)
 
 		// 映射管理
 		api.POST("/mappings", h.AddMapping)
 		api.GET("/mappings", h.ListMappings)
+		api.POST("/mappings/reconcile", h.Reconcile)
 		api.DELETE("/mappings/:port", h.RemoveMapping)
 		api.DELETE("/mappings/env/:id", h.RemoveByEnvID)
 	}
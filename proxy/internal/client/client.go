@@ -50,18 +50,38 @@ func (c *ServerClient) doPost(url string, body []byte) (*http.Response, error) {
 	req.Header.Set("Content-Type", "application/json")
 	if c.token != "" {
 		req.Header.Set("X-Proxy-Token", c.token)
+		// 后端 Proxy 接口与 Agent 共用 Bearer Token 认证
+		req.Header.Set("Authorization", "Bearer "+c.token)
 	}
 	return c.httpClient.Do(req)
 }
 
+// RegisterInfo Proxy 注册信息
+type RegisterInfo struct {
+	Name       string
+	Host       string // 对外访问地址
+	APIPort    int
+	HTTPPort   int
+	RangeStart int
+	RangeEnd   int
+	Version    string
+}
+
 // Register 向后端注册 Proxy
-func (c *ServerClient) Register(innerIP, outerIP string, portStart, portEnd int) error {
+func (c *ServerClient) Register(info *RegisterInfo) error {
+	name := info.Name
+	if name == "" {
+		name = c.proxyID
+	}
 	reqBody := map[string]interface{}{
-		"proxy_id":    c.proxyID,
-		"inner_ip":    innerIP,
-		"outer_ip":    outerIP,
-		"port_start":  portStart,
-		"port_end":    portEnd,
+		"id":          c.proxyID,
+		"name":        name,
+		"host":        info.Host,
+		"api_port":    info.APIPort,
+		"http_port":   info.HTTPPort,
+		"range_start": info.RangeStart,
+		"range_end":   info.RangeEnd,
+		"version":     info.Version,
 	}
 
 	body, err := json.Marshal(reqBody)
@@ -97,12 +117,14 @@ type PortUsage struct {
 	Available int `json:"available"`
 }
 
-// Heartbeat 上报心跳，携带端口使用情况
-func (c *ServerClient) Heartbeat(usage *PortUsage) error {
+// Heartbeat 上报心跳，携带活跃映射数和端口使用情况
+func (c *ServerClient) Heartbeat(activeMappings int, usage *PortUsage) error {
 	reqBody := map[string]interface{}{
-		"proxy_id": c.proxyID,
+		"id":              c.proxyID,
+		"active_mappings": activeMappings,
 	}
 	if usage != nil {
+		reqBody["used_ports"] = usage.Used
 		reqBody["port_usage"] = usage
 	}
 
@@ -17,6 +17,7 @@ type Config struct {
 	Heartbeat HeartbeatConfig `yaml:"heartbeat"`
 	HTTPProxy HTTPProxyConfig `yaml:"http_proxy"`
 	Network   NetworkConfig   `yaml:"network"`
+	Store     StoreConfig     `yaml:"store"`
 }
 
 // ServerConfig 后端服务器连接配置
@@ -50,6 +51,11 @@ type NetworkConfig struct {
 	OuterIP string `yaml:"outer_ip"`
 }
 
+// StoreConfig 映射持久化配置
+type StoreConfig struct {
+	Path string `yaml:"path"`
+}
+
 // DefaultConfig 返回默认配置
 func DefaultConfig() *Config {
 	return &Config{
@@ -68,6 +74,9 @@ func DefaultConfig() *Config {
 			Enabled: false,
 			Port:    9091,
 		},
+		Store: StoreConfig{
+			Path: "/var/lib/remotegpu-proxy/mappings.json",
+		},
 	}
 }
 
@@ -120,6 +129,9 @@ func applyEnv(cfg *Config) {
 	if v := os.Getenv("OUTER_IP"); v != "" {
 		cfg.Network.OuterIP = v
 	}
+	if v := os.Getenv("PROXY_STORE_PATH"); v != "" {
+		cfg.Store.Path = v
+	}
 }
 
 // ServerConfigured 检查 Server 配置是否完整
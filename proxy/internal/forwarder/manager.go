@@ -36,12 +36,23 @@ type mappingEntry struct {
 	forwarder *TCPForwarder
 }
 
+// Store 映射持久化接口
+type Store interface {
+	Save(m *models.PortMapping) error
+	Delete(externalPort int) error
+	List() ([]models.PortMapping, error)
+}
+
 // Manager 转发管理器，管理所有 TCP 转发器和 HTTP 代理路由
 type Manager struct {
+	// opMu 串行化所有映射变更（端口池、转发器和持久化存储），
+	// 避免对账在比较与增删之间被并发的添加/移除穿插，或持久化记录被乱序覆盖
+	opMu      sync.Mutex
 	mu        sync.RWMutex
 	pool      *portpool.Pool
 	mappings  map[int]*mappingEntry // externalPort -> entry
 	httpProxy *HTTPProxy
+	store     Store
 }
 
 // NewManager 创建转发管理器
@@ -53,57 +64,105 @@ func NewManager(pool *portpool.Pool, httpProxy *HTTPProxy) *Manager {
 	}
 }
 
+// SetStore 设置映射持久化存储
+func (m *Manager) SetStore(st Store) {
+	m.store = st
+}
+
 // AddMapping 添加映射：分配端口 + 启动转发
 func (m *Manager) AddMapping(req *models.MappingRequest) (*MappingInfo, error) {
+	m.opMu.Lock()
+	defer m.opMu.Unlock()
+
 	// 分配端口
 	port, err := m.pool.Allocate(req.EnvID)
 	if err != nil {
 		return nil, fmt.Errorf("分配端口失败: %w", err)
 	}
 
-	protocol := req.Protocol
-	if protocol == "" {
-		protocol = "tcp"
+	info := MappingInfo{
+		ID:           uuid.New().String(),
+		EnvID:        req.EnvID,
+		ServiceType:  req.ServiceType,
+		ExternalPort: port,
+		TargetHost:   req.TargetHost,
+		TargetPort:   req.TargetPort,
+		Protocol:     req.Protocol,
+		CreatedAt:    time.Now(),
 	}
-
-	// 启动 TCP 转发器
-	fwd := NewTCPForwarder(port, req.TargetHost, req.TargetPort)
-	if err := fwd.Start(); err != nil {
-		m.pool.Release(port)
-		return nil, fmt.Errorf("启动转发器失败: %w", err)
+	if err := m.activateAndPersist(&info); err != nil {
+		return nil, err
 	}
+	return &info, nil
+}
 
-	// 如果启用了 HTTP 代理，同时添加 HTTP 路由
-	if m.httpProxy != nil {
-		m.httpProxy.AddRoute(port, req.TargetHost, req.TargetPort)
+// addMappingAtPort 使用指定外部端口添加映射（对账时使用），调用方需持有 opMu
+func (m *Manager) addMappingAtPort(req *models.ReconcileMapping) error {
+	if err := m.pool.AllocateSpecific(req.ExternalPort, req.EnvID); err != nil {
+		return fmt.Errorf("分配端口失败: %w", err)
 	}
 
 	info := MappingInfo{
 		ID:           uuid.New().String(),
 		EnvID:        req.EnvID,
 		ServiceType:  req.ServiceType,
-		ExternalPort: port,
+		ExternalPort: req.ExternalPort,
 		TargetHost:   req.TargetHost,
 		TargetPort:   req.TargetPort,
-		Protocol:     protocol,
+		Protocol:     req.Protocol,
 		CreatedAt:    time.Now(),
 	}
+	return m.activateAndPersist(&info)
+}
+
+// activateAndPersist 启动转发并写入持久化存储，失败时回滚并释放端口
+func (m *Manager) activateAndPersist(info *MappingInfo) error {
+	if err := m.activate(info); err != nil {
+		m.pool.Release(info.ExternalPort)
+		return err
+	}
+
+	if m.store != nil {
+		if err := m.store.Save(toRecord(info)); err != nil {
+			m.deactivate(info.ExternalPort)
+			return fmt.Errorf("持久化映射失败: %w", err)
+		}
+	}
+
+	slog.Info("映射已添加", "id", info.ID, "env", info.EnvID, "port", info.ExternalPort, "target", fmt.Sprintf("%s:%d", info.TargetHost, info.TargetPort))
+	return nil
+}
+
+// activate 启动转发器并登记映射，端口需已在端口池中分配
+func (m *Manager) activate(info *MappingInfo) error {
+	if info.Protocol == "" {
+		info.Protocol = "tcp"
+	}
+
+	// 启动 TCP 转发器
+	fwd := NewTCPForwarder(info.ExternalPort, info.TargetHost, info.TargetPort)
+	if err := fwd.Start(); err != nil {
+		return fmt.Errorf("启动转发器失败: %w", err)
+	}
+
+	// 如果启用了 HTTP 代理，同时添加 HTTP 路由
+	if m.httpProxy != nil {
+		m.httpProxy.AddRoute(info.ExternalPort, info.TargetHost, info.TargetPort)
+	}
 
 	m.mu.Lock()
-	m.mappings[port] = &mappingEntry{info: info, forwarder: fwd}
+	m.mappings[info.ExternalPort] = &mappingEntry{info: *info, forwarder: fwd}
 	m.mu.Unlock()
-
-	slog.Info("映射已添加", "id", info.ID, "env", req.EnvID, "port", port, "target", fmt.Sprintf("%s:%d", req.TargetHost, req.TargetPort))
-	return &info, nil
+	return nil
 }
 
-// RemoveMapping 移除指定端口的映射：停止转发 + 释放端口
-func (m *Manager) RemoveMapping(externalPort int) error {
+// deactivate 停止转发并释放端口，不修改持久化存储
+func (m *Manager) deactivate(externalPort int) (*mappingEntry, bool) {
 	m.mu.Lock()
 	entry, ok := m.mappings[externalPort]
 	if !ok {
 		m.mu.Unlock()
-		return fmt.Errorf("端口 %d 无映射记录", externalPort)
+		return nil, false
 	}
 	delete(m.mappings, externalPort)
 	m.mu.Unlock()
@@ -114,6 +173,151 @@ func (m *Manager) RemoveMapping(externalPort int) error {
 	if m.httpProxy != nil {
 		m.httpProxy.RemoveRoute(externalPort)
 	}
+	return entry, true
+}
+
+// Restore 从持久化存储恢复映射，返回恢复成功的数量
+// 无法恢复的记录会从存储中删除，由后端对账重新下发
+func (m *Manager) Restore() (int, error) {
+	if m.store == nil {
+		return 0, nil
+	}
+	m.opMu.Lock()
+	defer m.opMu.Unlock()
+
+	records, err := m.store.List()
+	if err != nil {
+		return 0, err
+	}
+
+	restored := 0
+	for i := range records {
+		rec := &records[i]
+		if err := m.pool.AllocateSpecific(rec.ExternalPort, rec.EnvID); err != nil {
+			slog.Error("恢复映射失败", "port", rec.ExternalPort, "env", rec.EnvID, "error", err)
+			m.store.Delete(rec.ExternalPort)
+			continue
+		}
+		info := fromRecord(rec)
+		if err := m.activate(&info); err != nil {
+			m.pool.Release(rec.ExternalPort)
+			slog.Error("恢复映射失败", "port", rec.ExternalPort, "env", rec.EnvID, "error", err)
+			m.store.Delete(rec.ExternalPort)
+			continue
+		}
+		restored++
+	}
+	slog.Info("映射已从存储恢复", "restored", restored, "total", len(records))
+	return restored, nil
+}
+
+// Reconcile 按后端下发的期望映射集合收敛：移除多余映射，补齐缺失映射
+// 整个对账持有 opMu，比较结果在增删期间不会被其他请求改变
+func (m *Manager) Reconcile(desired []models.ReconcileMapping) *models.ReconcileResult {
+	m.opMu.Lock()
+	defer m.opMu.Unlock()
+
+	result := &models.ReconcileResult{}
+
+	want := make(map[int]*models.ReconcileMapping, len(desired))
+	for i := range desired {
+		d := &desired[i]
+		if d.Protocol == "" {
+			d.Protocol = "tcp"
+		}
+		want[d.ExternalPort] = d
+	}
+
+	// 移除期望集合中不存在或目标已变化的映射
+	m.mu.RLock()
+	var stale []int
+	for port, entry := range m.mappings {
+		d, ok := want[port]
+		if ok && d.EnvID == entry.info.EnvID && d.TargetHost == entry.info.TargetHost && d.TargetPort == entry.info.TargetPort {
+			continue
+		}
+		stale = append(stale, port)
+	}
+	m.mu.RUnlock()
+
+	for _, port := range stale {
+		if err := m.removeMapping(port); err != nil {
+			slog.Error("对账移除映射失败", "port", port, "error", err)
+			continue
+		}
+		result.Removed++
+	}
+
+	// 补齐缺失的映射
+	for port, d := range want {
+		m.mu.RLock()
+		_, exists := m.mappings[port]
+		m.mu.RUnlock()
+		if exists {
+			result.Unchanged++
+			continue
+		}
+		if err := m.addMappingAtPort(d); err != nil {
+			result.Failed = append(result.Failed, models.ReconcileFailure{
+				ExternalPort: port,
+				EnvID:        d.EnvID,
+				Error:        err.Error(),
+			})
+			continue
+		}
+		result.Added++
+	}
+
+	slog.Info("映射对账完成", "added", result.Added, "removed", result.Removed, "unchanged", result.Unchanged, "failed", len(result.Failed))
+	return result
+}
+
+func toRecord(info *MappingInfo) *models.PortMapping {
+	return &models.PortMapping{
+		ID:           info.ID,
+		EnvID:        info.EnvID,
+		ServiceType:  info.ServiceType,
+		ExternalPort: info.ExternalPort,
+		TargetHost:   info.TargetHost,
+		TargetPort:   info.TargetPort,
+		Protocol:     info.Protocol,
+		Status:       "active",
+		CreatedAt:    info.CreatedAt,
+	}
+}
+
+func fromRecord(rec *models.PortMapping) MappingInfo {
+	return MappingInfo{
+		ID:           rec.ID,
+		EnvID:        rec.EnvID,
+		ServiceType:  rec.ServiceType,
+		ExternalPort: rec.ExternalPort,
+		TargetHost:   rec.TargetHost,
+		TargetPort:   rec.TargetPort,
+		Protocol:     rec.Protocol,
+		CreatedAt:    rec.CreatedAt,
+	}
+}
+
+// RemoveMapping 移除指定端口的映射：停止转发 + 释放端口 + 删除持久化记录
+func (m *Manager) RemoveMapping(externalPort int) error {
+	m.opMu.Lock()
+	defer m.opMu.Unlock()
+	return m.removeMapping(externalPort)
+}
+
+// removeMapping 调用方需持有 opMu
+func (m *Manager) removeMapping(externalPort int) error {
+	entry, ok := m.deactivate(externalPort)
+	if !ok {
+		return fmt.Errorf("端口 %d 无映射记录", externalPort)
+	}
+
+	if m.store != nil {
+		if err := m.store.Delete(externalPort); err != nil {
+			slog.Error("删除映射持久化记录失败", "port", externalPort, "error", err)
+		}
+	}
 
 	slog.Info("映射已移除", "port", externalPort, "env", entry.info.EnvID)
 	return nil
@@ -121,6 +325,9 @@ func (m *Manager) RemoveMapping(externalPort int) error {
 
 // RemoveByEnvID 移除指定环境的所有映射
 func (m *Manager) RemoveByEnvID(envID string) error {
+	m.opMu.Lock()
+	defer m.opMu.Unlock()
+
 	m.mu.RLock()
 	var ports []int
 	for port, entry := range m.mappings {
@@ -131,7 +338,7 @@ func (m *Manager) RemoveByEnvID(envID string) error {
 	m.mu.RUnlock()
 
 	for _, port := range ports {
-		if err := m.RemoveMapping(port); err != nil {
+		if err := m.removeMapping(port); err != nil {
 			slog.Error("移除映射失败", "port", port, "error", err)
 		}
 	}
@@ -166,8 +373,11 @@ func (m *Manager) Stats() ManagerStats {
 	}
 }
 
-// StopAll 停止所有转发器
+// StopAll 停止所有转发器（保留持久化记录，重启后恢复）
 func (m *Manager) StopAll() {
+	m.opMu.Lock()
+	defer m.opMu.Unlock()
+
 	m.mu.Lock()
 	entries := make([]*mappingEntry, 0, len(m.mappings))
 	for _, entry := range m.mappings {
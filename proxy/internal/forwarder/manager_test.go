@@ -0,0 +1,176 @@
+package forwarder
+
+import (
+	"fmt"
+	"net"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/YoungBoyGod/remotegpu-proxy/internal/models"
+	"github.com/YoungBoyGod/remotegpu-proxy/internal/portpool"
+	"github.com/YoungBoyGod/remotegpu-proxy/internal/store"
+)
+
+// freePortRange 返回一段当前都未被占用的连续端口
+func freePortRange(t *testing.T, n int) int {
+	t.Helper()
+	for base := 41000; base < 60000; base += n {
+		ok := true
+		for p := base; p < base+n; p++ {
+			ln, err := net.Listen("tcp", fmt.Sprintf(":%d", p))
+			if err != nil {
+				ok = false
+				break
+			}
+			ln.Close()
+		}
+		if ok {
+			return base
+		}
+	}
+	t.Fatal("no free port range")
+	return 0
+}
+
+func newTestManager(t *testing.T, start, end int, path string) (*Manager, *store.FileStore) {
+	t.Helper()
+	st, err := store.NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewManager(portpool.NewPool(start, end), nil)
+	m.SetStore(st)
+	t.Cleanup(m.StopAll)
+	return m, st
+}
+
+func storedPorts(t *testing.T, st *store.FileStore) map[int]string {
+	t.Helper()
+	list, err := st.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ports := make(map[int]string, len(list))
+	for _, rec := range list {
+		ports[rec.ExternalPort] = rec.EnvID
+	}
+	return ports
+}
+
+func TestRestore(t *testing.T) {
+	base := freePortRange(t, 4)
+	path := filepath.Join(t.TempDir(), "mappings.json")
+
+	m, _ := newTestManager(t, base, base+3, path)
+	for _, env := range []string{"env-1", "env-2"} {
+		if _, err := m.AddMapping(&models.MappingRequest{EnvID: env, ServiceType: "ssh", TargetHost: "127.0.0.1", TargetPort: 22}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m.StopAll()
+
+	// 端口范围缩小后，超出范围的记录无法恢复并从存储中删除
+	restored, st := newTestManager(t, base, base, path)
+	n, err := restored.Restore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 || restored.Stats().ActiveMappings != 1 {
+		t.Fatalf("restored %d mappings, active %d", n, restored.Stats().ActiveMappings)
+	}
+	if got := storedPorts(t, st); len(got) != 1 || got[base] != "env-1" {
+		t.Errorf("store after restore: %v", got)
+	}
+	list := restored.ListMappings()
+	if list[0].EnvID != "env-1" || list[0].TargetPort != 22 || list[0].Protocol != "tcp" {
+		t.Errorf("unexpected restored mapping: %+v", list[0])
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	base := freePortRange(t, 4)
+	m, st := newTestManager(t, base, base+3, filepath.Join(t.TempDir(), "mappings.json"))
+
+	keep, _ := m.AddMapping(&models.MappingRequest{EnvID: "env-1", ServiceType: "ssh", TargetHost: "127.0.0.1", TargetPort: 22})
+	moved, _ := m.AddMapping(&models.MappingRequest{EnvID: "env-2", ServiceType: "ssh", TargetHost: "127.0.0.1", TargetPort: 22})
+	gone, _ := m.AddMapping(&models.MappingRequest{EnvID: "env-3", ServiceType: "ssh", TargetHost: "127.0.0.1", TargetPort: 22})
+	if keep == nil || moved == nil || gone == nil {
+		t.Fatal("add mapping failed")
+	}
+
+	result := m.Reconcile([]models.ReconcileMapping{
+		{EnvID: "env-1", ExternalPort: keep.ExternalPort, TargetHost: "127.0.0.1", TargetPort: 22},
+		// 目标变化的映射重建
+		{EnvID: "env-2", ExternalPort: moved.ExternalPort, TargetHost: "127.0.0.1", TargetPort: 2222},
+		{EnvID: "env-4", ExternalPort: base + 3, TargetHost: "127.0.0.1", TargetPort: 8888},
+		// 超出端口池范围的映射无法恢复
+		{EnvID: "env-5", ExternalPort: base + 100, TargetHost: "127.0.0.1", TargetPort: 22},
+	})
+	if result.Unchanged != 1 || result.Removed != 2 || result.Added != 2 || len(result.Failed) != 1 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if result.Failed[0].EnvID != "env-5" {
+		t.Errorf("unexpected failure: %+v", result.Failed[0])
+	}
+
+	want := map[int]string{keep.ExternalPort: "env-1", moved.ExternalPort: "env-2", base + 3: "env-4"}
+	if got := storedPorts(t, st); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("store = %v, want %v", got, want)
+	}
+	for _, info := range m.ListMappings() {
+		if want[info.ExternalPort] != info.EnvID {
+			t.Errorf("unexpected active mapping: %+v", info)
+		}
+		if info.EnvID == "env-2" && info.TargetPort != 2222 {
+			t.Errorf("changed target not applied: %+v", info)
+		}
+	}
+
+	// 空的期望集合移除全部映射
+	if result := m.Reconcile(nil); result.Removed != 3 || m.Stats().ActiveMappings != 0 || len(storedPorts(t, st)) != 0 {
+		t.Errorf("unexpected result for empty reconcile: %+v", result)
+	}
+}
+
+// 并发的对账与增删结束后，活跃映射、端口池和持久化记录保持一致
+func TestReconcileConcurrent(t *testing.T) {
+	base := freePortRange(t, 8)
+	m, st := newTestManager(t, base, base+7, filepath.Join(t.TempDir(), "mappings.json"))
+	desired := []models.ReconcileMapping{
+		{EnvID: "env-1", ExternalPort: base, TargetHost: "127.0.0.1", TargetPort: 22},
+		{EnvID: "env-1", ExternalPort: base + 1, TargetHost: "127.0.0.1", TargetPort: 8888},
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			m.Reconcile(append([]models.ReconcileMapping(nil), desired...))
+		}()
+		go func(i int) {
+			defer wg.Done()
+			m.AddMapping(&models.MappingRequest{EnvID: fmt.Sprintf("env-x%d", i), ServiceType: "ssh", TargetHost: "127.0.0.1", TargetPort: 22})
+		}(i)
+		go func() {
+			defer wg.Done()
+			m.RemoveByEnvID("env-1")
+		}()
+	}
+	wg.Wait()
+
+	stored := storedPorts(t, st)
+	active := m.ListMappings()
+	if len(stored) != len(active) {
+		t.Fatalf("store has %d records, %d active mappings", len(stored), len(active))
+	}
+	for _, info := range active {
+		if stored[info.ExternalPort] != info.EnvID {
+			t.Errorf("mapping %d (%s) not persisted as active", info.ExternalPort, info.EnvID)
+		}
+	}
+	if used := m.pool.Stats().Used; used != len(active) {
+		t.Errorf("pool has %d used ports, %d active mappings", used, len(active))
+	}
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/YoungBoyGod/remotegpu-proxy/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// TokenAuth 管理 API 认证：校验后端携带的共享 Token（与 server.token 一致）
+// 未配置 Token 时仅允许本机访问
+func TokenAuth(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			if !isLoopback(c.Request.RemoteAddr) {
+				abortUnauthorized(c, "未配置 Token，管理 API 仅允许本机访问")
+				return
+			}
+			c.Next()
+			return
+		}
+
+		got := tokenFromRequest(c)
+		if got == "" || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			slog.Warn("拒绝未认证的管理请求", "path", c.Request.URL.Path, "remote", c.Request.RemoteAddr)
+			abortUnauthorized(c, "认证失败")
+			return
+		}
+		c.Next()
+	}
+}
+
+// tokenFromRequest 同时支持 X-Proxy-Token 和 Authorization: Bearer 两种方式
+func tokenFromRequest(c *gin.Context) string {
+	if token := c.GetHeader("X-Proxy-Token"); token != "" {
+		return token
+	}
+	if auth := c.GetHeader("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimPrefix(auth, "Bearer ")
+	}
+	return ""
+}
+
+func abortUnauthorized(c *gin.Context, msg string) {
+	c.AbortWithStatusJSON(http.StatusUnauthorized, models.MappingResponse{Code: 401, Msg: msg})
+}
+
+// isLoopback 按 TCP 连接地址判断是否为本机请求（不信任 X-Forwarded-For）
+func isLoopback(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
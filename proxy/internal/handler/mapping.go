@@ -12,11 +12,12 @@ import (
 // MappingHandler 映射管理 API Handler
 type MappingHandler struct {
 	manager *forwarder.Manager
+	proxyID string
 }
 
 // NewMappingHandler 创建 Handler
-func NewMappingHandler(manager *forwarder.Manager) *MappingHandler {
-	return &MappingHandler{manager: manager}
+func NewMappingHandler(manager *forwarder.Manager, proxyID string) *MappingHandler {
+	return &MappingHandler{manager: manager, proxyID: proxyID}
 }
 
 // respond 统一响应
@@ -77,6 +78,24 @@ func (h *MappingHandler) RemoveByEnvID(c *gin.Context) {
 	respond(c, 0, "success", nil)
 }
 
+// Reconcile 映射对账 POST /api/v1/mappings/reconcile
+// 后端下发该 Proxy 的全量期望映射，Proxy 增删转发以收敛
+func (h *MappingHandler) Reconcile(c *gin.Context) {
+	var req models.ReconcileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respond(c, 1, "参数错误: "+err.Error(), nil)
+		return
+	}
+	// 对账会删除列表外的全部映射，必须确认请求是发给本 Proxy 的
+	if req.ProxyID == "" || req.ProxyID != h.proxyID {
+		respond(c, 1, "proxy_id 不匹配", nil)
+		return
+	}
+
+	result := h.manager.Reconcile(req.Mappings)
+	respond(c, 0, "success", result)
+}
+
 // ListMappings 列出所有映射 GET /api/v1/mappings
 func (h *MappingHandler) ListMappings(c *gin.Context) {
 	list := h.manager.ListMappings()
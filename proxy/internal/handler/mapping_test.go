@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/YoungBoyGod/remotegpu-proxy/internal/forwarder"
+	"github.com/YoungBoyGod/remotegpu-proxy/internal/models"
+	"github.com/YoungBoyGod/remotegpu-proxy/internal/portpool"
+	"github.com/gin-gonic/gin"
+)
+
+func newTestRouter(token string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	h := NewMappingHandler(forwarder.NewManager(portpool.NewPool(20000, 20010), nil), "proxy-1")
+	r := gin.New()
+	api := r.Group("/api/v1", TokenAuth(token))
+	api.POST("/mappings/reconcile", h.Reconcile)
+	return r
+}
+
+func doReconcile(r *gin.Engine, remote, token, body string) (int, models.MappingResponse) {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/mappings/reconcile", strings.NewReader(body))
+	req.RemoteAddr = remote
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var resp models.MappingResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	return w.Code, resp
+}
+
+func TestReconcileRequiresToken(t *testing.T) {
+	r := newTestRouter("secret")
+	body := `{"proxy_id":"proxy-1","mappings":[]}`
+
+	if status, _ := doReconcile(r, "10.0.0.2:1234", "", body); status != http.StatusUnauthorized {
+		t.Errorf("no token: status = %d, want 401", status)
+	}
+	if status, _ := doReconcile(r, "127.0.0.1:1234", "wrong", body); status != http.StatusUnauthorized {
+		t.Errorf("wrong token: status = %d, want 401", status)
+	}
+	if status, resp := doReconcile(r, "10.0.0.2:1234", "secret", body); status != http.StatusOK || resp.Code != 0 {
+		t.Errorf("valid token: status = %d code = %d msg = %s", status, resp.Code, resp.Msg)
+	}
+}
+
+func TestReconcileWithoutTokenOnlyLoopback(t *testing.T) {
+	r := newTestRouter("")
+	body := `{"proxy_id":"proxy-1","mappings":[]}`
+
+	if status, _ := doReconcile(r, "10.0.0.2:1234", "", body); status != http.StatusUnauthorized {
+		t.Errorf("remote request: status = %d, want 401", status)
+	}
+	if _, resp := doReconcile(r, "127.0.0.1:1234", "", body); resp.Code != 0 {
+		t.Errorf("loopback request rejected: %s", resp.Msg)
+	}
+}
+
+func TestReconcileRejectsProxyID(t *testing.T) {
+	r := newTestRouter("secret")
+	for _, body := range []string{
+		`{"mappings":[]}`,
+		`{"proxy_id":"","mappings":[]}`,
+		`{"proxy_id":"proxy-2","mappings":[]}`,
+	} {
+		if _, resp := doReconcile(r, "10.0.0.2:1234", "secret", body); resp.Code == 0 {
+			t.Errorf("%s: expected rejection", body)
+		}
+	}
+}
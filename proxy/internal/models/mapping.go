@@ -31,3 +31,34 @@ type MappingResponse struct {
 	Msg  string      `json:"msg"`
 	Data interface{} `json:"data,omitempty"`
 }
+
+// ReconcileMapping 对账时后端期望存在的映射
+type ReconcileMapping struct {
+	EnvID        string `json:"env_id" binding:"required"`
+	ServiceType  string `json:"service_type"`
+	ExternalPort int    `json:"external_port" binding:"required"`
+	TargetHost   string `json:"target_host" binding:"required"`
+	TargetPort   int    `json:"target_port" binding:"required"`
+	Protocol     string `json:"protocol"`
+}
+
+// ReconcileRequest 对账请求：后端下发该 Proxy 的全量期望映射
+type ReconcileRequest struct {
+	ProxyID  string             `json:"proxy_id" binding:"required"`
+	Mappings []ReconcileMapping `json:"mappings"`
+}
+
+// ReconcileFailure 对账失败的映射
+type ReconcileFailure struct {
+	ExternalPort int    `json:"external_port"`
+	EnvID        string `json:"env_id"`
+	Error        string `json:"error"`
+}
+
+// ReconcileResult 对账结果
+type ReconcileResult struct {
+	Added     int                `json:"added"`
+	Removed   int                `json:"removed"`
+	Unchanged int                `json:"unchanged"`
+	Failed    []ReconcileFailure `json:"failed,omitempty"`
+}
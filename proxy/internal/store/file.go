@@ -0,0 +1,134 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/YoungBoyGod/remotegpu-proxy/internal/models"
+)
+
+// FileStore 基于 JSON 文件的映射持久化存储
+// 每次变更整体写入临时文件后 rename，保证文件不会被写坏
+type FileStore struct {
+	mu       sync.Mutex
+	path     string
+	mappings map[int]models.PortMapping // externalPort -> mapping
+}
+
+// NewFileStore 创建文件存储，文件不存在时视为空
+func NewFileStore(path string) (*FileStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("create store dir: %w", err)
+	}
+
+	s := &FileStore{
+		path:     path,
+		mappings: make(map[int]models.PortMapping),
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, fmt.Errorf("read store: %w", err)
+	}
+	if len(data) == 0 {
+		return s, nil
+	}
+
+	var list []models.PortMapping
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("decode store: %w", err)
+	}
+	for _, m := range list {
+		s.mappings[m.ExternalPort] = m
+	}
+	return s, nil
+}
+
+// Save 保存映射（按外部端口覆盖）
+func (s *FileStore) Save(m *models.PortMapping) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prev, existed := s.mappings[m.ExternalPort]
+	s.mappings[m.ExternalPort] = *m
+	if err := s.flush(); err != nil {
+		if existed {
+			s.mappings[m.ExternalPort] = prev
+		} else {
+			delete(s.mappings, m.ExternalPort)
+		}
+		return err
+	}
+	return nil
+}
+
+// Delete 删除指定外部端口的映射
+func (s *FileStore) Delete(externalPort int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prev, ok := s.mappings[externalPort]
+	if !ok {
+		return nil
+	}
+	delete(s.mappings, externalPort)
+	if err := s.flush(); err != nil {
+		s.mappings[externalPort] = prev
+		return err
+	}
+	return nil
+}
+
+// List 返回所有映射，按外部端口升序
+func (s *FileStore) List() ([]models.PortMapping, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sorted(), nil
+}
+
+func (s *FileStore) sorted() []models.PortMapping {
+	list := make([]models.PortMapping, 0, len(s.mappings))
+	for _, m := range s.mappings {
+		list = append(list, m)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ExternalPort < list[j].ExternalPort })
+	return list
+}
+
+// flush 原子写入文件，调用方需持有锁
+func (s *FileStore) flush() error {
+	data, err := json.MarshalIndent(s.sorted(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode store: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".mappings-*.tmp")
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName)
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write temp file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("sync temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close temp file: %w", err)
+	}
+	if err := os.Rename(tmpName, s.path); err != nil {
+		return fmt.Errorf("rename store file: %w", err)
+	}
+	return nil
+}
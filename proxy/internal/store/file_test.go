@@ -0,0 +1,101 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/YoungBoyGod/remotegpu-proxy/internal/models"
+)
+
+func TestFileStorePersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "mappings.json")
+	s, err := NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list, _ := s.List(); len(list) != 0 {
+		t.Fatalf("new store not empty: %v", list)
+	}
+
+	for _, port := range []int{20002, 20000, 20001} {
+		if err := s.Save(&models.PortMapping{ID: "m", EnvID: "env-1", ExternalPort: port, TargetHost: "10.0.0.1", TargetPort: 22}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 同端口覆盖
+	if err := s.Save(&models.PortMapping{ID: "m2", EnvID: "env-2", ExternalPort: 20001, TargetHost: "10.0.0.2", TargetPort: 8888}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete(20002); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete(29999); err != nil {
+		t.Errorf("delete missing port: %v", err)
+	}
+
+	reopened, err := NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, _ := reopened.List()
+	if len(list) != 2 || list[0].ExternalPort != 20000 || list[1].ExternalPort != 20001 {
+		t.Fatalf("unexpected mappings after reload: %+v", list)
+	}
+	if list[1].EnvID != "env-2" || list[1].TargetPort != 8888 {
+		t.Errorf("overwritten mapping not persisted: %+v", list[1])
+	}
+
+	// 临时文件不应残留
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("unexpected files in store dir: %v", entries)
+	}
+}
+
+func TestFileStoreCorrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mappings.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewFileStore(path); err == nil {
+		t.Fatal("expected error for corrupt store")
+	}
+
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewFileStore(path); err != nil {
+		t.Errorf("empty file: %v", err)
+	}
+}
+
+func TestFileStoreSaveRollback(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "mappings.json")
+	s, err := NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Save(&models.PortMapping{EnvID: "env-1", ExternalPort: 20000}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 目录不可写时写入失败，内存状态回滚
+	if os.Geteuid() == 0 {
+		t.Skip("root ignores directory permissions")
+	}
+	if err := os.Chmod(dir, 0555); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chmod(dir, 0755)
+	if err := s.Save(&models.PortMapping{EnvID: "env-2", ExternalPort: 20001}); err == nil {
+		t.Fatal("expected save error")
+	}
+	if err := s.Delete(20000); err == nil {
+		t.Fatal("expected delete error")
+	}
+	if list, _ := s.List(); len(list) != 1 || list[0].ExternalPort != 20000 {
+		t.Errorf("state not rolled back: %+v", list)
+	}
+}